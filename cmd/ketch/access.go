@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+)
+
+// requiredAccessAnnotation is an annotation of a command with the access it requires,
+// a comma-separated list of "verb:group:resource:namespace".
+const requiredAccessAnnotation = "theketch.io/required-access"
+
+// resourceAccess is a verb on resources of a group, in a namespace for namespaced resources.
+type resourceAccess struct {
+	verb      string
+	group     string
+	resource  string
+	namespace string
+}
+
+func appsAccess(verb string) resourceAccess {
+	return resourceAccess{verb: verb, group: ketchv1.Group, resource: "apps"}
+}
+
+func jobsAccess(verb string) resourceAccess {
+	return resourceAccess{verb: verb, group: ketchv1.Group, resource: "jobs"}
+}
+
+func ingressConfigmapAccess(verb string) resourceAccess {
+	return resourceAccess{verb: verb, resource: "configmaps", namespace: ketchv1.IngressConfigmapNamespace}
+}
+
+// resourceName returns the resource the way kubectl names it, e.g. "apps.theketch.io".
+func (a resourceAccess) resourceName() string {
+	if a.group == "" {
+		return a.resource
+	}
+	return a.resource + "." + a.group
+}
+
+func (a resourceAccess) String() string {
+	return strings.Join([]string{a.verb, a.group, a.resource, a.namespace}, ":")
+}
+
+// requireAccess annotates the command with the access it requires,
+// it's checked before the command runs so users without it get an actionable error instead of a partial change.
+func requireAccess(cmd *cobra.Command, accesses ...resourceAccess) *cobra.Command {
+	values := make([]string, 0, len(accesses))
+	for _, access := range accesses {
+		values = append(values, access.String())
+	}
+	if cmd.Annotations == nil {
+		cmd.Annotations = map[string]string{}
+	}
+	cmd.Annotations[requiredAccessAnnotation] = strings.Join(values, ",")
+	return cmd
+}
+
+// commandAccess returns the access required by the command.
+func commandAccess(cmd *cobra.Command) []resourceAccess {
+	value := cmd.Annotations[requiredAccessAnnotation]
+	if value == "" {
+		return nil
+	}
+	var accesses []resourceAccess
+	for _, item := range strings.Split(value, ",") {
+		parts := strings.SplitN(item, ":", 4)
+		if len(parts) != 4 {
+			continue
+		}
+		accesses = append(accesses, resourceAccess{verb: parts[0], group: parts[1], resource: parts[2], namespace: parts[3]})
+	}
+	return accesses
+}
+
+// checkAccess asks the cluster with SelfSubjectAccessReviews whether the user has the access required by the command.
+func checkAccess(ctx context.Context, cfg config, cmd *cobra.Command) error {
+	accesses := commandAccess(cmd)
+	if len(accesses) == 0 {
+		return nil
+	}
+	kubeClient := cfg.KubernetesClient()
+	for _, access := range accesses {
+		review := &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: &authorizationv1.ResourceAttributes{
+					Namespace: access.namespace,
+					Verb:      access.verb,
+					Group:     access.group,
+					Resource:  access.resource,
+				},
+			},
+		}
+		result, err := kubeClient.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
+		if err != nil {
+			// clusters that can't review access report missing permissions when the command runs.
+			return nil
+		}
+		if !result.Status.Allowed {
+			return newPermissionError(access)
+		}
+	}
+	return nil
+}
+
+func newPermissionError(access resourceAccess) error {
+	canI := fmt.Sprintf("kubectl auth can-i %s %s", access.verb, access.resourceName())
+	where := ""
+	if access.namespace != "" {
+		canI += " -n " + access.namespace
+		where = fmt.Sprintf(" in namespace %q", access.namespace)
+	}
+	return fmt.Errorf("you don't have permission to %s %s%s, ask a cluster administrator for a role allowing it.\nCheck your permissions with:\n  %s",
+		access.verb, access.resourceName(), where, canI)
+}
+
+// withPermissionHint adds a hint to errors of requests the cluster forbids.
+func withPermissionHint(err error) error {
+	if err == nil || !apierrors.IsForbidden(err) {
+		return err
+	}
+	return fmt.Errorf("%w\nYou don't have permission for this operation, ask a cluster administrator for a role allowing it.\nCheck your permissions with:\n  kubectl auth can-i --list", err)
+}
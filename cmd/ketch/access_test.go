@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	ktesting "k8s.io/client-go/testing"
+
+	"github.com/theketchio/ketch/internal/mocks"
+)
+
+func TestCheckAccess(t *testing.T) {
+	// allowed returns a reactor allowing only the given verb on apps.
+	allowed := func(verb string) []mocks.KubeClientReactor {
+		return []mocks.KubeClientReactor{{
+			Verb:     "create",
+			Resource: "selfsubjectaccessreviews",
+			Reaction: func(action ktesting.Action) (bool, runtime.Object, error) {
+				review := action.(ktesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+				attributes := review.Spec.ResourceAttributes
+				review.Status.Allowed = attributes.Verb == verb && attributes.Resource == "apps"
+				return true, review, nil
+			},
+		}}
+	}
+	tests := []struct {
+		name     string
+		cmd      *cobra.Command
+		reactors []mocks.KubeClientReactor
+		wantErr  string
+	}{
+		{
+			name: "command without required access",
+			cmd:  &cobra.Command{Use: "list"},
+		},
+		{
+			name:     "allowed",
+			cmd:      requireAccess(&cobra.Command{Use: "remove"}, appsAccess("delete")),
+			reactors: allowed("delete"),
+		},
+		{
+			name:     "denied",
+			cmd:      requireAccess(&cobra.Command{Use: "deploy"}, appsAccess("create"), appsAccess("update")),
+			reactors: allowed("create"),
+			wantErr:  "you don't have permission to update apps.theketch.io, ask a cluster administrator for a role allowing it.\nCheck your permissions with:\n  kubectl auth can-i update apps.theketch.io",
+		},
+		{
+			name:     "denied in a namespace",
+			cmd:      requireAccess(&cobra.Command{Use: "set"}, ingressConfigmapAccess("update")),
+			reactors: allowed("update"),
+			wantErr:  "you don't have permission to update configmaps in namespace \"default\", ask a cluster administrator for a role allowing it.\nCheck your permissions with:\n  kubectl auth can-i update configmaps -n default",
+		},
+		{
+			name: "access can't be reviewed",
+			cmd:  requireAccess(&cobra.Command{Use: "remove"}, appsAccess("delete")),
+			reactors: []mocks.KubeClientReactor{{
+				Verb:     "create",
+				Resource: "selfsubjectaccessreviews",
+				Reaction: func(action ktesting.Action) (bool, runtime.Object, error) {
+					return true, nil, fmt.Errorf("the server could not find the requested resource")
+				},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &mocks.Configuration{KubeClientReactors: tt.reactors}
+			err := checkAccess(context.Background(), cfg, tt.cmd)
+			if tt.wantErr != "" {
+				require.NotNil(t, err)
+				require.Equal(t, tt.wantErr, err.Error())
+				return
+			}
+			require.Nil(t, err)
+		})
+	}
+}
+
+func TestWithPermissionHint(t *testing.T) {
+	require.Nil(t, withPermissionHint(nil))
+
+	err := fmt.Errorf("failed")
+	require.Equal(t, err, withPermissionHint(err))
+
+	forbidden := fmt.Errorf("failed to create pod: %w", apierrors.NewForbidden(corev1.Resource("pods"), "shop-run", fmt.Errorf("no access")))
+	err = withPermissionHint(forbidden)
+	require.True(t, apierrors.IsForbidden(err))
+	require.Contains(t, err.Error(), "kubectl auth can-i --list")
+}
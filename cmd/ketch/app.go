@@ -14,7 +14,7 @@ import (
 	"github.com/theketchio/ketch/internal/pack"
 )
 
-func newAppCmd(cfg config, out io.Writer, packSvc *pack.Client, configDefaultBuilder string) *cobra.Command {
+func newAppCmd(cfg config, out io.Writer, packSvc *pack.Client, configDefaultBuilder, configDefaultRegistrySecret string, redact redactor) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "app",
 		Short: "Manage applications",
@@ -29,23 +29,44 @@ func newAppCmd(cfg config, out io.Writer, packSvc *pack.Client, configDefaultBui
 		KubeClient:     cfg.KubernetesClient(),
 		Builder:        build.GetSourceHandler(packSvc),
 		GetImageConfig: deploy.GetImageConfig,
+		CloneSource:    deploy.CloneGitSource,
 		Wait:           deploy.WaitForDeployment,
+		Templates:      cfg.Storage(),
 		Writer:         out,
 	}
 
-	cmd.AddCommand(newAppDeployCmd(cfg, params, configDefaultBuilder))
+	cmd.AddCommand(requireAccess(newAppDeployCmd(cfg, params, configDefaultBuilder, configDefaultRegistrySecret), appsAccess("create"), appsAccess("update")))
 	cmd.AddCommand(newAppListCmd(cfg, out))
 	cmd.AddCommand(newAppLogCmd(cfg, out, appLog))
-	cmd.AddCommand(newAppRemoveCmd(cfg, out, appRemove))
-	cmd.AddCommand(newAppInfoCmd(cfg, out))
-	cmd.AddCommand(newAppStartCmd(cfg, out, appStart))
-	cmd.AddCommand(newAppStopCmd(cfg, out, appStop))
-	cmd.AddCommand(newAppExportCmd(cfg, exportApp, out))
+	cmd.AddCommand(requireAccess(newAppRemoveCmd(cfg, out, appRemove), appsAccess("delete")))
+	cmd.AddCommand(newAppInfoCmd(cfg, out, redact))
+	cmd.AddCommand(newAppTopCmd(cfg, out))
+	cmd.AddCommand(requireAccess(newAppStartCmd(cfg, out, appStart), appsAccess("update")))
+	cmd.AddCommand(requireAccess(newAppStopCmd(cfg, out, appStop), appsAccess("update")))
+	cmd.AddCommand(newAppExportCmd(cfg, redact, exportApp, out))
+	cmd.AddCommand(requireAccess(newAppImportCmd(cfg, appImport, out), appsAccess("create")))
+	cmd.AddCommand(newAppTemplateCmd(cfg, redact, out))
+	cmd.AddCommand(requireAccess(newAppRepairCmd(cfg, out, appRepair), appsAccess("update")))
+	cmd.AddCommand(newAppRegistrySecretCmd(cfg, out))
+	cmd.AddCommand(newAppRunCmd(cfg, out))
+	cmd.AddCommand(newAppHistoryCmd(cfg, out))
+	cmd.AddCommand(requireAccess(newAppRollbackCmd(cfg, out), appsAccess("update")))
+	cmd.AddCommand(newAppReleasesCmd(cfg, out))
+	cmd.AddCommand(requireAccess(newAppPromoteCmd(cfg, out), appsAccess("create"), appsAccess("update")))
+	cmd.AddCommand(requireAccess(newAppBindCmd(cfg, out), appsAccess("update")))
+	cmd.AddCommand(requireAccess(newAppUnbindCmd(cfg, out), appsAccess("update")))
+	cmd.AddCommand(newAppMaintenanceCmd(cfg, out))
+	cmd.AddCommand(newAppAuthCmd(cfg, out))
+	cmd.AddCommand(newAppAllowlistCmd(cfg, out))
+	cmd.AddCommand(requireAccess(newAppExposeCmd(cfg, out), appsAccess("update")))
 	return cmd
 }
 
+// appStateUnknown is the state of an app whose pods can't be read.
+const appStateUnknown = "unknown"
+
 func appState(pods []apiv1.Pod) (state string) {
-	state = "unknown"
+	state = appStateUnknown
 	// If there's no pods for this app, then app in `created` state
 	if len(pods) == 0 {
 		state = strings.ToLower(string(ketchv1.AppCreated))
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+)
+
+const appAllowlistSetHelp = `
+Restrict clients of an application's cnames to CIDRs, the ingress controller rejects requests from other addresses.
+The CIDRs apply to all cnames of the application, use --cname to restrict one cname, the cname's CIDRs take precedence.
+nginx serves several cnames with one ingress object, so cnames of the same scheme must have the same CIDRs.
+istio checks the client address of the X-Forwarded-For header, so the ingress gateway must be configured with the number of trusted proxies.
+`
+
+const appAllowlistUnsetHelp = `
+Allow all clients to reach an application's cnames.
+Use --cname to remove the CIDRs of one cname, it gets the CIDRs of the application again.
+`
+
+func newAppAllowlistCmd(cfg config, out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "allowlist",
+		Short: "Manage CIDRs of clients allowed to reach an application",
+		Long:  "Manage CIDRs of clients allowed to reach an application",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Usage()
+		},
+	}
+	cmd.AddCommand(requireAccess(newAppAllowlistSetCmd(cfg, out), appsAccess("update")))
+	cmd.AddCommand(requireAccess(newAppAllowlistUnsetCmd(cfg, out), appsAccess("update")))
+	return cmd
+}
+
+func newAppAllowlistSetCmd(cfg config, out io.Writer) *cobra.Command {
+	options := appAllowlistSetOptions{}
+	cmd := &cobra.Command{
+		Use:   "set APPNAME CIDR...",
+		Args:  cobra.MinimumNArgs(2),
+		Short: "Restrict clients of an application's cnames to CIDRs.",
+		Long:  appAllowlistSetHelp,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			options.appName, options.cidrs = args[0], args[1:]
+			return appAllowlistSet(cmd.Context(), cfg, options, out)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) > 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return autoCompleteAppNames(cfg, toComplete)
+		},
+	}
+	cmd.Flags().StringVar(&options.cname, "cname", "", "The CName to restrict, e.g. example.com/api")
+	return cmd
+}
+
+type appAllowlistSetOptions struct {
+	appName string
+	cname   string
+	cidrs   []string
+}
+
+func appAllowlistSet(ctx context.Context, cfg config, options appAllowlistSetOptions, out io.Writer) error {
+	if err := ketchv1.ValidateCIDRs(options.cidrs); err != nil {
+		return err
+	}
+	app := ketchv1.App{}
+	if err := cfg.Client().Get(ctx, types.NamespacedName{Name: options.appName}, &app); err != nil {
+		return fmt.Errorf("failed to get the app: %w", err)
+	}
+	target := "all cnames of " + app.Name
+	if options.cname != "" {
+		cname := app.Spec.Ingress.Cnames.Find(strings.TrimRight(options.cname, "/"))
+		if cname == nil {
+			return fmt.Errorf("%w: %s", ErrCnameNotFound, options.cname)
+		}
+		cname.AllowedCIDRs = options.cidrs
+		target = cname.Address()
+	} else {
+		app.Spec.Ingress.AllowedCIDRs = options.cidrs
+	}
+	if err := cfg.Client().Update(ctx, &app); err != nil {
+		return fmt.Errorf("failed to update the app: %w", err)
+	}
+	fmt.Fprintf(out, "%s restricted to %s.\n", target, strings.Join(options.cidrs, ", "))
+	return nil
+}
+
+func newAppAllowlistUnsetCmd(cfg config, out io.Writer) *cobra.Command {
+	options := appAllowlistUnsetOptions{}
+	cmd := &cobra.Command{
+		Use:   "unset APPNAME",
+		Args:  cobra.ExactValidArgs(1),
+		Short: "Allow all clients to reach an application's cnames.",
+		Long:  appAllowlistUnsetHelp,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			options.appName = args[0]
+			return appAllowlistUnset(cmd.Context(), cfg, options, out)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return autoCompleteAppNames(cfg, toComplete)
+		},
+	}
+	cmd.Flags().StringVar(&options.cname, "cname", "", "The CName whose CIDRs are removed, e.g. example.com/api")
+	return cmd
+}
+
+type appAllowlistUnsetOptions struct {
+	appName string
+	cname   string
+}
+
+func appAllowlistUnset(ctx context.Context, cfg config, options appAllowlistUnsetOptions, out io.Writer) error {
+	app := ketchv1.App{}
+	if err := cfg.Client().Get(ctx, types.NamespacedName{Name: options.appName}, &app); err != nil {
+		return fmt.Errorf("failed to get the app: %w", err)
+	}
+	if options.cname != "" {
+		cname := app.Spec.Ingress.Cnames.Find(strings.TrimRight(options.cname, "/"))
+		if cname == nil {
+			return fmt.Errorf("%w: %s", ErrCnameNotFound, options.cname)
+		}
+		cname.AllowedCIDRs = nil
+	} else {
+		app.Spec.Ingress.AllowedCIDRs = nil
+	}
+	if err := cfg.Client().Update(ctx, &app); err != nil {
+		return fmt.Errorf("failed to update the app: %w", err)
+	}
+	fmt.Fprintln(out, "Allowlist removed.")
+	return nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/mocks"
+)
+
+func TestAppAllowlistSet(t *testing.T) {
+	tests := []struct {
+		name        string
+		app         *ketchv1.App
+		options     appAllowlistSetOptions
+		wantIngress ketchv1.IngressSpec
+		wantOut     string
+		wantErr     string
+	}{
+		{
+			name:        "cidrs of the app",
+			app:         newAuthApp(nil, ketchv1.Cname{Name: "theketch.io"}),
+			options:     appAllowlistSetOptions{appName: "go-app", cidrs: []string{"10.0.0.0/8", "172.16.0.0/12"}},
+			wantIngress: ketchv1.IngressSpec{Cnames: ketchv1.CnameList{{Name: "theketch.io"}}, AllowedCIDRs: []string{"10.0.0.0/8", "172.16.0.0/12"}},
+			wantOut:     "all cnames of go-app restricted to 10.0.0.0/8, 172.16.0.0/12.\n",
+		},
+		{
+			name:        "cidrs of a cname",
+			app:         newAuthApp(nil, ketchv1.Cname{Name: "theketch.io", Path: "/admin"}),
+			options:     appAllowlistSetOptions{appName: "go-app", cname: "theketch.io/admin", cidrs: []string{"192.168.0.0/16"}},
+			wantIngress: ketchv1.IngressSpec{Cnames: ketchv1.CnameList{{Name: "theketch.io", Path: "/admin", AllowedCIDRs: []string{"192.168.0.0/16"}}}},
+			wantOut:     "theketch.io/admin restricted to 192.168.0.0/16.\n",
+		},
+		{
+			name:    "cname not found",
+			app:     newAuthApp(nil),
+			options: appAllowlistSetOptions{appName: "go-app", cname: "theketch.io", cidrs: []string{"10.0.0.0/8"}},
+			wantErr: "cname not found: theketch.io",
+		},
+		{
+			name:    "invalid cidr",
+			app:     newAuthApp(nil),
+			options: appAllowlistSetOptions{appName: "go-app", cidrs: []string{"10.0.0.1"}},
+			wantErr: `invalid cidr "10.0.0.1"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &mocks.Configuration{CtrlClientObjects: []runtime.Object{tt.app}}
+			out := &bytes.Buffer{}
+			err := appAllowlistSet(context.Background(), cfg, tt.options, out)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.wantOut, out.String())
+			app := ketchv1.App{}
+			require.Nil(t, cfg.Client().Get(context.Background(), types.NamespacedName{Name: "go-app"}, &app))
+			require.Equal(t, tt.wantIngress, app.Spec.Ingress)
+		})
+	}
+}
+
+func TestAppAllowlistUnset(t *testing.T) {
+	app := newAuthApp(nil, ketchv1.Cname{Name: "theketch.io", AllowedCIDRs: []string{"192.168.0.0/16"}})
+	app.Spec.Ingress.AllowedCIDRs = []string{"10.0.0.0/8"}
+	cfg := &mocks.Configuration{CtrlClientObjects: []runtime.Object{app}}
+	out := &bytes.Buffer{}
+	require.Nil(t, appAllowlistUnset(context.Background(), cfg, appAllowlistUnsetOptions{appName: "go-app", cname: "theketch.io"}, out))
+	require.Equal(t, "Allowlist removed.\n", out.String())
+	got := ketchv1.App{}
+	require.Nil(t, cfg.Client().Get(context.Background(), types.NamespacedName{Name: "go-app"}, &got))
+	require.Equal(t, ketchv1.IngressSpec{Cnames: ketchv1.CnameList{{Name: "theketch.io"}}, AllowedCIDRs: []string{"10.0.0.0/8"}}, got.Spec.Ingress)
+}
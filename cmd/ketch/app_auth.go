@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+)
+
+const appAuthSetHelp = `
+Protect an application's cnames with authentication, the ingress controller rejects requests that fail it.
+Use exactly one of:
+  --basic-auth-secret  a secret in the app's namespace with htpasswd users under the "auth" key for nginx or the "users" key for traefik,
+  --forward-auth-url   a URL of an authentication service like oauth2-proxy, a request is allowed if the service responds with a 2xx status,
+  --oidc-issuer        an issuer of JWTs requests must carry, istio only, --oidc-jwks-uri sets a URL of the issuer's keys.
+The auth protects all cnames of the application, use --cname to protect one cname, the cname's auth takes precedence.
+nginx serves several cnames with one ingress object, so cnames of the same scheme must have the same auth.
+`
+
+const appAuthUnsetHelp = `
+Remove authentication of an application's cnames.
+Use --cname to remove the auth of one cname, it gets the auth of the application again.
+`
+
+func newAppAuthCmd(cfg config, out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "auth",
+		Short: "Manage authentication of an application's cnames",
+		Long:  "Manage authentication of an application's cnames",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Usage()
+		},
+	}
+	cmd.AddCommand(requireAccess(newAppAuthSetCmd(cfg, out), appsAccess("update")))
+	cmd.AddCommand(requireAccess(newAppAuthUnsetCmd(cfg, out), appsAccess("update")))
+	return cmd
+}
+
+func newAppAuthSetCmd(cfg config, out io.Writer) *cobra.Command {
+	options := appAuthSetOptions{}
+	cmd := &cobra.Command{
+		Use:   "set APPNAME",
+		Args:  cobra.ExactValidArgs(1),
+		Short: "Protect an application's cnames with authentication.",
+		Long:  appAuthSetHelp,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			options.appName = args[0]
+			return appAuthSet(cmd.Context(), cfg, options, out)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return autoCompleteAppNames(cfg, toComplete)
+		},
+	}
+	cmd.Flags().StringVar(&options.cname, "cname", "", "The CName to protect, e.g. example.com/api")
+	cmd.Flags().StringVar(&options.basicAuthSecret, "basic-auth-secret", "", "The name of a secret in the app's namespace with htpasswd users")
+	cmd.Flags().StringVar(&options.forwardAuthURL, "forward-auth-url", "", "The URL of an authentication service requests are verified with")
+	cmd.Flags().StringVar(&options.oidcIssuer, "oidc-issuer", "", "The issuer of JWTs requests must carry")
+	cmd.Flags().StringVar(&options.oidcJWKSURI, "oidc-jwks-uri", "", "The URL of public keys of the OIDC issuer")
+	return cmd
+}
+
+type appAuthSetOptions struct {
+	appName         string
+	cname           string
+	basicAuthSecret string
+	forwardAuthURL  string
+	oidcIssuer      string
+	oidcJWKSURI     string
+}
+
+func (o appAuthSetOptions) auth() ketchv1.AuthSpec {
+	auth := ketchv1.AuthSpec{BasicAuthSecret: o.basicAuthSecret, ForwardAuthURL: o.forwardAuthURL}
+	if o.oidcIssuer != "" || o.oidcJWKSURI != "" {
+		auth.OIDC = &ketchv1.OIDCAuth{Issuer: o.oidcIssuer, JWKSURI: o.oidcJWKSURI}
+	}
+	return auth
+}
+
+func appAuthSet(ctx context.Context, cfg config, options appAuthSetOptions, out io.Writer) error {
+	auth := options.auth()
+	if err := auth.Validate(); err != nil {
+		return err
+	}
+	app := ketchv1.App{}
+	if err := cfg.Client().Get(ctx, types.NamespacedName{Name: options.appName}, &app); err != nil {
+		return fmt.Errorf("failed to get the app: %w", err)
+	}
+	target := "all cnames of " + app.Name
+	if options.cname != "" {
+		cname := app.Spec.Ingress.Cnames.Find(strings.TrimRight(options.cname, "/"))
+		if cname == nil {
+			return fmt.Errorf("%w: %s", ErrCnameNotFound, options.cname)
+		}
+		cname.Auth = &auth
+		target = cname.Address()
+	} else {
+		app.Spec.Ingress.Auth = &auth
+	}
+	if err := cfg.Client().Update(ctx, &app); err != nil {
+		return fmt.Errorf("failed to update the app: %w", err)
+	}
+	fmt.Fprintf(out, "%s protected with %s.\n", target, auth.String())
+	return nil
+}
+
+func newAppAuthUnsetCmd(cfg config, out io.Writer) *cobra.Command {
+	options := appAuthUnsetOptions{}
+	cmd := &cobra.Command{
+		Use:   "unset APPNAME",
+		Args:  cobra.ExactValidArgs(1),
+		Short: "Remove authentication of an application's cnames.",
+		Long:  appAuthUnsetHelp,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			options.appName = args[0]
+			return appAuthUnset(cmd.Context(), cfg, options, out)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return autoCompleteAppNames(cfg, toComplete)
+		},
+	}
+	cmd.Flags().StringVar(&options.cname, "cname", "", "The CName whose auth is removed, e.g. example.com/api")
+	return cmd
+}
+
+type appAuthUnsetOptions struct {
+	appName string
+	cname   string
+}
+
+func appAuthUnset(ctx context.Context, cfg config, options appAuthUnsetOptions, out io.Writer) error {
+	app := ketchv1.App{}
+	if err := cfg.Client().Get(ctx, types.NamespacedName{Name: options.appName}, &app); err != nil {
+		return fmt.Errorf("failed to get the app: %w", err)
+	}
+	if options.cname != "" {
+		cname := app.Spec.Ingress.Cnames.Find(strings.TrimRight(options.cname, "/"))
+		if cname == nil {
+			return fmt.Errorf("%w: %s", ErrCnameNotFound, options.cname)
+		}
+		cname.Auth = nil
+	} else {
+		app.Spec.Ingress.Auth = nil
+	}
+	if err := cfg.Client().Update(ctx, &app); err != nil {
+		return fmt.Errorf("failed to update the app: %w", err)
+	}
+	fmt.Fprintln(out, "Auth removed.")
+	return nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/mocks"
+)
+
+func newAuthApp(auth *ketchv1.AuthSpec, cnames ...ketchv1.Cname) *ketchv1.App {
+	return &ketchv1.App{
+		ObjectMeta: metav1.ObjectMeta{Name: "go-app"},
+		Spec: ketchv1.AppSpec{
+			Namespace: "ketch-go-app",
+			Ingress:   ketchv1.IngressSpec{Cnames: cnames, Auth: auth},
+		},
+	}
+}
+
+func TestAppAuthSet(t *testing.T) {
+	basicAuth := &ketchv1.AuthSpec{BasicAuthSecret: "staging-users"}
+	tests := []struct {
+		name        string
+		app         *ketchv1.App
+		options     appAuthSetOptions
+		wantIngress ketchv1.IngressSpec
+		wantOut     string
+		wantErr     string
+	}{
+		{
+			name:        "auth of the app",
+			app:         newAuthApp(nil, ketchv1.Cname{Name: "theketch.io"}),
+			options:     appAuthSetOptions{appName: "go-app", basicAuthSecret: "staging-users"},
+			wantIngress: ketchv1.IngressSpec{Cnames: ketchv1.CnameList{{Name: "theketch.io"}}, Auth: basicAuth},
+			wantOut:     "all cnames of go-app protected with basic auth secret staging-users.\n",
+		},
+		{
+			name:    "auth of a cname",
+			app:     newAuthApp(basicAuth, ketchv1.Cname{Name: "theketch.io", Path: "/api"}),
+			options: appAuthSetOptions{appName: "go-app", cname: "theketch.io/api/", oidcIssuer: "https://accounts.google.com"},
+			wantIngress: ketchv1.IngressSpec{
+				Cnames: ketchv1.CnameList{{Name: "theketch.io", Path: "/api", Auth: &ketchv1.AuthSpec{OIDC: &ketchv1.OIDCAuth{Issuer: "https://accounts.google.com"}}}},
+				Auth:   basicAuth,
+			},
+			wantOut: "theketch.io/api protected with oidc issuer https://accounts.google.com.\n",
+		},
+		{
+			name:    "cname not found",
+			app:     newAuthApp(nil),
+			options: appAuthSetOptions{appName: "go-app", cname: "theketch.io", basicAuthSecret: "staging-users"},
+			wantErr: "cname not found: theketch.io",
+		},
+		{
+			name:    "ambiguous auth",
+			app:     newAuthApp(nil),
+			options: appAuthSetOptions{appName: "go-app", basicAuthSecret: "staging-users", forwardAuthURL: "https://auth.theketch.io"},
+			wantErr: "exactly one of basic auth secret, forward auth url and oidc must be set",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &mocks.Configuration{CtrlClientObjects: []runtime.Object{tt.app}}
+			out := &bytes.Buffer{}
+			err := appAuthSet(context.Background(), cfg, tt.options, out)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.wantOut, out.String())
+			app := ketchv1.App{}
+			require.Nil(t, cfg.Client().Get(context.Background(), types.NamespacedName{Name: "go-app"}, &app))
+			require.Equal(t, tt.wantIngress, app.Spec.Ingress)
+		})
+	}
+}
+
+func TestAppAuthUnset(t *testing.T) {
+	basicAuth := &ketchv1.AuthSpec{BasicAuthSecret: "staging-users"}
+	forwardAuth := &ketchv1.AuthSpec{ForwardAuthURL: "https://auth.theketch.io"}
+	tests := []struct {
+		name        string
+		options     appAuthUnsetOptions
+		wantIngress ketchv1.IngressSpec
+	}{
+		{
+			name:        "auth of the app",
+			options:     appAuthUnsetOptions{appName: "go-app"},
+			wantIngress: ketchv1.IngressSpec{Cnames: ketchv1.CnameList{{Name: "theketch.io", Auth: forwardAuth}}},
+		},
+		{
+			name:        "auth of a cname",
+			options:     appAuthUnsetOptions{appName: "go-app", cname: "theketch.io"},
+			wantIngress: ketchv1.IngressSpec{Cnames: ketchv1.CnameList{{Name: "theketch.io"}}, Auth: basicAuth},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &mocks.Configuration{CtrlClientObjects: []runtime.Object{newAuthApp(basicAuth, ketchv1.Cname{Name: "theketch.io", Auth: forwardAuth})}}
+			out := &bytes.Buffer{}
+			require.Nil(t, appAuthUnset(context.Background(), cfg, tt.options, out))
+			require.Equal(t, "Auth removed.\n", out.String())
+			app := ketchv1.App{}
+			require.Nil(t, cfg.Client().Get(context.Background(), types.NamespacedName{Name: "go-app"}, &app))
+			require.Equal(t, tt.wantIngress, app.Spec.Ingress)
+		})
+	}
+}
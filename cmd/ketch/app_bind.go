@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+)
+
+const appBindHelp = `
+Bind an application to an instance of an external service, like a database or a queue.
+Connection details of the instance are keys of a secret in the app's namespace, each key is set as an env variable
+named after the key with the prefix passed with --env-prefix:
+  ketch app bind myapp postgres --secret postgres-credentials --env-prefix DATABASE_
+
+Variables set by the app take precedence over variables of bindings.
+Pods of bound processes are restarted when the secret changes, so rotated credentials are picked up automatically.
+Binding again with the same name updates the binding.
+`
+
+func newAppBindCmd(cfg config, out io.Writer) *cobra.Command {
+	options := appBindOptions{}
+	cmd := &cobra.Command{
+		Use:   "bind APPNAME BINDING --secret SECRET",
+		Args:  cobra.ExactArgs(2),
+		Short: "Bind an application to an instance of an external service.",
+		Long:  appBindHelp,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			options.appName = args[0]
+			options.binding.Name = args[1]
+			return appBind(cmd.Context(), cfg, options, out)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return autoCompleteAppNames(cfg, toComplete)
+		},
+	}
+	cmd.Flags().StringVar(&options.binding.SecretName, "secret", "", "Name of a secret in the app's namespace with connection details of the service instance.")
+	cmd.Flags().StringVar(&options.binding.EnvPrefix, "env-prefix", "", "Prefix of names of env variables, e.g. DATABASE_ sets the \"host\" key as DATABASE_HOST.")
+	cmd.Flags().StringSliceVar(&options.binding.Processes, "process", nil, "Processes the service is bound to, all processes if not set.")
+	cmd.MarkFlagRequired("secret")
+	return cmd
+}
+
+type appBindOptions struct {
+	appName string
+	binding ketchv1.ServiceBinding
+}
+
+func appBind(ctx context.Context, cfg config, options appBindOptions, out io.Writer) error {
+	app := ketchv1.App{}
+	if err := cfg.Client().Get(ctx, types.NamespacedName{Name: options.appName}, &app); err != nil {
+		return fmt.Errorf("failed to get the app: %w", err)
+	}
+	secret := v1.Secret{}
+	if err := cfg.Client().Get(ctx, types.NamespacedName{Name: options.binding.SecretName, Namespace: app.Spec.Namespace}, &secret); err != nil {
+		return fmt.Errorf("failed to get secret %s in namespace %s: %w", options.binding.SecretName, app.Spec.Namespace, err)
+	}
+	updated := false
+	for i, binding := range app.Spec.ServiceBindings {
+		if binding.Name == options.binding.Name {
+			app.Spec.ServiceBindings[i] = options.binding
+			updated = true
+		}
+	}
+	if !updated {
+		app.Spec.ServiceBindings = append(app.Spec.ServiceBindings, options.binding)
+	}
+	if err := cfg.Client().Update(ctx, &app); err != nil {
+		return fmt.Errorf("failed to update the app: %w", err)
+	}
+	envs := options.binding.Envs(secret)
+	names := make([]string, 0, len(envs))
+	for _, env := range envs {
+		names = append(names, env.Name)
+	}
+	fmt.Fprintf(out, "Bound %s to %s with env variables %s.\n", app.Name, options.binding.Name, strings.Join(names, ", "))
+	return nil
+}
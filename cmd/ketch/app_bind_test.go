@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/mocks"
+)
+
+func newBindingApp(bindings ...ketchv1.ServiceBinding) *ketchv1.App {
+	return &ketchv1.App{
+		ObjectMeta: metav1.ObjectMeta{Name: "go-app"},
+		Spec:       ketchv1.AppSpec{Namespace: "ketch-go-app", ServiceBindings: bindings},
+	}
+}
+
+func TestAppBind(t *testing.T) {
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "postgres", Namespace: "ketch-go-app"},
+		Data:       map[string][]byte{"host": []byte("db.example.com"), "password": []byte("secret")},
+	}
+	tests := []struct {
+		name         string
+		objects      []runtime.Object
+		options      appBindOptions
+		wantBindings []ketchv1.ServiceBinding
+		wantOut      string
+		wantErr      string
+	}{
+		{
+			name:         "new binding",
+			objects:      []runtime.Object{newBindingApp(), secret},
+			options:      appBindOptions{appName: "go-app", binding: ketchv1.ServiceBinding{Name: "db", SecretName: "postgres", EnvPrefix: "DATABASE_"}},
+			wantBindings: []ketchv1.ServiceBinding{{Name: "db", SecretName: "postgres", EnvPrefix: "DATABASE_"}},
+			wantOut:      "Bound go-app to db with env variables DATABASE_HOST, DATABASE_PASSWORD.\n",
+		},
+		{
+			name:         "existing binding is updated",
+			objects:      []runtime.Object{newBindingApp(ketchv1.ServiceBinding{Name: "db", SecretName: "mysql"}), secret},
+			options:      appBindOptions{appName: "go-app", binding: ketchv1.ServiceBinding{Name: "db", SecretName: "postgres", Processes: []string{"web"}}},
+			wantBindings: []ketchv1.ServiceBinding{{Name: "db", SecretName: "postgres", Processes: []string{"web"}}},
+			wantOut:      "Bound go-app to db with env variables HOST, PASSWORD.\n",
+		},
+		{
+			name:    "missing secret",
+			objects: []runtime.Object{newBindingApp()},
+			options: appBindOptions{appName: "go-app", binding: ketchv1.ServiceBinding{Name: "db", SecretName: "postgres"}},
+			wantErr: `failed to get secret postgres in namespace ketch-go-app: secrets "postgres" not found`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &mocks.Configuration{CtrlClientObjects: tt.objects}
+			out := &bytes.Buffer{}
+			err := appBind(context.Background(), cfg, tt.options, out)
+			if len(tt.wantErr) > 0 {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.wantOut, out.String())
+			app := ketchv1.App{}
+			require.Nil(t, cfg.Client().Get(context.Background(), types.NamespacedName{Name: "go-app"}, &app))
+			require.Equal(t, tt.wantBindings, app.Spec.ServiceBindings)
+		})
+	}
+}
+
+func TestAppUnbind(t *testing.T) {
+	db := ketchv1.ServiceBinding{Name: "db", SecretName: "postgres"}
+	queue := ketchv1.ServiceBinding{Name: "queue", SecretName: "rabbitmq"}
+	cfg := &mocks.Configuration{CtrlClientObjects: []runtime.Object{newBindingApp(db, queue)}}
+
+	out := &bytes.Buffer{}
+	require.Nil(t, appUnbind(context.Background(), cfg, appUnbindOptions{appName: "go-app", bindingName: "db"}, out))
+	require.Equal(t, "Unbound go-app from db.\n", out.String())
+	app := ketchv1.App{}
+	require.Nil(t, cfg.Client().Get(context.Background(), types.NamespacedName{Name: "go-app"}, &app))
+	require.Equal(t, []ketchv1.ServiceBinding{queue}, app.Spec.ServiceBindings)
+
+	err := appUnbind(context.Background(), cfg, appUnbindOptions{appName: "go-app", bindingName: "db"}, out)
+	require.EqualError(t, err, "app go-app has no binding db")
+}
@@ -1,8 +1,16 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
 	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
 
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
 	"github.com/theketchio/ketch/internal/deploy"
 	"github.com/theketchio/ketch/internal/validation"
 )
@@ -21,9 +29,69 @@ Details about Procfile conventions can be found here: https://devcenter.heroku.c
   Ketch looks for ketch.yaml inside the source directory by default
   but you can provide a custom path with --ketch-yaml.
 
+Deploy from a git repository. <source> is a URL of the repository optionally followed by #<ref>,
+where <ref> is a branch, a tag or a commit. The repository is cloned and built the same way as a source directory.
+Credentials to clone a private repository are read from a Secret in the app's namespace passed with --git-secret,
+the secret must contain either "username" and "password" keys or a "ssh-privatekey" key.
+  ketch app deploy <app name> https://github.com/org/repo.git#main -i myregistry/myimage:latest
+
 Deploy from an image:
   ketch app deploy <app name> -i myregistry/myimage:latest
 
+Env variables passed with --env and --env-file are merged with the app's existing variables.
+A file passed with --env-file contains a NAME=VALUE pair per line, lines starting with '#' are ignored:
+  ketch app deploy <app name> -i myregistry/myimage:latest --env-file .env
+
+An app can run in a dedicated namespace created and removed by ketch together with the app,
+the namespace gets a resource quota and a network policy isolating it from namespaces of other apps:
+  ketch app deploy <app name> -i myregistry/myimage:latest --namespace-strategy perApp --namespace-quota pods=20
+
+The team and the owner of an app are added as theketch.io/team and theketch.io/owner labels to all its resources,
+"ketch app list --team" lists apps of a team. A cluster can allow only some teams to deploy with "ketch ingress set --allowed-teams":
+  ketch app deploy <app name> -i myregistry/myimage:latest --team payments --owner alice
+
+Clusters running several ketch-controllers started with --shard split apps between them,
+--shard assigns an app to the controllers of a shard and an empty --shard moves it back to the controller without a shard:
+  ketch app deploy <app name> -i myregistry/myimage:latest --shard payments
+
+Preview a deployment without changing the app, --dry-run prints the manifests the app would get
+and --diff prints what changes compared to the manifests of the app running in the cluster:
+  ketch app deploy <app name> -i myregistry/myimage:latest --dry-run --diff
+
+A canary deployment shifts traffic to a new version in --steps steps, one step every --step-interval.
+Requests with the header passed with --canary-header or with the cookie named with --canary-cookie set to "always"
+are routed to the new version regardless of its weight while the canary deployment is active:
+  ketch app deploy <app name> -i myregistry/myimage:v2 --steps 4 --step-interval 5m --canary-header X-Canary=true
+
+A new version can be validated with a copy of production traffic before it serves requests,
+--shadow deploys it as a shadow deployment getting the given percentage of requests mirrored by istio or traefik,
+its responses are discarded. The next deployment of the app replaces the shadow deployment:
+  ketch app deploy <app name> -i myregistry/myimage:v2 --shadow 10
+
+Commands of the image's Procfile or entrypoint can be overridden per process with --cmd,
+the deployed version keeps the command until the next deployment:
+  ketch app deploy <app name> -i myregistry/myimage:latest --cmd web='bundle exec puma -p $PORT'
+
+The processes of an image can also be replaced with a Procfile passed with --procfile.
+A "release" process of the Procfile isn't a long-running process, it runs once before a new version of the app
+is rolled out, e.g. to run database migrations, and the version isn't rolled out if the release fails:
+  ketch app deploy <app name> -i myregistry/myimage:latest --procfile Procfile
+
+Services of a docker-compose file are deployed as processes of the app with --compose.
+The image of the "web" service, or the first service by name, is the image of the app, ports, environment
+and named volumes of the services are deployed too. Images must be pushed to a registry the cluster can pull from:
+  ketch app deploy <app name> --compose docker-compose.yaml
+
+A deployment fails while the previous deployment of the app is rolling out or if the app is deployed by someone else
+meanwhile, --queue waits for the deployment in progress to roll out and deploys the new version on top of it.
+A deployment stops being in progress when it rolls out, fails, or doesn't roll out in 10 minutes:
+  ketch app deploy <app name> -i myregistry/myimage:v3 --queue --queue-timeout 30m
+
+CI pipelines can read the app name from KETCH_APP and the registry secret from KETCH_REGISTRY_SECRET.
+--output json prints the deployed version, the image pinned to its digest and URLs of the app as json,
+logs of the deployment are written to stderr:
+  KETCH_APP=myapp ketch app deploy -i myregistry/myimage:latest --non-interactive --wait --output json
+
 Users can deploy from image or source code by passing a filename such as app.yaml containing fields like:
 	name: test
 	image: gcr.io/shipa-ci/sample-go-app:latest
@@ -31,23 +99,54 @@ Users can deploy from image or source code by passing a filename such as app.yam
 `
 )
 
+// appDeployOutputJSON is the --output format of "ketch app deploy" printing an appDeployResult.
+const appDeployOutputJSON = "json"
+
+// appDeployResult describes a deployed version of an app for CI pipelines.
+type appDeployResult struct {
+	App       string `json:"app"`
+	Namespace string `json:"namespace"`
+	Version   int    `json:"version"`
+	Image     string `json:"image"`
+	// ImageDigest is the image pinned to its digest, it's empty if the registry can't be reached.
+	ImageDigest string   `json:"imageDigest,omitempty"`
+	URLs        []string `json:"urls"`
+}
+
 // NewCommand creates a command that will run the app deploy
-func newAppDeployCmd(cfg config, params *deploy.Services, configDefaultBuilder string) *cobra.Command {
+func newAppDeployCmd(cfg config, params *deploy.Services, configDefaultBuilder, configDefaultRegistrySecret string) *cobra.Command {
 	var options deploy.Options
+	var outputFormat string
 
 	cmd := &cobra.Command{
-		Use:   "deploy [APPNAME|FILENAME] [SOURCE DIRECTORY]",
+		Use:   "deploy [APPNAME|FILENAME] [SOURCE DIRECTORY|GIT URL]",
 		Short: "Deploy an app.",
 		Long:  appDeployHelp,
-		Args:  cobra.RangeArgs(1, 2),
+		Args:  cobra.RangeArgs(0, 2),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			options.AppName = args[0]
+			if len(args) == 0 {
+				if options.AppName = os.Getenv(appEnv); options.AppName == "" {
+					return fmt.Errorf("requires an APPNAME or FILENAME argument or %s", appEnv)
+				}
+			} else {
+				options.AppName = args[0]
+			}
 			if len(args) == 2 {
 				options.AppSourcePath = args[1]
 			}
 			if configDefaultBuilder != "" {
 				deploy.DefaultBuilder = configDefaultBuilder
 			}
+			deploy.DefaultRegistrySecret = configDefaultRegistrySecret
+			if outputFormat != "" && outputFormat != appDeployOutputJSON {
+				return fmt.Errorf("unknown output format %q, only %q is supported", outputFormat, appDeployOutputJSON)
+			}
+			if outputFormat == appDeployOutputJSON && options.DryRun {
+				return fmt.Errorf("--output json can't be used with --%s", deploy.FlagDryRun)
+			}
+			if outputFormat == appDeployOutputJSON {
+				return appDeployJSON(cmd, options, params, deploy.GetImageDigest)
+			}
 			return appDeploy(cmd, options, params)
 		},
 		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
@@ -55,26 +154,51 @@ func newAppDeployCmd(cfg config, params *deploy.Services, configDefaultBuilder s
 		},
 	}
 
-	cmd.Flags().StringVarP(&options.Image, deploy.FlagImage, deploy.FlagImageShort, "", "Name of the image to be deployed.")
+	cmd.Flags().StringVarP(&options.Image, deploy.FlagImage, deploy.FlagImageShort, "", "Name of the image to be deployed. An image built from source defaults to the cluster registry set by \"ketch ingress set --registry\".")
 	cmd.Flags().StringVar(&options.KetchYamlFileName, deploy.FlagKetchYaml, "", "Path to ketch.yaml.")
 
 	cmd.Flags().BoolVar(&options.StrictKetchYamlDecoding, deploy.FlagStrict, false, "Enforces strict decoding of ketch.yaml.")
 	cmd.Flags().IntVar(&options.Steps, deploy.FlagSteps, 0, "Number of steps for a canary deployment.")
+	cmd.Flags().StringVar(&options.CanaryHeader, deploy.FlagCanaryHeader, "", "Header in the form name=value, requests with the header are routed to the canary deployment regardless of its weight. Must be used with --steps.")
+	cmd.Flags().StringVar(&options.CanaryCookie, deploy.FlagCanaryCookie, "", "Name of a cookie, requests with the cookie set to \"always\" are routed to the canary deployment regardless of its weight. Must be used with --steps.")
+	cmd.Flags().IntVar(&options.Shadow, deploy.FlagShadow, 0, "Deploy a shadow version getting a copy of the given percentage of requests, its responses are discarded.")
 	cmd.Flags().StringVar(&options.StepTimeInterval, deploy.FlagStepInterval, "", "Time interval between canary deployment steps. Supported min: m, hour:h, second:s. ex. 1m, 60s, 1h.")
-	cmd.Flags().BoolVar(&options.Wait, deploy.FlagWait, false, "If true blocks until deploy completes or a timeout occurs.")
+	cmd.Flags().BoolVar(&options.Wait, deploy.FlagWait, false, "If true blocks until deploy completes or a timeout occurs, printing each step of the rollout.")
+	cmd.Flags().BoolVar(&options.Queue, deploy.FlagQueue, false, "Wait for a deployment of the app in progress to roll out and deploy on top of it instead of failing.")
+	cmd.Flags().StringVar(&options.QueueTimeout, deploy.FlagQueueTimeout, "15m", "Defines the length of time --queue waits in total for deployments of the app in progress. Supported min: m, hour:h, second:s. ex. 1m, 60s, 1h.")
 	cmd.Flags().StringVar(&options.Timeout, deploy.FlagTimeout, "20s", "Defines the length of time to block waiting for deployment completion. Supported min: m, hour:h, second:s. ex. 1m, 60s, 1h.")
+	cmd.Flags().BoolVar(&options.DryRun, deploy.FlagDryRun, false, "Print the rendered manifests of the app instead of deploying it. Can't be used to deploy from source.")
+	cmd.Flags().BoolVar(&options.Diff, deploy.FlagDiff, false, "Used with --dry-run, print a diff between the manifests of the app in the cluster and the rendered ones.")
 
 	cmd.Flags().StringVarP(&options.Description, deploy.FlagDescription, deploy.FlagDescriptionShort, "", "App description.")
+	cmd.Flags().StringVar(&options.Team, deploy.FlagTeam, "", "Team owning the app, added as a theketch.io/team label to all resources of the app.")
+	cmd.Flags().StringVar(&options.Owner, deploy.FlagOwner, "", "Person or group responsible for the app, added as a theketch.io/owner label to all resources of the app.")
+	cmd.Flags().StringVar(&options.Shard, deploy.FlagShard, "", "Shard of ketch-controllers reconciling the app, added as a theketch.io/shard label to the app. An empty value assigns the app to the controller without a shard.")
 	cmd.Flags().StringSliceVarP(&options.Envs, deploy.FlagEnvironment, deploy.FlagEnvironmentShort, []string{}, "App env variables.")
+	cmd.Flags().StringSliceVar(&options.EnvSets, deploy.FlagEnvSet, nil, "Names of env sets whose env variables are set in the app's pods, a later set takes precedence. Variables of the app take precedence over env sets.")
+	cmd.Flags().StringVar(&options.EnvFile, deploy.FlagEnvFile, "", "Path to a file with env variables in NAME=VALUE format, one per line. Variables passed with --env take precedence.")
 	cmd.Flags().StringVarP(&options.Namespace, deploy.FlagNamespace, deploy.FlagNamespaceShort, "", "Namespace to deploy your app.")
-	cmd.Flags().StringVarP(&options.DockerRegistrySecret, deploy.FlagRegistrySecret, "", "", "A name of a Secret with docker credentials. This secret must be created in the same namespace.")
+	cmd.Flags().StringVar(&options.NamespaceStrategy, deploy.FlagNamespaceStrategy, "", "Either \"shared\" to deploy the app to an existing namespace or \"perApp\" to let ketch manage a dedicated namespace of the app, ketch-<app name> by default.")
+	cmd.Flags().StringToStringVar(&options.NamespaceQuota, deploy.FlagNamespaceQuota, nil, "Resource quota of the app's dedicated namespace, e.g. requests.cpu=2,requests.memory=4Gi,pods=20.")
+	cmd.Flags().StringVarP(&options.DockerRegistrySecret, deploy.FlagRegistrySecret, "", "", "A name of a Secret with docker credentials. This secret must be created in the same namespace. New apps use default-registry-secret of the config.toml if it's not set.")
+	cmd.Flags().StringVar(&options.GitSecret, deploy.FlagGitSecret, "", "A name of a Secret with credentials to clone the git repository. This secret must be created in the app's namespace.")
 	cmd.Flags().StringVar(&options.Builder, deploy.FlagBuilder, "", "Builder to use when building from source.")
 	cmd.Flags().StringSliceVar(&options.BuildPacks, deploy.FlagBuildPacks, nil, "A list of build packs.")
+	cmd.Flags().StringVar(&options.CacheImage, deploy.FlagCacheImage, "", "Image the build cache is pushed to and restored from, so builds from source reuse layers. Defaults to the build cache of the cluster registry set by \"ketch ingress set --build-cache\".")
 	cmd.Flags().StringVar(&options.Volume, "volume", "", "Name of the volume to bind to the application.")
 	cmd.Flags().StringVar(&options.VolumeMountPath, "volume-mount-path", "", "Path to mount a volume.")
 	cmd.Flags().StringToStringVar(&options.VolumeMountOptions, "volume-mount-options", nil, "Options for volume mount.")
 	cmd.Flags().Int64Var(&options.FSGroup, "fs-group", 0, "The fsGroup for pod's security context; root if not set.")
 	cmd.Flags().Int64Var(&options.RunAsUser, "run-as-user", 0, "The user to use for running pod's processes; root if not set.")
+	cmd.Flags().BoolVar(&options.ForceHTTPS, deploy.FlagForceHTTPS, false, "Serve all CNAMEs over https and redirect http requests to https. If not set, the default of the ingress controller is used.")
+	cmd.Flags().BoolVar(&options.NetworkPolicy, deploy.FlagNetworkPolicy, false, "Accept traffic only from the ingress controller and the app's own pods. If not set, the default of the ingress controller is used.")
+	cmd.Flags().StringSliceVar(&options.AllowFrom, deploy.FlagAllowFrom, nil, "Namespaces whose pods can reach the app in addition to the ingress controller when the network policy is enabled.")
+	cmd.Flags().StringArrayVar(&options.Cmds, deploy.FlagCmd, nil, "Command of a process in the form process=command, it overrides the command of the image's Procfile or entrypoint for the deployed version. The command runs in /bin/sh, e.g. web=\"bundle exec puma -p $PORT\". Can be repeated.")
+	cmd.Flags().StringVar(&options.ProcfileName, deploy.FlagProcfile, "", "Path to a Procfile with the processes of the image, it replaces the image's Procfile or entrypoint. Can't be used to deploy from source.")
+	cmd.Flags().StringVar(&options.ComposeFile, deploy.FlagCompose, "", "Path to a docker-compose file, its services are deployed as processes of the app. Can't be used with --image, --procfile or to deploy from source.")
+
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "", "Output format, \"json\" prints the deployed version, the image pinned to its digest and URLs of the app.")
+	bindFlagEnv(cmd, deploy.FlagRegistrySecret, registrySecretEnv)
 
 	cmd.Flags().IntVar(&options.Units, deploy.FlagUnits, 1, "Set number of units for deployment.")
 	cmd.Flags().IntVar(&options.Version, deploy.FlagVersion, 1, "Specify version whose units to update. Must be used with units flag!")
@@ -84,6 +208,8 @@ func newAppDeployCmd(cfg config, params *deploy.Services, configDefaultBuilder s
 		return autoCompleteNamespaces(cfg, toComplete)
 	})
 
+	registerProcessNameCompletion(cmd, cfg, deploy.FlagProcess)
+
 	cmd.RegisterFlagCompletionFunc(deploy.FlagBuilder, func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		return autoCompleteBuilderNames(cfg, toComplete)
 	})
@@ -91,16 +217,63 @@ func newAppDeployCmd(cfg config, params *deploy.Services, configDefaultBuilder s
 }
 
 func appDeploy(cmd *cobra.Command, options deploy.Options, params *deploy.Services) error {
-	var changeSet *deploy.ChangeSet
-	var err error
-	switch {
-	case validation.ValidateYamlFilename(options.AppName):
-		changeSet, err = options.GetChangeSetFromYaml(options.AppName)
-		if err != nil {
-			return err
-		}
-	default:
-		changeSet = options.GetChangeSet(cmd.Flags())
+	changeSet, err := appDeployChangeSet(cmd, options)
+	if err != nil {
+		return err
 	}
 	return deploy.New(changeSet).Run(cmd.Context(), params)
 }
+
+func appDeployChangeSet(cmd *cobra.Command, options deploy.Options) (*deploy.ChangeSet, error) {
+	if validation.ValidateYamlFilename(options.AppName) {
+		return options.GetChangeSetFromYaml(options.AppName)
+	}
+	return options.GetChangeSet(cmd.Flags()), nil
+}
+
+// appDeployJSON deploys the app writing logs of the deployment to stderr and prints an appDeployResult as json.
+func appDeployJSON(cmd *cobra.Command, options deploy.Options, params *deploy.Services, imageDigest imageDigestFn) error {
+	changeSet, err := appDeployChangeSet(cmd, options)
+	if err != nil {
+		return err
+	}
+	svc := *params
+	svc.Writer = cmd.ErrOrStderr()
+	if err := deploy.New(changeSet).Run(cmd.Context(), &svc); err != nil {
+		return err
+	}
+	result, err := newAppDeployResult(cmd.Context(), &svc, changeSet.AppName(), imageDigest)
+	if err != nil {
+		return err
+	}
+	return writeAppDeployResult(cmd.OutOrStdout(), result)
+}
+
+// newAppDeployResult describes the latest deployment of the app.
+// A digest that can't be resolved is reported to the services' writer, the deployment has succeeded anyway.
+func newAppDeployResult(ctx context.Context, svc *deploy.Services, appName string, imageDigest imageDigestFn) (appDeployResult, error) {
+	var app ketchv1.App
+	if err := svc.Client.Get(ctx, types.NamespacedName{Name: appName}, &app); err != nil {
+		return appDeployResult{}, fmt.Errorf("failed to get app: %w", err)
+	}
+	result := appDeployResult{App: app.Name, Namespace: app.Spec.Namespace, URLs: app.CNames()}
+	if len(app.Spec.Deployments) == 0 {
+		return result, nil
+	}
+	deployment := app.Spec.Deployments[len(app.Spec.Deployments)-1]
+	result.Version = int(deployment.Version)
+	result.Image = deployment.Image
+	digest, err := imageDigest(ctx, svc.KubeClient, deployment.Image, app.Spec.DockerRegistry.SecretName, app.Spec.Namespace)
+	if err != nil {
+		fmt.Fprintf(svc.Writer, "Warning: %v\n", err)
+		return result, nil
+	}
+	result.ImageDigest = digest
+	return result, nil
+}
+
+func writeAppDeployResult(out io.Writer, result appDeployResult) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(result)
+}
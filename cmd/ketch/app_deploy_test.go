@@ -3,6 +3,8 @@ package main
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path"
@@ -13,14 +15,20 @@ import (
 	registryv1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/stretchr/testify/require"
 	v1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/fake"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
 	"github.com/theketchio/ketch/internal/build"
 	"github.com/theketchio/ketch/internal/deploy"
+	"github.com/theketchio/ketch/internal/mocks"
 	"github.com/theketchio/ketch/internal/pack"
+	"github.com/theketchio/ketch/internal/templates"
 )
 
 type getterCreatorMockFn func(m *mockClient, obj runtime.Object) error
@@ -63,6 +71,8 @@ func (m *mockClient) Get(_ context.Context, _ client.ObjectKey, obj client.Objec
 	case *ketchv1.App:
 		*v = *m.app
 		return nil
+	case *corev1.Namespace:
+		return errors.NewNotFound(corev1.Resource("namespaces"), v.Name)
 	}
 	panic("unhandled type")
 }
@@ -136,15 +146,39 @@ worker: python app.py
 	packBuildMetadata string = "{\"bom\":null,\"buildpacks\":[{\"id\":\"heroku/python\",\"version\":\"0.3.1\"},{\"id\":\"heroku/procfile\",\"version\":\"0.6.2\"}],\"launcher\":{\"version\":\"0.11.3\",\"source\":{\"git\":{\"repository\":\"github.com/buildpacks/lifecycle\",\"commit\":\"aa4bbac\"}}},\"processes\":[{\"type\":\"web\",\"command\":\"python app.py\",\"args\":null,\"direct\":false,\"buildpackID\":\"heroku/procfile\"},{\"type\":\"worker\",\"command\":\"python app.py\",\"args\":null,\"direct\":false,\"buildpackID\":\"heroku/procfile\"},{\"type\":\"worker1\",\"command\":\"python app.py\",\"args\":null,\"direct\":false,\"buildpackID\":\"heroku/procfile\"}]}"
 )
 
+type staticTemplates struct{}
+
+func (staticTemplates) Get(string) (*templates.Templates, error) {
+	return &templates.NginxDefaultTemplates, nil
+}
+
 func TestNewCommand(t *testing.T) {
+	dryRunOut := &bytes.Buffer{}
+	dryRunClient := func() *mockClient {
+		m := newMockClient()
+		m.app.Name = "myapp"
+		m.app.Spec.Namespace = "default"
+		m.app.Spec.DeploymentsCount = 1
+		m.app.Spec.Ingress.Controller = ketchv1.IngressControllerSpec{IngressType: ketchv1.NginxIngressControllerType, ClassName: "nginx", ServiceEndpoint: "10.10.10.10"}
+		m.app.Spec.Deployments = []ketchv1.AppDeploymentSpec{
+			{
+				Image:           "shipa/go-sample:0.1",
+				Version:         1,
+				Processes:       []ketchv1.ProcessSpec{{Name: "web", Cmd: []string{"/bin/eatme"}}},
+				RoutingSettings: ketchv1.RoutingSettings{Weight: 100},
+			},
+		}
+		return m
+	}
 	tt := []struct {
-		name        string
-		params      *deploy.Services
-		arguments   []string
-		setup       func(t *testing.T)
-		userDefault string
-		validate    func(t *testing.T, m *mockClient)
-		wantError   bool
+		name                  string
+		params                *deploy.Services
+		arguments             []string
+		setup                 func(t *testing.T)
+		userDefault           string
+		defaultRegistrySecret string
+		validate              func(t *testing.T, m *mockClient)
+		wantError             bool
 	}{
 		{
 			name: "change builder from previous deploy",
@@ -176,6 +210,70 @@ func TestNewCommand(t *testing.T) {
 				Writer:         &bytes.Buffer{},
 			},
 		},
+		{
+			name: "deploy from git repository",
+			arguments: []string{
+				"myapp",
+				"https://github.com/shipa-corp/go-sample.git#main",
+				"--image", "shipa/go-sample:latest",
+			},
+			validate: func(t *testing.T, mock *mockClient) {
+				require.Len(t, mock.app.Spec.Deployments, 1)
+				require.Equal(t, "shipa/go-sample:latest", mock.app.Spec.Deployments[0].Image)
+			},
+			params: &deploy.Services{
+				Client:         newMockClient(),
+				KubeClient:     fake.NewSimpleClientset(),
+				Builder:        build.GetSourceHandler(&packMocker{}),
+				GetImageConfig: getImageConfig,
+				CloneSource: func(_ context.Context, _ deploy.CloneSourceRequest) (string, error) {
+					dir, err := ioutil.TempDir("", "source")
+					if err != nil {
+						return "", err
+					}
+					return dir, ioutil.WriteFile(path.Join(dir, "Procfile"), []byte(procfile), 0600)
+				},
+				Wait:   nil,
+				Writer: &bytes.Buffer{},
+			},
+		},
+		{
+			name: "push image and build cache of a source build to the cluster registry",
+			arguments: []string{
+				"myapp",
+				"src",
+			},
+			setup: func(t *testing.T) {
+				dir := t.TempDir()
+				require.Nil(t, os.Mkdir(path.Join(dir, "src"), 0700))
+				require.Nil(t, os.Chdir(dir))
+				require.Nil(t, ioutil.WriteFile("src/Procfile", []byte(procfile), 0600))
+			},
+			validate: func(t *testing.T, mock *mockClient) {
+				require.Len(t, mock.app.Spec.Deployments, 1)
+				require.Equal(t, "registry.example.com/apps/myapp", mock.app.Spec.Deployments[0].Image)
+			},
+			params: &deploy.Services{
+				Client: newMockClient(),
+				KubeClient: fake.NewSimpleClientset(&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Name: ketchv1.IngressConfigmapName, Namespace: ketchv1.IngressConfigmapNamespace},
+					Data: map[string]string{
+						"registry":       "registry.example.com/apps",
+						"registrySecret": "registry-credentials",
+						"buildCache":     "registry.example.com/cache",
+					},
+				}),
+				Builder: func(_ context.Context, req *build.CreateImageFromSourceRequest, _ ...build.Option) error {
+					if req.CacheImage != "registry.example.com/cache/myapp" {
+						return fmt.Errorf("unexpected cache image %q", req.CacheImage)
+					}
+					return nil
+				},
+				GetImageConfig: getImageConfig,
+				Wait:           nil,
+				Writer:         &bytes.Buffer{},
+			},
+		},
 		{
 			name: "use builder from previous deploy",
 			arguments: []string{
@@ -595,6 +693,144 @@ func TestNewCommand(t *testing.T) {
 				Writer:         &bytes.Buffer{},
 			},
 		},
+		{
+			name: "with environment variables merged from a file",
+			arguments: []string{
+				"myapp",
+				"src",
+				"--image", "shipa/go-sample:latest",
+				"--env-file", ".env",
+				"--env", "DEBUG=true",
+			},
+			setup: func(t *testing.T) {
+				dir := t.TempDir()
+				require.Nil(t, os.Mkdir(path.Join(dir, "src"), 0700))
+				require.Nil(t, os.Chdir(dir))
+				require.Nil(t, ioutil.WriteFile("src/Procfile", []byte(procfile), 0600))
+				require.Nil(t, ioutil.WriteFile(".env", []byte("# settings\nDB_HOST=db.local\nDEBUG=false\n"), 0600))
+			},
+			validate: func(t *testing.T, mock *mockClient) {
+				require.Equal(t, []ketchv1.Env{
+					{Name: "API_TOKEN", Value: "xxx", Sensitive: true},
+					{Name: "DB_HOST", Value: "db.local"},
+					{Name: "DEBUG", Value: "true"},
+				}, mock.app.Spec.Env)
+			},
+			params: &deploy.Services{
+				Client: func() *mockClient {
+					m := newMockClient()
+					m.app.Spec.Env = []ketchv1.Env{
+						{Name: "API_TOKEN", Value: "xxx", Sensitive: true},
+						{Name: "DB_HOST", Value: "old.db.local"},
+					}
+					return m
+				}(),
+				KubeClient:     fake.NewSimpleClientset(),
+				Builder:        build.GetSourceHandler(&packMocker{}),
+				GetImageConfig: getImageConfig,
+				Wait:           nil,
+				Writer:         &bytes.Buffer{},
+			},
+		},
+		{
+			name: "new app with a dedicated namespace",
+			arguments: []string{
+				"myapp",
+				"src",
+				"--image", "shipa/go-sample:latest",
+				"--namespace-strategy", "perApp",
+				"--namespace-quota", "pods=20",
+			},
+			setup: func(t *testing.T) {
+				dir := t.TempDir()
+				require.Nil(t, os.Mkdir(path.Join(dir, "src"), 0700))
+				require.Nil(t, os.Chdir(dir))
+				require.Nil(t, ioutil.WriteFile("src/Procfile", []byte(procfile), 0600))
+			},
+			validate: func(t *testing.T, mock *mockClient) {
+				require.Equal(t, "ketch-myapp", mock.app.Spec.Namespace)
+				require.Equal(t, ketchv1.PerAppNamespace, mock.app.Spec.NamespaceStrategy)
+				require.Equal(t, corev1.ResourceList{"pods": resource.MustParse("20")}, mock.app.Spec.NamespaceQuota)
+			},
+			params: &deploy.Services{
+				Client: func() *mockClient {
+					m := newMockClient()
+					m.get[1] = func(_ *mockClient, _ runtime.Object) error {
+						return errors.NewNotFound(v1.Resource(""), "")
+					}
+					return m
+				}(),
+				KubeClient:     fake.NewSimpleClientset(),
+				Builder:        build.GetSourceHandler(&packMocker{}),
+				GetImageConfig: getImageConfig,
+				Wait:           nil,
+				Writer:         &bytes.Buffer{},
+			},
+		},
+		{
+			name: "new app uses the default registry secret",
+			arguments: []string{
+				"myapp",
+				"src",
+				"--image", "shipa/go-sample:latest",
+				"--namespace", "ketch",
+			},
+			setup: func(t *testing.T) {
+				dir := t.TempDir()
+				require.Nil(t, os.Mkdir(path.Join(dir, "src"), 0700))
+				require.Nil(t, os.Chdir(dir))
+				require.Nil(t, ioutil.WriteFile("src/Procfile", []byte(procfile), 0600))
+			},
+			defaultRegistrySecret: "ketch-registry",
+			validate: func(t *testing.T, mock *mockClient) {
+				require.Equal(t, "ketch-registry", mock.app.Spec.DockerRegistry.SecretName)
+			},
+			params: &deploy.Services{
+				Client: func() *mockClient {
+					m := newMockClient()
+					m.get[1] = func(_ *mockClient, _ runtime.Object) error {
+						return errors.NewNotFound(v1.Resource(""), "")
+					}
+					return m
+				}(),
+				KubeClient:     fake.NewSimpleClientset(),
+				Builder:        build.GetSourceHandler(&packMocker{}),
+				GetImageConfig: getImageConfig,
+				Wait:           nil,
+				Writer:         &bytes.Buffer{},
+			},
+		},
+		{
+			name: "app opts out of https forced by the ingress controller",
+			arguments: []string{
+				"myapp",
+				"src",
+				"--image", "shipa/go-sample:latest",
+				"--force-https=false",
+			},
+			setup: func(t *testing.T) {
+				dir := t.TempDir()
+				require.Nil(t, os.Mkdir(path.Join(dir, "src"), 0700))
+				require.Nil(t, os.Chdir(dir))
+				require.Nil(t, ioutil.WriteFile("src/Procfile", []byte(procfile), 0600))
+			},
+			validate: func(t *testing.T, mock *mockClient) {
+				require.NotNil(t, mock.app.Spec.Ingress.ForceHTTPS)
+				require.False(t, mock.app.Spec.Ingress.HTTPSForced())
+			},
+			params: &deploy.Services{
+				Client: func() *mockClient {
+					m := newMockClient()
+					m.app.Spec.Ingress.Controller.ForceHTTPS = true
+					return m
+				}(),
+				KubeClient:     fake.NewSimpleClientset(),
+				Builder:        build.GetSourceHandler(&packMocker{}),
+				GetImageConfig: getImageConfig,
+				Wait:           nil,
+				Writer:         &bytes.Buffer{},
+			},
+		},
 		{
 			name:      "with messed up environment variables",
 			wantError: true,
@@ -928,6 +1164,46 @@ func TestNewCommand(t *testing.T) {
 				Writer:         &bytes.Buffer{},
 			},
 		},
+		{
+			name: "dry run prints a diff and keeps the app",
+			arguments: []string{
+				"myapp",
+				"--image", "shipa/go-sample:0.2",
+				"--dry-run",
+				"--diff",
+			},
+			validate: func(t *testing.T, mock *mockClient) {
+				require.Len(t, mock.app.Spec.Deployments, 1)
+				require.Equal(t, "shipa/go-sample:0.1", mock.app.Spec.Deployments[0].Image)
+				require.Equal(t, 0, mock.updateCounter)
+				require.Contains(t, dryRunOut.String(), "Deployment myapp-web-1 (removed)")
+				require.Contains(t, dryRunOut.String(), "Deployment myapp-web-2 (added)")
+				require.Contains(t, dryRunOut.String(), "+          image: shipa/go-sample:0.2")
+			},
+			params: &deploy.Services{
+				Client:         dryRunClient(),
+				KubeClient:     fake.NewSimpleClientset(),
+				GetImageConfig: getImageConfig,
+				Templates:      staticTemplates{},
+				Writer:         dryRunOut,
+			},
+		},
+		{
+			name: "diff requires dry run",
+			arguments: []string{
+				"myapp",
+				"--image", "shipa/go-sample:0.2",
+				"--diff",
+			},
+			params: &deploy.Services{
+				Client:         dryRunClient(),
+				KubeClient:     fake.NewSimpleClientset(),
+				GetImageConfig: getImageConfig,
+				Templates:      staticTemplates{},
+				Writer:         &bytes.Buffer{},
+			},
+			wantError: true,
+		},
 	}
 
 	for _, tc := range tt {
@@ -942,7 +1218,7 @@ func TestNewCommand(t *testing.T) {
 			if tc.setup != nil {
 				tc.setup(t)
 			}
-			cmd := newAppDeployCmd(nil, tc.params, tc.userDefault)
+			cmd := newAppDeployCmd(nil, tc.params, tc.userDefault, tc.defaultRegistrySecret)
 			cmd.SetArgs(tc.arguments)
 			err = cmd.Execute()
 			if tc.wantError {
@@ -960,3 +1236,69 @@ func TestNewCommand(t *testing.T) {
 		})
 	}
 }
+
+func TestNewAppDeployResult(t *testing.T) {
+	app := &ketchv1.App{
+		ObjectMeta: metav1.ObjectMeta{Name: "go-app"},
+		Spec: ketchv1.AppSpec{
+			Namespace: "apps",
+			Deployments: []ketchv1.AppDeploymentSpec{
+				{Version: 1, Image: "shipa/go-sample:0.1"},
+				{Version: 2, Image: "shipa/go-sample:0.2"},
+			},
+			Ingress: ketchv1.IngressSpec{Cnames: ketchv1.CnameList{{Name: "go.example.com", Secure: true}}},
+		},
+	}
+	tests := []struct {
+		name        string
+		imageDigest imageDigestFn
+		wantResult  appDeployResult
+		wantOut     string
+	}{
+		{
+			name: "with digest",
+			imageDigest: func(ctx context.Context, kubeClient kubernetes.Interface, image, secretName, secretNamespace string) (string, error) {
+				return "shipa/go-sample@sha256:8c3d", nil
+			},
+			wantResult: appDeployResult{
+				App:         "go-app",
+				Namespace:   "apps",
+				Version:     2,
+				Image:       "shipa/go-sample:0.2",
+				ImageDigest: "shipa/go-sample@sha256:8c3d",
+				URLs:        []string{"https://go.example.com"},
+			},
+		},
+		{
+			name: "registry unreachable",
+			imageDigest: func(ctx context.Context, kubeClient kubernetes.Interface, image, secretName, secretNamespace string) (string, error) {
+				return "", fmt.Errorf("could not get digest of image %q", image)
+			},
+			wantResult: appDeployResult{
+				App:       "go-app",
+				Namespace: "apps",
+				Version:   2,
+				Image:     "shipa/go-sample:0.2",
+				URLs:      []string{"https://go.example.com"},
+			},
+			wantOut: "Warning: could not get digest of image \"shipa/go-sample:0.2\"\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &mocks.Configuration{CtrlClientObjects: []runtime.Object{app}}
+			out := &bytes.Buffer{}
+			svc := &deploy.Services{Client: cfg.Client(), KubeClient: cfg.KubernetesClient(), Writer: out}
+			result, err := newAppDeployResult(context.Background(), svc, "go-app", tt.imageDigest)
+			require.Nil(t, err)
+			require.Equal(t, tt.wantResult, result)
+			require.Equal(t, tt.wantOut, out.String())
+
+			out.Reset()
+			require.Nil(t, writeAppDeployResult(out, result))
+			var written appDeployResult
+			require.Nil(t, json.Unmarshal(out.Bytes(), &written))
+			require.Equal(t, result, written)
+		})
+	}
+}
@@ -15,12 +15,21 @@ import (
 
 const appExportHelp = `
 Export an application as a yaml file.
+
+Export the helm chart of an application with --chart. The chart is written to <dir>/<app name>
+and replaces a chart exported there before, so tools like ArgoCD or Flux can install it from a git repository:
+  ketch app export <app name> --chart charts
+
+With --git-repo the chart is committed to <dir>/<app name> of the repository and pushed.
+A branch can be added to the URL after "#". Credentials are read from a Secret in the app's namespace
+passed with --git-secret, the secret must contain either "username" and "password" keys or a "ssh-privatekey" key:
+  ketch app export <app name> --chart charts --git-repo https://github.com/org/deployments.git#main --git-secret deployments
 `
 
 type appExportFn func(ctx context.Context, cfg config, options appExportOptions, out io.Writer) error
 
-func newAppExportCmd(cfg config, appExport appExportFn, out io.Writer) *cobra.Command {
-	options := appExportOptions{}
+func newAppExportCmd(cfg config, redact redactor, appExport appExportFn, out io.Writer) *cobra.Command {
+	options := appExportOptions{redactor: redact}
 	cmd := &cobra.Command{
 		Use:   "export APPNAME",
 		Short: "Export an app's yaml",
@@ -35,12 +44,21 @@ func newAppExportCmd(cfg config, appExport appExportFn, out io.Writer) *cobra.Co
 		},
 	}
 	cmd.Flags().StringVarP(&options.filename, "file", "f", "", "filename for app export")
+	cmd.Flags().BoolVar(&options.showSecrets, showSecretsFlag, false, showSecretsUsage)
+	cmd.Flags().StringVar(&options.chartDir, "chart", "", "Directory to export the app's helm chart to instead of the app's yaml.")
+	cmd.Flags().StringVar(&options.gitRepo, "git-repo", "", "URL of a git repository to commit and push the exported chart to, the chart directory is relative to the repository's root.")
+	cmd.Flags().StringVar(&options.gitSecret, "git-secret", "", "A name of a Secret with credentials to push to the git repository. This secret must be created in the app's namespace.")
 	return cmd
 }
 
 type appExportOptions struct {
-	appName  string
-	filename string
+	appName     string
+	filename    string
+	showSecrets bool
+	redactor    redactor
+	chartDir    string
+	gitRepo     string
+	gitSecret   string
 }
 
 func exportApp(ctx context.Context, cfg config, options appExportOptions, out io.Writer) error {
@@ -48,6 +66,15 @@ func exportApp(ctx context.Context, cfg config, options appExportOptions, out io
 	if err := cfg.Client().Get(ctx, types.NamespacedName{Name: options.appName}, &app); err != nil {
 		return fmt.Errorf("failed to get app: %w", err)
 	}
+	if !options.showSecrets {
+		options.redactor.app(&app)
+	}
+	if options.chartDir != "" {
+		return exportAppChart(ctx, cfg, app, options, out)
+	}
+	if options.gitRepo != "" {
+		return fmt.Errorf("--git-repo requires --chart")
+	}
 	application := deploy.GetApplicationFromKetchApp(app)
 	return output.WriteToFileOrOut(application, out, options.filename)
 }
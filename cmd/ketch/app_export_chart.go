@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/chart"
+	"github.com/theketchio/ketch/internal/deploy"
+	"github.com/theketchio/ketch/internal/utils"
+)
+
+// exportAppChart writes the app's helm chart to <chart dir>/<app name>,
+// or commits it to the same path of a git repository and pushes it if --git-repo is set.
+func exportAppChart(ctx context.Context, cfg config, app ketchv1.App, options appExportOptions, out io.Writer) error {
+	tpls, err := appChartTemplates(ctx, cfg, &app)
+	if err != nil {
+		return err
+	}
+	envSets, err := ketchv1.GetAppEnvSets(ctx, cfg.Client(), &app)
+	if err != nil {
+		return err
+	}
+	bindingSecrets, err := ketchv1.GetAppBindingSecrets(ctx, cfg.Client(), &app)
+	if err != nil {
+		return err
+	}
+	appChrt, err := chart.New(&app, chart.WithExposedPorts(app.ExposedPorts()), chart.WithTemplates(*tpls),
+		chart.WithEnvSets(envSets), chart.WithServiceBindingSecrets(bindingSecrets))
+	if err != nil {
+		return fmt.Errorf("failed to create the app's chart: %w", err)
+	}
+	chartConfig := chart.NewChartConfig(app)
+	if options.gitRepo == "" {
+		dir := filepath.Join(options.chartDir, app.Name)
+		if err := appChrt.WriteToDirectory(dir, chartConfig); err != nil {
+			return fmt.Errorf("failed to write the app's chart: %w", err)
+		}
+		fmt.Fprintf(out, "Chart of app %s is written to %s\n", app.Name, dir)
+		return nil
+	}
+
+	url, branch := options.gitRepo, ""
+	if i := strings.LastIndex(url, "#"); i >= 0 {
+		url, branch = url[:i], url[i+1:]
+	}
+	auth, err := deploy.GitAuth(ctx, cfg.KubernetesClient(), options.gitSecret, app.Spec.Namespace)
+	if err != nil {
+		return err
+	}
+	repoDir, err := ioutil.TempDir("", "ketch-gitops-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(repoDir)
+	cloneOptions := &git.CloneOptions{URL: url, Auth: auth}
+	if branch != "" {
+		cloneOptions.ReferenceName = plumbing.NewBranchReferenceName(branch)
+		cloneOptions.SingleBranch = true
+	}
+	repo, err := git.PlainCloneContext(ctx, repoDir, false, cloneOptions)
+	if err != nil {
+		return fmt.Errorf("failed to clone %q: %w", url, err)
+	}
+	chartPath := filepath.Join(options.chartDir, app.Name)
+	if err := appChrt.WriteToDirectory(filepath.Join(repoDir, chartPath), chartConfig); err != nil {
+		return fmt.Errorf("failed to write the app's chart: %w", err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+	// new files have to be added, files removed from the chart are staged by the commit.
+	if err := worktree.AddGlob(filepath.ToSlash(chartPath) + "/*"); err != nil {
+		return fmt.Errorf("failed to add the chart to the git repository: %w", err)
+	}
+	status, err := worktree.Status()
+	if err != nil {
+		return err
+	}
+	if status.IsClean() {
+		fmt.Fprintf(out, "Chart of app %s in %s is up to date\n", app.Name, url)
+		return nil
+	}
+	_, err = worktree.Commit(fmt.Sprintf("Update chart of app %s", app.Name), &git.CommitOptions{
+		All: true,
+		Author: &object.Signature{
+			Name: utils.CurrentUser(),
+			When: time.Now(),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to commit the chart: %w", err)
+	}
+	if err := repo.PushContext(ctx, &git.PushOptions{Auth: auth}); err != nil {
+		return fmt.Errorf("failed to push the chart to %q: %w", url, err)
+	}
+	fmt.Fprintf(out, "Chart of app %s is pushed to %s/%s\n", app.Name, url, filepath.ToSlash(chartPath))
+	return nil
+}
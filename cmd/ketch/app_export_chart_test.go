@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/src-d/go-git.v4"
+	gitconfig "gopkg.in/src-d/go-git.v4/config"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/mocks"
+	"github.com/theketchio/ketch/internal/templates"
+)
+
+func newChartExportConfig() *mocks.Configuration {
+	app := &ketchv1.App{
+		ObjectMeta: metav1.ObjectMeta{Name: "hello"},
+		Spec: ketchv1.AppSpec{
+			Namespace:        "default",
+			DeploymentsCount: 1,
+			Deployments: []ketchv1.AppDeploymentSpec{
+				{
+					Image:           "nginx:latest",
+					Version:         1,
+					Processes:       []ketchv1.ProcessSpec{{Name: "web", Cmd: []string{"nginx"}}},
+					ExposedPorts:    []ketchv1.ExposedPort{{Port: 80, Protocol: "TCP"}},
+					RoutingSettings: ketchv1.RoutingSettings{Weight: 100},
+				},
+			},
+		},
+	}
+	ingressConfigmap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: ketchv1.IngressConfigmapName, Namespace: ketchv1.IngressConfigmapNamespace},
+		Data:       map[string]string{"type": "nginx", "className": "nginx", "serviceEndpoint": "10.10.10.10"},
+	}
+	return &mocks.Configuration{
+		CtrlClientObjects: []runtime.Object{app, ingressConfigmap},
+		StorageInstance: &mockStorage{
+			OnGet: func(name string) (*templates.Templates, error) {
+				return &templates.NginxDefaultTemplates, nil
+			},
+		},
+	}
+}
+
+func Test_exportAppChart(t *testing.T) {
+	dir := t.TempDir()
+	out := &bytes.Buffer{}
+	options := appExportOptions{appName: "hello", chartDir: dir, redactor: newRedactor(nil)}
+	err := exportApp(context.Background(), newChartExportConfig(), options, out)
+	require.Nil(t, err)
+	require.Equal(t, "Chart of app hello is written to "+filepath.Join(dir, "hello")+"\n", out.String())
+	for _, name := range []string{"Chart.yaml", "values.yaml", "templates/deployment.yaml"} {
+		_, err := os.Stat(filepath.Join(dir, "hello", name))
+		require.Nil(t, err, name)
+	}
+
+	err = exportApp(context.Background(), newChartExportConfig(), appExportOptions{appName: "hello", gitRepo: "https://example.com/repo.git"}, out)
+	require.EqualError(t, err, "--git-repo requires --chart")
+}
+
+func Test_exportAppChart_gitRepo(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is required to push to a local repository")
+	}
+	remote := filepath.Join(t.TempDir(), "deployments.git")
+	_, err := git.PlainInit(remote, true)
+	require.Nil(t, err)
+
+	// the remote repository needs a commit to be cloned.
+	seed := t.TempDir()
+	repo, err := git.PlainInit(seed, false)
+	require.Nil(t, err)
+	require.Nil(t, os.WriteFile(filepath.Join(seed, "README.md"), []byte("deployments"), 0600))
+	worktree, err := repo.Worktree()
+	require.Nil(t, err)
+	_, err = worktree.Add("README.md")
+	require.Nil(t, err)
+	_, err = worktree.Commit("init", &git.CommitOptions{Author: &object.Signature{Name: "test", When: time.Now()}})
+	require.Nil(t, err)
+	_, err = repo.CreateRemote(&gitconfig.RemoteConfig{Name: "origin", URLs: []string{remote}})
+	require.Nil(t, err)
+	require.Nil(t, repo.Push(&git.PushOptions{}))
+
+	options := appExportOptions{appName: "hello", chartDir: "charts", gitRepo: remote + "#master", redactor: newRedactor(nil)}
+	out := &bytes.Buffer{}
+	require.Nil(t, exportApp(context.Background(), newChartExportConfig(), options, out))
+	require.Equal(t, "Chart of app hello is pushed to "+remote+"/charts/hello\n", out.String())
+
+	clone := t.TempDir()
+	cloned, err := git.PlainClone(clone, false, &git.CloneOptions{URL: remote})
+	require.Nil(t, err)
+	_, err = os.Stat(filepath.Join(clone, "charts", "hello", "templates", "deployment.yaml"))
+	require.Nil(t, err)
+	head, err := cloned.Head()
+	require.Nil(t, err)
+	commit, err := cloned.CommitObject(head.Hash())
+	require.Nil(t, err)
+	require.Equal(t, "Update chart of app hello", commit.Message)
+
+	out.Reset()
+	require.Nil(t, exportApp(context.Background(), newChartExportConfig(), options, out))
+	require.Equal(t, "Chart of app hello in "+remote+" is up to date\n", out.String())
+}
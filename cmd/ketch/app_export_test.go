@@ -44,7 +44,7 @@ func Test_newAppExportCmd(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			cmd := newAppExportCmd(nil, tt.appExport, &bytes.Buffer{})
+			cmd := newAppExportCmd(nil, newRedactor(nil), tt.appExport, &bytes.Buffer{})
 			cmd.SetArgs(tt.args)
 			err := cmd.Execute()
 			if tt.wantErr {
@@ -84,6 +84,13 @@ func Test_appExport(t *testing.T) {
 		},
 	}
 
+	withEnvs := dashboard.DeepCopy()
+	withEnvs.Spec.Env = []ketchv1.Env{
+		{Name: "GITHUB_TOKEN", Value: "ghp_123"},
+		{Name: "DATABASE_URL", Value: "postgres://user:pass@db", Sensitive: true},
+		{Name: "DEBUG", Value: "true"},
+	}
+
 	tests := []struct {
 		name    string
 		cfg     config
@@ -130,6 +137,45 @@ version: v1
 namespace: mynamespace
 type: Application
 version: v1
+`,
+		},
+		{
+			name: "sensitive env variables are redacted",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{withEnvs},
+			},
+			options: appExportOptions{
+				appName:  "dashboard",
+				redactor: newRedactor(nil),
+			},
+			wantOut: `environment:
+- GITHUB_TOKEN=<redacted>
+- DATABASE_URL=<redacted>
+- DEBUG=true
+name: dashboard
+namespace: mynamespace
+type: Application
+version: v1
+`,
+		},
+		{
+			name: "show secrets",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{withEnvs},
+			},
+			options: appExportOptions{
+				appName:     "dashboard",
+				redactor:    newRedactor(nil),
+				showSecrets: true,
+			},
+			wantOut: `environment:
+- GITHUB_TOKEN=ghp_123
+- DATABASE_URL=postgres://user:pass@db
+- DEBUG=true
+name: dashboard
+namespace: mynamespace
+type: Application
+version: v1
 `,
 		},
 		{
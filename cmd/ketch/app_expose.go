@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+)
+
+const appExposeHelp = `
+Set how clients reach an application:
+  public    the application's cnames are served by the ingress controller, the default,
+  internal  the application gets services but no ingress objects, it's reached only inside the cluster,
+            "ketch app info" shows the in-cluster DNS names of its services.
+Use --load-balancer-annotation to turn the service of an internal application into an internal load balancer of the cloud,
+e.g. --load-balancer-annotation networking.gke.io/load-balancer-type=Internal.
+`
+
+func newAppExposeCmd(cfg config, out io.Writer) *cobra.Command {
+	options := appExposeOptions{}
+	cmd := &cobra.Command{
+		Use:   "expose APPNAME MODE",
+		Args:  cobra.ExactValidArgs(2),
+		Short: "Set how clients reach an application, publicly or only inside the cluster.",
+		Long:  appExposeHelp,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			options.appName, options.mode = args[0], ketchv1.ExposeMode(args[1])
+			return appExpose(cmd.Context(), cfg, options, out)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) == 1 {
+				return []string{string(ketchv1.ExposePublic), string(ketchv1.ExposeInternal)}, cobra.ShellCompDirectiveNoFileComp
+			}
+			if len(args) > 1 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return autoCompleteAppNames(cfg, toComplete)
+		},
+	}
+	cmd.Flags().StringToStringVar(&options.loadBalancerAnnotations, "load-balancer-annotation", nil, "An annotation of an internal load balancer of an internal app, e.g. service.beta.kubernetes.io/aws-load-balancer-internal=true")
+	return cmd
+}
+
+type appExposeOptions struct {
+	appName                 string
+	mode                    ketchv1.ExposeMode
+	loadBalancerAnnotations map[string]string
+}
+
+func appExpose(ctx context.Context, cfg config, options appExposeOptions, out io.Writer) error {
+	switch options.mode {
+	case ketchv1.ExposePublic:
+		if len(options.loadBalancerAnnotations) > 0 {
+			return fmt.Errorf("%w: load balancer annotations require the internal mode", ErrInvalidExposeMode)
+		}
+	case ketchv1.ExposeInternal:
+	default:
+		return fmt.Errorf("%w: %q, use %q or %q", ErrInvalidExposeMode, options.mode, ketchv1.ExposePublic, ketchv1.ExposeInternal)
+	}
+	app := ketchv1.App{}
+	if err := cfg.Client().Get(ctx, types.NamespacedName{Name: options.appName}, &app); err != nil {
+		return fmt.Errorf("failed to get the app: %w", err)
+	}
+	app.Spec.Expose = options.mode
+	app.Spec.InternalLoadBalancerAnnotations = options.loadBalancerAnnotations
+	if err := cfg.Client().Update(ctx, &app); err != nil {
+		return fmt.Errorf("failed to update the app: %w", err)
+	}
+	fmt.Fprintf(out, "%s exposed as %s.\n", app.Name, options.mode)
+	return nil
+}
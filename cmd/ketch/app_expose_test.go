@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/mocks"
+)
+
+func TestAppExpose(t *testing.T) {
+	lbAnnotations := map[string]string{"networking.gke.io/load-balancer-type": "Internal"}
+	internalApp := newAuthApp(nil)
+	internalApp.Spec.Expose = ketchv1.ExposeInternal
+	internalApp.Spec.InternalLoadBalancerAnnotations = lbAnnotations
+	tests := []struct {
+		name              string
+		app               *ketchv1.App
+		options           appExposeOptions
+		wantExpose        ketchv1.ExposeMode
+		wantLBAnnotations map[string]string
+		wantOut           string
+		wantErr           string
+	}{
+		{
+			name:              "internal with a load balancer",
+			app:               newAuthApp(nil),
+			options:           appExposeOptions{appName: "go-app", mode: ketchv1.ExposeInternal, loadBalancerAnnotations: lbAnnotations},
+			wantExpose:        ketchv1.ExposeInternal,
+			wantLBAnnotations: lbAnnotations,
+			wantOut:           "go-app exposed as internal.\n",
+		},
+		{
+			name:       "public again",
+			app:        internalApp,
+			options:    appExposeOptions{appName: "go-app", mode: ketchv1.ExposePublic},
+			wantExpose: ketchv1.ExposePublic,
+			wantOut:    "go-app exposed as public.\n",
+		},
+		{
+			name:    "public with a load balancer",
+			app:     newAuthApp(nil),
+			options: appExposeOptions{appName: "go-app", mode: ketchv1.ExposePublic, loadBalancerAnnotations: lbAnnotations},
+			wantErr: "invalid expose mode: load balancer annotations require the internal mode",
+		},
+		{
+			name:    "unknown mode",
+			app:     newAuthApp(nil),
+			options: appExposeOptions{appName: "go-app", mode: "private"},
+			wantErr: `invalid expose mode: "private", use "public" or "internal"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &mocks.Configuration{CtrlClientObjects: []runtime.Object{tt.app}}
+			out := &bytes.Buffer{}
+			err := appExpose(context.Background(), cfg, tt.options, out)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.wantOut, out.String())
+			app := ketchv1.App{}
+			require.Nil(t, cfg.Client().Get(context.Background(), types.NamespacedName{Name: "go-app"}, &app))
+			require.Equal(t, tt.wantExpose, app.Spec.Expose)
+			require.Equal(t, tt.wantLBAnnotations, app.Spec.InternalLoadBalancerAnnotations)
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/theketchio/ketch/cmd/ketch/output"
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+)
+
+const appHistoryHelp = `
+Show the last deployments of an application, the current deployment is marked with "*".
+Use "ketch app rollback" to deploy one of them again.
+`
+
+type appHistoryOutput struct {
+	Version    string `json:"version" yaml:"version"`
+	Image      string `json:"image" yaml:"image"`
+	KetchYaml  string `json:"ketchYaml" yaml:"ketch.yaml"`
+	DeployedAt string `json:"deployedAt" yaml:"deployed at"`
+	DeployedBy string `json:"deployedBy" yaml:"deployed by"`
+}
+
+func newAppHistoryCmd(cfg config, out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "history APPNAME",
+		Short: "Show the deployment history of an application.",
+		Long:  appHistoryHelp,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return appHistory(cmd.Context(), cfg, args[0], out)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return autoCompleteAppNames(cfg, toComplete)
+		},
+	}
+	return cmd
+}
+
+func appHistory(ctx context.Context, cfg config, appName string, out io.Writer) error {
+	app := ketchv1.App{}
+	if err := cfg.Client().Get(ctx, types.NamespacedName{Name: appName}, &app); err != nil {
+		return fmt.Errorf("failed to get app: %w", err)
+	}
+	return output.Write(generateAppHistoryOutput(app), out, "column")
+}
+
+func generateAppHistoryOutput(app ketchv1.App) []appHistoryOutput {
+	current := map[ketchv1.DeploymentVersion]bool{}
+	for _, deployment := range app.Spec.Deployments {
+		current[deployment.Version] = true
+	}
+	history := make([]appHistoryOutput, 0, len(app.Status.DeploymentHistory))
+	for _, record := range app.Status.DeploymentHistory {
+		version := record.Version.String()
+		if current[record.Version] {
+			version += "*"
+		}
+		ketchYaml := record.KetchYamlHash
+		if len(ketchYaml) > 12 {
+			ketchYaml = ketchYaml[:12]
+		}
+		history = append(history, appHistoryOutput{
+			Version:    version,
+			Image:      record.Image,
+			KetchYaml:  ketchYaml,
+			DeployedAt: record.DeployedAt.UTC().Format(time.RFC3339),
+			DeployedBy: record.DeployedBy,
+		})
+	}
+	return history
+}
@@ -0,0 +1,389 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/chart"
+	"github.com/theketchio/ketch/internal/utils"
+)
+
+const appImportHelp = `
+Import existing Deployments of a namespace as an application.
+
+Every Deployment becomes a process of the app, named after the Deployment unless a process name is given
+as PROCESS=DEPLOYMENT. Commands, environment, replicas and resources of containers become processes,
+ports of Services selecting the Deployments' pods become ports of the processes and hosts of Ingresses routing
+to the "web" process, or the first process by name, become cnames of the app:
+  ketch app import myapp web=frontend worker=queue-worker -n production
+
+Ketch deploys the app next to the imported objects, they are labeled with theketch.io/imported-by=<app name>
+and can be removed once the app is running:
+  kubectl delete deployment,service,ingress -n production -l theketch.io/imported-by=myapp
+
+Print the App without creating it with --dry-run.
+`
+
+type appImportFn func(ctx context.Context, cfg config, options appImportOptions, out io.Writer) error
+
+func newAppImportCmd(cfg config, appImport appImportFn, out io.Writer) *cobra.Command {
+	options := appImportOptions{}
+	cmd := &cobra.Command{
+		Use:   "import APPNAME [PROCESS=]DEPLOYMENT...",
+		Short: "Import existing Deployments as an app",
+		Long:  appImportHelp,
+		Args:  cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			options.appName = args[0]
+			options.deployments = args[1:]
+			return appImport(cmd.Context(), cfg, options, out)
+		},
+	}
+	cmd.Flags().StringVarP(&options.namespace, "namespace", "n", "default", "Namespace of the Deployments, the app is deployed to it.")
+	cmd.Flags().BoolVar(&options.dryRun, "dry-run", false, "Print the App instead of creating it.")
+	return cmd
+}
+
+type appImportOptions struct {
+	appName     string
+	namespace   string
+	deployments []string
+	dryRun      bool
+}
+
+// importedProcess is a Deployment imported as a process of an app.
+type importedProcess struct {
+	name       string
+	deployment *appsv1.Deployment
+	services   []corev1.Service
+}
+
+func appImport(ctx context.Context, cfg config, options appImportOptions, out io.Writer) error {
+	if errs := validation.IsDNS1123Label(options.appName); len(errs) > 0 {
+		return fmt.Errorf("invalid app name %q: %s", options.appName, strings.Join(errs, ", "))
+	}
+	err := cfg.Client().Get(ctx, types.NamespacedName{Name: options.appName}, &ketchv1.App{})
+	if err == nil {
+		return fmt.Errorf("app %q already exists", options.appName)
+	}
+	if !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to get app: %w", err)
+	}
+
+	kubeClient := cfg.KubernetesClient()
+	processes, err := getImportedProcesses(ctx, kubeClient, options)
+	if err != nil {
+		return err
+	}
+	ingresses, err := kubeClient.NetworkingV1().Ingresses(options.namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list ingresses: %w", err)
+	}
+	app, usedIngresses, notes, err := newImportedApp(options, processes, ingresses.Items)
+	if err != nil {
+		return err
+	}
+
+	if options.dryRun {
+		app.TypeMeta = metav1.TypeMeta{APIVersion: ketchv1.Group + "/v1beta1", Kind: "App"}
+		content, err := yaml.Marshal(app)
+		if err != nil {
+			return err
+		}
+		_, err = out.Write(content)
+		return err
+	}
+
+	if err := cfg.Client().Create(ctx, app); err != nil {
+		return fmt.Errorf("failed to create app: %w", err)
+	}
+	if err := labelImportedObjects(ctx, kubeClient, options, processes, usedIngresses); err != nil {
+		return err
+	}
+	for _, note := range notes {
+		fmt.Fprintln(out, note)
+	}
+	fmt.Fprintf(out, "Successfully imported %s, remove the imported objects once the app is running:\n", options.appName)
+	fmt.Fprintf(out, "  kubectl delete deployment,service,ingress -n %s -l %s=%s\n", options.namespace, utils.KetchImportedByLabel, options.appName)
+	return nil
+}
+
+// getImportedProcesses gets the Deployments of the options and the Services selecting their pods.
+func getImportedProcesses(ctx context.Context, kubeClient kubernetes.Interface, options appImportOptions) ([]importedProcess, error) {
+	services, err := kubeClient.CoreV1().Services(options.namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services: %w", err)
+	}
+	processes := make([]importedProcess, 0, len(options.deployments))
+	seen := map[string]bool{}
+	for _, arg := range options.deployments {
+		name, deploymentName := arg, arg
+		if parts := strings.SplitN(arg, "=", 2); len(parts) == 2 {
+			name, deploymentName = parts[0], parts[1]
+		}
+		if errs := validation.IsDNS1123Label(name); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid process name %q: %s", name, strings.Join(errs, ", "))
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("process %q is imported more than once", name)
+		}
+		seen[name] = true
+		deployment, err := kubeClient.AppsV1().Deployments(options.namespace).Get(ctx, deploymentName, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get deployment: %w", err)
+		}
+		process := importedProcess{name: name, deployment: deployment}
+		podLabels := labels.Set(deployment.Spec.Template.Labels)
+		for _, service := range services.Items {
+			if len(service.Spec.Selector) > 0 && labels.SelectorFromSet(service.Spec.Selector).Matches(podLabels) {
+				process.services = append(process.services, service)
+			}
+		}
+		processes = append(processes, process)
+	}
+	return processes, nil
+}
+
+// newImportedApp synthesizes an App from the processes and the ingresses routing to the services of its routable process.
+// It returns the App, the ingresses it got cnames from and notes about parts of the objects ketch doesn't import.
+func newImportedApp(options appImportOptions, processes []importedProcess, ingresses []networkingv1.Ingress) (*ketchv1.App, []networkingv1.Ingress, []string, error) {
+	var notes []string
+	names := make([]string, 0, len(processes))
+	byName := make(map[string]importedProcess, len(processes))
+	for _, process := range processes {
+		names = append(names, process.name)
+		byName[process.name] = process
+	}
+	sort.Strings(names)
+	specs := make([]ketchv1.ProcessSpec, 0, len(processes))
+	for _, name := range names {
+		specs = append(specs, ketchv1.ProcessSpec{Name: name})
+	}
+	procfile, err := chart.ProcfileFromProcesses(specs)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	routable := byName[procfile.RoutableProcessName]
+
+	deployment := ketchv1.AppDeploymentSpec{
+		Image:            importedContainer(routable.deployment).Image,
+		Version:          1,
+		ImagePullSecrets: routable.deployment.Spec.Template.Spec.ImagePullSecrets,
+		RoutingSettings:  ketchv1.RoutingSettings{Weight: 100},
+	}
+	var processConfigs map[string]ketchv1.KetchYamlProcessConfig
+	for i, name := range names {
+		process := byName[name]
+		if n := len(process.deployment.Spec.Template.Spec.Containers); n != 1 {
+			return nil, nil, nil, fmt.Errorf("deployment %q has %d containers, ketch runs one container per process", process.deployment.Name, n)
+		}
+		container := importedContainer(process.deployment)
+		if len(container.Command) == 0 && len(container.Args) > 0 {
+			return nil, nil, nil, fmt.Errorf("container of deployment %q sets args without a command, ketch can only override the command of an image", process.deployment.Name)
+		}
+		spec := ketchv1.ProcessSpec{
+			Name: name,
+			Cmd:  append(append([]string{}, container.Command...), container.Args...),
+		}
+		if replicas := process.deployment.Spec.Replicas; replicas != nil {
+			units := int(*replicas)
+			spec.Units = &units
+		}
+		if container.Image != deployment.Image {
+			spec.Image = container.Image
+		}
+		for _, env := range container.Env {
+			if env.ValueFrom != nil {
+				notes = append(notes, fmt.Sprintf("variable %s of deployment %s isn't a plain value, it isn't imported", env.Name, process.deployment.Name))
+				continue
+			}
+			spec.Env = append(spec.Env, ketchv1.Env{Name: env.Name, Value: env.Value})
+		}
+		if len(container.Resources.Limits) > 0 || len(container.Resources.Requests) > 0 {
+			resources := container.Resources
+			spec.Resources = &resources
+		}
+		if len(container.VolumeMounts) > 0 {
+			notes = append(notes, fmt.Sprintf("volumes of deployment %s aren't imported, add them to ketch.yaml", process.deployment.Name))
+		}
+		specs[i] = spec
+
+		ports := importedPorts(container, process.services)
+		if len(ports) == 0 {
+			continue
+		}
+		if processConfigs == nil {
+			processConfigs = map[string]ketchv1.KetchYamlProcessConfig{}
+		}
+		processConfigs[name] = ketchv1.KetchYamlProcessConfig{Ports: ports}
+	}
+	deployment.Processes = specs
+	if processConfigs != nil {
+		deployment.KetchYaml = &ketchv1.KetchYamlData{
+			Kubernetes: &ketchv1.KetchYamlKubernetesConfig{Processes: processConfigs},
+		}
+	}
+	for _, port := range importedContainer(routable.deployment).Ports {
+		deployment.ExposedPorts = append(deployment.ExposedPorts, ketchv1.ExposedPort{Port: int(port.ContainerPort), Protocol: string(port.Protocol)})
+	}
+
+	cnames, usedIngresses := importedCnames(routable.services, ingresses)
+	for _, name := range names {
+		if name == routable.name {
+			continue
+		}
+		if _, used := importedCnames(byName[name].services, ingresses); len(used) > 0 {
+			notes = append(notes, fmt.Sprintf("ingresses route to process %s, ketch routes cnames to process %s only", name, routable.name))
+		}
+	}
+
+	app := &ketchv1.App{
+		ObjectMeta: metav1.ObjectMeta{Name: options.appName},
+		Spec: ketchv1.AppSpec{
+			Namespace:        options.namespace,
+			Deployments:      []ketchv1.AppDeploymentSpec{deployment},
+			DeploymentsCount: 1,
+			Ingress: ketchv1.IngressSpec{
+				GenerateDefaultCname: len(cnames) == 0,
+				Cnames:               cnames,
+			},
+		},
+	}
+	return app, usedIngresses, notes, nil
+}
+
+func importedContainer(deployment *appsv1.Deployment) corev1.Container {
+	if containers := deployment.Spec.Template.Spec.Containers; len(containers) > 0 {
+		return containers[0]
+	}
+	return corev1.Container{}
+}
+
+// importedPorts returns ports of the services, a named target port is resolved to the port of the container with the name.
+func importedPorts(container corev1.Container, services []corev1.Service) []ketchv1.KetchYamlProcessPortConfig {
+	var ports []ketchv1.KetchYamlProcessPortConfig
+	for _, service := range services {
+		for _, servicePort := range service.Spec.Ports {
+			targetPort := servicePort.TargetPort.IntValue()
+			if targetPort == 0 {
+				for _, containerPort := range container.Ports {
+					if containerPort.Name == servicePort.TargetPort.String() {
+						targetPort = int(containerPort.ContainerPort)
+					}
+				}
+			}
+			if targetPort == 0 {
+				targetPort = int(servicePort.Port)
+			}
+			ports = append(ports, ketchv1.KetchYamlProcessPortConfig{
+				Name:       servicePort.Name,
+				Protocol:   string(servicePort.Protocol),
+				Port:       int(servicePort.Port),
+				TargetPort: targetPort,
+			})
+		}
+	}
+	return ports
+}
+
+// importedCnames returns cnames of hosts of ingress rules routing to the services and the ingresses they were found in.
+func importedCnames(services []corev1.Service, ingresses []networkingv1.Ingress) (ketchv1.CnameList, []networkingv1.Ingress) {
+	serviceNames := map[string]bool{}
+	for _, service := range services {
+		serviceNames[service.Name] = true
+	}
+	var cnames ketchv1.CnameList
+	var used []networkingv1.Ingress
+	seen := map[string]bool{}
+	for _, ingress := range ingresses {
+		secrets := map[string]string{}
+		for _, tls := range ingress.Spec.TLS {
+			for _, host := range tls.Hosts {
+				secrets[host] = tls.SecretName
+			}
+		}
+		routes := false
+		for _, rule := range ingress.Spec.Rules {
+			if rule.HTTP == nil || rule.Host == "" {
+				continue
+			}
+			for _, path := range rule.HTTP.Paths {
+				if path.Backend.Service == nil || !serviceNames[path.Backend.Service.Name] {
+					continue
+				}
+				routes = true
+				cname := ketchv1.Cname{Name: rule.Host}
+				if path.Path != "/" {
+					cname.Path = path.Path
+				}
+				if secret, ok := secrets[rule.Host]; ok {
+					cname.Secure = true
+					cname.SecretName = secret
+				}
+				if key := cname.Name + cname.Path; !seen[key] {
+					seen[key] = true
+					cnames = append(cnames, cname)
+				}
+			}
+		}
+		if routes {
+			used = append(used, ingress)
+		}
+	}
+	return cnames, used
+}
+
+// labelImportedObjects labels the Deployments, Services and Ingresses the app was imported from.
+func labelImportedObjects(ctx context.Context, kubeClient kubernetes.Interface, options appImportOptions, processes []importedProcess, ingresses []networkingv1.Ingress) error {
+	labeled := map[string]bool{}
+	for _, process := range processes {
+		deployment := process.deployment.DeepCopy()
+		deployment.Labels = importedLabels(deployment.Labels, options.appName)
+		if _, err := kubeClient.AppsV1().Deployments(options.namespace).Update(ctx, deployment, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("failed to label deployment: %w", err)
+		}
+		for _, service := range process.services {
+			// a service selecting pods of several deployments is labeled once.
+			if labeled[service.Name] {
+				continue
+			}
+			labeled[service.Name] = true
+			service.Labels = importedLabels(service.Labels, options.appName)
+			if _, err := kubeClient.CoreV1().Services(options.namespace).Update(ctx, &service, metav1.UpdateOptions{}); err != nil {
+				return fmt.Errorf("failed to label service: %w", err)
+			}
+		}
+	}
+	for _, ingress := range ingresses {
+		ingress.Labels = importedLabels(ingress.Labels, options.appName)
+		if _, err := kubeClient.NetworkingV1().Ingresses(options.namespace).Update(ctx, &ingress, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("failed to label ingress: %w", err)
+		}
+	}
+	return nil
+}
+
+func importedLabels(objectLabels map[string]string, appName string) map[string]string {
+	result := make(map[string]string, len(objectLabels)+1)
+	for k, v := range objectLabels {
+		result[k] = v
+	}
+	result[utils.KetchImportedByLabel] = appName
+	return result
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/mocks"
+)
+
+func importDeployment(name, image string, replicas int32, container corev1.Container) *appsv1.Deployment {
+	container.Name = name
+	container.Image = image
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "production"},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": name}},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{container}},
+			},
+		},
+	}
+}
+
+func TestAppImport(t *testing.T) {
+	frontend := importDeployment("frontend", "shipa/frontend:1.0", 3, corev1.Container{
+		Env: []corev1.EnvVar{
+			{Name: "API_URL", Value: "http://api"},
+			{Name: "TOKEN", ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{Key: "token"}}},
+		},
+		Ports: []corev1.ContainerPort{{Name: "http", ContainerPort: 8080, Protocol: corev1.ProtocolTCP}},
+		Resources: corev1.ResourceRequirements{
+			Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("256Mi")},
+		},
+	})
+	worker := importDeployment("queue-worker", "shipa/worker:1.0", 1, corev1.Container{
+		Command: []string{"/bin/worker"},
+		Args:    []string{"--queue", "jobs"},
+	})
+	sidecars := importDeployment("sidecars", "shipa/frontend:1.0", 1, corev1.Container{})
+	sidecars.Spec.Template.Spec.Containers = append(sidecars.Spec.Template.Spec.Containers, corev1.Container{Name: "proxy"})
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "frontend", Namespace: "production"},
+		Spec: corev1.ServiceSpec{
+			Selector: map[string]string{"app": "frontend"},
+			Ports:    []corev1.ServicePort{{Name: "http", Port: 80, TargetPort: intstr.FromString("http"), Protocol: corev1.ProtocolTCP}},
+		},
+	}
+	pathType := networkingv1.PathTypePrefix
+	ingress := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "frontend", Namespace: "production"},
+		Spec: networkingv1.IngressSpec{
+			TLS: []networkingv1.IngressTLS{{Hosts: []string{"shop.example.com"}, SecretName: "shop-tls"}},
+			Rules: []networkingv1.IngressRule{
+				{
+					Host: "shop.example.com",
+					IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{
+						Paths: []networkingv1.HTTPIngressPath{
+							{Path: "/", PathType: &pathType, Backend: networkingv1.IngressBackend{Service: &networkingv1.IngressServiceBackend{Name: "frontend"}}},
+						},
+					}},
+				},
+				{
+					Host: "example.com",
+					IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{
+						Paths: []networkingv1.HTTPIngressPath{
+							{Path: "/shop", PathType: &pathType, Backend: networkingv1.IngressBackend{Service: &networkingv1.IngressServiceBackend{Name: "frontend"}}},
+						},
+					}},
+				},
+			},
+		},
+	}
+	existingApp := &ketchv1.App{ObjectMeta: metav1.ObjectMeta{Name: "existing"}}
+
+	three, one := 3, 1
+	tests := []struct {
+		name       string
+		options    appImportOptions
+		wantApp    *ketchv1.App
+		wantOut    string
+		wantErr    string
+		wantDryRun string
+	}{
+		{
+			name:    "import deployments, services and ingresses",
+			options: appImportOptions{appName: "shop", namespace: "production", deployments: []string{"web=frontend", "queue-worker"}},
+			wantApp: &ketchv1.App{
+				ObjectMeta: metav1.ObjectMeta{Name: "shop"},
+				Spec: ketchv1.AppSpec{
+					Namespace:        "production",
+					DeploymentsCount: 1,
+					Deployments: []ketchv1.AppDeploymentSpec{
+						{
+							Image:   "shipa/frontend:1.0",
+							Version: 1,
+							Processes: []ketchv1.ProcessSpec{
+								{Name: "queue-worker", Units: &one, Image: "shipa/worker:1.0", Cmd: []string{"/bin/worker", "--queue", "jobs"}},
+								{
+									Name:  "web",
+									Units: &three,
+									Cmd:   []string{},
+									Env:   []ketchv1.Env{{Name: "API_URL", Value: "http://api"}},
+									Resources: &corev1.ResourceRequirements{
+										Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("256Mi")},
+									},
+								},
+							},
+							KetchYaml: &ketchv1.KetchYamlData{
+								Kubernetes: &ketchv1.KetchYamlKubernetesConfig{
+									Processes: map[string]ketchv1.KetchYamlProcessConfig{
+										"web": {Ports: []ketchv1.KetchYamlProcessPortConfig{{Name: "http", Protocol: "TCP", Port: 80, TargetPort: 8080}}},
+									},
+								},
+							},
+							RoutingSettings: ketchv1.RoutingSettings{Weight: 100},
+							ExposedPorts:    []ketchv1.ExposedPort{{Port: 8080, Protocol: "TCP"}},
+						},
+					},
+					Ingress: ketchv1.IngressSpec{
+						Cnames: ketchv1.CnameList{
+							{Name: "shop.example.com", Secure: true, SecretName: "shop-tls"},
+							{Name: "example.com", Path: "/shop"},
+						},
+					},
+				},
+			},
+			wantOut: "variable TOKEN of deployment frontend isn't a plain value, it isn't imported\n" +
+				"Successfully imported shop, remove the imported objects once the app is running:\n" +
+				"  kubectl delete deployment,service,ingress -n production -l theketch.io/imported-by=shop\n",
+		},
+		{
+			name:       "dry run",
+			options:    appImportOptions{appName: "shop", namespace: "production", deployments: []string{"queue-worker"}, dryRun: true},
+			wantDryRun: "apiVersion: theketch.io/v1beta1\nkind: App\n",
+		},
+		{
+			name:    "app exists",
+			options: appImportOptions{appName: "existing", namespace: "production", deployments: []string{"frontend"}},
+			wantErr: `app "existing" already exists`,
+		},
+		{
+			name:    "deployment not found",
+			options: appImportOptions{appName: "shop", namespace: "production", deployments: []string{"backend"}},
+			wantErr: `failed to get deployment: deployments.apps "backend" not found`,
+		},
+		{
+			name:    "several containers",
+			options: appImportOptions{appName: "shop", namespace: "production", deployments: []string{"sidecars"}},
+			wantErr: `deployment "sidecars" has 2 containers, ketch runs one container per process`,
+		},
+		{
+			name:    "process imported twice",
+			options: appImportOptions{appName: "shop", namespace: "production", deployments: []string{"web=frontend", "web=queue-worker"}},
+			wantErr: `process "web" is imported more than once`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{existingApp.DeepCopy()},
+				KubeClientObjects: []runtime.Object{frontend, worker, sidecars, service, ingress},
+			}
+			out := &bytes.Buffer{}
+			err := appImport(context.Background(), cfg, tt.options, out)
+			if len(tt.wantErr) > 0 {
+				require.NotNil(t, err)
+				require.Equal(t, tt.wantErr, err.Error())
+				return
+			}
+			require.Nil(t, err)
+			if len(tt.wantDryRun) > 0 {
+				require.Contains(t, out.String(), tt.wantDryRun)
+				require.NotNil(t, cfg.Client().Get(context.Background(), types.NamespacedName{Name: tt.options.appName}, &ketchv1.App{}))
+				return
+			}
+			require.Equal(t, tt.wantOut, out.String())
+			app := ketchv1.App{}
+			require.Nil(t, cfg.Client().Get(context.Background(), types.NamespacedName{Name: tt.options.appName}, &app))
+			require.Equal(t, tt.wantApp.Spec, app.Spec)
+		})
+	}
+}
@@ -6,11 +6,15 @@ import (
 	"fmt"
 	"html/template"
 	"io"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 
@@ -31,9 +35,67 @@ Builder: {{ .App.Spec.Builder }}
 {{- if .App.Spec.Description }}
 Description: {{ .App.Spec.Description }}
 {{- end }}
-{{- if .Cnames }}
+{{- if .App.Spec.Team }}
+Team: {{ .App.Spec.Team }}
+{{- end }}
+{{- if .App.Spec.Owner }}
+Owner: {{ .App.Spec.Owner }}
+{{- end }}
+{{- if .App.DeletionTimestamp }}
+Removing{{ with .App.Status.Condition "Removed" }}: {{ .Message }}{{ end }}
+{{- end }}
+{{- with .App.Status.Condition "Deploying" }}{{ if eq .Status "True" }}
+Deploying: {{ .Message }}
+{{- end }}{{ end }}
+{{- with .App.Spec.Maintenance }}
+Maintenance: on{{ if .ProcessesStopped }} (processes stopped){{ end }}
+{{- end }}
+{{- with .App.Status.ReleaseFailure }}
+Deployment blocked: {{ if .Process }}{{ .Process }}{{ else }}release{{ end }} process of version {{ .Version }} failed: {{ .Message }}
+{{- end }}
+{{- range .App.Status.DeployHooks }}
+Deploy hook {{ .Process }} of version {{ .Version }}: {{ .Phase }}
+{{- end }}
+{{- range .App.Status.DriftedResources }}
+Drifted: {{ .Kind }} {{ .Name }} {{ if .Missing }}was removed{{ else }}has edited fields {{ join .Fields ", " }}{{ end }}
+{{- end }}
+{{- if .App.Spec.IsInternal }}
+Expose: internal
+{{- with .App.Spec.InternalLoadBalancerAnnotations }}
+Internal load balancer:{{ range $k, $v := . }} {{ $k }}={{ $v }}{{ end }}
+{{- end }}
+{{- range .InternalAddresses }}
+Internal address: {{ . }}
+{{- end }}
+{{- else if .Cnames }}
 {{- range $address := .Cnames }}
-Address: {{ $address }}
+Address: {{ $address }}{{ if eq $address $.PrimaryURL }} (primary){{ end }}
+{{- end }}
+{{- range .App.Status.Cnames }}
+{{- if not .Valid }}
+Invalid cname {{ .Name }}: {{ .Message }}
+{{- end }}
+{{- end }}
+{{- range $cname := .App.Spec.Ingress.Cnames }}
+{{- with $cname.DNS }}
+DNS of {{ $cname.Address }}: {{ .String }}
+{{- end }}
+{{- end }}
+{{- with .App.Spec.Ingress.Auth }}
+Auth: {{ .String }}
+{{- end }}
+{{- range $cname := .App.Spec.Ingress.Cnames }}
+{{- with $cname.Auth }}
+Auth of {{ $cname.Address }}: {{ .String }}
+{{- end }}
+{{- end }}
+{{- with .App.Spec.Ingress.AllowedCIDRs }}
+Allowed CIDRs: {{ join . ", " }}
+{{- end }}
+{{- range $cname := .App.Spec.Ingress.Cnames }}
+{{- with $cname.AllowedCIDRs }}
+Allowed CIDRs of {{ $cname.Address }}: {{ join . ", " }}
+{{- end }}
 {{- end }}
 {{- else }}
 The default cname hasn't assigned yet because cluster doesn't have ingress service endpoint.
@@ -41,21 +103,36 @@ The default cname hasn't assigned yet because cluster doesn't have ingress servi
 {{- if .App.Spec.DockerRegistry.SecretName }}
 Secret name to pull application's images: {{ .App.Spec.DockerRegistry.SecretName }}
 {{- end }}
+{{- range .App.Spec.DockerRegistry.Mirrors }}
+Registry mirror: {{ .Registry }} => {{ .Endpoint }}
+{{- end }}
 {{ if .App.Spec.Env }}
 Environment variables:
 {{- range .App.Spec.Env }}
-{{ .Name }}={{ .Value }}
+{{ .Name }}={{ .DisplayValue }}
 {{- end }}
 {{- else }}
 No environment variables.
 {{- end }}
+{{- if .App.Spec.EnvSets }}
+Env sets: {{ join .App.Spec.EnvSets ", " }}
+{{- end }}
+{{- range .App.Spec.ServiceBindings }}
+Service binding: {{ .Name }} (secret {{ .SecretName }}{{ if .Processes }}, processes {{ join .Processes ", " }}{{ end }})
+{{- end }}
+{{- range .App.Status.Dependencies }}
+Dependency: {{ .Name }} ({{ if .Ready }}ready, secret {{ .SecretName }}{{ else }}{{ .Message }}{{ end }})
+{{- end }}
 `
 )
 
 type appInfoContext struct {
-	App         ketchv1.App `json:"app" yaml:"app"`
-	Cnames      []string    `json:"cnames" yaml:"cnames"`
-	NoProcesses bool        `json:"noProcesses" yaml:"noProcesses"`
+	App    ketchv1.App `json:"app" yaml:"app"`
+	Cnames []string    `json:"cnames" yaml:"cnames"`
+	// InternalAddresses are in-cluster addresses of services of an internal app.
+	InternalAddresses []string `json:"internalAddresses,omitempty" yaml:"internalAddresses,omitempty"`
+	PrimaryURL        string   `json:"primaryURL,omitempty" yaml:"primaryURL,omitempty"`
+	NoProcesses       bool     `json:"noProcesses" yaml:"noProcesses"`
 }
 
 type appInfoOutput struct {
@@ -69,15 +146,29 @@ type deploymentOutput struct {
 	ProcessName       string `json:"processName" yaml:"processName"`
 	Weight            string `json:"weight" yaml:"weight"`
 	State             string `json:"state" yaml:"state"`
+	CPU               string `json:"cpu" yaml:"cpu" column:"CPU"`
+	Memory            string `json:"memory" yaml:"memory"`
 	Cmd               string `json:"cmd" yaml:"cmd"`
 }
 
+type eventOutput struct {
+	LastSeen string `json:"lastSeen" yaml:"lastSeen"`
+	Type     string `json:"type" yaml:"type"`
+	Reason   string `json:"reason" yaml:"reason"`
+	Message  string `json:"message" yaml:"message"`
+}
+
+// appInfoEventsLimit is the number of the most recent events shown by "app info --events".
+const appInfoEventsLimit = 20
+
 const appInfoHelp = `
 Show information about a specific app.
+CPU and memory usage of processes is shown if metrics-server is installed in the cluster.
+Use --watch to follow a rollout, the information is refreshed every time the app or its pods change.
 `
 
-func newAppInfoCmd(cfg config, out io.Writer) *cobra.Command {
-	options := appInfoOptions{}
+func newAppInfoCmd(cfg config, out io.Writer, redact redactor) *cobra.Command {
+	options := appInfoOptions{redactor: redact}
 	cmd := &cobra.Command{
 		Use:   "info APPNAME",
 		Short: "Show information about a specific app.",
@@ -85,17 +176,42 @@ func newAppInfoCmd(cfg config, out io.Writer) *cobra.Command {
 		Long:  appInfoHelp,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			options.name = args[0]
+			if options.watch {
+				return appInfoWatch(cmd.Context(), cfg, options, out)
+			}
 			return appInfo(cmd.Context(), cfg, options, out)
 		},
 		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 			return autoCompleteAppNames(cfg, toComplete)
 		},
 	}
+	cmd.Flags().BoolVar(&options.showSecrets, showSecretsFlag, false, showSecretsUsage)
+	cmd.Flags().BoolVar(&options.showEvents, "events", false, "Show recent events of the app.")
+	cmd.Flags().BoolVarP(&options.watch, watchFlag, "w", false, watchUsage)
 	return cmd
 }
 
 type appInfoOptions struct {
-	name string
+	name        string
+	showSecrets bool
+	showEvents  bool
+	watch       bool
+	redactor    redactor
+}
+
+// appInfoWatch shows information about the app and refreshes it every time the app or its pods change.
+func appInfoWatch(ctx context.Context, cfg config, options appInfoOptions, out io.Writer) error {
+	app := ketchv1.App{}
+	if err := cfg.Client().Get(ctx, types.NamespacedName{Name: options.name}, &app); err != nil {
+		return fmt.Errorf("failed to get app: %w", err)
+	}
+	render := func(ctx context.Context, out io.Writer) error {
+		return appInfo(ctx, cfg, options, out)
+	}
+	return watchView(ctx, out, render,
+		watchApps(cfg, fmt.Sprintf("metadata.name=%s", app.Name)),
+		watchPods(cfg, app.Spec.Namespace, fmt.Sprintf("%s=%s", utils.KetchAppNameLabel, app.Name)),
+	)
 }
 
 func appInfo(ctx context.Context, cfg config, options appInfoOptions, out io.Writer) error {
@@ -103,40 +219,164 @@ func appInfo(ctx context.Context, cfg config, options appInfoOptions, out io.Wri
 	if err := cfg.Client().Get(ctx, types.NamespacedName{Name: options.name}, &app); err != nil {
 		return fmt.Errorf("failed to get app: %w", err)
 	}
+	if !options.showSecrets {
+		options.redactor.app(&app)
+	}
 
-	appPods, err := cfg.KubernetesClient().CoreV1().Pods(app.Namespace).List(ctx, metav1.ListOptions{
-		LabelSelector: fmt.Sprintf(`%s=%s`, utils.KetchAppNameLabel, app.Name),
-	})
-	if err != nil {
+	appPods, err := appInfoPods(ctx, cfg, app)
+	var podsForbidden bool
+	if apierrors.IsForbidden(err) {
+		// users with read-only access to apps still get the app's info.
+		appPods, podsForbidden = &v1.PodList{}, true
+	} else if err != nil {
 		return err
 	}
 
-	data := generateAppInfoOutput(app, appPods)
+	// usage is optional, clusters without metrics-server get empty CPU and MEMORY columns.
+	usage, _ := appPodsUsage(ctx, cfg, app)
+	data := generateAppInfoOutput(app, appPods, usage)
+	if app.Spec.IsInternal() {
+		// users who can't list services still get the rest of the app's info.
+		if data.AppInfoContext.InternalAddresses, err = appInternalAddresses(ctx, cfg, app); err != nil && !apierrors.IsForbidden(err) {
+			return err
+		}
+	}
 
 	buf := bytes.Buffer{}
-	t := template.Must(template.New("app-info").Parse(appInfoTemplate))
+	t := template.Must(template.New("app-info").Funcs(template.FuncMap{"join": strings.Join}).Parse(appInfoTemplate))
 
 	if err := t.Execute(&buf, data.AppInfoContext); err != nil {
 		return err
 	}
 	fmt.Fprintf(out, "%v", buf.String())
-	return output.Write(data.Deployments, out, "column")
+	if err := output.Write(data.Deployments, out, "column"); err != nil {
+		return err
+	}
+	if podsForbidden {
+		fmt.Fprintf(out, "\nUnits aren't shown, you don't have permission to list pods in namespace %q.\n", app.Spec.Namespace)
+	}
+	if !options.showEvents {
+		return nil
+	}
+	events, err := appEvents(ctx, cfg, app.Name, appInfoEventsLimit)
+	if apierrors.IsForbidden(err) {
+		fmt.Fprintln(out, "\nEvents aren't shown, you don't have permission to list events.")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(events) == 0 {
+		fmt.Fprintln(out, "\nNo events found.")
+		return nil
+	}
+	fmt.Fprintln(out, "\nEvents:")
+	return output.Write(events, out, "column")
+}
 
+// appInternalAddresses returns in-cluster addresses of ports of the app's services.
+func appInternalAddresses(ctx context.Context, cfg config, app ketchv1.App) ([]string, error) {
+	listOptions := metav1.ListOptions{
+		LabelSelector: fmt.Sprintf(`%s=%s`, utils.KetchAppNameLabel, app.Name),
+	}
+	services, err := cfg.KubernetesClient().CoreV1().Services(app.Spec.Namespace).List(ctx, listOptions)
+	if err != nil {
+		return nil, err
+	}
+	var addresses []string
+	for _, service := range services.Items {
+		for _, port := range service.Spec.Ports {
+			address := fmt.Sprintf("%s:%d", app.ServiceDNSName(service.Name), port.Port)
+			for _, lb := range service.Status.LoadBalancer.Ingress {
+				if lb.IP != "" {
+					address += fmt.Sprintf(" (load balancer %s:%d)", lb.IP, port.Port)
+				}
+			}
+			addresses = append(addresses, address)
+		}
+	}
+	sort.Strings(addresses)
+	return addresses, nil
 }
 
-func generateAppInfoOutput(app ketchv1.App, appPods *v1.PodList) appInfoOutput {
+// appInfoPods lists pods of the app, in the app's namespace if the user can't list pods of all namespaces.
+func appInfoPods(ctx context.Context, cfg config, app ketchv1.App) (*v1.PodList, error) {
+	listOptions := metav1.ListOptions{
+		LabelSelector: fmt.Sprintf(`%s=%s`, utils.KetchAppNameLabel, app.Name),
+	}
+	pods, err := cfg.KubernetesClient().CoreV1().Pods(app.Namespace).List(ctx, listOptions)
+	if apierrors.IsForbidden(err) && app.Namespace != app.Spec.Namespace {
+		return cfg.KubernetesClient().CoreV1().Pods(app.Spec.Namespace).List(ctx, listOptions)
+	}
+	return pods, err
+}
+
+// appEvents returns up to limit most recent events of the app, the oldest first.
+func appEvents(ctx context.Context, cfg config, appName string, limit int) ([]eventOutput, error) {
+	list, err := cfg.KubernetesClient().CoreV1().Events(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
+		FieldSelector: fmt.Sprintf("involvedObject.kind=App,involvedObject.name=%s", appName),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get app events: %w", err)
+	}
+	var events []v1.Event
+	for _, event := range list.Items {
+		if event.InvolvedObject.Kind == "App" && event.InvolvedObject.Name == appName {
+			events = append(events, event)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return eventTime(events[i]).Before(eventTime(events[j]))
+	})
+	if len(events) > limit {
+		events = events[len(events)-limit:]
+	}
+	outputs := make([]eventOutput, 0, len(events))
+	for _, event := range events {
+		outputs = append(outputs, eventOutput{
+			LastSeen: eventTime(event).Format(time.RFC3339),
+			Type:     event.Type,
+			Reason:   event.Reason,
+			Message:  event.Message,
+		})
+	}
+	return outputs, nil
+}
+
+// eventTime returns the time an event was seen last.
+func eventTime(event v1.Event) time.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	}
+	return event.FirstTimestamp.Time
+}
+
+func generateAppInfoOutput(app ketchv1.App, appPods *v1.PodList, usage map[string]podUsage) appInfoOutput {
 	noProcesses := true
 	var deployments []deploymentOutput
 	for _, deployment := range app.Spec.Deployments {
 		for _, process := range deployment.Processes {
 			noProcesses = false
 			state := appState(filterProcessDeploymentPods(appPods.Items, deployment.Version.String(), process.Name))
+			var cpu, memory string
+			if cpuTotal, memoryTotal, ok := processUsage(usage, deployment.Version.String(), process.Name); ok {
+				cpu, memory = formatCPU(cpuTotal), formatMemory(memoryTotal)
+			}
+			weight := fmt.Sprintf("%v%%", deployment.RoutingSettings.Weight)
+			if deployment.IsShadow() {
+				weight = fmt.Sprintf("shadow %v%%", deployment.RoutingSettings.MirrorPercentage)
+			}
 			deployments = append(deployments, deploymentOutput{
 				DeploymentVersion: deployment.Version.String(),
 				Image:             deployment.Image,
 				ProcessName:       process.Name,
-				Weight:            fmt.Sprintf("%v%%", deployment.RoutingSettings.Weight),
+				Weight:            weight,
 				State:             state,
+				CPU:               cpu,
+				Memory:            memory,
 				Cmd:               strings.Join(process.Cmd, " "),
 			})
 		}
@@ -146,6 +386,9 @@ func generateAppInfoOutput(app ketchv1.App, appPods *v1.PodList) appInfoOutput {
 		Cnames:      app.CNames(),
 		NoProcesses: noProcesses,
 	}
+	if app.Spec.Ingress.Cnames.Primary() != nil {
+		infoContext.PrimaryURL = app.PrimaryURL()
+	}
 
 	return appInfoOutput{
 		infoContext, deployments,
@@ -163,3 +406,15 @@ func filterProcessDeploymentPods(appPods []corev1.Pod, version, process string)
 	}
 	return pods
 }
+
+// processUsage returns the total usage of the units of a process, ok is false if there are no metrics of the units.
+func processUsage(usage map[string]podUsage, version, process string) (cpu resource.Quantity, memory resource.Quantity, ok bool) {
+	for _, pod := range usage {
+		if pod.version == version && pod.process == process {
+			cpu.Add(pod.cpu)
+			memory.Add(pod.memory)
+			ok = true
+		}
+	}
+	return cpu, memory, ok
+}
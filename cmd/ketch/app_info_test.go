@@ -3,15 +3,22 @@ package main
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"io/ioutil"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ktesting "k8s.io/client-go/testing"
 
 	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
 	"github.com/theketchio/ketch/internal/mocks"
+	"github.com/theketchio/ketch/internal/utils"
 )
 
 func Test_appInfo(t *testing.T) {
@@ -63,12 +70,23 @@ func Test_appInfo(t *testing.T) {
 				{Name: "API_KEY", Value: "public_key"},
 				{Name: "VAR1", Value: "VALUE"},
 			},
+			EnvSets: []string{"database", "observability"},
+			ServiceBindings: []ketchv1.ServiceBinding{
+				{Name: "db", SecretName: "postgres"},
+				{Name: "queue", SecretName: "rabbitmq", Processes: []string{"worker"}},
+			},
 			Namespace: "aws",
 			Ingress: ketchv1.IngressSpec{
 				GenerateDefaultCname: true,
 				Controller:           ketchv1.IngressControllerSpec{ServiceEndpoint: "10.10.10.10"},
 			},
 		},
+		Status: ketchv1.AppStatus{
+			Dependencies: []ketchv1.DependencyStatus{
+				{Name: "postgres", Provisioner: ketchv1.CrossplaneProvisionerType, SecretName: "go-app-postgres", Ready: true},
+				{Name: "redis", Provisioner: ketchv1.ExternalSecretsProvisionerType, SecretName: "go-app-redis", Message: "ExternalSecret go-app-redis is not ready: SecretSyncedError"},
+			},
+		},
 	}
 	goAppWithSecretName := &ketchv1.App{
 		ObjectMeta: metav1.ObjectMeta{
@@ -98,6 +116,66 @@ func Test_appInfo(t *testing.T) {
 			},
 		},
 	}
+	goAppWithPrimaryCname := goAppWithSecretName.DeepCopy()
+	goAppWithPrimaryCname.Spec.Ingress.Cnames = ketchv1.CnameList{{Name: "theketch.io"}, {Name: "www.theketch.io", Secure: true, Primary: true, DNS: &ketchv1.CnameDNS{Target: "lb.theketch.io", TTL: 60}}}
+	goAppWithPrimaryCname.Spec.DockerRegistry = ketchv1.DockerRegistrySpec{}
+	goAppWithPrimaryCname.Status.Cnames = []ketchv1.CnameStatus{
+		{Name: "theketch.io", Message: "cname does not point to the ingress controller: theketch.io resolves to 20.20.20.20, expected 10.10.10.10"},
+		{Name: "www.theketch.io", Valid: true},
+	}
+	removingDashboard := dashboard.DeepCopy()
+	removingDashboard.DeletionTimestamp = &metav1.Time{Time: time.Date(2022, time.June, 1, 9, 0, 0, 0, time.UTC)}
+	removingDashboard.Finalizers = []string{ketchv1.KetchFinalizer}
+	removingDashboard.Status.Conditions = []ketchv1.Condition{
+		{Type: ketchv1.Removed, Status: corev1.ConditionFalse, Message: "failed to remove resources of the app: waiting for 1 jobs in namespace gke to be removed"},
+	}
+	deployingDashboard := dashboard.DeepCopy()
+	deployingDashboard.Status.Conditions = []ketchv1.Condition{
+		{Type: ketchv1.Deploying, Status: corev1.ConditionTrue, Message: "version 3 is rolling out"},
+	}
+	driftedDashboard := dashboard.DeepCopy()
+	driftedDashboard.Status.DriftedResources = []ketchv1.DriftedResource{
+		{Kind: "Deployment", Name: "dashboard-web-3", Fields: []string{"spec.replicas", "spec.template.spec.containers[0].image"}},
+		{Kind: "Service", Name: "dashboard-web-3", Missing: true},
+	}
+	dashboardInMaintenance := dashboard.DeepCopy()
+	dashboardInMaintenance.Spec.Maintenance = &ketchv1.MaintenanceSpec{ProcessesStopped: true}
+	internalDashboard := dashboard.DeepCopy()
+	internalDashboard.Spec.Expose = ketchv1.ExposeInternal
+	internalDashboard.Spec.InternalLoadBalancerAnnotations = map[string]string{"networking.gke.io/load-balancer-type": "Internal"}
+	internalService := func(name string, lbIP string, ports ...int32) *corev1.Service {
+		service := &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "gke", Labels: map[string]string{utils.KetchAppNameLabel: "dashboard"}},
+		}
+		for _, port := range ports {
+			service.Spec.Ports = append(service.Spec.Ports, corev1.ServicePort{Port: port})
+		}
+		if lbIP != "" {
+			service.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: lbIP}}
+		}
+		return service
+	}
+	dashboardWithHooks := dashboard.DeepCopy()
+	dashboardWithHooks.Status.DeployHooks = []ketchv1.DeployHookStatus{
+		{Process: "pre-deploy", Version: 3, Phase: "Succeeded"},
+		{Process: "release", Version: 3, Phase: "Failed"},
+	}
+	dashboardWithHooks.Status.ReleaseFailure = &ketchv1.ReleaseFailure{
+		Version: 3,
+		Process: "release",
+		Message: "UPGRADE FAILED: pre-upgrade hooks failed: job failed: BackoffLimitExceeded",
+	}
+	event := func(name, appName, reason, message string, lastSeen time.Time) *corev1.Event {
+		return &corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: name, Namespace: "default"},
+			InvolvedObject: corev1.ObjectReference{Kind: "App", Name: appName},
+			Type:           corev1.EventTypeNormal,
+			Reason:         reason,
+			Message:        message,
+			LastTimestamp:  metav1.NewTime(lastSeen),
+		}
+	}
+	now := time.Date(2022, time.June, 1, 10, 0, 0, 0, time.UTC)
 	tests := []struct {
 		name               string
 		cfg                config
@@ -135,6 +213,16 @@ func Test_appInfo(t *testing.T) {
 			},
 			wantOutputFilename: "./testdata/app-info/go-app-secret-name.output",
 		},
+		{
+			name: "cnames with a primary one",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{goAppWithPrimaryCname},
+			},
+			options: appInfoOptions{
+				name: "go-app",
+			},
+			wantOutputFilename: "./testdata/app-info/go-app-primary-cname.output",
+		},
 		{
 			name: "app with builder",
 			cfg: &mocks.Configuration{
@@ -145,6 +233,117 @@ func Test_appInfo(t *testing.T) {
 			},
 			wantOutputFilename: "./testdata/app-info/app-python.output",
 		},
+		{
+			name: "events",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{goApp},
+				KubeClientObjects: []runtime.Object{
+					event("go-app.2", "go-app", ketchv1.AppScaledUp, "scaled from 1 to 3 units", now.Add(time.Minute)),
+					event("go-app.1", "go-app", ketchv1.AppDeployed, "version 1 deployed with image shipasoftware/go-app:v1", now),
+					event("dashboard.1", "dashboard", ketchv1.AppDeployed, "version 1 deployed with image shipasoftware/dashboard:v1", now),
+				},
+			},
+			options: appInfoOptions{
+				name:       "go-app",
+				showEvents: true,
+			},
+			wantOutputFilename: "./testdata/app-info/go-app-events.output",
+		},
+		{
+			name: "no events",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{dashboard},
+			},
+			options: appInfoOptions{
+				name:       "dashboard",
+				showEvents: true,
+			},
+			wantOutputFilename: "./testdata/app-info/dashboard-no-events.output",
+		},
+		{
+			name: "removal is stuck",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects:    []runtime.Object{removingDashboard},
+				DynamicClientObjects: []runtime.Object{},
+			},
+			options: appInfoOptions{
+				name: "dashboard",
+			},
+			wantOutputFilename: "./testdata/app-info/dashboard-removing.output",
+		},
+		{
+			name: "app deploying",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects:    []runtime.Object{deployingDashboard},
+				DynamicClientObjects: []runtime.Object{},
+			},
+			options: appInfoOptions{
+				name: "dashboard",
+			},
+			wantOutputFilename: "./testdata/app-info/dashboard-deploying.output",
+		},
+		{
+			name: "drifted app",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects:    []runtime.Object{driftedDashboard},
+				DynamicClientObjects: []runtime.Object{},
+			},
+			options: appInfoOptions{
+				name: "dashboard",
+			},
+			wantOutputFilename: "./testdata/app-info/dashboard-drifted.output",
+		},
+		{
+			name: "app in maintenance",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects:    []runtime.Object{dashboardInMaintenance},
+				DynamicClientObjects: []runtime.Object{},
+			},
+			options: appInfoOptions{
+				name: "dashboard",
+			},
+			wantOutputFilename: "./testdata/app-info/dashboard-maintenance.output",
+		},
+		{
+			name: "internal app",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{internalDashboard},
+				KubeClientObjects: []runtime.Object{
+					internalService("app-dashboard", "10.128.0.7", 9090),
+					internalService("dashboard-web-3", "", 9090, 9091),
+				},
+			},
+			options: appInfoOptions{
+				name: "dashboard",
+			},
+			wantOutputFilename: "./testdata/app-info/dashboard-internal.output",
+		},
+		{
+			name: "failed deploy hook",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects:    []runtime.Object{dashboardWithHooks},
+				DynamicClientObjects: []runtime.Object{},
+			},
+			options: appInfoOptions{
+				name: "dashboard",
+			},
+			wantOutputFilename: "./testdata/app-info/dashboard-deploy-hooks.output",
+		},
+		{
+			name: "read-only access",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{dashboard},
+				KubeClientReactors: []mocks.KubeClientReactor{
+					{Verb: "list", Resource: "pods", Reaction: forbiddenReaction},
+					{Verb: "list", Resource: "events", Reaction: forbiddenReaction},
+				},
+			},
+			options: appInfoOptions{
+				name:       "dashboard",
+				showEvents: true,
+			},
+			wantOutputFilename: "./testdata/app-info/dashboard-read-only.output",
+		},
 		{
 			name: "no app",
 			cfg: &mocks.Configuration{
@@ -171,3 +370,8 @@ func Test_appInfo(t *testing.T) {
 		})
 	}
 }
+
+// forbiddenReaction makes the kubernetes client return a forbidden error.
+func forbiddenReaction(action ktesting.Action) (bool, runtime.Object, error) {
+	return true, nil, apierrors.NewForbidden(schema.GroupResource{Resource: action.GetResource().Resource}, "", fmt.Errorf("no access"))
+}
@@ -4,11 +4,16 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/theketchio/ketch/cmd/ketch/output"
 	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
@@ -24,33 +29,235 @@ type appListOutput struct {
 	Description string `json:"description" yaml:"description"`
 }
 
+// appListWideOutput is the "-o wide" output of ketch app list.
+type appListWideOutput struct {
+	Name        string `json:"name" yaml:"name"`
+	Namespace   string `json:"namespace" yaml:"namespace"`
+	State       string `json:"state" yaml:"state"`
+	Units       int    `json:"units" yaml:"units"`
+	LastDeploy  string `json:"lastDeploy" yaml:"last deploy"`
+	Health      string `json:"health" yaml:"health"`
+	Addresses   string `json:"addresses" yaml:"addresses"`
+	Builder     string `json:"builder" yaml:"builder"`
+	Description string `json:"description" yaml:"description"`
+}
+
 const appListHelp = `
 List all apps running on a kubernetes cluster.
+
+Apps can be filtered by namespace, by team and by a label selector, the label selector is evaluated by the cluster.
+Use --sort-by to sort apps by name, namespace, units or last-deploy, and "-o wide" to show
+the number of units, the time of the last deployment and the health of each app.
+Use --watch to follow rollouts, the list is refreshed every time apps or their pods change.
 `
 
+const (
+	appListSortByName       = "name"
+	appListSortByNamespace  = "namespace"
+	appListSortByUnits      = "units"
+	appListSortByLastDeploy = "last-deploy"
+
+	appListOutputWide = "wide"
+
+	// listPageSize is the number of objects requested from the cluster at once,
+	// listing all apps in pages keeps memory and apiserver load bounded on large clusters.
+	listPageSize = 500
+)
+
+type appListOptions struct {
+	namespace string
+	team      string
+	selector  string
+	sortBy    string
+	output    string
+	watch     bool
+}
+
+func (o appListOptions) validate() error {
+	switch o.sortBy {
+	case "", appListSortByName, appListSortByNamespace, appListSortByUnits, appListSortByLastDeploy:
+	default:
+		return fmt.Errorf("invalid --sort-by %q, must be one of: name, namespace, units, last-deploy", o.sortBy)
+	}
+	switch o.output {
+	case "", appListOutputWide:
+	default:
+		return fmt.Errorf("invalid --output %q, only wide is supported", o.output)
+	}
+	return nil
+}
+
 func newAppListCmd(cfg config, out io.Writer) *cobra.Command {
+	options := appListOptions{}
 	cmd := &cobra.Command{
 		Use:   "list",
 		Short: "List all apps.",
 		Long:  appListHelp,
 		Args:  cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return appList(cmd.Context(), cfg, out)
+			if err := options.validate(); err != nil {
+				return err
+			}
+			if options.watch {
+				return appListWatch(cmd.Context(), cfg, options, out)
+			}
+			return appList(cmd.Context(), cfg, options, out)
 		},
 	}
+	cmd.Flags().StringVarP(&options.namespace, "namespace", "n", "", "Show only apps deployed to the namespace.")
+	cmd.Flags().StringVar(&options.team, "team", "", "Show only apps owned by the team.")
+	cmd.Flags().StringVarP(&options.selector, "label", "l", "", "Show only apps matching the label selector, e.g. team=payments,tier!=frontend.")
+	cmd.Flags().StringVar(&options.sortBy, "sort-by", appListSortByName, "Sort apps by name, namespace, units or last-deploy.")
+	cmd.Flags().StringVarP(&options.output, "output", "o", "", "Output format, \"wide\" adds units, last deploy and health columns.")
+	cmd.Flags().BoolVarP(&options.watch, watchFlag, "w", false, watchUsage)
+	cmd.RegisterFlagCompletionFunc("namespace", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return autoCompleteNamespaces(cfg, toComplete)
+	})
+	cmd.RegisterFlagCompletionFunc("sort-by", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{appListSortByName, appListSortByNamespace, appListSortByUnits, appListSortByLastDeploy}, cobra.ShellCompDirectiveNoFileComp
+	})
+	cmd.RegisterFlagCompletionFunc("output", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{appListOutputWide}, cobra.ShellCompDirectiveNoFileComp
+	})
 	return cmd
 }
 
-func appList(ctx context.Context, cfg config, out io.Writer) error {
-	apps := ketchv1.AppList{}
-	if err := cfg.Client().List(ctx, &apps); err != nil {
-		return fmt.Errorf("failed to list apps: %w", err)
+func appList(ctx context.Context, cfg config, options appListOptions, out io.Writer) error {
+	var listOptions []client.ListOption
+	if len(options.selector) > 0 {
+		selector, err := labels.Parse(options.selector)
+		if err != nil {
+			return fmt.Errorf("invalid label selector: %w", err)
+		}
+		listOptions = append(listOptions, client.MatchingLabelsSelector{Selector: selector})
 	}
-	allPods, err := allAppsPods(ctx, cfg, apps.Items)
+	apps, err := listApps(ctx, cfg, listOptions...)
+	if err != nil {
+		return err
+	}
+	if len(options.namespace) > 0 || len(options.team) > 0 {
+		// an app's namespace and team are a part of its spec, so they can't be selected by the cluster.
+		items := make([]ketchv1.App, 0, len(apps.Items))
+		for _, app := range apps.Items {
+			if len(options.namespace) > 0 && app.Spec.Namespace != options.namespace {
+				continue
+			}
+			if len(options.team) > 0 && app.Spec.Team != options.team {
+				continue
+			}
+			items = append(items, app)
+		}
+		apps.Items = items
+	}
+	sortApps(apps.Items, options.sortBy)
+	allPods, forbidden, err := readableAppsPods(ctx, cfg, options.namespace, apps.Items)
 	if err != nil {
 		return fmt.Errorf("failed to list apps pods: %w", err)
 	}
-	return output.Write(generateAppListOutput(apps, allPods), out, "column")
+	if options.output == appListOutputWide {
+		outputs := generateAppListWideOutput(apps, allPods)
+		for i := range outputs {
+			if forbidden[outputs[i].Namespace] {
+				outputs[i].State = appStateUnknown
+			}
+		}
+		if err := output.Write(outputs, out, "column"); err != nil {
+			return err
+		}
+	} else {
+		outputs := generateAppListOutput(apps, allPods)
+		for i := range outputs {
+			if forbidden[outputs[i].Namespace] {
+				outputs[i].State = appStateUnknown
+			}
+		}
+		if err := output.Write(outputs, out, "column"); err != nil {
+			return err
+		}
+	}
+	if len(forbidden) > 0 {
+		namespaces := make([]string, 0, len(forbidden))
+		for namespace := range forbidden {
+			namespaces = append(namespaces, namespace)
+		}
+		sort.Strings(namespaces)
+		fmt.Fprintf(out, "\nStates of apps in namespaces %s are unknown, you don't have permission to list pods there.\n", strings.Join(namespaces, ", "))
+	}
+	return nil
+}
+
+// appListWatch lists apps and refreshes the list every time apps or their pods change.
+func appListWatch(ctx context.Context, cfg config, options appListOptions, out io.Writer) error {
+	render := func(ctx context.Context, out io.Writer) error {
+		return appList(ctx, cfg, options, out)
+	}
+	return watchView(ctx, out, render,
+		watchApps(cfg, ""),
+		watchPods(cfg, options.namespace, utils.KetchAppNameLabel),
+	)
+}
+
+// readableAppsPods returns pods of all apps like allAppsPods does. A user who can't list pods of all namespaces
+// gets pods of the apps' namespaces the user can read, namespaces whose pods are forbidden are returned too.
+func readableAppsPods(ctx context.Context, cfg config, namespace string, apps []ketchv1.App) (*corev1.PodList, map[string]bool, error) {
+	pods, err := allAppsPods(ctx, cfg, namespace, apps)
+	if !apierrors.IsForbidden(err) {
+		return pods, nil, err
+	}
+	if namespace != "" {
+		return &corev1.PodList{}, map[string]bool{namespace: true}, nil
+	}
+	pods = &corev1.PodList{}
+	forbidden := map[string]bool{}
+	seen := map[string]bool{}
+	for _, app := range apps {
+		if seen[app.Spec.Namespace] {
+			continue
+		}
+		seen[app.Spec.Namespace] = true
+		namespacePods, err := allAppsPods(ctx, cfg, app.Spec.Namespace, apps)
+		if apierrors.IsForbidden(err) {
+			forbidden[app.Spec.Namespace] = true
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		pods.Items = append(pods.Items, namespacePods.Items...)
+	}
+	return pods, forbidden, nil
+}
+
+// sortApps sorts apps by the given key, apps with the same key are sorted by name.
+func sortApps(apps []ketchv1.App, sortBy string) {
+	sort.SliceStable(apps, func(i, j int) bool {
+		a, b := apps[i], apps[j]
+		switch sortBy {
+		case appListSortByNamespace:
+			if a.Spec.Namespace != b.Spec.Namespace {
+				return a.Spec.Namespace < b.Spec.Namespace
+			}
+		case appListSortByUnits:
+			if a.Units() != b.Units() {
+				return a.Units() < b.Units()
+			}
+		case appListSortByLastDeploy:
+			aTime, bTime := lastDeployTime(a), lastDeployTime(b)
+			if !aTime.Equal(bTime) {
+				return aTime.Before(bTime)
+			}
+		}
+		return a.Name < b.Name
+	})
+}
+
+// lastDeployTime returns the time of the app's latest deployment, zero if the app has never been deployed.
+func lastDeployTime(app ketchv1.App) time.Time {
+	history := app.Status.DeploymentHistory
+	if len(history) == 0 {
+		return time.Time{}
+	}
+	return history[len(history)-1].DeployedAt.Time
 }
 
 func generateAppListOutput(apps ketchv1.AppList, allPods *corev1.PodList) []appListOutput {
@@ -70,7 +277,48 @@ func generateAppListOutput(apps ketchv1.AppList, allPods *corev1.PodList) []appL
 	return outputs
 }
 
-func allAppsPods(ctx context.Context, cfg config, apps []ketchv1.App) (*corev1.PodList, error) {
+func generateAppListWideOutput(apps ketchv1.AppList, allPods *corev1.PodList) []appListWideOutput {
+	var outputs []appListWideOutput
+	for _, item := range apps.Items {
+		pods := filterAppPods(item.Name, allPods.Items)
+		var lastDeploy string
+		if deployedAt := lastDeployTime(item); !deployedAt.IsZero() {
+			lastDeploy = deployedAt.UTC().Format(time.RFC3339)
+		}
+		outputs = append(outputs, appListWideOutput{
+			Name:        item.Name,
+			Namespace:   item.Spec.Namespace,
+			State:       appState(pods),
+			Units:       item.Units(),
+			LastDeploy:  lastDeploy,
+			Health:      strings.ToLower(string(item.Phase())),
+			Addresses:   strings.Join(item.CNames(), " "),
+			Builder:     item.Spec.Builder,
+			Description: item.Spec.Description,
+		})
+	}
+	return outputs
+}
+
+// listApps lists apps in pages of listPageSize apps.
+func listApps(ctx context.Context, cfg config, opts ...client.ListOption) (ketchv1.AppList, error) {
+	var apps ketchv1.AppList
+	var continueToken string
+	for {
+		var page ketchv1.AppList
+		pageOpts := append([]client.ListOption{client.Limit(listPageSize), client.Continue(continueToken)}, opts...)
+		if err := cfg.Client().List(ctx, &page, pageOpts...); err != nil {
+			return ketchv1.AppList{}, fmt.Errorf("failed to list apps: %w", err)
+		}
+		apps.Items = append(apps.Items, page.Items...)
+		if continueToken = page.Continue; continueToken == "" {
+			return apps, nil
+		}
+	}
+}
+
+// allAppsPods returns pods of all apps in the namespace, or in all namespaces if the namespace is empty.
+func allAppsPods(ctx context.Context, cfg config, namespace string, apps []ketchv1.App) (*corev1.PodList, error) {
 	if len(apps) == 0 {
 		return &corev1.PodList{}, nil
 	}
@@ -87,9 +335,18 @@ func allAppsPods(ctx context.Context, cfg config, apps []ketchv1.App) (*corev1.P
 		return nil, err
 	}
 
-	return cfg.KubernetesClient().CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
-		LabelSelector: s.String(),
-	})
+	pods := &corev1.PodList{}
+	listOptions := metav1.ListOptions{LabelSelector: s.String(), Limit: listPageSize}
+	for {
+		page, err := cfg.KubernetesClient().CoreV1().Pods(namespace).List(ctx, listOptions)
+		if err != nil {
+			return nil, err
+		}
+		pods.Items = append(pods.Items, page.Items...)
+		if listOptions.Continue = page.Continue; listOptions.Continue == "" {
+			return pods, nil
+		}
+	}
 }
 
 func filterAppPods(appName string, pods []corev1.Pod) []corev1.Pod {
@@ -103,9 +360,9 @@ func filterAppPods(appName string, pods []corev1.Pod) []corev1.Pod {
 }
 
 func appListNames(cfg config, nameFilter ...string) ([]string, error) {
-	apps := ketchv1.AppList{}
-	if err := cfg.Client().List(context.TODO(), &apps); err != nil {
-		return nil, fmt.Errorf("failed to list apps: %w", err)
+	apps, err := listApps(context.TODO(), cfg)
+	if err != nil {
+		return nil, err
 	}
 
 	appNames := make([]string, 0)
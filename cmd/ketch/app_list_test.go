@@ -3,13 +3,21 @@ package main
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"reflect"
+	"strconv"
 	"testing"
+	"time"
 
 	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
 	"github.com/theketchio/ketch/internal/mocks"
+	"github.com/theketchio/ketch/internal/utils/conversions"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	ktesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 func Test_appList(t *testing.T) {
@@ -36,6 +44,7 @@ func Test_appList(t *testing.T) {
 		Spec: ketchv1.AppSpec{
 			Description: "my app-b",
 			Namespace:   "fw1",
+			Team:        "search",
 			Ingress: ketchv1.IngressSpec{
 				GenerateDefaultCname: false,
 				Cnames:               ketchv1.CnameList{{Name: "app-b-cname1"}},
@@ -43,10 +52,29 @@ func Test_appList(t *testing.T) {
 			Builder: "",
 		},
 	}
+	appC := &ketchv1.App{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "app-c",
+			Labels: map[string]string{"team": "payments"},
+		},
+		Spec: ketchv1.AppSpec{
+			Description: "my app-c",
+			Namespace:   "fw2",
+			Deployments: []ketchv1.AppDeploymentSpec{
+				{Version: 1, Processes: []ketchv1.ProcessSpec{{Name: "web", Units: conversions.IntPtr(3)}}},
+			},
+		},
+		Status: ketchv1.AppStatus{
+			DeploymentHistory: []ketchv1.DeploymentRecord{
+				{DeployedAt: metav1.NewTime(time.Date(2022, 4, 1, 10, 0, 0, 0, time.UTC))},
+			},
+		},
+	}
 
 	tests := []struct {
-		name string
-		cfg  config
+		name    string
+		cfg     config
+		options appListOptions
 
 		wantOut string
 		wantErr bool
@@ -59,13 +87,86 @@ func Test_appList(t *testing.T) {
 			wantOut: `NAME     NAMESPACE    STATE      ADDRESSES              BUILDER    DESCRIPTION
 app-a    fw1          created    http://app-a-cname1               my app-a
 app-b    fw1          created    http://app-b-cname1               my app-b
+`,
+		},
+		{
+			name: "filter by namespace",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{appA, appB, appC},
+			},
+			options: appListOptions{namespace: "fw2"},
+			wantOut: `NAME     NAMESPACE    STATE      ADDRESSES    BUILDER    DESCRIPTION
+app-c    fw2          created                            my app-c
+`,
+		},
+		{
+			name: "filter by team",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{appA, appB, appC},
+			},
+			options: appListOptions{team: "search"},
+			wantOut: `NAME     NAMESPACE    STATE      ADDRESSES              BUILDER    DESCRIPTION
+app-b    fw1          created    http://app-b-cname1               my app-b
+`,
+		},
+		{
+			name: "filter by label",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{appA, appB, appC},
+			},
+			options: appListOptions{selector: "team=payments"},
+			wantOut: `NAME     NAMESPACE    STATE      ADDRESSES    BUILDER    DESCRIPTION
+app-c    fw2          created                            my app-c
+`,
+		},
+		{
+			name: "invalid label selector",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{appA, appB, appC},
+			},
+			options: appListOptions{selector: "team in ("},
+			wantErr: true,
+		},
+		{
+			name: "wide output sorted by units",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{appC, appB, appA},
+			},
+			options: appListOptions{sortBy: appListSortByUnits, output: appListOutputWide},
+			wantOut: `NAME     NAMESPACE    STATE      UNITS    LAST DEPLOY             HEALTH     ADDRESSES              BUILDER    DESCRIPTION
+app-a    fw1          created    0                                created    http://app-a-cname1               my app-a
+app-b    fw1          created    0                                created    http://app-b-cname1               my app-b
+app-c    fw2          created    3        2022-04-01T10:00:00Z    running                                      my app-c
+`,
+		},
+		{
+			name: "pods forbidden in a namespace",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{appA, appB, appC},
+				KubeClientReactors: []mocks.KubeClientReactor{{
+					Verb:     "list",
+					Resource: "pods",
+					Reaction: func(action ktesting.Action) (bool, runtime.Object, error) {
+						if namespace := action.GetNamespace(); namespace == "" || namespace == "fw1" {
+							return true, nil, apierrors.NewForbidden(corev1.Resource("pods"), "", fmt.Errorf("no access"))
+						}
+						return false, nil, nil
+					},
+				}},
+			},
+			wantOut: `NAME     NAMESPACE    STATE      ADDRESSES              BUILDER    DESCRIPTION
+app-a    fw1          unknown    http://app-a-cname1               my app-a
+app-b    fw1          unknown    http://app-b-cname1               my app-b
+app-c    fw2          created                                      my app-c
+
+States of apps in namespaces fw1 are unknown, you don't have permission to list pods there.
 `,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			out := &bytes.Buffer{}
-			err := appList(context.Background(), tt.cfg, out)
+			err := appList(context.Background(), tt.cfg, tt.options, out)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("appList() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -153,3 +254,92 @@ func Test_appListNames(t *testing.T) {
 		})
 	}
 }
+
+func Test_sortApps(t *testing.T) {
+	deployedAt := func(hour int) ketchv1.AppStatus {
+		return ketchv1.AppStatus{DeploymentHistory: []ketchv1.DeploymentRecord{
+			{DeployedAt: metav1.NewTime(time.Date(2022, 4, 1, hour, 0, 0, 0, time.UTC))},
+		}}
+	}
+	apps := []ketchv1.App{
+		{ObjectMeta: metav1.ObjectMeta{Name: "app-c"}, Spec: ketchv1.AppSpec{Namespace: "a"}, Status: deployedAt(9)},
+		{ObjectMeta: metav1.ObjectMeta{Name: "app-a"}, Spec: ketchv1.AppSpec{Namespace: "b"}, Status: deployedAt(11)},
+		{ObjectMeta: metav1.ObjectMeta{Name: "app-b"}, Spec: ketchv1.AppSpec{Namespace: "a"}, Status: deployedAt(10)},
+	}
+	tests := []struct {
+		sortBy string
+		want   []string
+	}{
+		{sortBy: appListSortByName, want: []string{"app-a", "app-b", "app-c"}},
+		{sortBy: appListSortByNamespace, want: []string{"app-b", "app-c", "app-a"}},
+		{sortBy: appListSortByLastDeploy, want: []string{"app-c", "app-b", "app-a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sortBy, func(t *testing.T) {
+			sorted := append([]ketchv1.App{}, apps...)
+			sortApps(sorted, tt.sortBy)
+			var names []string
+			for _, app := range sorted {
+				names = append(names, app.Name)
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("sortApps() got = %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+// pagedClient returns apps in pages of the requested limit like the apiserver does.
+type pagedClient struct {
+	client.Client
+	calls int
+}
+
+func (c *pagedClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	c.calls++
+	listOptions := client.ListOptions{}
+	listOptions.ApplyOptions(opts)
+	var all ketchv1.AppList
+	if err := c.Client.List(ctx, &all); err != nil {
+		return err
+	}
+	start, _ := strconv.Atoi(listOptions.Continue)
+	end := start + int(listOptions.Limit)
+	apps := list.(*ketchv1.AppList)
+	if end >= len(all.Items) {
+		apps.Items = all.Items[start:]
+		return nil
+	}
+	apps.Items = all.Items[start:end]
+	apps.Continue = strconv.Itoa(end)
+	return nil
+}
+
+type pagedConfiguration struct {
+	*mocks.Configuration
+	client *pagedClient
+}
+
+func (cfg *pagedConfiguration) Client() client.Client {
+	return cfg.client
+}
+
+func Test_listApps(t *testing.T) {
+	var objects []runtime.Object
+	for i := 0; i < 2*listPageSize+1; i++ {
+		objects = append(objects, &ketchv1.App{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("app-%04d", i)}})
+	}
+	mockCfg := &mocks.Configuration{CtrlClientObjects: objects}
+	cli := &pagedClient{Client: mockCfg.Client()}
+
+	apps, err := listApps(context.Background(), &pagedConfiguration{Configuration: mockCfg, client: cli})
+	if err != nil {
+		t.Fatalf("listApps() error = %v", err)
+	}
+	if len(apps.Items) != 2*listPageSize+1 {
+		t.Errorf("listApps() got %d apps, want %d", len(apps.Items), 2*listPageSize+1)
+	}
+	if cli.calls != 3 {
+		t.Errorf("listApps() made %d list calls, want 3", cli.calls)
+	}
+}
@@ -50,6 +50,7 @@ func newAppLogCmd(cfg config, out io.Writer, appLog appLogFn) *cobra.Command {
 		},
 	}
 	cmd.Flags().StringVarP(&options.processName, "process", "p", "", "Process name")
+	registerProcessNameCompletion(cmd, cfg, "process")
 	cmd.Flags().IntVarP(&options.deploymentVersion, "version", "v", 0, "Deployment version")
 	cmd.Flags().BoolVarP(&options.follow, "follow", "f", false, "Specify if the logs should be streamed")
 	cmd.Flags().BoolVar(&options.ignoreErrors, "ignore-errors", false, "If watching / following pod logs, allow for any errors that occur to be non-fatal")
@@ -99,13 +100,23 @@ func appLog(ctx context.Context, cfg config, options appLogOptions, out io.Write
 }
 
 type watchOptions struct {
-	namespace    string
-	selector     labels.Selector
-	follow       bool
-	ignoreErrors bool
-	timestamps   bool
-	prefix       bool
-	out          io.Writer
+	namespace string
+	selector  labels.Selector
+	// containerName if set, logs of this container are shown instead of the app container.
+	containerName string
+	follow        bool
+	ignoreErrors  bool
+	timestamps    bool
+	prefix        bool
+	out           io.Writer
+}
+
+// podContainerName returns a name of the container whose logs are shown.
+func (o watchOptions) podContainerName(pod corev1.Pod) (*string, error) {
+	if len(o.containerName) > 0 {
+		return &o.containerName, nil
+	}
+	return ketchContainerName(pod)
 }
 
 // ketchContainerName returns a name of an application container.
@@ -142,7 +153,7 @@ func watchLogs(cli kubernetes.Interface, options watchOptions, readLogs readLogs
 	// we are going to read logs from all running pods, just read without streaming.
 	msgChs := make(map[types.UID]chan logMessage, len(pods.Items))
 	for _, pod := range pods.Items {
-		containerName, err := ketchContainerName(pod)
+		containerName, err := options.podContainerName(pod)
 		if err != nil {
 			return err
 		}
@@ -218,7 +229,7 @@ func watchLogs(cli kubernetes.Interface, options watchOptions, readLogs readLogs
 				if _, ok := doneChannels[pod.UID]; ok {
 					continue
 				}
-				containerName, err := ketchContainerName(*pod)
+				containerName, err := options.podContainerName(*pod)
 				if err != nil {
 					if !options.ignoreErrors {
 						return err
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+)
+
+const appMaintenanceOnHelp = `
+Turn the maintenance mode of an application on.
+Requests to the application's cnames are answered with 503 and a static maintenance page,
+the page defaults to the maintenancePage of "ketch ingress set" or to a page of ketch.
+Use --stop to stop the application's processes while it's in maintenance, "ketch app maintenance off" starts them again.
+`
+
+const appMaintenanceOffHelp = `
+Turn the maintenance mode of an application off.
+Requests are routed to the application again, its processes are started if they were stopped by "ketch app maintenance on --stop".
+`
+
+func newAppMaintenanceCmd(cfg config, out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "maintenance",
+		Short: "Manage the maintenance mode of an application",
+		Long:  "Manage the maintenance mode of an application",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Usage()
+		},
+	}
+	cmd.AddCommand(requireAccess(newAppMaintenanceOnCmd(cfg, out), appsAccess("update")))
+	cmd.AddCommand(requireAccess(newAppMaintenanceOffCmd(cfg, out), appsAccess("update")))
+	return cmd
+}
+
+func newAppMaintenanceOnCmd(cfg config, out io.Writer) *cobra.Command {
+	options := appMaintenanceOnOptions{}
+	cmd := &cobra.Command{
+		Use:   "on APPNAME",
+		Args:  cobra.ExactValidArgs(1),
+		Short: "Turn the maintenance mode of an application on.",
+		Long:  appMaintenanceOnHelp,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			options.appName = args[0]
+			return appMaintenanceOn(cmd.Context(), cfg, options, out)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return autoCompleteAppNames(cfg, toComplete)
+		},
+	}
+	cmd.Flags().StringVar(&options.page, "page", "", "Path to an HTML file served as the maintenance page of the application")
+	cmd.Flags().BoolVar(&options.stop, "stop", false, "Stop the application's processes while it's in maintenance")
+	return cmd
+}
+
+type appMaintenanceOnOptions struct {
+	appName string
+	page    string
+	stop    bool
+}
+
+func appMaintenanceOn(ctx context.Context, cfg config, options appMaintenanceOnOptions, out io.Writer) error {
+	app := ketchv1.App{}
+	if err := cfg.Client().Get(ctx, types.NamespacedName{Name: options.appName}, &app); err != nil {
+		return fmt.Errorf("failed to get the app: %w", err)
+	}
+	if app.Spec.Maintenance == nil {
+		app.Spec.Maintenance = &ketchv1.MaintenanceSpec{}
+	}
+	if options.page != "" {
+		page, err := ioutil.ReadFile(options.page)
+		if err != nil {
+			return fmt.Errorf("failed to read the maintenance page: %w", err)
+		}
+		app.Spec.Maintenance.Page = string(page)
+	}
+	if options.stop && !app.Spec.Maintenance.ProcessesStopped {
+		if err := app.Stop(ketchv1.Selector{}); err != nil {
+			return fmt.Errorf("failed to stop the app: %w", err)
+		}
+		app.Spec.Maintenance.ProcessesStopped = true
+	}
+	if err := cfg.Client().Update(ctx, &app); err != nil {
+		return fmt.Errorf("failed to update the app: %w", err)
+	}
+	fmt.Fprintf(out, "%s is in maintenance.\n", app.Name)
+	return nil
+}
+
+func newAppMaintenanceOffCmd(cfg config, out io.Writer) *cobra.Command {
+	options := appMaintenanceOffOptions{}
+	cmd := &cobra.Command{
+		Use:   "off APPNAME",
+		Args:  cobra.ExactValidArgs(1),
+		Short: "Turn the maintenance mode of an application off.",
+		Long:  appMaintenanceOffHelp,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			options.appName = args[0]
+			return appMaintenanceOff(cmd.Context(), cfg, options, out)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return autoCompleteAppNames(cfg, toComplete)
+		},
+	}
+	return cmd
+}
+
+type appMaintenanceOffOptions struct {
+	appName string
+}
+
+func appMaintenanceOff(ctx context.Context, cfg config, options appMaintenanceOffOptions, out io.Writer) error {
+	app := ketchv1.App{}
+	if err := cfg.Client().Get(ctx, types.NamespacedName{Name: options.appName}, &app); err != nil {
+		return fmt.Errorf("failed to get the app: %w", err)
+	}
+	if app.Spec.Maintenance == nil {
+		fmt.Fprintf(out, "%s is not in maintenance.\n", app.Name)
+		return nil
+	}
+	if app.Spec.Maintenance.ProcessesStopped {
+		if err := app.Start(ketchv1.Selector{}); err != nil {
+			return fmt.Errorf("failed to start the app: %w", err)
+		}
+	}
+	app.Spec.Maintenance = nil
+	if err := cfg.Client().Update(ctx, &app); err != nil {
+		return fmt.Errorf("failed to update the app: %w", err)
+	}
+	fmt.Fprintf(out, "%s is no longer in maintenance.\n", app.Name)
+	return nil
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/mocks"
+)
+
+func newMaintenanceApp(maintenance *ketchv1.MaintenanceSpec, units int) *ketchv1.App {
+	return &ketchv1.App{
+		ObjectMeta: metav1.ObjectMeta{Name: "go-app"},
+		Spec: ketchv1.AppSpec{
+			Namespace: "ketch-go-app",
+			Deployments: []ketchv1.AppDeploymentSpec{
+				{Version: 1, Processes: []ketchv1.ProcessSpec{{Name: "web", Units: &units}}},
+			},
+			Maintenance: maintenance,
+		},
+	}
+}
+
+func TestAppMaintenanceOn(t *testing.T) {
+	page := filepath.Join(t.TempDir(), "maintenance.html")
+	require.Nil(t, os.WriteFile(page, []byte("<h1>Back soon</h1>\n"), 0644))
+	tests := []struct {
+		name            string
+		app             *ketchv1.App
+		options         appMaintenanceOnOptions
+		wantMaintenance *ketchv1.MaintenanceSpec
+		wantUnits       int
+		wantErr         string
+	}{
+		{
+			name:            "processes keep running",
+			app:             newMaintenanceApp(nil, 3),
+			options:         appMaintenanceOnOptions{appName: "go-app", page: page},
+			wantMaintenance: &ketchv1.MaintenanceSpec{Page: "<h1>Back soon</h1>\n"},
+			wantUnits:       3,
+		},
+		{
+			name:            "processes are stopped",
+			app:             newMaintenanceApp(nil, 3),
+			options:         appMaintenanceOnOptions{appName: "go-app", stop: true},
+			wantMaintenance: &ketchv1.MaintenanceSpec{ProcessesStopped: true},
+			wantUnits:       0,
+		},
+		{
+			name:            "page of an app in maintenance is changed",
+			app:             newMaintenanceApp(&ketchv1.MaintenanceSpec{ProcessesStopped: true}, 0),
+			options:         appMaintenanceOnOptions{appName: "go-app", page: page},
+			wantMaintenance: &ketchv1.MaintenanceSpec{Page: "<h1>Back soon</h1>\n", ProcessesStopped: true},
+			wantUnits:       0,
+		},
+		{
+			name:    "page not found",
+			app:     newMaintenanceApp(nil, 3),
+			options: appMaintenanceOnOptions{appName: "go-app", page: filepath.Join(t.TempDir(), "missing.html")},
+			wantErr: "failed to read the maintenance page",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &mocks.Configuration{CtrlClientObjects: []runtime.Object{tt.app}}
+			err := appMaintenanceOn(context.Background(), cfg, tt.options, &bytes.Buffer{})
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.Nil(t, err)
+			app := ketchv1.App{}
+			require.Nil(t, cfg.Client().Get(context.Background(), types.NamespacedName{Name: "go-app"}, &app))
+			require.Equal(t, tt.wantMaintenance, app.Spec.Maintenance)
+			require.Equal(t, tt.wantUnits, *app.Spec.Deployments[0].Processes[0].Units)
+		})
+	}
+}
+
+func TestAppMaintenanceOff(t *testing.T) {
+	tests := []struct {
+		name      string
+		app       *ketchv1.App
+		wantUnits int
+		wantOut   string
+	}{
+		{
+			name:      "processes stopped by maintenance are started",
+			app:       newMaintenanceApp(&ketchv1.MaintenanceSpec{ProcessesStopped: true}, 3),
+			wantUnits: 3,
+			wantOut:   "go-app is no longer in maintenance.\n",
+		},
+		{
+			name:      "app is not in maintenance",
+			app:       newMaintenanceApp(nil, 2),
+			wantUnits: 2,
+			wantOut:   "go-app is not in maintenance.\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.app.Spec.Maintenance != nil && tt.app.Spec.Maintenance.ProcessesStopped {
+				require.Nil(t, tt.app.Stop(ketchv1.Selector{}))
+			}
+			cfg := &mocks.Configuration{CtrlClientObjects: []runtime.Object{tt.app}}
+			out := &bytes.Buffer{}
+			err := appMaintenanceOff(context.Background(), cfg, appMaintenanceOffOptions{appName: "go-app"}, out)
+			require.Nil(t, err)
+			require.Equal(t, tt.wantOut, out.String())
+			app := ketchv1.App{}
+			require.Nil(t, cfg.Client().Get(context.Background(), types.NamespacedName{Name: "go-app"}, &app))
+			require.Nil(t, app.Spec.Maintenance)
+			require.Equal(t, tt.wantUnits, *app.Spec.Deployments[0].Processes[0].Units)
+		})
+	}
+}
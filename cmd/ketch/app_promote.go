@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/deploy"
+	"github.com/theketchio/ketch/internal/utils"
+)
+
+const appPromoteHelp = `
+Promote the current deployment of an app to another app, e.g. from staging to production.
+The image pinned to its digest, the processes with their units and commands, ketch.yaml and env variables
+of the source app are deployed as a new version of the target app:
+  ketch app promote myapp-staging myapp-prod --exclude-env DATABASE_URL,REDIS_URL
+
+Env variables passed with --exclude-env keep their values of the target app, other variables of the target app
+are replaced. A target app that doesn't exist is created in the namespace passed with --namespace.
+
+A diff of the target app's manifests is printed before the promotion, --dry-run prints the diff only.
+`
+
+// imageDigestFn returns a reference to an image pinned to its digest.
+type imageDigestFn func(ctx context.Context, kubeClient kubernetes.Interface, image, secretName, secretNamespace string) (string, error)
+
+func newAppPromoteCmd(cfg config, out io.Writer) *cobra.Command {
+	options := appPromoteOptions{imageDigest: deploy.GetImageDigest}
+	cmd := &cobra.Command{
+		Use:   "promote SOURCE_APP TARGET_APP",
+		Short: "Promote the current deployment of an app to another app.",
+		Long:  appPromoteHelp,
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			options.sourceApp = args[0]
+			options.targetApp = args[1]
+			return appPromote(cmd.Context(), cfg, options, out)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return autoCompleteAppNames(cfg, toComplete)
+		},
+	}
+	cmd.Flags().StringVarP(&options.namespace, deploy.FlagNamespace, deploy.FlagNamespaceShort, "", "Namespace of the target app if it doesn't exist.")
+	cmd.Flags().StringSliceVar(&options.excludeEnvs, "exclude-env", nil, "Names of env variables that aren't promoted, the target app keeps its values.")
+	cmd.Flags().BoolVar(&options.dryRun, deploy.FlagDryRun, false, "Print a diff of the target app's manifests without promoting.")
+	return cmd
+}
+
+type appPromoteOptions struct {
+	sourceApp   string
+	targetApp   string
+	namespace   string
+	excludeEnvs []string
+	dryRun      bool
+	imageDigest imageDigestFn
+}
+
+func appPromote(ctx context.Context, cfg config, options appPromoteOptions, out io.Writer) error {
+	if options.sourceApp == options.targetApp {
+		return errors.New("source and target apps must be different")
+	}
+	source := ketchv1.App{}
+	if err := cfg.Client().Get(ctx, types.NamespacedName{Name: options.sourceApp}, &source); err != nil {
+		return fmt.Errorf("failed to get app %q: %w", options.sourceApp, err)
+	}
+	if len(source.Spec.Deployments) == 0 {
+		return fmt.Errorf("app %q has no deployment to promote", source.Name)
+	}
+	if source.Spec.Canary.Active {
+		return fmt.Errorf("can't promote app %q while a canary deployment is active", source.Name)
+	}
+
+	var current *ketchv1.App
+	target := ketchv1.App{}
+	err := cfg.Client().Get(ctx, types.NamespacedName{Name: options.targetApp}, &target)
+	switch {
+	case err == nil:
+		current = target.DeepCopy()
+		if target.Spec.Canary.Active {
+			return fmt.Errorf("can't promote to app %q while a canary deployment is active", target.Name)
+		}
+	case apierrors.IsNotFound(err):
+		if options.namespace == "" {
+			return fmt.Errorf("app %q doesn't exist, pass --namespace to create it", options.targetApp)
+		}
+		target.Name = options.targetApp
+		target.Spec.Namespace = options.namespace
+		target.Spec.Ingress.GenerateDefaultCname = true
+	default:
+		return fmt.Errorf("failed to get app %q: %w", options.targetApp, err)
+	}
+
+	deployment := source.Spec.Deployments[0].DeepCopy()
+	secretName := source.Spec.DockerRegistry.SecretName
+	if len(deployment.ImagePullSecrets) > 0 {
+		secretName = deployment.ImagePullSecrets[0].Name
+	}
+	image, err := options.imageDigest(ctx, cfg.KubernetesClient(), deployment.Image, secretName, source.Spec.Namespace)
+	if err != nil {
+		return err
+	}
+	deployment.Image = image
+	// pull secrets of the source deployment are in its namespace, the target app uses its own registry secret.
+	deployment.ImagePullSecrets = nil
+	excluded := make(map[string]bool, len(options.excludeEnvs))
+	for _, name := range options.excludeEnvs {
+		excluded[name] = true
+	}
+	for i := range deployment.Processes {
+		deployment.Processes[i].Env = promotedEnvs(deployment.Processes[i].Env, nil, excluded)
+	}
+	target.Spec.Env = promotedEnvs(source.Spec.Env, target.Spec.Env, excluded)
+	target.Spec.DeploymentsCount += 1
+	deployment.Version = ketchv1.DeploymentVersion(target.Spec.DeploymentsCount)
+	deployment.RoutingSettings = ketchv1.RoutingSettings{Weight: 100}
+	target.Spec.Deployments = []ketchv1.AppDeploymentSpec{*deployment}
+
+	svc := &deploy.Services{
+		Client:     cfg.Client(),
+		KubeClient: cfg.KubernetesClient(),
+		Templates:  cfg.Storage(),
+		Writer:     out,
+	}
+	if _, err := deploy.WriteAppDiff(ctx, svc, current, &target); err != nil {
+		return err
+	}
+	if options.dryRun {
+		return nil
+	}
+
+	if target.Annotations == nil {
+		target.Annotations = map[string]string{}
+	}
+	target.Annotations[utils.KetchDeployedByAnnotation] = utils.CurrentUser()
+	if current == nil {
+		if err := cfg.Client().Create(ctx, &target); err != nil {
+			return fmt.Errorf("failed to create app %q: %w", target.Name, err)
+		}
+	} else if err := cfg.Client().Update(ctx, &target); err != nil {
+		return fmt.Errorf("failed to update app %q: %w", target.Name, err)
+	}
+	fmt.Fprintf(out, "Promoted %s version %d to %s as version %d with image %s.\n",
+		source.Name, source.Spec.Deployments[0].Version, target.Name, deployment.Version, image)
+	return nil
+}
+
+// promotedEnvs returns the source envs without the excluded ones, excluded envs keep their values of the target.
+func promotedEnvs(source, target []ketchv1.Env, excluded map[string]bool) []ketchv1.Env {
+	var envs []ketchv1.Env
+	for _, env := range source {
+		if !excluded[env.Name] {
+			envs = append(envs, env)
+		}
+	}
+	for _, env := range target {
+		if excluded[env.Name] {
+			envs = append(envs, env)
+		}
+	}
+	return envs
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/mocks"
+	"github.com/theketchio/ketch/internal/templates"
+)
+
+// staticTemplatesStorage is a templates.Client returning the default nginx templates.
+type staticTemplatesStorage struct {
+	staticTemplates
+}
+
+func (staticTemplatesStorage) Update(string, templates.Templates) error {
+	return nil
+}
+
+func TestAppPromote(t *testing.T) {
+	ingressConfigmap := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: ketchv1.IngressConfigmapName, Namespace: ketchv1.IngressConfigmapNamespace},
+		Data:       map[string]string{"className": "nginx", "serviceEndpoint": "10.10.10.10", "ingressType": "nginx"},
+	}
+	units := 3
+	staging := &ketchv1.App{
+		ObjectMeta: metav1.ObjectMeta{Name: "shop-staging"},
+		Spec: ketchv1.AppSpec{
+			Namespace: "staging",
+			Env:       []ketchv1.Env{{Name: "LOG_LEVEL", Value: "debug"}, {Name: "DATABASE_URL", Value: "postgres://staging"}},
+			Deployments: []ketchv1.AppDeploymentSpec{{
+				Image:           "shipa/shop:1.2",
+				Version:         4,
+				Processes:       []ketchv1.ProcessSpec{{Name: "web", Cmd: []string{"./shop"}, Units: &units}},
+				RoutingSettings: ketchv1.RoutingSettings{Weight: 100},
+			}},
+			DeploymentsCount: 4,
+		},
+	}
+	prod := &ketchv1.App{
+		ObjectMeta: metav1.ObjectMeta{Name: "shop-prod"},
+		Spec: ketchv1.AppSpec{
+			Namespace: "prod",
+			Env:       []ketchv1.Env{{Name: "DATABASE_URL", Value: "postgres://prod"}, {Name: "DEBUG", Value: "1"}},
+			Deployments: []ketchv1.AppDeploymentSpec{{
+				Image:           "shipa/shop:1.1",
+				Version:         2,
+				Processes:       []ketchv1.ProcessSpec{{Name: "web", Cmd: []string{"./shop"}}},
+				RoutingSettings: ketchv1.RoutingSettings{Weight: 100},
+			}},
+			DeploymentsCount: 2,
+		},
+	}
+	imageDigest := func(ctx context.Context, kubeClient kubernetes.Interface, image, secretName, secretNamespace string) (string, error) {
+		require.Equal(t, "shipa/shop:1.2", image)
+		require.Equal(t, "staging", secretNamespace)
+		return "index.docker.io/shipa/shop@sha256:0123", nil
+	}
+	newCfg := func() *mocks.Configuration {
+		cfg := &mocks.Configuration{
+			CtrlClientObjects: []runtime.Object{staging.DeepCopy(), prod.DeepCopy()},
+			KubeClientObjects: []runtime.Object{ingressConfigmap.DeepCopy()},
+		}
+		cfg.StorageInstance = staticTemplatesStorage{}
+		return cfg
+	}
+
+	t.Run("dry run", func(t *testing.T) {
+		cfg := newCfg()
+		out := &bytes.Buffer{}
+		options := appPromoteOptions{sourceApp: "shop-staging", targetApp: "shop-prod", excludeEnvs: []string{"DATABASE_URL"}, dryRun: true, imageDigest: imageDigest}
+		require.Nil(t, appPromote(context.Background(), cfg, options, out))
+		require.Contains(t, out.String(), "+          image: index.docker.io/shipa/shop@sha256:0123\n")
+		require.NotContains(t, out.String(), "Promoted")
+		app := ketchv1.App{}
+		require.Nil(t, cfg.Client().Get(context.Background(), types.NamespacedName{Name: "shop-prod"}, &app))
+		require.Equal(t, prod.Spec, app.Spec)
+	})
+
+	t.Run("existing target app", func(t *testing.T) {
+		cfg := newCfg()
+		out := &bytes.Buffer{}
+		options := appPromoteOptions{sourceApp: "shop-staging", targetApp: "shop-prod", excludeEnvs: []string{"DATABASE_URL"}, imageDigest: imageDigest}
+		require.Nil(t, appPromote(context.Background(), cfg, options, out))
+		require.Contains(t, out.String(), "Promoted shop-staging version 4 to shop-prod as version 3 with image index.docker.io/shipa/shop@sha256:0123.\n")
+
+		app := ketchv1.App{}
+		require.Nil(t, cfg.Client().Get(context.Background(), types.NamespacedName{Name: "shop-prod"}, &app))
+		require.Equal(t, "prod", app.Spec.Namespace)
+		require.Equal(t, []ketchv1.Env{{Name: "LOG_LEVEL", Value: "debug"}, {Name: "DATABASE_URL", Value: "postgres://prod"}}, app.Spec.Env)
+		require.Equal(t, 3, app.Spec.DeploymentsCount)
+		require.Len(t, app.Spec.Deployments, 1)
+		deployment := app.Spec.Deployments[0]
+		require.Equal(t, "index.docker.io/shipa/shop@sha256:0123", deployment.Image)
+		require.Equal(t, ketchv1.DeploymentVersion(3), deployment.Version)
+		require.Equal(t, staging.Spec.Deployments[0].Processes, deployment.Processes)
+	})
+
+	t.Run("new target app", func(t *testing.T) {
+		cfg := newCfg()
+		options := appPromoteOptions{sourceApp: "shop-staging", targetApp: "shop-qa", imageDigest: imageDigest}
+		err := appPromote(context.Background(), cfg, options, &bytes.Buffer{})
+		require.Equal(t, `app "shop-qa" doesn't exist, pass --namespace to create it`, err.Error())
+
+		options.namespace = "qa"
+		require.Nil(t, appPromote(context.Background(), cfg, options, &bytes.Buffer{}))
+		app := ketchv1.App{}
+		require.Nil(t, cfg.Client().Get(context.Background(), types.NamespacedName{Name: "shop-qa"}, &app))
+		require.Equal(t, "qa", app.Spec.Namespace)
+		require.Equal(t, staging.Spec.Env, app.Spec.Env)
+		require.Equal(t, 1, app.Spec.DeploymentsCount)
+		require.True(t, app.Spec.Ingress.GenerateDefaultCname)
+	})
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+
+	"github.com/spf13/cobra"
+)
+
+const defaultRegistryServer = "https://index.docker.io/v1/"
+
+func newAppRegistrySecretCmd(cfg config, out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "registry-secret",
+		Short: "Manage the secret used to pull images of an application",
+		Long:  "Manage the secret used to pull images of an application",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Usage()
+		},
+	}
+	cmd.AddCommand(requireAccess(newAppRegistrySecretSetCmd(cfg, out), appsAccess("update")))
+	cmd.AddCommand(requireAccess(newAppRegistrySecretUnsetCmd(cfg, out), appsAccess("update")))
+	return cmd
+}
+
+// registrySecretName returns a name of the docker-registry secret ketch creates for the app.
+func registrySecretName(appName string) string {
+	return appName + "-registry"
+}
+
+// dockerConfigJSON returns the content of a kubernetes.io/dockerconfigjson secret with credentials of a single registry.
+func dockerConfigJSON(server, username, password, email string) ([]byte, error) {
+	type authEntry struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+		Email    string `json:"email,omitempty"`
+		Auth     string `json:"auth"`
+	}
+	config := struct {
+		Auths map[string]authEntry `json:"auths"`
+	}{
+		Auths: map[string]authEntry{
+			server: {
+				Username: username,
+				Password: password,
+				Email:    email,
+				Auth:     base64.StdEncoding.EncodeToString([]byte(username + ":" + password)),
+			},
+		},
+	}
+	return json.Marshal(config)
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+)
+
+const appRegistrySecretSetHelp = `
+Create a docker-registry secret in the app's namespace and use it to pull images of the application.
+Running the command again updates the credentials of the secret.
+
+A secret used by default for new applications can be set with "default-registry-secret" in the config.toml,
+it must exist in the namespace of each app.
+`
+
+func newAppRegistrySecretSetCmd(cfg config, out io.Writer) *cobra.Command {
+	options := appRegistrySecretSetOptions{}
+	cmd := &cobra.Command{
+		Use:   "set APPNAME",
+		Args:  cobra.ExactValidArgs(1),
+		Short: "Set credentials to pull images of an application.",
+		Long:  appRegistrySecretSetHelp,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			options.appName = args[0]
+			if options.passwordStdin {
+				password, err := ioutil.ReadAll(cmd.InOrStdin())
+				if err != nil {
+					return fmt.Errorf("failed to read the password: %w", err)
+				}
+				options.password = strings.TrimRight(string(password), "\r\n")
+			}
+			return appRegistrySecretSet(cmd.Context(), cfg, options, out)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return autoCompleteAppNames(cfg, toComplete)
+		},
+	}
+	cmd.Flags().StringVar(&options.server, "server", defaultRegistryServer, "Address of the docker registry.")
+	cmd.Flags().StringVar(&options.username, "username", "", "Username to log in to the registry.")
+	cmd.Flags().StringVar(&options.password, "password", "", "Password to log in to the registry.")
+	cmd.Flags().BoolVar(&options.passwordStdin, "password-stdin", false, "Read the password from stdin.")
+	cmd.Flags().StringVar(&options.email, "email", "", "Email of the registry account.")
+	cmd.MarkFlagRequired("username")
+	return cmd
+}
+
+type appRegistrySecretSetOptions struct {
+	appName       string
+	server        string
+	username      string
+	password      string
+	passwordStdin bool
+	email         string
+}
+
+func appRegistrySecretSet(ctx context.Context, cfg config, options appRegistrySecretSetOptions, out io.Writer) error {
+	if len(options.password) == 0 {
+		return errors.New("password is required, use either --password or --password-stdin")
+	}
+	app := ketchv1.App{}
+	if err := cfg.Client().Get(ctx, types.NamespacedName{Name: options.appName}, &app); err != nil {
+		return fmt.Errorf("failed to get the app: %w", err)
+	}
+	data, err := dockerConfigJSON(options.server, options.username, options.password, options.email)
+	if err != nil {
+		return err
+	}
+	name := registrySecretName(app.Name)
+	secret := v1.Secret{}
+	err = cfg.Client().Get(ctx, types.NamespacedName{Name: name, Namespace: app.Spec.Namespace}, &secret)
+	switch {
+	case k8serrors.IsNotFound(err):
+		secret = v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: app.Spec.Namespace,
+				Labels:    map[string]string{ketchv1.Group + "/app-name": app.Name},
+			},
+			Type: v1.SecretTypeDockerConfigJson,
+			Data: map[string][]byte{v1.DockerConfigJsonKey: data},
+		}
+		if err := cfg.Client().Create(ctx, &secret); err != nil {
+			return fmt.Errorf("failed to create the secret: %w", err)
+		}
+	case err != nil:
+		return fmt.Errorf("failed to get the secret: %w", err)
+	default:
+		if secret.Labels[ketchv1.Group+"/app-name"] != app.Name {
+			return fmt.Errorf("secret %s already exists and is not managed by ketch", name)
+		}
+		secret.Type = v1.SecretTypeDockerConfigJson
+		secret.Data = map[string][]byte{v1.DockerConfigJsonKey: data}
+		if err := cfg.Client().Update(ctx, &secret); err != nil {
+			return fmt.Errorf("failed to update the secret: %w", err)
+		}
+	}
+	if app.Spec.DockerRegistry.SecretName != name {
+		app.Spec.DockerRegistry.SecretName = name
+		if err := cfg.Client().Update(ctx, &app); err != nil {
+			return fmt.Errorf("failed to update the app: %w", err)
+		}
+	}
+	fmt.Fprintf(out, "Images of %s are pulled with the secret %s.\n", app.Name, name)
+	return nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/mocks"
+)
+
+func newRegistrySecretApp(secretName string) *ketchv1.App {
+	return &ketchv1.App{
+		ObjectMeta: metav1.ObjectMeta{Name: "go-app"},
+		Spec: ketchv1.AppSpec{
+			Namespace:      "ketch-go-app",
+			DockerRegistry: ketchv1.DockerRegistrySpec{SecretName: secretName},
+		},
+	}
+}
+
+func TestAppRegistrySecretSet(t *testing.T) {
+	managedSecret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "go-app-registry", Namespace: "ketch-go-app", Labels: map[string]string{"theketch.io/app-name": "go-app"}},
+		Type:       v1.SecretTypeDockerConfigJson,
+		Data:       map[string][]byte{v1.DockerConfigJsonKey: []byte(`{"auths":{}}`)},
+	}
+	manualSecret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "go-app-registry", Namespace: "ketch-go-app"},
+	}
+	tests := []struct {
+		name    string
+		objects []runtime.Object
+		options appRegistrySecretSetOptions
+		wantErr string
+	}{
+		{
+			name:    "new secret",
+			objects: []runtime.Object{newRegistrySecretApp("")},
+			options: appRegistrySecretSetOptions{appName: "go-app", server: "ghcr.io", username: "ketch", password: "secret"},
+		},
+		{
+			name:    "credentials of an existing secret are updated",
+			objects: []runtime.Object{newRegistrySecretApp("go-app-registry"), managedSecret},
+			options: appRegistrySecretSetOptions{appName: "go-app", server: "ghcr.io", username: "ketch", password: "secret"},
+		},
+		{
+			name:    "secret created manually",
+			objects: []runtime.Object{newRegistrySecretApp(""), manualSecret},
+			options: appRegistrySecretSetOptions{appName: "go-app", server: "ghcr.io", username: "ketch", password: "secret"},
+			wantErr: "secret go-app-registry already exists and is not managed by ketch",
+		},
+		{
+			name:    "missing password",
+			objects: []runtime.Object{newRegistrySecretApp("")},
+			options: appRegistrySecretSetOptions{appName: "go-app", server: "ghcr.io", username: "ketch"},
+			wantErr: "password is required, use either --password or --password-stdin",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &mocks.Configuration{CtrlClientObjects: tt.objects}
+			err := appRegistrySecretSet(context.Background(), cfg, tt.options, &bytes.Buffer{})
+			if len(tt.wantErr) > 0 {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.Nil(t, err)
+			app := ketchv1.App{}
+			require.Nil(t, cfg.Client().Get(context.Background(), types.NamespacedName{Name: "go-app"}, &app))
+			require.Equal(t, "go-app-registry", app.Spec.DockerRegistry.SecretName)
+			secret := v1.Secret{}
+			require.Nil(t, cfg.Client().Get(context.Background(), types.NamespacedName{Name: "go-app-registry", Namespace: "ketch-go-app"}, &secret))
+			require.Equal(t, v1.SecretTypeDockerConfigJson, secret.Type)
+			require.JSONEq(t, `{"auths":{"ghcr.io":{"username":"ketch","password":"secret","auth":"a2V0Y2g6c2VjcmV0"}}}`, string(secret.Data[v1.DockerConfigJsonKey]))
+		})
+	}
+}
+
+func TestAppRegistrySecretUnset(t *testing.T) {
+	tests := []struct {
+		name              string
+		secret            *v1.Secret
+		wantSecretDeleted bool
+	}{
+		{
+			name:              "secret created by ketch is deleted",
+			secret:            &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "go-app-registry", Namespace: "ketch-go-app", Labels: map[string]string{"theketch.io/app-name": "go-app"}}},
+			wantSecretDeleted: true,
+		},
+		{
+			name:   "secret created manually is kept",
+			secret: &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "go-app-registry", Namespace: "ketch-go-app"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &mocks.Configuration{CtrlClientObjects: []runtime.Object{newRegistrySecretApp("go-app-registry"), tt.secret}}
+			err := appRegistrySecretUnset(context.Background(), cfg, appRegistrySecretUnsetOptions{appName: "go-app"}, &bytes.Buffer{})
+			require.Nil(t, err)
+			app := ketchv1.App{}
+			require.Nil(t, cfg.Client().Get(context.Background(), types.NamespacedName{Name: "go-app"}, &app))
+			require.Equal(t, "", app.Spec.DockerRegistry.SecretName)
+			err = cfg.Client().Get(context.Background(), types.NamespacedName{Name: "go-app-registry", Namespace: "ketch-go-app"}, &v1.Secret{})
+			require.Equal(t, tt.wantSecretDeleted, k8serrors.IsNotFound(err))
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+)
+
+const appRegistrySecretUnsetHelp = `
+Stop using a secret to pull images of an application.
+The secret is removed if it was created by "ketch app registry-secret set".
+`
+
+func newAppRegistrySecretUnsetCmd(cfg config, out io.Writer) *cobra.Command {
+	options := appRegistrySecretUnsetOptions{}
+	cmd := &cobra.Command{
+		Use:   "unset APPNAME",
+		Args:  cobra.ExactValidArgs(1),
+		Short: "Stop using a secret to pull images of an application.",
+		Long:  appRegistrySecretUnsetHelp,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			options.appName = args[0]
+			return appRegistrySecretUnset(cmd.Context(), cfg, options, out)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return autoCompleteAppNames(cfg, toComplete)
+		},
+	}
+	return cmd
+}
+
+type appRegistrySecretUnsetOptions struct {
+	appName string
+}
+
+func appRegistrySecretUnset(ctx context.Context, cfg config, options appRegistrySecretUnsetOptions, out io.Writer) error {
+	app := ketchv1.App{}
+	if err := cfg.Client().Get(ctx, types.NamespacedName{Name: options.appName}, &app); err != nil {
+		return fmt.Errorf("failed to get the app: %w", err)
+	}
+	name := app.Spec.DockerRegistry.SecretName
+	if len(name) == 0 {
+		fmt.Fprintf(out, "%s doesn't use a secret to pull images.\n", app.Name)
+		return nil
+	}
+	app.Spec.DockerRegistry.SecretName = ""
+	if err := cfg.Client().Update(ctx, &app); err != nil {
+		return fmt.Errorf("failed to update the app: %w", err)
+	}
+	secret := v1.Secret{}
+	err := cfg.Client().Get(ctx, types.NamespacedName{Name: name, Namespace: app.Spec.Namespace}, &secret)
+	switch {
+	case k8serrors.IsNotFound(err):
+	case err != nil:
+		return fmt.Errorf("failed to get the secret: %w", err)
+	case secret.Labels[ketchv1.Group+"/app-name"] == app.Name:
+		// secrets created manually are left untouched.
+		if err := cfg.Client().Delete(ctx, &secret); err != nil && !k8serrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete the secret: %w", err)
+		}
+	}
+	fmt.Fprintf(out, "%s no longer uses the secret %s.\n", app.Name, name)
+	return nil
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/theketchio/ketch/cmd/ketch/output"
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/chart"
+)
+
+const appReleasesHelp = `
+List revisions of the helm release of an application, the oldest first.
+The controller keeps the number of revisions set by its --helm-max-history flag, 10 by default.
+Releases are read from the storage the controller uses, --helm-driver and --helm-namespace must match
+the --helm-driver and --helm-storage-namespace flags of the controller if they are set.
+`
+
+const appReleasesRollbackHelp = `
+Roll an application back to the latest deployment of a revision of its helm release.
+The deployment is deployed again with "ketch app rollback" rather than with "helm rollback",
+so the app keeps the rolled back version after the controller reconciles it. The deployment must be
+in the deployment history shown by "ketch app history".
+`
+
+type appReleasesOptions struct {
+	appName string
+	storage chart.HelmStorage
+}
+
+type appReleaseOutput struct {
+	Revision    int    `json:"revision" yaml:"revision"`
+	Updated     string `json:"updated" yaml:"updated"`
+	Status      string `json:"status" yaml:"status"`
+	Versions    string `json:"versions" yaml:"versions"`
+	Description string `json:"description" yaml:"description"`
+}
+
+func newAppReleasesCmd(cfg config, out io.Writer) *cobra.Command {
+	options := appReleasesOptions{}
+	cmd := &cobra.Command{
+		Use:   "releases APPNAME",
+		Short: "List revisions of the helm release of an application.",
+		Long:  appReleasesHelp,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			options.appName = args[0]
+			return appReleases(cmd.Context(), cfg, options, out)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return autoCompleteAppNames(cfg, toComplete)
+		},
+	}
+	addHelmStorageFlags(cmd, &options.storage)
+	cmd.AddCommand(requireAccess(newAppReleasesRollbackCmd(cfg, out), appsAccess("update")))
+	return cmd
+}
+
+func newAppReleasesRollbackCmd(cfg config, out io.Writer) *cobra.Command {
+	options := appReleasesOptions{}
+	cmd := &cobra.Command{
+		Use:   "rollback APPNAME REVISION",
+		Short: "Roll an application back to a revision of its helm release.",
+		Long:  appReleasesRollbackHelp,
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			options.appName = args[0]
+			revision, err := strconv.Atoi(args[1])
+			if err != nil || revision <= 0 {
+				return fmt.Errorf("invalid revision %q, it must be a positive number", args[1])
+			}
+			return appReleasesRollback(cmd.Context(), cfg, options, revision, out)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return autoCompleteAppNames(cfg, toComplete)
+		},
+	}
+	addHelmStorageFlags(cmd, &options.storage)
+	return cmd
+}
+
+func addHelmStorageFlags(cmd *cobra.Command, storage *chart.HelmStorage) {
+	cmd.Flags().StringVar(&storage.Driver, "helm-driver", chart.SecretStorageDriver, "Storage of helm releases, either \"secret\" or \"configmap\".")
+	cmd.Flags().StringVar(&storage.Namespace, "helm-namespace", "", "Namespace of helm releases, the app's namespace by default.")
+}
+
+func appReleases(ctx context.Context, cfg config, options appReleasesOptions, out io.Writer) error {
+	revisions, err := appReleaseRevisions(ctx, cfg, options)
+	if err != nil {
+		return err
+	}
+	return output.Write(generateAppReleasesOutput(revisions), out, "column")
+}
+
+func appReleasesRollback(ctx context.Context, cfg config, options appReleasesOptions, revision int, out io.Writer) error {
+	revisions, err := appReleaseRevisions(ctx, cfg, options)
+	if err != nil {
+		return err
+	}
+	for _, r := range revisions {
+		if r.Revision != revision {
+			continue
+		}
+		deployment := r.LatestDeployment()
+		if deployment == nil {
+			return fmt.Errorf("revision %d of app %s has no deployments to roll back to", revision, options.appName)
+		}
+		return appRollback(ctx, cfg, appRollbackOptions{appName: options.appName, version: deployment.Version}, out)
+	}
+	return fmt.Errorf("revision %d of app %s not found", revision, options.appName)
+}
+
+func appReleaseRevisions(ctx context.Context, cfg config, options appReleasesOptions) ([]chart.ReleaseRevision, error) {
+	if err := options.storage.Validate(); err != nil {
+		return nil, err
+	}
+	app := ketchv1.App{}
+	if err := cfg.Client().Get(ctx, types.NamespacedName{Name: options.appName}, &app); err != nil {
+		return nil, fmt.Errorf("failed to get app: %w", err)
+	}
+	revisions, err := chart.ReleaseHistory(cfg.KubernetesClient(), options.storage, app.Spec.Namespace, app.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get releases of app %s: %w", app.Name, err)
+	}
+	return revisions, nil
+}
+
+func generateAppReleasesOutput(revisions []chart.ReleaseRevision) []appReleaseOutput {
+	releases := make([]appReleaseOutput, 0, len(revisions))
+	for _, r := range revisions {
+		versions := make([]string, 0, len(r.Deployments))
+		for _, deployment := range r.Deployments {
+			versions = append(versions, deployment.Version.String())
+		}
+		releases = append(releases, appReleaseOutput{
+			Revision:    r.Revision,
+			Updated:     r.Updated.UTC().Format(time.RFC3339),
+			Status:      string(r.Status),
+			Versions:    strings.Join(versions, ","),
+			Description: r.Description,
+		})
+	}
+	return releases
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage/driver"
+	helmTime "helm.sh/helm/v3/pkg/time"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/chart"
+	"github.com/theketchio/ketch/internal/mocks"
+)
+
+// releaseSecrets returns secrets of helm releases of the app the way the controller stores them.
+func releaseSecrets(t *testing.T, namespace string, releases ...*release.Release) []runtime.Object {
+	clientset := fake.NewSimpleClientset()
+	secrets := driver.NewSecrets(clientset.CoreV1().Secrets(namespace))
+	for _, rel := range releases {
+		require.Nil(t, secrets.Create(fmt.Sprintf("%s.v%d", rel.Name, rel.Version), rel))
+	}
+	list, err := clientset.CoreV1().Secrets(namespace).List(context.Background(), metav1.ListOptions{})
+	require.Nil(t, err)
+	objects := make([]runtime.Object, 0, len(list.Items))
+	for i := range list.Items {
+		objects = append(objects, &list.Items[i])
+	}
+	return objects
+}
+
+func TestAppReleases(t *testing.T) {
+	mockApp := &ketchv1.App{
+		ObjectMeta: metav1.ObjectMeta{Name: "hello"},
+		Spec: ketchv1.AppSpec{
+			Namespace:        "default",
+			DeploymentsCount: 3,
+			Deployments:      []ketchv1.AppDeploymentSpec{{Image: "nginx:3.0", Version: 3, RoutingSettings: ketchv1.RoutingSettings{Weight: 100}}},
+		},
+		Status: ketchv1.AppStatus{
+			DeploymentHistory: []ketchv1.DeploymentRecord{
+				{AppDeploymentSpec: ketchv1.AppDeploymentSpec{Image: "nginx:1.0", Version: 1}},
+				{AppDeploymentSpec: ketchv1.AppDeploymentSpec{Image: "nginx:2.0", Version: 2}},
+				{AppDeploymentSpec: ketchv1.AppDeploymentSpec{Image: "nginx:3.0", Version: 3}},
+			},
+		},
+	}
+	newRelease := func(revision int, status release.Status, versions ...int) *release.Release {
+		deployments := make([]interface{}, 0, len(versions))
+		for _, version := range versions {
+			deployments = append(deployments, map[string]interface{}{"version": version})
+		}
+		return &release.Release{
+			Name:      "hello",
+			Namespace: "default",
+			Version:   revision,
+			Info: &release.Info{
+				Status:       status,
+				Description:  "Upgrade complete",
+				LastDeployed: helmTime.Date(2022, time.June, revision, 9, 0, 0, 0, time.UTC),
+			},
+			Config: map[string]interface{}{"app": map[string]interface{}{"deployments": deployments}},
+		}
+	}
+	cfg := &mocks.Configuration{
+		CtrlClientObjects: []runtime.Object{mockApp},
+		KubeClientObjects: releaseSecrets(t, "default",
+			newRelease(1, release.StatusSuperseded, 1),
+			newRelease(2, release.StatusSuperseded, 1, 2),
+			newRelease(3, release.StatusDeployed, 3),
+		),
+	}
+	options := appReleasesOptions{appName: "hello", storage: chart.HelmStorage{Driver: chart.SecretStorageDriver}}
+
+	out := &bytes.Buffer{}
+	require.Nil(t, appReleases(context.Background(), cfg, options, out))
+	require.Equal(t, `REVISION    UPDATED                 STATUS        VERSIONS    DESCRIPTION
+1           2022-06-01T09:00:00Z    superseded    1           Upgrade complete
+2           2022-06-02T09:00:00Z    superseded    1,2         Upgrade complete
+3           2022-06-03T09:00:00Z    deployed      3           Upgrade complete
+`, out.String())
+
+	err := appReleases(context.Background(), cfg, appReleasesOptions{appName: "hello", storage: chart.HelmStorage{Driver: "sql"}}, out)
+	require.EqualError(t, err, `unsupported helm storage driver "sql", use "secret" or "configmap"`)
+
+	err = appReleasesRollback(context.Background(), cfg, options, 7, out)
+	require.EqualError(t, err, "revision 7 of app hello not found")
+
+	out = &bytes.Buffer{}
+	require.Nil(t, appReleasesRollback(context.Background(), cfg, options, 2, out))
+	require.Equal(t, "Rolled back hello to version 2, deployed as version 4.\n", out.String())
+	gotApp := ketchv1.App{}
+	require.Nil(t, cfg.Client().Get(context.Background(), types.NamespacedName{Name: "hello"}, &gotApp))
+	require.Equal(t, "nginx:2.0", gotApp.Spec.Deployments[0].Image)
+}
@@ -1,51 +1,153 @@
 package main
 
 import (
+	"bufio"
 	"context"
 	"fmt"
 	"io"
+	"sort"
+	"strings"
 
 	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/utils"
 	"github.com/theketchio/ketch/internal/validation"
 )
 
 const appRemoveHelp = `
 Remove an application.
+An application with the "perApp" namespace strategy takes its namespace with it, so it isn't removed
+while ketch jobs run in the namespace. Use --cascade to remove the jobs along with the application,
+and --dry-run to list what would be removed.
+Persistent volume claims of the application's statefulsets are kept unless --delete-volumes is set.
 `
 
-type appRemoveFn func(context.Context, config, string, io.Writer) error
+type appRemoveFn func(context.Context, config, appRemoveOptions, io.Reader, io.Writer) error
+
+type appRemoveOptions struct {
+	appName        string
+	cascade        bool
+	deleteVolumes  bool
+	dryRun         bool
+	yes            bool
+	nonInteractive bool
+}
 
 func newAppRemoveCmd(cfg config, out io.Writer, appRemove appRemoveFn) *cobra.Command {
+	options := appRemoveOptions{}
 	cmd := &cobra.Command{
 		Use:   "remove APPNAME",
 		Short: "Remove an application.",
 		Args:  cobra.ExactValidArgs(1),
 		Long:  appRemoveHelp,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			appName := args[0]
-			if !validation.ValidateName(appName) {
+			options.appName = args[0]
+			if !validation.ValidateName(options.appName) {
 				return ErrInvalidAppName
 			}
-			return appRemove(cmd.Context(), cfg, appName, out)
+			options.nonInteractive = nonInteractive(cmd)
+			return appRemove(cmd.Context(), cfg, options, cmd.InOrStdin(), out)
 		},
 		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 			return autoCompleteAppNames(cfg, toComplete)
 		},
 	}
+	cmd.Flags().BoolVar(&options.cascade, "cascade", false, "Remove jobs running in the app's namespace along with the app.")
+	cmd.Flags().BoolVar(&options.deleteVolumes, "delete-volumes", false, "Remove persistent volume claims of the app's statefulsets along with the app.")
+	cmd.Flags().BoolVar(&options.dryRun, "dry-run", false, "List the app and the jobs that would be removed without removing them.")
+	cmd.Flags().BoolVarP(&options.yes, "yes", "y", false, "Don't ask for confirmation before removing jobs with --cascade.")
 	return cmd
 }
 
-func appRemove(ctx context.Context, cfg config, appName string, out io.Writer) error {
+func appRemove(ctx context.Context, cfg config, options appRemoveOptions, in io.Reader, out io.Writer) error {
 	app := ketchv1.App{}
-	if err := cfg.Client().Get(ctx, types.NamespacedName{Name: appName}, &app); err != nil {
+	if err := cfg.Client().Get(ctx, types.NamespacedName{Name: options.appName}, &app); err != nil {
 		return fmt.Errorf("failed to get app: %w", err)
 	}
+	jobs, err := appNamespaceJobs(ctx, cfg.Client(), app)
+	if err != nil {
+		return err
+	}
+	if len(jobs) > 0 && !options.cascade {
+		return fmt.Errorf("app %s has jobs in its namespace %s (%s), use --cascade to remove them along with the app",
+			app.Name, app.Spec.Namespace, strings.Join(jobNames(jobs), ", "))
+	}
+	if options.dryRun {
+		fmt.Fprintf(out, "app/%s would be removed\n", app.Name)
+		for _, name := range jobNames(jobs) {
+			fmt.Fprintf(out, "job/%s would be removed\n", name)
+		}
+		if options.deleteVolumes {
+			var claims v1.PersistentVolumeClaimList
+			if err := cfg.Client().List(ctx, &claims, client.InNamespace(app.Spec.Namespace), client.MatchingLabels{utils.KetchAppNameLabel: app.Name}); err != nil {
+				return fmt.Errorf("failed to list persistent volume claims: %w", err)
+			}
+			for _, claim := range claims.Items {
+				fmt.Fprintf(out, "persistentvolumeclaim/%s would be removed\n", claim.Name)
+			}
+		}
+		return nil
+	}
+	if len(jobs) > 0 && !options.yes && options.nonInteractive {
+		return fmt.Errorf("removing app %s removes jobs %s, use --yes to confirm it with --non-interactive", app.Name, strings.Join(jobNames(jobs), ", "))
+	}
+	if len(jobs) > 0 && !options.yes {
+		fmt.Fprintf(out, "Remove app %s and jobs %s? [y/N]: ", app.Name, strings.Join(jobNames(jobs), ", "))
+		answer, _ := bufio.NewReader(in).ReadString('\n')
+		if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+			fmt.Fprintln(out, "Aborted.")
+			return nil
+		}
+	}
+	if options.deleteVolumes {
+		// the controller removes volume claims once the app's chart is uninstalled.
+		if app.Annotations == nil {
+			app.Annotations = map[string]string{}
+		}
+		app.Annotations[ketchv1.DeleteVolumesAnnotation(ketchv1.Group)] = "true"
+		if err := cfg.Client().Update(ctx, &app); err != nil {
+			return fmt.Errorf("failed to update app: %w", err)
+		}
+	}
+	for i := range jobs {
+		if err := cfg.Client().Delete(ctx, &jobs[i]); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete job %s: %w", jobs[i].Name, err)
+		}
+	}
 	if err := cfg.Client().Delete(ctx, &app); err != nil {
 		return fmt.Errorf("failed to delete app: %w", err)
 	}
 	fmt.Fprintln(out, "Successfully removed!")
 	return nil
 }
+
+// appNamespaceJobs returns jobs that run in the app's namespace if the namespace is removed with the app.
+func appNamespaceJobs(ctx context.Context, cli client.Client, app ketchv1.App) ([]ketchv1.Job, error) {
+	if app.Spec.NamespaceStrategy != ketchv1.PerAppNamespace {
+		return nil, nil
+	}
+	var list ketchv1.JobList
+	if err := cli.List(ctx, &list); err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	var jobs []ketchv1.Job
+	for _, job := range list.Items {
+		if job.Spec.Namespace == app.Spec.Namespace {
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs, nil
+}
+
+func jobNames(jobs []ketchv1.Job) []string {
+	names := make([]string, 0, len(jobs))
+	for _, job := range jobs {
+		names = append(names, job.Name)
+	}
+	sort.Strings(names)
+	return names
+}
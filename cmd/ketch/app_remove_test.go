@@ -1,13 +1,23 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"io"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/spf13/pflag"
 	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/mocks"
 )
 
 func TestAppRemoveCmd(t *testing.T) {
@@ -22,8 +32,16 @@ func TestAppRemoveCmd(t *testing.T) {
 		{
 			description: "happy path",
 			args:        []string{"ketch", "foo-bar"},
-			appRemover: func(_ context.Context, _ config, appName string, _ io.Writer) error {
-				require.Equal(t, "foo-bar", appName)
+			appRemover: func(_ context.Context, _ config, options appRemoveOptions, _ io.Reader, _ io.Writer) error {
+				require.Equal(t, appRemoveOptions{appName: "foo-bar"}, options)
+				return nil
+			},
+		},
+		{
+			description: "cascade",
+			args:        []string{"ketch", "foo-bar", "--cascade", "--delete-volumes", "--dry-run", "-y"},
+			appRemover: func(_ context.Context, _ config, options appRemoveOptions, _ io.Reader, _ io.Writer) error {
+				require.Equal(t, appRemoveOptions{appName: "foo-bar", cascade: true, deleteVolumes: true, dryRun: true, yes: true}, options)
 				return nil
 			},
 		},
@@ -58,3 +76,113 @@ func TestAppRemoveCmd(t *testing.T) {
 		})
 	}
 }
+
+func TestAppRemove(t *testing.T) {
+	app := &ketchv1.App{
+		ObjectMeta: metav1.ObjectMeta{Name: "go-app"},
+		Spec:       ketchv1.AppSpec{Namespace: "ketch-go-app", NamespaceStrategy: ketchv1.PerAppNamespace},
+	}
+	sharedApp := &ketchv1.App{
+		ObjectMeta: metav1.ObjectMeta{Name: "shared-app"},
+		Spec:       ketchv1.AppSpec{Namespace: "ketch-go-app"},
+	}
+	migrate := &ketchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "migrate"}, Spec: ketchv1.JobSpec{Namespace: "ketch-go-app"}}
+	backup := &ketchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "backup"}, Spec: ketchv1.JobSpec{Namespace: "ketch-go-app"}}
+	report := &ketchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "report"}, Spec: ketchv1.JobSpec{Namespace: "default"}}
+	claim := &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "data-go-app-db-1-0", Namespace: "ketch-go-app", Labels: map[string]string{"theketch.io/app-name": "go-app"}}}
+	otherClaim := &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "data-other-app-db-1-0", Namespace: "ketch-go-app"}}
+
+	tests := []struct {
+		name        string
+		options     appRemoveOptions
+		in          string
+		objects     []runtime.Object
+		wantOut     string
+		wantErr     string
+		wantRemoved []string
+	}{
+		{
+			name:        "no jobs",
+			options:     appRemoveOptions{appName: "go-app"},
+			objects:     []runtime.Object{app, report},
+			wantOut:     "Successfully removed!\n",
+			wantRemoved: []string{"go-app"},
+		},
+		{
+			name:        "jobs in a shared namespace are kept",
+			options:     appRemoveOptions{appName: "shared-app"},
+			objects:     []runtime.Object{sharedApp, migrate},
+			wantOut:     "Successfully removed!\n",
+			wantRemoved: []string{"shared-app"},
+		},
+		{
+			name:    "jobs without cascade",
+			options: appRemoveOptions{appName: "go-app"},
+			objects: []runtime.Object{app, migrate, backup},
+			wantErr: "app go-app has jobs in its namespace ketch-go-app (backup, migrate), use --cascade to remove them along with the app",
+		},
+		{
+			name:    "dry run",
+			options: appRemoveOptions{appName: "go-app", cascade: true, dryRun: true},
+			objects: []runtime.Object{app, migrate, backup, report},
+			wantOut: "app/go-app would be removed\njob/backup would be removed\njob/migrate would be removed\n",
+		},
+		{
+			name:    "dry run with volumes",
+			options: appRemoveOptions{appName: "go-app", deleteVolumes: true, dryRun: true},
+			objects: []runtime.Object{app, claim, otherClaim},
+			wantOut: "app/go-app would be removed\npersistentvolumeclaim/data-go-app-db-1-0 would be removed\n",
+		},
+		{
+			name:        "cascade confirmed",
+			options:     appRemoveOptions{appName: "go-app", cascade: true},
+			in:          "y\n",
+			objects:     []runtime.Object{app, migrate, report},
+			wantOut:     "Remove app go-app and jobs migrate? [y/N]: Successfully removed!\n",
+			wantRemoved: []string{"go-app", "migrate"},
+		},
+		{
+			name:    "cascade aborted",
+			options: appRemoveOptions{appName: "go-app", cascade: true},
+			in:      "\n",
+			objects: []runtime.Object{app, migrate},
+			wantOut: "Remove app go-app and jobs migrate? [y/N]: Aborted.\n",
+		},
+		{
+			name:    "cascade non-interactive",
+			options: appRemoveOptions{appName: "go-app", cascade: true, nonInteractive: true},
+			objects: []runtime.Object{app, migrate},
+			wantErr: "removing app go-app removes jobs migrate, use --yes to confirm it with --non-interactive",
+		},
+		{
+			name:        "cascade without confirmation",
+			options:     appRemoveOptions{appName: "go-app", cascade: true, yes: true},
+			objects:     []runtime.Object{app, migrate, backup},
+			wantOut:     "Successfully removed!\n",
+			wantRemoved: []string{"go-app", "migrate", "backup"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &mocks.Configuration{CtrlClientObjects: tt.objects}
+			out := &bytes.Buffer{}
+			err := appRemove(context.Background(), cfg, tt.options, strings.NewReader(tt.in), out)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.wantOut, out.String())
+
+			var removed []string
+			for _, obj := range tt.objects {
+				o := obj.(client.Object)
+				err := cfg.Client().Get(context.Background(), client.ObjectKeyFromObject(o), o.DeepCopyObject().(client.Object))
+				if apierrors.IsNotFound(err) {
+					removed = append(removed, o.GetName())
+				}
+			}
+			require.Equal(t, tt.wantRemoved, removed)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/utils"
+)
+
+const appRepairHelp = `
+Repair an application whose helm release is stuck in a pending install, upgrade or rollback.
+Ketch unlocks such releases automatically after a timeout, this command asks ketch-controller to do it right away.
+`
+
+type appRepairFn func(context.Context, config, string, io.Writer) error
+
+func newAppRepairCmd(cfg config, out io.Writer, appRepair appRepairFn) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "repair APPNAME",
+		Short: "Repair an application whose helm release is stuck.",
+		Args:  cobra.ExactArgs(1),
+		Long:  appRepairHelp,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return appRepair(cmd.Context(), cfg, args[0], out)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return autoCompleteAppNames(cfg, toComplete)
+		},
+	}
+	return cmd
+}
+
+func appRepair(ctx context.Context, cfg config, appName string, out io.Writer) error {
+	app := ketchv1.App{}
+	if err := cfg.Client().Get(ctx, types.NamespacedName{Name: appName}, &app); err != nil {
+		return fmt.Errorf("failed to get app: %w", err)
+	}
+	if app.Annotations == nil {
+		app.Annotations = map[string]string{}
+	}
+	app.Annotations[utils.KetchRepairRequestedAnnotation] = time.Now().UTC().Format(time.RFC3339)
+	if err := cfg.Client().Update(ctx, &app); err != nil {
+		return fmt.Errorf("failed to update app: %w", err)
+	}
+	fmt.Fprintln(out, "Repair requested, ketch-controller will unlock the app's release shortly.")
+	return nil
+}
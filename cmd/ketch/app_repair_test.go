@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/mocks"
+	"github.com/theketchio/ketch/internal/utils"
+)
+
+func TestAppRepair(t *testing.T) {
+	mockApp := &ketchv1.App{
+		ObjectMeta: metav1.ObjectMeta{Name: "hello"},
+		Spec:       ketchv1.AppSpec{Namespace: "default"},
+	}
+	tests := []struct {
+		name    string
+		appName string
+		wantErr string
+	}{
+		{
+			name:    "success",
+			appName: "hello",
+		},
+		{
+			name:    "error - app not found",
+			appName: "no-exist",
+			wantErr: `failed to get app: apps.theketch.io "no-exist" not found`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &mocks.Configuration{CtrlClientObjects: []runtime.Object{mockApp.DeepCopy()}}
+			out := &bytes.Buffer{}
+			err := appRepair(context.Background(), cfg, tt.appName, out)
+			if len(tt.wantErr) > 0 {
+				require.NotNil(t, err)
+				require.Equal(t, tt.wantErr, err.Error())
+				return
+			}
+			require.Nil(t, err)
+
+			app := ketchv1.App{}
+			require.Nil(t, cfg.Client().Get(context.Background(), types.NamespacedName{Name: tt.appName}, &app))
+			require.NotEmpty(t, app.Annotations[utils.KetchRepairRequestedAnnotation])
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/utils"
+)
+
+const appRollbackHelp = `
+Deploy a previous version of an application again.
+The version is one of the deployment history shown by "ketch app history", the previous deployment by default.
+The rolled back deployment gets a new version with the image, processes and ketch.yaml of the old one.
+`
+
+func newAppRollbackCmd(cfg config, out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rollback APPNAME [VERSION]",
+		Short: "Deploy a previous version of an application again.",
+		Long:  appRollbackHelp,
+		Args:  cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			options := appRollbackOptions{appName: args[0]}
+			if len(args) == 2 {
+				version, err := strconv.Atoi(args[1])
+				if err != nil || version <= 0 {
+					return fmt.Errorf("invalid version %q, it must be a positive number", args[1])
+				}
+				options.version = ketchv1.DeploymentVersion(version)
+			}
+			return appRollback(cmd.Context(), cfg, options, out)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return autoCompleteAppNames(cfg, toComplete)
+		},
+	}
+	return cmd
+}
+
+type appRollbackOptions struct {
+	appName string
+	// version is a version to roll back to, zero means the previous deployment.
+	version ketchv1.DeploymentVersion
+}
+
+func appRollback(ctx context.Context, cfg config, options appRollbackOptions, out io.Writer) error {
+	app := ketchv1.App{}
+	if err := cfg.Client().Get(ctx, types.NamespacedName{Name: options.appName}, &app); err != nil {
+		return fmt.Errorf("failed to get app: %w", err)
+	}
+	version := options.version
+	if version == 0 {
+		previous := previousDeployment(app)
+		if previous == nil {
+			return fmt.Errorf("app %s has no previous deployment to roll back to", app.Name)
+		}
+		version = previous.Version
+	}
+	if err := app.RollbackTo(version); err != nil {
+		return err
+	}
+	if app.Annotations == nil {
+		app.Annotations = map[string]string{}
+	}
+	app.Annotations[utils.KetchDeployedByAnnotation] = utils.CurrentUser()
+	if err := cfg.Client().Update(ctx, &app); err != nil {
+		return fmt.Errorf("failed to update app: %w", err)
+	}
+	fmt.Fprintf(out, "Rolled back %s to version %d, deployed as version %d.\n", app.Name, version, app.Spec.Deployments[0].Version)
+	return nil
+}
+
+// previousDeployment returns the record of the deployment made before the current one.
+func previousDeployment(app ketchv1.App) *ketchv1.DeploymentRecord {
+	if len(app.Spec.Deployments) == 0 {
+		return nil
+	}
+	current := app.Spec.Deployments[0].Version
+	history := app.Status.DeploymentHistory
+	for i := len(history) - 1; i > 0; i-- {
+		if history[i].Version == current {
+			return &history[i-1]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/mocks"
+	"github.com/theketchio/ketch/internal/utils"
+)
+
+func TestAppRollback(t *testing.T) {
+	mockApp := &ketchv1.App{
+		ObjectMeta: metav1.ObjectMeta{Name: "hello"},
+		Spec: ketchv1.AppSpec{
+			Namespace:        "default",
+			DeploymentsCount: 3,
+			Deployments:      []ketchv1.AppDeploymentSpec{{Image: "nginx:3.0", Version: 3, RoutingSettings: ketchv1.RoutingSettings{Weight: 100}}},
+		},
+		Status: ketchv1.AppStatus{
+			DeploymentHistory: []ketchv1.DeploymentRecord{
+				{AppDeploymentSpec: ketchv1.AppDeploymentSpec{Image: "nginx:1.0", Version: 1}},
+				{AppDeploymentSpec: ketchv1.AppDeploymentSpec{Image: "nginx:2.0", Version: 2}},
+				{AppDeploymentSpec: ketchv1.AppDeploymentSpec{Image: "nginx:3.0", Version: 3}},
+			},
+		},
+	}
+	tests := []struct {
+		name      string
+		options   appRollbackOptions
+		wantImage string
+		wantOut   string
+		wantErr   string
+	}{
+		{
+			name:      "rollback to the previous deployment",
+			options:   appRollbackOptions{appName: "hello"},
+			wantImage: "nginx:2.0",
+			wantOut:   "Rolled back hello to version 2, deployed as version 4.\n",
+		},
+		{
+			name:      "rollback to a given version",
+			options:   appRollbackOptions{appName: "hello", version: 1},
+			wantImage: "nginx:1.0",
+			wantOut:   "Rolled back hello to version 1, deployed as version 4.\n",
+		},
+		{
+			name:    "error - version not found",
+			options: appRollbackOptions{appName: "hello", version: 7},
+			wantErr: "deployment version 7 not found in the deployment history",
+		},
+		{
+			name:    "error - app not found",
+			options: appRollbackOptions{appName: "no-exist"},
+			wantErr: `failed to get app: apps.theketch.io "no-exist" not found`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &mocks.Configuration{CtrlClientObjects: []runtime.Object{mockApp.DeepCopy()}}
+			out := &bytes.Buffer{}
+			err := appRollback(context.Background(), cfg, tt.options, out)
+			if len(tt.wantErr) > 0 {
+				require.NotNil(t, err)
+				require.Equal(t, tt.wantErr, err.Error())
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.wantOut, out.String())
+
+			app := ketchv1.App{}
+			require.Nil(t, cfg.Client().Get(context.Background(), types.NamespacedName{Name: tt.options.appName}, &app))
+			require.Len(t, app.Spec.Deployments, 1)
+			require.Equal(t, tt.wantImage, app.Spec.Deployments[0].Image)
+			require.Equal(t, ketchv1.DeploymentVersion(4), app.Spec.Deployments[0].Version)
+			require.NotEmpty(t, app.Annotations[utils.KetchDeployedByAnnotation])
+		})
+	}
+}
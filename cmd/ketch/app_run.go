@@ -0,0 +1,330 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+	"k8s.io/kubectl/pkg/util/term"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/chart"
+	"github.com/theketchio/ketch/internal/validation"
+)
+
+const appRunHelp = `
+Run a one-off command in a new pod of an application, for example:
+
+	ketch app run myapp -- rake db:migrate
+	ketch app run myapp -it -- /bin/sh
+
+The pod uses the image, env variables, volumes and service account of a process of the application,
+it doesn't receive traffic and it's removed once the command exits.
+ketch exits with the exit code of the command.
+`
+
+var appRunPollInterval = time.Second
+
+type attachFn func(restConfig *rest.Config, cli kubernetes.Interface, pod *corev1.Pod, in io.Reader, out io.Writer) error
+
+func newAppRunCmd(cfg config, out io.Writer) *cobra.Command {
+	options := appRunOptions{}
+	cmd := &cobra.Command{
+		Use:   "run APPNAME -- COMMAND [ARGS...]",
+		Short: "Run a one-off command in a new pod of an application.",
+		Long:  appRunHelp,
+		Args:  cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			options.appName = args[0]
+			options.command = args[1:]
+			if !validation.ValidateName(options.appName) {
+				return ErrInvalidAppName
+			}
+			return appRun(cmd.Context(), cfg, options, os.Stdin, out, attachPod)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return autoCompleteAppNames(cfg, toComplete)
+		},
+	}
+	cmd.Flags().StringVarP(&options.processName, "process", "p", "", "Process whose pod template is used, web or the first process by default.")
+	registerProcessNameCompletion(cmd, cfg, "process")
+	cmd.Flags().IntVarP(&options.deploymentVersion, "version", "v", 0, "Deployment version, the latest one by default.")
+	cmd.Flags().BoolVarP(&options.interactive, "interactive", "i", false, "Keep stdin open and allocate a TTY.")
+	cmd.Flags().BoolP("tty", "t", false, "Allocate a TTY, same as --interactive.")
+	cmd.Flags().DurationVar(&options.timeout, "timeout", 5*time.Minute, "Time to wait for the pod to start.")
+	cmd.PreRun = func(cmd *cobra.Command, args []string) {
+		if tty, _ := cmd.Flags().GetBool("tty"); tty {
+			options.interactive = true
+		}
+	}
+	return cmd
+}
+
+type appRunOptions struct {
+	appName           string
+	processName       string
+	deploymentVersion int
+	command           []string
+	interactive       bool
+	timeout           time.Duration
+}
+
+// commandFailedError is returned when the command of "ketch app run" fails, ketch exits with its exit code.
+type commandFailedError struct {
+	exitCode int
+}
+
+func (e commandFailedError) Error() string {
+	return fmt.Sprintf("command failed with exit code %d", e.exitCode)
+}
+
+// ExitCode returns the exit code ketch exits with.
+func (e commandFailedError) ExitCode() int {
+	return e.exitCode
+}
+
+func appRun(ctx context.Context, cfg config, options appRunOptions, in io.Reader, out io.Writer, attach attachFn) error {
+	app := ketchv1.App{}
+	if err := cfg.Client().Get(ctx, types.NamespacedName{Name: options.appName}, &app); err != nil {
+		return fmt.Errorf("failed to get app: %w", err)
+	}
+	deployment, process, err := appRunProcess(app, options)
+	if err != nil {
+		return err
+	}
+	cli := cfg.KubernetesClient()
+	workloadName := fmt.Sprintf("%s-%s-%d", app.Name, process.Name, deployment.Version)
+	template, err := processPodTemplate(ctx, cli, app.Spec.Namespace, workloadName)
+	if err != nil {
+		return err
+	}
+	pod, err := newRunPod(app.Name, workloadName, template, options.command, options.interactive)
+	if err != nil {
+		return err
+	}
+	pod, err = cli.CoreV1().Pods(app.Spec.Namespace).Create(ctx, pod, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to create pod: %w", err)
+	}
+	defer func() {
+		// the pod is removed even if the command was interrupted.
+		err := cli.CoreV1().Pods(pod.Namespace).Delete(context.Background(), pod.Name, metav1.DeleteOptions{})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			fmt.Fprintf(out, "failed to remove pod %s: %v\n", pod.Name, err)
+		}
+	}()
+
+	startCtx, cancel := context.WithTimeout(ctx, options.timeout)
+	defer cancel()
+	if pod, err = waitForPod(startCtx, cli, pod, isContainerStarted); err != nil {
+		return err
+	}
+	if options.interactive {
+		if err := attach(cfg.RESTConfig(), cli, pod, in, out); err != nil {
+			return fmt.Errorf("failed to attach to pod %s: %w", pod.Name, err)
+		}
+	} else {
+		streamContainerLogs(ctx, cli, *pod, workloadName, "", out)
+	}
+	if pod, err = waitForPod(ctx, cli, pod, isContainerTerminated); err != nil {
+		return err
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == workloadName && status.State.Terminated.ExitCode != 0 {
+			return commandFailedError{exitCode: int(status.State.Terminated.ExitCode)}
+		}
+	}
+	return nil
+}
+
+// appRunProcess returns a deployment and a process whose pod template is used to run a command.
+func appRunProcess(app ketchv1.App, options appRunOptions) (*ketchv1.AppDeploymentSpec, *ketchv1.ProcessSpec, error) {
+	if len(app.Spec.Deployments) == 0 {
+		return nil, nil, fmt.Errorf("app %s has no deployments", app.Name)
+	}
+	deployment := &app.Spec.Deployments[len(app.Spec.Deployments)-1]
+	if options.deploymentVersion > 0 {
+		deployment = nil
+		for i, d := range app.Spec.Deployments {
+			if int(d.Version) == options.deploymentVersion {
+				deployment = &app.Spec.Deployments[i]
+			}
+		}
+		if deployment == nil {
+			return nil, nil, fmt.Errorf("deployment version %d not found", options.deploymentVersion)
+		}
+	}
+	if len(deployment.Processes) == 0 {
+		return nil, nil, fmt.Errorf("deployment version %d has no processes", deployment.Version)
+	}
+	processName := options.processName
+	if len(processName) == 0 {
+		processName = deployment.Processes[0].Name
+		for _, p := range deployment.Processes {
+			if p.Name == chart.DefaultRoutableProcessName {
+				processName = p.Name
+			}
+		}
+	}
+	for i, p := range deployment.Processes {
+		if p.Name == processName {
+			return deployment, &deployment.Processes[i], nil
+		}
+	}
+	return nil, nil, fmt.Errorf("process %s not found", processName)
+}
+
+// processPodTemplate returns the pod template of a deployment, statefulset or daemonset of the process.
+func processPodTemplate(ctx context.Context, cli kubernetes.Interface, namespace, name string) (*corev1.PodTemplateSpec, error) {
+	deployment, err := cli.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err == nil {
+		return &deployment.Spec.Template, nil
+	}
+	if !k8serrors.IsNotFound(err) {
+		return nil, err
+	}
+	statefulSet, err := cli.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err == nil {
+		template := statefulSet.Spec.Template.DeepCopy()
+		// volume claim templates are bound to the pods of the statefulset.
+		for _, claim := range statefulSet.Spec.VolumeClaimTemplates {
+			for i := range template.Spec.Containers {
+				template.Spec.Containers[i].VolumeMounts = removeVolumeMount(template.Spec.Containers[i].VolumeMounts, claim.Name)
+			}
+		}
+		return template, nil
+	}
+	if !k8serrors.IsNotFound(err) {
+		return nil, err
+	}
+	daemonSet, err := cli.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err == nil {
+		return &daemonSet.Spec.Template, nil
+	}
+	if k8serrors.IsNotFound(err) {
+		return nil, fmt.Errorf("%s not found, the app must be deployed to run a command", name)
+	}
+	return nil, err
+}
+
+func removeVolumeMount(mounts []corev1.VolumeMount, name string) []corev1.VolumeMount {
+	result := make([]corev1.VolumeMount, 0, len(mounts))
+	for _, m := range mounts {
+		if m.Name != name {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+// newRunPod returns a pod running the command in the app container of the template.
+// The pod is labeled as an isolated run, so services of the app don't send traffic to it.
+func newRunPod(appName, containerName string, template *corev1.PodTemplateSpec, command []string, interactive bool) (*corev1.Pod, error) {
+	spec := template.Spec.DeepCopy()
+	var container *corev1.Container
+	for i := range spec.Containers {
+		if spec.Containers[i].Name == containerName {
+			container = &spec.Containers[i]
+		}
+	}
+	if container == nil {
+		return nil, fmt.Errorf("pod template of %s doesn't have an app container", containerName)
+	}
+	container.Command = command
+	container.Args = nil
+	container.LivenessProbe = nil
+	container.ReadinessProbe = nil
+	container.StartupProbe = nil
+	container.Lifecycle = nil
+	container.Stdin = interactive
+	container.StdinOnce = interactive
+	container.TTY = interactive
+	spec.Containers = []corev1.Container{*container}
+	spec.RestartPolicy = corev1.RestartPolicyNever
+
+	labels := make(map[string]string, len(template.Labels))
+	for k, v := range template.Labels {
+		labels[k] = v
+	}
+	labels[ketchv1.Group+"/is-isolated-run"] = "true"
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: appName + "-run-",
+			Labels:       labels,
+			Annotations:  template.Annotations,
+		},
+		Spec: *spec,
+	}, nil
+}
+
+func isContainerTerminated(pod corev1.Pod, containerName string) bool {
+	for _, container := range pod.Status.ContainerStatuses {
+		if container.Name == containerName {
+			return container.State.Terminated != nil
+		}
+	}
+	return false
+}
+
+// waitForPod polls the pod until the condition is true for its container.
+func waitForPod(ctx context.Context, cli kubernetes.Interface, pod *corev1.Pod, condition func(corev1.Pod, string) bool) (*corev1.Pod, error) {
+	containerName := pod.Spec.Containers[0].Name
+	for {
+		current, err := cli.CoreV1().Pods(pod.Namespace).Get(ctx, pod.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get pod %s: %w", pod.Name, err)
+		}
+		if condition(*current, containerName) {
+			return current, nil
+		}
+		if current.Status.Phase == corev1.PodFailed {
+			return nil, fmt.Errorf("pod %s failed: %s", pod.Name, current.Status.Message)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, errors.New("timed out waiting for pod " + pod.Name)
+		case <-time.After(appRunPollInterval):
+		}
+	}
+}
+
+// attachPod attaches stdin and stdout to the app container of the pod using a TTY.
+func attachPod(restConfig *rest.Config, cli kubernetes.Interface, pod *corev1.Pod, in io.Reader, out io.Writer) error {
+	containerName := pod.Spec.Containers[0].Name
+	req := cli.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Name(pod.Name).
+		Namespace(pod.Namespace).
+		SubResource("attach").
+		VersionedParams(&corev1.PodAttachOptions{
+			Container: containerName,
+			Stdin:     true,
+			Stdout:    true,
+			TTY:       true,
+		}, scheme.ParameterCodec)
+	executor, err := remotecommand.NewSPDYExecutor(restConfig, "POST", req.URL())
+	if err != nil {
+		return err
+	}
+	tty := term.TTY{In: in, Out: out, Raw: true}
+	return tty.Safe(func() error {
+		return executor.Stream(remotecommand.StreamOptions{
+			Stdin:             tty.In,
+			Stdout:            tty.Out,
+			Tty:               true,
+			TerminalSizeQueue: tty.MonitorSize(tty.GetSize()),
+		})
+	})
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+)
+
+func TestAppRunProcess(t *testing.T) {
+	app := ketchv1.App{
+		ObjectMeta: metav1.ObjectMeta{Name: "go-app"},
+		Spec: ketchv1.AppSpec{
+			Deployments: []ketchv1.AppDeploymentSpec{
+				{Version: 1, Processes: []ketchv1.ProcessSpec{{Name: "worker"}, {Name: "web"}}},
+				{Version: 2, Processes: []ketchv1.ProcessSpec{{Name: "worker"}, {Name: "web"}}},
+			},
+		},
+	}
+	tests := []struct {
+		name        string
+		options     appRunOptions
+		wantVersion ketchv1.DeploymentVersion
+		wantProcess string
+		wantErr     string
+	}{
+		{
+			name:        "web process of the latest deployment by default",
+			wantVersion: 2,
+			wantProcess: "web",
+		},
+		{
+			name:        "process and version",
+			options:     appRunOptions{processName: "worker", deploymentVersion: 1},
+			wantVersion: 1,
+			wantProcess: "worker",
+		},
+		{
+			name:    "unknown version",
+			options: appRunOptions{deploymentVersion: 3},
+			wantErr: "deployment version 3 not found",
+		},
+		{
+			name:    "unknown process",
+			options: appRunOptions{processName: "cron"},
+			wantErr: "process cron not found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deployment, process, err := appRunProcess(app, tt.options)
+			if len(tt.wantErr) > 0 {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.wantVersion, deployment.Version)
+			require.Equal(t, tt.wantProcess, process.Name)
+		})
+	}
+}
+
+func TestProcessPodTemplate(t *testing.T) {
+	template := corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name:         "go-app-web-1",
+				VolumeMounts: []corev1.VolumeMount{{Name: "data", MountPath: "/data"}, {Name: "config", MountPath: "/config"}},
+			}},
+		},
+	}
+	tests := []struct {
+		name       string
+		objects    []runtime.Object
+		wantMounts []corev1.VolumeMount
+		wantErr    string
+	}{
+		{
+			name: "deployment",
+			objects: []runtime.Object{&appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "go-app-web-1", Namespace: "ketch"},
+				Spec:       appsv1.DeploymentSpec{Template: template},
+			}},
+			wantMounts: []corev1.VolumeMount{{Name: "data", MountPath: "/data"}, {Name: "config", MountPath: "/config"}},
+		},
+		{
+			name: "volume claim templates of a statefulset aren't mounted",
+			objects: []runtime.Object{&appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "go-app-web-1", Namespace: "ketch"},
+				Spec: appsv1.StatefulSetSpec{
+					Template:             template,
+					VolumeClaimTemplates: []corev1.PersistentVolumeClaim{{ObjectMeta: metav1.ObjectMeta{Name: "data"}}},
+				},
+			}},
+			wantMounts: []corev1.VolumeMount{{Name: "config", MountPath: "/config"}},
+		},
+		{
+			name:    "not deployed",
+			wantErr: "go-app-web-1 not found, the app must be deployed to run a command",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := processPodTemplate(context.Background(), fake.NewSimpleClientset(tt.objects...), "ketch", "go-app-web-1")
+			if len(tt.wantErr) > 0 {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.wantMounts, got.Spec.Containers[0].VolumeMounts)
+		})
+	}
+}
+
+func TestNewRunPod(t *testing.T) {
+	template := &corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{"theketch.io/app-name": "go-app", "theketch.io/is-isolated-run": "false"},
+		},
+		Spec: corev1.PodSpec{
+			ServiceAccountName: "go-app",
+			Containers: []corev1.Container{
+				{
+					Name:           "go-app-web-1",
+					Image:          "go-app:v1",
+					Command:        []string{"/bin/server"},
+					Env:            []corev1.EnvVar{{Name: "PORT", Value: "8080"}},
+					ReadinessProbe: &corev1.Probe{},
+				},
+				{Name: "istio-proxy", Image: "istio/proxyv2"},
+			},
+		},
+	}
+	pod, err := newRunPod("go-app", "go-app-web-1", template, []string{"rake", "db:migrate"}, true)
+	require.Nil(t, err)
+	require.Equal(t, "go-app-run-", pod.GenerateName)
+	require.Equal(t, map[string]string{"theketch.io/app-name": "go-app", "theketch.io/is-isolated-run": "true"}, pod.Labels)
+	require.Equal(t, corev1.RestartPolicyNever, pod.Spec.RestartPolicy)
+	require.Equal(t, "go-app", pod.Spec.ServiceAccountName)
+	require.Equal(t, []corev1.Container{{
+		Name:      "go-app-web-1",
+		Image:     "go-app:v1",
+		Command:   []string{"rake", "db:migrate"},
+		Env:       []corev1.EnvVar{{Name: "PORT", Value: "8080"}},
+		Stdin:     true,
+		StdinOnce: true,
+		TTY:       true,
+	}}, pod.Spec.Containers)
+	// the template is left untouched.
+	require.Equal(t, "false", template.Labels["theketch.io/is-isolated-run"])
+
+	_, err = newRunPod("go-app", "go-app-worker-1", template, []string{"ls"}, false)
+	require.EqualError(t, err, "pod template of go-app-worker-1 doesn't have an app container")
+}
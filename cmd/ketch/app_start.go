@@ -14,6 +14,7 @@ import (
 
 const appStartHelp = `
 Start an application, or one of the processes of the application.
+A process gets the number of units it had when it was stopped, or one unit.
 `
 
 type appStartFn func(context.Context, config, appStartOptions, io.Writer) error
@@ -38,6 +39,7 @@ func newAppStartCmd(cfg config, out io.Writer, appStart appStartFn) *cobra.Comma
 	}
 
 	cmd.Flags().StringVarP(&options.processName, "process", "p", "", "Process name.")
+	registerProcessNameCompletion(cmd, cfg, "process")
 	cmd.Flags().IntVarP(&options.deploymentVersion, "version", "v", 0, "Deployment version.")
 
 	return cmd
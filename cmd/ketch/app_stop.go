@@ -13,6 +13,7 @@ import (
 
 const appStopHelp = `
 Stop an application, or one of the processes of the application.
+Stopped processes are scaled to zero, their numbers of units are kept and restored by "ketch app start".
 `
 
 type appStopFn func(context.Context, config, appStopOptions, io.Writer) error
@@ -34,6 +35,7 @@ func newAppStopCmd(cfg config, out io.Writer, appStop appStopFn) *cobra.Command
 	}
 
 	cmd.Flags().StringVarP(&options.processName, "process", "p", "", "Process name.")
+	registerProcessNameCompletion(cmd, cfg, "process")
 	cmd.Flags().IntVarP(&options.deploymentVersion, "version", "v", 0, "Deployment version.")
 	return cmd
 }
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/chart"
+	"github.com/theketchio/ketch/internal/templates"
+)
+
+const appTemplateHelp = `
+Render the helm chart of an application to yaml without installing it.
+The manifests are the ones ketch would apply to the cluster, so they can be committed to a GitOps repository:
+  ketch app template <app name> --show-secrets > manifests/<app name>.yaml
+
+Values of sensitive environment variables are masked unless --show-secrets is set.
+`
+
+func newAppTemplateCmd(cfg config, redact redactor, out io.Writer) *cobra.Command {
+	options := appTemplateOptions{redactor: redact}
+	cmd := &cobra.Command{
+		Use:   "template APPNAME",
+		Short: "Render an app's helm chart to yaml.",
+		Long:  appTemplateHelp,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			options.appName = args[0]
+			return appTemplate(cmd.Context(), cfg, options, out)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return autoCompleteAppNames(cfg, toComplete)
+		},
+	}
+	cmd.Flags().BoolVar(&options.showSecrets, showSecretsFlag, false, showSecretsUsage)
+	return cmd
+}
+
+type appTemplateOptions struct {
+	appName     string
+	showSecrets bool
+	redactor    redactor
+}
+
+func appTemplate(ctx context.Context, cfg config, options appTemplateOptions, out io.Writer) error {
+	app := ketchv1.App{}
+	if err := cfg.Client().Get(ctx, types.NamespacedName{Name: options.appName}, &app); err != nil {
+		return fmt.Errorf("failed to get app: %w", err)
+	}
+	if !options.showSecrets {
+		app.Spec.Env = options.redactor.envs(app.Spec.Env)
+	}
+	tpls, err := appChartTemplates(ctx, cfg, &app)
+	if err != nil {
+		return err
+	}
+	envSets, err := ketchv1.GetAppEnvSets(ctx, cfg.Client(), &app)
+	if err != nil {
+		return err
+	}
+	if !options.showSecrets {
+		for i := range envSets {
+			envSets[i].Spec.Env = options.redactor.envs(envSets[i].Spec.Env)
+		}
+	}
+	bindingSecrets, err := ketchv1.GetAppBindingSecrets(ctx, cfg.Client(), &app)
+	if err != nil {
+		return err
+	}
+	manifests, err := chart.RenderApplication(&app, *tpls, chart.WithEnvSets(envSets), chart.WithServiceBindingSecrets(bindingSecrets))
+	if err != nil {
+		return fmt.Errorf("failed to render the app's chart: %w", err)
+	}
+	fmt.Fprintln(out, strings.TrimSpace(manifests))
+	return nil
+}
+
+// appChartTemplates returns the templates of the app's chart, an app without an ingress controller
+// gets the cluster's default one the same way the app controller does.
+func appChartTemplates(ctx context.Context, cfg config, app *ketchv1.App) (*templates.Templates, error) {
+	controller := app.Spec.Ingress.Controller
+	if controller.IngressType == "" || controller.ServiceEndpoint == "" || controller.ClassName == "" {
+		ingressControllerSpec, err := ketchv1.GetIngressControllerSpec(ctx, cfg.Client())
+		if client.IgnoreNotFound(err) != nil {
+			return nil, fmt.Errorf("failed to get ingress controller: %w", err)
+		}
+		if ingressControllerSpec != nil {
+			app.Spec.Ingress.Controller = *ingressControllerSpec
+		}
+	}
+	return templates.AppTemplates(cfg.Storage(), app.Spec.Ingress.Controller.IngressType.String(), app.Spec.Ingress.Controller.Templates)
+}
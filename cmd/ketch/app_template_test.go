@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/mocks"
+	"github.com/theketchio/ketch/internal/templates"
+)
+
+func Test_appTemplate(t *testing.T) {
+	hello := &ketchv1.App{
+		ObjectMeta: metav1.ObjectMeta{Name: "hello"},
+		Spec: ketchv1.AppSpec{
+			Namespace:        "default",
+			DeploymentsCount: 1,
+			Deployments: []ketchv1.AppDeploymentSpec{
+				{
+					Image:           "nginx:latest",
+					Version:         1,
+					Processes:       []ketchv1.ProcessSpec{{Name: "web", Cmd: []string{"nginx"}}},
+					ExposedPorts:    []ketchv1.ExposedPort{{Port: 80, Protocol: "TCP"}},
+					RoutingSettings: ketchv1.RoutingSettings{Weight: 100},
+				},
+			},
+			Ingress: ketchv1.IngressSpec{GenerateDefaultCname: true},
+			Env: []ketchv1.Env{
+				{Name: "GITHUB_TOKEN", Value: "ghp_123"},
+				{Name: "DEBUG", Value: "true"},
+			},
+		},
+	}
+	ingressConfigmap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: ketchv1.IngressConfigmapName, Namespace: ketchv1.IngressConfigmapNamespace},
+		Data: map[string]string{
+			"type":            "nginx",
+			"className":       "nginx",
+			"serviceEndpoint": "10.10.10.10",
+		},
+	}
+	nginxStorage := &mockStorage{
+		OnGet: func(name string) (*templates.Templates, error) {
+			require.Equal(t, templates.IngressConfigMapName(ketchv1.NginxIngressControllerType.String()), name)
+			return &templates.NginxDefaultTemplates, nil
+		},
+	}
+	tests := []struct {
+		name        string
+		options     appTemplateOptions
+		wantOut     []string
+		wantMissing []string
+		wantErr     string
+	}{
+		{
+			name:        "sensitive env variables are redacted",
+			options:     appTemplateOptions{appName: "hello", redactor: newRedactor(nil)},
+			wantOut:     []string{"kind: Deployment", "name: hello-web-1", "image: nginx:latest", "<redacted>", `ingressClassName: "nginx"`, "host: \"hello.10.10.10.10.shipa.cloud\""},
+			wantMissing: []string{"ghp_123"},
+		},
+		{
+			name:    "show secrets",
+			options: appTemplateOptions{appName: "hello", showSecrets: true, redactor: newRedactor(nil)},
+			wantOut: []string{"ghp_123"},
+		},
+		{
+			name:    "error - app not found",
+			options: appTemplateOptions{appName: "no-exist"},
+			wantErr: `failed to get app: apps.theketch.io "no-exist" not found`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{hello.DeepCopy(), ingressConfigmap},
+				StorageInstance:   nginxStorage,
+			}
+			out := &bytes.Buffer{}
+			err := appTemplate(context.Background(), cfg, tt.options, out)
+			if len(tt.wantErr) > 0 {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.Nil(t, err)
+			for _, s := range tt.wantOut {
+				require.Contains(t, out.String(), s)
+			}
+			for _, s := range tt.wantMissing {
+				require.NotContains(t, out.String(), s)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/theketchio/ketch/cmd/ketch/output"
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/utils"
+)
+
+const appTopHelp = `
+Show the current CPU and memory usage of each unit of an application.
+Usage is reported by metrics-server, it must be installed in the cluster.
+`
+
+// podMetricsResource is the resource of pod metrics served by metrics-server.
+var podMetricsResource = schema.GroupVersionResource{
+	Group:    "metrics.k8s.io",
+	Version:  "v1beta1",
+	Resource: "pods",
+}
+
+type appTopOutput struct {
+	Unit    string `json:"unit" yaml:"unit"`
+	Version string `json:"version" yaml:"version"`
+	Process string `json:"process" yaml:"process"`
+	CPU     string `json:"cpu" yaml:"cpu" column:"CPU"`
+	Memory  string `json:"memory" yaml:"memory"`
+}
+
+// podUsage is the sum of the usage of all containers of a pod.
+type podUsage struct {
+	version string
+	process string
+	cpu     resource.Quantity
+	memory  resource.Quantity
+}
+
+func newAppTopCmd(cfg config, out io.Writer) *cobra.Command {
+	options := appTopOptions{}
+	cmd := &cobra.Command{
+		Use:   "top APPNAME",
+		Short: "Show CPU and memory usage of an application.",
+		Long:  appTopHelp,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			options.appName = args[0]
+			return appTop(cmd.Context(), cfg, options, out)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return autoCompleteAppNames(cfg, toComplete)
+		},
+	}
+	cmd.Flags().StringVarP(&options.processName, "process", "p", "", "Show only units of the process.")
+	registerProcessNameCompletion(cmd, cfg, "process")
+	return cmd
+}
+
+type appTopOptions struct {
+	appName     string
+	processName string
+}
+
+func appTop(ctx context.Context, cfg config, options appTopOptions, out io.Writer) error {
+	app := ketchv1.App{}
+	if err := cfg.Client().Get(ctx, types.NamespacedName{Name: options.appName}, &app); err != nil {
+		return fmt.Errorf("failed to get app: %w", err)
+	}
+	usage, err := appPodsUsage(ctx, cfg, app)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(usage))
+	for name, pod := range usage {
+		if options.processName == "" || pod.process == options.processName {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		fmt.Fprintln(out, "No units found.")
+		return nil
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, b := usage[names[i]], usage[names[j]]
+		if a.version != b.version {
+			return a.version < b.version
+		}
+		if a.process != b.process {
+			return a.process < b.process
+		}
+		return names[i] < names[j]
+	})
+	outputs := make([]appTopOutput, 0, len(names))
+	for _, name := range names {
+		pod := usage[name]
+		outputs = append(outputs, appTopOutput{
+			Unit:    name,
+			Version: pod.version,
+			Process: pod.process,
+			CPU:     formatCPU(pod.cpu),
+			Memory:  formatMemory(pod.memory),
+		})
+	}
+	return output.Write(outputs, out, "column")
+}
+
+// appPodsUsage returns the current usage of the app's pods by pod name.
+func appPodsUsage(ctx context.Context, cfg config, app ketchv1.App) (map[string]podUsage, error) {
+	list, err := cfg.DynamicClient().Resource(podMetricsResource).Namespace(app.Spec.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf(`%s=%s`, utils.KetchAppNameLabel, app.Name),
+	})
+	if apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("metrics API is not available, make sure metrics-server is installed: %w", err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get metrics of the app's pods: %w", err)
+	}
+	usage := make(map[string]podUsage, len(list.Items))
+	for _, item := range list.Items {
+		pod := podUsage{
+			version: item.GetLabels()[utils.KetchDeploymentVersionLabel],
+			process: item.GetLabels()[utils.KetchProcessNameLabel],
+		}
+		containers, _, err := unstructured.NestedSlice(item.Object, "containers")
+		if err != nil {
+			return nil, fmt.Errorf("failed to read metrics of pod %s: %w", item.GetName(), err)
+		}
+		for _, container := range containers {
+			containerUsage, _, err := unstructured.NestedStringMap(container.(map[string]interface{}), "usage")
+			if err != nil {
+				return nil, fmt.Errorf("failed to read metrics of pod %s: %w", item.GetName(), err)
+			}
+			for name, total := range map[string]*resource.Quantity{"cpu": &pod.cpu, "memory": &pod.memory} {
+				value, ok := containerUsage[name]
+				if !ok {
+					continue
+				}
+				quantity, err := resource.ParseQuantity(value)
+				if err != nil {
+					return nil, fmt.Errorf("failed to read metrics of pod %s: %w", item.GetName(), err)
+				}
+				total.Add(quantity)
+			}
+		}
+		usage[item.GetName()] = pod
+	}
+	return usage, nil
+}
+
+// formatCPU formats CPU usage in millicores the way "kubectl top" does.
+func formatCPU(q resource.Quantity) string {
+	return fmt.Sprintf("%dm", q.MilliValue())
+}
+
+// formatMemory formats memory usage in mebibytes the way "kubectl top" does.
+func formatMemory(q resource.Quantity) string {
+	return fmt.Sprintf("%dMi", q.Value()/(1024*1024))
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/mocks"
+	"github.com/theketchio/ketch/internal/utils"
+)
+
+func newPodMetrics(name, version, process string, usage ...map[string]interface{}) *unstructured.Unstructured {
+	containers := make([]interface{}, 0, len(usage))
+	for _, u := range usage {
+		containers = append(containers, map[string]interface{}{"name": name, "usage": u})
+	}
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "metrics.k8s.io/v1beta1",
+		"kind":       "PodMetrics",
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": "ketch-hello",
+			"labels": map[string]interface{}{
+				utils.KetchAppNameLabel:           "hello",
+				utils.KetchDeploymentVersionLabel: version,
+				utils.KetchProcessNameLabel:       process,
+			},
+		},
+		"containers": containers,
+	}}
+}
+
+func Test_appTop(t *testing.T) {
+	app := &ketchv1.App{
+		ObjectMeta: metav1.ObjectMeta{Name: "hello"},
+		Spec:       ketchv1.AppSpec{Namespace: "ketch-hello"},
+	}
+	metrics := []runtime.Object{
+		newPodMetrics("hello-worker-1-abc", "1", "worker", map[string]interface{}{"cpu": "250m", "memory": "64Mi"}),
+		newPodMetrics("hello-web-1-def", "1", "web",
+			map[string]interface{}{"cpu": "1500u", "memory": "10Mi"},
+			map[string]interface{}{"cpu": "10m", "memory": "6Mi"},
+		),
+		newPodMetrics("hello-web-1-abc", "1", "web", map[string]interface{}{"cpu": "2m", "memory": "20Mi"}),
+	}
+	tests := []struct {
+		name    string
+		cfg     config
+		options appTopOptions
+		want    string
+		wantErr string
+	}{
+		{
+			name: "all units",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects:    []runtime.Object{app},
+				DynamicClientObjects: metrics,
+			},
+			options: appTopOptions{appName: "hello"},
+			want: `UNIT                  VERSION    PROCESS    CPU     MEMORY
+hello-web-1-abc       1          web        2m      20Mi
+hello-web-1-def       1          web        12m     16Mi
+hello-worker-1-abc    1          worker     250m    64Mi
+`,
+		},
+		{
+			name: "units of a process",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects:    []runtime.Object{app},
+				DynamicClientObjects: metrics,
+			},
+			options: appTopOptions{appName: "hello", processName: "worker"},
+			want: `UNIT                  VERSION    PROCESS    CPU     MEMORY
+hello-worker-1-abc    1          worker     250m    64Mi
+`,
+		},
+		{
+			name: "no units",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{app},
+			},
+			options: appTopOptions{appName: "hello"},
+			want:    "No units found.\n",
+		},
+		{
+			name:    "no app",
+			cfg:     &mocks.Configuration{},
+			options: appTopOptions{appName: "hello"},
+			wantErr: `failed to get app: apps.theketch.io "hello" not found`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			err := appTop(context.Background(), tt.cfg, tt.options, out)
+			if len(tt.wantErr) > 0 {
+				require.NotNil(t, err)
+				require.Equal(t, tt.wantErr, err.Error())
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.want, out.String())
+		})
+	}
+}
+
+func Test_processUsage(t *testing.T) {
+	cfg := &mocks.Configuration{
+		DynamicClientObjects: []runtime.Object{
+			newPodMetrics("hello-web-1-abc", "1", "web", map[string]interface{}{"cpu": "2m", "memory": "20Mi"}),
+			newPodMetrics("hello-web-1-def", "1", "web", map[string]interface{}{"cpu": "3m", "memory": "10Mi"}),
+			newPodMetrics("hello-web-2-abc", "2", "web", map[string]interface{}{"cpu": "5m", "memory": "30Mi"}),
+		},
+	}
+	usage, err := appPodsUsage(context.Background(), cfg, ketchv1.App{
+		ObjectMeta: metav1.ObjectMeta{Name: "hello"},
+		Spec:       ketchv1.AppSpec{Namespace: "ketch-hello"},
+	})
+	require.Nil(t, err)
+
+	cpu, memory, ok := processUsage(usage, "1", "web")
+	require.True(t, ok)
+	require.Equal(t, "5m", formatCPU(cpu))
+	require.Equal(t, "30Mi", formatMemory(memory))
+
+	_, _, ok = processUsage(usage, "1", "worker")
+	require.False(t, ok)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+)
+
+const appUnbindHelp = `
+Unbind an application from an instance of an external service.
+Env variables of the binding are removed from pods of the app, the secret of the binding is kept.
+`
+
+func newAppUnbindCmd(cfg config, out io.Writer) *cobra.Command {
+	options := appUnbindOptions{}
+	cmd := &cobra.Command{
+		Use:   "unbind APPNAME BINDING",
+		Args:  cobra.ExactArgs(2),
+		Short: "Unbind an application from an instance of an external service.",
+		Long:  appUnbindHelp,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			options.appName = args[0]
+			options.bindingName = args[1]
+			return appUnbind(cmd.Context(), cfg, options, out)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return autoCompleteAppNames(cfg, toComplete)
+		},
+	}
+	return cmd
+}
+
+type appUnbindOptions struct {
+	appName     string
+	bindingName string
+}
+
+func appUnbind(ctx context.Context, cfg config, options appUnbindOptions, out io.Writer) error {
+	app := ketchv1.App{}
+	if err := cfg.Client().Get(ctx, types.NamespacedName{Name: options.appName}, &app); err != nil {
+		return fmt.Errorf("failed to get the app: %w", err)
+	}
+	bindings := make([]ketchv1.ServiceBinding, 0, len(app.Spec.ServiceBindings))
+	for _, binding := range app.Spec.ServiceBindings {
+		if binding.Name != options.bindingName {
+			bindings = append(bindings, binding)
+		}
+	}
+	if len(bindings) == len(app.Spec.ServiceBindings) {
+		return fmt.Errorf("app %s has no binding %s", app.Name, options.bindingName)
+	}
+	app.Spec.ServiceBindings = bindings
+	if err := cfg.Client().Update(ctx, &app); err != nil {
+		return fmt.Errorf("failed to update the app: %w", err)
+	}
+	fmt.Fprintf(out, "Unbound %s from %s.\n", app.Name, options.bindingName)
+	return nil
+}
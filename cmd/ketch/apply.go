@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/spf13/cobra"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"github.com/theketchio/ketch/internal/deploy"
+	"github.com/theketchio/ketch/pkg/ketchclient"
+)
+
+const applyHelp = `
+Create or update apps and the ingress configuration from a yaml file, so their definitions can be kept in git
+and applied repeatedly. Documents of the file are applied in order, every document has a type:
+
+  type: Ingress
+  className: nginx
+  serviceEndpoint: 10.10.10.10
+  ingressType: nginx
+  forceHTTPS: true
+  ---
+  type: Application
+  name: dashboard
+  image: shipa/dashboard:0.1
+  namespace: apps
+
+An Ingress document has the keys of "ketch ingress set" and replaces the whole ingress configuration,
+an Application document has the fields of the app.yaml of "ketch app deploy" and deploys its image.
+A diff is printed for every document, documents without changes are skipped. --dry-run prints diffs only:
+  ketch apply -f apps.yaml --dry-run
+`
+
+const (
+	applyTypeApplication = "Application"
+	applyTypeIngress     = "Ingress"
+)
+
+type applyOptions struct {
+	filename string
+	dryRun   bool
+}
+
+type applyFn func(ctx context.Context, cfg config, svc *deploy.Services, options applyOptions, out io.Writer) error
+
+func newApplyCmd(cfg config, out io.Writer, apply applyFn) *cobra.Command {
+	var options applyOptions
+	cmd := &cobra.Command{
+		Use:   "apply -f FILENAME [--dry-run]",
+		Short: "Create or update apps and the ingress configuration from a yaml file",
+		Long:  applyHelp,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			svc := &deploy.Services{
+				Client:         cfg.Client(),
+				KubeClient:     cfg.KubernetesClient(),
+				GetImageConfig: deploy.GetImageConfig,
+				Wait:           deploy.WaitForDeployment,
+				Templates:      cfg.Storage(),
+				Writer:         out,
+			}
+			return apply(cmd.Context(), cfg, svc, options, out)
+		},
+	}
+	cmd.Flags().StringVarP(&options.filename, "file", "f", "", "Path to a yaml file with apps and the ingress configuration")
+	cmd.Flags().BoolVar(&options.dryRun, "dry-run", false, "Print diffs without changing anything")
+	cmd.MarkFlagRequired("file")
+	return cmd
+}
+
+func apply(ctx context.Context, cfg config, svc *deploy.Services, options applyOptions, out io.Writer) error {
+	content, err := os.ReadFile(options.filename)
+	if err != nil {
+		return err
+	}
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(content)))
+	for {
+		document, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", options.filename, err)
+		}
+		if len(bytes.TrimSpace(document)) == 0 {
+			continue
+		}
+		var header struct {
+			Type string `json:"type"`
+		}
+		if err := yaml.Unmarshal(document, &header); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", options.filename, err)
+		}
+		switch header.Type {
+		case applyTypeIngress:
+			err = applyIngress(ctx, cfg, document, options, out)
+		case applyTypeApplication, "":
+			err = applyApplication(ctx, svc, document, options, out)
+		default:
+			err = fmt.Errorf("unknown type %q, supported types are %s and %s", header.Type, applyTypeApplication, applyTypeIngress)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func applyApplication(ctx context.Context, svc *deploy.Services, document []byte, options applyOptions, out io.Writer) error {
+	var application deploy.Application
+	if err := yaml.Unmarshal(document, &application); err != nil {
+		return err
+	}
+	deployOptions := deploy.Options{}
+	changeSet, err := deployOptions.GetChangeSetFromApplication(application)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "App %s:\n", *application.Name)
+	runner := deploy.New(changeSet)
+	changed, err := runner.Diff(ctx, svc)
+	if err != nil {
+		return err
+	}
+	if !changed || options.dryRun {
+		return nil
+	}
+	if err := runner.Run(ctx, svc); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "App %s applied.\n", *application.Name)
+	return nil
+}
+
+func applyIngress(ctx context.Context, cfg config, document []byte, options applyOptions, out io.Writer) error {
+	data, err := ingressApplyData(document)
+	if err != nil {
+		return err
+	}
+	if err := ketchclient.ValidateIngress(data); err != nil {
+		return err
+	}
+
+	ketchClient := newKetchClient(cfg)
+	current, err := ketchClient.GetIngress(ctx)
+	if client.IgnoreNotFound(err) != nil {
+		return err
+	}
+	fmt.Fprintln(out, "Ingress:")
+	changed, diffErr := writeIngressDiff(out, current, data)
+	if diffErr != nil {
+		return diffErr
+	}
+	if !changed || options.dryRun {
+		return nil
+	}
+	if err := ketchClient.SetIngress(ctx, data); err != nil {
+		return err
+	}
+	fmt.Fprintln(out, "Ingress applied.")
+	return nil
+}
+
+// ingressApplyData converts an Ingress document to data of the ingress configmap.
+// Scalars are written the way "ketch ingress set" writes them, maps and lists like appDefaults are written as yaml.
+func ingressApplyData(document []byte) (map[string]string, error) {
+	jsonDocument, err := yaml.YAMLToJSON(document)
+	if err != nil {
+		return nil, err
+	}
+	var values map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(jsonDocument))
+	decoder.UseNumber()
+	if err := decoder.Decode(&values); err != nil {
+		return nil, err
+	}
+	data := make(map[string]string, len(values))
+	for key, value := range values {
+		switch value := value.(type) {
+		case nil:
+		case string:
+			data[key] = value
+		case map[string]interface{}, []interface{}:
+			content, err := yaml.Marshal(value)
+			if err != nil {
+				return nil, err
+			}
+			data[key] = strings.TrimRight(string(content), "\n")
+		default:
+			data[key] = fmt.Sprint(value)
+		}
+	}
+	delete(data, "type")
+	return data, nil
+}
+
+// writeIngressDiff writes a unified diff of the ingress configmap's data, it returns false if nothing changes.
+func writeIngressDiff(out io.Writer, current, updated map[string]string) (bool, error) {
+	currentYaml, err := yaml.Marshal(current)
+	if err != nil {
+		return false, err
+	}
+	updatedYaml, err := yaml.Marshal(updated)
+	if err != nil {
+		return false, err
+	}
+	if bytes.Equal(currentYaml, updatedYaml) {
+		fmt.Fprintln(out, "No changes.")
+		return false, nil
+	}
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(currentYaml)),
+		B:        difflib.SplitLines(string(updatedYaml)),
+		FromFile: "current",
+		ToFile:   "new",
+		Context:  3,
+	})
+	if err != nil {
+		return false, err
+	}
+	fmt.Fprint(out, diff)
+	return true, nil
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/deploy"
+	"github.com/theketchio/ketch/internal/mocks"
+)
+
+const applyFile = `
+type: Ingress
+className: nginx
+serviceEndpoint: 10.10.10.10
+ingressType: nginx
+forceHTTPS: true
+preStopSleepSeconds: 10
+appDefaults:
+  labels:
+    team: platform
+---
+type: Application
+name: dashboard
+image: shipa/dashboard:0.1
+namespace: apps
+`
+
+func TestApply(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "apps.yaml")
+	require.Nil(t, os.WriteFile(filename, []byte(applyFile), 0600))
+	ingressConfigmap := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: ketchv1.IngressConfigmapName, Namespace: ketchv1.IngressConfigmapNamespace},
+		Data:       map[string]string{"className": "nginx", "serviceEndpoint": "10.10.10.10", "ingressType": "nginx"},
+	}
+	cfg := &mocks.Configuration{
+		CtrlClientObjects: []runtime.Object{ingressConfigmap.DeepCopy()},
+		KubeClientObjects: []runtime.Object{ingressConfigmap.DeepCopy()},
+	}
+	out := &bytes.Buffer{}
+	svc := &deploy.Services{
+		Client:         cfg.Client(),
+		KubeClient:     cfg.KubernetesClient(),
+		GetImageConfig: getImageConfig,
+		Templates:      staticTemplates{},
+		Writer:         out,
+	}
+
+	// a dry run prints diffs and changes nothing.
+	require.Nil(t, apply(context.Background(), cfg, svc, applyOptions{filename: filename, dryRun: true}, out))
+	require.Contains(t, out.String(), "Ingress:\n")
+	require.Contains(t, out.String(), "+forceHTTPS: \"true\"\n")
+	require.Contains(t, out.String(), "App dashboard:\n")
+	require.Contains(t, out.String(), "Deployment dashboard-web-1 (added)")
+	require.NotContains(t, out.String(), "applied")
+	err := cfg.Client().Get(context.Background(), types.NamespacedName{Name: "dashboard"}, &ketchv1.App{})
+	require.NotNil(t, err)
+
+	out.Reset()
+	require.Nil(t, apply(context.Background(), cfg, svc, applyOptions{filename: filename}, out))
+	require.Contains(t, out.String(), "Ingress applied.\n")
+	require.Contains(t, out.String(), "App dashboard applied.\n")
+	configmap := v1.ConfigMap{}
+	require.Nil(t, cfg.Client().Get(context.Background(), types.NamespacedName{Name: ketchv1.IngressConfigmapName, Namespace: ketchv1.IngressConfigmapNamespace}, &configmap))
+	require.Equal(t, map[string]string{
+		"className":           "nginx",
+		"serviceEndpoint":     "10.10.10.10",
+		"ingressType":         "nginx",
+		"forceHTTPS":          "true",
+		"preStopSleepSeconds": "10",
+		"appDefaults":         "labels:\n  team: platform",
+	}, configmap.Data)
+	app := ketchv1.App{}
+	require.Nil(t, cfg.Client().Get(context.Background(), types.NamespacedName{Name: "dashboard"}, &app))
+	require.Equal(t, "shipa/dashboard:0.1", app.Spec.Deployments[0].Image)
+
+	// applying the same file again changes nothing.
+	out.Reset()
+	require.Nil(t, apply(context.Background(), cfg, svc, applyOptions{filename: filename}, out))
+	require.Equal(t, "Ingress:\nNo changes.\nApp dashboard:\nNo changes.\n", out.String())
+}
+
+func TestApplyErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name:    "unknown type",
+			content: "type: Framework\nname: default\n",
+			wantErr: `unknown type "Framework", supported types are Application and Ingress`,
+		},
+		{
+			name:    "incomplete ingress",
+			content: "type: Ingress\nclassName: nginx\n",
+			wantErr: ingressSetValidationError.Error(),
+		},
+		{
+			name:    "application without a name",
+			content: "type: Application\nimage: shipa/dashboard:0.1\n",
+			wantErr: "missing required field name",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "apps.yaml")
+			require.Nil(t, os.WriteFile(filename, []byte(tt.content), 0600))
+			cfg := &mocks.Configuration{}
+			svc := &deploy.Services{Client: cfg.Client(), KubeClient: cfg.KubernetesClient(), Writer: &bytes.Buffer{}}
+			err := apply(context.Background(), cfg, svc, applyOptions{filename: filename}, &bytes.Buffer{})
+			require.NotNil(t, err)
+			require.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
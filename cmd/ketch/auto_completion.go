@@ -4,11 +4,15 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
 
 	"github.com/spf13/cobra"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
 )
 
 func newCompletionCmd() *cobra.Command {
@@ -113,3 +117,63 @@ func autoCompleteJobNames(cfg config, toComplete ...string) ([]string, cobra.She
 	}
 	return names, cobra.ShellCompDirectiveNoSpace
 }
+
+// autoCompleteProcessNames returns names of the processes of all deployments of the app.
+// Nothing is completed if the app doesn't exist, because its name can be a filename like in "ketch app deploy".
+func autoCompleteProcessNames(cfg config, appName string, toComplete ...string) ([]string, cobra.ShellCompDirective) {
+	app := ketchv1.App{}
+	if err := cfg.Client().Get(context.Background(), types.NamespacedName{Name: appName}, &app); err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	processes := map[string]struct{}{}
+	for _, deployment := range app.Spec.Deployments {
+		for _, process := range deployment.Processes {
+			processes[process.Name] = struct{}{}
+		}
+	}
+	var names []string
+	for name := range processes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return filterNames(names, toComplete...), cobra.ShellCompDirectiveNoFileComp
+}
+
+// autoCompleteCnames returns cnames of the app in the CNAME[/PATH] form "ketch cname remove" accepts.
+func autoCompleteCnames(cfg config, appName string, toComplete ...string) ([]string, cobra.ShellCompDirective) {
+	app := ketchv1.App{}
+	if err := cfg.Client().Get(context.Background(), types.NamespacedName{Name: appName}, &app); err != nil {
+		return []string{fmt.Sprintf("failed to get app: %s", err.Error())}, cobra.ShellCompDirectiveError
+	}
+	names := make([]string, 0, len(app.Spec.Ingress.Cnames))
+	for _, cname := range app.Spec.Ingress.Cnames {
+		names = append(names, cname.Address())
+	}
+	return filterNames(names, toComplete...), cobra.ShellCompDirectiveNoFileComp
+}
+
+// registerProcessNameCompletion completes the --process flag of a command whose first argument is an app name.
+func registerProcessNameCompletion(cmd *cobra.Command, cfg config, flag string) {
+	cmd.RegisterFlagCompletionFunc(flag, func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return autoCompleteProcessNames(cfg, args[0], toComplete)
+	})
+}
+
+func filterNames(names []string, filters ...string) []string {
+	if len(filters) == 0 {
+		return names
+	}
+	filtered := make([]string, 0, len(names))
+	for _, name := range names {
+		for _, filter := range filters {
+			if strings.Contains(name, filter) {
+				filtered = append(filtered, name)
+				break
+			}
+		}
+	}
+	return filtered
+}
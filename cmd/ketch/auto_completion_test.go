@@ -103,3 +103,121 @@ func Test_autoCompleteNamespaces(t *testing.T) {
 		})
 	}
 }
+
+func Test_autoCompleteProcessNames(t *testing.T) {
+	app := &ketchv1.App{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "app-a",
+		},
+		Spec: ketchv1.AppSpec{
+			Deployments: []ketchv1.AppDeploymentSpec{
+				{Version: 1, Processes: []ketchv1.ProcessSpec{{Name: "web"}, {Name: "worker"}}},
+				{Version: 2, Processes: []ketchv1.ProcessSpec{{Name: "web"}, {Name: "cron"}}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		cfg        config
+		appName    string
+		toComplete []string
+
+		want         []string
+		wantFallback cobra.ShellCompDirective
+	}{
+		{
+			name: "processes of all deployments",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{app},
+			},
+			appName: "app-a",
+
+			want:         []string{"cron", "web", "worker"},
+			wantFallback: cobra.ShellCompDirectiveNoFileComp,
+		},
+		{
+			name: "filtered",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{app},
+			},
+			appName:    "app-a",
+			toComplete: []string{"w"},
+
+			want:         []string{"web", "worker"},
+			wantFallback: cobra.ShellCompDirectiveNoFileComp,
+		},
+		{
+			name:    "no app",
+			cfg:     &mocks.Configuration{},
+			appName: "app.yaml",
+
+			wantFallback: cobra.ShellCompDirectiveNoFileComp,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			names, fallback := autoCompleteProcessNames(tt.cfg, tt.appName, tt.toComplete...)
+			if fallback != tt.wantFallback {
+				t.Errorf("autoCompleteProcessNames() fallback = %v, wantFallback %v", fallback, tt.wantFallback)
+				return
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("autoCompleteProcessNames() got = \n%v\n, want \n%v\n", names, tt.want)
+			}
+		})
+	}
+}
+
+func Test_autoCompleteCnames(t *testing.T) {
+	app := &ketchv1.App{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "app-a",
+		},
+		Spec: ketchv1.AppSpec{
+			Ingress: ketchv1.IngressSpec{
+				Cnames: ketchv1.CnameList{{Name: "theketch.io"}, {Name: "api.theketch.io", Path: "/v1"}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		cfg     config
+		appName string
+
+		want         []string
+		wantFallback cobra.ShellCompDirective
+	}{
+		{
+			name: "show all, no error",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{app},
+			},
+			appName: "app-a",
+
+			want:         []string{"theketch.io", "api.theketch.io/v1"},
+			wantFallback: cobra.ShellCompDirectiveNoFileComp,
+		},
+		{
+			name:    "no app",
+			cfg:     &mocks.Configuration{},
+			appName: "app-a",
+
+			want:         []string{`failed to get app: apps.theketch.io "app-a" not found`},
+			wantFallback: cobra.ShellCompDirectiveError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			names, fallback := autoCompleteCnames(tt.cfg, tt.appName)
+			if fallback != tt.wantFallback {
+				t.Errorf("autoCompleteCnames() fallback = %v, wantFallback %v", fallback, tt.wantFallback)
+				return
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("autoCompleteCnames() got = \n%v\n, want \n%v\n", names, tt.want)
+			}
+		})
+	}
+}
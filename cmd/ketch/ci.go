@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+)
+
+const (
+	nonInteractiveFlag  = "non-interactive"
+	nonInteractiveUsage = "Never prompt for input, commands needing a confirmation fail instead. Set KETCH_NON_INTERACTIVE=true to enable it for CI runners like GitHub Actions."
+
+	// Environment variables configuring ketch on CI runners.
+	nonInteractiveEnv   = "KETCH_NON_INTERACTIVE"
+	appEnv              = "KETCH_APP"
+	targetEnv           = "KETCH_TARGET"
+	registrySecretEnv   = "KETCH_REGISTRY_SECRET"
+	registryServerEnv   = "KETCH_REGISTRY_SERVER"
+	registryUsernameEnv = "KETCH_REGISTRY_USERNAME"
+	registryPasswordEnv = "KETCH_REGISTRY_PASSWORD"
+
+	// flagEnvAnnotation annotates flags whose value defaults to an environment variable.
+	flagEnvAnnotation = "theketch.io/env"
+
+	maskedValue = "***"
+	// minMaskedLength keeps short values like "1" or "true" from being masked everywhere in the output.
+	minMaskedLength = 4
+)
+
+// bindFlagEnv makes the flag default to the environment variable when the flag isn't passed.
+func bindFlagEnv(cmd *cobra.Command, flag, env string) {
+	cmd.Flags().SetAnnotation(flag, flagEnvAnnotation, []string{env})
+}
+
+// setFlagsFromEnv sets flags bound to environment variables with bindFlagEnv unless they are passed.
+func setFlagsFromEnv(cmd *cobra.Command, getenv func(string) string) error {
+	var err error
+	set := func(flag *pflag.Flag) {
+		envs := flag.Annotations[flagEnvAnnotation]
+		if err != nil || flag.Changed || len(envs) == 0 {
+			return
+		}
+		if value := getenv(envs[0]); value != "" {
+			if setErr := flag.Value.Set(value); setErr != nil {
+				err = fmt.Errorf("invalid value %q of %s: %w", value, envs[0], setErr)
+				return
+			}
+			flag.Changed = true
+		}
+	}
+	cmd.Flags().VisitAll(set)
+	return err
+}
+
+// nonInteractive reports whether the command must not prompt for input.
+func nonInteractive(cmd *cobra.Command) bool {
+	if flag := cmd.Flags().Lookup(nonInteractiveFlag); flag != nil && flag.Changed {
+		value, _ := strconv.ParseBool(flag.Value.String())
+		return value
+	}
+	value, _ := strconv.ParseBool(os.Getenv(nonInteractiveEnv))
+	return value
+}
+
+// useRegistryCredentials writes a docker config file with credentials of KETCH_REGISTRY_USERNAME and KETCH_REGISTRY_PASSWORD
+// and points DOCKER_CONFIG to it, so images built from source are pushed without "docker login".
+// Settings of the current docker config file like credential helpers are kept. The returned function removes the file.
+func useRegistryCredentials(getenv func(string) string) (func(), error) {
+	username, password := getenv(registryUsernameEnv), getenv(registryPasswordEnv)
+	if username == "" && password == "" {
+		return func() {}, nil
+	}
+	if username == "" || password == "" {
+		return nil, fmt.Errorf("%s and %s must be set together", registryUsernameEnv, registryPasswordEnv)
+	}
+	server := getenv(registryServerEnv)
+	if server == "" {
+		server = defaultRegistryServer
+	}
+	dockerConfig := map[string]interface{}{}
+	if content, err := ioutil.ReadFile(filepath.Join(dockerConfigDir(getenv), "config.json")); err == nil {
+		if err := json.Unmarshal(content, &dockerConfig); err != nil {
+			return nil, fmt.Errorf("failed to read docker config: %w", err)
+		}
+	}
+	auths, _ := dockerConfig["auths"].(map[string]interface{})
+	if auths == nil {
+		auths = map[string]interface{}{}
+	}
+	auths[server] = map[string]string{"auth": base64.StdEncoding.EncodeToString([]byte(username + ":" + password))}
+	dockerConfig["auths"] = auths
+	// credentials of the server are read from the file, a credential helper of the server would take precedence.
+	if helpers, ok := dockerConfig["credHelpers"].(map[string]interface{}); ok {
+		delete(helpers, strings.TrimPrefix(strings.TrimPrefix(server, "https://"), "http://"))
+	}
+	content, err := json.Marshal(dockerConfig)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := ioutil.TempDir("", "ketch-docker-config")
+	if err != nil {
+		return nil, err
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+	if err := ioutil.WriteFile(filepath.Join(dir, "config.json"), content, 0600); err != nil {
+		cleanup()
+		return nil, err
+	}
+	if err := os.Setenv("DOCKER_CONFIG", dir); err != nil {
+		cleanup()
+		return nil, err
+	}
+	return cleanup, nil
+}
+
+func dockerConfigDir(getenv func(string) string) string {
+	if dir := getenv("DOCKER_CONFIG"); dir != "" {
+		return dir
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".docker")
+}
+
+// secretMasker replaces values of sensitive environment variables of the ketch process in its output,
+// so secrets a CI runner passes to ketch, e.g. with --env DB_PASSWORD=$DB_PASSWORD, don't end up in build logs.
+type secretMasker struct {
+	mu      sync.Mutex
+	secrets [][]byte
+}
+
+// newSecretMasker returns a masker of values of environment variables in NAME=VALUE format
+// whose names match the redactor's patterns.
+func newSecretMasker(redact redactor, environ []string) *secretMasker {
+	m := &secretMasker{}
+	for _, env := range environ {
+		parts := strings.SplitN(env, "=", 2)
+		if len(parts) != 2 || len(parts[1]) < minMaskedLength {
+			continue
+		}
+		if redact.isSensitive(ketchv1.Env{Name: parts[0]}) {
+			m.secrets = append(m.secrets, []byte(parts[1]))
+		}
+	}
+	// a secret containing another one is masked first.
+	sort.Slice(m.secrets, func(i, j int) bool { return len(m.secrets[i]) > len(m.secrets[j]) })
+	return m
+}
+
+// writer returns a writer masking secrets of the output written to out.
+// A secret split between two writes isn't masked, writers of ketch write whole lines.
+func (m *secretMasker) writer(out io.Writer) io.Writer {
+	if len(m.secrets) == 0 {
+		return out
+	}
+	return &maskingWriter{masker: m, out: out}
+}
+
+type maskingWriter struct {
+	masker *secretMasker
+	out    io.Writer
+}
+
+func (w *maskingWriter) Write(p []byte) (int, error) {
+	masked := p
+	for _, secret := range w.masker.secrets {
+		masked = bytes.ReplaceAll(masked, secret, []byte(maskedValue))
+	}
+	w.masker.mu.Lock()
+	defer w.masker.mu.Unlock()
+	if _, err := w.out.Write(masked); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
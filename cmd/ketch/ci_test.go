@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetFlagsFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		env     map[string]string
+		wantApp string
+		wantErr string
+	}{
+		{
+			name:    "env",
+			env:     map[string]string{appEnv: "go-app"},
+			wantApp: "go-app",
+		},
+		{
+			name:    "flag takes precedence",
+			args:    []string{"--app", "other-app"},
+			env:     map[string]string{appEnv: "go-app"},
+			wantApp: "other-app",
+		},
+		{
+			name:    "no env",
+			wantErr: `required flag(s) "app" not set`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var app string
+			cmd := &cobra.Command{
+				Use: "test",
+				PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+					return setFlagsFromEnv(cmd, func(name string) string { return tt.env[name] })
+				},
+				RunE: func(cmd *cobra.Command, args []string) error { return nil },
+			}
+			cmd.Flags().StringVar(&app, "app", "", "")
+			cmd.MarkFlagRequired("app")
+			bindFlagEnv(cmd, "app", appEnv)
+			cmd.SetArgs(append([]string{}, tt.args...))
+			cmd.SetOut(&bytes.Buffer{})
+			cmd.SetErr(&bytes.Buffer{})
+			err := cmd.Execute()
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.wantApp, app)
+		})
+	}
+}
+
+func TestNonInteractive(t *testing.T) {
+	cmd := &cobra.Command{Use: "test"}
+	cmd.Flags().Bool(nonInteractiveFlag, false, "")
+	t.Setenv(nonInteractiveEnv, "")
+	require.False(t, nonInteractive(cmd))
+
+	t.Setenv(nonInteractiveEnv, "true")
+	require.True(t, nonInteractive(cmd))
+
+	require.Nil(t, cmd.Flags().Set(nonInteractiveFlag, "false"))
+	require.False(t, nonInteractive(cmd))
+}
+
+func TestUseRegistryCredentials(t *testing.T) {
+	dockerConfig := t.TempDir()
+	content := `{"auths":{"ghcr.io":{"auth":"Z2hjcjp0b2tlbg=="}},"credHelpers":{"registry.example.com":"ecr-login","gcr.io":"gcloud"}}`
+	require.Nil(t, ioutil.WriteFile(filepath.Join(dockerConfig, "config.json"), []byte(content), 0600))
+	t.Setenv("DOCKER_CONFIG", dockerConfig)
+
+	env := map[string]string{
+		"DOCKER_CONFIG":     dockerConfig,
+		registryServerEnv:   "https://registry.example.com",
+		registryUsernameEnv: "ci",
+		registryPasswordEnv: "s3cr3t",
+	}
+	cleanup, err := useRegistryCredentials(func(name string) string { return env[name] })
+	require.Nil(t, err)
+	dir := os.Getenv("DOCKER_CONFIG")
+	require.NotEqual(t, dockerConfig, dir)
+
+	written, err := ioutil.ReadFile(filepath.Join(dir, "config.json"))
+	require.Nil(t, err)
+	var got map[string]interface{}
+	require.Nil(t, json.Unmarshal(written, &got))
+	require.Equal(t, map[string]interface{}{
+		"auths": map[string]interface{}{
+			"ghcr.io":                      map[string]interface{}{"auth": "Z2hjcjp0b2tlbg=="},
+			"https://registry.example.com": map[string]interface{}{"auth": "Y2k6czNjcjN0"},
+		},
+		"credHelpers": map[string]interface{}{"gcr.io": "gcloud"},
+	}, got)
+
+	cleanup()
+	_, err = os.Stat(dir)
+	require.True(t, os.IsNotExist(err))
+
+	_, err = useRegistryCredentials(func(name string) string { return map[string]string{registryUsernameEnv: "ci"}[name] })
+	require.EqualError(t, err, "KETCH_REGISTRY_USERNAME and KETCH_REGISTRY_PASSWORD must be set together")
+}
+
+func TestSecretMasker(t *testing.T) {
+	environ := []string{
+		"HOME=/home/ci",
+		"DB_PASSWORD=hunter22",
+		"GITHUB_TOKEN=ghp_abc",
+		"DEPLOY_TOKEN=ghp_abcdef",
+		"SHORT_SECRET=abc",
+	}
+	masker := newSecretMasker(newRedactor(nil), environ)
+	out := &bytes.Buffer{}
+	w := masker.writer(out)
+	_, err := w.Write([]byte("password hunter22, tokens ghp_abcdef and ghp_abc, home /home/ci, abc\n"))
+	require.Nil(t, err)
+	require.Equal(t, "password ***, tokens *** and ***, home /home/ci, abc\n", out.String())
+
+	out = &bytes.Buffer{}
+	require.Equal(t, out, newSecretMasker(newRedactor(nil), []string{"HOME=/home/ci"}).writer(out))
+}
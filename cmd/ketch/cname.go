@@ -15,7 +15,7 @@ func newCnameCmd(cfg config, out io.Writer) *cobra.Command {
 			return cmd.Usage()
 		},
 	}
-	cmd.AddCommand(newCnameAddCmd(cfg, out))
-	cmd.AddCommand(newCnameRemoveCmd(cfg, out))
+	cmd.AddCommand(requireAccess(newCnameAddCmd(cfg, out), appsAccess("update")))
+	cmd.AddCommand(requireAccess(newCnameRemoveCmd(cfg, out), appsAccess("update")))
 	return cmd
 }
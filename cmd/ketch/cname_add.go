@@ -2,25 +2,31 @@ package main
 
 import (
 	"context"
-	"fmt"
 	"io"
 
 	"github.com/spf13/cobra"
-	"k8s.io/apimachinery/pkg/types"
 
-	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
 	"github.com/theketchio/ketch/internal/deploy"
 	"github.com/theketchio/ketch/internal/validation"
+	"github.com/theketchio/ketch/pkg/ketchclient"
 )
 
 const cnameAddHelp = `
 Add a new CNAME to an application.
+A CNAME can be a wildcard like "*.example.com" and have a path prefix like "example.com/api",
+only requests with the prefix are routed to the application, so several applications can share one hostname.
+Use --primary to make the CNAME the canonical address of the application, it can be used with an existing CNAME.
+Use --tls-secret to serve the CNAME over https with a certificate from an existing secret of type kubernetes.io/tls in the app's namespace,
+the secret is used instead of a certificate obtained by the cluster issuer. It can be used with an existing CNAME to change its certificate.
+Use --validate-dns to check that the CNAME's DNS record points to the ingress controller before adding it.
+Use --dns-target, --dns-ttl and --dns-provider-hint to override external-dns settings of "ketch ingress set --external-dns" for the CNAME,
+they can be used with an existing CNAME.
 `
 
 func newCnameAddCmd(cfg config, out io.Writer) *cobra.Command {
 	options := cnameAddOptions{}
 	cmd := &cobra.Command{
-		Use:   "add CNAME",
+		Use:   "add CNAME[/PATH]",
 		Args:  cobra.ExactValidArgs(1),
 		Short: "Add a new CNAME to an application.",
 		Long:  cnameAddHelp,
@@ -31,7 +37,14 @@ func newCnameAddCmd(cfg config, out io.Writer) *cobra.Command {
 	}
 	cmd.Flags().StringVarP(&options.appName, deploy.FlagApp, deploy.FlagAppShort, "", "The name of the app.")
 	cmd.MarkFlagRequired("app")
+	bindFlagEnv(cmd, "app", appEnv)
 	cmd.Flags().BoolVar(&options.secure, "secure", false, "Whether the CName should be https")
+	cmd.Flags().BoolVar(&options.primary, "primary", false, "Whether the CName is the canonical address of the app")
+	cmd.Flags().StringVar(&options.tlsSecret, "tls-secret", "", "The name of a secret in the app's namespace with an SSL certificate for the CName, implies --secure")
+	cmd.Flags().BoolVar(&options.validateDNS, "validate-dns", false, "Check that the CName's DNS record points to the ingress controller")
+	cmd.Flags().StringVar(&options.dnsTarget, "dns-target", "", "The target of the CName's DNS record created by external-dns")
+	cmd.Flags().Int64Var(&options.dnsTTL, "dns-ttl", 0, "The TTL in seconds of the CName's DNS record created by external-dns")
+	cmd.Flags().StringToStringVar(&options.dnsProviderHints, "dns-provider-hint", nil, "A provider-specific external-dns annotation of the CName without the prefix, e.g. cloudflare-proxied=false")
 
 	cmd.RegisterFlagCompletionFunc(deploy.FlagApp, func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		return autoCompleteAppNames(cfg, toComplete)
@@ -40,30 +53,30 @@ func newCnameAddCmd(cfg config, out io.Writer) *cobra.Command {
 }
 
 type cnameAddOptions struct {
-	appName string
-	cname   string
-	secure  bool
+	appName     string
+	cname       string
+	secure      bool
+	primary     bool
+	validateDNS bool
+	tlsSecret   string
+	resolver    validation.Resolver
+
+	dnsTarget        string
+	dnsTTL           int64
+	dnsProviderHints map[string]string
 }
 
 func cnameAdd(ctx context.Context, cfg config, options cnameAddOptions, out io.Writer) error {
-	if err := validation.ValidateCname(options.cname); err != nil {
-		return err
-	}
-	app := ketchv1.App{}
-	if err := cfg.Client().Get(ctx, types.NamespacedName{Name: options.appName}, &app); err != nil {
-		return fmt.Errorf("failed to get the app: %w", err)
-	}
-	for _, cname := range app.Spec.Ingress.Cnames {
-		if cname.Name == options.cname {
-			return nil
-		}
-	}
-	if options.secure && len(app.Spec.Ingress.Controller.ClusterIssuer) == 0 {
-		return ErrClusterIssuerRequired
-	}
-	app.Spec.Ingress.Cnames = append(app.Spec.Ingress.Cnames, ketchv1.Cname{Name: options.cname, Secure: options.secure})
-	if err := cfg.Client().Update(ctx, &app); err != nil {
-		return fmt.Errorf("failed to update the app: %w", err)
-	}
-	return nil
+	return newKetchClient(cfg).SetCname(ctx, ketchclient.SetCnameRequest{
+		App:              options.appName,
+		Cname:            options.cname,
+		Secure:           options.secure,
+		Primary:          options.primary,
+		TLSSecret:        options.tlsSecret,
+		ValidateDNS:      options.validateDNS,
+		Resolver:         options.resolver,
+		DNSTarget:        options.dnsTarget,
+		DNSTTL:           options.dnsTTL,
+		DNSProviderHints: options.dnsProviderHints,
+	})
 }
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/mocks"
+)
+
+func TestCnameAdd(t *testing.T) {
+	newApp := func(clusterIssuer string, cnames ...ketchv1.Cname) *ketchv1.App {
+		return &ketchv1.App{
+			ObjectMeta: metav1.ObjectMeta{Name: "go-app"},
+			Spec: ketchv1.AppSpec{
+				Namespace: "ketch-go-app",
+				Ingress: ketchv1.IngressSpec{
+					Cnames:     cnames,
+					Controller: ketchv1.IngressControllerSpec{ClusterIssuer: clusterIssuer},
+				},
+			},
+		}
+	}
+	tlsSecret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "theketch-tls", Namespace: "ketch-go-app"},
+		Type:       v1.SecretTypeTLS,
+		Data:       map[string][]byte{v1.TLSCertKey: []byte("cert"), v1.TLSPrivateKeyKey: []byte("key")},
+	}
+	opaqueSecret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "opaque", Namespace: "ketch-go-app"},
+		Data:       map[string][]byte{"password": []byte("secret")},
+	}
+	tests := []struct {
+		name       string
+		objects    []runtime.Object
+		options    cnameAddOptions
+		wantCnames ketchv1.CnameList
+		wantErr    error
+	}{
+		{
+			name:       "path prefix",
+			objects:    []runtime.Object{newApp("")},
+			options:    cnameAddOptions{appName: "go-app", cname: "theketch.io/api/"},
+			wantCnames: ketchv1.CnameList{{Name: "theketch.io", Path: "/api"}},
+		},
+		{
+			name:       "same hostname with another path",
+			objects:    []runtime.Object{newApp("", ketchv1.Cname{Name: "theketch.io"})},
+			options:    cnameAddOptions{appName: "go-app", cname: "theketch.io/api", primary: true},
+			wantCnames: ketchv1.CnameList{{Name: "theketch.io"}, {Name: "theketch.io", Path: "/api", Primary: true}},
+		},
+		{
+			name:    "invalid path",
+			objects: []runtime.Object{newApp("")},
+			options: cnameAddOptions{appName: "go-app", cname: "theketch.io/a b"},
+			wantErr: errors.New("invalid cname: path must start with '/' and consist of alphanumeric characters, '-', '_', '.', '~' or '%' separated by '/'"),
+		},
+		{
+			name:    "secure cname requires a cluster issuer",
+			objects: []runtime.Object{newApp("")},
+			options: cnameAddOptions{appName: "go-app", cname: "theketch.io", secure: true},
+			wantErr: ErrClusterIssuerRequired,
+		},
+		{
+			name:       "tls secret doesn't require a cluster issuer",
+			objects:    []runtime.Object{newApp(""), tlsSecret},
+			options:    cnameAddOptions{appName: "go-app", cname: "theketch.io", tlsSecret: "theketch-tls"},
+			wantCnames: ketchv1.CnameList{{Name: "theketch.io", Secure: true, SecretName: "theketch-tls"}},
+		},
+		{
+			name:       "tls secret of an existing cname",
+			objects:    []runtime.Object{newApp("letsencrypt", ketchv1.Cname{Name: "theketch.io", Secure: true}), tlsSecret},
+			options:    cnameAddOptions{appName: "go-app", cname: "theketch.io", tlsSecret: "theketch-tls"},
+			wantCnames: ketchv1.CnameList{{Name: "theketch.io", Secure: true, SecretName: "theketch-tls"}},
+		},
+		{
+			name:       "dns overrides",
+			objects:    []runtime.Object{newApp("")},
+			options:    cnameAddOptions{appName: "go-app", cname: "theketch.io", dnsTarget: "lb.theketch.io", dnsTTL: 60},
+			wantCnames: ketchv1.CnameList{{Name: "theketch.io", DNS: &ketchv1.CnameDNS{Target: "lb.theketch.io", TTL: 60}}},
+		},
+		{
+			name:       "dns overrides of an existing cname",
+			objects:    []runtime.Object{newApp("", ketchv1.Cname{Name: "theketch.io", Secure: true, SecretName: "theketch-tls"})},
+			options:    cnameAddOptions{appName: "go-app", cname: "theketch.io", dnsProviderHints: map[string]string{"cloudflare-proxied": "false"}},
+			wantCnames: ketchv1.CnameList{{Name: "theketch.io", Secure: true, SecretName: "theketch-tls", DNS: &ketchv1.CnameDNS{ProviderHints: map[string]string{"cloudflare-proxied": "false"}}}},
+		},
+		{
+			name:    "negative dns ttl",
+			objects: []runtime.Object{newApp("")},
+			options: cnameAddOptions{appName: "go-app", cname: "theketch.io", dnsTTL: -1},
+			wantErr: errors.New("dns ttl must be greater than or equal to 0"),
+		},
+		{
+			name:    "tls secret not found",
+			objects: []runtime.Object{newApp("")},
+			options: cnameAddOptions{appName: "go-app", cname: "theketch.io", tlsSecret: "theketch-tls"},
+			wantErr: errors.New("tls secret not found in the app namespace: theketch-tls"),
+		},
+		{
+			name:    "secret without a certificate",
+			objects: []runtime.Object{newApp(""), opaqueSecret},
+			options: cnameAddOptions{appName: "go-app", cname: "theketch.io", tlsSecret: "opaque"},
+			wantErr: errors.New("secret opaque must contain tls.crt and tls.key"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &mocks.Configuration{CtrlClientObjects: tt.objects}
+			err := cnameAdd(context.Background(), cfg, tt.options, &bytes.Buffer{})
+			if tt.wantErr != nil {
+				require.EqualError(t, err, tt.wantErr.Error())
+				return
+			}
+			require.Nil(t, err)
+			app := ketchv1.App{}
+			require.Nil(t, cfg.Client().Get(context.Background(), types.NamespacedName{Name: "go-app"}, &app))
+			require.Equal(t, tt.wantCnames, app.Spec.Ingress.Cnames)
+		})
+	}
+}
@@ -2,24 +2,23 @@ package main
 
 import (
 	"context"
-	"fmt"
 	"io"
 
 	"github.com/spf13/cobra"
-	"k8s.io/apimachinery/pkg/types"
 
-	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
 	"github.com/theketchio/ketch/internal/deploy"
+	"github.com/theketchio/ketch/pkg/ketchclient"
 )
 
 const cnameRemoveHelp = `
 Remove a CNAME from an application.
+A CNAME with a path prefix is removed by its full address like "example.com/api".
 `
 
 func newCnameRemoveCmd(cfg config, out io.Writer) *cobra.Command {
 	options := cnameRemoveOptions{}
 	cmd := &cobra.Command{
-		Use:   "remove CNAME",
+		Use:   "remove CNAME[/PATH]",
 		Args:  cobra.ExactValidArgs(1),
 		Short: "Remove a CNAME from an application.",
 		Long:  cnameRemoveHelp,
@@ -27,9 +26,17 @@ func newCnameRemoveCmd(cfg config, out io.Writer) *cobra.Command {
 			options.cname = args[0]
 			return cnameRemove(cmd.Context(), cfg, options, out)
 		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			appName, _ := cmd.Flags().GetString(deploy.FlagApp)
+			if len(args) > 0 || appName == "" {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return autoCompleteCnames(cfg, appName, toComplete)
+		},
 	}
 	cmd.Flags().StringVarP(&options.appName, deploy.FlagApp, deploy.FlagAppShort, "", "The name of the app.")
 	cmd.MarkFlagRequired(deploy.FlagApp)
+	bindFlagEnv(cmd, deploy.FlagApp, appEnv)
 	cmd.RegisterFlagCompletionFunc(deploy.FlagApp, func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		return autoCompleteAppNames(cfg, toComplete)
 	})
@@ -42,20 +49,5 @@ type cnameRemoveOptions struct {
 }
 
 func cnameRemove(ctx context.Context, cfg config, options cnameRemoveOptions, out io.Writer) error {
-	app := ketchv1.App{}
-	if err := cfg.Client().Get(ctx, types.NamespacedName{Name: options.appName}, &app); err != nil {
-		return fmt.Errorf("failed to get the app: %w", err)
-	}
-	cnames := make(ketchv1.CnameList, 0, len(app.Spec.Ingress.Cnames))
-	for _, cname := range app.Spec.Ingress.Cnames {
-		if cname.Name == options.cname {
-			continue
-		}
-		cnames = append(cnames, cname)
-	}
-	app.Spec.Ingress.Cnames = cnames
-	if err := cfg.Client().Update(ctx, &app); err != nil {
-		return fmt.Errorf("failed to update the app: %w", err)
-	}
-	return nil
+	return newKetchClient(cfg).RemoveCname(ctx, ketchclient.RemoveCnameRequest{App: options.appName, Cname: options.cname})
 }
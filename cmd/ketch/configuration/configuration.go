@@ -11,6 +11,7 @@ import (
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -32,12 +33,39 @@ func init() {
 type Configuration struct {
 	cli     client.Client
 	storage *templates.Storage
+	target  *Target
 }
 
 // KetchConfig contains all the values present in the config.toml
 type KetchConfig struct {
 	AdditionalBuilders []AdditionalBuilder `toml:"additional-builders,omitempty"`
 	DefaultBuilder     string              `toml:"default-builder,omitempty"`
+	// DefaultRegistrySecret is a name of a secret to pull images of new applications unless --registry-secret is set.
+	DefaultRegistrySecret string `toml:"default-registry-secret,omitempty"`
+	// SensitiveEnvPatterns are shell patterns matching names of env variables whose values ketch masks in its output.
+	SensitiveEnvPatterns []string `toml:"sensitive-env-patterns,omitempty"`
+	// Targets are clusters managed by ketch, commands run against CurrentTarget unless --target is set.
+	Targets       []Target `toml:"targets,omitempty"`
+	CurrentTarget string   `toml:"current-target,omitempty"`
+}
+
+// Target is a named cluster, a context of a kubeconfig file.
+type Target struct {
+	Name string `toml:"name" json:"name" yaml:"name"`
+	// Kubeconfig is a path to a kubeconfig file, the default kubeconfig is used if it's empty.
+	Kubeconfig string `toml:"kubeconfig,omitempty" json:"kubeconfig" yaml:"kubeconfig"`
+	// Context is a context of the kubeconfig, its current context is used if it's empty.
+	Context string `toml:"context,omitempty" json:"context" yaml:"context"`
+}
+
+// Target returns the target with the given name.
+func (c KetchConfig) Target(name string) (Target, bool) {
+	for _, target := range c.Targets {
+		if target.Name == name {
+			return target, true
+		}
+	}
+	return Target{}, false
 }
 
 // AdditionalBuilder contains the information of any user added builders
@@ -47,18 +75,20 @@ type AdditionalBuilder struct {
 	Description string `toml:"description" json:"description" yaml:"description"`
 }
 
+// UseTarget makes clients connect to the target's cluster.
+func (cfg *Configuration) UseTarget(target Target) {
+	cfg.target = &target
+	cfg.cli = nil
+	cfg.storage = nil
+}
+
 // Client returns initialized controller-runtime's Client to perform CRUD operations on Kubernetes objects.
 func (cfg *Configuration) Client() client.Client {
 	if cfg.cli != nil {
 		return cfg.cli
 	}
-	configFlags := genericclioptions.NewConfigFlags(true)
-	factory := cmdutil.NewFactory(configFlags)
-	kubeCfg, err := factory.ToRESTConfig()
-	if err != nil {
-		log.Fatalf("failed to create kubernetes client: %v", err)
-	}
-	cfg.cli, err = client.New(kubeCfg, client.Options{Scheme: scheme})
+	var err error
+	cfg.cli, err = client.New(cfg.RESTConfig(), client.Options{Scheme: scheme})
 	if err != nil {
 		log.Fatalf("failed to create kubernetes client: %v", err)
 	}
@@ -67,13 +97,7 @@ func (cfg *Configuration) Client() client.Client {
 
 // KubernetesClient returns kubernetes typed client. It's used to work with standard kubernetes types.
 func (cfg *Configuration) KubernetesClient() kubernetes.Interface {
-	configFlags := genericclioptions.NewConfigFlags(true)
-	factory := cmdutil.NewFactory(configFlags)
-	kubeCfg, err := factory.ToRESTConfig()
-	if err != nil {
-		log.Fatalf("failed to create kubernetes client: %v", err)
-	}
-	clientset, err := kubernetes.NewForConfig(kubeCfg)
+	clientset, err := kubernetes.NewForConfig(cfg.RESTConfig())
 	if err != nil {
 		log.Fatalf("failed to create kubernetes client: %v", err)
 	}
@@ -91,17 +115,30 @@ func (cfg *Configuration) Storage() templates.Client {
 
 // DynamicClient returns kubernetes dynamic client. It's used to work with CRDs for which we don't have go types like ClusterIssuer.
 func (cfg *Configuration) DynamicClient() dynamic.Interface {
-	flags := genericclioptions.NewConfigFlags(true)
-	factory := cmdutil.NewFactory(flags)
-	conf, err := factory.ToRESTConfig()
+	i, err := dynamic.NewForConfig(cfg.RESTConfig())
 	if err != nil {
 		log.Fatalf("failed to create kubernetes client: %v", err)
 	}
-	i, err := dynamic.NewForConfig(conf)
+	return i
+}
+
+// RESTConfig returns a config of kubernetes clients. It's used to stream to pods like "ketch app run" does.
+func (cfg *Configuration) RESTConfig() *rest.Config {
+	flags := genericclioptions.NewConfigFlags(true)
+	if cfg.target != nil {
+		if cfg.target.Kubeconfig != "" {
+			flags.KubeConfig = &cfg.target.Kubeconfig
+		}
+		if cfg.target.Context != "" {
+			flags.Context = &cfg.target.Context
+		}
+	}
+	factory := cmdutil.NewFactory(flags)
+	conf, err := factory.ToRESTConfig()
 	if err != nil {
 		log.Fatalf("failed to create kubernetes client: %v", err)
 	}
-	return i
+	return conf
 }
 
 // DefaultConfigPath returns the path to the config.toml file
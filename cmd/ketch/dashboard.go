@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/utils"
+)
+
+const dashboardHelp = `
+Show a terminal dashboard of apps of the cluster.
+
+The dashboard lists apps with their processes, units and recent events, and refreshes every few seconds.
+Keys of the selected process:
+  s  scale the process
+  r  restart pods of the process
+  l  show logs of the process
+Tab switches between apps and processes, Esc closes dialogs and q quits.
+`
+
+const (
+	// dashboardRefreshInterval is the time between two refreshes of the dashboard.
+	dashboardRefreshInterval = 2 * time.Second
+	// dashboardLogLines is the number of the most recent log lines of every pod shown by the dashboard.
+	dashboardLogLines = 100
+)
+
+func newDashboardCmd(cfg config, out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "dashboard",
+		Short: "Show a terminal dashboard of apps.",
+		Long:  dashboardHelp,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if nonInteractive(cmd) {
+				return ErrInteractiveCommand
+			}
+			return runDashboard(cmd.Context(), cfg)
+		},
+	}
+	return cmd
+}
+
+// dashboardCluster returns a description of the cluster's ingress controller shown in the header of the dashboard.
+func dashboardCluster(ctx context.Context, cfg config) string {
+	configmap := corev1.ConfigMap{}
+	err := cfg.Client().Get(ctx, types.NamespacedName{Name: ketchv1.IngressConfigmapName, Namespace: ketchv1.IngressConfigmapNamespace}, &configmap)
+	if err != nil {
+		return "Ingress controller: unknown"
+	}
+	spec := ketchv1.NewIngressControllerSpec(configmap)
+	description := fmt.Sprintf("Ingress controller: %s", spec.IngressType)
+	if spec.ClassName != "" {
+		description += fmt.Sprintf(" (class %s)", spec.ClassName)
+	}
+	if spec.ServiceEndpoint != "" {
+		description += fmt.Sprintf(", endpoint %s", spec.ServiceEndpoint)
+	}
+	return description
+}
+
+// dashboardApps returns apps of the cluster with their states, like "ketch app list" shows them.
+func dashboardApps(ctx context.Context, cfg config) ([]appListOutput, error) {
+	apps, err := listApps(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	sortApps(apps.Items, appListSortByName)
+	pods, forbidden, err := readableAppsPods(ctx, cfg, "", apps.Items)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list apps pods: %w", err)
+	}
+	outputs := generateAppListOutput(apps, pods)
+	for i := range outputs {
+		if forbidden[outputs[i].Namespace] {
+			outputs[i].State = appStateUnknown
+		}
+	}
+	return outputs, nil
+}
+
+// dashboardProcesses returns processes of the app with their units, like "ketch app info" shows them.
+func dashboardProcesses(ctx context.Context, cfg config, appName string) ([]deploymentOutput, error) {
+	app := ketchv1.App{}
+	if err := cfg.Client().Get(ctx, types.NamespacedName{Name: appName}, &app); err != nil {
+		return nil, fmt.Errorf("failed to get app: %w", err)
+	}
+	pods, err := appInfoPods(ctx, cfg, app)
+	if apierrors.IsForbidden(err) {
+		pods = &corev1.PodList{}
+	} else if err != nil {
+		return nil, err
+	}
+	return generateAppInfoOutput(app, pods, nil).Deployments, nil
+}
+
+// scaleProcess sets the number of units of the process of the deployment, like "ketch unit set" does.
+func scaleProcess(ctx context.Context, cfg config, appName string, version int, process string, units int) error {
+	return unitSet(ctx, cfg, unitOptions{
+		appName:           appName,
+		processName:       process,
+		deploymentVersion: version,
+		quantity:          units,
+	}, io.Discard)
+}
+
+// restartProcess restarts pods of the process of the deployment by changing an annotation of the pods,
+// the pods are replaced by a rolling update of the process.
+func restartProcess(ctx context.Context, cfg config, appName string, version int, process string, now time.Time) error {
+	app := ketchv1.App{}
+	if err := cfg.Client().Get(ctx, types.NamespacedName{Name: appName}, &app); err != nil {
+		return fmt.Errorf("failed to get app: %w", err)
+	}
+	item := ketchv1.MetadataItem{
+		Target:            ketchv1.Target{APIVersion: "v1", Kind: "Pod"},
+		Apply:             map[string]string{utils.KetchRestartedAtAnnotation: now.UTC().Format(time.RFC3339)},
+		DeploymentVersion: version,
+		ProcessName:       process,
+	}
+	replaced := false
+	for i, annotation := range app.Spec.Annotations {
+		if _, ok := annotation.Apply[utils.KetchRestartedAtAnnotation]; ok && annotation.DeploymentVersion == version && annotation.ProcessName == process {
+			app.Spec.Annotations[i] = item
+			replaced = true
+		}
+	}
+	if !replaced {
+		app.Spec.Annotations = append(app.Spec.Annotations, item)
+	}
+	if err := cfg.Client().Update(ctx, &app); err != nil {
+		return fmt.Errorf("failed to update app: %w", err)
+	}
+	return nil
+}
+
+// processLogs returns the most recent logs of every pod of the process of the deployment.
+func processLogs(ctx context.Context, cfg config, appName string, version int, process string) (string, error) {
+	app := ketchv1.App{}
+	if err := cfg.Client().Get(ctx, types.NamespacedName{Name: appName}, &app); err != nil {
+		return "", fmt.Errorf("failed to get app: %w", err)
+	}
+	selector := fmt.Sprintf("%s=%s,%s=%s,%s=%d", utils.KetchAppNameLabel, appName, utils.KetchProcessNameLabel, process, utils.KetchDeploymentVersionLabel, version)
+	pods, err := cfg.KubernetesClient().CoreV1().Pods(app.Spec.Namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return "", fmt.Errorf("failed to list pods: %w", err)
+	}
+	sort.Slice(pods.Items, func(i, j int) bool {
+		return pods.Items[i].Name < pods.Items[j].Name
+	})
+	tailLines := int64(dashboardLogLines)
+	var b strings.Builder
+	for _, pod := range pods.Items {
+		logs, err := cfg.KubernetesClient().CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{TailLines: &tailLines}).DoRaw(ctx)
+		if err != nil {
+			fmt.Fprintf(&b, "==> %s: failed to read logs: %v\n", pod.Name, unwrappedError(err))
+			continue
+		}
+		fmt.Fprintf(&b, "==> %s\n%s\n", pod.Name, strings.TrimRight(string(logs), "\n"))
+	}
+	if b.Len() == 0 {
+		return "No units found.\n", nil
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/mocks"
+	"github.com/theketchio/ketch/internal/utils"
+)
+
+func dashboardTestPod(name, process string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "ketch-hello",
+			Labels: map[string]string{
+				utils.KetchAppNameLabel:           "hello",
+				utils.KetchProcessNameLabel:       process,
+				utils.KetchDeploymentVersionLabel: "2",
+			},
+		},
+	}
+}
+
+func Test_dashboardCluster(t *testing.T) {
+	cfg := &mocks.Configuration{}
+	require.Equal(t, "Ingress controller: unknown", dashboardCluster(context.Background(), cfg))
+
+	configmap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: ketchv1.IngressConfigmapName, Namespace: ketchv1.IngressConfigmapNamespace},
+		Data: map[string]string{
+			"ingressType":     "traefik",
+			"className":       "traefik",
+			"serviceEndpoint": "10.10.10.10",
+		},
+	}
+	cfg = &mocks.Configuration{CtrlClientObjects: []runtime.Object{configmap}}
+	require.Equal(t, "Ingress controller: traefik (class traefik), endpoint 10.10.10.10", dashboardCluster(context.Background(), cfg))
+}
+
+func Test_dashboardAppsAndProcesses(t *testing.T) {
+	cfg := &mocks.Configuration{CtrlClientObjects: []runtime.Object{unitTestApp()}}
+
+	apps, err := dashboardApps(context.Background(), cfg)
+	require.Nil(t, err)
+	require.Len(t, apps, 1)
+	require.Equal(t, "hello", apps[0].Name)
+	require.Equal(t, "ketch-hello", apps[0].Namespace)
+
+	processes, err := dashboardProcesses(context.Background(), cfg, "hello")
+	require.Nil(t, err)
+	require.Len(t, processes, 2)
+	require.Equal(t, "2", processes[0].DeploymentVersion)
+	require.Equal(t, "web", processes[0].ProcessName)
+	require.Equal(t, "worker", processes[1].ProcessName)
+
+	_, err = dashboardProcesses(context.Background(), cfg, "unknown")
+	require.NotNil(t, err)
+}
+
+func Test_scaleProcess(t *testing.T) {
+	cfg := &mocks.Configuration{CtrlClientObjects: []runtime.Object{unitTestApp()}}
+	require.Nil(t, scaleProcess(context.Background(), cfg, "hello", 2, "worker", 3))
+
+	app := ketchv1.App{}
+	require.Nil(t, cfg.Client().Get(context.Background(), types.NamespacedName{Name: "hello"}, &app))
+	require.Equal(t, 3, *app.Spec.Deployments[0].Processes[1].Units)
+}
+
+func Test_restartProcess(t *testing.T) {
+	cfg := &mocks.Configuration{CtrlClientObjects: []runtime.Object{unitTestApp()}}
+	first := time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)
+	require.Nil(t, restartProcess(context.Background(), cfg, "hello", 2, "web", first))
+
+	app := ketchv1.App{}
+	require.Nil(t, cfg.Client().Get(context.Background(), types.NamespacedName{Name: "hello"}, &app))
+	require.Equal(t, []ketchv1.MetadataItem{
+		{
+			Target:            ketchv1.Target{APIVersion: "v1", Kind: "Pod"},
+			Apply:             map[string]string{utils.KetchRestartedAtAnnotation: "2022-03-01T10:00:00Z"},
+			DeploymentVersion: 2,
+			ProcessName:       "web",
+		},
+	}, app.Spec.Annotations)
+
+	// restarting the process again replaces its annotation.
+	require.Nil(t, restartProcess(context.Background(), cfg, "hello", 2, "web", first.Add(time.Hour)))
+	require.Nil(t, cfg.Client().Get(context.Background(), types.NamespacedName{Name: "hello"}, &app))
+	require.Len(t, app.Spec.Annotations, 1)
+	require.Equal(t, "2022-03-01T11:00:00Z", app.Spec.Annotations[0].Apply[utils.KetchRestartedAtAnnotation])
+
+	err := restartProcess(context.Background(), cfg, "unknown", 2, "web", first)
+	require.NotNil(t, err)
+}
+
+func Test_processLogs(t *testing.T) {
+	cfg := &mocks.Configuration{
+		CtrlClientObjects: []runtime.Object{unitTestApp()},
+		KubeClientObjects: []runtime.Object{
+			dashboardTestPod("hello-web-2-b", "web"),
+			dashboardTestPod("hello-web-2-a", "web"),
+			dashboardTestPod("hello-worker-2-a", "worker"),
+		},
+	}
+	logs, err := processLogs(context.Background(), cfg, "hello", 2, "web")
+	require.Nil(t, err)
+	require.Equal(t, "==> hello-web-2-a\nfake logs\n==> hello-web-2-b\nfake logs\n", logs)
+
+	logs, err = processLogs(context.Background(), cfg, "hello", 3, "web")
+	require.Nil(t, err)
+	require.Equal(t, "No units found.\n", logs)
+}
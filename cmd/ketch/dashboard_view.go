@@ -0,0 +1,350 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const (
+	dashboardScalePage   = "scale"
+	dashboardRestartPage = "restart"
+	dashboardLogsPage    = "logs"
+)
+
+// dashboardView is the terminal UI of "ketch dashboard".
+type dashboardView struct {
+	ctx context.Context
+	cfg config
+
+	app       *tview.Application
+	pages     *tview.Pages
+	header    *tview.TextView
+	apps      *tview.Table
+	processes *tview.Table
+	events    *tview.TextView
+	status    *tview.TextView
+
+	// refresh asks the refresh loop to refresh the dashboard before the next tick.
+	refresh chan struct{}
+}
+
+// runDashboard shows the dashboard until the user quits or ctx is done.
+func runDashboard(ctx context.Context, cfg config) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	v := newDashboardView(ctx, cfg)
+	go func() {
+		<-ctx.Done()
+		v.app.Stop()
+	}()
+	go v.refreshLoop()
+	return v.app.Run()
+}
+
+func newDashboardView(ctx context.Context, cfg config) *dashboardView {
+	v := &dashboardView{
+		ctx:       ctx,
+		cfg:       cfg,
+		app:       tview.NewApplication(),
+		pages:     tview.NewPages(),
+		header:    tview.NewTextView().SetDynamicColors(true),
+		apps:      tview.NewTable().SetSelectable(true, false).SetFixed(1, 0),
+		processes: tview.NewTable().SetSelectable(true, false).SetFixed(1, 0),
+		events:    tview.NewTextView().SetScrollable(true),
+		status:    tview.NewTextView(),
+		refresh:   make(chan struct{}, 1),
+	}
+	v.apps.SetBorder(true).SetTitle(" Apps ")
+	v.processes.SetBorder(true).SetTitle(" Processes ")
+	v.events.SetBorder(true).SetTitle(" Events ")
+	v.apps.SetSelectionChangedFunc(func(row, column int) {
+		v.requestRefresh()
+	})
+	v.processes.SetInputCapture(v.processKeys)
+
+	details := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(v.processes, 0, 1, false).
+		AddItem(v.events, 0, 1, false)
+	body := tview.NewFlex().
+		AddItem(v.apps, 0, 1, true).
+		AddItem(details, 0, 2, false)
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(v.header, 2, 0, false).
+		AddItem(body, 0, 1, true).
+		AddItem(v.status, 1, 0, false)
+	v.pages.AddPage("main", layout, true, true)
+
+	v.app.SetRoot(v.pages, true).SetFocus(v.apps)
+	v.app.SetInputCapture(v.globalKeys)
+	return v
+}
+
+// globalKeys handles keys which work everywhere but in dialogs.
+func (v *dashboardView) globalKeys(event *tcell.EventKey) *tcell.EventKey {
+	if name, _ := v.pages.GetFrontPage(); name != "main" {
+		if event.Key() == tcell.KeyEscape {
+			v.closeDialog(name)
+			return nil
+		}
+		return event
+	}
+	switch {
+	case event.Key() == tcell.KeyTab:
+		if v.apps.HasFocus() {
+			v.app.SetFocus(v.processes)
+		} else {
+			v.app.SetFocus(v.apps)
+		}
+		return nil
+	case event.Rune() == 'q':
+		v.app.Stop()
+		return nil
+	}
+	return event
+}
+
+// processKeys handles keys of the selected process.
+func (v *dashboardView) processKeys(event *tcell.EventKey) *tcell.EventKey {
+	appName, version, process, ok := v.selectedProcess()
+	if !ok {
+		return event
+	}
+	switch event.Rune() {
+	case 's':
+		v.showScale(appName, version, process)
+	case 'r':
+		v.showRestart(appName, version, process)
+	case 'l':
+		v.showLogs(appName, version, process)
+	default:
+		return event
+	}
+	return nil
+}
+
+func (v *dashboardView) selectedApp() (string, bool) {
+	row, _ := v.apps.GetSelection()
+	if row < 1 || row >= v.apps.GetRowCount() {
+		return "", false
+	}
+	return v.apps.GetCell(row, 0).Text, true
+}
+
+func (v *dashboardView) selectedProcess() (string, int, string, bool) {
+	appName, ok := v.selectedApp()
+	if !ok {
+		return "", 0, "", false
+	}
+	row, _ := v.processes.GetSelection()
+	if row < 1 || row >= v.processes.GetRowCount() {
+		return "", 0, "", false
+	}
+	version, err := strconv.Atoi(v.processes.GetCell(row, 0).Text)
+	if err != nil {
+		return "", 0, "", false
+	}
+	return appName, version, v.processes.GetCell(row, 1).Text, true
+}
+
+func (v *dashboardView) showScale(appName string, version int, process string) {
+	input := tview.NewInputField().
+		SetLabel(fmt.Sprintf("Units of %s (deployment %d) of %s: ", process, version, appName)).
+		SetFieldWidth(6).
+		SetAcceptanceFunc(tview.InputFieldInteger)
+	input.SetDoneFunc(func(key tcell.Key) {
+		if key != tcell.KeyEnter {
+			return
+		}
+		units, err := strconv.Atoi(input.GetText())
+		v.closeDialog(dashboardScalePage)
+		if err != nil || units < 0 {
+			v.setStatus("invalid number of units %q", input.GetText())
+			return
+		}
+		v.run(fmt.Sprintf("scaled %s to %d units", process, units), func() error {
+			return scaleProcess(v.ctx, v.cfg, appName, version, process, units)
+		})
+	})
+	input.SetBorder(true).SetTitle(" Scale ")
+	v.showDialog(dashboardScalePage, dialog(input, 60, 3))
+}
+
+func (v *dashboardView) showRestart(appName string, version int, process string) {
+	modal := tview.NewModal().
+		SetText(fmt.Sprintf("Restart pods of %s (deployment %d) of %s?", process, version, appName)).
+		AddButtons([]string{"Restart", "Cancel"}).
+		SetDoneFunc(func(_ int, label string) {
+			v.closeDialog(dashboardRestartPage)
+			if label != "Restart" {
+				return
+			}
+			v.run(fmt.Sprintf("restarting %s", process), func() error {
+				return restartProcess(v.ctx, v.cfg, appName, version, process, time.Now())
+			})
+		})
+	v.showDialog(dashboardRestartPage, modal)
+}
+
+func (v *dashboardView) showLogs(appName string, version int, process string) {
+	logs := tview.NewTextView().SetScrollable(true)
+	logs.SetBorder(true).SetTitle(fmt.Sprintf(" Logs of %s (deployment %d) of %s, Esc closes ", process, version, appName))
+	logs.SetText("Loading logs...")
+	v.showDialog(dashboardLogsPage, logs)
+	go func() {
+		text, err := processLogs(v.ctx, v.cfg, appName, version, process)
+		if err != nil {
+			text = err.Error()
+		}
+		v.app.QueueUpdateDraw(func() {
+			logs.SetText(text).ScrollToEnd()
+		})
+	}()
+}
+
+func (v *dashboardView) showDialog(name string, p tview.Primitive) {
+	v.pages.AddPage(name, p, true, true)
+	v.app.SetFocus(p)
+}
+
+func (v *dashboardView) closeDialog(name string) {
+	v.pages.RemovePage(name)
+	v.app.SetFocus(v.processes)
+}
+
+// run runs an action of a dialog in the background and shows its outcome in the status line.
+func (v *dashboardView) run(done string, action func() error) {
+	go func() {
+		err := action()
+		v.app.QueueUpdateDraw(func() {
+			if err != nil {
+				v.setStatus("%v", unwrappedError(err))
+				return
+			}
+			v.setStatus("%s", done)
+		})
+		v.requestRefresh()
+	}()
+}
+
+func (v *dashboardView) setStatus(format string, args ...interface{}) {
+	v.status.SetText(fmt.Sprintf(format, args...))
+}
+
+func (v *dashboardView) requestRefresh() {
+	select {
+	case v.refresh <- struct{}{}:
+	default:
+		// a refresh is pending already.
+	}
+}
+
+// refreshLoop reads apps, processes and events of the selected app every dashboardRefreshInterval,
+// the cluster is read outside of the UI goroutine so slow requests don't freeze the dashboard.
+func (v *dashboardView) refreshLoop() {
+	ticker := time.NewTicker(dashboardRefreshInterval)
+	defer ticker.Stop()
+	for {
+		header := dashboardCluster(v.ctx, v.cfg)
+		apps, err := dashboardApps(v.ctx, v.cfg)
+		var selected string
+		v.app.QueueUpdate(func() {
+			selected, _ = v.selectedApp()
+		})
+		var processes []deploymentOutput
+		var events []eventOutput
+		var detailsErr error
+		if selected != "" {
+			processes, detailsErr = dashboardProcesses(v.ctx, v.cfg, selected)
+			if detailsErr == nil {
+				events, detailsErr = appEvents(v.ctx, v.cfg, selected, appInfoEventsLimit)
+			}
+		}
+		v.app.QueueUpdateDraw(func() {
+			v.header.SetText(fmt.Sprintf("[::b]ketch dashboard[::-]  %s\n%s", header, dashboardKeys))
+			switch {
+			case err != nil:
+				v.setStatus("%v", unwrappedError(err))
+			case detailsErr != nil:
+				v.setStatus("%v", unwrappedError(detailsErr))
+			}
+			if err == nil {
+				v.showApps(apps)
+			}
+			if current, _ := v.selectedApp(); current == selected {
+				v.showProcesses(processes)
+				v.showEvents(events)
+			}
+		})
+		select {
+		case <-v.ctx.Done():
+			return
+		case <-ticker.C:
+		case <-v.refresh:
+		}
+	}
+}
+
+// dashboardKeys is the key help shown in the header of the dashboard.
+const dashboardKeys = "s scale  r restart  l logs  Tab apps/processes  Esc close  q quit"
+
+func (v *dashboardView) showApps(apps []appListOutput) {
+	rows := make([][]string, 0, len(apps))
+	for _, app := range apps {
+		rows = append(rows, []string{app.Name, app.Namespace, app.State, app.Addresses})
+	}
+	fillTable(v.apps, []string{"NAME", "NAMESPACE", "STATE", "ADDRESSES"}, rows)
+}
+
+func (v *dashboardView) showProcesses(processes []deploymentOutput) {
+	rows := make([][]string, 0, len(processes))
+	for _, p := range processes {
+		rows = append(rows, []string{p.DeploymentVersion, p.ProcessName, p.Weight, p.State, p.Cmd})
+	}
+	fillTable(v.processes, []string{"VERSION", "PROCESS", "WEIGHT", "STATE", "CMD"}, rows)
+}
+
+func (v *dashboardView) showEvents(events []eventOutput) {
+	var b strings.Builder
+	for _, e := range events {
+		fmt.Fprintf(&b, "%s  %s  %s  %s\n", e.LastSeen, e.Type, e.Reason, e.Message)
+	}
+	v.events.SetText(b.String())
+}
+
+// fillTable replaces rows of the table keeping the selected row.
+func fillTable(table *tview.Table, header []string, rows [][]string) {
+	row, _ := table.GetSelection()
+	table.Clear()
+	for i, title := range header {
+		table.SetCell(0, i, tview.NewTableCell(title).SetSelectable(false).SetAttributes(tcell.AttrBold))
+	}
+	for i, cells := range rows {
+		for j, text := range cells {
+			table.SetCell(i+1, j, tview.NewTableCell(tview.Escape(text)))
+		}
+	}
+	if row < 1 {
+		row = 1
+	}
+	if row > len(rows) {
+		row = len(rows)
+	}
+	table.Select(row, 0)
+}
+
+// dialog centers the primitive in a box of the given size.
+func dialog(p tview.Primitive, width, height int) tview.Primitive {
+	return tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(p, height, 1, true).
+			AddItem(nil, 0, 1, false), width, 1, true).
+		AddItem(nil, 0, 1, false)
+}
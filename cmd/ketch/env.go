@@ -10,7 +10,7 @@ const envCmdHelp = `
 Manage an app's environment variables.
 `
 
-func newEnvCmd(cfg config, out io.Writer) *cobra.Command {
+func newEnvCmd(cfg config, out io.Writer, redact redactor) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "env",
 		Short: "Manage an app's environment variables",
@@ -20,8 +20,8 @@ func newEnvCmd(cfg config, out io.Writer) *cobra.Command {
 			return cmd.Usage()
 		},
 	}
-	cmd.AddCommand(newEnvSetCmd(cfg, out))
-	cmd.AddCommand(newEnvGetCmd(cfg, out))
-	cmd.AddCommand(newEnvUnsetCmd(cfg, out))
+	cmd.AddCommand(requireAccess(newEnvSetCmd(cfg, out), appsAccess("update")))
+	cmd.AddCommand(newEnvGetCmd(cfg, out, redact))
+	cmd.AddCommand(requireAccess(newEnvUnsetCmd(cfg, out), appsAccess("update")))
 	return cmd
 }
@@ -18,8 +18,8 @@ Retrieve environment variables for an application.
 ketch env-get [-a/--app appname] [ENVIRONMENT_VARIABLE1] [ENVIRONMENT_VARIABLE2] ...
 `
 
-func newEnvGetCmd(cfg config, out io.Writer) *cobra.Command {
-	options := envGetOptions{}
+func newEnvGetCmd(cfg config, out io.Writer, redact redactor) *cobra.Command {
+	options := envGetOptions{redactor: redact}
 	cmd := &cobra.Command{
 		Use:   "get ENV_VAR1 ENV_VAR2 ...",
 		Short: "Retrieve environment variables for an application.",
@@ -32,12 +32,19 @@ func newEnvGetCmd(cfg config, out io.Writer) *cobra.Command {
 	}
 	cmd.Flags().StringVarP(&options.appName, "app", "a", "", "The name of the app.")
 	cmd.MarkFlagRequired("app")
+	bindFlagEnv(cmd, "app", appEnv)
+	cmd.RegisterFlagCompletionFunc("app", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return autoCompleteAppNames(cfg, toComplete)
+	})
+	cmd.Flags().BoolVar(&options.showSecrets, showSecretsFlag, false, showSecretsUsage)
 	return cmd
 }
 
 type envGetOptions struct {
-	appName string
-	envs    []string
+	appName     string
+	envs        []string
+	showSecrets bool
+	redactor    redactor
 }
 
 func envGet(ctx context.Context, cfg config, options envGetOptions, out io.Writer) error {
@@ -45,5 +52,8 @@ func envGet(ctx context.Context, cfg config, options envGetOptions, out io.Write
 	if err := cfg.Client().Get(ctx, types.NamespacedName{Name: options.appName}, &app); err != nil {
 		return fmt.Errorf("failed to get the app: %w", err)
 	}
+	if !options.showSecrets {
+		app.Spec.Env = options.redactor.envs(app.Spec.Env)
+	}
 	return output.Write(app.Envs(options.envs), out, "column")
 }
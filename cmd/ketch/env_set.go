@@ -16,13 +16,22 @@ import (
 
 const envSetHelp = `
 Set environment variables for an application.
+
+Variables can be passed as NAME=VALUE arguments or read from a dotenv file with --env-file,
+arguments take precedence over variables of the file:
+  ketch env set -a <app name> --env-file .env DEBUG=true
 `
 
 func newEnvSetCmd(cfg config, out io.Writer) *cobra.Command {
 	options := envSetOptions{}
 	cmd := &cobra.Command{
-		Use:   "set",
-		Args:  cobra.MinimumNArgs(1),
+		Use: "set [NAME=VALUE]...",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 && options.envFile == "" {
+				return ErrNoEnvVariables
+			}
+			return nil
+		},
 		Short: "Set environment variables for an application.",
 		Long:  envSetHelp,
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -33,6 +42,9 @@ func newEnvSetCmd(cfg config, out io.Writer) *cobra.Command {
 	}
 	cmd.Flags().StringVarP(&options.appName, deploy.FlagApp, deploy.FlagAppShort, "", "The name of the app.")
 	cmd.MarkFlagRequired(deploy.FlagApp)
+	bindFlagEnv(cmd, deploy.FlagApp, appEnv)
+	cmd.Flags().StringVar(&options.envFile, deploy.FlagEnvFile, "", "Path to a file with env variables in NAME=VALUE format, one per line.")
+	cmd.Flags().BoolVar(&options.sensitive, "sensitive", false, "Mark the variables as sensitive, ketch masks their values in its output.")
 	cmd.RegisterFlagCompletionFunc(deploy.FlagApp, func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		return autoCompleteAppNames(cfg, toComplete)
 	})
@@ -40,19 +52,34 @@ func newEnvSetCmd(cfg config, out io.Writer) *cobra.Command {
 }
 
 type envSetOptions struct {
-	appName string
-	envs    []string
+	appName   string
+	envs      []string
+	envFile   string
+	sensitive bool
 }
 
 func envSet(ctx context.Context, cfg config, options envSetOptions, out io.Writer) error {
-	envs, err := utils.MakeEnvironments(options.envs)
+	var envs []ketchv1.Env
+	if options.envFile != "" {
+		fileEnvs, err := utils.ReadEnvFile(options.envFile)
+		if err != nil {
+			return fmt.Errorf("failed to read env file: %w", err)
+		}
+		envs = append(envs, fileEnvs...)
+	}
+	argEnvs, err := utils.MakeEnvironments(options.envs)
 	if err != nil {
-		return fmt.Errorf("failed to get kubernetes client: %w", err)
+		return fmt.Errorf("invalid env variables: %w", err)
 	}
+	envs = append(envs, argEnvs...)
 	app := ketchv1.App{}
 	if err = cfg.Client().Get(ctx, types.NamespacedName{Name: options.appName}, &app); err != nil {
 		log.Fatalf("failed to get the app: %v", err)
 	}
+	for i := range envs {
+		// a variable stays sensitive when its value is changed.
+		envs[i].Sensitive = options.sensitive || app.IsSensitiveEnv(envs[i].Name)
+	}
 	app.SetEnvs(envs)
 	if err := cfg.Client().Update(ctx, &app); err != nil {
 		return fmt.Errorf("failed to update the app: %w", err)
@@ -2,6 +2,8 @@ package main
 
 import (
 	"errors"
+
+	"github.com/theketchio/ketch/pkg/ketchclient"
 )
 
 type cliError string
@@ -20,7 +22,26 @@ const (
 
 	ErrClusterIssuerNotFound cliError = "cluster issuer not found"
 
-	ErrClusterIssuerRequired cliError = "secure cnames require app.Ingress.Controller.ClusterIssuer to be set"
+	ErrCnameNotFound     cliError = "cname not found"
+	ErrInvalidExposeMode cliError = "invalid expose mode"
+	ErrLinkerdWithIstio  cliError = "linkerd can't be used with the istio ingress controller, istio is a service mesh itself"
+
+	ErrInvalidUnitsQuantity       cliError = "invalid quantity, units must be a positive number"
+	ErrProcessAutoscaled          cliError = "units of a process managed by a horizontal pod autoscaler can't be changed manually"
+	ErrInvalidAutoscaleBounds     cliError = "invalid autoscaling bounds, min must be at least 1 and max must be greater than or equal to min"
+	ErrInvalidAutoscaleCPUPercent cliError = "invalid cpu percent, it must be a positive number"
+	ErrDaemonSetNotAutoscalable   cliError = "a daemonset process runs one unit per node and can't be autoscaled"
+
+	ErrNoEnvVariables cliError = "no env variables, pass NAME=VALUE arguments or --env-file"
+
+	ErrInteractiveCommand cliError = "the command is interactive and can't run with --non-interactive"
+)
+
+// Errors of changes made with the ketchclient package.
+var (
+	ErrClusterIssuerRequired   = ketchclient.ErrClusterIssuerRequired
+	ErrTLSSecretNotFound       = ketchclient.ErrTLSSecretNotFound
+	ErrIngressEndpointNotFound = ketchclient.ErrIngressEndpointNotFound
 )
 
 func unwrappedError(err error) error {
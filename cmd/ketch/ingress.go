@@ -21,13 +21,19 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
 	"text/template"
 
 	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/chart"
+	"github.com/theketchio/ketch/pkg/ketchclient"
 
 	"github.com/spf13/cobra"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -36,6 +42,32 @@ type ingressSetOptions struct {
 	serviceEndpoint string
 	ingressType     string
 	clusterIssuer   string
+	forceHTTPS      *bool
+	namespace       string
+	networkPolicy   *bool
+	templates       string
+	appDefaults     string
+	certIssuer      string
+	externalDNS     string
+	openTelemetry   string
+	logging         string
+	grafana         string
+	notifications   string
+	roles           *[]string
+	maintenanceImg  string
+	maintenancePage string
+	preStopSleep    *int64
+	podSecurity     string
+	serviceMesh     string
+	engine          string
+	driftPolicy     string
+	registry        string
+	registrySecret  string
+	registryMirror  string
+	mirrors         *[]string
+	buildCache      string
+	allowedTeams    *[]string
+	defaultEnvs     *[]string
 }
 
 func newIngressCmd(cfg config, out io.Writer) *cobra.Command {
@@ -47,8 +79,10 @@ func newIngressCmd(cfg config, out io.Writer) *cobra.Command {
 			return cmd.Usage()
 		},
 	}
-	cmd.AddCommand(newIngressSetCmd(cfg, out))
+	cmd.AddCommand(requireAccess(newIngressSetCmd(cfg, out), ingressConfigmapAccess("create"), ingressConfigmapAccess("update")))
 	cmd.AddCommand(newIngressGetCmd(cfg, out))
+	cmd.AddCommand(newIngressExportCmd(cfg, out))
+	cmd.AddCommand(requireAccess(newIngressImportCmd(cfg, out), ingressConfigmapAccess("create"), ingressConfigmapAccess("update"), appsAccess("create"), appsAccess("update")))
 	return cmd
 }
 
@@ -59,18 +93,126 @@ data:
   ingressType: nginx #required
   serviceEndpoint: 127.0.0.1 #required
   clusterIssuer: letsencrypt
+  certificateIssuer: | # issuer of certificates of secure cnames, takes precedence over clusterIssuer
+    issuerRef:
+      name: letsencrypt
+      kind: Issuer # an Issuer in namespaces of apps, or a ClusterIssuer
+    solver: http01 # wildcard cnames are always validated with dns01
+    solverLabels: # labels of certificates matching selector.matchLabels of the issuer's solvers
+      dns01:
+        acme-solver: route53
+  externalDNS: | # external-dns annotations of ingress objects of apps, cnames can override them with "ketch cname add --dns-target/--dns-ttl"
+    target: lb.example.com # defaults to serviceEndpoint
+    ttl: 300
+    providerHints:
+      cloudflare-proxied: "true"
+  openTelemetry: | # OpenTelemetry instrumentation of apps, the language of an app's agent comes from "language" of its ketch.yaml
+    endpoint: http://otel-collector.monitoring:4317 # passed to apps in OTEL_EXPORTER_OTLP_ENDPOINT
+    instrumentation: monitoring/default # Instrumentation of the OpenTelemetry operator injecting agents
+    sidecar: "true" # OpenTelemetryCollector of the OpenTelemetry operator injected as a sidecar
+  logging: | # metadata of pods of apps read by the cluster's log agent, processes opt out with "excludeLogs" of their ketch.yaml
+    annotations:
+      fluentbit.io/parser: json
+    appLabel: app.kubernetes.io/name # label with the app's name
+    processLabel: app.kubernetes.io/component # label with the process's name
+    excludeAnnotations: # annotations of excluded processes, defaults to fluentbit.io/exclude: "true"
+      fluentbit.io/exclude: "true"
+  grafanaDashboards: | # a configmap with a grafana dashboard of every app, "{}" uses the defaults
+    labels: # labels the grafana sidecar provisions dashboards of, defaults to grafana_dashboard: "1"
+      grafana_dashboard: "1"
+    annotations:
+      grafana_folder: apps
+    datasource: Prometheus # default prometheus datasource of dashboards
+  notifications: | # receivers notified about events of all apps in addition to receivers of each app
+    - name: platform
+      type: slack
+      urlSecret: # a secret in the namespace of this configmap
+        name: platform-webhooks
+        key: slack
+      events:
+        - DeployFailed
+        - CanaryRolledBack
+  serviceAccountRoles: | # roles apps can bind to their service accounts with serviceAccount.roles of ketch.yaml, the controller must hold their permissions
+    ClusterRole/view
+    Role/config-reader
+  forceHTTPS: "true" # apps serve their cnames over https unless they opt out
+  namespace: ingress-nginx # namespace of the ingress controller's pods
+  networkPolicy: "true" # apps accept traffic only from the ingress controller and their own pods unless they opt out
+  templates: company-templates # configmap in ketch-system with chart templates replacing or extending the built-in ones
+  maintenanceImage: nginxinc/nginx-unprivileged:1.23-alpine # nginx image serving pages of apps in "ketch app maintenance"
+  maintenancePage: | # page of apps in maintenance that don't have a page of their own
+    <h1>Under maintenance</h1>
+  preStopSleepSeconds: "10" # routable processes sleep before they are stopped, so the ingress controller stops sending them requests first
+  podSecurityProfile: restricted # pods of apps comply with the baseline or restricted Pod Security Standard
+  serviceMesh: linkerd # pods of apps get the linkerd proxy, ServiceProfiles of routes of ketch.yaml and TrafficSplits of canary deployments
+  engine: server-side-apply # charts of new apps and jobs are applied with server-side apply instead of helm releases
+  driftPolicy: revert # edits of resources of apps are reverted instead of reported in the apps' status, missing resources are always recreated
+  registry: registry.example.com/apps # images of apps built from source without --image are pushed here
+  registrySecret: registry-credentials # docker-registry secret used by apps that don't set their own secret
+  registryMirror: cache.example.com/apps # pull-through cache images of the registry are pulled from
+  registryMirrors: | # pull-through caches images of upstream registries are pulled from, registryMirror and apps mirroring a registry themselves take precedence
+    docker.io=cache.example.com/dockerhub
+    quay.io=cache.example.com/quay
+  buildCache: registry.example.com/cache # build caches of apps built from source without --cache-image are pushed here
+  allowedTeams: payments,search # only apps of these teams can be deployed, apps of any team can if not set
+  defaultEnvs: | # env variables of all apps, variables set by an app or its processes take precedence
+    REGION=eu-west-1
+    OTEL_EXPORTER_OTLP_ENDPOINT=http://otel-collector.monitoring:4317
+  appDefaults: | # defaults applied to apps when they are created or updated, an app's own values win
+    resources:
+      requests:
+        cpu: 100m
+        memory: 128Mi
+    securityContext:
+      runAsNonRoot: true
+    labels:
+      team: platform
+
+A configmap with custom templates contains a yaml per template, for example a template named "deployment.yaml"
+replaces the built-in deployment template, a template with a new name is added to the charts of all apps,
+and a template with empty content removes the built-in template with the same name.
+
+Apps and jobs keep the engine their charts were installed with, changing the engine applies to new apps and jobs only.
+An app moves to the new engine when it's removed and deployed again.
+
+Changing ingressType re-renders ingress resources of all apps for the new ingress controller
+and removes resources of the previous one, "ketch ingress get" shows the progress.
 `
 
-var ingressSetValidationError = fmt.Errorf("ingress-class-name, ingress-service-endpoint, and ingress-type are required")
+var ingressSetValidationError = ketchclient.ErrIngressSettingsRequired
 
 func newIngressSetCmd(cfg config, out io.Writer) *cobra.Command {
 	var options ingressSetOptions
+	var forceHTTPS, networkPolicy bool
+	var preStopSleep int64
+	var allowedTeams, defaultEnvs, mirrors, roles []string
 
 	cmd := &cobra.Command{
-		Use:   "set [--ingress-class-name/-c <class_name>] [--ingress-service-endpoint/-s <service_endpoint>] [--ingress-type/-t <type>] [--cluster-issuer <cluster_issuer>]",
+		Use:   "set [--ingress-class-name/-c <class_name>] [--ingress-service-endpoint/-s <service_endpoint>] [--ingress-type/-t <type>] [--cluster-issuer <cluster_issuer>] [--force-https] [--namespace <namespace>] [--network-policy] [--templates <configmap>] [--app-defaults <file>] [--certificate-issuer <file>] [--external-dns <file>] [--pre-stop-sleep <seconds>] [--pod-security-profile <profile>] [--engine <engine>] [--drift-policy <policy>] [--registry <url>] [--registry-secret <secret>] [--registry-mirror <mirror>] [--mirror <REGISTRY=ENDPOINT>] [--service-account-role <KIND/NAME>] [--build-cache <url>] [--allowed-teams <team,...>] [--default-env <NAME=VALUE>]",
 		Short: "Set ingress controller values",
 		Long:  ingressSetHelp,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if cmd.Flags().Changed("force-https") {
+				options.forceHTTPS = &forceHTTPS
+			}
+			if cmd.Flags().Changed("network-policy") {
+				options.networkPolicy = &networkPolicy
+			}
+			if cmd.Flags().Changed("pre-stop-sleep") {
+				options.preStopSleep = &preStopSleep
+			}
+			if cmd.Flags().Changed("allowed-teams") {
+				options.allowedTeams = &allowedTeams
+			}
+			if cmd.Flags().Changed("default-env") {
+				options.defaultEnvs = &defaultEnvs
+			}
+			if cmd.Flags().Changed("mirror") {
+				options.mirrors = &mirrors
+			}
+			if cmd.Flags().Changed("service-account-role") {
+				options.roles = &roles
+			}
 			return ingressSet(cmd.Context(), cfg, options, out)
 		},
 	}
@@ -78,6 +220,32 @@ func newIngressSetCmd(cfg config, out io.Writer) *cobra.Command {
 	cmd.Flags().StringVarP(&options.serviceEndpoint, "ingress-service-endpoint", "s", "", "An IP address or DNS name of the ingress controller's Service")
 	cmd.Flags().StringVarP(&options.ingressType, "ingress-type", "t", "", "Ingress controller type: nginx, traefik, istio")
 	cmd.Flags().StringVar(&options.clusterIssuer, "cluster-issuer", "", "ClusterIssuer to obtain SSL certificates")
+	cmd.Flags().BoolVar(&forceHTTPS, "force-https", false, "Redirect http requests of apps to https by default, apps can override it with \"ketch app deploy --force-https=false\"")
+	cmd.Flags().StringVar(&options.namespace, "namespace", "", "Namespace of the ingress controller's pods, defaults to the namespace of the controller's default installation")
+	cmd.Flags().BoolVar(&networkPolicy, "network-policy", false, "Isolate pods of apps with NetworkPolicies by default, apps can override it with \"ketch app deploy --network-policy=false\"")
+	cmd.Flags().StringVar(&options.templates, "templates", "", "Name of a configmap in ketch-system with chart templates that replace or extend the built-in templates of apps")
+	cmd.Flags().StringVar(&options.appDefaults, "app-defaults", "", "Path to a yaml file with resources, securityContext and labels applied to apps that don't set them")
+	cmd.Flags().StringVar(&options.certIssuer, "certificate-issuer", "", "Path to a yaml file with the issuerRef and ACME solvers of certificates of secure cnames, it takes precedence over --cluster-issuer")
+	cmd.Flags().StringVar(&options.maintenanceImg, "maintenance-image", "", "An nginx image serving maintenance pages of apps in maintenance mode")
+	cmd.Flags().StringVar(&options.maintenancePage, "maintenance-page", "", "Path to an HTML file served as the maintenance page of apps that don't have a page of their own")
+	cmd.Flags().StringVar(&options.externalDNS, "external-dns", "", "Path to a yaml file with the target, ttl and providerHints of external-dns annotations of ingress objects of apps")
+	cmd.Flags().StringVar(&options.openTelemetry, "open-telemetry", "", "Path to a yaml file with the OTLP endpoint, the Instrumentation and the collector sidecar of the OpenTelemetry operator instrumenting apps")
+	cmd.Flags().StringVar(&options.logging, "logging", "", "Path to a yaml file with annotations and labels of pods of apps read by the cluster's log agent")
+	cmd.Flags().StringArrayVar(&roles, "service-account-role", nil, "Role apps can bind to their service accounts with serviceAccount.roles of ketch.yaml in Role/NAME or ClusterRole/NAME format. Can be repeated and replaces the current roles, an empty value removes them")
+	cmd.Flags().StringVar(&options.notifications, "notifications", "", "Path to a yaml file with a list of receivers notified about events of all apps, secrets of receivers are read from the namespace of the ingress configmap")
+	cmd.Flags().StringVar(&options.grafana, "grafana-dashboards", "", "Path to a yaml file with labels, annotations and the datasource of configmaps with grafana dashboards of apps")
+	cmd.Flags().StringVar(&options.podSecurity, "pod-security-profile", "", "Pod Security Standard of apps: baseline or restricted. Processes get compliant security context defaults and apps violating the profile are rejected")
+	cmd.Flags().StringVar(&options.serviceMesh, "service-mesh", "", "Service mesh of apps: linkerd. Pods get the linkerd proxy, processes get ServiceProfiles of their ketch.yaml routes and canary deployments get TrafficSplits")
+	cmd.Flags().StringVar(&options.engine, "engine", "", "Engine installing charts of new apps and jobs: helm or server-side-apply. Apps and jobs keep the engine they were installed with")
+	cmd.Flags().StringVar(&options.driftPolicy, "drift-policy", "", "What ketch-controller does when resources of apps are edited: report to list them in the apps' status or revert to upgrade the apps' charts. Missing resources are recreated either way")
+	cmd.Flags().StringVar(&options.registry, "registry", "", "Registry and path prefix images of apps built from source are pushed to when \"ketch app deploy\" gets no --image")
+	cmd.Flags().StringVar(&options.registrySecret, "registry-secret", "", "Name of a docker-registry Secret used to pull images of apps that don't set their own --registry-secret")
+	cmd.Flags().StringVar(&options.registryMirror, "registry-mirror", "", "Pull-through cache images of the registry are pulled from instead, e.g. cache.example.com/apps. It takes precedence over a --mirror of the registry's host")
+	cmd.Flags().StringArrayVar(&mirrors, "mirror", nil, "Pull-through cache of an upstream registry in REGISTRY=ENDPOINT format, e.g. docker.io=cache.example.com/dockerhub. Can be repeated and replaces the current mirrors, an empty value removes them")
+	cmd.Flags().StringVar(&options.buildCache, "build-cache", "", "Registry path build caches of apps are pushed to when \"ketch app deploy\" builds from source without --cache-image")
+	cmd.Flags().StringSliceVar(&allowedTeams, "allowed-teams", nil, "Teams that can deploy apps, apps of other teams are rejected. An empty value allows all teams")
+	cmd.Flags().StringArrayVar(&defaultEnvs, "default-env", nil, "Env variable of all apps in NAME=VALUE format, can be repeated and replaces the current default variables. An empty value removes them")
+	cmd.Flags().Int64Var(&preStopSleep, "pre-stop-sleep", 0, "Seconds routable processes sleep before they are stopped, the sleep counts against their termination grace period, 0 turns it off")
 
 	return cmd
 }
@@ -103,32 +271,233 @@ func ingressSet(ctx context.Context, cfg config, options ingressSetOptions, out
 	if options.clusterIssuer != "" {
 		configmap.Data["clusterIssuer"] = options.clusterIssuer
 	}
-	if val, ok := configmap.Data["className"]; !ok || val == "" {
-		return ingressSetValidationError
+	if options.forceHTTPS != nil {
+		configmap.Data["forceHTTPS"] = strconv.FormatBool(*options.forceHTTPS)
 	}
-	if val, ok := configmap.Data["serviceEndpoint"]; !ok || val == "" {
-		return ingressSetValidationError
+	if options.namespace != "" {
+		configmap.Data["namespace"] = options.namespace
 	}
-	if val, ok := configmap.Data["ingressType"]; !ok || val == "" {
-		return ingressSetValidationError
+	if options.networkPolicy != nil {
+		configmap.Data["networkPolicy"] = strconv.FormatBool(*options.networkPolicy)
 	}
-	if err != nil {
-		// create
-		configmap.Name = ketchv1.IngressConfigmapName
-		configmap.Namespace = ketchv1.IngressConfigmapNamespace
-		if err := cfg.Client().Create(ctx, &configmap); err != nil {
-			return fmt.Errorf("failed to create ingress: %w", err)
+	if options.templates != "" {
+		configmap.Data["templates"] = options.templates
+	}
+	if options.podSecurity != "" {
+		if _, err := ketchv1.ParsePodSecurityProfile(options.podSecurity); err != nil {
+			return err
+		}
+		configmap.Data["podSecurityProfile"] = options.podSecurity
+	}
+	if options.serviceMesh != "" {
+		if _, err := ketchv1.ParseServiceMesh(options.serviceMesh); err != nil {
+			return err
+		}
+		configmap.Data[ketchv1.ServiceMeshKey] = options.serviceMesh
+	}
+	if options.engine != "" {
+		if err := chart.ValidateEngine(options.engine); err != nil {
+			return err
+		}
+		configmap.Data[ketchv1.EngineKey] = options.engine
+	}
+	if options.driftPolicy != "" {
+		if err := chart.ValidateDriftPolicy(options.driftPolicy); err != nil {
+			return err
+		}
+		configmap.Data[ketchv1.DriftPolicyKey] = options.driftPolicy
+	}
+	if spec := ketchv1.NewIngressControllerSpec(configmap); spec.ServiceMesh == ketchv1.LinkerdServiceMesh && spec.IngressType == ketchv1.IstioIngressControllerType {
+		return ErrLinkerdWithIstio
+	}
+	if options.registry != "" {
+		configmap.Data["registry"] = options.registry
+	}
+	if options.registrySecret != "" {
+		configmap.Data["registrySecret"] = options.registrySecret
+	}
+	if options.registryMirror != "" {
+		configmap.Data["registryMirror"] = options.registryMirror
+	}
+	if options.mirrors != nil {
+		var lines []string
+		for _, mirror := range *options.mirrors {
+			if strings.TrimSpace(mirror) != "" {
+				lines = append(lines, mirror)
+			}
+		}
+		if _, err := ketchv1.ParseRegistryMirrors(strings.Join(lines, "\n")); err != nil {
+			return err
+		}
+		if len(lines) > 0 {
+			configmap.Data[ketchv1.RegistryMirrorsKey] = strings.Join(lines, "\n")
+		} else {
+			delete(configmap.Data, ketchv1.RegistryMirrorsKey)
+		}
+	}
+	if options.buildCache != "" {
+		configmap.Data["buildCache"] = options.buildCache
+	}
+	if options.roles != nil {
+		var lines []string
+		for _, role := range *options.roles {
+			if strings.TrimSpace(role) != "" {
+				lines = append(lines, role)
+			}
+		}
+		if _, err := ketchv1.ParseServiceAccountRoles(strings.Join(lines, "\n")); err != nil {
+			return err
+		}
+		if len(lines) > 0 {
+			configmap.Data[ketchv1.ServiceAccountRolesKey] = strings.Join(lines, "\n")
+		} else {
+			delete(configmap.Data, ketchv1.ServiceAccountRolesKey)
+		}
+	}
+	if options.allowedTeams != nil {
+		var teams []string
+		for _, team := range *options.allowedTeams {
+			if team = strings.TrimSpace(team); team == "" {
+				continue
+			}
+			if msgs := validation.IsValidLabelValue(team); len(msgs) > 0 {
+				return fmt.Errorf("invalid team %q: %s", team, strings.Join(msgs, ", "))
+			}
+			teams = append(teams, team)
+		}
+		if len(teams) > 0 {
+			configmap.Data["allowedTeams"] = strings.Join(teams, ",")
+		} else {
+			delete(configmap.Data, "allowedTeams")
+		}
+	}
+	if options.defaultEnvs != nil {
+		lines, err := defaultEnvLines(*options.defaultEnvs)
+		if err != nil {
+			return err
+		}
+		if len(lines) > 0 {
+			configmap.Data[ketchv1.DefaultEnvsKey] = strings.Join(lines, "\n")
+		} else {
+			delete(configmap.Data, ketchv1.DefaultEnvsKey)
+		}
+	}
+	if options.preStopSleep != nil {
+		if *options.preStopSleep < 0 {
+			return fmt.Errorf("pre-stop-sleep must be greater than or equal to 0")
+		}
+		configmap.Data["preStopSleepSeconds"] = strconv.FormatInt(*options.preStopSleep, 10)
+	}
+	if options.appDefaults != "" {
+		content, err := ioutil.ReadFile(options.appDefaults)
+		if err != nil {
+			return fmt.Errorf("failed to read app defaults: %w", err)
+		}
+		if _, err := ketchv1.ParseAppDefaults(string(content)); err != nil {
+			return err
+		}
+		configmap.Data[ketchv1.AppDefaultsKey] = strings.TrimRight(string(content), "\n")
+	}
+	if options.certIssuer != "" {
+		content, err := ioutil.ReadFile(options.certIssuer)
+		if err != nil {
+			return fmt.Errorf("failed to read certificate issuer: %w", err)
+		}
+		if _, err := ketchv1.ParseCertificateIssuer(string(content)); err != nil {
+			return err
+		}
+		configmap.Data[ketchv1.CertificateIssuerKey] = strings.TrimRight(string(content), "\n")
+	}
+	if options.externalDNS != "" {
+		content, err := ioutil.ReadFile(options.externalDNS)
+		if err != nil {
+			return fmt.Errorf("failed to read external-dns settings: %w", err)
+		}
+		if _, err := ketchv1.ParseExternalDNS(string(content)); err != nil {
+			return err
+		}
+		configmap.Data[ketchv1.ExternalDNSKey] = strings.TrimRight(string(content), "\n")
+	}
+	if options.openTelemetry != "" {
+		content, err := ioutil.ReadFile(options.openTelemetry)
+		if err != nil {
+			return fmt.Errorf("failed to read open telemetry settings: %w", err)
+		}
+		if _, err := ketchv1.ParseOpenTelemetry(string(content)); err != nil {
+			return err
+		}
+		configmap.Data[ketchv1.OpenTelemetryKey] = strings.TrimRight(string(content), "\n")
+	}
+	if options.logging != "" {
+		content, err := ioutil.ReadFile(options.logging)
+		if err != nil {
+			return fmt.Errorf("failed to read logging settings: %w", err)
+		}
+		if _, err := ketchv1.ParseLogging(string(content)); err != nil {
+			return err
+		}
+		configmap.Data[ketchv1.LoggingKey] = strings.TrimRight(string(content), "\n")
+	}
+	if options.grafana != "" {
+		content, err := ioutil.ReadFile(options.grafana)
+		if err != nil {
+			return fmt.Errorf("failed to read grafana dashboards settings: %w", err)
 		}
-	} else {
-		// update
-		if err := cfg.Client().Update(ctx, &configmap); err != nil {
-			return fmt.Errorf("failed to set ingress: %w", err)
+		if _, err := ketchv1.ParseGrafanaDashboards(string(content)); err != nil {
+			return err
 		}
+		configmap.Data[ketchv1.GrafanaDashboardsKey] = strings.TrimRight(string(content), "\n")
+	}
+	if options.notifications != "" {
+		content, err := ioutil.ReadFile(options.notifications)
+		if err != nil {
+			return fmt.Errorf("failed to read notifications: %w", err)
+		}
+		if _, err := ketchv1.ParseNotifications(string(content)); err != nil {
+			return err
+		}
+		configmap.Data[ketchv1.NotificationsKey] = strings.TrimRight(string(content), "\n")
+	}
+	if options.maintenanceImg != "" {
+		configmap.Data["maintenanceImage"] = options.maintenanceImg
+	}
+	if options.maintenancePage != "" {
+		content, err := ioutil.ReadFile(options.maintenancePage)
+		if err != nil {
+			return fmt.Errorf("failed to read maintenance page: %w", err)
+		}
+		configmap.Data["maintenancePage"] = strings.TrimRight(string(content), "\n")
+	}
+	if err := newKetchClient(cfg).SetIngress(ctx, configmap.Data); err != nil {
+		return err
 	}
 	fmt.Fprintln(out, "Successfully set!")
 	return nil
 }
 
+// defaultEnvLines validates env variables passed with --default-env and returns them as lines of the ingress configmap's defaultEnvs.
+func defaultEnvLines(envs []string) ([]string, error) {
+	var lines []string
+	for _, env := range envs {
+		if strings.TrimSpace(env) == "" {
+			continue
+		}
+		parts := strings.SplitN(env, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid default env %q, env variables should have NAME=VALUE format", env)
+		}
+		name, value := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		if msgs := validation.IsEnvVarName(name); len(msgs) > 0 {
+			return nil, fmt.Errorf("invalid env variable name %q: %s", name, strings.Join(msgs, ", "))
+		}
+		if strings.Contains(value, "\n") {
+			return nil, fmt.Errorf("value of default env %s can't contain new lines", name)
+		}
+		lines = append(lines, name+"="+value)
+	}
+	return lines, nil
+}
+
 var (
 	ingressGetTemplate = `Class Name: {{ .className }}
 Service Endpoint: {{ .serviceEndpoint }}
@@ -136,6 +505,95 @@ Ingress Type: {{ .ingressType }}
 {{- if .clusterIssuer }}
 Cluster Issuer: {{ .clusterIssuer }}
 {{- end }}
+{{- if .certificateIssuer }}
+Certificate Issuer:
+{{ .certificateIssuer }}
+{{- end }}
+{{- if .externalDNS }}
+External DNS:
+{{ .externalDNS }}
+{{- end }}
+{{- if .openTelemetry }}
+Open Telemetry:
+{{ .openTelemetry }}
+{{- end }}
+{{- if .logging }}
+Logging:
+{{ .logging }}
+{{- end }}
+{{- if .grafanaDashboards }}
+Grafana Dashboards:
+{{ .grafanaDashboards }}
+{{- end }}
+{{- if .notifications }}
+Notifications:
+{{ .notifications }}
+{{- end }}
+{{- if .serviceAccountRoles }}
+Service Account Roles:
+{{ .serviceAccountRoles }}
+{{- end }}
+{{- if .forceHTTPS }}
+Force HTTPS: {{ .forceHTTPS }}
+{{- end }}
+{{- if .namespace }}
+Namespace: {{ .namespace }}
+{{- end }}
+{{- if .networkPolicy }}
+Network Policy: {{ .networkPolicy }}
+{{- end }}
+{{- if .templates }}
+Templates: {{ .templates }}
+{{- end }}
+{{- if .podSecurityProfile }}
+Pod Security Profile: {{ .podSecurityProfile }}
+{{- end }}
+{{- if .serviceMesh }}
+Service Mesh: {{ .serviceMesh }}
+{{- end }}
+{{- if .engine }}
+Engine: {{ .engine }}
+{{- end }}
+{{- if .driftPolicy }}
+Drift Policy: {{ .driftPolicy }}
+{{- end }}
+{{- if .registry }}
+Registry: {{ .registry }}
+{{- end }}
+{{- if .registrySecret }}
+Registry Secret: {{ .registrySecret }}
+{{- end }}
+{{- if .registryMirror }}
+Registry Mirror: {{ .registryMirror }}
+{{- end }}
+{{- if .registryMirrors }}
+Registry Mirrors:
+{{ .registryMirrors }}
+{{- end }}
+{{- if .buildCache }}
+Build Cache: {{ .buildCache }}
+{{- end }}
+{{- if .allowedTeams }}
+Allowed Teams: {{ .allowedTeams }}
+{{- end }}
+{{- if .defaultEnvs }}
+Default Envs:
+{{ .defaultEnvs }}
+{{- end }}
+{{- if .maintenanceImage }}
+Maintenance Image: {{ .maintenanceImage }}
+{{- end }}
+{{- if .maintenancePage }}
+Maintenance Page:
+{{ .maintenancePage }}
+{{- end }}
+{{- if .preStopSleepSeconds }}
+Pre-stop Sleep: {{ .preStopSleepSeconds }}s
+{{- end }}
+{{- if .appDefaults }}
+App Defaults:
+{{ .appDefaults }}
+{{- end }}
 `
 )
 
@@ -162,6 +620,58 @@ func ingressGet(ctx context.Context, cfg config, out io.Writer) error {
 	if err := t.Execute(&buf, configmap.Data); err != nil {
 		return err
 	}
-	_, err := fmt.Fprintf(out, "%v", buf.String())
+	if _, err := fmt.Fprintf(out, "%v", buf.String()); err != nil {
+		return err
+	}
+	if err := printIngressMigration(ctx, cfg, ketchv1.NewIngressControllerSpec(configmap).IngressType, out); err != nil {
+		return err
+	}
+	return printEngineApps(ctx, cfg, configmap.Data[ketchv1.EngineKey], out)
+}
+
+// printEngineApps shows how many apps keep an engine other than the engine of the ingress configmap,
+// it prints nothing if all apps use the engine.
+func printEngineApps(ctx context.Context, cfg config, engine string, out io.Writer) error {
+	if engine == "" {
+		engine = chart.HelmEngine
+	}
+	apps, err := listApps(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	var others int
+	for _, app := range apps.Items {
+		if app.Status.Engine != "" && app.Status.Engine != engine {
+			others++
+		}
+	}
+	if others == 0 {
+		return nil
+	}
+	_, err = fmt.Fprintf(out, "Other Engines: %d/%d apps keep the engine they were installed with instead of %s\n", others, len(apps.Items), engine)
+	return err
+}
+
+// printIngressMigration shows how many apps have been migrated to a new ingress controller type,
+// it prints nothing if there is no migration in progress.
+func printIngressMigration(ctx context.Context, cfg config, ingressType ketchv1.IngressControllerType, out io.Writer) error {
+	apps, err := listApps(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	var migrated, pending int
+	for _, app := range apps.Items {
+		switch app.Status.IngressType {
+		case ingressType:
+			migrated++
+		case "":
+		default:
+			pending++
+		}
+	}
+	if pending == 0 {
+		return nil
+	}
+	_, err = fmt.Fprintf(out, "Migration: %d/%d apps migrated to %s\n", migrated, migrated+pending, ingressType)
 	return err
 }
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"github.com/theketchio/ketch/cmd/ketch/output"
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/templates"
+)
+
+const ingressExportHelp = `
+Export the ingress configuration shared by all apps as a yaml bundle, with --include-apps the bundle contains
+specs of all apps too. Custom chart templates set with "ketch ingress set --templates" are exported with the ingress.
+"ketch ingress import" recreates the bundle in another cluster, e.g. to migrate apps or recover from a disaster:
+  ketch ingress export --include-apps -f backup.yaml
+  ketch ingress import backup.yaml
+
+An existing file isn't overwritten unless --force is passed, --append adds the bundle to the file as a new yaml document.
+The file is replaced atomically, "-f -" writes the bundle to stdout.
+
+Statuses of apps are included for audits with --include-status, they are ignored by "ketch ingress import".
+Secrets referenced by the ingress and apps, like registry secrets and certificates, aren't exported.
+`
+
+const ingressImportHelp = `
+Import a bundle exported by "ketch ingress export". The ingress configuration and custom chart templates
+are created or replaced, apps of the bundle are created or their specs are replaced.
+A file with several bundles appended by "ketch ingress export --append" is imported from the last bundle.
+`
+
+// ingressBundle is the ingress configuration shared by all apps, optionally with the apps.
+type ingressBundle struct {
+	Ingress   map[string]string `json:"ingress"`
+	Templates map[string]string `json:"templates,omitempty"`
+	Apps      []bundledApp      `json:"apps,omitempty"`
+}
+
+// bundledApp is an app of a bundle, its status is only exported for audits.
+type bundledApp struct {
+	Metadata metav1.ObjectMeta  `json:"metadata"`
+	Spec     ketchv1.AppSpec    `json:"spec"`
+	Status   *ketchv1.AppStatus `json:"status,omitempty"`
+}
+
+type ingressExportOptions struct {
+	filename      string
+	includeApps   bool
+	includeStatus bool
+	file          output.FileOptions
+}
+
+func newIngressExportCmd(cfg config, out io.Writer) *cobra.Command {
+	var options ingressExportOptions
+	cmd := &cobra.Command{
+		Use:   "export [--include-apps] [--include-status] [-f <file>] [--force|--append]",
+		Short: "Export the ingress configuration, optionally with all apps",
+		Long:  ingressExportHelp,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return ingressExport(cmd.Context(), cfg, options, out)
+		},
+	}
+	cmd.Flags().StringVarP(&options.filename, "file", "f", "", "filename for the exported bundle")
+	cmd.Flags().BoolVar(&options.includeApps, "include-apps", false, "Include specs of all apps in the bundle")
+	cmd.Flags().BoolVar(&options.includeStatus, "include-status", false, "Include statuses of the apps for audits, requires --include-apps")
+	cmd.Flags().BoolVar(&options.file.Force, "force", false, "Overwrite the file if it exists")
+	cmd.Flags().BoolVar(&options.file.Append, "append", false, "Append the bundle to the file as a new yaml document if it exists")
+	return cmd
+}
+
+func newIngressImportCmd(cfg config, out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import FILENAME",
+		Short: "Import a bundle exported by \"ketch ingress export\"",
+		Long:  ingressImportHelp,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return ingressImport(cmd.Context(), cfg, args[0], out)
+		},
+	}
+	return cmd
+}
+
+func ingressExport(ctx context.Context, cfg config, options ingressExportOptions, out io.Writer) error {
+	if options.includeStatus && !options.includeApps {
+		return fmt.Errorf("--include-status requires --include-apps")
+	}
+	configmap := v1.ConfigMap{}
+	if err := cfg.Client().Get(ctx, types.NamespacedName{Name: ketchv1.IngressConfigmapName, Namespace: ketchv1.IngressConfigmapNamespace}, &configmap); err != nil {
+		return fmt.Errorf("failed to get ingress: %w", err)
+	}
+	bundle := ingressBundle{Ingress: configmap.Data}
+	if name := configmap.Data["templates"]; name != "" {
+		tpls, err := cfg.Storage().Get(name)
+		if err != nil {
+			return fmt.Errorf("failed to get templates %q: %w", name, err)
+		}
+		bundle.Templates = tpls.Yamls
+	}
+	if options.includeApps {
+		apps, err := listApps(ctx, cfg)
+		if err != nil {
+			return err
+		}
+		for i, app := range apps.Items {
+			bundled := bundledApp{
+				Metadata: metav1.ObjectMeta{Name: app.Name, Labels: app.Labels, Annotations: app.Annotations},
+				Spec:     app.Spec,
+			}
+			if options.includeStatus {
+				bundled.Status = &apps.Items[i].Status
+			}
+			bundle.Apps = append(bundle.Apps, bundled)
+		}
+	}
+	return output.WriteToFile(bundle, out, options.filename, options.file)
+}
+
+func ingressImport(ctx context.Context, cfg config, filename string, out io.Writer) error {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	bundle, err := lastIngressBundle(content)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+	for _, key := range []string{"className", "serviceEndpoint", "ingressType"} {
+		if bundle.Ingress[key] == "" {
+			return ingressSetValidationError
+		}
+	}
+	if name := bundle.Ingress["templates"]; name != "" && len(bundle.Templates) > 0 {
+		if err := cfg.Storage().Update(name, templates.Templates{Yamls: bundle.Templates}); err != nil {
+			return fmt.Errorf("failed to import templates %q: %w", name, err)
+		}
+	}
+
+	if err := newKetchClient(cfg).SetIngress(ctx, bundle.Ingress); err != nil {
+		return err
+	}
+
+	for _, bundled := range bundle.Apps {
+		if err := importBundledApp(ctx, cfg, bundled); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(out, "Successfully imported ingress and %d apps!\n", len(bundle.Apps))
+	return nil
+}
+
+// lastIngressBundle returns the last bundle of a file, "ketch ingress export --append" adds bundles to the end of a file.
+func lastIngressBundle(content []byte) (ingressBundle, error) {
+	var bundle ingressBundle
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(content)))
+	for {
+		document, err := reader.Read()
+		if err == io.EOF {
+			return bundle, nil
+		}
+		if err != nil {
+			return ingressBundle{}, err
+		}
+		if len(bytes.TrimSpace(document)) == 0 {
+			continue
+		}
+		bundle = ingressBundle{}
+		if err := yaml.Unmarshal(document, &bundle); err != nil {
+			return ingressBundle{}, err
+		}
+	}
+}
+
+func importBundledApp(ctx context.Context, cfg config, bundled bundledApp) error {
+	app := ketchv1.App{}
+	err := cfg.Client().Get(ctx, types.NamespacedName{Name: bundled.Metadata.Name}, &app)
+	if client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("failed to get app %q: %w", bundled.Metadata.Name, err)
+	}
+	app.Labels = bundled.Metadata.Labels
+	app.Annotations = bundled.Metadata.Annotations
+	app.Spec = bundled.Spec
+	if err != nil {
+		app.Name = bundled.Metadata.Name
+		if err := cfg.Client().Create(ctx, &app); err != nil {
+			return fmt.Errorf("failed to create app %q: %w", app.Name, err)
+		}
+		return nil
+	}
+	if err := cfg.Client().Update(ctx, &app); err != nil {
+		return fmt.Errorf("failed to update app %q: %w", app.Name, err)
+	}
+	return nil
+}
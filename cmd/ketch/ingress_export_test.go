@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/yaml"
+
+	"github.com/theketchio/ketch/cmd/ketch/output"
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/mocks"
+	"github.com/theketchio/ketch/internal/templates"
+)
+
+func TestIngressExportImport(t *testing.T) {
+	ingressConfigmap := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: ketchv1.IngressConfigmapName, Namespace: ketchv1.IngressConfigmapNamespace},
+		Data: map[string]string{
+			"className":       "nginx",
+			"serviceEndpoint": "10.10.10.10",
+			"ingressType":     "nginx",
+			"templates":       "company-templates",
+		},
+	}
+	templatesConfigmap := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "company-templates", Namespace: "ketch-system"},
+		Data:       map[string]string{"pdb.yaml": "kind: PodDisruptionBudget"},
+	}
+	dashboard := &ketchv1.App{
+		ObjectMeta: metav1.ObjectMeta{Name: "dashboard", Labels: map[string]string{"team": "platform"}},
+		Spec: ketchv1.AppSpec{
+			Namespace: "apps",
+			Deployments: []ketchv1.AppDeploymentSpec{
+				{Image: "shipa/dashboard:0.1", Version: 1, Processes: []ketchv1.ProcessSpec{{Name: "web", Cmd: []string{"./dashboard"}}}},
+			},
+			DeploymentsCount: 1,
+		},
+		Status: ketchv1.AppStatus{IngressType: ketchv1.NginxIngressControllerType},
+	}
+
+	source := &mocks.Configuration{CtrlClientObjects: []runtime.Object{ingressConfigmap, templatesConfigmap, dashboard}}
+	source.StorageInstance = templates.NewStorage(source.Client(), "ketch-system")
+
+	out := &bytes.Buffer{}
+	require.Nil(t, ingressExport(context.Background(), source, ingressExportOptions{}, out))
+	var bundle ingressBundle
+	require.Nil(t, yaml.Unmarshal(out.Bytes(), &bundle))
+	require.Equal(t, ingressConfigmap.Data, bundle.Ingress)
+	require.Equal(t, templatesConfigmap.Data, bundle.Templates)
+	require.Empty(t, bundle.Apps)
+
+	filename := filepath.Join(t.TempDir(), "backup.yaml")
+	require.Nil(t, ingressExport(context.Background(), source, ingressExportOptions{filename: filename, includeApps: true}, out))
+
+	existing := &ketchv1.App{
+		ObjectMeta: metav1.ObjectMeta{Name: "dashboard"},
+		Spec:       ketchv1.AppSpec{Namespace: "old"},
+	}
+	target := &mocks.Configuration{CtrlClientObjects: []runtime.Object{existing}}
+	target.StorageInstance = templates.NewStorage(target.Client(), "ketch-system")
+	out.Reset()
+	require.Nil(t, ingressImport(context.Background(), target, filename, out))
+	require.Equal(t, "Successfully imported ingress and 1 apps!\n", out.String())
+
+	configmap := v1.ConfigMap{}
+	require.Nil(t, target.Client().Get(context.Background(), types.NamespacedName{Name: ketchv1.IngressConfigmapName, Namespace: ketchv1.IngressConfigmapNamespace}, &configmap))
+	require.Equal(t, ingressConfigmap.Data, configmap.Data)
+	tpls, err := target.Storage().Get("company-templates")
+	require.Nil(t, err)
+	require.Equal(t, templatesConfigmap.Data, tpls.Yamls)
+	app := ketchv1.App{}
+	require.Nil(t, target.Client().Get(context.Background(), types.NamespacedName{Name: "dashboard"}, &app))
+	require.Equal(t, dashboard.Spec, app.Spec)
+	require.Equal(t, dashboard.Labels, app.Labels)
+}
+
+func TestIngressExportAppend(t *testing.T) {
+	configmap := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: ketchv1.IngressConfigmapName, Namespace: ketchv1.IngressConfigmapNamespace},
+		Data:       map[string]string{"className": "nginx", "serviceEndpoint": "10.10.10.10", "ingressType": "nginx"},
+	}
+	dashboard := &ketchv1.App{
+		ObjectMeta: metav1.ObjectMeta{Name: "dashboard"},
+		Spec:       ketchv1.AppSpec{Namespace: "apps"},
+		Status:     ketchv1.AppStatus{IngressType: ketchv1.NginxIngressControllerType},
+	}
+	cfg := &mocks.Configuration{CtrlClientObjects: []runtime.Object{configmap, dashboard}}
+	filename := filepath.Join(t.TempDir(), "audit.yaml")
+
+	err := ingressExport(context.Background(), cfg, ingressExportOptions{filename: filename, includeStatus: true}, &bytes.Buffer{})
+	require.Equal(t, "--include-status requires --include-apps", err.Error())
+
+	require.Nil(t, ingressExport(context.Background(), cfg, ingressExportOptions{filename: filename}, &bytes.Buffer{}))
+	err = ingressExport(context.Background(), cfg, ingressExportOptions{filename: filename, includeApps: true}, &bytes.Buffer{})
+	require.Equal(t, output.ErrFileExists, err)
+	options := ingressExportOptions{filename: filename, includeApps: true, includeStatus: true, file: output.FileOptions{Append: true}}
+	require.Nil(t, ingressExport(context.Background(), cfg, options, &bytes.Buffer{}))
+
+	content, err := os.ReadFile(filename)
+	require.Nil(t, err)
+	bundle, err := lastIngressBundle(content)
+	require.Nil(t, err)
+	require.Len(t, bundle.Apps, 1)
+	require.Equal(t, ketchv1.NginxIngressControllerType, bundle.Apps[0].Status.IngressType)
+
+	target := &mocks.Configuration{}
+	out := &bytes.Buffer{}
+	require.Nil(t, ingressImport(context.Background(), target, filename, out))
+	require.Equal(t, "Successfully imported ingress and 1 apps!\n", out.String())
+}
+
+func TestIngressImportErrors(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "backup.yaml")
+	require.Nil(t, os.WriteFile(filename, []byte("ingress:\n  className: nginx\n"), 0600))
+	err := ingressImport(context.Background(), &mocks.Configuration{}, filename, &bytes.Buffer{})
+	require.Equal(t, ingressSetValidationError, err)
+}
@@ -19,6 +19,8 @@ package main
 import (
 	"bytes"
 	"context"
+	"os"
+	"path/filepath"
 	"testing"
 
 	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
@@ -31,6 +33,41 @@ import (
 )
 
 func TestIngressSet(t *testing.T) {
+	forceHTTPS := true
+	networkPolicy := true
+	preStopSleep := int64(10)
+	negativePreStopSleep := int64(-1)
+	appDefaults := filepath.Join(t.TempDir(), "app-defaults.yaml")
+	require.Nil(t, os.WriteFile(appDefaults, []byte("resources:\n  requests:\n    cpu: 100m\nlabels:\n  team: platform\n"), 0644))
+	invalidAppDefaults := filepath.Join(t.TempDir(), "invalid-app-defaults.yaml")
+	require.Nil(t, os.WriteFile(invalidAppDefaults, []byte("replicas: 2\n"), 0644))
+	certIssuer := filepath.Join(t.TempDir(), "certificate-issuer.yaml")
+	require.Nil(t, os.WriteFile(certIssuer, []byte("issuerRef:\n  name: letsencrypt\n  kind: Issuer\nsolverLabels:\n  dns01:\n    acme-solver: route53\n"), 0644))
+	externalDNS := filepath.Join(t.TempDir(), "external-dns.yaml")
+	require.Nil(t, os.WriteFile(externalDNS, []byte("ttl: 300\nproviderHints:\n  cloudflare-proxied: \"true\"\n"), 0644))
+	maintenancePage := filepath.Join(t.TempDir(), "maintenance.html")
+	require.Nil(t, os.WriteFile(maintenancePage, []byte("<h1>Back soon</h1>\n"), 0644))
+	missingMaintenancePage := filepath.Join(t.TempDir(), "missing.html")
+	openTelemetry := filepath.Join(t.TempDir(), "open-telemetry.yaml")
+	require.Nil(t, os.WriteFile(openTelemetry, []byte("endpoint: http://otel-collector.monitoring:4317\ninstrumentation: monitoring/default\n"), 0644))
+	invalidOpenTelemetry := filepath.Join(t.TempDir(), "invalid-open-telemetry.yaml")
+	require.Nil(t, os.WriteFile(invalidOpenTelemetry, []byte("endpoint: otel-collector:4317\n"), 0644))
+	logging := filepath.Join(t.TempDir(), "logging.yaml")
+	require.Nil(t, os.WriteFile(logging, []byte("annotations:\n  fluentbit.io/parser: json\nappLabel: app.kubernetes.io/name\n"), 0644))
+	invalidLogging := filepath.Join(t.TempDir(), "invalid-logging.yaml")
+	require.Nil(t, os.WriteFile(invalidLogging, []byte("sidecar: fluent-bit\n"), 0644))
+	notifications := filepath.Join(t.TempDir(), "notifications.yaml")
+	require.Nil(t, os.WriteFile(notifications, []byte("- name: platform\n  type: slack\n  url: https://hooks.slack.com/services/T0/B0/X\n  events: [DeployFailed]\n"), 0644))
+	invalidNotifications := filepath.Join(t.TempDir(), "invalid-notifications.yaml")
+	require.Nil(t, os.WriteFile(invalidNotifications, []byte("- name: platform\n  type: email\n  url: ops@example.com\n"), 0644))
+	grafanaDashboards := filepath.Join(t.TempDir(), "grafana-dashboards.yaml")
+	require.Nil(t, os.WriteFile(grafanaDashboards, []byte("annotations:\n  grafana_folder: apps\n"), 0644))
+	invalidGrafanaDashboards := filepath.Join(t.TempDir(), "invalid-grafana-dashboards.yaml")
+	require.Nil(t, os.WriteFile(invalidGrafanaDashboards, []byte("folder: apps\n"), 0644))
+	invalidExternalDNS := filepath.Join(t.TempDir(), "invalid-external-dns.yaml")
+	require.Nil(t, os.WriteFile(invalidExternalDNS, []byte("ttl: -1\n"), 0644))
+	invalidCertIssuer := filepath.Join(t.TempDir(), "invalid-certificate-issuer.yaml")
+	require.Nil(t, os.WriteFile(invalidCertIssuer, []byte("issuerRef:\n  name: letsencrypt\n  kind: Vault\n"), 0644))
 	mockConfigmap := &v1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{Name: ketchv1.IngressConfigmapName, Namespace: ketchv1.IngressConfigmapNamespace},
 		Data: map[string]string{
@@ -57,6 +94,402 @@ func TestIngressSet(t *testing.T) {
 			},
 			want: "Successfully set!\n",
 		},
+		{
+			name: "force https by default",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{mockConfigmap},
+			},
+			options: ingressSetOptions{
+				forceHTTPS: &forceHTTPS,
+			},
+			want: "Successfully set!\n",
+		},
+		{
+			name: "network policy by default",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{mockConfigmap},
+			},
+			options: ingressSetOptions{
+				namespace:     "ingress-system",
+				networkPolicy: &networkPolicy,
+			},
+			want: "Successfully set!\n",
+		},
+		{
+			name: "custom templates",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{mockConfigmap},
+			},
+			options: ingressSetOptions{
+				templates: "company-templates",
+			},
+			want: "Successfully set!\n",
+		},
+		{
+			name: "app defaults",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{mockConfigmap},
+			},
+			options: ingressSetOptions{
+				appDefaults: appDefaults,
+			},
+			want: "Successfully set!\n",
+		},
+		{
+			name: "error - invalid app defaults",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{mockConfigmap},
+			},
+			options: ingressSetOptions{
+				appDefaults: invalidAppDefaults,
+			},
+			wantErr: "failed to parse app defaults: error unmarshaling JSON: while decoding JSON: json: unknown field \"replicas\"",
+		},
+		{
+			name: "certificate issuer",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{mockConfigmap},
+			},
+			options: ingressSetOptions{
+				certIssuer: certIssuer,
+			},
+			want: "Successfully set!\n",
+		},
+		{
+			name: "error - invalid certificate issuer",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{mockConfigmap},
+			},
+			options: ingressSetOptions{
+				certIssuer: invalidCertIssuer,
+			},
+			wantErr: "invalid certificate issuer: unsupported kind \"Vault\", it must be ClusterIssuer or Issuer",
+		},
+		{
+			name: "external-dns settings",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{mockConfigmap},
+			},
+			options: ingressSetOptions{
+				externalDNS: externalDNS,
+			},
+			want: "Successfully set!\n",
+		},
+		{
+			name: "error - invalid external-dns settings",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{mockConfigmap},
+			},
+			options: ingressSetOptions{
+				externalDNS: invalidExternalDNS,
+			},
+			wantErr: "invalid external-dns settings: ttl must be greater than or equal to 0",
+		},
+		{
+			name: "open telemetry settings",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{mockConfigmap},
+			},
+			options: ingressSetOptions{
+				openTelemetry: openTelemetry,
+			},
+			want: "Successfully set!\n",
+		},
+		{
+			name: "error - invalid open telemetry settings",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{mockConfigmap},
+			},
+			options: ingressSetOptions{
+				openTelemetry: invalidOpenTelemetry,
+			},
+			wantErr: "invalid open telemetry settings: invalid open telemetry endpoint \"otel-collector:4317\"",
+		},
+		{
+			name: "logging settings",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{mockConfigmap},
+			},
+			options: ingressSetOptions{
+				logging: logging,
+			},
+			want: "Successfully set!\n",
+		},
+		{
+			name: "error - invalid logging settings",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{mockConfigmap},
+			},
+			options: ingressSetOptions{
+				logging: invalidLogging,
+			},
+			wantErr: "invalid logging settings: error unmarshaling JSON: while decoding JSON: json: unknown field \"sidecar\"",
+		},
+		{
+			name: "notifications",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{mockConfigmap},
+			},
+			options: ingressSetOptions{
+				notifications: notifications,
+			},
+			want: "Successfully set!\n",
+		},
+		{
+			name: "error - invalid notifications",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{mockConfigmap},
+			},
+			options: ingressSetOptions{
+				notifications: invalidNotifications,
+			},
+			wantErr: `invalid notifications: receiver "platform" has unsupported type "email", use "slack" or "webhook"`,
+		},
+		{
+			name: "grafana dashboards settings",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{mockConfigmap},
+			},
+			options: ingressSetOptions{
+				grafana: grafanaDashboards,
+			},
+			want: "Successfully set!\n",
+		},
+		{
+			name: "error - invalid grafana dashboards settings",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{mockConfigmap},
+			},
+			options: ingressSetOptions{
+				grafana: invalidGrafanaDashboards,
+			},
+			wantErr: "invalid grafana dashboards settings: error unmarshaling JSON: while decoding JSON: json: unknown field \"folder\"",
+		},
+		{
+			name: "maintenance image and page",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{mockConfigmap},
+			},
+			options: ingressSetOptions{
+				maintenanceImg:  "registry.example.com/nginx:1.23",
+				maintenancePage: maintenancePage,
+			},
+			want: "Successfully set!\n",
+		},
+		{
+			name: "error - maintenance page not found",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{mockConfigmap},
+			},
+			options: ingressSetOptions{
+				maintenancePage: missingMaintenancePage,
+			},
+			wantErr: "failed to read maintenance page: open " + missingMaintenancePage + ": no such file or directory",
+		},
+		{
+			name: "pre-stop sleep",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{mockConfigmap},
+			},
+			options: ingressSetOptions{
+				preStopSleep: &preStopSleep,
+			},
+			want: "Successfully set!\n",
+		},
+		{
+			name: "pod security profile",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{mockConfigmap},
+			},
+			options: ingressSetOptions{
+				podSecurity: "restricted",
+			},
+			want: "Successfully set!\n",
+		},
+		{
+			name: "error - unsupported pod security profile",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{mockConfigmap},
+			},
+			options: ingressSetOptions{
+				podSecurity: "privileged",
+			},
+			wantErr: `unsupported pod security profile "privileged", supported profiles: baseline, restricted`,
+		},
+		{
+			name: "service mesh",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{mockConfigmap},
+			},
+			options: ingressSetOptions{
+				serviceMesh: "linkerd",
+			},
+			want: "Successfully set!\n",
+		},
+		{
+			name: "error - unsupported service mesh",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{mockConfigmap},
+			},
+			options: ingressSetOptions{
+				serviceMesh: "consul",
+			},
+			wantErr: `unsupported service mesh "consul", supported service meshes: linkerd`,
+		},
+		{
+			name: "engine",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{mockConfigmap},
+			},
+			options: ingressSetOptions{
+				engine: "server-side-apply",
+			},
+			want: "Successfully set!\n",
+		},
+		{
+			name: "error - unsupported engine",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{mockConfigmap},
+			},
+			options: ingressSetOptions{
+				engine: "kapp",
+			},
+			wantErr: `unsupported engine "kapp", use "helm" or "server-side-apply"`,
+		},
+		{
+			name: "drift policy",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{mockConfigmap},
+			},
+			options: ingressSetOptions{
+				driftPolicy: "revert",
+			},
+			want: "Successfully set!\n",
+		},
+		{
+			name: "error - unsupported drift policy",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{mockConfigmap},
+			},
+			options: ingressSetOptions{
+				driftPolicy: "ignore",
+			},
+			wantErr: `unsupported drift policy "ignore", use "revert" or "report"`,
+		},
+		{
+			name: "error - linkerd with istio",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{mockConfigmap},
+			},
+			options: ingressSetOptions{
+				ingressType: "istio",
+				serviceMesh: "linkerd",
+			},
+			wantErr: "linkerd can't be used with the istio ingress controller, istio is a service mesh itself",
+		},
+		{
+			name: "registry",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{mockConfigmap},
+			},
+			options: ingressSetOptions{
+				registry:       "registry.example.com/apps",
+				registrySecret: "registry-credentials",
+				registryMirror: "cache.example.com/apps",
+				buildCache:     "registry.example.com/cache",
+			},
+			want: "Successfully set!\n",
+		},
+		{
+			name: "allowed teams",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{mockConfigmap},
+			},
+			options: ingressSetOptions{
+				allowedTeams: &[]string{"payments", " search"},
+			},
+			want: "Successfully set!\n",
+		},
+		{
+			name: "error - invalid allowed team",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{mockConfigmap},
+			},
+			options: ingressSetOptions{
+				allowedTeams: &[]string{"payments team"},
+			},
+			wantErr: `invalid team "payments team": a valid label must be an empty string or consist of alphanumeric characters, '-', '_' or '.', and must start and end with an alphanumeric character (e.g. 'MyValue',  or 'my_value',  or '12345', regex used for validation is '(([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9])?')`,
+		},
+		{
+			name: "default envs",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{mockConfigmap},
+			},
+			options: ingressSetOptions{
+				defaultEnvs: &[]string{"REGION=eu-west-1", "OTEL_EXPORTER_OTLP_ENDPOINT=http://otel-collector:4317?a=b"},
+			},
+			want: "Successfully set!\n",
+		},
+		{
+			name: "error - invalid default env",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{mockConfigmap},
+			},
+			options: ingressSetOptions{
+				defaultEnvs: &[]string{"REGION"},
+			},
+			wantErr: `invalid default env "REGION", env variables should have NAME=VALUE format`,
+		},
+		{
+			name: "registry mirrors",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{mockConfigmap},
+			},
+			options: ingressSetOptions{
+				mirrors: &[]string{"docker.io=cache.example.com/dockerhub", "quay.io=cache.example.com/quay"},
+			},
+			want: "Successfully set!\n",
+		},
+		{
+			name: "error - invalid registry mirror",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{mockConfigmap},
+			},
+			options: ingressSetOptions{
+				mirrors: &[]string{"docker.io"},
+			},
+			wantErr: `invalid registry mirror "docker.io", registry mirrors should have REGISTRY=ENDPOINT format`,
+		},
+		{
+			name: "service account roles",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{mockConfigmap},
+			},
+			options: ingressSetOptions{
+				roles: &[]string{"ClusterRole/view", "Role/config-reader"},
+			},
+			want: "Successfully set!\n",
+		},
+		{
+			name: "error - invalid service account role",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{mockConfigmap},
+			},
+			options: ingressSetOptions{
+				roles: &[]string{"Group/admins"},
+			},
+			wantErr: `invalid service account role "Group/admins", roles should have Role/NAME or ClusterRole/NAME format`,
+		},
+		{
+			name: "error - negative pre-stop sleep",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{mockConfigmap},
+			},
+			options: ingressSetOptions{
+				preStopSleep: &negativePreStopSleep,
+			},
+			wantErr: "pre-stop-sleep must be greater than or equal to 0",
+		},
 		{
 			name: "error - missing fields",
 			cfg:  &mocks.Configuration{},
@@ -114,6 +547,301 @@ func TestIngressGet(t *testing.T) {
 			},
 			want: "Class Name: nginx\nService Endpoint: 127.0.0.1\nIngress Type: nginx\nCluster Issuer: letsencrypt\n",
 		},
+		{
+			name: "force https",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{&v1.ConfigMap{
+					ObjectMeta: mockConfigmap.ObjectMeta,
+					Data: map[string]string{
+						"className":       "nginx",
+						"serviceEndpoint": "127.0.0.1",
+						"ingressType":     "nginx",
+						"forceHTTPS":      "true",
+					},
+				}},
+			},
+			want: "Class Name: nginx\nService Endpoint: 127.0.0.1\nIngress Type: nginx\nForce HTTPS: true\n",
+		},
+		{
+			name: "network policy",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{&v1.ConfigMap{
+					ObjectMeta: mockConfigmap.ObjectMeta,
+					Data: map[string]string{
+						"className":       "nginx",
+						"serviceEndpoint": "127.0.0.1",
+						"ingressType":     "nginx",
+						"namespace":       "ingress-system",
+						"networkPolicy":   "true",
+					},
+				}},
+			},
+			want: "Class Name: nginx\nService Endpoint: 127.0.0.1\nIngress Type: nginx\nNamespace: ingress-system\nNetwork Policy: true\n",
+		},
+		{
+			name: "custom templates",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{&v1.ConfigMap{
+					ObjectMeta: mockConfigmap.ObjectMeta,
+					Data: map[string]string{
+						"className":       "nginx",
+						"serviceEndpoint": "127.0.0.1",
+						"ingressType":     "nginx",
+						"templates":       "company-templates",
+					},
+				}},
+			},
+			want: "Class Name: nginx\nService Endpoint: 127.0.0.1\nIngress Type: nginx\nTemplates: company-templates\n",
+		},
+		{
+			name: "pod security profile",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{&v1.ConfigMap{
+					ObjectMeta: mockConfigmap.ObjectMeta,
+					Data: map[string]string{
+						"className":          "nginx",
+						"serviceEndpoint":    "127.0.0.1",
+						"ingressType":        "nginx",
+						"podSecurityProfile": "baseline",
+					},
+				}},
+			},
+			want: "Class Name: nginx\nService Endpoint: 127.0.0.1\nIngress Type: nginx\nPod Security Profile: baseline\n",
+		},
+		{
+			name: "service mesh",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{&v1.ConfigMap{
+					ObjectMeta: mockConfigmap.ObjectMeta,
+					Data: map[string]string{
+						"className":       "nginx",
+						"serviceEndpoint": "127.0.0.1",
+						"ingressType":     "nginx",
+						"serviceMesh":     "linkerd",
+					},
+				}},
+			},
+			want: "Class Name: nginx\nService Endpoint: 127.0.0.1\nIngress Type: nginx\nService Mesh: linkerd\n",
+		},
+		{
+			name: "allowed teams",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{&v1.ConfigMap{
+					ObjectMeta: mockConfigmap.ObjectMeta,
+					Data: map[string]string{
+						"className":       "nginx",
+						"serviceEndpoint": "127.0.0.1",
+						"ingressType":     "nginx",
+						"allowedTeams":    "payments,search",
+					},
+				}},
+			},
+			want: "Class Name: nginx\nService Endpoint: 127.0.0.1\nIngress Type: nginx\nAllowed Teams: payments,search\n",
+		},
+		{
+			name: "default envs",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{&v1.ConfigMap{
+					ObjectMeta: mockConfigmap.ObjectMeta,
+					Data: map[string]string{
+						"className":       "nginx",
+						"serviceEndpoint": "127.0.0.1",
+						"ingressType":     "nginx",
+						"defaultEnvs":     "REGION=eu-west-1\nCLUSTER=prod",
+					},
+				}},
+			},
+			want: "Class Name: nginx\nService Endpoint: 127.0.0.1\nIngress Type: nginx\nDefault Envs:\nREGION=eu-west-1\nCLUSTER=prod\n",
+		},
+		{
+			name: "pre-stop sleep",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{&v1.ConfigMap{
+					ObjectMeta: mockConfigmap.ObjectMeta,
+					Data: map[string]string{
+						"className":           "nginx",
+						"serviceEndpoint":     "127.0.0.1",
+						"ingressType":         "nginx",
+						"preStopSleepSeconds": "10",
+					},
+				}},
+			},
+			want: "Class Name: nginx\nService Endpoint: 127.0.0.1\nIngress Type: nginx\nPre-stop Sleep: 10s\n",
+		},
+		{
+			name: "maintenance image and page",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{&v1.ConfigMap{
+					ObjectMeta: mockConfigmap.ObjectMeta,
+					Data: map[string]string{
+						"className":        "nginx",
+						"serviceEndpoint":  "127.0.0.1",
+						"ingressType":      "nginx",
+						"maintenanceImage": "registry.example.com/nginx:1.23",
+						"maintenancePage":  "<h1>Back soon</h1>",
+					},
+				}},
+			},
+			want: "Class Name: nginx\nService Endpoint: 127.0.0.1\nIngress Type: nginx\nMaintenance Image: registry.example.com/nginx:1.23\nMaintenance Page:\n<h1>Back soon</h1>\n",
+		},
+		{
+			name: "registry",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{&v1.ConfigMap{
+					ObjectMeta: mockConfigmap.ObjectMeta,
+					Data: map[string]string{
+						"className":       "nginx",
+						"serviceEndpoint": "127.0.0.1",
+						"ingressType":     "nginx",
+						"registry":        "registry.example.com/apps",
+						"registrySecret":  "registry-credentials",
+						"registryMirror":  "cache.example.com/apps",
+						"registryMirrors": "docker.io=cache.example.com/dockerhub",
+						"buildCache":      "registry.example.com/cache",
+					},
+				}},
+			},
+			want: "Class Name: nginx\nService Endpoint: 127.0.0.1\nIngress Type: nginx\nRegistry: registry.example.com/apps\nRegistry Secret: registry-credentials\nRegistry Mirror: cache.example.com/apps\nRegistry Mirrors:\ndocker.io=cache.example.com/dockerhub\nBuild Cache: registry.example.com/cache\n",
+		},
+		{
+			name: "app defaults",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{&v1.ConfigMap{
+					ObjectMeta: mockConfigmap.ObjectMeta,
+					Data: map[string]string{
+						"className":       "nginx",
+						"serviceEndpoint": "127.0.0.1",
+						"ingressType":     "nginx",
+						"appDefaults":     "labels:\n  team: platform",
+					},
+				}},
+			},
+			want: "Class Name: nginx\nService Endpoint: 127.0.0.1\nIngress Type: nginx\nApp Defaults:\nlabels:\n  team: platform\n",
+		},
+		{
+			name: "certificate issuer",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{&v1.ConfigMap{
+					ObjectMeta: mockConfigmap.ObjectMeta,
+					Data: map[string]string{
+						"className":         "nginx",
+						"serviceEndpoint":   "127.0.0.1",
+						"ingressType":       "nginx",
+						"certificateIssuer": "issuerRef:\n  name: letsencrypt\n  kind: Issuer",
+					},
+				}},
+			},
+			want: "Class Name: nginx\nService Endpoint: 127.0.0.1\nIngress Type: nginx\nCertificate Issuer:\nissuerRef:\n  name: letsencrypt\n  kind: Issuer\n",
+		},
+		{
+			name: "external-dns settings",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{&v1.ConfigMap{
+					ObjectMeta: mockConfigmap.ObjectMeta,
+					Data: map[string]string{
+						"className":       "nginx",
+						"serviceEndpoint": "127.0.0.1",
+						"ingressType":     "nginx",
+						"externalDNS":     "ttl: 300",
+					},
+				}},
+			},
+			want: "Class Name: nginx\nService Endpoint: 127.0.0.1\nIngress Type: nginx\nExternal DNS:\nttl: 300\n",
+		},
+		{
+			name: "open telemetry settings",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{&v1.ConfigMap{
+					ObjectMeta: mockConfigmap.ObjectMeta,
+					Data: map[string]string{
+						"className":       "nginx",
+						"serviceEndpoint": "127.0.0.1",
+						"ingressType":     "nginx",
+						"openTelemetry":   "endpoint: http://otel-collector.monitoring:4317",
+					},
+				}},
+			},
+			want: "Class Name: nginx\nService Endpoint: 127.0.0.1\nIngress Type: nginx\nOpen Telemetry:\nendpoint: http://otel-collector.monitoring:4317\n",
+		},
+		{
+			name: "logging settings",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{&v1.ConfigMap{
+					ObjectMeta: mockConfigmap.ObjectMeta,
+					Data: map[string]string{
+						"className":       "nginx",
+						"serviceEndpoint": "127.0.0.1",
+						"ingressType":     "nginx",
+						"logging":         "appLabel: app.kubernetes.io/name",
+					},
+				}},
+			},
+			want: "Class Name: nginx\nService Endpoint: 127.0.0.1\nIngress Type: nginx\nLogging:\nappLabel: app.kubernetes.io/name\n",
+		},
+		{
+			name: "grafana dashboards settings",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{&v1.ConfigMap{
+					ObjectMeta: mockConfigmap.ObjectMeta,
+					Data: map[string]string{
+						"className":         "nginx",
+						"serviceEndpoint":   "127.0.0.1",
+						"ingressType":       "nginx",
+						"grafanaDashboards": "{}",
+					},
+				}},
+			},
+			want: "Class Name: nginx\nService Endpoint: 127.0.0.1\nIngress Type: nginx\nGrafana Dashboards:\n{}\n",
+		},
+		{
+			name: "migration in progress",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{
+					mockConfigmap,
+					&ketchv1.App{ObjectMeta: metav1.ObjectMeta{Name: "app-1"}, Status: ketchv1.AppStatus{IngressType: ketchv1.NginxIngressControllerType}},
+					&ketchv1.App{ObjectMeta: metav1.ObjectMeta{Name: "app-2"}, Status: ketchv1.AppStatus{IngressType: ketchv1.TraefikIngressControllerType}},
+					&ketchv1.App{ObjectMeta: metav1.ObjectMeta{Name: "app-3"}},
+				},
+			},
+			want: "Class Name: nginx\nService Endpoint: 127.0.0.1\nIngress Type: nginx\nCluster Issuer: letsencrypt\nMigration: 1/2 apps migrated to nginx\n",
+		},
+		{
+			name: "apps keeping another engine",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{
+					&v1.ConfigMap{
+						ObjectMeta: mockConfigmap.ObjectMeta,
+						Data: map[string]string{
+							"className":       "nginx",
+							"serviceEndpoint": "127.0.0.1",
+							"ingressType":     "nginx",
+							"engine":          "server-side-apply",
+						},
+					},
+					&ketchv1.App{ObjectMeta: metav1.ObjectMeta{Name: "app-1"}, Status: ketchv1.AppStatus{Engine: "helm"}},
+					&ketchv1.App{ObjectMeta: metav1.ObjectMeta{Name: "app-2"}, Status: ketchv1.AppStatus{Engine: "server-side-apply"}},
+					&ketchv1.App{ObjectMeta: metav1.ObjectMeta{Name: "app-3"}},
+				},
+			},
+			want: "Class Name: nginx\nService Endpoint: 127.0.0.1\nIngress Type: nginx\nEngine: server-side-apply\nOther Engines: 1/3 apps keep the engine they were installed with instead of server-side-apply\n",
+		},
+		{
+			name: "drift policy",
+			cfg: &mocks.Configuration{
+				CtrlClientObjects: []runtime.Object{
+					&v1.ConfigMap{
+						ObjectMeta: mockConfigmap.ObjectMeta,
+						Data: map[string]string{
+							"className":       "nginx",
+							"serviceEndpoint": "127.0.0.1",
+							"ingressType":     "nginx",
+							"driftPolicy":     "revert",
+						},
+					},
+				},
+			},
+			want: "Class Name: nginx\nService Endpoint: 127.0.0.1\nIngress Type: nginx\nDrift Policy: revert\n",
+		},
 		{
 			name:    "error - not set",
 			cfg:     &mocks.Configuration{},
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/theketchio/ketch/cmd/ketch/output"
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/chart"
+	"github.com/theketchio/ketch/internal/deploy"
+	"github.com/theketchio/ketch/internal/utils/conversions"
+)
+
+const initHelp = `
+Inspect a source directory and generate a starter ketch.yaml and app.yaml in it, so an existing service
+can be deployed without writing them by hand:
+  ketch init ./myservice --name myservice
+  ketch app deploy ./myservice/app.yaml ./myservice
+
+The language is detected from files like go.mod, package.json or requirements.txt, the processes are read
+from the Procfile and ports from EXPOSE instructions of a Dockerfile and port arguments of the Procfile's commands.
+The app is built with buildpacks of the default builder set by "ketch builder set",
+the image defaults to the cluster registry set by "ketch ingress set --registry" unless --image is passed.
+
+Existing files aren't overwritten unless --force is passed. Review the generated files before the first deployment.
+`
+
+const (
+	initKetchYamlFilename = "ketch.yaml"
+	initAppYamlFilename   = "app.yaml"
+)
+
+// initLanguages are marker files of languages supported by buildpacks, in order of detection.
+var initLanguages = []struct {
+	name  string
+	files []string
+}{
+	{name: "Go", files: []string{"go.mod", "Gopkg.toml"}},
+	{name: "Node.js", files: []string{"package.json"}},
+	{name: "Python", files: []string{"requirements.txt", "pyproject.toml", "Pipfile", "setup.py"}},
+	{name: "Ruby", files: []string{"Gemfile"}},
+	{name: "Java", files: []string{"pom.xml", "build.gradle", "build.gradle.kts"}},
+	{name: "PHP", files: []string{"composer.json"}},
+}
+
+// initPortArgRegex matches port arguments of commands, like "-p 5000", "--port=5000" or "--bind 0.0.0.0:5000".
+var initPortArgRegex = regexp.MustCompile(`(?:^|\s)(?:-p|--port|-b|--bind)[\s=](?:[\w.\-]*:)?(\d+)(?:\s|$)`)
+
+type initOptions struct {
+	sourcePath string
+	appName    string
+	image      string
+	namespace  string
+	builder    string
+	force      bool
+}
+
+// initResult is what "ketch init" detected in a source directory.
+type initResult struct {
+	language  string
+	procfile  *chart.Procfile
+	ports     []int
+	ketchYaml ketchv1.KetchYamlData
+	app       deploy.Application
+}
+
+func newInitCmd(out io.Writer, configDefaultBuilder string) *cobra.Command {
+	options := initOptions{builder: deploy.DefaultBuilder}
+	if configDefaultBuilder != "" {
+		options.builder = configDefaultBuilder
+	}
+	cmd := &cobra.Command{
+		Use:   "init [SOURCE DIRECTORY]",
+		Short: "Generate ketch.yaml and app.yaml for a source directory",
+		Long:  initHelp,
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			options.sourcePath = "."
+			if len(args) == 1 {
+				options.sourcePath = args[0]
+			}
+			return initApp(options, out)
+		},
+	}
+	cmd.Flags().StringVar(&options.appName, "name", "", "Name of the app, defaults to the name of the source directory.")
+	cmd.Flags().StringVarP(&options.image, deploy.FlagImage, deploy.FlagImageShort, "", "Image the source is built to.")
+	cmd.Flags().StringVarP(&options.namespace, deploy.FlagNamespace, deploy.FlagNamespaceShort, "default", "Namespace of the app.")
+	cmd.Flags().BoolVar(&options.force, "force", false, "Overwrite existing ketch.yaml and app.yaml.")
+	return cmd
+}
+
+func initApp(options initOptions, out io.Writer) error {
+	info, err := os.Stat(options.sourcePath)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", options.sourcePath)
+	}
+	if options.appName == "" {
+		absPath, err := filepath.Abs(options.sourcePath)
+		if err != nil {
+			return err
+		}
+		options.appName = strings.ToLower(filepath.Base(absPath))
+	}
+	if errs := validation.IsDNS1123Label(options.appName); len(errs) > 0 {
+		return fmt.Errorf("invalid app name %q, pass a valid one with --name: %s", options.appName, strings.Join(errs, ", "))
+	}
+	ketchYamlPath := filepath.Join(options.sourcePath, initKetchYamlFilename)
+	appYamlPath := filepath.Join(options.sourcePath, initAppYamlFilename)
+	if !options.force {
+		for _, filename := range []string{ketchYamlPath, appYamlPath} {
+			if _, err := os.Stat(filename); err == nil {
+				return fmt.Errorf("%s: %w, pass --force to overwrite it", filename, output.ErrFileExists)
+			}
+		}
+	}
+
+	result, err := detectApp(options)
+	if err != nil {
+		return err
+	}
+	fileOptions := output.FileOptions{Force: options.force}
+	if err := output.WriteToFile(result.ketchYaml, out, ketchYamlPath, fileOptions); err != nil {
+		return err
+	}
+	if err := output.WriteToFile(result.app, out, appYamlPath, fileOptions); err != nil {
+		return err
+	}
+
+	if result.language != "" {
+		fmt.Fprintf(out, "Detected a %s app", result.language)
+	} else {
+		fmt.Fprint(out, "No language detected, buildpacks of the builder must support the app")
+	}
+	if result.procfile != nil {
+		fmt.Fprintf(out, " with processes %s", strings.Join(result.procfile.SortedNames(), ", "))
+	}
+	fmt.Fprintln(out, ".")
+	if len(result.ports) == 0 {
+		fmt.Fprintln(out, "No ports detected, the app must listen on the port of the PORT env variable.")
+	}
+	fmt.Fprintf(out, "Created %s and %s, deploy the app with:\n", ketchYamlPath, appYamlPath)
+	fmt.Fprintf(out, "  ketch app deploy %s %s\n", appYamlPath, options.sourcePath)
+	return nil
+}
+
+// detectApp inspects the source directory and returns ketch.yaml and app.yaml of the app.
+func detectApp(options initOptions) (*initResult, error) {
+	result := &initResult{language: detectLanguage(options.sourcePath)}
+	procfile, err := readInitProcfile(options.sourcePath)
+	if err != nil {
+		return nil, err
+	}
+	result.procfile = procfile
+	ports, err := detectPorts(options.sourcePath, procfile)
+	if err != nil {
+		return nil, err
+	}
+	result.ports = ports
+
+	routableProcess := chart.DefaultRoutableProcessName
+	if procfile != nil {
+		routableProcess = procfile.RoutableProcessName
+	}
+	processConfig := ketchv1.KetchYamlProcessConfig{}
+	for _, port := range ports {
+		processConfig.Ports = append(processConfig.Ports, ketchv1.KetchYamlProcessPortConfig{
+			Name:       fmt.Sprintf("http-%d", port),
+			Protocol:   "TCP",
+			Port:       port,
+			TargetPort: port,
+		})
+	}
+	result.ketchYaml = ketchv1.KetchYamlData{
+		Kubernetes: &ketchv1.KetchYamlKubernetesConfig{
+			Processes: map[string]ketchv1.KetchYamlProcessConfig{routableProcess: processConfig},
+		},
+	}
+
+	result.app = deploy.Application{
+		Version:   conversions.StrPtr("v1"),
+		Type:      conversions.StrPtr("Application"),
+		Name:      conversions.StrPtr(options.appName),
+		Namespace: conversions.StrPtr(options.namespace),
+		Builder:   conversions.StrPtr(options.builder),
+	}
+	if options.image != "" {
+		result.app.Image = conversions.StrPtr(options.image)
+	}
+	if procfile != nil {
+		for _, name := range procfile.SortedNames() {
+			result.app.Processes = append(result.app.Processes, deploy.Process{Name: name, Units: conversions.IntPtr(1)})
+		}
+	}
+	return result, nil
+}
+
+// detectLanguage returns the language of the source directory or an empty string if it's unknown.
+func detectLanguage(sourcePath string) string {
+	for _, language := range initLanguages {
+		for _, file := range language.files {
+			if _, err := os.Stat(filepath.Join(sourcePath, file)); err == nil {
+				return language.name
+			}
+		}
+	}
+	return ""
+}
+
+// readInitProcfile returns the Procfile of the source directory or nil if there is none.
+func readInitProcfile(sourcePath string) (*chart.Procfile, error) {
+	content, err := os.ReadFile(filepath.Join(sourcePath, "Procfile"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	procfile, err := chart.ParseProcfile(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Procfile: %w", err)
+	}
+	return procfile, nil
+}
+
+// detectPorts returns sorted ports of EXPOSE instructions of the Dockerfile
+// and of port arguments of the command of the routable process.
+func detectPorts(sourcePath string, procfile *chart.Procfile) ([]int, error) {
+	seen := map[int]bool{}
+	file, err := os.Open(filepath.Join(sourcePath, "Dockerfile"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		defer file.Close()
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) < 2 || !strings.EqualFold(fields[0], "EXPOSE") {
+				continue
+			}
+			for _, field := range fields[1:] {
+				// "8080/tcp" exposes 8080, variables like $PORT can't be resolved.
+				if port, err := strconv.Atoi(strings.SplitN(field, "/", 2)[0]); err == nil {
+					seen[port] = true
+				}
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+	if procfile != nil {
+		command := strings.Join(procfile.Processes[procfile.RoutableProcessName], " ")
+		for _, match := range initPortArgRegex.FindAllStringSubmatch(command, -1) {
+			if port, err := strconv.Atoi(match[1]); err == nil {
+				seen[port] = true
+			}
+		}
+	}
+	ports := make([]int, 0, len(seen))
+	for port := range seen {
+		if port > 0 && port < 65536 {
+			ports = append(ports, port)
+		}
+	}
+	sort.Ints(ports)
+	return ports, nil
+}
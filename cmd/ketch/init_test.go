@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/theketchio/ketch/cmd/ketch/output"
+)
+
+func TestInitApp(t *testing.T) {
+	tests := []struct {
+		name          string
+		files         map[string]string
+		options       initOptions
+		wantKetchYaml string
+		wantAppYaml   string
+		wantOut       string
+	}{
+		{
+			name: "go app with a Dockerfile and a Procfile",
+			files: map[string]string{
+				"go.mod":     "module example.com/orders\n",
+				"Dockerfile": "FROM golang:1.17\nEXPOSE 8080/tcp 9090\n",
+				"Procfile":   "web: ./orders --port 8080\nworker: ./orders-worker\n",
+			},
+			options: initOptions{appName: "orders", image: "docker.io/shipa/orders:latest", namespace: "apps", builder: "heroku/buildpacks:20"},
+			wantKetchYaml: `kubernetes:
+  processes:
+    web:
+      ports:
+      - name: http-8080
+        port: 8080
+        protocol: TCP
+        target_port: 8080
+      - name: http-9090
+        port: 9090
+        protocol: TCP
+        target_port: 9090
+`,
+			wantAppYaml: `builder: heroku/buildpacks:20
+image: docker.io/shipa/orders:latest
+name: orders
+namespace: apps
+processes:
+- name: web
+  units: 1
+- name: worker
+  units: 1
+type: Application
+version: v1
+`,
+			wantOut: "Detected a Go app with processes web, worker.\n",
+		},
+		{
+			name: "node app listening on PORT",
+			files: map[string]string{
+				"package.json": "{}",
+				"Procfile":     "web: node server.js -p $PORT\n",
+			},
+			options: initOptions{appName: "frontend", namespace: "default", builder: "heroku/buildpacks:20"},
+			wantKetchYaml: `kubernetes:
+  processes:
+    web: {}
+`,
+			wantAppYaml: `builder: heroku/buildpacks:20
+name: frontend
+namespace: default
+processes:
+- name: web
+  units: 1
+type: Application
+version: v1
+`,
+			wantOut: "Detected a Node.js app with processes web.\nNo ports detected, the app must listen on the port of the PORT env variable.\n",
+		},
+		{
+			name:    "unknown language without a Procfile",
+			files:   map[string]string{"main.rs": "fn main() {}"},
+			options: initOptions{appName: "api", namespace: "default", builder: "heroku/buildpacks:20"},
+			wantKetchYaml: `kubernetes:
+  processes:
+    web: {}
+`,
+			wantAppYaml: `builder: heroku/buildpacks:20
+name: api
+namespace: default
+type: Application
+version: v1
+`,
+			wantOut: "No language detected, buildpacks of the builder must support the app.\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				require.Nil(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0600))
+			}
+			tt.options.sourcePath = dir
+			out := &bytes.Buffer{}
+			require.Nil(t, initApp(tt.options, out))
+			require.Contains(t, out.String(), tt.wantOut)
+
+			ketchYaml, err := os.ReadFile(filepath.Join(dir, "ketch.yaml"))
+			require.Nil(t, err)
+			require.Equal(t, tt.wantKetchYaml, string(ketchYaml))
+			appYaml, err := os.ReadFile(filepath.Join(dir, "app.yaml"))
+			require.Nil(t, err)
+			require.Equal(t, tt.wantAppYaml, string(appYaml))
+		})
+	}
+}
+
+func TestInitAppExistingFiles(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "Billing")
+	require.Nil(t, os.Mkdir(dir, 0700))
+	require.Nil(t, os.WriteFile(filepath.Join(dir, "app.yaml"), []byte("name: old\n"), 0600))
+
+	options := initOptions{sourcePath: dir, namespace: "default", builder: "heroku/buildpacks:20"}
+	err := initApp(options, &bytes.Buffer{})
+	require.True(t, errors.Is(err, output.ErrFileExists))
+	_, err = os.Stat(filepath.Join(dir, "ketch.yaml"))
+	require.True(t, os.IsNotExist(err))
+
+	options.force = true
+	require.Nil(t, initApp(options, &bytes.Buffer{}))
+	appYaml, err := os.ReadFile(filepath.Join(dir, "app.yaml"))
+	require.Nil(t, err)
+	require.Contains(t, string(appYaml), "name: billing\n")
+}
@@ -20,8 +20,9 @@ func newJobCmd(cfg config, out io.Writer) *cobra.Command {
 		},
 	}
 	cmd.AddCommand(newJobListCmd(cfg, out))
-	cmd.AddCommand(newJobDeployCmd(cfg, out))
-	cmd.AddCommand(newJobRemoveCmd(cfg, out))
+	cmd.AddCommand(requireAccess(newJobDeployCmd(cfg, out), jobsAccess("create"), jobsAccess("update")))
+	cmd.AddCommand(requireAccess(newJobRemoveCmd(cfg, out), jobsAccess("delete")))
 	cmd.AddCommand(newJobExportCmd(cfg, out))
+	cmd.AddCommand(newJobLogsCmd(cfg, out, jobLogs))
 	return cmd
 }
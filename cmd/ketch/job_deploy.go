@@ -7,6 +7,7 @@ import (
 	"io"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -14,10 +15,14 @@ import (
 	"sigs.k8s.io/yaml"
 
 	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/utils"
 )
 
 const jobDeployHelp = `
 Deploy a job.
+With --follow, ketch streams logs of the job until it finishes and exits with the exit code of the failed container,
+so one-off tasks like database migrations can be run from CI.
+With --shard, only ketch-controllers started with the same --shard reconcile the job, an empty --shard assigns it to the controller without a shard.
 `
 
 const (
@@ -27,20 +32,37 @@ const (
 )
 
 func newJobDeployCmd(cfg config, out io.Writer) *cobra.Command {
+	options := jobDeployOptions{}
+	var shard string
 	cmd := &cobra.Command{
-		Use:   "deploy [FILENAME]",
-		Short: "Deploy a job.",
-		Long:  jobDeployHelp,
+		Use:     "deploy [FILENAME]",
+		Aliases: []string{"run"},
+		Short:   "Deploy a job.",
+		Long:    jobDeployHelp,
+		Args:    cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			filename := args[0]
-			return jobDeploy(cmd.Context(), cfg, filename, out)
+			options.filename = args[0]
+			if cmd.Flags().Changed("shard") {
+				options.shard = &shard
+			}
+			return jobDeploy(cmd.Context(), cfg, options, out)
 		},
 	}
+	cmd.Flags().BoolVarP(&options.follow, "follow", "f", false, "Wait for the job to finish streaming its logs, exit with the job's exit code.")
+	cmd.Flags().DurationVar(&options.timeout, "timeout", 30*time.Minute, "Time to wait for the job to finish when --follow is set.")
+	cmd.Flags().StringVar(&shard, "shard", "", "Shard of ketch-controllers reconciling the job, added as a theketch.io/shard label to the job.")
 	return cmd
 }
 
-func jobDeploy(ctx context.Context, cfg config, filename string, out io.Writer) error {
-	b, err := os.ReadFile(filename)
+type jobDeployOptions struct {
+	filename string
+	follow   bool
+	timeout  time.Duration
+	shard    *string
+}
+
+func jobDeploy(ctx context.Context, cfg config, options jobDeployOptions, out io.Writer) error {
+	b, err := os.ReadFile(options.filename)
 	if err != nil {
 		return err
 	}
@@ -57,6 +79,16 @@ func jobDeploy(ctx context.Context, cfg config, filename string, out io.Writer)
 	job := &ketchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: spec.Name, Namespace: "default"}}
 	res, err := controllerutil.CreateOrUpdate(ctx, cfg.Client(), job, func() error {
 		job.Spec = spec
+		switch {
+		case options.shard == nil:
+		case *options.shard == "":
+			delete(job.Labels, utils.KetchShardLabel)
+		default:
+			if job.Labels == nil {
+				job.Labels = map[string]string{}
+			}
+			job.Labels[utils.KetchShardLabel] = *options.shard
+		}
 		return nil
 	})
 	if err != nil {
@@ -70,6 +102,9 @@ func jobDeploy(ctx context.Context, cfg config, filename string, out io.Writer)
 	}
 
 	fmt.Fprintln(out, "Successfully added!")
+	if options.follow {
+		return followJob(ctx, cfg.KubernetesClient(), *job, options.timeout, out)
+	}
 	return nil
 }
 
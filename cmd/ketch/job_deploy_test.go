@@ -99,7 +99,7 @@ description: test`,
 				tt.filename = file.Name()
 			}
 			out := &bytes.Buffer{}
-			err := jobDeploy(context.Background(), tt.cfg, tt.filename, out)
+			err := jobDeploy(context.Background(), tt.cfg, jobDeployOptions{filename: tt.filename}, out)
 			if len(tt.wantErr) > 0 {
 				require.NotNil(t, err)
 				require.Equal(t, tt.wantErr, err.Error())
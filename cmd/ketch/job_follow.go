@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/utils"
+)
+
+var jobFollowPollInterval = 2 * time.Second
+
+// jobFailedError is returned when a followed job fails, ketch exits with the exit code of the job's container.
+type jobFailedError struct {
+	name     string
+	reason   string
+	exitCode int
+}
+
+func (e jobFailedError) Error() string {
+	return fmt.Sprintf("job %s failed: %s (exit code %d)", e.name, e.reason, e.exitCode)
+}
+
+// ExitCode returns the exit code ketch exits with.
+func (e jobFailedError) ExitCode() int {
+	return e.exitCode
+}
+
+// followJob streams logs of the job's pods until the job completes or fails.
+func followJob(ctx context.Context, cli kubernetes.Interface, job ketchv1.Job, timeout time.Duration, out io.Writer) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	w := &syncWriter{out: out}
+	var wg sync.WaitGroup
+	streamed := map[types.UID]map[string]bool{}
+	selector := labels.SelectorFromSet(map[string]string{utils.KetchJobNameLabel: job.Spec.Name}).String()
+	ticker := time.NewTicker(jobFollowPollInterval)
+	defer ticker.Stop()
+	for {
+		pods, err := cli.CoreV1().Pods(job.Spec.Namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			return fmt.Errorf("failed to list pods of job %s: %w", job.Spec.Name, err)
+		}
+		for _, pod := range pods.Items {
+			if streamed[pod.UID] == nil {
+				streamed[pod.UID] = map[string]bool{}
+			}
+			for _, container := range job.Spec.Containers {
+				if streamed[pod.UID][container.Name] || !isContainerStarted(pod, container.Name) {
+					continue
+				}
+				streamed[pod.UID][container.Name] = true
+				prefix := ""
+				if len(job.Spec.Containers) > 1 {
+					prefix = fmt.Sprintf("[%s/%s] ", pod.Name, container.Name)
+				}
+				wg.Add(1)
+				go func(pod corev1.Pod, containerName string) {
+					defer wg.Done()
+					streamContainerLogs(ctx, cli, pod, containerName, prefix, w)
+				}(pod, container.Name)
+			}
+		}
+
+		batchJob, err := cli.BatchV1().Jobs(job.Spec.Namespace).Get(ctx, job.Spec.Name, metav1.GetOptions{})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return fmt.Errorf("failed to get job %s: %w", job.Spec.Name, err)
+		}
+		if err == nil {
+			if c := jobFinishedCondition(batchJob); c != nil {
+				// the containers are terminated, so their log streams end shortly.
+				wg.Wait()
+				if c.Type == batchv1.JobComplete {
+					return nil
+				}
+				return jobFailedError{name: job.Spec.Name, reason: c.Message, exitCode: jobExitCode(pods.Items)}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for job %s to finish", job.Spec.Name)
+		case <-ticker.C:
+		}
+	}
+}
+
+func jobFinishedCondition(job *batchv1.Job) *batchv1.JobCondition {
+	for _, c := range job.Status.Conditions {
+		if (c.Type == batchv1.JobComplete || c.Type == batchv1.JobFailed) && c.Status == corev1.ConditionTrue {
+			return &c
+		}
+	}
+	return nil
+}
+
+// jobExitCode returns the exit code of the most recently failed container of the job's pods.
+func jobExitCode(pods []corev1.Pod) int {
+	exitCode := 1
+	var finishedAt time.Time
+	for _, pod := range pods {
+		for _, status := range pod.Status.ContainerStatuses {
+			terminated := status.State.Terminated
+			if terminated == nil || terminated.ExitCode == 0 || terminated.FinishedAt.Time.Before(finishedAt) {
+				continue
+			}
+			exitCode = int(terminated.ExitCode)
+			finishedAt = terminated.FinishedAt.Time
+		}
+	}
+	return exitCode
+}
+
+func isContainerStarted(pod corev1.Pod, containerName string) bool {
+	for _, container := range pod.Status.ContainerStatuses {
+		if container.Name == containerName {
+			return container.State.Running != nil || container.State.Terminated != nil
+		}
+	}
+	return false
+}
+
+// streamContainerLogs copies logs of the container to out until the container terminates.
+func streamContainerLogs(ctx context.Context, cli kubernetes.Interface, pod corev1.Pod, containerName, prefix string, out io.Writer) {
+	req := cli.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{Container: containerName, Follow: true})
+	stream, err := req.Stream(ctx)
+	if err != nil {
+		fmt.Fprintf(out, "failed to read logs from pod %v: %v\n", pod.Name, unwrappedError(err))
+		return
+	}
+	defer stream.Close()
+	scanner := bufio.NewScanner(stream)
+	for scanner.Scan() {
+		fmt.Fprintf(out, "%s%s\n", prefix, scanner.Text())
+	}
+}
+
+// syncWriter serializes writes of several log streams.
+type syncWriter struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+func (w *syncWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.out.Write(p)
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/utils"
+)
+
+func TestFollowJob(t *testing.T) {
+	jobFollowPollInterval = 10 * time.Millisecond
+	job := ketchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: "migrate", Namespace: "default"},
+		Spec: ketchv1.JobSpec{
+			Name:       "migrate",
+			Namespace:  "jobs",
+			Containers: []ketchv1.Container{{Name: "migrate", Image: "migrate", Command: []string{"migrate", "up"}}},
+		},
+	}
+	batchJob := func(conditionType batchv1.JobConditionType, message string) *batchv1.Job {
+		return &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Name: "migrate", Namespace: "jobs"},
+			Status: batchv1.JobStatus{
+				Conditions: []batchv1.JobCondition{{Type: conditionType, Status: corev1.ConditionTrue, Message: message}},
+			},
+		}
+	}
+	pod := func(name string, exitCode int32, finishedAt time.Time) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "jobs", UID: types.UID(name), Labels: map[string]string{utils.KetchJobNameLabel: "migrate"}},
+			Status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name:  "migrate",
+					State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: exitCode, FinishedAt: metav1.NewTime(finishedAt)}},
+				}},
+			},
+		}
+	}
+	now := time.Now()
+	tests := []struct {
+		name         string
+		objects      []runtime.Object
+		timeout      time.Duration
+		wantOut      string
+		wantExitCode int
+		wantErr      string
+	}{
+		{
+			name:    "job completes",
+			objects: []runtime.Object{batchJob(batchv1.JobComplete, ""), pod("migrate-1", 0, now)},
+			timeout: time.Second,
+			wantOut: "fake logs\n",
+		},
+		{
+			name:         "exit code of the last failed pod",
+			objects:      []runtime.Object{batchJob(batchv1.JobFailed, "BackoffLimitExceeded"), pod("migrate-1", 2, now.Add(-time.Minute)), pod("migrate-2", 3, now)},
+			timeout:      time.Second,
+			wantOut:      "fake logs\nfake logs\n",
+			wantExitCode: 3,
+			wantErr:      "job migrate failed: BackoffLimitExceeded (exit code 3)",
+		},
+		{
+			name:    "job doesn't finish in time",
+			timeout: 50 * time.Millisecond,
+			wantErr: "timed out waiting for job migrate to finish",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			err := followJob(context.Background(), fake.NewSimpleClientset(tt.objects...), job, tt.timeout, out)
+			require.Equal(t, tt.wantOut, out.String())
+			if len(tt.wantErr) > 0 {
+				require.EqualError(t, err, tt.wantErr)
+				var exitErr jobFailedError
+				if tt.wantExitCode > 0 {
+					require.True(t, errors.As(err, &exitErr))
+					require.Equal(t, tt.wantExitCode, exitErr.ExitCode())
+				}
+				return
+			}
+			require.Nil(t, err)
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/utils"
+	"github.com/theketchio/ketch/internal/validation"
+)
+
+const jobLogsHelp = `
+Show logs of a job.
+Logs of the first container of the job are shown unless --container is set.
+`
+
+type jobLogsFn func(context.Context, config, jobLogsOptions, io.Writer, watchLogsFn) error
+
+func newJobLogsCmd(cfg config, out io.Writer, jobLogs jobLogsFn) *cobra.Command {
+	options := jobLogsOptions{}
+	cmd := &cobra.Command{
+		Use:   "logs NAME",
+		Short: "Show logs of a job.",
+		Long:  jobLogsHelp,
+		Args:  cobra.ExactValidArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			options.jobName = args[0]
+			if !validation.ValidateName(options.jobName) {
+				return ErrInvalidJobName
+			}
+			return jobLogs(cmd.Context(), cfg, options, out, watchLogs)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return autoCompleteJobNames(cfg, toComplete)
+		},
+	}
+	cmd.Flags().StringVarP(&options.containerName, "container", "c", "", "Container name")
+	cmd.Flags().BoolVarP(&options.follow, "follow", "f", false, "Specify if the logs should be streamed")
+	cmd.Flags().BoolVar(&options.prefix, "prefix", false, "Prefix each log line with the log source (pod name and container name)")
+	cmd.Flags().BoolVar(&options.timestamps, "timestamps", false, "Include timestamps on each line in the log output")
+	return cmd
+}
+
+type jobLogsOptions struct {
+	jobName       string
+	containerName string
+	follow        bool
+	timestamps    bool
+	prefix        bool
+}
+
+func jobLogs(ctx context.Context, cfg config, options jobLogsOptions, out io.Writer, watchLogs watchLogsFn) error {
+	job := ketchv1.Job{}
+	if err := cfg.Client().Get(ctx, types.NamespacedName{Name: options.jobName, Namespace: "default"}, &job); err != nil {
+		return fmt.Errorf("failed to get job: %w", err)
+	}
+	containerName := options.containerName
+	if len(containerName) == 0 {
+		if len(job.Spec.Containers) == 0 {
+			return fmt.Errorf("job %s has no containers", job.Name)
+		}
+		containerName = job.Spec.Containers[0].Name
+	}
+	opts := watchOptions{
+		namespace:     job.Spec.Namespace,
+		selector:      labels.SelectorFromSet(map[string]string{utils.KetchJobNameLabel: job.Spec.Name}),
+		containerName: containerName,
+		follow:        options.follow,
+		timestamps:    options.timestamps,
+		prefix:        options.prefix,
+		out:           out,
+	}
+	return watchLogs(cfg.KubernetesClient(), opts, readLogs, streamLogs)
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/mocks"
+	"github.com/theketchio/ketch/internal/utils"
+)
+
+func TestJobLogs(t *testing.T) {
+	mockJob := &ketchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: "hello", Namespace: "default"},
+		Spec: ketchv1.JobSpec{
+			Name:      "hello",
+			Namespace: "jobs",
+			Containers: []ketchv1.Container{
+				{Name: "lister", Image: "ubuntu", Command: []string{"ls", "/"}},
+				{Name: "sidecar", Image: "busybox", Command: []string{"sleep", "10"}},
+			},
+			Type: "Job",
+		},
+	}
+	tests := []struct {
+		name             string
+		options          jobLogsOptions
+		wantWatchOptions watchOptions
+		wantErr          string
+	}{
+		{
+			name:    "first container by default",
+			options: jobLogsOptions{jobName: "hello", follow: true},
+			wantWatchOptions: watchOptions{
+				namespace:     "jobs",
+				selector:      labels.SelectorFromSet(map[string]string{utils.KetchJobNameLabel: "hello"}),
+				containerName: "lister",
+				follow:        true,
+			},
+		},
+		{
+			name:    "container is set",
+			options: jobLogsOptions{jobName: "hello", containerName: "sidecar", prefix: true},
+			wantWatchOptions: watchOptions{
+				namespace:     "jobs",
+				selector:      labels.SelectorFromSet(map[string]string{utils.KetchJobNameLabel: "hello"}),
+				containerName: "sidecar",
+				prefix:        true,
+			},
+		},
+		{
+			name:    "no job",
+			options: jobLogsOptions{jobName: "missing"},
+			wantErr: `failed to get job: jobs.theketch.io "missing" not found`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &mocks.Configuration{CtrlClientObjects: []runtime.Object{mockJob}}
+			watchFn := func(client kubernetes.Interface, options watchOptions, readLogs_ readLogsFn, streamLogs_ streamLogsFn) error {
+				options.out = nil
+				require.Equal(t, tt.wantWatchOptions, options)
+				return nil
+			}
+			err := jobLogs(context.Background(), cfg, tt.options, &bytes.Buffer{}, watchFn)
+			if len(tt.wantErr) > 0 {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.Nil(t, err)
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"log"
 	"os"
 
@@ -22,14 +23,40 @@ func main() {
 	// Remove any flags that were added by libraries automatically.
 	pflag.CommandLine = pflag.NewFlagSet("ketch", pflag.ExitOnError)
 
-	out := os.Stdout
+	ketchConfig := getKetchConfig()
+	masker := newSecretMasker(newRedactor(ketchConfig.SensitiveEnvPatterns), os.Environ())
+	out := masker.writer(os.Stdout)
+	log.SetOutput(masker.writer(os.Stderr))
+
 	packSvc, err := pack.New(out)
 	if err != nil {
 		log.Fatalf("couldn't create pack service %q", err)
 	}
+	cleanup, err := useRegistryCredentials(os.Getenv)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+
+	cfg := &configuration.Configuration{}
+	target, ok, err := resolveTarget(ketchConfig, os.Args[1:])
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	if ok {
+		cfg.UseTarget(target)
+	}
 
-	cmd := newRootCmd(&configuration.Configuration{}, out, packSvc, getKetchConfig())
-	if err := cmd.Execute(); err != nil {
+	cmd := newRootCmd(cfg, out, packSvc, ketchConfig)
+	cmd.SetErr(masker.writer(os.Stderr))
+	err = withPermissionHint(cmd.Execute())
+	cleanup()
+	if err != nil {
+		// a followed job that failed makes ketch exit with the job's exit code.
+		var exitErr interface{ ExitCode() int }
+		if errors.As(err, &exitErr) {
+			log.Printf("Error: %v", err)
+			os.Exit(exitErr.ExitCode())
+		}
 		log.Fatalf("Error: %v", err)
 	}
 }
@@ -1,9 +1,12 @@
 package output
 
 import (
+	"bytes"
 	"errors"
 	"io"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 
 	"sigs.k8s.io/yaml"
 )
@@ -12,7 +15,10 @@ type writer interface {
 	write() error
 }
 
-var ErrFileExists = errors.New("file already exists")
+var (
+	ErrFileExists     = errors.New("file already exists")
+	ErrForceAndAppend = errors.New("force and append can't be used together")
+)
 
 // Write writes data to out, switching marshaling type based on outputFlag
 func Write(data interface{}, out io.Writer, outputFlag string) error {
@@ -27,24 +33,78 @@ func Write(data interface{}, out io.Writer, outputFlag string) error {
 	return w.write()
 }
 
+// StdoutFilename is a filename that explicitly means out instead of a file.
+const StdoutFilename = "-"
+
+// FileOptions controls what happens to a file that already exists.
+type FileOptions struct {
+	// Force replaces the file.
+	Force bool
+	// Append adds the output to the file as a new yaml document.
+	Append bool
+}
+
 // WriteToFileOrOut marshals output to yaml and writes to file, if a filename is passed, or out.
+// An existing file is an error.
 func WriteToFileOrOut(output interface{}, out io.Writer, filename string) error {
-	if filename != "" {
-		_, err := os.Stat(filename)
-		if !os.IsNotExist(err) {
-			return ErrFileExists
+	return WriteToFile(output, out, filename, FileOptions{})
+}
+
+// WriteToFile marshals output to yaml and writes it to file, or to out if filename is empty or "-".
+// The file is replaced atomically, readers see either the previous content or the new one.
+func WriteToFile(output interface{}, out io.Writer, filename string, options FileOptions) error {
+	if options.Force && options.Append {
+		return ErrForceAndAppend
+	}
+	b, err := yaml.Marshal(output)
+	if err != nil {
+		return err
+	}
+	if filename == "" || filename == StdoutFilename {
+		_, err = out.Write(b)
+		return err
+	}
+	previous, err := ioutil.ReadFile(filename)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return err
+	case options.Append:
+		if len(previous) > 0 && !bytes.HasSuffix(previous, []byte("\n")) {
+			previous = append(previous, '\n')
 		}
-		f, err := os.Create(filename)
-		if err != nil {
-			return err
+		if len(previous) > 0 {
+			previous = append(previous, []byte("---\n")...)
 		}
-		defer f.Close()
-		out = f
+		b = append(previous, b...)
+	case !options.Force:
+		return ErrFileExists
 	}
-	b, err := yaml.Marshal(output)
+	return writeFileAtomically(filename, b)
+}
+
+// writeFileAtomically writes content to a temporary file next to filename and renames it to filename.
+// A replaced file keeps its permissions.
+func writeFileAtomically(filename string, content []byte) error {
+	mode := os.FileMode(0644)
+	if stat, err := os.Stat(filename); err == nil {
+		mode = stat.Mode().Perm()
+	}
+	f, err := ioutil.TempFile(filepath.Dir(filename), "."+filepath.Base(filename)+"-")
 	if err != nil {
 		return err
 	}
-	_, err = out.Write(b)
-	return err
+	defer os.Remove(f.Name())
+	if _, err := f.Write(content); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Chmod(mode); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), filename)
 }
@@ -0,0 +1,100 @@
+package output
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteToFile(t *testing.T) {
+	type doc struct {
+		Name string `json:"name"`
+	}
+	tests := []struct {
+		name     string
+		previous string
+		filename string
+		options  FileOptions
+		want     string
+		wantOut  string
+		wantErr  error
+	}{
+		{
+			name:    "no file",
+			wantOut: "name: new\n",
+		},
+		{
+			name:     "stdout",
+			filename: StdoutFilename,
+			wantOut:  "name: new\n",
+		},
+		{
+			name:     "new file",
+			filename: "export.yaml",
+			want:     "name: new\n",
+		},
+		{
+			name:     "existing file",
+			previous: "name: old\n",
+			filename: "export.yaml",
+			want:     "name: old\n",
+			wantErr:  ErrFileExists,
+		},
+		{
+			name:     "force",
+			previous: "name: old\n",
+			filename: "export.yaml",
+			options:  FileOptions{Force: true},
+			want:     "name: new\n",
+		},
+		{
+			name:     "append",
+			previous: "name: old",
+			filename: "export.yaml",
+			options:  FileOptions{Append: true},
+			want:     "name: old\n---\nname: new\n",
+		},
+		{
+			name:     "append to a new file",
+			filename: "export.yaml",
+			options:  FileOptions{Append: true},
+			want:     "name: new\n",
+		},
+		{
+			name:     "force and append",
+			previous: "name: old\n",
+			filename: "export.yaml",
+			options:  FileOptions{Force: true, Append: true},
+			want:     "name: old\n",
+			wantErr:  ErrForceAndAppend,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			filename := tt.filename
+			if filename != "" && filename != StdoutFilename {
+				filename = filepath.Join(dir, filename)
+			}
+			if tt.previous != "" {
+				require.Nil(t, os.WriteFile(filename, []byte(tt.previous), 0600))
+			}
+			out := &bytes.Buffer{}
+			err := WriteToFile(doc{Name: "new"}, out, filename, tt.options)
+			require.Equal(t, tt.wantErr, err)
+			require.Equal(t, tt.wantOut, out.String())
+			if tt.want != "" {
+				content, err := os.ReadFile(filename)
+				require.Nil(t, err)
+				require.Equal(t, tt.want, string(content))
+			}
+			// no temporary files are left behind
+			entries, err := os.ReadDir(dir)
+			require.Nil(t, err)
+			require.LessOrEqual(t, len(entries), 1)
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"path"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+)
+
+const (
+	redactedValue = "<redacted>"
+
+	showSecretsFlag  = "show-secrets"
+	showSecretsUsage = "Show values of sensitive environment variables instead of masking them."
+)
+
+// defaultSensitiveEnvPatterns are used when config.toml doesn't configure sensitive-env-patterns.
+var defaultSensitiveEnvPatterns = []string{"*_TOKEN", "*_SECRET", "*_PASSWORD", "*_API_KEY", "*_PRIVATE_KEY"}
+
+// redactor masks values of sensitive environment variables in the output of ketch commands.
+// A variable is sensitive if it's marked so in the app's spec or its name matches one of the patterns.
+type redactor struct {
+	patterns []string
+}
+
+func newRedactor(patterns []string) redactor {
+	if patterns == nil {
+		patterns = defaultSensitiveEnvPatterns
+	}
+	return redactor{patterns: patterns}
+}
+
+func (r redactor) isSensitive(env ketchv1.Env) bool {
+	if env.Sensitive {
+		return true
+	}
+	for _, pattern := range r.patterns {
+		if ok, _ := path.Match(pattern, env.Name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// envs returns a copy of the given variables with values of sensitive ones masked.
+// Variables read from secrets or configmaps are kept as is because the app's spec contains only references to them.
+func (r redactor) envs(envs []ketchv1.Env) []ketchv1.Env {
+	if envs == nil {
+		return nil
+	}
+	redacted := make([]ketchv1.Env, 0, len(envs))
+	for _, env := range envs {
+		if env.ValueFrom == nil && r.isSensitive(env) {
+			env.Value = redactedValue
+		}
+		redacted = append(redacted, env)
+	}
+	return redacted
+}
+
+// app masks values of sensitive environment variables of the app and of its processes.
+func (r redactor) app(app *ketchv1.App) {
+	app.Spec.Env = r.envs(app.Spec.Env)
+	for i := range app.Spec.Deployments {
+		processes := app.Spec.Deployments[i].Processes
+		for j := range processes {
+			processes[j].Env = r.envs(processes[j].Env)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+)
+
+func TestRedactor_envs(t *testing.T) {
+	dbPassword := &v1.EnvVarSource{SecretKeyRef: &v1.SecretKeySelector{LocalObjectReference: v1.LocalObjectReference{Name: "db"}, Key: "password"}}
+	envs := []ketchv1.Env{
+		{Name: "GITHUB_TOKEN", Value: "ghp_123"},
+		{Name: "DATABASE_URL", Value: "postgres://user:pass@db", Sensitive: true},
+		{Name: "DEBUG", Value: "true"},
+		{Name: "DB_PASSWORD", ValueFrom: dbPassword},
+	}
+	tests := []struct {
+		name     string
+		patterns []string
+		want     []ketchv1.Env
+	}{
+		{
+			name: "default patterns",
+			want: []ketchv1.Env{
+				{Name: "GITHUB_TOKEN", Value: redactedValue},
+				{Name: "DATABASE_URL", Value: redactedValue, Sensitive: true},
+				{Name: "DEBUG", Value: "true"},
+				{Name: "DB_PASSWORD", ValueFrom: dbPassword},
+			},
+		},
+		{
+			name:     "configured patterns",
+			patterns: []string{"DEBUG"},
+			want: []ketchv1.Env{
+				{Name: "GITHUB_TOKEN", Value: "ghp_123"},
+				{Name: "DATABASE_URL", Value: redactedValue, Sensitive: true},
+				{Name: "DEBUG", Value: redactedValue},
+				{Name: "DB_PASSWORD", ValueFrom: dbPassword},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newRedactor(tt.patterns).envs(envs)
+			require.Equal(t, tt.want, got)
+			require.Equal(t, "ghp_123", envs[0].Value)
+		})
+	}
+}
+
+func TestRedactor_app(t *testing.T) {
+	app := &ketchv1.App{
+		Spec: ketchv1.AppSpec{
+			Env: []ketchv1.Env{{Name: "GITHUB_TOKEN", Value: "ghp_123"}},
+			Deployments: []ketchv1.AppDeploymentSpec{
+				{
+					Processes: []ketchv1.ProcessSpec{
+						{Name: "web", Env: []ketchv1.Env{{Name: "STRIPE_API_KEY", Value: "sk_123"}, {Name: "DEBUG", Value: "true"}}},
+						{Name: "worker"},
+					},
+				},
+			},
+		},
+	}
+	newRedactor(nil).app(app)
+	require.Equal(t, []ketchv1.Env{{Name: "GITHUB_TOKEN", Value: redactedValue}}, app.Spec.Env)
+	require.Equal(t, []ketchv1.Env{{Name: "STRIPE_API_KEY", Value: redactedValue}, {Name: "DEBUG", Value: "true"}}, app.Spec.Deployments[0].Processes[0].Env)
+	require.Nil(t, app.Spec.Deployments[0].Processes[1].Env)
+}
@@ -2,15 +2,18 @@ package main
 
 import (
 	"io"
+	"os"
 
 	"github.com/spf13/cobra"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/theketchio/ketch/cmd/ketch/configuration"
 	"github.com/theketchio/ketch/internal/pack"
 	"github.com/theketchio/ketch/internal/templates"
+	"github.com/theketchio/ketch/pkg/ketchclient"
 )
 
 type config interface {
@@ -20,6 +23,13 @@ type config interface {
 	KubernetesClient() kubernetes.Interface
 	// DynamicClient returns kubernetes dynamic client. It's used to work with CRDs for which we don't have go types like ClusterIssuer.
 	DynamicClient() dynamic.Interface
+	// RESTConfig returns a config of kubernetes clients. It's used to stream to pods like "ketch app run" does.
+	RESTConfig() *rest.Config
+}
+
+// newKetchClient returns a client of the ketchclient package that works with cfg's clients.
+func newKetchClient(cfg config) *ketchclient.Client {
+	return ketchclient.NewWithClients(cfg.Client(), cfg.KubernetesClient())
 }
 
 // RootCmd represents the base command when called without any subcommands
@@ -30,16 +40,31 @@ func newRootCmd(cfg config, out io.Writer, packSvc *pack.Client, ketchConfig con
 		Long:          `For details see https://theketch.io`,
 		Version:       version,
 		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := setFlagsFromEnv(cmd, os.Getenv); err != nil {
+				return err
+			}
+			return checkAccess(cmd.Context(), cfg, cmd)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return cmd.Usage()
 		},
 	}
-	cmd.AddCommand(newAppCmd(cfg, out, packSvc, ketchConfig.DefaultBuilder))
+	redact := newRedactor(ketchConfig.SensitiveEnvPatterns)
+	cmd.AddCommand(newAppCmd(cfg, out, packSvc, ketchConfig.DefaultBuilder, ketchConfig.DefaultRegistrySecret, redact))
+	cmd.PersistentFlags().String(targetFlag, "", "Name of the target to run the command against, KETCH_TARGET or the current target by default.")
+	cmd.PersistentFlags().Bool(nonInteractiveFlag, false, nonInteractiveUsage)
 	cmd.AddCommand(newBuilderCmd(ketchConfig, out))
 	cmd.AddCommand(newCnameCmd(cfg, out))
-	cmd.AddCommand(newEnvCmd(cfg, out))
+	cmd.AddCommand(newDashboardCmd(cfg, out))
+	cmd.AddCommand(newEnvCmd(cfg, out, redact))
 	cmd.AddCommand(newJobCmd(cfg, out))
 	cmd.AddCommand(newIngressCmd(cfg, out))
+	cmd.AddCommand(newServerCmd(cfg, out, serve, serveGRPC))
+	cmd.AddCommand(requireAccess(newApplyCmd(cfg, out, apply), appsAccess("create"), appsAccess("update")))
+	cmd.AddCommand(newInitCmd(out, ketchConfig.DefaultBuilder))
+	cmd.AddCommand(newTargetCmd(ketchConfig, out))
+	cmd.AddCommand(newUnitCmd(cfg, out))
 	cmd.AddCommand(newCompletionCmd())
 	return cmd
 }
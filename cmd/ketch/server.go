@@ -0,0 +1,514 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/deploy"
+	"github.com/theketchio/ketch/pkg/ketchclient"
+)
+
+const serverHelp = `
+Run an HTTP server with a JSON API and a web UI of apps of the cluster, so portals and users without the CLI can see apps.
+
+Requests must send the token of --token-file as a bearer token, --allow-anonymous-read lets requests reading apps omit it.
+Apps can't be changed without --token-file. The API serves:
+  GET  /api/v1/apps                         apps with their states, like "ketch app list"
+  POST /api/v1/apps                         create an app without a deployment, e.g. {"name": "myapp", "namespace": "apps"}
+  GET  /api/v1/apps/<app name>              the app and its processes, like "ketch app info", env values are redacted
+  GET  /api/v1/apps/<app name>/deployments  processes of deployments of the app
+  POST /api/v1/apps/<app name>/deployments  deploy an image, the body has fields of "ketch apply" apps
+  PUT  /api/v1/apps/<app name>/env          set env variables, e.g. {"env": {"DEBUG": "true"}, "sensitive": false}
+  PUT  /api/v1/apps/<app name>/units        set units of a process, e.g. {"process": "web", "deploymentVersion": 2, "units": 3}
+For example:
+  curl -H "Authorization: Bearer $TOKEN" -d '{"image": "myregistry/myimage:v2"}' http://localhost:8080/api/v1/apps/myapp/deployments
+
+Go programs can change apps the same way with the github.com/theketchio/ketch/pkg/ketchclient package.
+
+With --grpc-address, the server also serves the gRPC service ketch.v1.Apps described by cmd/ketch/apps.proto
+with the methods CreateApp, Deploy, SetEnv and Scale. Their requests and responses are google.protobuf.Struct messages
+with the fields of the HTTP requests, Deploy names the app with a "name" field, SetEnv and Scale with an "app" field.
+Calls must send the token as "authorization: Bearer <token>" metadata, for example:
+  grpcurl -plaintext -import-path cmd/ketch -proto apps.proto -H "authorization: Bearer $TOKEN" \
+    -d '{"name": "myapp", "image": "myregistry/myimage:v2"}' localhost:9090 ketch.v1.Apps/Deploy
+
+The web UI at / lists apps, /apps/<app name> shows processes of an app. Browsers can only open it with --allow-anonymous-read
+or through a proxy adding the token.
+The server listens on 127.0.0.1 by default, use --address to expose it, e.g. --address :8080 behind TLS termination.
+The server uses the credentials of the current target, they must allow listing apps and pods and updating apps to deploy.
+`
+
+const (
+	// serverRequestTimeout limits the time a request to the server spends reading or changing the cluster.
+	serverRequestTimeout = 30 * time.Second
+	// serverMaxBodySize is the maximum size of a deploy request.
+	serverMaxBodySize = 1 << 20
+)
+
+type serverOptions struct {
+	address            string
+	grpcAddress        string
+	tokenFile          string
+	allowAnonymousRead bool
+}
+
+type serverFn func(ctx context.Context, address string, handler http.Handler) error
+
+func newServerCmd(cfg config, out io.Writer, serve serverFn, serveGRPC grpcServerFn) *cobra.Command {
+	options := serverOptions{}
+	cmd := &cobra.Command{
+		Use:   "server",
+		Short: "Run an HTTP API and web UI of apps.",
+		Long:  serverHelp,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var token string
+			if options.tokenFile != "" {
+				content, err := os.ReadFile(options.tokenFile)
+				if err != nil {
+					return fmt.Errorf("failed to read token: %w", err)
+				}
+				if token = strings.TrimSpace(string(content)); token == "" {
+					return fmt.Errorf("token file %s is empty", options.tokenFile)
+				}
+			} else if !options.allowAnonymousRead {
+				return fmt.Errorf("the server requires --token-file or --allow-anonymous-read")
+			}
+			svc := &deploy.Services{
+				Client:         cfg.Client(),
+				KubeClient:     cfg.KubernetesClient(),
+				GetImageConfig: deploy.GetImageConfig,
+				Wait:           deploy.WaitForDeployment,
+				Templates:      cfg.Storage(),
+			}
+			s := newAPIServer(cfg, svc, token, options.allowAnonymousRead)
+			fmt.Fprintf(out, "Listening on %s\n", options.address)
+			if options.grpcAddress == "" {
+				return serve(cmd.Context(), options.address, s.handler())
+			}
+			fmt.Fprintf(out, "Serving gRPC on %s\n", options.grpcAddress)
+			ctx, cancel := context.WithCancel(cmd.Context())
+			defer cancel()
+			errs := make(chan error, 2)
+			go func() {
+				errs <- serve(ctx, options.address, s.handler())
+			}()
+			go func() {
+				errs <- serveGRPC(ctx, options.grpcAddress, newGRPCServer(s))
+			}()
+			// the servers stop together.
+			err := <-errs
+			cancel()
+			if grpcErr := <-errs; err == nil {
+				err = grpcErr
+			}
+			return err
+		},
+	}
+	cmd.Flags().StringVar(&options.address, "address", "127.0.0.1:8080", "Address the server listens on.")
+	cmd.Flags().StringVar(&options.grpcAddress, "grpc-address", "", "Address the gRPC API listens on, e.g. 127.0.0.1:9090. The gRPC API isn't served if it's empty.")
+	cmd.Flags().StringVar(&options.tokenFile, "token-file", "", "Path to a file with a token requests must send as a bearer token. Apps can't be changed if it's not set.")
+	cmd.Flags().BoolVar(&options.allowAnonymousRead, "allow-anonymous-read", false, "Serve requests reading apps and the web UI without the token.")
+	return cmd
+}
+
+// serve runs the server until ctx is done.
+func serve(ctx context.Context, address string, handler http.Handler) error {
+	server := &http.Server{Addr: address, Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.ListenAndServe()
+	}()
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return server.Shutdown(shutdownCtx)
+	}
+}
+
+// apiServer serves the API and the web UI of "ketch server".
+type apiServer struct {
+	cfg    config
+	svc    *deploy.Services
+	client *ketchclient.Client
+	redact redactor
+	// token is a token requests must send, apps can't be changed if it's empty.
+	token string
+	// allowAnonymousRead lets requests reading apps omit the token.
+	allowAnonymousRead bool
+}
+
+type apiError struct {
+	Error string `json:"error"`
+}
+
+type apiResponse struct {
+	Message string `json:"message"`
+	// Output is the output of a deploy, like "ketch app deploy" prints it.
+	Output string `json:"output,omitempty"`
+}
+
+func newAPIServer(cfg config, svc *deploy.Services, token string, allowAnonymousRead bool) *apiServer {
+	return &apiServer{
+		cfg:                cfg,
+		svc:                svc,
+		client:             newKetchClient(cfg),
+		redact:             newRedactor(nil),
+		token:              token,
+		allowAnonymousRead: allowAnonymousRead,
+	}
+}
+
+// handler returns the handler of the HTTP API and the web UI.
+func (s *apiServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/apps", s.handleApps)
+	mux.HandleFunc("/api/v1/apps/", s.handleApp)
+	mux.HandleFunc("/apps/", s.handleAppPage)
+	mux.HandleFunc("/", s.handleAppsPage)
+	return s.authorizeReads(mux)
+}
+
+// authorizeReads rejects requests reading apps without the token unless anonymous reads are allowed,
+// requests changing apps are authorized by their handlers.
+func (s *apiServer) authorizeReads(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead || s.allowAnonymousRead || s.validToken(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		writeAPIError(w, http.StatusUnauthorized, fmt.Errorf("invalid token"))
+	})
+}
+
+// validToken tells if the request sends the server's token.
+func (s *apiServer) validToken(r *http.Request) bool {
+	if s.token == "" {
+		return false
+	}
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1
+}
+
+func (s *apiServer) handleApps(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), serverRequestTimeout)
+	defer cancel()
+	if r.Method == http.MethodPost {
+		s.createApp(ctx, w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeAPIError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s isn't allowed", r.Method))
+		return
+	}
+	apps, err := dashboardApps(ctx, s.cfg)
+	if err != nil {
+		writeAPIError(w, apiStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, apps)
+}
+
+// handleApp serves /api/v1/apps/<app name> and its deployments, env and units.
+func (s *apiServer) handleApp(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/v1/apps/"), "/")
+	ctx, cancel := context.WithTimeout(r.Context(), serverRequestTimeout)
+	defer cancel()
+	switch {
+	case len(parts) == 1 && parts[0] != "" && r.Method == http.MethodGet:
+		info, err := s.appInfo(ctx, parts[0])
+		if err != nil {
+			writeAPIError(w, apiStatus(err), err)
+			return
+		}
+		writeJSON(w, http.StatusOK, info)
+	case len(parts) == 2 && parts[0] != "" && parts[1] == "deployments" && r.Method == http.MethodGet:
+		deployments, err := dashboardProcesses(ctx, s.cfg, parts[0])
+		if err != nil {
+			writeAPIError(w, apiStatus(err), err)
+			return
+		}
+		writeJSON(w, http.StatusOK, deployments)
+	case len(parts) == 2 && parts[0] != "" && parts[1] == "deployments" && r.Method == http.MethodPost:
+		s.deploy(ctx, w, r, parts[0])
+	case len(parts) == 2 && parts[0] != "" && parts[1] == "env" && r.Method == http.MethodPut:
+		s.setEnv(ctx, w, r, parts[0])
+	case len(parts) == 2 && parts[0] != "" && parts[1] == "units" && r.Method == http.MethodPut:
+		s.scale(ctx, w, r, parts[0])
+	case len(parts) == 1 && parts[0] != "",
+		len(parts) == 2 && parts[0] != "" && (parts[1] == "deployments" || parts[1] == "env" || parts[1] == "units"):
+		writeAPIError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s isn't allowed", r.Method))
+	default:
+		writeAPIError(w, http.StatusNotFound, fmt.Errorf("%s not found", r.URL.Path))
+	}
+}
+
+// appInfo returns the app with its processes, values of env variables of the app and its processes are redacted.
+func (s *apiServer) appInfo(ctx context.Context, name string) (*appInfoOutput, error) {
+	app := ketchv1.App{}
+	if err := s.cfg.Client().Get(ctx, types.NamespacedName{Name: name}, &app); err != nil {
+		return nil, fmt.Errorf("failed to get app: %w", err)
+	}
+	s.redact.app(&app)
+	pods, err := appInfoPods(ctx, s.cfg, app)
+	if apierrors.IsForbidden(err) {
+		pods = &corev1.PodList{}
+	} else if err != nil {
+		return nil, err
+	}
+	info := generateAppInfoOutput(app, pods, nil)
+	return &info, nil
+}
+
+// authorize tells if the request may change apps, it writes an error response if it may not.
+func (s *apiServer) authorize(w http.ResponseWriter, r *http.Request) bool {
+	if s.token == "" {
+		writeAPIError(w, http.StatusForbidden, fmt.Errorf("changes of apps are disabled, the server runs without --token-file"))
+		return false
+	}
+	if !s.validToken(r) {
+		writeAPIError(w, http.StatusUnauthorized, fmt.Errorf("invalid token"))
+		return false
+	}
+	return true
+}
+
+// decodeRequest reads the JSON body of an authorized request, it writes an error response if it can't.
+func (s *apiServer) decodeRequest(w http.ResponseWriter, r *http.Request, kind string, v interface{}) bool {
+	if !s.authorize(w, r) {
+		return false
+	}
+	decoder := json.NewDecoder(io.LimitReader(r.Body, serverMaxBodySize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		writeAPIError(w, http.StatusBadRequest, fmt.Errorf("invalid %s request: %w", kind, err))
+		return false
+	}
+	return true
+}
+
+func (s *apiServer) createApp(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	var req ketchclient.CreateAppRequest
+	if !s.decodeRequest(w, r, "create", &req) {
+		return
+	}
+	if err := s.client.CreateApp(ctx, req); err != nil {
+		writeAPIError(w, apiStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, apiResponse{Message: fmt.Sprintf("Successfully created %s", req.Name)})
+}
+
+func (s *apiServer) setEnv(ctx context.Context, w http.ResponseWriter, r *http.Request, name string) {
+	var req ketchclient.SetEnvRequest
+	if !s.decodeRequest(w, r, "env", &req) {
+		return
+	}
+	req.App = name
+	if err := s.client.SetEnv(ctx, req); err != nil {
+		writeAPIError(w, apiStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, apiResponse{Message: fmt.Sprintf("Successfully updated env variables of %s", name)})
+}
+
+func (s *apiServer) scale(ctx context.Context, w http.ResponseWriter, r *http.Request, name string) {
+	var req ketchclient.ScaleRequest
+	if !s.decodeRequest(w, r, "units", &req) {
+		return
+	}
+	req.App = name
+	if err := s.client.Scale(ctx, req); err != nil {
+		writeAPIError(w, apiStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, apiResponse{Message: fmt.Sprintf("Successfully set units of %s of %s to %d", req.Process, name, req.Units)})
+}
+
+func (s *apiServer) deploy(ctx context.Context, w http.ResponseWriter, r *http.Request, name string) {
+	var application deploy.Application
+	if !s.decodeRequest(w, r, "deploy", &application) {
+		return
+	}
+	if application.Name != nil && *application.Name != name {
+		writeAPIError(w, http.StatusBadRequest, fmt.Errorf("invalid deploy request: name %q doesn't match app %q", *application.Name, name))
+		return
+	}
+	application.Name = &name
+	response, err := s.deployApplication(ctx, application)
+	if err != nil {
+		writeAPIError(w, apiStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// deployApplication deploys an image to the app named by the application, like "ketch apply" does.
+func (s *apiServer) deployApplication(ctx context.Context, application deploy.Application) (*apiResponse, error) {
+	if application.Name == nil || *application.Name == "" {
+		return nil, fmt.Errorf("%w: name is required", ketchclient.ErrInvalidRequest)
+	}
+	if application.Image == nil || *application.Image == "" {
+		return nil, fmt.Errorf("%w: image is required", ketchclient.ErrInvalidRequest)
+	}
+	name := *application.Name
+	if application.Namespace == nil {
+		// a deploy of an existing app keeps its namespace.
+		app := ketchv1.App{}
+		if err := s.cfg.Client().Get(ctx, types.NamespacedName{Name: name}, &app); err == nil {
+			application.Namespace = &app.Spec.Namespace
+		}
+	}
+	deployOptions := deploy.Options{}
+	changeSet, err := deployOptions.GetChangeSetFromApplication(application)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ketchclient.ErrInvalidRequest, err)
+	}
+	var out bytes.Buffer
+	svc := *s.svc
+	svc.Writer = &out
+	if err := deploy.New(changeSet).Run(ctx, &svc); err != nil {
+		return nil, err
+	}
+	return &apiResponse{
+		Message: fmt.Sprintf("Successfully deployed %s to %s", *application.Image, name),
+		Output:  out.String(),
+	}, nil
+}
+
+// apiStatus returns the HTTP status of an error reading or changing the cluster.
+func apiStatus(err error) int {
+	switch {
+	case errors.Is(err, ketchclient.ErrInvalidRequest):
+		return http.StatusBadRequest
+	case apierrors.IsNotFound(err):
+		return http.StatusNotFound
+	case apierrors.IsForbidden(err):
+		return http.StatusForbidden
+	case apierrors.IsInvalid(err), apierrors.IsBadRequest(err):
+		return http.StatusBadRequest
+	case apierrors.IsConflict(err), apierrors.IsAlreadyExists(err):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(v)
+}
+
+func writeAPIError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, apiError{Error: err.Error()})
+}
+
+var serverPageTemplate = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="10">
+<title>{{ .Title }}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { text-align: left; padding: 0.3em 1em; border-bottom: 1px solid #ddd; }
+.error { color: #b00; }
+</style>
+</head>
+<body>
+<h1>{{ .Title }}</h1>
+{{- if .Error }}
+<p class="error">{{ .Error }}</p>
+{{- else if .Apps }}
+<table>
+<tr><th>NAME</th><th>NAMESPACE</th><th>STATE</th><th>ADDRESSES</th><th>DESCRIPTION</th></tr>
+{{- range .Apps }}
+<tr><td><a href="/apps/{{ .Name }}">{{ .Name }}</a></td><td>{{ .Namespace }}</td><td>{{ .State }}</td><td>{{ .Addresses }}</td><td>{{ .Description }}</td></tr>
+{{- end }}
+</table>
+{{- else if .Deployments }}
+<p><a href="/">All apps</a></p>
+<table>
+<tr><th>VERSION</th><th>IMAGE</th><th>PROCESS</th><th>WEIGHT</th><th>STATE</th><th>CMD</th></tr>
+{{- range .Deployments }}
+<tr><td>{{ .DeploymentVersion }}</td><td>{{ .Image }}</td><td>{{ .ProcessName }}</td><td>{{ .Weight }}</td><td>{{ .State }}</td><td>{{ .Cmd }}</td></tr>
+{{- end }}
+</table>
+{{- else }}
+<p>No apps found.</p>
+{{- end }}
+</body>
+</html>
+`))
+
+type serverPage struct {
+	Title       string
+	Error       string
+	Apps        []appListOutput
+	Deployments []deploymentOutput
+}
+
+func (s *apiServer) handleAppsPage(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), serverRequestTimeout)
+	defer cancel()
+	page := serverPage{Title: "Apps"}
+	apps, err := dashboardApps(ctx, s.cfg)
+	if err != nil {
+		page.Error = err.Error()
+	}
+	page.Apps = apps
+	writePage(w, page)
+}
+
+func (s *apiServer) handleAppPage(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/apps/")
+	if name == "" || strings.Contains(name, "/") {
+		http.NotFound(w, r)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), serverRequestTimeout)
+	defer cancel()
+	page := serverPage{Title: fmt.Sprintf("App %s", name)}
+	deployments, err := dashboardProcesses(ctx, s.cfg, name)
+	if err != nil {
+		page.Error = err.Error()
+	}
+	page.Deployments = deployments
+	writePage(w, page)
+}
+
+func writePage(w http.ResponseWriter, page serverPage) {
+	var buf bytes.Buffer
+	if err := serverPageTemplate.Execute(&buf, page); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(buf.Bytes())
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"github.com/theketchio/ketch/internal/deploy"
+	"github.com/theketchio/ketch/pkg/ketchclient"
+)
+
+// appsServiceName is the name of the gRPC service of "ketch server", apps.proto describes it.
+const appsServiceName = "ketch.v1.Apps"
+
+type grpcServerFn func(ctx context.Context, address string, server *grpc.Server) error
+
+// serveGRPC runs the gRPC server until ctx is done.
+func serveGRPC(ctx context.Context, address string, server *grpc.Server) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.Serve(listener)
+	}()
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+		server.GracefulStop()
+		return nil
+	}
+}
+
+// appsServer is the interface of the gRPC service, requests and responses are google.protobuf.Struct messages
+// with the fields of the requests of the HTTP API.
+type appsServer interface {
+	CreateApp(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error)
+	Deploy(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error)
+	SetEnv(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error)
+	Scale(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error)
+}
+
+var appsServiceDesc = grpc.ServiceDesc{
+	ServiceName: appsServiceName,
+	HandlerType: (*appsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "CreateApp", Handler: appsHandler("CreateApp", appsServer.CreateApp)},
+		{MethodName: "Deploy", Handler: appsHandler("Deploy", appsServer.Deploy)},
+		{MethodName: "SetEnv", Handler: appsHandler("SetEnv", appsServer.SetEnv)},
+		{MethodName: "Scale", Handler: appsHandler("Scale", appsServer.Scale)},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "apps.proto",
+}
+
+// appsHandler returns a handler of a unary method of the service, calls go through the server's interceptor.
+func appsHandler(name string, method func(appsServer, context.Context, *structpb.Struct) (*structpb.Struct, error)) func(interface{}, context.Context, func(interface{}) error, grpc.UnaryServerInterceptor) (interface{}, error) {
+	return func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+		in := &structpb.Struct{}
+		if err := dec(in); err != nil {
+			return nil, err
+		}
+		if interceptor == nil {
+			return method(srv.(appsServer), ctx, in)
+		}
+		info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + appsServiceName + "/" + name}
+		return interceptor(ctx, in, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return method(srv.(appsServer), ctx, req.(*structpb.Struct))
+		})
+	}
+}
+
+// newGRPCServer returns a gRPC server of the service changing apps with the same token as the HTTP API.
+func newGRPCServer(s *apiServer) *grpc.Server {
+	server := grpc.NewServer(grpc.UnaryInterceptor(s.authorizeGRPC))
+	server.RegisterService(&appsServiceDesc, &grpcAppsServer{api: s})
+	return server
+}
+
+// authorizeGRPC rejects calls without the token, all methods of the service change apps.
+func (s *apiServer) authorizeGRPC(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if s.token == "" {
+		return nil, status.Error(codes.PermissionDenied, "changes of apps are disabled, the server runs without --token-file")
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	var token string
+	if values := md.Get("authorization"); len(values) > 0 {
+		token = strings.TrimPrefix(values[0], "Bearer ")
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+	ctx, cancel := context.WithTimeout(ctx, serverRequestTimeout)
+	defer cancel()
+	return handler(ctx, req)
+}
+
+// grpcAppsServer implements the gRPC service with the clients of the HTTP API.
+type grpcAppsServer struct {
+	api *apiServer
+}
+
+func (g *grpcAppsServer) CreateApp(ctx context.Context, in *structpb.Struct) (*structpb.Struct, error) {
+	var req ketchclient.CreateAppRequest
+	if err := decodeStruct(in, "create", &req); err != nil {
+		return nil, err
+	}
+	if err := g.api.client.CreateApp(ctx, req); err != nil {
+		return nil, grpcError(err)
+	}
+	return responseStruct(apiResponse{Message: fmt.Sprintf("Successfully created %s", req.Name)})
+}
+
+// Deploy deploys an image, the request has fields of "ketch apply" apps.
+func (g *grpcAppsServer) Deploy(ctx context.Context, in *structpb.Struct) (*structpb.Struct, error) {
+	var application deploy.Application
+	if err := decodeStruct(in, "deploy", &application); err != nil {
+		return nil, err
+	}
+	response, err := g.api.deployApplication(ctx, application)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return responseStruct(*response)
+}
+
+func (g *grpcAppsServer) SetEnv(ctx context.Context, in *structpb.Struct) (*structpb.Struct, error) {
+	var req ketchclient.SetEnvRequest
+	if err := decodeStruct(in, "env", &req); err != nil {
+		return nil, err
+	}
+	if err := g.api.client.SetEnv(ctx, req); err != nil {
+		return nil, grpcError(err)
+	}
+	return responseStruct(apiResponse{Message: fmt.Sprintf("Successfully updated env variables of %s", req.App)})
+}
+
+func (g *grpcAppsServer) Scale(ctx context.Context, in *structpb.Struct) (*structpb.Struct, error) {
+	var req ketchclient.ScaleRequest
+	if err := decodeStruct(in, "units", &req); err != nil {
+		return nil, err
+	}
+	if err := g.api.client.Scale(ctx, req); err != nil {
+		return nil, grpcError(err)
+	}
+	return responseStruct(apiResponse{Message: fmt.Sprintf("Successfully set units of %s of %s to %d", req.Process, req.App, req.Units)})
+}
+
+// decodeStruct reads a request message into v the way the HTTP API reads request bodies.
+func decodeStruct(in *structpb.Struct, kind string, v interface{}) error {
+	data, err := protojson.Marshal(in)
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "invalid %s request: %v", kind, err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return status.Errorf(codes.InvalidArgument, "invalid %s request: %v", kind, err)
+	}
+	return nil
+}
+
+func responseStruct(response apiResponse) (*structpb.Struct, error) {
+	fields := map[string]interface{}{"message": response.Message}
+	if response.Output != "" {
+		fields["output"] = response.Output
+	}
+	return structpb.NewStruct(fields)
+}
+
+// grpcError returns a gRPC status of an error reading or changing the cluster.
+func grpcError(err error) error {
+	code := codes.Internal
+	switch {
+	case errors.Is(err, ketchclient.ErrInvalidRequest):
+		code = codes.InvalidArgument
+	case apierrors.IsNotFound(err):
+		code = codes.NotFound
+	case apierrors.IsForbidden(err):
+		code = codes.PermissionDenied
+	case apierrors.IsInvalid(err), apierrors.IsBadRequest(err):
+		code = codes.InvalidArgument
+	case apierrors.IsAlreadyExists(err):
+		code = codes.AlreadyExists
+	case apierrors.IsConflict(err):
+		code = codes.Aborted
+	}
+	return status.Error(code, err.Error())
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/structpb"
+	"k8s.io/apimachinery/pkg/types"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+)
+
+func TestServerGRPC(t *testing.T) {
+	tests := []struct {
+		name        string
+		token       string
+		sendToken   string
+		method      string
+		request     map[string]interface{}
+		wantCode    codes.Code
+		wantMessage string
+		check       func(t *testing.T, app ketchv1.App)
+	}{
+		{
+			name:      "changes are disabled without a token",
+			sendToken: "s3cr3t",
+			method:    "SetEnv",
+			request:   map[string]interface{}{"app": "hello", "env": map[string]interface{}{"DEBUG": "true"}},
+			wantCode:  codes.PermissionDenied,
+		},
+		{
+			name:      "invalid token",
+			token:     "s3cr3t",
+			sendToken: "guess",
+			method:    "SetEnv",
+			request:   map[string]interface{}{"app": "hello", "env": map[string]interface{}{"DEBUG": "true"}},
+			wantCode:  codes.Unauthenticated,
+		},
+		{
+			name:        "create an app",
+			token:       "s3cr3t",
+			sendToken:   "s3cr3t",
+			method:      "CreateApp",
+			request:     map[string]interface{}{"name": "api", "namespace": "apps"},
+			wantMessage: "Successfully created api",
+		},
+		{
+			name:      "create an existing app",
+			token:     "s3cr3t",
+			sendToken: "s3cr3t",
+			method:    "CreateApp",
+			request:   map[string]interface{}{"name": "hello", "namespace": "apps"},
+			wantCode:  codes.AlreadyExists,
+		},
+		{
+			name:        "deploy",
+			token:       "s3cr3t",
+			sendToken:   "s3cr3t",
+			method:      "Deploy",
+			request:     map[string]interface{}{"name": "hello", "image": "shipa/hello:v2"},
+			wantMessage: "Successfully deployed shipa/hello:v2 to hello",
+			check: func(t *testing.T, app ketchv1.App) {
+				require.Equal(t, "shipa/hello:v2", app.Spec.Deployments[len(app.Spec.Deployments)-1].Image)
+			},
+		},
+		{
+			name:      "deploy without an image",
+			token:     "s3cr3t",
+			sendToken: "s3cr3t",
+			method:    "Deploy",
+			request:   map[string]interface{}{"name": "hello"},
+			wantCode:  codes.InvalidArgument,
+		},
+		{
+			name:        "set env",
+			token:       "s3cr3t",
+			sendToken:   "s3cr3t",
+			method:      "SetEnv",
+			request:     map[string]interface{}{"app": "hello", "env": map[string]interface{}{"DEBUG": "true"}},
+			wantMessage: "Successfully updated env variables of hello",
+			check: func(t *testing.T, app ketchv1.App) {
+				require.Equal(t, ketchv1.Env{Name: "DEBUG", Value: "true"}, app.Spec.Env[1])
+			},
+		},
+		{
+			name:      "set env of an unknown app",
+			token:     "s3cr3t",
+			sendToken: "s3cr3t",
+			method:    "SetEnv",
+			request:   map[string]interface{}{"app": "unknown", "env": map[string]interface{}{"DEBUG": "true"}},
+			wantCode:  codes.NotFound,
+		},
+		{
+			name:        "scale",
+			token:       "s3cr3t",
+			sendToken:   "s3cr3t",
+			method:      "Scale",
+			request:     map[string]interface{}{"app": "hello", "process": "worker", "deploymentVersion": 2, "units": 4},
+			wantMessage: "Successfully set units of worker of hello to 4",
+			check: func(t *testing.T, app ketchv1.App) {
+				require.Equal(t, 4, *app.Spec.Deployments[0].Processes[1].Units)
+			},
+		},
+		{
+			name:      "unknown field",
+			token:     "s3cr3t",
+			sendToken: "s3cr3t",
+			method:    "Scale",
+			request:   map[string]interface{}{"app": "hello", "replicas": 4},
+			wantCode:  codes.InvalidArgument,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, cfg := serverTestAPI(tt.token, false)
+			listener := bufconn.Listen(1 << 20)
+			server := newGRPCServer(s)
+			go server.Serve(listener)
+			defer server.Stop()
+
+			ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+tt.sendToken)
+			conn, err := grpc.DialContext(ctx, "bufnet",
+				grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+				grpc.WithTransportCredentials(insecure.NewCredentials()))
+			require.Nil(t, err)
+			defer conn.Close()
+
+			in, err := structpb.NewStruct(tt.request)
+			require.Nil(t, err)
+			out := &structpb.Struct{}
+			err = conn.Invoke(ctx, "/"+appsServiceName+"/"+tt.method, in, out)
+			require.Equal(t, tt.wantCode, status.Code(err), err)
+			if tt.wantCode != codes.OK {
+				return
+			}
+			require.Equal(t, tt.wantMessage, out.Fields["message"].GetStringValue())
+			if tt.check == nil {
+				return
+			}
+			app := ketchv1.App{}
+			require.Nil(t, cfg.Client().Get(context.Background(), types.NamespacedName{Name: "hello"}, &app))
+			tt.check(t, app)
+		})
+	}
+}
@@ -0,0 +1,392 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/deploy"
+	"github.com/theketchio/ketch/internal/mocks"
+)
+
+func serverTestHandler(token string, allowAnonymousRead bool) (http.Handler, *mocks.Configuration) {
+	s, cfg := serverTestAPI(token, allowAnonymousRead)
+	return s.handler(), cfg
+}
+
+func serverTestAPI(token string, allowAnonymousRead bool) (*apiServer, *mocks.Configuration) {
+	app := unitTestApp()
+	app.Spec.Env = []ketchv1.Env{{Name: "DATABASE_PASSWORD", Value: "secret"}}
+	ingressConfigmap := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: ketchv1.IngressConfigmapName, Namespace: ketchv1.IngressConfigmapNamespace},
+		Data:       map[string]string{"className": "nginx", "serviceEndpoint": "10.10.10.10", "ingressType": "nginx"},
+	}
+	cfg := &mocks.Configuration{
+		CtrlClientObjects: []runtime.Object{app, ingressConfigmap.DeepCopy()},
+		KubeClientObjects: []runtime.Object{ingressConfigmap.DeepCopy()},
+	}
+	svc := &deploy.Services{
+		Client:         cfg.Client(),
+		KubeClient:     cfg.KubernetesClient(),
+		GetImageConfig: getImageConfig,
+		Templates:      staticTemplates{},
+	}
+	return newAPIServer(cfg, svc, token, allowAnonymousRead), cfg
+}
+
+func serverRequest(handler http.Handler, method, path, token, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func TestServerReadAPI(t *testing.T) {
+	handler, _ := serverTestHandler("", true)
+
+	w := serverRequest(handler, http.MethodGet, "/api/v1/apps", "", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	var apps []appListOutput
+	require.Nil(t, json.Unmarshal(w.Body.Bytes(), &apps))
+	require.Len(t, apps, 1)
+	require.Equal(t, "hello", apps[0].Name)
+
+	w = serverRequest(handler, http.MethodGet, "/api/v1/apps/hello", "", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var info appInfoOutput
+	require.Nil(t, json.Unmarshal(w.Body.Bytes(), &info))
+	require.Equal(t, "hello", info.AppInfoContext.App.Name)
+	require.Equal(t, redactedValue, info.AppInfoContext.App.Spec.Env[0].Value)
+	require.Len(t, info.Deployments, 2)
+
+	w = serverRequest(handler, http.MethodGet, "/api/v1/apps/hello/deployments", "", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var deployments []deploymentOutput
+	require.Nil(t, json.Unmarshal(w.Body.Bytes(), &deployments))
+	require.Equal(t, "web", deployments[0].ProcessName)
+	require.Equal(t, "worker", deployments[1].ProcessName)
+
+	w = serverRequest(handler, http.MethodGet, "/api/v1/apps/unknown", "", "")
+	require.Equal(t, http.StatusNotFound, w.Code)
+	require.Contains(t, w.Body.String(), `"error": "failed to get app`)
+
+	w = serverRequest(handler, http.MethodDelete, "/api/v1/apps/hello", "", "")
+	require.Equal(t, http.StatusMethodNotAllowed, w.Code)
+
+	w = serverRequest(handler, http.MethodGet, "/api/v1/apps/hello/pods", "", "")
+	require.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestServerReadAuthorization(t *testing.T) {
+	handler, _ := serverTestHandler("s3cr3t", false)
+
+	w := serverRequest(handler, http.MethodGet, "/api/v1/apps", "", "")
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	require.Contains(t, w.Body.String(), "invalid token")
+
+	w = serverRequest(handler, http.MethodGet, "/apps/hello", "guess", "")
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = serverRequest(handler, http.MethodGet, "/api/v1/apps/hello", "s3cr3t", "")
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = serverRequest(handler, http.MethodGet, "/", "s3cr3t", "")
+	require.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestServerDeploy(t *testing.T) {
+	tests := []struct {
+		name       string
+		token      string
+		sendToken  string
+		body       string
+		wantStatus int
+		wantBody   string
+		wantImage  string
+	}{
+		{
+			name:       "deploys are disabled without a token",
+			body:       `{"image": "shipa/hello:v2"}`,
+			wantStatus: http.StatusForbidden,
+			wantBody:   "changes of apps are disabled",
+		},
+		{
+			name:       "invalid token",
+			token:      "s3cr3t",
+			sendToken:  "guess",
+			body:       `{"image": "shipa/hello:v2"}`,
+			wantStatus: http.StatusUnauthorized,
+			wantBody:   "invalid token",
+		},
+		{
+			name:       "missing image",
+			token:      "s3cr3t",
+			sendToken:  "s3cr3t",
+			body:       `{"description": "hello"}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "image is required",
+		},
+		{
+			name:       "unknown field",
+			token:      "s3cr3t",
+			sendToken:  "s3cr3t",
+			body:       `{"image": "shipa/hello:v2", "replicas": 3}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "unknown field",
+		},
+		{
+			name:       "name of another app",
+			token:      "s3cr3t",
+			sendToken:  "s3cr3t",
+			body:       `{"name": "dashboard", "image": "shipa/hello:v2"}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `name \"dashboard\" doesn't match app \"hello\"`,
+		},
+		{
+			name:       "deploy",
+			token:      "s3cr3t",
+			sendToken:  "s3cr3t",
+			body:       `{"image": "shipa/hello:v2"}`,
+			wantStatus: http.StatusOK,
+			wantBody:   "Successfully deployed shipa/hello:v2 to hello",
+			wantImage:  "shipa/hello:v2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, cfg := serverTestHandler(tt.token, false)
+			w := serverRequest(handler, http.MethodPost, "/api/v1/apps/hello/deployments", tt.sendToken, tt.body)
+			require.Equal(t, tt.wantStatus, w.Code, w.Body.String())
+			require.Contains(t, w.Body.String(), tt.wantBody)
+			if tt.wantImage == "" {
+				return
+			}
+			app := ketchv1.App{}
+			require.Nil(t, cfg.Client().Get(context.Background(), types.NamespacedName{Name: "hello"}, &app))
+			require.Equal(t, tt.wantImage, app.Spec.Deployments[len(app.Spec.Deployments)-1].Image)
+		})
+	}
+}
+
+func TestServerChanges(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		token      string
+		body       string
+		wantStatus int
+		wantBody   string
+		check      func(t *testing.T, app ketchv1.App)
+	}{
+		{
+			name:       "create an app",
+			method:     http.MethodPost,
+			path:       "/api/v1/apps",
+			token:      "s3cr3t",
+			body:       `{"name": "api", "namespace": "apps", "team": "payments"}`,
+			wantStatus: http.StatusCreated,
+			wantBody:   "Successfully created api",
+		},
+		{
+			name:       "create an existing app",
+			method:     http.MethodPost,
+			path:       "/api/v1/apps",
+			token:      "s3cr3t",
+			body:       `{"name": "hello", "namespace": "apps"}`,
+			wantStatus: http.StatusConflict,
+			wantBody:   `app \"hello\" already exists`,
+		},
+		{
+			name:       "create an app without a namespace",
+			method:     http.MethodPost,
+			path:       "/api/v1/apps",
+			token:      "s3cr3t",
+			body:       `{"name": "api"}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "namespace of app",
+		},
+		{
+			name:       "create an app without a token",
+			method:     http.MethodPost,
+			path:       "/api/v1/apps",
+			body:       `{"name": "api", "namespace": "apps"}`,
+			wantStatus: http.StatusUnauthorized,
+			wantBody:   "invalid token",
+		},
+		{
+			name:       "set env",
+			method:     http.MethodPut,
+			path:       "/api/v1/apps/hello/env",
+			token:      "s3cr3t",
+			body:       `{"env": {"DEBUG": "true"}}`,
+			wantStatus: http.StatusOK,
+			wantBody:   "Successfully updated env variables of hello",
+			check: func(t *testing.T, app ketchv1.App) {
+				require.Equal(t, ketchv1.Env{Name: "DEBUG", Value: "true"}, app.Spec.Env[1])
+			},
+		},
+		{
+			name:       "set env of an unknown app",
+			method:     http.MethodPut,
+			path:       "/api/v1/apps/unknown/env",
+			token:      "s3cr3t",
+			body:       `{"env": {"DEBUG": "true"}}`,
+			wantStatus: http.StatusNotFound,
+			wantBody:   "failed to get app",
+		},
+		{
+			name:       "set units",
+			method:     http.MethodPut,
+			path:       "/api/v1/apps/hello/units",
+			token:      "s3cr3t",
+			body:       `{"process": "worker", "deploymentVersion": 2, "units": 4}`,
+			wantStatus: http.StatusOK,
+			wantBody:   "Successfully set units of worker of hello to 4",
+			check: func(t *testing.T, app ketchv1.App) {
+				require.Equal(t, 4, *app.Spec.Deployments[0].Processes[1].Units)
+			},
+		},
+		{
+			name:       "set units of an unknown process",
+			method:     http.MethodPut,
+			path:       "/api/v1/apps/hello/units",
+			token:      "s3cr3t",
+			body:       `{"process": "db", "units": 4}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "failed to set units",
+		},
+		{
+			name:       "get units",
+			method:     http.MethodGet,
+			path:       "/api/v1/apps/hello/units",
+			token:      "s3cr3t",
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, cfg := serverTestHandler("s3cr3t", false)
+			w := serverRequest(handler, tt.method, tt.path, tt.token, tt.body)
+			require.Equal(t, tt.wantStatus, w.Code, w.Body.String())
+			require.Contains(t, w.Body.String(), tt.wantBody)
+			if tt.check == nil {
+				return
+			}
+			app := ketchv1.App{}
+			require.Nil(t, cfg.Client().Get(context.Background(), types.NamespacedName{Name: "hello"}, &app))
+			tt.check(t, app)
+		})
+	}
+}
+
+func TestServerPages(t *testing.T) {
+	handler, _ := serverTestHandler("", true)
+
+	w := serverRequest(handler, http.MethodGet, "/", "", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), `<a href="/apps/hello">hello</a>`)
+
+	w = serverRequest(handler, http.MethodGet, "/apps/hello", "", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), "<h1>App hello</h1>")
+	require.Contains(t, w.Body.String(), "<td>worker</td>")
+
+	w = serverRequest(handler, http.MethodGet, "/apps/unknown", "", "")
+	require.Contains(t, w.Body.String(), `<p class="error">failed to get app`)
+
+	w = serverRequest(handler, http.MethodGet, "/favicon.ico", "", "")
+	require.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestNewServerCmd(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.Nil(t, os.WriteFile(tokenFile, []byte("s3cr3t\n"), 0600))
+	emptyFile := filepath.Join(t.TempDir(), "empty")
+	require.Nil(t, os.WriteFile(emptyFile, nil, 0600))
+
+	tests := []struct {
+		name            string
+		args            []string
+		wantAddress     string
+		wantGRPCAddress string
+		wantErr         string
+	}{
+		{
+			name:        "default address",
+			args:        []string{"--token-file", tokenFile},
+			wantAddress: "127.0.0.1:8080",
+		},
+		{
+			name:        "address and anonymous reads",
+			args:        []string{"--address", ":9000", "--allow-anonymous-read"},
+			wantAddress: ":9000",
+		},
+		{
+			name:            "grpc address",
+			args:            []string{"--token-file", tokenFile, "--grpc-address", "127.0.0.1:9090"},
+			wantAddress:     "127.0.0.1:8080",
+			wantGRPCAddress: "127.0.0.1:9090",
+		},
+		{
+			name:    "empty token",
+			args:    []string{"--token-file", emptyFile},
+			wantErr: "is empty",
+		},
+		{
+			name:    "neither token nor anonymous reads",
+			wantErr: "the server requires --token-file or --allow-anonymous-read",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var address string
+			serve := func(ctx context.Context, addr string, handler http.Handler) error {
+				address = addr
+				return nil
+			}
+			var grpcAddress string
+			serveGRPC := func(ctx context.Context, addr string, server *grpc.Server) error {
+				grpcAddress = addr
+				return nil
+			}
+			out := &bytes.Buffer{}
+			cmd := newServerCmd(&mocks.Configuration{}, out, serve, serveGRPC)
+			cmd.SetArgs(append([]string{}, tt.args...))
+			cmd.SetOut(&bytes.Buffer{})
+			cmd.SetErr(&bytes.Buffer{})
+			err := cmd.Execute()
+			if tt.wantErr != "" {
+				require.NotNil(t, err)
+				require.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.wantAddress, address)
+			require.Equal(t, tt.wantGRPCAddress, grpcAddress)
+			wantOut := "Listening on " + tt.wantAddress + "\n"
+			if tt.wantGRPCAddress != "" {
+				wantOut += "Serving gRPC on " + tt.wantGRPCAddress + "\n"
+			}
+			require.Equal(t, wantOut, out.String())
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/theketchio/ketch/cmd/ketch/configuration"
+	"github.com/theketchio/ketch/cmd/ketch/output"
+)
+
+const targetCmdHelp = `
+Manage clusters ketch runs commands against.
+
+A target is a named context of a kubeconfig file stored in config.toml (default path: $HOME/.ketch).
+Commands run against the current target set with "ketch target set", a target passed with --target or KETCH_TARGET,
+the current context of the default kubeconfig is used if there is no target:
+  ketch target add staging --kubeconfig ~/.kube/staging.yaml
+  ketch target add prod --context prod-cluster
+  ketch target set staging
+  ketch app list --target prod
+`
+
+const targetFlag = "target"
+
+func newTargetCmd(ketchConfig configuration.KetchConfig, out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "target",
+		Short: "Manage clusters ketch runs commands against",
+		Long:  targetCmdHelp,
+		Args:  cobra.NoArgs,
+	}
+	cmd.AddCommand(newTargetAddCmd(ketchConfig))
+	cmd.AddCommand(newTargetSetCmd(ketchConfig))
+	cmd.AddCommand(newTargetListCmd(ketchConfig, out))
+	return cmd
+}
+
+func newTargetAddCmd(ketchConfig configuration.KetchConfig) *cobra.Command {
+	var target configuration.Target
+	cmd := &cobra.Command{
+		Use:   "add NAME [--kubeconfig PATH] [--context CONTEXT]",
+		Short: "Add a target",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			target.Name = args[0]
+			return addTarget(ketchConfig, target)
+		},
+	}
+	cmd.Flags().StringVar(&target.Kubeconfig, "kubeconfig", "", "Path to the kubeconfig file of the cluster, the default kubeconfig if not set.")
+	cmd.Flags().StringVar(&target.Context, "context", "", "Context of the kubeconfig, its current context if not set.")
+	return cmd
+}
+
+func newTargetSetCmd(ketchConfig configuration.KetchConfig) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "set NAME",
+		Short: "Set the current target",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return setCurrentTarget(ketchConfig, args[0])
+		},
+	}
+	return cmd
+}
+
+type targetOutput struct {
+	Current    string `column:"CURRENT"`
+	Name       string `column:"NAME"`
+	Kubeconfig string `column:"KUBECONFIG"`
+	Context    string `column:"CONTEXT"`
+}
+
+func newTargetListCmd(ketchConfig configuration.KetchConfig, out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List targets",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			targets := make([]targetOutput, 0, len(ketchConfig.Targets))
+			for _, target := range ketchConfig.Targets {
+				current := ""
+				if target.Name == ketchConfig.CurrentTarget {
+					current = "*"
+				}
+				targets = append(targets, targetOutput{Current: current, Name: target.Name, Kubeconfig: target.Kubeconfig, Context: target.Context})
+			}
+			return output.Write(targets, out, "column")
+		},
+	}
+	return cmd
+}
+
+func addTarget(ketchConfig configuration.KetchConfig, target configuration.Target) error {
+	if _, ok := ketchConfig.Target(target.Name); ok {
+		return fmt.Errorf("target %q already exists", target.Name)
+	}
+	ketchConfig.Targets = append(ketchConfig.Targets, target)
+	path, err := configuration.DefaultConfigPath()
+	if err != nil {
+		return err
+	}
+	return configuration.Write(ketchConfig, path)
+}
+
+func setCurrentTarget(ketchConfig configuration.KetchConfig, name string) error {
+	if _, ok := ketchConfig.Target(name); !ok {
+		return fmt.Errorf("target %q not found", name)
+	}
+	ketchConfig.CurrentTarget = name
+	path, err := configuration.DefaultConfigPath()
+	if err != nil {
+		return err
+	}
+	return configuration.Write(ketchConfig, path)
+}
+
+// resolveTarget returns the target passed with --target, KETCH_TARGET or the current target of the config,
+// it returns false if commands run against the default kubeconfig.
+// Clients are created while commands are built, so --target is read before cobra parses the arguments.
+func resolveTarget(ketchConfig configuration.KetchConfig, args []string) (configuration.Target, bool, error) {
+	flags := pflag.NewFlagSet(targetFlag, pflag.ContinueOnError)
+	flags.ParseErrorsWhitelist.UnknownFlags = true
+	flags.SetOutput(io.Discard)
+	flags.BoolP("help", "h", false, "")
+	name := flags.String(targetFlag, "", "")
+	// errors are reported by cobra when it parses the arguments.
+	_ = flags.Parse(args)
+	// "ketch target" doesn't connect to clusters, it fixes a current target that doesn't exist.
+	if flags.Arg(0) == "target" {
+		return configuration.Target{}, false, nil
+	}
+	if *name == "" {
+		*name = os.Getenv(targetEnv)
+	}
+	if *name == "" {
+		*name = ketchConfig.CurrentTarget
+	}
+	if *name == "" {
+		return configuration.Target{}, false, nil
+	}
+	target, ok := ketchConfig.Target(*name)
+	if !ok {
+		return configuration.Target{}, false, fmt.Errorf("target %q not found", *name)
+	}
+	return target, true, nil
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/theketchio/ketch/cmd/ketch/configuration"
+)
+
+func TestTargetAddSet(t *testing.T) {
+	rootPath := t.TempDir()
+	t.Setenv("KETCH_HOME", rootPath)
+	path := filepath.Join(rootPath, "config.toml")
+
+	staging := configuration.Target{Name: "staging", Kubeconfig: "/home/ketch/.kube/staging.yaml"}
+	require.Nil(t, addTarget(configuration.KetchConfig{DefaultBuilder: "heroku/buildpacks:20"}, staging))
+	ketchConfig := configuration.Read(path)
+	require.Equal(t, []configuration.Target{staging}, ketchConfig.Targets)
+	require.Equal(t, "heroku/buildpacks:20", ketchConfig.DefaultBuilder)
+
+	err := addTarget(ketchConfig, configuration.Target{Name: "staging"})
+	require.Equal(t, `target "staging" already exists`, err.Error())
+
+	prod := configuration.Target{Name: "prod", Context: "prod-cluster"}
+	require.Nil(t, addTarget(ketchConfig, prod))
+	ketchConfig = configuration.Read(path)
+	require.Nil(t, setCurrentTarget(ketchConfig, "prod"))
+	err = setCurrentTarget(ketchConfig, "qa")
+	require.Equal(t, `target "qa" not found`, err.Error())
+
+	ketchConfig = configuration.Read(path)
+	require.Equal(t, []configuration.Target{staging, prod}, ketchConfig.Targets)
+	require.Equal(t, "prod", ketchConfig.CurrentTarget)
+
+	out := &bytes.Buffer{}
+	cmd := newTargetListCmd(ketchConfig, out)
+	cmd.SetArgs([]string{})
+	require.Nil(t, cmd.Execute())
+	require.Equal(t, `CURRENT    NAME       KUBECONFIG                        CONTEXT
+           staging    /home/ketch/.kube/staging.yaml    
+*          prod                                         prod-cluster
+`, out.String())
+}
+
+func TestResolveTarget(t *testing.T) {
+	ketchConfig := configuration.KetchConfig{
+		Targets: []configuration.Target{
+			{Name: "staging", Kubeconfig: "/home/ketch/.kube/staging.yaml"},
+			{Name: "prod", Context: "prod-cluster"},
+		},
+		CurrentTarget: "staging",
+	}
+	tests := []struct {
+		name        string
+		ketchConfig configuration.KetchConfig
+		args        []string
+		env         string
+		want        string
+		wantErr     string
+	}{
+		{
+			name:        "no targets",
+			ketchConfig: configuration.KetchConfig{},
+			args:        []string{"app", "list"},
+		},
+		{
+			name:        "current target",
+			ketchConfig: ketchConfig,
+			args:        []string{"app", "list"},
+			want:        "staging",
+		},
+		{
+			name:        "target flag",
+			ketchConfig: ketchConfig,
+			args:        []string{"app", "deploy", "shop", "-i", "shipa/shop:1.2", "--target", "prod", "--wait"},
+			want:        "prod",
+		},
+		{
+			name:        "target flag with equal sign",
+			ketchConfig: ketchConfig,
+			args:        []string{"--target=prod", "app", "list"},
+			want:        "prod",
+		},
+		{
+			name:        "target env",
+			ketchConfig: ketchConfig,
+			args:        []string{"app", "list"},
+			env:         "prod",
+			want:        "prod",
+		},
+		{
+			name:        "target flag takes precedence over env",
+			ketchConfig: ketchConfig,
+			args:        []string{"app", "list", "--target", "staging"},
+			env:         "prod",
+			want:        "staging",
+		},
+		{
+			name:        "unknown target",
+			ketchConfig: ketchConfig,
+			args:        []string{"app", "list", "--target", "qa"},
+			wantErr:     `target "qa" not found`,
+		},
+		{
+			name:        "target command ignores a missing current target",
+			ketchConfig: configuration.KetchConfig{CurrentTarget: "removed"},
+			args:        []string{"target", "set", "prod"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(targetEnv, tt.env)
+			target, ok, err := resolveTarget(tt.ketchConfig, tt.args)
+			if tt.wantErr != "" {
+				require.Equal(t, tt.wantErr, err.Error())
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.want != "", ok)
+			require.Equal(t, tt.want, target.Name)
+		})
+	}
+}
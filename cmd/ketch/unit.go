@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"k8s.io/api/autoscaling/v2beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+)
+
+func newUnitCmd(cfg config, out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "unit",
+		Short: "Manage units of an application",
+		Long:  "Manage units of an application",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Usage()
+		},
+	}
+	cmd.AddCommand(requireAccess(newUnitAddCmd(cfg, out, unitAdd), appsAccess("update")))
+	cmd.AddCommand(requireAccess(newUnitRemoveCmd(cfg, out, unitRemove), appsAccess("update")))
+	cmd.AddCommand(requireAccess(newUnitSetCmd(cfg, out, unitSet), appsAccess("update")))
+	cmd.AddCommand(newUnitAutoscaleCmd(cfg, out, unitAutoscale))
+	return cmd
+}
+
+type unitOptions struct {
+	appName           string
+	processName       string
+	deploymentVersion int
+	quantity          int
+}
+
+func addUnitFlags(cmd *cobra.Command, cfg config, options *unitOptions) {
+	cmd.Flags().StringVarP(&options.processName, "process", "p", "", "Process name.")
+	registerProcessNameCompletion(cmd, cfg, "process")
+	cmd.Flags().IntVarP(&options.deploymentVersion, "version", "v", 0, "Deployment version.")
+}
+
+// workloadName returns the name of the Deployment or StatefulSet running the given process.
+func workloadName(app ketchv1.App, process string, version ketchv1.DeploymentVersion) string {
+	return fmt.Sprintf("%s-%s-%s", app.Name, process, version)
+}
+
+// autoscaledProcesses returns the names of the processes selected by the given selector
+// that are autoscaled or whose Deployment or StatefulSet is the scale target of a HorizontalPodAutoscaler.
+func autoscaledProcesses(ctx context.Context, cfg config, app ketchv1.App, selector ketchv1.Selector) ([]string, error) {
+	var hpaList v2beta1.HorizontalPodAutoscalerList
+	if err := cfg.Client().List(ctx, &hpaList, client.InNamespace(app.Spec.Namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list horizontal pod autoscalers: %w", err)
+	}
+	targets := map[string]v2beta1.CrossVersionObjectReference{}
+	for _, hpa := range hpaList.Items {
+		targets[hpa.Spec.ScaleTargetRef.Name] = hpa.Spec.ScaleTargetRef
+	}
+	processes := map[string]struct{}{}
+	for _, deployment := range app.Spec.Deployments {
+		if selector.DeploymentVersion != nil && *selector.DeploymentVersion != deployment.Version {
+			continue
+		}
+		for _, process := range deployment.Processes {
+			if selector.Process != nil && *selector.Process != process.Name {
+				continue
+			}
+			target, ok := targets[workloadName(app, process.Name, deployment.Version)]
+			if process.Autoscale != nil || ok && target.Kind == string(app.Spec.ProcessType(deployment, process.Name)) && target.APIVersion == "apps/v1" {
+				processes[process.Name] = struct{}{}
+			}
+		}
+	}
+	names := make([]string, 0, len(processes))
+	for name := range processes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// checkNotAutoscaled returns an error if any of the selected processes is managed by a HorizontalPodAutoscaler.
+// Changing the number of units of such a process is pointless because the autoscaler overrides it.
+func checkNotAutoscaled(ctx context.Context, cfg config, app ketchv1.App, selector ketchv1.Selector) error {
+	processes, err := autoscaledProcesses(ctx, cfg, app, selector)
+	if err != nil {
+		return err
+	}
+	if len(processes) > 0 {
+		return fmt.Errorf("%w: %s, use \"ketch unit autoscale\" to change its scaling bounds",
+			ErrProcessAutoscaled, strings.Join(processes, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+)
+
+const unitAddHelp = `
+Add units to a process of an application.
+If no process is specified, units are added to all processes of the application.
+Processes managed by a horizontal pod autoscaler can't be scaled manually, use "ketch unit autoscale" instead.
+`
+
+type unitAddFn func(context.Context, config, unitOptions, io.Writer) error
+
+func newUnitAddCmd(cfg config, out io.Writer, unitAdd unitAddFn) *cobra.Command {
+	options := unitOptions{}
+	cmd := &cobra.Command{
+		Use:   "add APPNAME QUANTITY",
+		Short: "Add units to a process of an application.",
+		Args:  cobra.ExactArgs(2),
+		Long:  unitAddHelp,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			quantity, err := strconv.Atoi(args[1])
+			if err != nil || quantity <= 0 {
+				return ErrInvalidUnitsQuantity
+			}
+			options.appName = args[0]
+			options.quantity = quantity
+			return unitAdd(cmd.Context(), cfg, options, out)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return autoCompleteAppNames(cfg, toComplete)
+		},
+	}
+	addUnitFlags(cmd, cfg, &options)
+	return cmd
+}
+
+func unitAdd(ctx context.Context, cfg config, options unitOptions, out io.Writer) error {
+	return changeUnits(ctx, cfg, options, out, options.quantity)
+}
+
+// changeUnits adds delta units to the selected processes, a negative delta removes units.
+func changeUnits(ctx context.Context, cfg config, options unitOptions, out io.Writer, delta int) error {
+	app := ketchv1.App{}
+	if err := cfg.Client().Get(ctx, types.NamespacedName{Name: options.appName}, &app); err != nil {
+		return fmt.Errorf("failed to get app: %w", err)
+	}
+	s := ketchv1.NewSelector(options.deploymentVersion, options.processName)
+	if err := checkNotAutoscaled(ctx, cfg, app, s); err != nil {
+		return err
+	}
+	if err := app.AddUnits(s, delta); err != nil {
+		return fmt.Errorf("failed to change units: %w", err)
+	}
+	if err := cfg.Client().Update(ctx, &app); err != nil {
+		return fmt.Errorf("failed to update app: %w", err)
+	}
+	fmt.Fprintln(out, "Successfully updated!")
+	return nil
+}
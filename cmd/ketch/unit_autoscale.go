@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"k8s.io/api/autoscaling/v2beta1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/chart"
+)
+
+const unitAutoscaleHelp = `
+Configure a horizontal pod autoscaler for a process of an application.
+The autoscaler is installed with the application's chart and scales the latest deployment of the application
+unless a deployment version is specified. New deployments keep the autoscaler of the process whatever their image is.
+Use --disable to remove the autoscaler, the process gets back the number of units set with "ketch unit set".
+`
+
+const (
+	// helmReleaseNameAnnotation is set by helm on objects of a release.
+	helmReleaseNameAnnotation = "meta.helm.sh/release-name"
+)
+
+type unitAutoscaleFn func(context.Context, config, unitAutoscaleOptions, io.Writer) error
+
+func newUnitAutoscaleCmd(cfg config, out io.Writer, unitAutoscale unitAutoscaleFn) *cobra.Command {
+	options := unitAutoscaleOptions{}
+	cmd := &cobra.Command{
+		Use:   "autoscale APPNAME",
+		Short: "Configure a horizontal pod autoscaler for a process of an application.",
+		Args:  cobra.ExactArgs(1),
+		Long:  unitAutoscaleHelp,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			options.appName = args[0]
+			if err := options.validate(); err != nil {
+				return err
+			}
+			return unitAutoscale(cmd.Context(), cfg, options, out)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return autoCompleteAppNames(cfg, toComplete)
+		},
+	}
+	cmd.Flags().StringVarP(&options.processName, "process", "p", "", "Process name.")
+	registerProcessNameCompletion(cmd, cfg, "process")
+	cmd.Flags().IntVarP(&options.deploymentVersion, "version", "v", 0, "Deployment version.")
+	cmd.Flags().Int32Var(&options.minUnits, "min", 1, "Minimum number of units.")
+	cmd.Flags().Int32Var(&options.maxUnits, "max", 0, "Maximum number of units.")
+	cmd.Flags().Int32Var(&options.cpuPercent, "cpu-percent", 80, "Target average CPU utilization, as a percentage of the requested CPU.")
+	cmd.Flags().BoolVar(&options.disable, "disable", false, "Remove the autoscaler of the process.")
+	cmd.MarkFlagRequired("process")
+	return cmd
+}
+
+type unitAutoscaleOptions struct {
+	appName           string
+	processName       string
+	deploymentVersion int
+	minUnits          int32
+	maxUnits          int32
+	cpuPercent        int32
+	disable           bool
+}
+
+func (o unitAutoscaleOptions) validate() error {
+	if o.disable {
+		return nil
+	}
+	if o.minUnits < 1 || o.maxUnits < o.minUnits {
+		return ErrInvalidAutoscaleBounds
+	}
+	if o.cpuPercent < 1 {
+		return ErrInvalidAutoscaleCPUPercent
+	}
+	return nil
+}
+
+func unitAutoscale(ctx context.Context, cfg config, options unitAutoscaleOptions, out io.Writer) error {
+	app := ketchv1.App{}
+	if err := cfg.Client().Get(ctx, types.NamespacedName{Name: options.appName}, &app); err != nil {
+		return fmt.Errorf("failed to get app: %w", err)
+	}
+	deployment, err := autoscaleDeployment(app, options.deploymentVersion)
+	if err != nil {
+		return err
+	}
+	process := deploymentProcess(deployment, options.processName)
+	if process == nil {
+		return fmt.Errorf("failed to configure autoscaler: %w", ketchv1.ErrProcessNotFound)
+	}
+	if app.Spec.ProcessType(*deployment, options.processName) == ketchv1.DaemonSetAppType {
+		return fmt.Errorf("failed to configure autoscaler: %w", ErrDaemonSetNotAutoscalable)
+	}
+	// autoscalers created by previous versions of ketch aren't part of the app's chart and block installing the chart's one.
+	if err := deleteLegacyAutoscaler(ctx, cfg, app.Spec.Namespace, workloadName(app, options.processName, deployment.Version)); err != nil {
+		return err
+	}
+
+	if options.disable {
+		if process.Autoscale == nil {
+			fmt.Fprintln(out, "Autoscaler not found, nothing to do.")
+			return nil
+		}
+		process.Autoscale = nil
+		if err := cfg.Client().Update(ctx, &app); err != nil {
+			return fmt.Errorf("failed to update app: %w", err)
+		}
+		fmt.Fprintln(out, "Successfully disabled!")
+		return nil
+	}
+
+	process.Autoscale = &ketchv1.AutoscaleSpec{
+		MinUnits:   options.minUnits,
+		MaxUnits:   options.maxUnits,
+		CPUPercent: options.cpuPercent,
+	}
+	if err := cfg.Client().Update(ctx, &app); err != nil {
+		return fmt.Errorf("failed to update app: %w", err)
+	}
+	fmt.Fprintln(out, "Successfully updated!")
+	return nil
+}
+
+// deleteLegacyAutoscaler removes a HorizontalPodAutoscaler of the workload that was created by "ketch unit autoscale"
+// before autoscaling became part of the process spec, autoscalers installed with the app's chart are kept.
+func deleteLegacyAutoscaler(ctx context.Context, cfg config, namespace, name string) error {
+	hpa := v2beta1.HorizontalPodAutoscaler{}
+	err := cfg.Client().Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, &hpa)
+	if k8serrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get horizontal pod autoscaler: %w", err)
+	}
+	if _, ok := hpa.Annotations[helmReleaseNameAnnotation]; ok {
+		return nil
+	}
+	for _, field := range hpa.ManagedFields {
+		if field.Manager == chart.FieldManager {
+			return nil
+		}
+	}
+	if err := cfg.Client().Delete(ctx, &hpa); err != nil && !k8serrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete horizontal pod autoscaler: %w", err)
+	}
+	return nil
+}
+
+// autoscaleDeployment returns the deployment with the given version or the latest deployment if version is 0.
+func autoscaleDeployment(app ketchv1.App, version int) (*ketchv1.AppDeploymentSpec, error) {
+	if len(app.Spec.Deployments) == 0 {
+		return nil, fmt.Errorf("failed to configure autoscaler: %w", ketchv1.ErrDeploymentNotFound)
+	}
+	if version == 0 {
+		return &app.Spec.Deployments[len(app.Spec.Deployments)-1], nil
+	}
+	for i, deployment := range app.Spec.Deployments {
+		if deployment.Version == ketchv1.DeploymentVersion(version) {
+			return &app.Spec.Deployments[i], nil
+		}
+	}
+	return nil, fmt.Errorf("failed to configure autoscaler: %w", ketchv1.ErrDeploymentNotFound)
+}
+
+func deploymentProcess(deployment *ketchv1.AppDeploymentSpec, process string) *ketchv1.ProcessSpec {
+	for i, p := range deployment.Processes {
+		if p.Name == process {
+			return &deployment.Processes[i]
+		}
+	}
+	return nil
+}
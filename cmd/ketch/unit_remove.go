@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"io"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+const unitRemoveHelp = `
+Remove units from a process of an application.
+If no process is specified, units are removed from all processes of the application.
+Processes managed by a horizontal pod autoscaler can't be scaled manually, use "ketch unit autoscale" instead.
+`
+
+type unitRemoveFn func(context.Context, config, unitOptions, io.Writer) error
+
+func newUnitRemoveCmd(cfg config, out io.Writer, unitRemove unitRemoveFn) *cobra.Command {
+	options := unitOptions{}
+	cmd := &cobra.Command{
+		Use:   "remove APPNAME QUANTITY",
+		Short: "Remove units from a process of an application.",
+		Args:  cobra.ExactArgs(2),
+		Long:  unitRemoveHelp,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			quantity, err := strconv.Atoi(args[1])
+			if err != nil || quantity <= 0 {
+				return ErrInvalidUnitsQuantity
+			}
+			options.appName = args[0]
+			options.quantity = quantity
+			return unitRemove(cmd.Context(), cfg, options, out)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return autoCompleteAppNames(cfg, toComplete)
+		},
+	}
+	addUnitFlags(cmd, cfg, &options)
+	return cmd
+}
+
+func unitRemove(ctx context.Context, cfg config, options unitOptions, out io.Writer) error {
+	return changeUnits(ctx, cfg, options, out, -options.quantity)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+)
+
+const unitSetHelp = `
+Set the number of units of a process of an application.
+If no process is specified, the number of units is set for all processes of the application.
+Processes managed by a horizontal pod autoscaler can't be scaled manually, use "ketch unit autoscale" instead.
+`
+
+type unitSetFn func(context.Context, config, unitOptions, io.Writer) error
+
+func newUnitSetCmd(cfg config, out io.Writer, unitSet unitSetFn) *cobra.Command {
+	options := unitOptions{}
+	cmd := &cobra.Command{
+		Use:   "set APPNAME QUANTITY",
+		Short: "Set the number of units of a process of an application.",
+		Args:  cobra.ExactArgs(2),
+		Long:  unitSetHelp,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			quantity, err := strconv.Atoi(args[1])
+			if err != nil || quantity < 0 {
+				return ErrInvalidUnitsQuantity
+			}
+			options.appName = args[0]
+			options.quantity = quantity
+			return unitSet(cmd.Context(), cfg, options, out)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return autoCompleteAppNames(cfg, toComplete)
+		},
+	}
+	addUnitFlags(cmd, cfg, &options)
+	return cmd
+}
+
+func unitSet(ctx context.Context, cfg config, options unitOptions, out io.Writer) error {
+	app := ketchv1.App{}
+	if err := cfg.Client().Get(ctx, types.NamespacedName{Name: options.appName}, &app); err != nil {
+		return fmt.Errorf("failed to get app: %w", err)
+	}
+	s := ketchv1.NewSelector(options.deploymentVersion, options.processName)
+	if err := checkNotAutoscaled(ctx, cfg, app, s); err != nil {
+		return err
+	}
+	if err := app.SetUnits(s, options.quantity); err != nil {
+		return fmt.Errorf("failed to set units: %w", err)
+	}
+	if err := cfg.Client().Update(ctx, &app); err != nil {
+		return fmt.Errorf("failed to update app: %w", err)
+	}
+	fmt.Fprintln(out, "Successfully updated!")
+	return nil
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/require"
+	"k8s.io/api/autoscaling/v2beta1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/mocks"
+	"github.com/theketchio/ketch/internal/utils/conversions"
+)
+
+func unitTestApp() *ketchv1.App {
+	return &ketchv1.App{
+		ObjectMeta: metav1.ObjectMeta{Name: "hello"},
+		Spec: ketchv1.AppSpec{
+			Namespace: "ketch-hello",
+			Deployments: []ketchv1.AppDeploymentSpec{
+				{
+					Version: 2,
+					Processes: []ketchv1.ProcessSpec{
+						{Name: "web", Units: conversions.IntPtr(2)},
+						{Name: "worker", Units: conversions.IntPtr(1)},
+					},
+				},
+			},
+		},
+	}
+}
+
+func unitTestDaemonSetApp() *ketchv1.App {
+	app := unitTestApp()
+	app.Spec.Deployments[0].KetchYaml = &ketchv1.KetchYamlData{
+		Kubernetes: &ketchv1.KetchYamlKubernetesConfig{
+			Processes: map[string]ketchv1.KetchYamlProcessConfig{
+				"worker": {Kind: "daemonset"},
+			},
+		},
+	}
+	return app
+}
+
+func unitTestAutoscaledApp() *ketchv1.App {
+	app := unitTestApp()
+	app.Spec.Deployments[0].Processes[0].Autoscale = &ketchv1.AutoscaleSpec{MinUnits: 2, MaxUnits: 6, CPUPercent: 70}
+	return app
+}
+
+func unitTestHPA() *v2beta1.HorizontalPodAutoscaler {
+	return &v2beta1.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "hello-web-2", Namespace: "ketch-hello"},
+		Spec: v2beta1.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: v2beta1.CrossVersionObjectReference{
+				Kind:       "Deployment",
+				Name:       "hello-web-2",
+				APIVersion: "apps/v1",
+			},
+			MaxReplicas: 5,
+		},
+	}
+}
+
+func TestUnitAddCmd(t *testing.T) {
+	pflag.CommandLine = pflag.NewFlagSet("ketch", pflag.ExitOnError)
+
+	tt := []struct {
+		description string
+		args        []string
+		unitAdd     unitAddFn
+		wantErr     bool
+	}{
+		{
+			description: "happy path",
+			args:        []string{"ketch", "myapp", "3", "-p", "web", "-v", "2"},
+			unitAdd: func(_ context.Context, _ config, opts unitOptions, _ io.Writer) error {
+				require.Equal(t, "myapp", opts.appName)
+				require.Equal(t, "web", opts.processName)
+				require.Equal(t, 2, opts.deploymentVersion)
+				require.Equal(t, 3, opts.quantity)
+				return nil
+			},
+		},
+		{
+			description: "invalid quantity",
+			args:        []string{"ketch", "myapp", "many"},
+			wantErr:     true,
+		},
+		{
+			description: "zero quantity",
+			args:        []string{"ketch", "myapp", "0"},
+			wantErr:     true,
+		},
+		{
+			description: "missing quantity",
+			args:        []string{"ketch", "myapp"},
+			wantErr:     true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.description, func(t *testing.T) {
+			os.Args = tc.args
+			cmd := newUnitAddCmd(nil, nil, tc.unitAdd)
+			err := cmd.Execute()
+			if tc.wantErr {
+				require.NotNil(t, err)
+				return
+			}
+			require.Nil(t, err)
+		})
+	}
+}
+
+func TestUnitChange(t *testing.T) {
+	tests := []struct {
+		name      string
+		objects   []runtime.Object
+		options   unitOptions
+		change    func(context.Context, config, unitOptions, io.Writer) error
+		wantUnits map[string]int
+		wantErr   string
+	}{
+		{
+			name:      "add units to a process",
+			objects:   []runtime.Object{unitTestApp()},
+			options:   unitOptions{appName: "hello", processName: "worker", quantity: 2},
+			change:    unitAdd,
+			wantUnits: map[string]int{"web": 2, "worker": 3},
+		},
+		{
+			name:      "remove units from all processes",
+			objects:   []runtime.Object{unitTestApp()},
+			options:   unitOptions{appName: "hello", quantity: 1},
+			change:    unitRemove,
+			wantUnits: map[string]int{"web": 1, "worker": 0},
+		},
+		{
+			name:      "set units",
+			objects:   []runtime.Object{unitTestApp()},
+			options:   unitOptions{appName: "hello", processName: "web", quantity: 4},
+			change:    unitSet,
+			wantUnits: map[string]int{"web": 4, "worker": 1},
+		},
+		{
+			name:      "autoscaled process is not touched",
+			objects:   []runtime.Object{unitTestApp(), unitTestHPA()},
+			options:   unitOptions{appName: "hello", processName: "worker", quantity: 4},
+			change:    unitSet,
+			wantUnits: map[string]int{"web": 2, "worker": 4},
+		},
+		{
+			name:    "error - process is autoscaled",
+			objects: []runtime.Object{unitTestApp(), unitTestHPA()},
+			options: unitOptions{appName: "hello", quantity: 1},
+			change:  unitAdd,
+			wantErr: `units of a process managed by a horizontal pod autoscaler can't be changed manually: web, use "ketch unit autoscale" to change its scaling bounds`,
+		},
+		{
+			name:    "error - process is autoscaled by its spec",
+			objects: []runtime.Object{unitTestAutoscaledApp()},
+			options: unitOptions{appName: "hello", processName: "web", quantity: 3},
+			change:  unitSet,
+			wantErr: `units of a process managed by a horizontal pod autoscaler can't be changed manually: web, use "ketch unit autoscale" to change its scaling bounds`,
+		},
+		{
+			name:    "error - negative units",
+			objects: []runtime.Object{unitTestApp()},
+			options: unitOptions{appName: "hello", processName: "worker", quantity: 2},
+			change:  unitRemove,
+			wantErr: "failed to change units: the number of units can't be negative",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &mocks.Configuration{CtrlClientObjects: tt.objects}
+			out := &bytes.Buffer{}
+			err := tt.change(context.Background(), cfg, tt.options, out)
+			if len(tt.wantErr) > 0 {
+				require.NotNil(t, err)
+				require.Equal(t, tt.wantErr, err.Error())
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, "Successfully updated!\n", out.String())
+
+			app := ketchv1.App{}
+			require.Nil(t, cfg.Client().Get(context.Background(), types.NamespacedName{Name: "hello"}, &app))
+			units := map[string]int{}
+			for _, p := range app.Spec.Deployments[0].Processes {
+				units[p.Name] = *p.Units
+			}
+			require.Equal(t, tt.wantUnits, units)
+		})
+	}
+}
+
+func TestUnitAutoscale(t *testing.T) {
+	tests := []struct {
+		name          string
+		objects       []runtime.Object
+		options       unitAutoscaleOptions
+		wantAutoscale *ketchv1.AutoscaleSpec
+		wantOut       string
+		wantErr       string
+	}{
+		{
+			name:          "create autoscaler",
+			objects:       []runtime.Object{unitTestApp()},
+			options:       unitAutoscaleOptions{appName: "hello", processName: "web", minUnits: 2, maxUnits: 6, cpuPercent: 70},
+			wantAutoscale: &ketchv1.AutoscaleSpec{MinUnits: 2, MaxUnits: 6, CPUPercent: 70},
+			wantOut:       "Successfully updated!\n",
+		},
+		{
+			name:          "update autoscaler",
+			objects:       []runtime.Object{unitTestAutoscaledApp()},
+			options:       unitAutoscaleOptions{appName: "hello", processName: "web", minUnits: 1, maxUnits: 10, cpuPercent: 70},
+			wantAutoscale: &ketchv1.AutoscaleSpec{MinUnits: 1, MaxUnits: 10, CPUPercent: 70},
+			wantOut:       "Successfully updated!\n",
+		},
+		{
+			name:          "autoscaler created by a previous version is replaced",
+			objects:       []runtime.Object{unitTestApp(), unitTestHPA()},
+			options:       unitAutoscaleOptions{appName: "hello", processName: "web", minUnits: 1, maxUnits: 10, cpuPercent: 70},
+			wantAutoscale: &ketchv1.AutoscaleSpec{MinUnits: 1, MaxUnits: 10, CPUPercent: 70},
+			wantOut:       "Successfully updated!\n",
+		},
+		{
+			name:    "disable autoscaler",
+			objects: []runtime.Object{unitTestAutoscaledApp()},
+			options: unitAutoscaleOptions{appName: "hello", processName: "web", disable: true},
+			wantOut: "Successfully disabled!\n",
+		},
+		{
+			name:    "disable missing autoscaler",
+			objects: []runtime.Object{unitTestApp()},
+			options: unitAutoscaleOptions{appName: "hello", processName: "web", disable: true},
+			wantOut: "Autoscaler not found, nothing to do.\n",
+		},
+		{
+			name:    "error - process not found",
+			objects: []runtime.Object{unitTestApp()},
+			options: unitAutoscaleOptions{appName: "hello", processName: "db", minUnits: 1, maxUnits: 2, cpuPercent: 70},
+			wantErr: "failed to configure autoscaler: process not found",
+		},
+		{
+			name:    "error - deployment not found",
+			objects: []runtime.Object{unitTestApp()},
+			options: unitAutoscaleOptions{appName: "hello", processName: "web", deploymentVersion: 5, minUnits: 1, maxUnits: 2, cpuPercent: 70},
+			wantErr: "failed to configure autoscaler: deployment not found",
+		},
+		{
+			name:    "error - daemonset process",
+			objects: []runtime.Object{unitTestDaemonSetApp()},
+			options: unitAutoscaleOptions{appName: "hello", processName: "worker", minUnits: 1, maxUnits: 2, cpuPercent: 70},
+			wantErr: "failed to configure autoscaler: a daemonset process runs one unit per node and can't be autoscaled",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &mocks.Configuration{CtrlClientObjects: tt.objects}
+			out := &bytes.Buffer{}
+			err := unitAutoscale(context.Background(), cfg, tt.options, out)
+			if len(tt.wantErr) > 0 {
+				require.NotNil(t, err)
+				require.Equal(t, tt.wantErr, err.Error())
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.wantOut, out.String())
+
+			app := ketchv1.App{}
+			require.Nil(t, cfg.Client().Get(context.Background(), types.NamespacedName{Name: "hello"}, &app))
+			require.Equal(t, tt.wantAutoscale, app.Spec.Deployments[0].Processes[0].Autoscale)
+
+			hpa := v2beta1.HorizontalPodAutoscaler{}
+			err = cfg.Client().Get(context.Background(), types.NamespacedName{Namespace: "ketch-hello", Name: "hello-web-2"}, &hpa)
+			require.True(t, k8serrors.IsNotFound(err))
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+)
+
+const (
+	watchFlag  = "watch"
+	watchUsage = "Keep watching the cluster and refresh the output when apps or their pods change."
+
+	// clearScreen moves the cursor to the top left corner and clears the terminal,
+	// so a refreshed view replaces the previous one.
+	clearScreen = "\033[H\033[2J"
+)
+
+// watchRefreshInterval is the minimum time between two refreshes of a view,
+// a rollout changes pods many times a second and a view is refreshed once for all of them.
+var watchRefreshInterval = 500 * time.Millisecond
+
+// watchFn starts a watch of objects a view shows.
+type watchFn func(ctx context.Context) (watch.Interface, error)
+
+// renderFn writes a view.
+type renderFn func(ctx context.Context, out io.Writer) error
+
+// appsResource returns the resource of apps watched with the dynamic client.
+func appsResource() schema.GroupVersionResource {
+	return schema.GroupVersionResource{Group: ketchv1.Group, Version: "v1beta1", Resource: "apps"}
+}
+
+// watchApps returns a watch of apps matching the field selector, an empty selector watches all apps.
+func watchApps(cfg config, fieldSelector string) watchFn {
+	return func(ctx context.Context) (watch.Interface, error) {
+		return cfg.DynamicClient().Resource(appsResource()).Watch(ctx, metav1.ListOptions{FieldSelector: fieldSelector})
+	}
+}
+
+// watchPods returns a watch of pods of the namespace matching the label selector, an empty namespace watches all namespaces.
+func watchPods(cfg config, namespace, labelSelector string) watchFn {
+	return func(ctx context.Context) (watch.Interface, error) {
+		return cfg.KubernetesClient().CoreV1().Pods(namespace).Watch(ctx, metav1.ListOptions{LabelSelector: labelSelector})
+	}
+}
+
+// watchView renders the view, then renders it again every time watched objects change until ctx is done,
+// like "kubectl get -w" but refreshing the whole view.
+func watchView(ctx context.Context, out io.Writer, render renderFn, watches ...watchFn) error {
+	changes := make(chan struct{}, 1)
+	for _, w := range watches {
+		go keepWatching(ctx, w, changes)
+	}
+	for {
+		var buf bytes.Buffer
+		if err := render(ctx, &buf); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "%s%s", clearScreen, buf.String())
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-changes:
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(watchRefreshInterval):
+		}
+	}
+}
+
+// keepWatching notifies about changes of watched objects until ctx is done, watches closed by the cluster are started again.
+// A watch the user isn't allowed to start, e.g. of pods of another team, leaves the view to the other watches.
+func keepWatching(ctx context.Context, w watchFn, changes chan<- struct{}) {
+	for {
+		watcher, err := w(ctx)
+		if apierrors.IsForbidden(err) {
+			return
+		}
+		if err == nil {
+			if !forwardChanges(ctx, watcher, changes) {
+				return
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(watchRefreshInterval):
+		}
+	}
+}
+
+// forwardChanges notifies about events of the watch, it returns false once ctx is done.
+func forwardChanges(ctx context.Context, watcher watch.Interface, changes chan<- struct{}) bool {
+	defer watcher.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case _, ok := <-watcher.ResultChan():
+			if !ok {
+				return true
+			}
+			select {
+			case changes <- struct{}{}:
+			default:
+				// a refresh is pending already.
+			}
+		}
+	}
+}
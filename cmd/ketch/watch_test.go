@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/mocks"
+)
+
+func Test_watchView(t *testing.T) {
+	original := watchRefreshInterval
+	watchRefreshInterval = time.Millisecond
+	defer func() {
+		watchRefreshInterval = original
+	}()
+
+	pods := watch.NewFake()
+	forbidden := func(ctx context.Context) (watch.Interface, error) {
+		return nil, apierrors.NewForbidden(schema.GroupResource{Resource: "pods"}, "", fmt.Errorf("forbidden"))
+	}
+	renders := make(chan int)
+	count := 0
+	render := func(ctx context.Context, out io.Writer) error {
+		count++
+		fmt.Fprintf(out, "render %d\n", count)
+		renders <- count
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	out := &bytes.Buffer{}
+	done := make(chan error)
+	go func() {
+		done <- watchView(ctx, out, render, func(ctx context.Context) (watch.Interface, error) { return pods, nil }, forbidden)
+	}()
+	require.Equal(t, 1, <-renders)
+	pods.Modify(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "dashboard-web-1"}})
+	require.Equal(t, 2, <-renders)
+	cancel()
+	require.Nil(t, <-done)
+	require.Equal(t, clearScreen+"render 1\n"+clearScreen+"render 2\n", out.String())
+}
+
+func Test_watchViewRenderError(t *testing.T) {
+	render := func(ctx context.Context, out io.Writer) error {
+		return fmt.Errorf("failed to get app")
+	}
+	err := watchView(context.Background(), &bytes.Buffer{}, render)
+	require.EqualError(t, err, "failed to get app")
+}
+
+func Test_appInfoWatch(t *testing.T) {
+	dashboard := &ketchv1.App{
+		ObjectMeta: metav1.ObjectMeta{Name: "dashboard"},
+		Spec:       ketchv1.AppSpec{Namespace: "gke"},
+	}
+	cfg := &mocks.Configuration{CtrlClientObjects: []runtime.Object{dashboard}}
+
+	// a cancelled watch renders the app's info once.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	out := &bytes.Buffer{}
+	require.Nil(t, appInfoWatch(ctx, cfg, appInfoOptions{name: "dashboard"}, out))
+	require.True(t, strings.HasPrefix(out.String(), clearScreen+"Application: dashboard\n"))
+
+	err := appInfoWatch(ctx, cfg, appInfoOptions{name: "unknown"}, out)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "failed to get app")
+}
@@ -21,6 +21,7 @@ import (
 	"flag"
 	"fmt"
 	"math"
+	"net"
 	"os"
 	"time"
 
@@ -36,8 +37,10 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	ketchv1beta2 "github.com/theketchio/ketch/internal/api/v1beta2"
 	"github.com/theketchio/ketch/internal/chart"
 	"github.com/theketchio/ketch/internal/controllers"
+	"github.com/theketchio/ketch/internal/provisioner"
 	"github.com/theketchio/ketch/internal/templates"
 	"github.com/theketchio/ketch/internal/watchers"
 	// +kubebuilder:scaffold:imports
@@ -55,6 +58,9 @@ func main() {
 	var disableWebhooks bool
 	var group string
 	var namespace string
+	var shard string
+	var helmStorage chart.HelmStorage
+	var options controllers.ControllerOptions
 	flag.StringVar(&metricsAddr, "metrics-addr", ":8080", "The address the metric endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "enable-leader-election", true,
 		"Enable leader election for controller manager. "+
@@ -62,20 +68,44 @@ func main() {
 	flag.BoolVar(&disableWebhooks, "disable-webhooks", false, "Disable webhooks.")
 	flag.StringVar(&group, "group", ketchv1.TheKetchGroup, "specify a non-default group")
 	flag.StringVar(&namespace, "namespace", controllers.KetchNamespace, "specify a non-default namespace")
+	flag.IntVar(&options.MaxConcurrentReconciles, "max-concurrent-reconciles", 1, "Number of apps and jobs each controller reconciles in parallel.")
+	flag.DurationVar(&options.BaseRetryDelay, "rate-limiter-base-delay", 5*time.Millisecond, "Delay before the first retry of a failed reconcile, it doubles with each failure.")
+	flag.DurationVar(&options.MaxRetryDelay, "rate-limiter-max-delay", 1000*time.Second, "Maximum delay between retries of a failed reconcile.")
+	flag.Float64Var(&options.QPS, "rate-limiter-qps", 10, "Overall rate of reconciles per second of each controller.")
+	flag.IntVar(&options.Burst, "rate-limiter-burst", 100, "Burst of reconciles of each controller above the rate.")
+	flag.StringVar(&helmStorage.Driver, "helm-driver", "", "Storage of helm releases of apps and jobs, either \"secret\" or \"configmap\". Defaults to HELM_DRIVER or \"secret\".")
+	flag.StringVar(&helmStorage.Namespace, "helm-storage-namespace", "", "Namespace of helm releases of apps and jobs, the namespace of each app or job if it's empty. "+
+		"Changing it or --helm-driver makes the controller install apps again under new releases.")
+	flag.IntVar(&helmStorage.MaxHistory, "helm-max-history", chart.DefaultMaxHistory, "Number of helm releases kept per app or job including the latest one, older releases are removed on upgrade.")
+	flag.StringVar(&shard, "shard", "", "Reconcile only apps and jobs labeled with <group>/shard=<shard>, "+
+		"\"ketch app deploy --shard\" and \"ketch job deploy --shard\" set the label. Apps and jobs without the label are reconciled only by a controller without a shard, "+
+		"so one controller must run without --shard. Controllers of different shards run in parallel.")
 	flag.Parse()
 
 	_ = clientgoscheme.AddToScheme(scheme)
 	_ = ketchv1.AddToScheme(ketchv1.WithGroup(group))(scheme)
+	_ = ketchv1beta2.AddToScheme(group)(scheme)
 	// +kubebuilder:scaffold:scheme
 
 	ctrl.SetLogger(zap.New(zap.UseDevMode(true)))
 
+	if err := helmStorage.Validate(); err != nil {
+		setupLog.Error(err, "invalid helm storage")
+		os.Exit(1)
+	}
+
+	newCache, err := controllers.NewCache(group, shard)
+	if err != nil {
+		setupLog.Error(err, "unable to configure cache")
+		os.Exit(1)
+	}
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:             scheme,
+		NewCache:           newCache,
 		MetricsBindAddress: metricsAddr,
 		Port:               9443,
 		LeaderElection:     enableLeaderElection,
-		LeaderElectionID:   "dcbf0335.theketch.io",
+		LeaderElectionID:   leaderElectionID(shard),
 	})
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
@@ -125,7 +155,10 @@ func main() {
 	eventBroadcaster.StartLogging(func(format string, args ...interface{}) { logg.Info(fmt.Sprintf(format, args...)) })
 	eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: clientSet.CoreV1().Events("")})
 
-	factory := chart.NewHelmClientFactory()
+	factory := chart.NewHelmClientFactory(helmStorage)
+	applyFactoryFn := func(namespace string) (controllers.Helm, error) {
+		return chart.NewApplyClient(namespace, mgr.GetClient(), logg), nil
+	}
 
 	if err = (&controllers.AppReconciler{
 		TemplateReader: storage,
@@ -141,8 +174,14 @@ func main() {
 			Component: "ketch-controller",
 		},
 		),
-		Config:    ctrl.GetConfigOrDie(),
-		CancelMap: controllers.NewCancelMap(),
+		Config:         ctrl.GetConfigOrDie(),
+		CancelMap:      controllers.NewCancelMap(),
+		Notifier:       controllers.NewHTTPNotifier(mgr.GetClient(), logg.WithName("notifier")),
+		Resolver:       net.DefaultResolver,
+		Provisioners:   provisioner.Default(mgr.GetClient()),
+		Options:        options,
+		ApplyFactoryFn: applyFactoryFn,
+		HelmStorage:    helmStorage,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "App")
 		os.Exit(1)
@@ -159,6 +198,8 @@ func main() {
 		Recorder: eventBroadcaster.NewRecorder(clientgoscheme.Scheme, v1.EventSource{
 			Component: "ketch-controller",
 		}),
+		Options:        options,
+		ApplyFactoryFn: applyFactoryFn,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Job")
 		os.Exit(1)
@@ -169,6 +210,10 @@ func main() {
 			setupLog.Error(err, "unable to create webhook", "webhook", "Job")
 			os.Exit(1)
 		}
+		if err = (&ketchv1.App{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "App")
+			os.Exit(1)
+		}
 	}
 	// +kubebuilder:scaffold:builder
 
@@ -186,3 +231,11 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// leaderElectionID returns the id of the leader election lock of the shard, so each shard elects its own leader.
+func leaderElectionID(shard string) string {
+	if shard == "" {
+		return "dcbf0335.theketch.io"
+	}
+	return fmt.Sprintf("dcbf0335-%s.theketch.io", shard)
+}
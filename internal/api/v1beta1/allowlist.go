@@ -0,0 +1,25 @@
+package v1beta1
+
+import (
+	"fmt"
+	"net"
+)
+
+// ValidateCIDRs returns an error if one of the CIDRs isn't valid, e.g. "10.0.0.0/8".
+func ValidateCIDRs(cidrs []string) error {
+	for _, cidr := range cidrs {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return fmt.Errorf("invalid cidr %q", cidr)
+		}
+	}
+	return nil
+}
+
+// CnameAllowedCIDRs returns CIDRs of clients allowed to reach the cname, nil if all clients are allowed.
+// CIDRs of the cname take precedence over CIDRs of the app.
+func (s IngressSpec) CnameAllowedCIDRs(cname Cname) []string {
+	if len(cname.AllowedCIDRs) > 0 {
+		return cname.AllowedCIDRs
+	}
+	return s.AllowedCIDRs
+}
@@ -0,0 +1,19 @@
+package v1beta1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateCIDRs(t *testing.T) {
+	require.Nil(t, ValidateCIDRs([]string{"10.0.0.0/8", "192.168.1.10/32", "2001:db8::/32"}))
+	require.EqualError(t, ValidateCIDRs([]string{"10.0.0.0/8", "192.168.1.10"}), `invalid cidr "192.168.1.10"`)
+}
+
+func TestIngressSpec_CnameAllowedCIDRs(t *testing.T) {
+	spec := IngressSpec{AllowedCIDRs: []string{"10.0.0.0/8"}}
+	require.Equal(t, []string{"10.0.0.0/8"}, spec.CnameAllowedCIDRs(Cname{Name: "theketch.io"}))
+	require.Equal(t, []string{"192.168.0.0/16"}, spec.CnameAllowedCIDRs(Cname{Name: "theketch.io", AllowedCIDRs: []string{"192.168.0.0/16"}}))
+	require.Nil(t, IngressSpec{}.CnameAllowedCIDRs(Cname{Name: "theketch.io"}))
+}
@@ -7,3 +7,9 @@ import "fmt"
 func DontUninstallHelmChartAnnotation(group string) string {
 	return fmt.Sprintf("%s/dont-uninstall-helm-chart", group)
 }
+
+// DeleteVolumesAnnotation returns an annotation that makes ketch-controller
+// remove persistent volume claims of an Application when the application is removed.
+func DeleteVolumesAnnotation(group string) string {
+	return fmt.Sprintf("%s/delete-volumes", group)
+}
@@ -0,0 +1,4 @@
+package v1beta1
+
+// Hub marks v1beta1 as the version apps are stored in, apps of other versions are converted to and from it.
+func (*App) Hub() {}
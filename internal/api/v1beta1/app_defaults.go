@@ -0,0 +1,96 @@
+package v1beta1
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/yaml"
+)
+
+// AppDefaultsKey is a key of the ingress configmap's data with cluster-wide defaults of apps in yaml.
+const AppDefaultsKey = "appDefaults"
+
+// AppDefaults are applied to apps when they are created or updated, so the defaults are stored in the apps' specs.
+// A default never overrides a value set by an app.
+type AppDefaults struct {
+	// Resources are resource requests and limits of processes, a process gets the requests and limits it doesn't set.
+	Resources *v1.ResourceRequirements `json:"resources,omitempty"`
+	// SecurityContext is used by processes without a security context.
+	SecurityContext *v1.SecurityContext `json:"securityContext,omitempty"`
+	// Labels are added to pods of apps unless an app sets a pod label with the same key.
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// ParseAppDefaults parses AppDefaults from yaml, an empty string means there are no defaults.
+func ParseAppDefaults(data string) (*AppDefaults, error) {
+	defaults := &AppDefaults{}
+	if err := yaml.UnmarshalStrict([]byte(data), defaults); err != nil {
+		return nil, fmt.Errorf("failed to parse app defaults: %w", err)
+	}
+	return defaults, nil
+}
+
+// Apply sets the defaults to the app.
+func (d AppDefaults) Apply(app *App) {
+	for i := range app.Spec.Deployments {
+		for j := range app.Spec.Deployments[i].Processes {
+			process := &app.Spec.Deployments[i].Processes[j]
+			if d.Resources != nil {
+				if process.Resources == nil {
+					process.Resources = &v1.ResourceRequirements{}
+				}
+				resources := process.Resources
+				// a default is skipped if it conflicts with a request or a limit of the process.
+				resources.Limits = mergeResources(resources.Limits, d.Resources.Limits, func(name v1.ResourceName, limit resource.Quantity) bool {
+					request, ok := resources.Requests[name]
+					return !ok || request.Cmp(limit) <= 0
+				})
+				resources.Requests = mergeResources(resources.Requests, d.Resources.Requests, func(name v1.ResourceName, request resource.Quantity) bool {
+					limit, ok := resources.Limits[name]
+					return !ok || request.Cmp(limit) <= 0
+				})
+			}
+			if d.SecurityContext != nil && process.SecurityContext == nil {
+				process.SecurityContext = d.SecurityContext.DeepCopy()
+			}
+		}
+	}
+	podTarget := Target{APIVersion: "v1", Kind: "Pod"}
+	labels := map[string]string{}
+	for key, value := range d.Labels {
+		if !app.hasLabel(key, podTarget) {
+			labels[key] = value
+		}
+	}
+	if len(labels) > 0 {
+		app.Spec.Labels = append(app.Spec.Labels, MetadataItem{Target: podTarget, Apply: labels})
+	}
+}
+
+// hasLabel returns true if the app applies a label with the key to all objects of the target.
+func (app *App) hasLabel(key string, target Target) bool {
+	for _, item := range app.Spec.Labels {
+		if item.Target != target || item.DeploymentVersion != 0 || item.ProcessName != "" {
+			continue
+		}
+		if _, ok := item.Apply[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeResources returns resources with quantities from defaults that resources don't set and that fit.
+func mergeResources(resources, defaults v1.ResourceList, fits func(v1.ResourceName, resource.Quantity) bool) v1.ResourceList {
+	for name, quantity := range defaults {
+		if _, ok := resources[name]; ok || !fits(name, quantity) {
+			continue
+		}
+		if resources == nil {
+			resources = v1.ResourceList{}
+		}
+		resources[name] = quantity.DeepCopy()
+	}
+	return resources
+}
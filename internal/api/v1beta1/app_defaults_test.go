@@ -0,0 +1,95 @@
+package v1beta1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestParseAppDefaults(t *testing.T) {
+	defaults, err := ParseAppDefaults(`
+resources:
+  requests:
+    cpu: 100m
+securityContext:
+  runAsNonRoot: true
+labels:
+  team: platform
+`)
+	require.Nil(t, err)
+	runAsNonRoot := true
+	require.Equal(t, &AppDefaults{
+		Resources:       &v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("100m")}},
+		SecurityContext: &v1.SecurityContext{RunAsNonRoot: &runAsNonRoot},
+		Labels:          map[string]string{"team": "platform"},
+	}, defaults)
+
+	defaults, err = ParseAppDefaults("")
+	require.Nil(t, err)
+	require.Equal(t, &AppDefaults{}, defaults)
+
+	_, err = ParseAppDefaults("replicas: 2")
+	require.NotNil(t, err)
+}
+
+func TestAppDefaults_Apply(t *testing.T) {
+	runAsNonRoot := true
+	runAsUser := int64(1000)
+	defaults := AppDefaults{
+		Resources: &v1.ResourceRequirements{
+			Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("100m"), v1.ResourceMemory: resource.MustParse("256Mi")},
+			Limits:   v1.ResourceList{v1.ResourceMemory: resource.MustParse("512Mi")},
+		},
+		SecurityContext: &v1.SecurityContext{RunAsNonRoot: &runAsNonRoot},
+		Labels:          map[string]string{"team": "platform", "tier": "backend"},
+	}
+	podTarget := Target{APIVersion: "v1", Kind: "Pod"}
+	app := &App{
+		Spec: AppSpec{
+			Deployments: []AppDeploymentSpec{
+				{
+					Processes: []ProcessSpec{
+						{Name: "web"},
+						{
+							Name: "worker",
+							Resources: &v1.ResourceRequirements{
+								Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")},
+								Limits:   v1.ResourceList{v1.ResourceMemory: resource.MustParse("128Mi")},
+							},
+							SecurityContext: &v1.SecurityContext{RunAsUser: &runAsUser},
+						},
+					},
+				},
+			},
+			Labels: []MetadataItem{{Target: podTarget, Apply: map[string]string{"team": "payments"}}},
+		},
+	}
+	defaults.Apply(app)
+
+	web := app.Spec.Deployments[0].Processes[0]
+	require.Equal(t, &v1.ResourceRequirements{
+		Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("100m"), v1.ResourceMemory: resource.MustParse("256Mi")},
+		Limits:   v1.ResourceList{v1.ResourceMemory: resource.MustParse("512Mi")},
+	}, web.Resources)
+	require.Equal(t, &v1.SecurityContext{RunAsNonRoot: &runAsNonRoot}, web.SecurityContext)
+
+	// values of the process are kept and the default memory request doesn't fit the process' limit.
+	worker := app.Spec.Deployments[0].Processes[1]
+	require.Equal(t, &v1.ResourceRequirements{
+		Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")},
+		Limits:   v1.ResourceList{v1.ResourceMemory: resource.MustParse("128Mi")},
+	}, worker.Resources)
+	require.Equal(t, &v1.SecurityContext{RunAsUser: &runAsUser}, worker.SecurityContext)
+
+	require.Equal(t, []MetadataItem{
+		{Target: podTarget, Apply: map[string]string{"team": "payments"}},
+		{Target: podTarget, Apply: map[string]string{"tier": "backend"}},
+	}, app.Spec.Labels)
+
+	// applying defaults again changes nothing.
+	applied := app.DeepCopy()
+	defaults.Apply(app)
+	require.Equal(t, applied, app)
+}
@@ -46,7 +46,32 @@ type Env struct {
 	Name string `json:"name"`
 
 	// Value of the environment variable.
-	Value string `json:"value"`
+	Value string `json:"value,omitempty"`
+
+	// ValueFrom references a key of a secret or a configmap, or a field of the pod, to read the value from.
+	// Cannot be used if value is not empty.
+	ValueFrom *v1.EnvVarSource `json:"valueFrom,omitempty"`
+
+	// Sensitive marks the variable as a secret, ketch CLI masks its value unless asked to show secrets.
+	Sensitive bool `json:"sensitive,omitempty"`
+}
+
+// DisplayValue returns the value of the variable or a description of its source if the value is read from elsewhere,
+// e.g. "<secret db-credentials/password>".
+func (e Env) DisplayValue() string {
+	switch {
+	case e.ValueFrom == nil:
+		return e.Value
+	case e.ValueFrom.SecretKeyRef != nil:
+		return fmt.Sprintf("<secret %s/%s>", e.ValueFrom.SecretKeyRef.Name, e.ValueFrom.SecretKeyRef.Key)
+	case e.ValueFrom.ConfigMapKeyRef != nil:
+		return fmt.Sprintf("<configmap %s/%s>", e.ValueFrom.ConfigMapKeyRef.Name, e.ValueFrom.ConfigMapKeyRef.Key)
+	case e.ValueFrom.FieldRef != nil:
+		return fmt.Sprintf("<field %s>", e.ValueFrom.FieldRef.FieldPath)
+	case e.ValueFrom.ResourceFieldRef != nil:
+		return fmt.Sprintf("<resource %s>", e.ValueFrom.ResourceFieldRef.Resource)
+	}
+	return e.Value
 }
 
 // Label represents an environment variable present in an application.
@@ -64,11 +89,69 @@ type CnameList []Cname
 
 // Cname represents a DNS record and whether the record use TLS.
 type Cname struct {
+	// Name is a hostname of the cname, it can be a wildcard like "*.example.com".
 	Name   string `json:"name"`
 	Secure bool   `json:"secure"`
+	// Path is a path prefix like "/api".
+	// If set, only requests with the prefix are routed to the app, so several apps can share one hostname.
+	Path string `json:"path,omitempty"`
 	// SecretName if provided must contain an SSL certificate that will be used to serve this cname.
 	// Currently, the secret must be in the app's namespace.
 	SecretName string `json:"secretName,omitempty"`
+	// Primary marks the cname as the canonical address of the application.
+	// At most one cname of an application is primary.
+	Primary bool `json:"primary,omitempty"`
+	// DNS overrides external-dns settings of the cluster for the cname.
+	DNS *CnameDNS `json:"dns,omitempty"`
+	// Auth protects the cname, it takes precedence over the auth of the app.
+	Auth *AuthSpec `json:"auth,omitempty"`
+	// AllowedCIDRs restricts clients of the cname to the CIDRs, they take precedence over CIDRs of the app.
+	AllowedCIDRs []string `json:"allowedCIDRs,omitempty"`
+}
+
+// Find returns the cname with the given address or nil if there is no such cname.
+func (list CnameList) Find(address string) *Cname {
+	for i := range list {
+		if list[i].Address() == address {
+			return &list[i]
+		}
+	}
+	return nil
+}
+
+// Primary returns the cname marked as primary or nil if there is no such cname.
+func (list CnameList) Primary() *Cname {
+	for i := range list {
+		if list[i].Primary {
+			return &list[i]
+		}
+	}
+	return nil
+}
+
+// SetPrimary marks the cname with the given address as primary and unmarks all others.
+func (list CnameList) SetPrimary(address string) {
+	for i := range list {
+		list[i].Primary = list[i].Address() == address
+	}
+}
+
+// NeedsClusterIssuer returns true if a certificate of the cname must be obtained by cert-manager.
+func (c Cname) NeedsClusterIssuer() bool {
+	return c.Secure && len(c.SecretName) == 0
+}
+
+// Address returns the hostname of the cname followed by its path prefix, e.g. "example.com/api".
+func (c Cname) Address() string {
+	return c.Name + c.Path
+}
+
+// URL returns the address of the cname including its scheme.
+func (c Cname) URL() string {
+	if c.Secure {
+		return fmt.Sprintf("https://%s", c.Address())
+	}
+	return fmt.Sprintf("http://%s", c.Address())
 }
 
 // RoutingSettings contains a weight of the current deployment used to route incoming traffic.
@@ -76,6 +159,10 @@ type Cname struct {
 // then 3 of 10 incoming requests will be sent to the first deployment (approximately).
 type RoutingSettings struct {
 	Weight uint8 `json:"weight"`
+	// MirrorPercentage of requests to the app is copied to the deployment if it's a shadow deployment without traffic of its own,
+	// responses of the shadow deployment are discarded.
+	// +kubebuilder:validation:Maximum=100
+	MirrorPercentage uint8 `json:"mirrorPercentage,omitempty"`
 }
 
 // ProcessSpec is a specification of the desired behavior of a process.
@@ -87,12 +174,20 @@ type ProcessSpec struct {
 	// Units is a number of replicas of the process.
 	Units *int `json:"units,omitempty"`
 
+	// StoppedUnits is a number of replicas the process had when it was stopped, starting the process restores them.
+	// It's meaningful only while the process has no units.
+	StoppedUnits *int `json:"stoppedUnits,omitempty"`
+
 	// Env is a list of environment variables to set in pods created for the process.
 	Env []Env `json:"env,omitempty"`
 
 	// Commands executed on startup.
 	Cmd []string `json:"cmd"`
 
+	// Image runs the process instead of the image of the deployment,
+	// it's set for processes built from their own directory of the source code.
+	Image string `json:"image,omitempty"`
+
 	Resources *v1.ResourceRequirements `json:"resources,omitempty"`
 
 	Volumes []v1.Volume `json:"volumes,omitempty"`
@@ -100,6 +195,20 @@ type ProcessSpec struct {
 	VolumeMounts []v1.VolumeMount `json:"volumeMounts,omitempty"`
 	// Security options the process should run with.
 	SecurityContext *v1.SecurityContext `json:"securityContext,omitempty"`
+
+	// Autoscale makes a HorizontalPodAutoscaler scale the process instead of Units.
+	Autoscale *AutoscaleSpec `json:"autoscale,omitempty"`
+}
+
+// AutoscaleSpec describes a HorizontalPodAutoscaler scaling a process on its CPU utilization.
+type AutoscaleSpec struct {
+	// +kubebuilder:validation:Minimum=1
+	MinUnits int32 `json:"minUnits"`
+	// +kubebuilder:validation:Minimum=1
+	MaxUnits int32 `json:"maxUnits"`
+	// CPUPercent is the target average CPU utilization, as a percentage of the requested CPU.
+	// +kubebuilder:validation:Minimum=1
+	CPUPercent int32 `json:"cpuPercent"`
 }
 
 type DeploymentVersion int
@@ -119,6 +228,9 @@ type AppDeploymentSpec struct {
 	Labels           []Label                   `json:"labels,omitempty"`
 	RoutingSettings  RoutingSettings           `json:"routingSettings,omitempty"`
 	ExposedPorts     []ExposedPort             `json:"exposedPorts,omitempty"`
+	// ReleaseCmd is the command of the release process of the Procfile.
+	// It runs once before the deployment's processes are updated, a deployment whose release fails gets no traffic.
+	ReleaseCmd []string `json:"releaseCmd,omitempty"`
 }
 
 // IngressSpec configures entrypoints to access an application.
@@ -132,6 +244,26 @@ type IngressSpec struct {
 
 	// Controller is the ingress controller the app is using
 	Controller IngressControllerSpec `json:"controller,omitempty"`
+
+	// ForceHTTPS if set to true, all cnames are served over https and http requests are redirected to https.
+	// Cnames without a secret get certificates from the cluster issuer.
+	// If not set, the default of the ingress controller is used.
+	ForceHTTPS *bool `json:"forceHTTPS,omitempty"`
+
+	// Auth protects all cnames of the application including the default one, unless a cname has an auth of its own.
+	Auth *AuthSpec `json:"auth,omitempty"`
+
+	// AllowedCIDRs restricts clients of all cnames of the application to the CIDRs, e.g. "10.0.0.0/8",
+	// unless a cname has CIDRs of its own. All clients are allowed if it's empty.
+	AllowedCIDRs []string `json:"allowedCIDRs,omitempty"`
+}
+
+// HTTPSForced returns true if http requests to the app's cnames must be redirected to https.
+func (s IngressSpec) HTTPSForced() bool {
+	if s.ForceHTTPS != nil {
+		return *s.ForceHTTPS
+	}
+	return s.Controller.ForceHTTPS
 }
 
 // DockerRegistrySpec contains docker registry configuration of an application.
@@ -139,6 +271,19 @@ type DockerRegistrySpec struct {
 
 	// SecretName is added to the "imagePullSecrets" list of each application pod.
 	SecretName string `json:"secretName,omitempty"`
+
+	// Mirrors are pull-through caches of upstream registries.
+	// Images hosted by a mirrored registry are pulled from its mirror instead.
+	Mirrors []RegistryMirror `json:"mirrors,omitempty"`
+}
+
+// RegistryMirror describes a pull-through cache of a docker registry.
+type RegistryMirror struct {
+	// Registry is the host of the upstream registry, e.g. "docker.io" or "quay.io".
+	Registry string `json:"registry"`
+
+	// Endpoint is the host of the mirror optionally followed by a path prefix, e.g. "cache.example.com/dockerhub".
+	Endpoint string `json:"endpoint"`
 }
 
 // AppPhase is a label for the condition of an application at the current time.
@@ -153,6 +298,12 @@ const (
 
 	// AppRunning means that ketch controller has rendered a helm chart of the application and installed it to a cluster.
 	AppRunning AppPhase = "Running"
+
+	// AppRemoving means the app has been removed and ketch controller is cleaning up its resources.
+	AppRemoving AppPhase = "Removing"
+
+	// AppStopped means all processes of the app have been stopped with "ketch app stop".
+	AppStopped AppPhase = "Stopped"
 )
 
 // AppStatus represents information about the status of an application.
@@ -163,6 +314,91 @@ type AppStatus struct {
 	// +optional
 	// +kubebuilder:pruning:PreserveUnknownFields
 	ExtensionsStatuses []runtime.RawExtension `json:"extensionsStatuses,omitempty"`
+	// Cnames contains results of the latest DNS validation of the app's cnames.
+	Cnames []CnameStatus `json:"cnames,omitempty"`
+	// IngressType is the type of the ingress controller the app's ingress resources were last rendered for.
+	IngressType IngressControllerType `json:"ingressType,omitempty"`
+	// Units is the number of units the app's chart was last installed with.
+	Units *int `json:"units,omitempty"`
+	// DeploymentHistory contains the last deployments of the app, oldest first.
+	DeploymentHistory []DeploymentRecord `json:"deploymentHistory,omitempty"`
+	// Schedules contains statuses of the app's scaling schedules.
+	Schedules []ScheduleStatus `json:"schedules,omitempty"`
+	// ReleaseFailure is set when the release process of the latest deployment fails,
+	// the app isn't updated until its spec changes.
+	ReleaseFailure *ReleaseFailure `json:"releaseFailure,omitempty"`
+	// DeployHooks contains results of the deploy hooks that ran for the latest deployment.
+	DeployHooks []DeployHookStatus `json:"deployHooks,omitempty"`
+	// Dependencies contains statuses of dependencies the latest deployment requires.
+	Dependencies []DependencyStatus `json:"dependencies,omitempty"`
+	// ManifestHash is the hash of the manifests the app's chart was last successfully upgraded with,
+	// upgrades are skipped while it doesn't change.
+	ManifestHash string `json:"manifestHash,omitempty"`
+	// ReleaseRevision is the revision of the app's release after the last successful upgrade,
+	// upgrades aren't skipped once the release is rolled back, uninstalled or changed by something else.
+	ReleaseRevision string `json:"releaseRevision,omitempty"`
+	// DriftedResources contains resources of the app whose live state diverges from the app's chart.
+	DriftedResources []DriftedResource `json:"driftedResources,omitempty"`
+	// Engine installed the app's chart, the app keeps it when the engine of the cluster changes.
+	Engine string `json:"engine,omitempty"`
+}
+
+// DriftedResource is a resource of an app that was edited after ketch-controller installed the app's chart.
+type DriftedResource struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	// Missing is true if the resource was removed.
+	Missing bool `json:"missing,omitempty"`
+	// Fields are paths of fields whose values differ from the app's chart.
+	Fields []string `json:"fields,omitempty"`
+}
+
+// DeployHookStatus is the result of a Job running a deploy hook or the release process of a deployment.
+type DeployHookStatus struct {
+	// Process is either pre-deploy, release or post-deploy.
+	Process string `json:"process"`
+	// Version is the deployment version the hook ran for.
+	Version DeploymentVersion `json:"version"`
+	// Phase is one of Running, Succeeded, Failed or Unknown.
+	Phase       string       `json:"phase"`
+	StartedAt   *metav1.Time `json:"startedAt,omitempty"`
+	CompletedAt *metav1.Time `json:"completedAt,omitempty"`
+}
+
+// ReleaseFailure describes a failed release process of a deployment.
+type ReleaseFailure struct {
+	// Version is the deployment version whose release process failed.
+	Version DeploymentVersion `json:"version"`
+	// Process is the failed process, either pre-deploy or release.
+	Process string `json:"process,omitempty"`
+	// ObservedGeneration is the generation of the app the release process ran for.
+	ObservedGeneration int64 `json:"observedGeneration"`
+	// Message is the error of the release process.
+	Message string `json:"message,omitempty"`
+}
+
+// DeploymentHistoryLimit is the number of deployments kept in the deployment history of an app.
+const DeploymentHistoryLimit = 10
+
+// DeploymentRecord describes a deployment of the app, it contains everything needed to roll the app back to it.
+type DeploymentRecord struct {
+	AppDeploymentSpec `json:",inline"`
+	// KetchYamlHash is a sha256 hash of ketch.yaml of the deployment.
+	KetchYamlHash string `json:"ketchYamlHash,omitempty"`
+	// DeployedAt is the time the deployment was first reconciled.
+	DeployedAt metav1.Time `json:"deployedAt"`
+	// DeployedBy is the user who deployed the app.
+	DeployedBy string `json:"deployedBy,omitempty"`
+}
+
+// CnameStatus shows whether the DNS record of a cname points to the app's ingress controller.
+type CnameStatus struct {
+	Name string `json:"name"`
+	// Valid is true if the cname resolves to the ingress controller's service endpoint.
+	Valid bool `json:"valid"`
+	// Message explains why the cname isn't valid.
+	Message       string      `json:"message,omitempty"`
+	LastCheckTime metav1.Time `json:"lastCheckTime,omitempty"`
 }
 
 // CanarySpec represents configuration for a canary deployment.
@@ -186,6 +422,8 @@ type CanarySpec struct {
 	Started *metav1.Time `json:"started,omitempty"`
 	// Target map of processes and target units value
 	Target map[string]uint16 `json:"target,omitempty"`
+	// Routing if set, requests matching it are routed to the canary deployment regardless of its weight.
+	Routing *CanaryRouting `json:"routing,omitempty"`
 }
 
 // AppSpec defines the desired state of App.
@@ -202,6 +440,15 @@ type AppSpec struct {
 	// +kubebuilder:validation:MaxLength=140
 	Description string `json:"description,omitempty"`
 
+	// Team owning the application, it's added as a "theketch.io/team" label to all resources of the app.
+	// +kubebuilder:validation:MaxLength=63
+	Team string `json:"team,omitempty"`
+
+	// Owner is a person or a group responsible for the application, e.g. a username,
+	// it's added as a "theketch.io/owner" label to all resources of the app.
+	// +kubebuilder:validation:MaxLength=63
+	Owner string `json:"owner,omitempty"`
+
 	// Canary contains a configuration which will be required for canary deployments.
 	Canary CanarySpec `json:"canary,omitempty"`
 
@@ -214,6 +461,10 @@ type AppSpec struct {
 	// List of environment variables of the application.
 	Env []Env `json:"env,omitempty"`
 
+	// EnvSets are names of EnvSets whose environment variables are set in pods of the application,
+	// variables of the application and its processes take precedence, a later set takes precedence over an earlier one.
+	EnvSets []string `json:"envSets,omitempty"`
+
 	// Ingress contains configuration of entrypoints to access the application.
 	Ingress IngressSpec `json:"ingress"`
 
@@ -235,6 +486,15 @@ type AppSpec struct {
 	// Namespace sets the namespace in which the app is run
 	Namespace string `json:"namespace"`
 
+	// NamespaceStrategy defines whether the app runs in its own namespace managed by ketch. Defaults to "shared".
+	NamespaceStrategy NamespaceStrategy `json:"namespaceStrategy,omitempty"`
+
+	// NamespaceQuota limits resources of the app's namespace when NamespaceStrategy is "perApp".
+	NamespaceQuota v1.ResourceList `json:"namespaceQuota,omitempty"`
+
+	// NetworkPolicy configures a NetworkPolicy isolating the app's pods.
+	NetworkPolicy *NetworkPolicySpec `json:"networkPolicy,omitempty"`
+
 	// ServiceAccountName specifies a service account name to be used for this application.
 	ServiceAccountName string `json:"serviceAccountName,omitempty"`
 
@@ -248,19 +508,119 @@ type AppSpec struct {
 	// VolumeClaimTemplates is a list of an app's volumeClaimTemplates
 	VolumeClaimTemplates []PersistentVolumeClaim `json:"volumeClaimTemplates,omitempty"`
 
-	// Type specifies whether an app should be a deployment or a statefulset
+	// Type specifies whether an app should be a deployment, a statefulset or a daemonset
 	// +kubebuilder:validation:default:=Deployment
 	Type *AppType `json:"type,omitempty"`
+
+	// Notifications is a list of receivers notified about deployments, canary releases and removal of the app.
+	Notifications []NotificationSpec `json:"notifications,omitempty"`
+
+	// Schedules change units of processes of the latest deployment at given times.
+	Schedules []ScalingSchedule `json:"schedules,omitempty"`
+
+	// ServiceBindings bind the application to instances of external services, like databases or queues.
+	ServiceBindings []ServiceBinding `json:"serviceBindings,omitempty"`
+
+	// Maintenance if set, the application's cnames are routed to a static maintenance page.
+	Maintenance *MaintenanceSpec `json:"maintenance,omitempty"`
+
+	// Expose is "public" by default, "internal" apps get services but no ingress objects of their cnames,
+	// they are reached only inside the cluster.
+	// +kubebuilder:validation:Enum=public;internal
+	Expose ExposeMode `json:"expose,omitempty"`
+
+	// InternalLoadBalancerAnnotations if set, the service of an internal app becomes a load balancer with these annotations,
+	// e.g. "networking.gke.io/load-balancer-type: Internal", to reach the app from the cloud network.
+	InternalLoadBalancerAnnotations map[string]string `json:"internalLoadBalancerAnnotations,omitempty"`
+}
+
+// NetworkPolicySpec configures a default-deny NetworkPolicy of an app.
+type NetworkPolicySpec struct {
+	// Enabled turns on a NetworkPolicy that accepts incoming traffic of the app's pods
+	// only from the ingress controller and pods of the app itself.
+	// If not set, the default of the ingress controller is used.
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// AllowFromNamespaces is a list of namespaces whose pods are allowed to reach the app's pods too.
+	AllowFromNamespaces []string `json:"allowFromNamespaces,omitempty"`
+}
+
+// NetworkPolicyEnabled returns true if the app's pods must be isolated with a NetworkPolicy.
+func (s AppSpec) NetworkPolicyEnabled() bool {
+	if s.NetworkPolicy != nil && s.NetworkPolicy.Enabled != nil {
+		return *s.NetworkPolicy.Enabled
+	}
+	return s.Ingress.Controller.NetworkPolicy
+}
+
+// DockerRegistryConfig returns the app's docker registry configuration completed with the cluster-wide registry.
+// An app without a secret uses the registry's secret, and cluster-wide mirrors are used unless the app mirrors the registry itself.
+// The mirror of the cluster-wide registry wins over a cluster-wide mirror of the same host.
+func (s AppSpec) DockerRegistryConfig() DockerRegistrySpec {
+	config := DockerRegistrySpec{
+		SecretName: s.DockerRegistry.SecretName,
+		Mirrors:    s.DockerRegistry.Mirrors,
+	}
+	registry := s.Ingress.Controller.Registry
+	if registry == nil {
+		return config
+	}
+	if len(config.SecretName) == 0 {
+		config.SecretName = registry.SecretName
+	}
+	mirrors := registry.Mirrors
+	if len(registry.URL) > 0 && len(registry.Mirror) > 0 {
+		mirrors = append([]RegistryMirror{{Registry: registry.Host(), Endpoint: registry.Mirror}}, mirrors...)
+	}
+	mirrored := make(map[string]bool, len(config.Mirrors))
+	for _, mirror := range config.Mirrors {
+		mirrored[mirror.Registry] = true
+	}
+	var inherited []RegistryMirror
+	for _, mirror := range mirrors {
+		if !mirrored[mirror.Registry] {
+			mirrored[mirror.Registry] = true
+			inherited = append(inherited, mirror)
+		}
+	}
+	if len(inherited) > 0 {
+		config.Mirrors = append(append([]RegistryMirror{}, config.Mirrors...), inherited...)
+	}
+	return config
 }
 
-// +kubebuilder:validation:Enum=Deployment;StatefulSet
+// NamespaceStrategy defines how an app's namespace is managed.
+// +kubebuilder:validation:Enum=shared;perApp
+type NamespaceStrategy string
+
+const (
+	// SharedNamespace means the namespace is created outside of ketch and can be shared by several apps.
+	SharedNamespace NamespaceStrategy = "shared"
+
+	// PerAppNamespace means ketch creates a dedicated namespace of the app with a resource quota and a network policy
+	// isolating it from namespaces of other apps, the namespace is removed together with the app.
+	PerAppNamespace NamespaceStrategy = "perApp"
+)
+
+// +kubebuilder:validation:Enum=Deployment;StatefulSet;DaemonSet
 type AppType string
 
 const (
 	DeploymentAppType  AppType = "Deployment"
 	StatefulSetAppType AppType = "StatefulSet"
+	DaemonSetAppType   AppType = "DaemonSet"
 )
 
+// ParseAppType returns the AppType with the given name, the name is case-insensitive.
+func ParseAppType(kind string) (AppType, error) {
+	for _, t := range []AppType{DeploymentAppType, StatefulSetAppType, DaemonSetAppType} {
+		if strings.EqualFold(kind, string(t)) {
+			return t, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnknownProcessKind, kind)
+}
+
 func (spec AppSpec) GetType() AppType {
 	if spec.Type == nil {
 		return DeploymentAppType
@@ -268,6 +628,20 @@ func (spec AppSpec) GetType() AppType {
 	return *spec.Type
 }
 
+// ProcessType returns the type of workload running the given process of the deployment.
+// ketch.yaml can override the type of the app for a particular process.
+func (spec AppSpec) ProcessType(deployment AppDeploymentSpec, process string) AppType {
+	config := deployment.KetchYaml.ProcessConfig(process)
+	if config == nil || config.Kind == "" {
+		return spec.GetType()
+	}
+	t, err := ParseAppType(config.Kind)
+	if err != nil {
+		return spec.GetType()
+	}
+	return t
+}
+
 type PersistentVolumeClaim struct {
 	Name             string                          `json:"name"`
 	AccessModes      []v1.PersistentVolumeAccessMode `json:"accessModes"`
@@ -291,6 +665,7 @@ type Target struct {
 // +kubebuilder:object:root=true
 // +kubebuilder:resource:scope=Cluster
 // +kubebuilder:subresource:status
+// +kubebuilder:storageversion
 // +kubebuilder:printcolumn:name="Description",type=string,JSONPath=`.spec.description`
 
 // App is the Schema for the apps API.
@@ -349,6 +724,62 @@ func (app *App) SetUnits(selector Selector, units int) error {
 	return nil
 }
 
+func (s *AppDeploymentSpec) addUnits(process string, quantity int) error {
+	for i, processSpec := range s.Processes {
+		if processSpec.Name == process {
+			return s.Processes[i].addUnits(quantity)
+		}
+	}
+	return ErrProcessNotFound
+}
+
+func (s *AppDeploymentSpec) addUnitsForAllProcess(quantity int) error {
+	for i := range s.Processes {
+		if err := s.Processes[i].addUnits(quantity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *ProcessSpec) addUnits(quantity int) error {
+	units := DefaultNumberOfUnits
+	if p.Units != nil {
+		units = *p.Units
+	}
+	units += quantity
+	if units < 0 {
+		return ErrNegativeUnits
+	}
+	p.Units = &units
+	return nil
+}
+
+// AddUnits adds the given quantity of units to the specified processes.
+// A negative quantity removes units, the resulting number of units of a process can't be less than zero.
+func (app *App) AddUnits(selector Selector, quantity int) error {
+	deploymentFound := false
+	for _, deploymentSpec := range app.Spec.Deployments {
+		if selector.DeploymentVersion != nil && *selector.DeploymentVersion != deploymentSpec.Version {
+			continue
+		}
+		if selector.Process != nil {
+			if err := deploymentSpec.addUnits(*selector.Process, quantity); err != nil {
+				return err
+			}
+		} else {
+			if err := deploymentSpec.addUnitsForAllProcess(quantity); err != nil {
+				return err
+			}
+		}
+		deploymentFound = true
+	}
+	if selector.DeploymentVersion != nil && !deploymentFound {
+		return ErrDeploymentNotFound
+	}
+	return nil
+}
+
 // SetEnvs extends the current list of environment variables with the provided list.
 // If the current list has an env variable from the provided list, the env variable will be updated with a new value.
 func (app *App) SetEnvs(envs []Env) {
@@ -365,12 +796,26 @@ func (app *App) SetEnvs(envs []Env) {
 		}
 		newEnvs = append(newEnvs, env)
 	}
-	for _, env := range names {
-		newEnvs = append(newEnvs, env)
+	// new variables are added in the given order, the last value wins if a name is repeated.
+	for _, env := range envs {
+		if newEnv, isNew := names[env.Name]; isNew {
+			newEnvs = append(newEnvs, newEnv)
+			delete(names, env.Name)
+		}
 	}
 	app.Spec.Env = newEnvs
 }
 
+// IsSensitiveEnv returns true if the app has a sensitive env variable with the given name.
+func (app *App) IsSensitiveEnv(name string) bool {
+	for _, env := range app.Spec.Env {
+		if env.Name == name {
+			return env.Sensitive
+		}
+	}
+	return false
+}
+
 // Envs returns values of the asked env variables.
 func (app *App) Envs(names []string) map[string]string {
 	namesMap := make(map[string]struct{}, len(names))
@@ -381,11 +826,11 @@ func (app *App) Envs(names []string) map[string]string {
 	envs := make(map[string]string)
 	for _, env := range app.Spec.Env {
 		if len(names) == 0 {
-			envs[env.Name] = env.Value
+			envs[env.Name] = env.DisplayValue()
 			continue
 		}
 		if _, ok := namesMap[env.Name]; ok {
-			envs[env.Name] = env.Value
+			envs[env.Name] = env.DisplayValue()
 		}
 	}
 	return envs
@@ -407,16 +852,60 @@ func (app *App) UnsetEnvs(envs []string) {
 }
 
 // Stop stops processes specified by the selector.
+// Units of a stopped process are kept in StoppedUnits, so Start restores them.
 func (app *App) Stop(selector Selector) error {
-	return app.SetUnits(selector, 0)
+	deploymentFound := false
+	for _, deploymentSpec := range app.Spec.Deployments {
+		if selector.DeploymentVersion != nil && *selector.DeploymentVersion != deploymentSpec.Version {
+			continue
+		}
+		processFound := false
+		for i, processSpec := range deploymentSpec.Processes {
+			if selector.Process != nil && processSpec.Name != *selector.Process {
+				continue
+			}
+			deploymentSpec.Processes[i].stop()
+			processFound = true
+		}
+		if selector.Process != nil && !processFound {
+			return ErrProcessNotFound
+		}
+		deploymentFound = true
+	}
+	if selector.DeploymentVersion != nil && !deploymentFound {
+		return ErrDeploymentNotFound
+	}
+	return nil
+}
+
+func (p *ProcessSpec) stop() {
+	if p.Units != nil && *p.Units == 0 {
+		// the process is stopped already, its units are kept.
+		return
+	}
+	units := DefaultNumberOfUnits
+	if p.Units != nil {
+		units = *p.Units
+	}
+	stopped := 0
+	p.StoppedUnits = &units
+	p.Units = &stopped
+}
+
+func (p *ProcessSpec) start() {
+	units := DefaultNumberOfUnits
+	if p.StoppedUnits != nil {
+		units = *p.StoppedUnits
+	}
+	p.Units = &units
+	p.StoppedUnits = nil
 }
 
 // Start starts processes specified by the selector.
-// We start a process by setting its unit quantity to 1.
+// We start a process by restoring units it had when it was stopped, or by setting its unit quantity to 1.
 // If a process has running units, nothing will be changed.
 func (app *App) Start(selector Selector) error {
 	deploymentFound := false
-	units := 1
 	for _, deploymentSpec := range app.Spec.Deployments {
 		if selector.DeploymentVersion != nil && *selector.DeploymentVersion != deploymentSpec.Version {
 			continue
@@ -424,16 +913,16 @@ func (app *App) Start(selector Selector) error {
 		if selector.Process != nil {
 			for i, processSpec := range deploymentSpec.Processes {
 				if processSpec.Name == *selector.Process && (processSpec.Units == nil || *processSpec.Units == 0) {
-					deploymentSpec.Processes[i].Units = &units
+					deploymentSpec.Processes[i].start()
 					deploymentFound = true
 				}
 			}
 		} else {
 			for i, processSpec := range deploymentSpec.Processes {
-				if processSpec.Units != nil && *processSpec.Units > 1 {
+				if processSpec.Units != nil && *processSpec.Units > 0 {
 					continue
 				}
-				deploymentSpec.Processes[i].Units = &units
+				deploymentSpec.Processes[i].start()
 				deploymentFound = true
 			}
 		}
@@ -445,22 +934,40 @@ func (app *App) Start(selector Selector) error {
 }
 
 // CNames returns all CNAMEs to access the application including a default cname.
+// The primary cname, if any, goes first.
 func (app *App) CNames() []string {
 	cnames := []string{}
+	forceHTTPS := app.Spec.Ingress.HTTPSForced()
+	primary := app.Spec.Ingress.Cnames.Primary()
+	if primary != nil {
+		cname := *primary
+		cname.Secure = cname.Secure || forceHTTPS
+		cnames = append(cnames, cname.URL())
+	}
 	defaultCname := app.DefaultCname()
 	if defaultCname != nil {
 		cnames = append(cnames, fmt.Sprintf("http://%s", *defaultCname))
 	}
 	for _, cname := range app.Spec.Ingress.Cnames {
-		scheme := "http"
-		if cname.Secure {
-			scheme = "https"
+		if cname.Primary {
+			continue
 		}
-		cnames = append(cnames, fmt.Sprintf("%s://%s", scheme, cname.Name))
+		cname.Secure = cname.Secure || forceHTTPS
+		cnames = append(cnames, cname.URL())
 	}
 	return cnames
 }
 
+// PrimaryURL returns the canonical address of the application.
+// It is the primary cname if the app has one, otherwise the first of the app's CNAMEs.
+func (app *App) PrimaryURL() string {
+	cnames := app.CNames()
+	if len(cnames) == 0 {
+		return ""
+	}
+	return cnames[0]
+}
+
 // DefaultCname returns a default cname to access the application.
 // A default cname uses the following format: <app name>.<App's Ingress ServiceEndpoint>.shipa.cloud.
 func (app *App) DefaultCname() *string {
@@ -520,12 +1027,22 @@ func (app *App) SetCondition(t ConditionType, status v1.ConditionStatus, message
 
 // Phase return a simple, high-level summary of where the application is in its lifecycle.
 func (app *App) Phase() AppPhase {
+	if !app.DeletionTimestamp.IsZero() {
+		return AppRemoving
+	}
 	for _, cond := range app.Status.Conditions {
-		if cond.Status == v1.ConditionFalse {
+		// Deploying and Drifted are false once the latest deployment stops rolling out and while resources match the chart,
+		// it doesn't mean the app is broken.
+		if cond.Status == v1.ConditionFalse && cond.Type != Deploying && cond.Type != Drifted {
 			return AppError
 		}
 	}
 	if app.Units() == 0 {
+		for _, deploymentSpec := range app.Spec.Deployments {
+			if len(deploymentSpec.Processes) > 0 {
+				return AppStopped
+			}
+		}
 		return AppCreated
 	}
 	return AppRunning
@@ -541,6 +1058,15 @@ func (s AppStatus) Condition(t ConditionType) *Condition {
 	return nil
 }
 
+// DeployInProgress returns the message of the Deploying condition if the app's latest deployment is rolling out.
+func (app *App) DeployInProgress() (string, bool) {
+	c := app.Status.Condition(Deploying)
+	if c == nil || c.Status != v1.ConditionTrue {
+		return "", false
+	}
+	return c.Message, true
+}
+
 // getUpdatedUnits(weight=75, target=4) -> (3 units in the source deploy, 1 unit in the target deployment)
 func getUpdatedUnits(weight uint8, targetUnits uint16) (int, int) {
 	if weight > 100 {
@@ -653,6 +1179,80 @@ func (app *App) DoCanary(now metav1.Time, logger logr.Logger, recorder record.Ev
 	return nil
 }
 
+// DeploymentRecord returns the deployment with the given version from the deployment history.
+func (s AppStatus) DeploymentRecord(version DeploymentVersion) *DeploymentRecord {
+	for _, record := range s.DeploymentHistory {
+		if record.Version == version {
+			return &record
+		}
+	}
+	return nil
+}
+
+// ReleaseFailed returns true if the release process of the app's latest deployment failed
+// and the app's spec hasn't changed since then.
+func (app *App) ReleaseFailed() bool {
+	failure := app.Status.ReleaseFailure
+	if failure == nil || failure.ObservedGeneration != app.Generation || len(app.Spec.Deployments) == 0 {
+		return false
+	}
+	return app.Spec.Deployments[len(app.Spec.Deployments)-1].Version == failure.Version
+}
+
+// RecordDeployments adds the app's deployments to the deployment history
+// and drops the oldest records to keep at most DeploymentHistoryLimit of them.
+func (app *App) RecordDeployments(now metav1.Time, deployedBy string) {
+	for _, deployment := range app.Spec.Deployments {
+		if app.Status.DeploymentRecord(deployment.Version) != nil {
+			continue
+		}
+		record := DeploymentRecord{
+			AppDeploymentSpec: *deployment.DeepCopy(),
+			KetchYamlHash:     deployment.KetchYaml.Hash(),
+			DeployedAt:        now,
+			DeployedBy:        deployedBy,
+		}
+		// traffic weight is a state of a canary deployment rather than a part of the deployment.
+		record.RoutingSettings = RoutingSettings{}
+		app.Status.DeploymentHistory = append(app.Status.DeploymentHistory, record)
+	}
+	if n := len(app.Status.DeploymentHistory); n > DeploymentHistoryLimit {
+		app.Status.DeploymentHistory = app.Status.DeploymentHistory[n-DeploymentHistoryLimit:]
+	}
+}
+
+// RollbackTo replaces the app's deployments with a new deployment of the given version from the deployment history.
+func (app *App) RollbackTo(version DeploymentVersion) error {
+	if app.Spec.Canary.Active {
+		return errors.New("can't roll back while a canary deployment is active")
+	}
+	record := app.Status.DeploymentRecord(version)
+	if record == nil {
+		return fmt.Errorf("deployment version %d not found in the deployment history", version)
+	}
+	deployment := *record.AppDeploymentSpec.DeepCopy()
+	app.Spec.DeploymentsCount += 1
+	deployment.Version = DeploymentVersion(app.Spec.DeploymentsCount)
+	deployment.RoutingSettings = RoutingSettings{Weight: 100}
+	app.Spec.Deployments = []AppDeploymentSpec{deployment}
+	return nil
+}
+
+// IsShadow returns true if the deployment gets a copy of requests to the app instead of traffic of its own.
+func (d AppDeploymentSpec) IsShadow() bool {
+	return d.RoutingSettings.Weight == 0 && d.RoutingSettings.MirrorPercentage > 0
+}
+
+// ShadowDeployment returns the deployment requests to the app are mirrored to or nil if there is no such deployment.
+func (s AppSpec) ShadowDeployment() *AppDeploymentSpec {
+	for i := range s.Deployments {
+		if s.Deployments[i].IsShadow() {
+			return &s.Deployments[i]
+		}
+	}
+	return nil
+}
+
 // DoRollback performs rollback
 func (app *App) DoRollback() {
 	// we need to rollback all weight to the primary deployment
@@ -748,6 +1348,7 @@ const (
 	CanaryStartedDesc  = "started"
 	CanaryFinished     = "CanaryFinished"
 	CanaryFinishedDesc = "finished"
+	CanaryRolledBack   = "CanaryRolledBack"
 
 	CanaryNextStep       = "CanaryNextStep"
 	CanaryNextStepDesc   = "weight change"
@@ -1042,6 +1643,19 @@ const (
 	AppReconcileComplete = "AppReconcileComplete"
 	AppReconcileUpdate   = "AppReconcileUpdate"
 	AppReconcileError    = "AppReconcileError"
+
+	// Reasons of events shown by "ketch app info --events".
+	AppDeployed          = "Deployed"
+	AppScaledUp          = "ScaledUp"
+	AppScaledDown        = "ScaledDown"
+	AppHelmUpgradeFailed = "HelmUpgradeFailed"
+	AppIngressError      = "IngressError"
+	AppRemoveFailed      = "RemoveFailed"
+	AppScheduledScaling  = "ScheduledScaling"
+	AppReleaseFailed     = "ReleaseFailed"
+	AppDeployHookFailed  = "DeployHookFailed"
+	AppDrifted           = "Drifted"
+	AppDriftReverted     = "DriftReverted"
 )
 
 // AppDeploymentEvent represents fields and annotations for an Event that describes an app deployment.
@@ -180,6 +180,101 @@ func TestApp_SetUnits(t *testing.T) {
 	}
 }
 
+func TestApp_AddUnits(t *testing.T) {
+	tests := []struct {
+		name string
+
+		spec     AppSpec
+		selector Selector
+		quantity int
+
+		wantSpec AppSpec
+		wantErr  error
+	}{
+		{
+			name:     "process not found",
+			spec:     defaultSpec(),
+			selector: Selector{Process: stringRef("database")},
+			quantity: 1,
+			wantErr:  ErrProcessNotFound,
+		},
+		{
+			name:     "deployment not found",
+			spec:     defaultSpec(),
+			selector: Selector{DeploymentVersion: versionRef(8)},
+			quantity: 1,
+			wantErr:  ErrDeploymentNotFound,
+		},
+		{
+			name:     "remove too many units",
+			spec:     defaultSpec(),
+			selector: Selector{DeploymentVersion: versionRef(1), Process: stringRef("worker")},
+			quantity: -3,
+			wantErr:  ErrNegativeUnits,
+		},
+		{
+			name:     "add units to all processes of a deployment",
+			spec:     defaultSpec(),
+			selector: Selector{DeploymentVersion: versionRef(1)},
+			quantity: 2,
+			wantSpec: AppSpec{
+				Deployments: []AppDeploymentSpec{
+					{
+						Version: 1,
+						Processes: []ProcessSpec{
+							{Name: "web", Units: intRef(3)},
+							{Name: "worker", Units: intRef(4)},
+						},
+					},
+					{
+						Version: 2,
+						Processes: []ProcessSpec{
+							{Name: "web", Units: intRef(0)},
+							{Name: "worker", Units: intRef(0)},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "remove units, nil units are treated as the default number",
+			spec: AppSpec{
+				Deployments: []AppDeploymentSpec{
+					{
+						Version:   1,
+						Processes: []ProcessSpec{{Name: "web"}},
+					},
+				},
+			},
+			selector: Selector{Process: stringRef("web")},
+			quantity: -1,
+			wantSpec: AppSpec{
+				Deployments: []AppDeploymentSpec{
+					{
+						Version:   1,
+						Processes: []ProcessSpec{{Name: "web", Units: intRef(0)}},
+					},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := &App{Spec: tt.spec}
+			if err := app.AddUnits(tt.selector, tt.quantity); err != tt.wantErr {
+				t.Errorf("AddUnits() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if diff := cmp.Diff(app.Spec, tt.wantSpec); diff != "" {
+				t.Errorf("AppSpec mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestApp_SetEnvs(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -230,6 +325,18 @@ func TestApp_SetEnvs(t *testing.T) {
 				"THEKETCH": {Name: "THEKETCH", Value: "1"},
 			},
 		},
+		{
+			name:        "repeated variable",
+			initialEnvs: []Env{{Name: "KETCH", Value: "true"}},
+			setEnvs: []Env{
+				{Name: "API_KEY", Value: "xxx"},
+				{Name: "API_KEY", Value: "yyy"},
+			},
+			wantEnvs: map[string]Env{
+				"KETCH":   {Name: "KETCH", Value: "true"},
+				"API_KEY": {Name: "API_KEY", Value: "yyy"},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -239,6 +346,7 @@ func TestApp_SetEnvs(t *testing.T) {
 				},
 			}
 			app.SetEnvs(tt.setEnvs)
+			require.Len(t, app.Spec.Env, len(tt.wantEnvs))
 			got := make(map[string]Env, len(app.Spec.Env))
 			for _, env := range app.Spec.Env {
 				got[env.Name] = env
@@ -250,6 +358,44 @@ func TestApp_SetEnvs(t *testing.T) {
 	}
 }
 
+func TestEnv_DisplayValue(t *testing.T) {
+	tests := []struct {
+		name string
+		env  Env
+		want string
+	}{
+		{
+			name: "value",
+			env:  Env{Name: "DEBUG", Value: "true"},
+			want: "true",
+		},
+		{
+			name: "secret",
+			env: Env{Name: "DB_PASSWORD", ValueFrom: &v1.EnvVarSource{
+				SecretKeyRef: &v1.SecretKeySelector{LocalObjectReference: v1.LocalObjectReference{Name: "db-credentials"}, Key: "password"},
+			}},
+			want: "<secret db-credentials/password>",
+		},
+		{
+			name: "configmap",
+			env: Env{Name: "LOG_LEVEL", ValueFrom: &v1.EnvVarSource{
+				ConfigMapKeyRef: &v1.ConfigMapKeySelector{LocalObjectReference: v1.LocalObjectReference{Name: "settings"}, Key: "log-level"},
+			}},
+			want: "<configmap settings/log-level>",
+		},
+		{
+			name: "pod field",
+			env:  Env{Name: "POD_NAME", ValueFrom: &v1.EnvVarSource{FieldRef: &v1.ObjectFieldSelector{FieldPath: "metadata.name"}}},
+			want: "<field metadata.name>",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.env.DisplayValue())
+		})
+	}
+}
+
 func TestApp_Envs(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -426,6 +572,20 @@ func TestApp_CNames(t *testing.T) {
 			cnames:               []Cname{{Name: "theketch.io"}, {Name: "app.theketch.io", Secure: true}},
 			want:                 []string{"http://ketch.10.20.30.40.shipa.cloud", "http://theketch.io", "https://app.theketch.io"},
 		},
+		{
+			name:                 "primary cname goes first",
+			generateDefaultCname: true,
+			ingressController:    IngressControllerSpec{ServiceEndpoint: "10.20.30.40", ClusterIssuer: "letsencrypt"},
+			cnames:               []Cname{{Name: "theketch.io"}, {Name: "app.theketch.io", Secure: true, Primary: true}},
+			want:                 []string{"https://app.theketch.io", "http://ketch.10.20.30.40.shipa.cloud", "http://theketch.io"},
+		},
+		{
+			name:                 "ingress controller forces https",
+			generateDefaultCname: true,
+			ingressController:    IngressControllerSpec{ServiceEndpoint: "10.20.30.40", ClusterIssuer: "letsencrypt", ForceHTTPS: true},
+			cnames:               []Cname{{Name: "theketch.io"}, {Name: "app.theketch.io", Secure: true}},
+			want:                 []string{"http://ketch.10.20.30.40.shipa.cloud", "https://theketch.io", "https://app.theketch.io"},
+		},
 		{
 			name:                 "without default cname",
 			generateDefaultCname: false,
@@ -468,6 +628,265 @@ func TestApp_CNames(t *testing.T) {
 	}
 }
 
+func TestApp_PrimaryURL(t *testing.T) {
+	tests := []struct {
+		name   string
+		cnames CnameList
+		want   string
+	}{
+		{
+			name:   "primary cname",
+			cnames: CnameList{{Name: "theketch.io"}, {Name: "app.theketch.io", Secure: true, Primary: true}},
+			want:   "https://app.theketch.io",
+		},
+		{
+			name:   "first cname without primary",
+			cnames: CnameList{{Name: "theketch.io"}, {Name: "app.theketch.io"}},
+			want:   "http://theketch.io",
+		},
+		{
+			name: "no cnames",
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := &App{Spec: AppSpec{Ingress: IngressSpec{Cnames: tt.cnames}}}
+			require.Equal(t, tt.want, app.PrimaryURL())
+		})
+	}
+}
+
+func TestIngressSpec_HTTPSForced(t *testing.T) {
+	enabled, disabled := true, false
+	tests := []struct {
+		name string
+		spec IngressSpec
+		want bool
+	}{
+		{
+			name: "default of the ingress controller",
+			spec: IngressSpec{Controller: IngressControllerSpec{ForceHTTPS: true}},
+			want: true,
+		},
+		{
+			name: "app opts out",
+			spec: IngressSpec{ForceHTTPS: &disabled, Controller: IngressControllerSpec{ForceHTTPS: true}},
+			want: false,
+		},
+		{
+			name: "app opts in",
+			spec: IngressSpec{ForceHTTPS: &enabled},
+			want: true,
+		},
+		{
+			name: "not set",
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.spec.HTTPSForced())
+		})
+	}
+}
+
+func TestAppSpec_NetworkPolicyEnabled(t *testing.T) {
+	enabled, disabled := true, false
+	tests := []struct {
+		name string
+		spec AppSpec
+		want bool
+	}{
+		{
+			name: "default of the ingress controller",
+			spec: AppSpec{Ingress: IngressSpec{Controller: IngressControllerSpec{NetworkPolicy: true}}},
+			want: true,
+		},
+		{
+			name: "app opts out",
+			spec: AppSpec{
+				NetworkPolicy: &NetworkPolicySpec{Enabled: &disabled},
+				Ingress:       IngressSpec{Controller: IngressControllerSpec{NetworkPolicy: true}},
+			},
+			want: false,
+		},
+		{
+			name: "app opts in",
+			spec: AppSpec{NetworkPolicy: &NetworkPolicySpec{Enabled: &enabled}},
+			want: true,
+		},
+		{
+			name: "allowed namespaces only",
+			spec: AppSpec{NetworkPolicy: &NetworkPolicySpec{AllowFromNamespaces: []string{"monitoring"}}},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.spec.NetworkPolicyEnabled())
+		})
+	}
+}
+
+func TestAppSpec_DockerRegistryConfig(t *testing.T) {
+	registry := &RegistrySpec{URL: "registry.example.com/apps", SecretName: "registry-credentials", Mirror: "cache.example.com/apps"}
+	tests := []struct {
+		name string
+		spec AppSpec
+		want DockerRegistrySpec
+	}{
+		{
+			name: "no cluster registry",
+			spec: AppSpec{DockerRegistry: DockerRegistrySpec{SecretName: "app-credentials"}},
+			want: DockerRegistrySpec{SecretName: "app-credentials"},
+		},
+		{
+			name: "cluster registry",
+			spec: AppSpec{Ingress: IngressSpec{Controller: IngressControllerSpec{Registry: registry}}},
+			want: DockerRegistrySpec{
+				SecretName: "registry-credentials",
+				Mirrors:    []RegistryMirror{{Registry: "registry.example.com", Endpoint: "cache.example.com/apps"}},
+			},
+		},
+		{
+			name: "app's own secret and mirror win",
+			spec: AppSpec{
+				DockerRegistry: DockerRegistrySpec{
+					SecretName: "app-credentials",
+					Mirrors:    []RegistryMirror{{Registry: "registry.example.com", Endpoint: "mirror.example.com"}},
+				},
+				Ingress: IngressSpec{Controller: IngressControllerSpec{Registry: registry}},
+			},
+			want: DockerRegistrySpec{
+				SecretName: "app-credentials",
+				Mirrors:    []RegistryMirror{{Registry: "registry.example.com", Endpoint: "mirror.example.com"}},
+			},
+		},
+		{
+			name: "mirror of another registry",
+			spec: AppSpec{
+				DockerRegistry: DockerRegistrySpec{
+					Mirrors: []RegistryMirror{{Registry: "docker.io", Endpoint: "cache.example.com/dockerhub"}},
+				},
+				Ingress: IngressSpec{Controller: IngressControllerSpec{Registry: registry}},
+			},
+			want: DockerRegistrySpec{
+				SecretName: "registry-credentials",
+				Mirrors: []RegistryMirror{
+					{Registry: "docker.io", Endpoint: "cache.example.com/dockerhub"},
+					{Registry: "registry.example.com", Endpoint: "cache.example.com/apps"},
+				},
+			},
+		},
+		{
+			name: "cluster-wide mirrors of upstream registries",
+			spec: AppSpec{
+				DockerRegistry: DockerRegistrySpec{
+					Mirrors: []RegistryMirror{{Registry: "docker.io", Endpoint: "mirror.example.com"}},
+				},
+				Ingress: IngressSpec{Controller: IngressControllerSpec{Registry: &RegistrySpec{
+					Mirrors: []RegistryMirror{
+						{Registry: "docker.io", Endpoint: "cache.example.com/dockerhub"},
+						{Registry: "quay.io", Endpoint: "cache.example.com/quay"},
+					},
+				}}},
+			},
+			want: DockerRegistrySpec{
+				Mirrors: []RegistryMirror{
+					{Registry: "docker.io", Endpoint: "mirror.example.com"},
+					{Registry: "quay.io", Endpoint: "cache.example.com/quay"},
+				},
+			},
+		},
+		{
+			name: "mirror of the cluster registry wins over cluster-wide mirrors of its host",
+			spec: AppSpec{
+				Ingress: IngressSpec{Controller: IngressControllerSpec{Registry: &RegistrySpec{
+					URL:    "registry.example.com/apps",
+					Mirror: "cache.example.com/apps",
+					Mirrors: []RegistryMirror{
+						{Registry: "docker.io", Endpoint: "cache.example.com/dockerhub"},
+						{Registry: "registry.example.com", Endpoint: "other-cache.example.com"},
+					},
+				}}},
+			},
+			want: DockerRegistrySpec{
+				Mirrors: []RegistryMirror{
+					{Registry: "registry.example.com", Endpoint: "cache.example.com/apps"},
+					{Registry: "docker.io", Endpoint: "cache.example.com/dockerhub"},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.spec.DockerRegistryConfig())
+		})
+	}
+}
+
+func TestParseRegistryMirrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    []RegistryMirror
+		wantErr string
+	}{
+		{
+			name: "mirrors are sorted by registry",
+			data: "# pull-through caches\nquay.io=cache.example.com/quay\n\n docker.io = cache.example.com/dockerhub \n",
+			want: []RegistryMirror{
+				{Registry: "docker.io", Endpoint: "cache.example.com/dockerhub"},
+				{Registry: "quay.io", Endpoint: "cache.example.com/quay"},
+			},
+		},
+		{
+			name:    "invalid line",
+			data:    "docker.io=\nquay.io=cache.example.com/quay",
+			want:    []RegistryMirror{{Registry: "quay.io", Endpoint: "cache.example.com/quay"}},
+			wantErr: `invalid registry mirror "docker.io=", registry mirrors should have REGISTRY=ENDPOINT format`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mirrors, err := ParseRegistryMirrors(tt.data)
+			if len(tt.wantErr) > 0 {
+				require.EqualError(t, err, tt.wantErr)
+			} else {
+				require.Nil(t, err)
+			}
+			require.Equal(t, tt.want, mirrors)
+		})
+	}
+}
+
+func TestParseServiceAccountRoles(t *testing.T) {
+	roles, err := ParseServiceAccountRoles("# roles of apps\nClusterRole/view\n\nGroup/admins\nRole/config-reader\n")
+	require.EqualError(t, err, `invalid service account role "Group/admins", roles should have Role/NAME or ClusterRole/NAME format`)
+	require.Equal(t, []KetchYamlRoleRef{{Kind: "ClusterRole", Name: "view"}, {Kind: "Role", Name: "config-reader"}}, roles)
+
+	spec := IngressControllerSpec{ServiceAccountRoles: roles}
+	require.True(t, spec.RoleAllowed(KetchYamlRoleRef{Kind: "ClusterRole", Name: "view"}))
+	require.False(t, spec.RoleAllowed(KetchYamlRoleRef{Kind: "Role", Name: "view"}))
+	require.False(t, IngressControllerSpec{}.RoleAllowed(KetchYamlRoleRef{Kind: "ClusterRole", Name: "view"}))
+}
+
+func TestCnameList_SetPrimary(t *testing.T) {
+	cnames := CnameList{{Name: "theketch.io", Primary: true}, {Name: "app.theketch.io"}}
+	cnames.SetPrimary("app.theketch.io")
+	require.Equal(t, CnameList{{Name: "theketch.io"}, {Name: "app.theketch.io", Primary: true}}, cnames)
+	require.Equal(t, &cnames[1], cnames.Primary())
+}
+
+func TestCnameList_Find(t *testing.T) {
+	cnames := CnameList{{Name: "theketch.io"}, {Name: "theketch.io", Path: "/api", Secure: true}}
+	require.Equal(t, &cnames[1], cnames.Find("theketch.io/api"))
+	require.Equal(t, "https://theketch.io/api", cnames.Find("theketch.io/api").URL())
+	require.Equal(t, &cnames[0], cnames.Find("theketch.io"))
+	require.Nil(t, cnames.Find("theketch.io/web"))
+}
+
 func TestApp_Units(t *testing.T) {
 	tests := []struct {
 		name string
@@ -538,6 +957,7 @@ func TestApp_Stop(t *testing.T) {
 	tests := []struct {
 		name     string
 		selector Selector
+		stopped  bool
 
 		wantSpec AppSpec
 		wantErr  bool
@@ -550,14 +970,14 @@ func TestApp_Stop(t *testing.T) {
 					{
 						Version: 1,
 						Processes: []ProcessSpec{
-							{Name: "web", Units: intRef(0)},
+							{Name: "web", Units: intRef(0), StoppedUnits: intRef(1)},
 							{Name: "worker", Units: intRef(2)},
 						},
 					},
 					{
 						Version: 2,
 						Processes: []ProcessSpec{
-							{Name: "web", Units: intRef(0)},
+							{Name: "web", Units: intRef(0), StoppedUnits: intRef(4)},
 							{Name: "worker", Units: intRef(5)},
 						},
 					},
@@ -572,8 +992,8 @@ func TestApp_Stop(t *testing.T) {
 					{
 						Version: 1,
 						Processes: []ProcessSpec{
-							{Name: "web", Units: intRef(0)},
-							{Name: "worker", Units: intRef(0)},
+							{Name: "web", Units: intRef(0), StoppedUnits: intRef(1)},
+							{Name: "worker", Units: intRef(0), StoppedUnits: intRef(2)},
 						},
 					},
 					{
@@ -602,12 +1022,40 @@ func TestApp_Stop(t *testing.T) {
 						Version: 2,
 						Processes: []ProcessSpec{
 							{Name: "web", Units: intRef(4)},
-							{Name: "worker", Units: intRef(0)},
+							{Name: "worker", Units: intRef(0), StoppedUnits: intRef(5)},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:     "stopped process keeps its units",
+			selector: Selector{Process: stringRef("web")},
+			stopped:  true,
+			wantSpec: AppSpec{
+				Deployments: []AppDeploymentSpec{
+					{
+						Version: 1,
+						Processes: []ProcessSpec{
+							{Name: "web", Units: intRef(0), StoppedUnits: intRef(1)},
+							{Name: "worker", Units: intRef(2)},
+						},
+					},
+					{
+						Version: 2,
+						Processes: []ProcessSpec{
+							{Name: "web", Units: intRef(0), StoppedUnits: intRef(4)},
+							{Name: "worker", Units: intRef(5)},
 						},
 					},
 				},
 			},
 		},
+		{
+			name:     "unknown process",
+			selector: Selector{Process: stringRef("db")},
+			wantErr:  true,
+		},
 		{
 			name:     "stop all",
 			selector: Selector{},
@@ -616,15 +1064,15 @@ func TestApp_Stop(t *testing.T) {
 					{
 						Version: 1,
 						Processes: []ProcessSpec{
-							{Name: "web", Units: intRef(0)},
-							{Name: "worker", Units: intRef(0)},
+							{Name: "web", Units: intRef(0), StoppedUnits: intRef(1)},
+							{Name: "worker", Units: intRef(0), StoppedUnits: intRef(2)},
 						},
 					},
 					{
 						Version: 2,
 						Processes: []ProcessSpec{
-							{Name: "web", Units: intRef(0)},
-							{Name: "worker", Units: intRef(0)},
+							{Name: "web", Units: intRef(0), StoppedUnits: intRef(4)},
+							{Name: "worker", Units: intRef(0), StoppedUnits: intRef(5)},
 						},
 					},
 				},
@@ -653,6 +1101,9 @@ func TestApp_Stop(t *testing.T) {
 					},
 				},
 			}
+			if tt.stopped {
+				require.Nil(t, app.Stop(tt.selector))
+			}
 			if err := app.Stop(tt.selector); (err != nil) != tt.wantErr {
 				t.Errorf("Stop() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -668,8 +1119,9 @@ func TestApp_Stop(t *testing.T) {
 
 func TestApp_Start(t *testing.T) {
 	tests := []struct {
-		name     string
-		selector Selector
+		name         string
+		selector     Selector
+		stoppedUnits int
 
 		wantSpec AppSpec
 		wantErr  bool
@@ -700,6 +1152,33 @@ func TestApp_Start(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:         "start restores stopped units",
+			selector:     Selector{DeploymentVersion: versionRef(1)},
+			stoppedUnits: 3,
+			wantSpec: AppSpec{
+				Deployments: []AppDeploymentSpec{
+					{
+						Version: 1,
+						Processes: []ProcessSpec{
+							{Name: "web", Units: intRef(1)},
+							{Name: "worker", Units: intRef(2)},
+							{Name: "db", Units: intRef(1)},
+							{Name: "db-2", Units: intRef(3)},
+						},
+					},
+					{
+						Version: 2,
+						Processes: []ProcessSpec{
+							{Name: "web", Units: intRef(4)},
+							{Name: "worker", Units: intRef(5)},
+							{Name: "db"},
+							{Name: "db-2", Units: intRef(0), StoppedUnits: intRef(3)},
+						},
+					},
+				},
+			},
+		},
 		{
 			name:     "start by process",
 			selector: Selector{Process: stringRef("db")},
@@ -805,6 +1284,11 @@ func TestApp_Start(t *testing.T) {
 					},
 				},
 			}
+			if tt.stoppedUnits > 0 {
+				for _, deployment := range app.Spec.Deployments {
+					deployment.Processes[3].StoppedUnits = intRef(tt.stoppedUnits)
+				}
+			}
 			err := app.Start(tt.selector)
 			if tt.wantErr {
 				require.NotNil(t, err)
@@ -866,6 +1350,29 @@ func TestApp_Phase(t *testing.T) {
 			},
 			want: AppCreated,
 		},
+		{
+			name: "all processes stopped - status is stopped",
+			app: App{
+				Spec: AppSpec{
+					Deployments: []AppDeploymentSpec{
+						{Processes: []ProcessSpec{{Units: intRef(0), StoppedUnits: intRef(2)}}},
+					},
+				},
+			},
+			want: AppStopped,
+		},
+		{
+			name: "removed - status is removing",
+			app: App{
+				ObjectMeta: metav1.ObjectMeta{DeletionTimestamp: &metav1.Time{Time: time.Now()}},
+				Status: AppStatus{
+					Conditions: []Condition{
+						{Type: Removed, Status: v1.ConditionFalse},
+					},
+				},
+			},
+			want: AppRemoving,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -1616,3 +2123,211 @@ func TestAppType(t *testing.T) {
 		})
 	}
 }
+
+func TestAppSpec_ProcessType(t *testing.T) {
+	statefulSet := StatefulSetAppType
+	deployment := AppDeploymentSpec{
+		KetchYaml: &KetchYamlData{
+			Kubernetes: &KetchYamlKubernetesConfig{
+				Processes: map[string]KetchYamlProcessConfig{
+					"queue":  {Kind: "statefulset"},
+					"agent":  {Kind: "daemonset"},
+					"web":    {Ports: []KetchYamlProcessPortConfig{{Port: 8080}}},
+					"broken": {Kind: "cronjob"},
+				},
+			},
+		},
+	}
+	tt := []struct {
+		name     string
+		appType  *AppType
+		process  string
+		expected AppType
+	}{
+		{name: "kind set in ketch.yaml", process: "queue", expected: StatefulSetAppType},
+		{name: "daemonset kind set in ketch.yaml", process: "agent", expected: DaemonSetAppType},
+		{name: "process without kind", process: "web", expected: DeploymentAppType},
+		{name: "process without kind uses app type", appType: &statefulSet, process: "web", expected: StatefulSetAppType},
+		{name: "process not in ketch.yaml", process: "worker", expected: DeploymentAppType},
+		{name: "unknown kind falls back to app type", process: "broken", expected: DeploymentAppType},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			spec := AppSpec{Type: tc.appType}
+			require.Equal(t, tc.expected, spec.ProcessType(deployment, tc.process))
+		})
+	}
+}
+
+func TestApp_RecordDeployments(t *testing.T) {
+	now := metav1.NewTime(time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC))
+	previous := DeploymentRecord{
+		AppDeploymentSpec: AppDeploymentSpec{Image: "nginx:1.0", Version: 1},
+		DeployedAt:        metav1.NewTime(now.Add(-time.Hour)),
+		DeployedBy:        "alice",
+	}
+	app := App{
+		Spec: AppSpec{
+			Deployments: []AppDeploymentSpec{
+				{Image: "nginx:1.0", Version: 1, RoutingSettings: RoutingSettings{Weight: 90}},
+				{Image: "nginx:2.0", Version: 2, RoutingSettings: RoutingSettings{Weight: 10}},
+			},
+		},
+		Status: AppStatus{DeploymentHistory: []DeploymentRecord{previous}},
+	}
+	app.RecordDeployments(now, "bob")
+	require.Equal(t, []DeploymentRecord{
+		previous,
+		{
+			AppDeploymentSpec: AppDeploymentSpec{Image: "nginx:2.0", Version: 2},
+			DeployedAt:        now,
+			DeployedBy:        "bob",
+		},
+	}, app.Status.DeploymentHistory)
+
+	for i := 3; i <= DeploymentHistoryLimit+5; i++ {
+		app.Spec.Deployments = []AppDeploymentSpec{{Image: "nginx", Version: DeploymentVersion(i)}}
+		app.RecordDeployments(now, "bob")
+	}
+	require.Len(t, app.Status.DeploymentHistory, DeploymentHistoryLimit)
+	require.Equal(t, DeploymentVersion(6), app.Status.DeploymentHistory[0].Version)
+	require.Equal(t, DeploymentVersion(DeploymentHistoryLimit+5), app.Status.DeploymentHistory[DeploymentHistoryLimit-1].Version)
+}
+
+func TestApp_ReleaseFailed(t *testing.T) {
+	failure := &ReleaseFailure{Version: 2, ObservedGeneration: 5}
+	tests := []struct {
+		name       string
+		generation int64
+		versions   []DeploymentVersion
+		failure    *ReleaseFailure
+		want       bool
+	}{
+		{name: "no failure", generation: 5, versions: []DeploymentVersion{2}},
+		{name: "release of the latest deployment failed", generation: 5, versions: []DeploymentVersion{1, 2}, failure: failure, want: true},
+		{name: "app changed after the failure", generation: 6, versions: []DeploymentVersion{2}, failure: failure},
+		{name: "app deployed again", generation: 5, versions: []DeploymentVersion{3}, failure: failure},
+		{name: "no deployments", generation: 5, failure: failure},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := App{
+				ObjectMeta: metav1.ObjectMeta{Generation: tt.generation},
+				Status:     AppStatus{ReleaseFailure: tt.failure},
+			}
+			for _, version := range tt.versions {
+				app.Spec.Deployments = append(app.Spec.Deployments, AppDeploymentSpec{Version: version})
+			}
+			require.Equal(t, tt.want, app.ReleaseFailed())
+		})
+	}
+}
+
+func TestApp_DeployInProgress(t *testing.T) {
+	tests := []struct {
+		name        string
+		conditions  []Condition
+		wantMessage string
+		want        bool
+	}{
+		{name: "no condition"},
+		{name: "rolling out", conditions: []Condition{{Type: Deploying, Status: v1.ConditionTrue, Message: "version 2 is rolling out"}}, wantMessage: "version 2 is rolling out", want: true},
+		{name: "deployed", conditions: []Condition{{Type: Deploying, Status: v1.ConditionFalse, Message: "version 2 is deployed"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := App{Status: AppStatus{Conditions: tt.conditions}}
+			message, ok := app.DeployInProgress()
+			require.Equal(t, tt.want, ok)
+			require.Equal(t, tt.wantMessage, message)
+		})
+	}
+}
+
+func TestApp_RollbackTo(t *testing.T) {
+	history := []DeploymentRecord{
+		{AppDeploymentSpec: AppDeploymentSpec{Image: "nginx:1.0", Version: 1, Processes: []ProcessSpec{{Name: "web", Cmd: []string{"nginx"}}}}},
+		{AppDeploymentSpec: AppDeploymentSpec{Image: "nginx:2.0", Version: 2}},
+	}
+	tests := []struct {
+		name    string
+		app     App
+		version DeploymentVersion
+		want    []AppDeploymentSpec
+		wantErr string
+	}{
+		{
+			name: "rollback to a recorded version",
+			app: App{
+				Spec: AppSpec{
+					DeploymentsCount: 2,
+					Deployments:      []AppDeploymentSpec{{Image: "nginx:2.0", Version: 2, RoutingSettings: RoutingSettings{Weight: 100}}},
+				},
+				Status: AppStatus{DeploymentHistory: history},
+			},
+			version: 1,
+			want: []AppDeploymentSpec{
+				{Image: "nginx:1.0", Version: 3, Processes: []ProcessSpec{{Name: "web", Cmd: []string{"nginx"}}}, RoutingSettings: RoutingSettings{Weight: 100}},
+			},
+		},
+		{
+			name: "unknown version",
+			app: App{
+				Spec:   AppSpec{DeploymentsCount: 2},
+				Status: AppStatus{DeploymentHistory: history},
+			},
+			version: 5,
+			wantErr: "deployment version 5 not found in the deployment history",
+		},
+		{
+			name: "active canary",
+			app: App{
+				Spec:   AppSpec{DeploymentsCount: 2, Canary: CanarySpec{Active: true}},
+				Status: AppStatus{DeploymentHistory: history},
+			},
+			version: 1,
+			wantErr: "can't roll back while a canary deployment is active",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.app.RollbackTo(tt.version)
+			if len(tt.wantErr) > 0 {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.want, tt.app.Spec.Deployments)
+			require.Equal(t, 3, tt.app.Spec.DeploymentsCount)
+		})
+	}
+}
+
+func TestAppSpec_ShadowDeployment(t *testing.T) {
+	tests := []struct {
+		name string
+		spec AppSpec
+		want *AppDeploymentSpec
+	}{
+		{
+			name: "no shadow deployment",
+			spec: AppSpec{Deployments: []AppDeploymentSpec{
+				{Version: 1, RoutingSettings: RoutingSettings{Weight: 80}},
+				{Version: 2, RoutingSettings: RoutingSettings{Weight: 20}},
+			}},
+		},
+		{
+			name: "shadow deployment",
+			spec: AppSpec{Deployments: []AppDeploymentSpec{
+				{Version: 1, RoutingSettings: RoutingSettings{Weight: 100}},
+				{Version: 2, RoutingSettings: RoutingSettings{MirrorPercentage: 10}},
+			}},
+			want: &AppDeploymentSpec{Version: 2, RoutingSettings: RoutingSettings{MirrorPercentage: 10}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.spec.ShadowDeployment())
+		})
+	}
+}
@@ -0,0 +1,540 @@
+package v1beta1
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	k8svalidation "k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+
+	"github.com/theketchio/ketch/internal/validation"
+)
+
+// applog is for logging in this package.
+var applog = logf.Log.WithName("app-resource")
+
+var appmgr manager = nil
+
+func (r *App) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	appmgr = mgr
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+// +kubebuilder:webhook:verbs=create;update,path=/mutate-theketch-io-v1beta1-app,mutating=true,failurePolicy=fail,groups=theketch.io,resources=apps,versions=v1beta1,name=mapp.kb.io,sideEffects=none,admissionReviewVersions=v1beta1
+
+var _ webhook.Defaulter = &App{}
+
+// Default implements webhook.Defaulter so a webhook will be registered for the type.
+// It applies cluster-wide app defaults and the pod security profile from the ingress configmap.
+func (r *App) Default() {
+	applog.Info("default", "name", r.Name)
+	var configmap v1.ConfigMap
+	err := appmgr.GetClient().Get(context.Background(), types.NamespacedName{Name: IngressConfigmapName, Namespace: IngressConfigmapNamespace}, &configmap)
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			applog.Error(err, "failed to get app defaults", "name", r.Name)
+		}
+		return
+	}
+	// the profile and allowed teams are validated against the app before the ingress watcher updates the app's controller.
+	controller := NewIngressControllerSpec(configmap)
+	r.Spec.Ingress.Controller.PodSecurityProfile = controller.PodSecurityProfile
+	r.Spec.Ingress.Controller.AllowedTeams = controller.AllowedTeams
+	defaults, err := ParseAppDefaults(configmap.Data[AppDefaultsKey])
+	if err != nil {
+		applog.Error(err, "failed to get app defaults", "name", r.Name)
+		return
+	}
+	defaults.Apply(r)
+}
+
+// +kubebuilder:webhook:verbs=create;update,path=/validate-theketch-io-v1beta1-app,mutating=false,failurePolicy=fail,groups=theketch.io,resources=apps,versions=v1beta1,name=vapp.kb.io,sideEffects=none,admissionReviewVersions=v1beta1
+
+var _ webhook.Validator = &App{}
+
+var (
+	// labelTargets are kinds of objects labels of an app can be applied to.
+	labelTargets = []Target{
+		{APIVersion: "apps/v1", Kind: "Deployment"},
+		{APIVersion: "v1", Kind: "Service"},
+		{APIVersion: "v1", Kind: "Pod"},
+	}
+	// annotationTargets are kinds of objects annotations of an app can be applied to.
+	annotationTargets = append(labelTargets,
+		Target{APIVersion: "networking.k8s.io/v1", Kind: "Ingress"},
+		Target{APIVersion: "networking.istio.io/v1alpha3", Kind: "Gateway"},
+		Target{APIVersion: "traefik.containo.us/v1alpha1", Kind: "IngressRoute"},
+	)
+)
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *App) ValidateCreate() error {
+	applog.Info("validate create", "name", r.Name)
+	return r.validate(true)
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *App) ValidateUpdate(old runtime.Object) error {
+	applog.Info("validate update", "name", r.Name)
+	deploying := true
+	if oldApp, ok := old.(*App); ok {
+		deploying = oldApp.Spec.Team != r.Spec.Team || oldApp.Spec.DeploymentsCount != r.Spec.DeploymentsCount
+	}
+	return r.validate(deploying)
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *App) ValidateDelete() error {
+	return nil
+}
+
+// validate checks the parts of the app's spec the CRD schema can't express,
+// so a mistake is reported when the app is applied and not when the controller fails to render its chart.
+// The cluster's allowed teams are checked only when the app is deployed, so existing apps keep getting
+// updates of the ingress controller after the allowed teams change.
+func (r *App) validate(deploying bool) error {
+	spec := field.NewPath("spec")
+	var errs field.ErrorList
+	errs = append(errs, validateCnames(r.Spec.Ingress.Cnames, spec.Child("ingress", "cnames"))...)
+	errs = append(errs, validateAuth(r.Spec.Ingress.Auth, spec.Child("ingress", "auth"))...)
+	errs = append(errs, validateAllowedCIDRs(r.Spec.Ingress.AllowedCIDRs, spec.Child("ingress", "allowedCIDRs"))...)
+	if len(r.Spec.InternalLoadBalancerAnnotations) > 0 && !r.Spec.IsInternal() {
+		errs = append(errs, field.Forbidden(spec.Child("internalLoadBalancerAnnotations"), "internal load balancers require the internal expose mode"))
+	}
+	errs = append(errs, validateOwnership(r.Spec, spec)...)
+	if deploying {
+		errs = append(errs, validateTeamAllowed(r.Spec, spec)...)
+	}
+	profile := r.Spec.Ingress.Controller.PodSecurityProfile
+	for i, deployment := range r.Spec.Deployments {
+		errs = append(errs, validateDeployment(deployment, spec.Child("deployments").Index(i))...)
+		for j, process := range deployment.Processes {
+			errs = append(errs, profile.ValidateSecurityContext(process.SecurityContext, spec.Child("deployments").Index(i).Child("processes").Index(j).Child("securityContext"))...)
+		}
+	}
+	errs = append(errs, profile.ValidatePodSecurityContext(r.Spec.SecurityContext, spec.Child("securityContext"))...)
+	errs = append(errs, validateMetadataItems(r.Spec.Labels, labelTargets, spec.Child("labels"))...)
+	errs = append(errs, validateMetadataItems(r.Spec.Annotations, annotationTargets, spec.Child("annotations"))...)
+	errs = append(errs, validateSchedules(r.Spec.Schedules, spec.Child("schedules"))...)
+	errs = append(errs, validateServiceBindings(r.Spec.ServiceBindings, spec.Child("serviceBindings"))...)
+	if len(errs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(schema.GroupKind{Group: Group, Kind: "App"}, r.Name, errs)
+}
+
+func validateCnames(cnames CnameList, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	primary := 0
+	for i, cname := range cnames {
+		if err := validation.ValidateCname(cname.Name); err != nil {
+			errs = append(errs, field.Invalid(path.Index(i).Child("name"), cname.Name, err.Error()))
+		}
+		if cname.Path != "" {
+			if err := validation.ValidateCnamePath(cname.Path); err != nil {
+				errs = append(errs, field.Invalid(path.Index(i).Child("path"), cname.Path, err.Error()))
+			}
+		}
+		if cname.Primary {
+			primary++
+			if primary > 1 {
+				errs = append(errs, field.Invalid(path.Index(i).Child("primary"), cname.Primary, "at most one cname can be primary"))
+			}
+		}
+		errs = append(errs, validateAuth(cname.Auth, path.Index(i).Child("auth"))...)
+		errs = append(errs, validateAllowedCIDRs(cname.AllowedCIDRs, path.Index(i).Child("allowedCIDRs"))...)
+	}
+	return errs
+}
+
+func validateAuth(auth *AuthSpec, path *field.Path) field.ErrorList {
+	if auth == nil {
+		return nil
+	}
+	if err := auth.Validate(); err != nil {
+		return field.ErrorList{field.Invalid(path, auth.String(), err.Error())}
+	}
+	return nil
+}
+
+func validateAllowedCIDRs(cidrs []string, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	for i, cidr := range cidrs {
+		if err := ValidateCIDRs([]string{cidr}); err != nil {
+			errs = append(errs, field.Invalid(path.Index(i), cidr, err.Error()))
+		}
+	}
+	return errs
+}
+
+// validateOwnership checks that the team and the owner can be used as label values.
+func validateOwnership(appSpec AppSpec, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	for _, msg := range k8svalidation.IsValidLabelValue(appSpec.Team) {
+		errs = append(errs, field.Invalid(path.Child("team"), appSpec.Team, msg))
+	}
+	for _, msg := range k8svalidation.IsValidLabelValue(appSpec.Owner) {
+		errs = append(errs, field.Invalid(path.Child("owner"), appSpec.Owner, msg))
+	}
+	return errs
+}
+
+// validateTeamAllowed checks that the app's team is allowed to deploy apps to the cluster.
+func validateTeamAllowed(appSpec AppSpec, path *field.Path) field.ErrorList {
+	controller := appSpec.Ingress.Controller
+	if controller.TeamAllowed(appSpec.Team) {
+		return nil
+	}
+	if appSpec.Team == "" {
+		return field.ErrorList{field.Required(path.Child("team"), fmt.Sprintf("apps must belong to one of the teams allowed by the cluster: %s", strings.Join(controller.AllowedTeams, ", ")))}
+	}
+	return field.ErrorList{field.NotSupported(path.Child("team"), appSpec.Team, controller.AllowedTeams)}
+}
+
+func validateDeployment(deployment AppDeploymentSpec, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	names := map[string]bool{}
+	for i, process := range deployment.Processes {
+		processPath := path.Child("processes").Index(i)
+		if msgs := k8svalidation.IsDNS1123Label(process.Name); len(msgs) > 0 {
+			errs = append(errs, field.Invalid(processPath.Child("name"), process.Name, "process name is used in names of kubernetes objects: "+strings.Join(msgs, ", ")))
+		}
+		if names[process.Name] {
+			errs = append(errs, field.Duplicate(processPath.Child("name"), process.Name))
+		}
+		names[process.Name] = true
+		if process.Units != nil && *process.Units < 0 {
+			errs = append(errs, field.Invalid(processPath.Child("units"), *process.Units, ErrNegativeUnits.Error()))
+		}
+		errs = append(errs, validateResources(process, processPath.Child("resources"))...)
+	}
+	for i, port := range deployment.ExposedPorts {
+		if port.Port == 0 && port.Protocol == "" {
+			// the image doesn't expose a port.
+			continue
+		}
+		portPath := path.Child("exposedPorts").Index(i)
+		if msgs := k8svalidation.IsValidPortNum(port.Port); len(msgs) > 0 {
+			errs = append(errs, field.Invalid(portPath.Child("port"), port.Port, strings.Join(msgs, ", ")))
+		}
+		errs = append(errs, validateProtocol(port.Protocol, portPath.Child("protocol"))...)
+	}
+	if deployment.KetchYaml != nil && deployment.KetchYaml.Kubernetes != nil {
+		errs = append(errs, validateKetchYamlProcesses(deployment.KetchYaml.Kubernetes.Processes, path.Child("ketchYaml", "kubernetes", "processes"))...)
+	}
+	if deployment.KetchYaml != nil {
+		errs = append(errs, validateHealthcheck(deployment.KetchYaml.Healthcheck, path.Child("ketchYaml", "healthcheck"))...)
+		errs = append(errs, validateScalers(deployment.KetchYaml.Scalers, names, path.Child("ketchYaml", "scalers"))...)
+		errs = append(errs, validateRequires(deployment.KetchYaml.Requires, path.Child("ketchYaml", "requires"))...)
+		if language := deployment.KetchYaml.Language; language != "" && ValidateOpenTelemetryLanguage(language) != nil {
+			errs = append(errs, field.NotSupported(path.Child("ketchYaml", "language"), language, OpenTelemetryLanguages))
+		}
+	}
+	return errs
+}
+
+// validateResources checks that quantities are not negative and requests don't exceed limits,
+// otherwise the API server rejects the process' pods and the app never gets units.
+func validateResources(process ProcessSpec, path *field.Path) field.ErrorList {
+	if process.Resources == nil {
+		return nil
+	}
+	var errs field.ErrorList
+	for _, name := range sortedResourceNames(process.Resources.Limits) {
+		quantity := process.Resources.Limits[name]
+		if quantity.Sign() < 0 {
+			errs = append(errs, field.Invalid(path.Child("limits").Key(string(name)), quantity.String(), "must be greater than or equal to 0"))
+		}
+	}
+	for _, name := range sortedResourceNames(process.Resources.Requests) {
+		quantity := process.Resources.Requests[name]
+		if quantity.Sign() < 0 {
+			errs = append(errs, field.Invalid(path.Child("requests").Key(string(name)), quantity.String(), "must be greater than or equal to 0"))
+			continue
+		}
+		if limit, ok := process.Resources.Limits[name]; ok && quantity.Cmp(limit) > 0 {
+			errs = append(errs, field.Invalid(path.Child("requests").Key(string(name)), quantity.String(), fmt.Sprintf("must be less than or equal to %s limit %s", name, limit.String())))
+		}
+	}
+	return errs
+}
+
+func sortedResourceNames(resources v1.ResourceList) []v1.ResourceName {
+	names := make([]v1.ResourceName, 0, len(resources))
+	for name := range resources {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
+
+// validateProcessBuild checks that the source of a process's image is a directory within the source code.
+func validateProcessBuild(build KetchYamlProcessBuild, path *field.Path) field.ErrorList {
+	sourcePath := path.Child("source")
+	if build.Source == "" {
+		return field.ErrorList{field.Required(sourcePath, "")}
+	}
+	if strings.HasPrefix(build.Source, "/") {
+		return field.ErrorList{field.Invalid(sourcePath, build.Source, "must be relative to the root of the source code")}
+	}
+	for _, element := range strings.Split(build.Source, "/") {
+		if element == ".." {
+			return field.ErrorList{field.Invalid(sourcePath, build.Source, "must not contain '..'")}
+		}
+	}
+	return nil
+}
+
+func validateRoute(route KetchYamlRoute, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if route.Name == "" {
+		errs = append(errs, field.Required(path.Child("name"), "route name is required"))
+	}
+	if route.PathRegex == "" {
+		errs = append(errs, field.Required(path.Child("pathRegex"), "route path regex is required"))
+	} else if _, err := regexp.Compile(route.PathRegex); err != nil {
+		errs = append(errs, field.Invalid(path.Child("pathRegex"), route.PathRegex, err.Error()))
+	}
+	if route.Timeout != "" {
+		if d, err := time.ParseDuration(route.Timeout); err != nil || d <= 0 {
+			errs = append(errs, field.Invalid(path.Child("timeout"), route.Timeout, "must be a positive duration, e.g. 5s"))
+		}
+	}
+	return errs
+}
+
+func validateMetrics(metrics KetchYamlMetrics, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if msgs := k8svalidation.IsValidPortNum(metrics.Port); len(msgs) > 0 {
+		errs = append(errs, field.Invalid(path.Child("port"), metrics.Port, strings.Join(msgs, ", ")))
+	}
+	if metrics.Path != "" && !strings.HasPrefix(metrics.Path, "/") {
+		errs = append(errs, field.Invalid(path.Child("path"), metrics.Path, "must start with /"))
+	}
+	if metrics.Interval != "" {
+		if d, err := time.ParseDuration(metrics.Interval); err != nil || d <= 0 {
+			errs = append(errs, field.Invalid(path.Child("interval"), metrics.Interval, "must be a positive duration, e.g. 30s"))
+		}
+	}
+	return errs
+}
+
+func validateKetchYamlProcesses(processes map[string]KetchYamlProcessConfig, path *field.Path) field.ErrorList {
+	names := make([]string, 0, len(processes))
+	for name := range processes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var errs field.ErrorList
+	for _, name := range names {
+		process := processes[name]
+		processPath := path.Key(name)
+		if process.Kind != "" {
+			if _, err := ParseAppType(process.Kind); err != nil {
+				errs = append(errs, field.NotSupported(processPath.Child("kind"), process.Kind, []string{"deployment", "statefulset", "daemonset"}))
+			}
+		}
+		if process.TerminationGracePeriodSeconds != nil && *process.TerminationGracePeriodSeconds < 0 {
+			errs = append(errs, field.Invalid(processPath.Child("terminationGracePeriodSeconds"), *process.TerminationGracePeriodSeconds, "must be greater than or equal to 0"))
+		}
+		if process.PriorityClassName != "" {
+			for _, msg := range k8svalidation.IsDNS1123Subdomain(process.PriorityClassName) {
+				errs = append(errs, field.Invalid(processPath.Child("priorityClassName"), process.PriorityClassName, msg))
+			}
+		}
+		if process.RuntimeClassName != "" {
+			for _, msg := range k8svalidation.IsDNS1123Subdomain(process.RuntimeClassName) {
+				errs = append(errs, field.Invalid(processPath.Child("runtimeClassName"), process.RuntimeClassName, msg))
+			}
+		}
+		switch process.HeadlessService {
+		case "", HeadlessServiceAlongside, HeadlessServiceInstead:
+		default:
+			errs = append(errs, field.NotSupported(processPath.Child("headlessService"), process.HeadlessService, []string{string(HeadlessServiceAlongside), string(HeadlessServiceInstead)}))
+		}
+		for i, route := range process.Routes {
+			errs = append(errs, validateRoute(route, processPath.Child("routes").Index(i))...)
+		}
+		if process.Metrics != nil {
+			errs = append(errs, validateMetrics(*process.Metrics, processPath.Child("metrics"))...)
+		}
+		if process.Build != nil {
+			errs = append(errs, validateProcessBuild(*process.Build, processPath.Child("build"))...)
+		}
+		errs = append(errs, validateHealthcheck(process.Healthcheck, processPath.Child("healthcheck"))...)
+		for i, port := range process.Ports {
+			portPath := processPath.Child("ports").Index(i)
+			if port.Port == 0 && port.TargetPort == 0 {
+				errs = append(errs, field.Required(portPath, "either port or target_port must be set"))
+			}
+			if port.Port != 0 {
+				if msgs := k8svalidation.IsValidPortNum(port.Port); len(msgs) > 0 {
+					errs = append(errs, field.Invalid(portPath.Child("port"), port.Port, strings.Join(msgs, ", ")))
+				}
+			}
+			if port.TargetPort != 0 {
+				if msgs := k8svalidation.IsValidPortNum(port.TargetPort); len(msgs) > 0 {
+					errs = append(errs, field.Invalid(portPath.Child("target_port"), port.TargetPort, strings.Join(msgs, ", ")))
+				}
+			}
+			errs = append(errs, validateProtocol(port.Protocol, portPath.Child("protocol"))...)
+		}
+	}
+	return errs
+}
+
+// validateHealthcheck checks that every probe has exactly one handler,
+// otherwise the API server rejects the pods of the processes.
+func validateHealthcheck(healthcheck *KetchYamlHealthcheck, path *field.Path) field.ErrorList {
+	if healthcheck == nil {
+		return nil
+	}
+	var errs field.ErrorList
+	errs = append(errs, validateProbe(healthcheck.LivenessProbe, path.Child("livenessProbe"))...)
+	errs = append(errs, validateProbe(healthcheck.ReadinessProbe, path.Child("readinessProbe"))...)
+	errs = append(errs, validateProbe(healthcheck.StartupProbe, path.Child("startupProbe"))...)
+	return errs
+}
+
+func validateProbe(probe *v1.Probe, path *field.Path) field.ErrorList {
+	if probe == nil {
+		return nil
+	}
+	var errs field.ErrorList
+	var handlers []string
+	if probe.Exec != nil {
+		handlers = append(handlers, "exec")
+		if len(probe.Exec.Command) == 0 {
+			errs = append(errs, field.Required(path.Child("exec", "command"), ""))
+		}
+	}
+	if probe.HTTPGet != nil {
+		handlers = append(handlers, "httpGet")
+		if probe.HTTPGet.Path != "" && !strings.HasPrefix(probe.HTTPGet.Path, "/") {
+			errs = append(errs, field.Invalid(path.Child("httpGet", "path"), probe.HTTPGet.Path, "must start with /"))
+		}
+		for i, header := range probe.HTTPGet.HTTPHeaders {
+			if msgs := k8svalidation.IsHTTPHeaderName(header.Name); len(msgs) > 0 {
+				errs = append(errs, field.Invalid(path.Child("httpGet", "httpHeaders").Index(i).Child("name"), header.Name, strings.Join(msgs, ", ")))
+			}
+		}
+	}
+	if probe.TCPSocket != nil {
+		handlers = append(handlers, "tcpSocket")
+	}
+	if probe.GRPC != nil {
+		handlers = append(handlers, "grpc")
+		if msgs := k8svalidation.IsValidPortNum(int(probe.GRPC.Port)); len(msgs) > 0 {
+			errs = append(errs, field.Invalid(path.Child("grpc", "port"), probe.GRPC.Port, strings.Join(msgs, ", ")))
+		}
+	}
+	switch len(handlers) {
+	case 0:
+		errs = append(errs, field.Required(path, "must specify one of exec, httpGet, tcpSocket or grpc"))
+	case 1:
+	default:
+		errs = append(errs, field.Forbidden(path.Child(handlers[1]), "may not specify more than 1 handler type"))
+	}
+	return errs
+}
+
+func validateScalers(scalers []KetchYamlScaler, processes map[string]bool, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	scaled := map[string]bool{}
+	for i, scaler := range scalers {
+		scalerPath := path.Index(i)
+		switch {
+		case !processes[scaler.Process]:
+			errs = append(errs, field.NotFound(scalerPath.Child("process"), scaler.Process))
+		case scaled[scaler.Process]:
+			errs = append(errs, field.Duplicate(scalerPath.Child("process"), scaler.Process))
+		}
+		scaled[scaler.Process] = true
+		if scaler.MinUnits != nil && *scaler.MinUnits < 0 {
+			errs = append(errs, field.Invalid(scalerPath.Child("minUnits"), *scaler.MinUnits, ErrNegativeUnits.Error()))
+		}
+		if scaler.MaxUnits != nil && (*scaler.MaxUnits < 1 || scaler.MinUnits != nil && *scaler.MaxUnits < *scaler.MinUnits) {
+			errs = append(errs, field.Invalid(scalerPath.Child("maxUnits"), *scaler.MaxUnits, "must be greater than 0 and greater than or equal to minUnits"))
+		}
+		if len(scaler.Triggers) == 0 {
+			errs = append(errs, field.Required(scalerPath.Child("triggers"), "at least one trigger is required"))
+		}
+		for j, trigger := range scaler.Triggers {
+			if trigger.Type == "" {
+				errs = append(errs, field.Required(scalerPath.Child("triggers").Index(j).Child("type"), ""))
+			}
+		}
+	}
+	return errs
+}
+
+func validateProtocol(protocol string, path *field.Path) field.ErrorList {
+	switch strings.ToUpper(protocol) {
+	case "", "TCP", "UDP", "SCTP":
+		return nil
+	}
+	return field.ErrorList{field.NotSupported(path, protocol, []string{"TCP", "UDP", "SCTP"})}
+}
+
+func validateMetadataItems(items []MetadataItem, targets []Target, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	for i, item := range items {
+		itemPath := path.Index(i)
+		if err := item.Validate(); err != nil {
+			errs = append(errs, field.Invalid(itemPath.Child("apply"), item.Apply, err.Error()))
+		}
+		supported := false
+		names := make([]string, 0, len(targets))
+		for _, target := range targets {
+			supported = supported || target == item.Target
+			names = append(names, target.APIVersion+" "+target.Kind)
+		}
+		if !supported {
+			errs = append(errs, field.NotSupported(itemPath.Child("target"), item.Target.APIVersion+" "+item.Target.Kind, names))
+		}
+	}
+	return errs
+}
+
+func validateSchedules(schedules []ScalingSchedule, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	names := map[string]bool{}
+	for i, schedule := range schedules {
+		schedulePath := path.Index(i)
+		if names[schedule.Name] {
+			errs = append(errs, field.Duplicate(schedulePath.Child("name"), schedule.Name))
+		}
+		names[schedule.Name] = true
+		if _, err := cronParser.Parse(schedule.Schedule); err != nil {
+			errs = append(errs, field.Invalid(schedulePath.Child("schedule"), schedule.Schedule, err.Error()))
+		}
+		if _, err := time.LoadLocation(schedule.TimeZone); err != nil {
+			errs = append(errs, field.Invalid(schedulePath.Child("timeZone"), schedule.TimeZone, err.Error()))
+		}
+		processes := make([]string, 0, len(schedule.Units))
+		for process := range schedule.Units {
+			processes = append(processes, process)
+		}
+		sort.Strings(processes)
+		for _, process := range processes {
+			if units := schedule.Units[process]; units < 0 {
+				errs = append(errs, field.Invalid(schedulePath.Child("units").Key(process), units, ErrNegativeUnits.Error()))
+			}
+		}
+	}
+	return errs
+}
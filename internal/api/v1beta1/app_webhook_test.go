@@ -0,0 +1,489 @@
+package v1beta1
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/theketchio/ketch/internal/api/v1beta1/mocks"
+)
+
+func TestApp_Default(t *testing.T) {
+	tests := []struct {
+		name        string
+		onGet       func(ctx context.Context, key client.ObjectKey, obj client.Object) error
+		wantLabels  []MetadataItem
+		wantProfile PodSecurityProfile
+		wantTeams   []string
+	}{
+		{
+			name: "defaults from the ingress configmap",
+			onGet: func(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+				require.Equal(t, client.ObjectKey{Name: IngressConfigmapName, Namespace: IngressConfigmapNamespace}, key)
+				obj.(*v1.ConfigMap).Data = map[string]string{AppDefaultsKey: "labels:\n  team: platform"}
+				return nil
+			},
+			wantLabels: []MetadataItem{{Target: Target{APIVersion: "v1", Kind: "Pod"}, Apply: map[string]string{"team": "platform"}}},
+		},
+		{
+			name: "pod security profile from the ingress configmap",
+			onGet: func(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+				obj.(*v1.ConfigMap).Data = map[string]string{"podSecurityProfile": "restricted"}
+				return nil
+			},
+			wantProfile: PodSecurityProfileRestricted,
+		},
+		{
+			name: "allowed teams from the ingress configmap",
+			onGet: func(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+				obj.(*v1.ConfigMap).Data = map[string]string{"allowedTeams": "payments, search"}
+				return nil
+			},
+			wantTeams: []string{"payments", "search"},
+		},
+		{
+			name: "no ingress configmap",
+			onGet: func(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+				return apierrors.NewNotFound(schema.GroupResource{Resource: "configmaps"}, key.Name)
+			},
+		},
+		{
+			name: "invalid defaults",
+			onGet: func(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+				obj.(*v1.ConfigMap).Data = map[string]string{AppDefaultsKey: "replicas: 2"}
+				return nil
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			appmgr = &mockManager{client: &mocks.MockClient{OnGet: tt.onGet}}
+			app := &App{ObjectMeta: metav1.ObjectMeta{Name: "go-app"}}
+			app.Default()
+			require.Equal(t, tt.wantLabels, app.Spec.Labels)
+			require.Equal(t, tt.wantProfile, app.Spec.Ingress.Controller.PodSecurityProfile)
+			require.Equal(t, tt.wantTeams, app.Spec.Ingress.Controller.AllowedTeams)
+		})
+	}
+}
+
+func TestApp_validate(t *testing.T) {
+	intPtr := func(i int) *int { return &i }
+	validApp := func() *App {
+		return &App{
+			ObjectMeta: metav1.ObjectMeta{Name: "go-app"},
+			Spec: AppSpec{
+				Deployments: []AppDeploymentSpec{
+					{
+						Version: 1,
+						Processes: []ProcessSpec{
+							{
+								Name:  "web",
+								Units: intPtr(2),
+								Resources: &v1.ResourceRequirements{
+									Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("100m")},
+									Limits:   v1.ResourceList{v1.ResourceCPU: resource.MustParse("200m")},
+								},
+							},
+							{Name: "worker"},
+						},
+						ExposedPorts: []ExposedPort{{Port: 8080, Protocol: "TCP"}, {}},
+						KetchYaml: &KetchYamlData{
+							Kubernetes: &KetchYamlKubernetesConfig{
+								Processes: map[string]KetchYamlProcessConfig{
+									"web": {Kind: "deployment", Ports: []KetchYamlProcessPortConfig{{Port: 80, TargetPort: 8080}}},
+								},
+							},
+						},
+					},
+				},
+				Ingress: IngressSpec{
+					Cnames: CnameList{{Name: "theketch.io", Path: "/api", Primary: true}, {Name: "*.theketch.io"}},
+				},
+				Labels: []MetadataItem{
+					{Target: Target{APIVersion: "v1", Kind: "Pod"}, Apply: map[string]string{"theketch.io/team": "a"}},
+				},
+				Annotations: []MetadataItem{
+					{Target: Target{APIVersion: "networking.k8s.io/v1", Kind: "Ingress"}, Apply: map[string]string{"nginx.ingress.kubernetes.io/ssl-redirect": "true"}},
+				},
+			},
+		}
+	}
+	tests := []struct {
+		name       string
+		modify     func(app *App)
+		wantFields []string
+	}{
+		{
+			name:   "valid app",
+			modify: func(app *App) {},
+		},
+		{
+			name: "invalid cnames",
+			modify: func(app *App) {
+				app.Spec.Ingress.Cnames = CnameList{
+					{Name: "10.10.10.10"},
+					{Name: "theketch.io", Path: "api", Primary: true},
+					{Name: "app.theketch.io", Primary: true},
+				}
+			},
+			wantFields: []string{
+				"spec.ingress.cnames[0].name",
+				"spec.ingress.cnames[1].path",
+				"spec.ingress.cnames[2].primary",
+			},
+		},
+		{
+			name: "invalid auth",
+			modify: func(app *App) {
+				app.Spec.Ingress.Auth = &AuthSpec{BasicAuthSecret: "users", ForwardAuthURL: "https://auth.theketch.io"}
+				app.Spec.Ingress.Cnames[1].Auth = &AuthSpec{OIDC: &OIDCAuth{Issuer: "accounts.theketch.io"}}
+			},
+			wantFields: []string{
+				"spec.ingress.cnames[1].auth",
+				"spec.ingress.auth",
+			},
+		},
+		{
+			name: "invalid allowed cidrs",
+			modify: func(app *App) {
+				app.Spec.Ingress.AllowedCIDRs = []string{"10.0.0.0/8", "10.0.0.1"}
+				app.Spec.Ingress.Cnames[0].AllowedCIDRs = []string{"office"}
+			},
+			wantFields: []string{
+				"spec.ingress.cnames[0].allowedCIDRs[0]",
+				"spec.ingress.allowedCIDRs[1]",
+			},
+		},
+		{
+			name: "internal load balancer of a public app",
+			modify: func(app *App) {
+				app.Spec.InternalLoadBalancerAnnotations = map[string]string{"networking.gke.io/load-balancer-type": "Internal"}
+			},
+			wantFields: []string{
+				"spec.internalLoadBalancerAnnotations",
+			},
+		},
+		{
+			name: "invalid processes",
+			modify: func(app *App) {
+				app.Spec.Deployments[0].Processes = []ProcessSpec{
+					{Name: "Web_1"},
+					{Name: "worker", Units: intPtr(-1)},
+					{Name: "worker"},
+				}
+			},
+			wantFields: []string{
+				"spec.deployments[0].processes[0].name",
+				"spec.deployments[0].processes[1].units",
+				"spec.deployments[0].processes[2].name",
+			},
+		},
+		{
+			name: "requests exceed limits",
+			modify: func(app *App) {
+				app.Spec.Deployments[0].Processes[0].Resources = &v1.ResourceRequirements{
+					Requests: v1.ResourceList{v1.ResourceMemory: resource.MustParse("1Gi"), v1.ResourceCPU: resource.MustParse("-1")},
+					Limits:   v1.ResourceList{v1.ResourceMemory: resource.MustParse("512Mi")},
+				}
+			},
+			wantFields: []string{
+				"spec.deployments[0].processes[0].resources.requests[cpu]",
+				"spec.deployments[0].processes[0].resources.requests[memory]",
+			},
+		},
+		{
+			name: "invalid ports",
+			modify: func(app *App) {
+				app.Spec.Deployments[0].ExposedPorts = []ExposedPort{{Port: 70000, Protocol: "HTTP"}}
+				app.Spec.Deployments[0].KetchYaml.Kubernetes.Processes = map[string]KetchYamlProcessConfig{
+					"web": {Kind: "job", Ports: []KetchYamlProcessPortConfig{{Name: "http"}, {TargetPort: -1}}},
+				}
+			},
+			wantFields: []string{
+				"spec.deployments[0].exposedPorts[0].port",
+				"spec.deployments[0].exposedPorts[0].protocol",
+				"spec.deployments[0].ketchYaml.kubernetes.processes[web].kind",
+				"spec.deployments[0].ketchYaml.kubernetes.processes[web].ports[0]",
+				"spec.deployments[0].ketchYaml.kubernetes.processes[web].ports[1].target_port",
+			},
+		},
+		{
+			name: "invalid termination grace period",
+			modify: func(app *App) {
+				gracePeriod := int64(-1)
+				app.Spec.Deployments[0].KetchYaml.Kubernetes.Processes["web"] = KetchYamlProcessConfig{TerminationGracePeriodSeconds: &gracePeriod}
+			},
+			wantFields: []string{
+				"spec.deployments[0].ketchYaml.kubernetes.processes[web].terminationGracePeriodSeconds",
+			},
+		},
+		{
+			name: "invalid priority and runtime classes",
+			modify: func(app *App) {
+				app.Spec.Deployments[0].KetchYaml.Kubernetes.Processes["web"] = KetchYamlProcessConfig{
+					PriorityClassName: "High_Priority",
+					RuntimeClassName:  "gVisor",
+				}
+			},
+			wantFields: []string{
+				"spec.deployments[0].ketchYaml.kubernetes.processes[web].priorityClassName",
+				"spec.deployments[0].ketchYaml.kubernetes.processes[web].runtimeClassName",
+			},
+		},
+		{
+			name: "unsupported language",
+			modify: func(app *App) {
+				app.Spec.Deployments[0].KetchYaml.Language = "cobol"
+			},
+			wantFields: []string{
+				"spec.deployments[0].ketchYaml.language",
+			},
+		},
+		{
+			name: "invalid routes",
+			modify: func(app *App) {
+				app.Spec.Deployments[0].KetchYaml.Kubernetes.Processes["web"] = KetchYamlProcessConfig{
+					Routes: []KetchYamlRoute{
+						{Name: "GET /books/{id}", Method: "GET", PathRegex: "/books/[^/]+", Timeout: "5s"},
+						{PathRegex: "/books/("},
+						{Name: "POST /books", PathRegex: "/books", Timeout: "5"},
+					},
+				}
+			},
+			wantFields: []string{
+				"spec.deployments[0].ketchYaml.kubernetes.processes[web].routes[1].name",
+				"spec.deployments[0].ketchYaml.kubernetes.processes[web].routes[1].pathRegex",
+				"spec.deployments[0].ketchYaml.kubernetes.processes[web].routes[2].timeout",
+			},
+		},
+		{
+			name: "invalid metrics",
+			modify: func(app *App) {
+				app.Spec.Deployments[0].KetchYaml.Kubernetes.Processes["web"] = KetchYamlProcessConfig{
+					Metrics: &KetchYamlMetrics{Port: 0, Path: "metrics", Interval: "30"},
+				}
+				app.Spec.Deployments[0].KetchYaml.Kubernetes.Processes["worker"] = KetchYamlProcessConfig{
+					Metrics: &KetchYamlMetrics{Port: 9102, Path: "/metrics", Interval: "30s"},
+				}
+			},
+			wantFields: []string{
+				"spec.deployments[0].ketchYaml.kubernetes.processes[web].metrics.port",
+				"spec.deployments[0].ketchYaml.kubernetes.processes[web].metrics.path",
+				"spec.deployments[0].ketchYaml.kubernetes.processes[web].metrics.interval",
+			},
+		},
+		{
+			name: "invalid headless service",
+			modify: func(app *App) {
+				app.Spec.Deployments[0].KetchYaml.Kubernetes.Processes["web"] = KetchYamlProcessConfig{HeadlessService: "only"}
+			},
+			wantFields: []string{
+				"spec.deployments[0].ketchYaml.kubernetes.processes[web].headlessService",
+			},
+		},
+		{
+			name: "invalid process builds",
+			modify: func(app *App) {
+				app.Spec.Deployments[0].KetchYaml.Kubernetes.Processes["web"] = KetchYamlProcessConfig{
+					Build: &KetchYamlProcessBuild{Source: "../web"},
+				}
+				app.Spec.Deployments[0].KetchYaml.Kubernetes.Processes["worker"] = KetchYamlProcessConfig{
+					Build: &KetchYamlProcessBuild{},
+				}
+			},
+			wantFields: []string{
+				"spec.deployments[0].ketchYaml.kubernetes.processes[web].build.source",
+				"spec.deployments[0].ketchYaml.kubernetes.processes[worker].build.source",
+			},
+		},
+		{
+			name: "violates the pod security profile",
+			modify: func(app *App) {
+				runAsUser := int64(0)
+				privileged := true
+				app.Spec.Ingress.Controller.PodSecurityProfile = PodSecurityProfileRestricted
+				app.Spec.SecurityContext = &v1.PodSecurityContext{RunAsUser: &runAsUser}
+				app.Spec.Deployments[0].Processes[1].SecurityContext = &v1.SecurityContext{Privileged: &privileged}
+			},
+			wantFields: []string{
+				"spec.deployments[0].processes[1].securityContext.privileged",
+				"spec.securityContext.runAsUser",
+			},
+		},
+		{
+			name: "invalid metadata",
+			modify: func(app *App) {
+				app.Spec.Labels = []MetadataItem{
+					{Target: Target{APIVersion: "networking.k8s.io/v1", Kind: "Ingress"}, Apply: map[string]string{"team": "a"}},
+				}
+				app.Spec.Annotations = []MetadataItem{
+					{Target: Target{APIVersion: "v1", Kind: "Service"}, Apply: map[string]string{"-invalid": "a"}},
+				}
+			},
+			wantFields: []string{
+				"spec.labels[0].target",
+				"spec.annotations[0].apply",
+			},
+		},
+		{
+			name: "team allowed by the cluster",
+			modify: func(app *App) {
+				app.Spec.Team = "payments"
+				app.Spec.Owner = "alice"
+				app.Spec.Ingress.Controller.AllowedTeams = []string{"search", "payments"}
+			},
+		},
+		{
+			name: "team not allowed by the cluster",
+			modify: func(app *App) {
+				app.Spec.Team = "marketing"
+				app.Spec.Owner = "alice@example.com"
+				app.Spec.Ingress.Controller.AllowedTeams = []string{"search", "payments"}
+			},
+			wantFields: []string{
+				"spec.owner",
+				"spec.team",
+			},
+		},
+		{
+			name: "no team while the cluster allows some teams",
+			modify: func(app *App) {
+				app.Spec.Ingress.Controller.AllowedTeams = []string{"payments"}
+			},
+			wantFields: []string{
+				"spec.team",
+			},
+		},
+		{
+			name: "invalid scalers",
+			modify: func(app *App) {
+				app.Spec.Deployments[0].KetchYaml.Scalers = []KetchYamlScaler{
+					{Process: "worker", MinUnits: intPtr(0), MaxUnits: intPtr(10), Triggers: []KetchYamlScalerTrigger{{Type: "kafka"}}},
+					{Process: "worker", MinUnits: intPtr(-1), Triggers: []KetchYamlScalerTrigger{{}}},
+					{Process: "api", MinUnits: intPtr(5), MaxUnits: intPtr(2)},
+				}
+			},
+			wantFields: []string{
+				"spec.deployments[0].ketchYaml.scalers[1].process",
+				"spec.deployments[0].ketchYaml.scalers[1].minUnits",
+				"spec.deployments[0].ketchYaml.scalers[1].triggers[0].type",
+				"spec.deployments[0].ketchYaml.scalers[2].process",
+				"spec.deployments[0].ketchYaml.scalers[2].maxUnits",
+				"spec.deployments[0].ketchYaml.scalers[2].triggers",
+			},
+		},
+		{
+			name: "invalid probes",
+			modify: func(app *App) {
+				ketchYaml := app.Spec.Deployments[0].KetchYaml
+				ketchYaml.Healthcheck = &KetchYamlHealthcheck{
+					LivenessProbe:  &v1.Probe{ProbeHandler: v1.ProbeHandler{HTTPGet: &v1.HTTPGetAction{Path: "health", HTTPHeaders: []v1.HTTPHeader{{Name: "X Probe"}}}}},
+					ReadinessProbe: &v1.Probe{ProbeHandler: v1.ProbeHandler{GRPC: &v1.GRPCAction{Port: 9090}}},
+				}
+				ketchYaml.Kubernetes.Processes["worker"] = KetchYamlProcessConfig{
+					Healthcheck: &KetchYamlHealthcheck{
+						LivenessProbe:  &v1.Probe{ProbeHandler: v1.ProbeHandler{Exec: &v1.ExecAction{}}},
+						ReadinessProbe: &v1.Probe{ProbeHandler: v1.ProbeHandler{GRPC: &v1.GRPCAction{Port: 70000}, TCPSocket: &v1.TCPSocketAction{}}},
+						StartupProbe:   &v1.Probe{},
+					},
+				}
+			},
+			wantFields: []string{
+				"spec.deployments[0].ketchYaml.kubernetes.processes[worker].healthcheck.livenessProbe.exec.command",
+				"spec.deployments[0].ketchYaml.kubernetes.processes[worker].healthcheck.readinessProbe.grpc.port",
+				"spec.deployments[0].ketchYaml.kubernetes.processes[worker].healthcheck.readinessProbe.grpc",
+				"spec.deployments[0].ketchYaml.kubernetes.processes[worker].healthcheck.startupProbe",
+				"spec.deployments[0].ketchYaml.healthcheck.livenessProbe.httpGet.path",
+				"spec.deployments[0].ketchYaml.healthcheck.livenessProbe.httpGet.httpHeaders[0].name",
+			},
+		},
+		{
+			name: "invalid schedules",
+			modify: func(app *App) {
+				app.Spec.Schedules = []ScalingSchedule{
+					{Name: "day", Schedule: "0 8 * * 1-5", TimeZone: "Europe/Berlin", Units: map[string]int{"web": 10}},
+					{Name: "night", Schedule: "0 25 * * *", TimeZone: "Mars/Olympus", Units: map[string]int{"web": -1}},
+					{Name: "day", Schedule: "@daily"},
+				}
+			},
+			wantFields: []string{
+				"spec.schedules[1].schedule",
+				"spec.schedules[1].timeZone",
+				"spec.schedules[1].units[web]",
+				"spec.schedules[2].name",
+			},
+		},
+		{
+			name: "invalid service bindings",
+			modify: func(app *App) {
+				app.Spec.ServiceBindings = []ServiceBinding{
+					{Name: "db", SecretName: "postgres", EnvPrefix: "DATABASE_"},
+					{Name: "db", SecretName: "mysql", EnvPrefix: "DB-"},
+				}
+			},
+			wantFields: []string{
+				"spec.serviceBindings[1].name",
+				"spec.serviceBindings[1].envPrefix",
+			},
+		},
+		{
+			name: "invalid required dependencies",
+			modify: func(app *App) {
+				app.Spec.Deployments[0].KetchYaml.Requires = []string{"postgres", "Redis_Cache", "postgres"}
+			},
+			wantFields: []string{
+				"spec.deployments[0].ketchYaml.requires[1]",
+				"spec.deployments[0].ketchYaml.requires[2]",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := validApp()
+			tt.modify(app)
+			err := app.ValidateCreate()
+			if len(tt.wantFields) == 0 {
+				require.Nil(t, err)
+				require.Nil(t, app.ValidateUpdate(app.DeepCopy()))
+				return
+			}
+			require.True(t, apierrors.IsInvalid(err), "unexpected error: %v", err)
+			var fields []string
+			for _, cause := range err.(*apierrors.StatusError).ErrStatus.Details.Causes {
+				fields = append(fields, cause.Field)
+			}
+			require.Equal(t, tt.wantFields, fields, err.Error())
+		})
+	}
+}
+
+func TestApp_ValidateUpdate_allowedTeams(t *testing.T) {
+	old := &App{
+		ObjectMeta: metav1.ObjectMeta{Name: "go-app"},
+		Spec: AppSpec{
+			Team:             "marketing",
+			DeploymentsCount: 1,
+			Deployments:      []AppDeploymentSpec{{Version: 1, Processes: []ProcessSpec{{Name: "web"}}}},
+		},
+	}
+	app := old.DeepCopy()
+	app.Spec.Ingress.Controller.AllowedTeams = []string{"payments"}
+	// apps deployed before the cluster restricted teams keep getting updates of the ingress controller.
+	require.Nil(t, app.ValidateUpdate(old))
+
+	app.Spec.DeploymentsCount = 2
+	app.Spec.Deployments = append(app.Spec.Deployments, AppDeploymentSpec{Version: 2, Processes: []ProcessSpec{{Name: "web"}}})
+	err := app.ValidateUpdate(old)
+	require.True(t, apierrors.IsInvalid(err), "unexpected error: %v", err)
+
+	app.Spec.Team = "payments"
+	require.Nil(t, app.ValidateUpdate(old))
+}
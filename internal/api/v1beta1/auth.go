@@ -0,0 +1,94 @@
+package v1beta1
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// AuthSpec protects endpoints of an app, ingress controllers reject requests that fail the authentication.
+// Exactly one of BasicAuthSecret, ForwardAuthURL and OIDC must be set.
+// nginx and traefik support basic and forward auth, istio supports OIDC.
+type AuthSpec struct {
+	// BasicAuthSecret is a name of a secret in the app's namespace with users in the htpasswd format,
+	// nginx reads them from the "auth" key of the secret and traefik from the "users" key.
+	BasicAuthSecret string `json:"basicAuthSecret,omitempty"`
+
+	// ForwardAuthURL is a URL of an authentication service like oauth2-proxy,
+	// a request is allowed if the service responds with a 2xx status to a copy of the request.
+	ForwardAuthURL string `json:"forwardAuthURL,omitempty"`
+
+	// OIDC requires requests to carry a JWT issued by an OIDC provider.
+	OIDC *OIDCAuth `json:"oidc,omitempty"`
+}
+
+// OIDCAuth describes an OIDC provider issuing JWTs of requests.
+type OIDCAuth struct {
+	// Issuer of JWTs, e.g. "https://accounts.google.com".
+	Issuer string `json:"issuer"`
+
+	// JWKSURI is a URL of the issuer's public keys,
+	// if omitted, it's discovered with the OpenID configuration of the issuer.
+	JWKSURI string `json:"jwksURI,omitempty"`
+}
+
+// Validate returns an error if the auth is incomplete or ambiguous.
+func (s AuthSpec) Validate() error {
+	set := 0
+	if s.BasicAuthSecret != "" {
+		set++
+	}
+	if s.ForwardAuthURL != "" {
+		set++
+		if err := validateURL("forward auth url", s.ForwardAuthURL); err != nil {
+			return err
+		}
+	}
+	if s.OIDC != nil {
+		set++
+		if s.OIDC.Issuer == "" {
+			return errors.New("oidc issuer is required")
+		}
+		if err := validateURL("oidc issuer", s.OIDC.Issuer); err != nil {
+			return err
+		}
+		if s.OIDC.JWKSURI != "" {
+			if err := validateURL("oidc jwks uri", s.OIDC.JWKSURI); err != nil {
+				return err
+			}
+		}
+	}
+	if set != 1 {
+		return errors.New("exactly one of basic auth secret, forward auth url and oidc must be set")
+	}
+	return nil
+}
+
+func validateURL(name, value string) error {
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid %s %q", name, value)
+	}
+	return nil
+}
+
+// String returns a human-readable description of the auth, e.g. "basic auth secret staging-users".
+func (s AuthSpec) String() string {
+	switch {
+	case s.BasicAuthSecret != "":
+		return "basic auth secret " + s.BasicAuthSecret
+	case s.ForwardAuthURL != "":
+		return "forward auth " + s.ForwardAuthURL
+	case s.OIDC != nil:
+		return "oidc issuer " + s.OIDC.Issuer
+	}
+	return ""
+}
+
+// CnameAuth returns the auth protecting the cname, the cname's own auth takes precedence over the app's auth.
+func (s IngressSpec) CnameAuth(cname Cname) *AuthSpec {
+	if cname.Auth != nil {
+		return cname.Auth
+	}
+	return s.Auth
+}
@@ -0,0 +1,71 @@
+package v1beta1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthSpec_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		auth    AuthSpec
+		wantErr string
+	}{
+		{
+			name: "basic auth",
+			auth: AuthSpec{BasicAuthSecret: "staging-users"},
+		},
+		{
+			name: "forward auth",
+			auth: AuthSpec{ForwardAuthURL: "http://oauth2-proxy.auth.svc/oauth2/auth"},
+		},
+		{
+			name: "oidc",
+			auth: AuthSpec{OIDC: &OIDCAuth{Issuer: "https://accounts.google.com", JWKSURI: "https://www.googleapis.com/oauth2/v3/certs"}},
+		},
+		{
+			name:    "nothing set",
+			wantErr: "exactly one of basic auth secret, forward auth url and oidc must be set",
+		},
+		{
+			name:    "basic and forward auth",
+			auth:    AuthSpec{BasicAuthSecret: "staging-users", ForwardAuthURL: "https://auth.theketch.io"},
+			wantErr: "exactly one of basic auth secret, forward auth url and oidc must be set",
+		},
+		{
+			name:    "forward auth url without scheme",
+			auth:    AuthSpec{ForwardAuthURL: "auth.theketch.io/verify"},
+			wantErr: `invalid forward auth url "auth.theketch.io/verify"`,
+		},
+		{
+			name:    "oidc without issuer",
+			auth:    AuthSpec{OIDC: &OIDCAuth{JWKSURI: "https://www.googleapis.com/oauth2/v3/certs"}},
+			wantErr: "oidc issuer is required",
+		},
+		{
+			name:    "invalid jwks uri",
+			auth:    AuthSpec{OIDC: &OIDCAuth{Issuer: "https://accounts.google.com", JWKSURI: "certs"}},
+			wantErr: `invalid oidc jwks uri "certs"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.auth.Validate()
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.Nil(t, err)
+		})
+	}
+}
+
+func TestIngressSpec_CnameAuth(t *testing.T) {
+	appAuth := &AuthSpec{BasicAuthSecret: "staging-users"}
+	cnameAuth := &AuthSpec{ForwardAuthURL: "https://auth.theketch.io"}
+	spec := IngressSpec{Auth: appAuth}
+	require.Equal(t, appAuth, spec.CnameAuth(Cname{Name: "theketch.io"}))
+	require.Equal(t, cnameAuth, spec.CnameAuth(Cname{Name: "theketch.io", Auth: cnameAuth}))
+	require.Nil(t, IngressSpec{}.CnameAuth(Cname{Name: "theketch.io"}))
+}
@@ -0,0 +1,62 @@
+package v1beta1
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// CanaryCookieValue is a value of the canary cookie that routes a request to the canary deployment.
+const CanaryCookieValue = "always"
+
+var (
+	canaryHeaderNameRegex  = regexp.MustCompile(`^[A-Za-z0-9-]+$`)
+	canaryCookieNameRegex  = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+	canaryHeaderValueRegex = regexp.MustCompile("^[^\"`]+$")
+)
+
+// CanaryRouting routes requests that match it to the canary deployment regardless of the canary's weight.
+type CanaryRouting struct {
+	// Header is a name of a request header, requests with the header set to HeaderValue go to the canary deployment.
+	Header      string `json:"header,omitempty"`
+	HeaderValue string `json:"headerValue,omitempty"`
+
+	// Cookie is a name of a cookie, requests with the cookie set to "always" go to the canary deployment.
+	Cookie string `json:"cookie,omitempty"`
+}
+
+// ParseCanaryHeader parses a header rule of a canary deployment in the form "name=value".
+func ParseCanaryHeader(rule string) (name string, value string, err error) {
+	parts := strings.SplitN(rule, "=", 2)
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("canary header must be in the form name=value")
+	}
+	name, value = strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+	if !canaryHeaderNameRegex.MatchString(name) {
+		return "", "", fmt.Errorf("invalid canary header name %q", name)
+	}
+	if !canaryHeaderValueRegex.MatchString(value) {
+		return "", "", fmt.Errorf("invalid canary header value %q", value)
+	}
+	return name, value, nil
+}
+
+// ValidateCanaryCookie returns an error if the name can't be used as a canary cookie.
+func ValidateCanaryCookie(name string) error {
+	if !canaryCookieNameRegex.MatchString(name) {
+		return fmt.Errorf("invalid canary cookie name %q", name)
+	}
+	return nil
+}
+
+// String returns a human-readable description of the rules.
+func (r CanaryRouting) String() string {
+	var rules []string
+	if r.Header != "" {
+		rules = append(rules, fmt.Sprintf("header %s=%s", r.Header, r.HeaderValue))
+	}
+	if r.Cookie != "" {
+		rules = append(rules, fmt.Sprintf("cookie %s=%s", r.Cookie, CanaryCookieValue))
+	}
+	return strings.Join(rules, ", ")
+}
@@ -0,0 +1,67 @@
+package v1beta1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCanaryHeader(t *testing.T) {
+	tests := []struct {
+		name      string
+		rule      string
+		wantName  string
+		wantValue string
+		wantErr   string
+	}{
+		{
+			name:      "name and value",
+			rule:      "X-Canary=true",
+			wantName:  "X-Canary",
+			wantValue: "true",
+		},
+		{
+			name:      "value with an equal sign",
+			rule:      "X-Canary-Token=abc=",
+			wantName:  "X-Canary-Token",
+			wantValue: "abc=",
+		},
+		{
+			name:    "no value",
+			rule:    "X-Canary",
+			wantErr: "canary header must be in the form name=value",
+		},
+		{
+			name:    "invalid name",
+			rule:    "X Canary=true",
+			wantErr: `invalid canary header name "X Canary"`,
+		},
+		{
+			name:    "empty value",
+			rule:    "X-Canary=",
+			wantErr: `invalid canary header value ""`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, value, err := ParseCanaryHeader(tt.rule)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.wantName, name)
+			require.Equal(t, tt.wantValue, value)
+		})
+	}
+}
+
+func TestValidateCanaryCookie(t *testing.T) {
+	require.Nil(t, ValidateCanaryCookie("canary_v2"))
+	require.EqualError(t, ValidateCanaryCookie("canary;v2"), `invalid canary cookie name "canary;v2"`)
+}
+
+func TestCanaryRouting_String(t *testing.T) {
+	routing := CanaryRouting{Header: "X-Canary", HeaderValue: "true", Cookie: "canary"}
+	require.Equal(t, "header X-Canary=true, cookie canary=always", routing.String())
+}
@@ -0,0 +1,116 @@
+package v1beta1
+
+import (
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// CertificateIssuerKey is a key of the ingress configmap with a YAML configuration of the certificate issuer of apps' cnames.
+const CertificateIssuerKey = "certificateIssuer"
+
+// IssuerKind is a kind of a cert-manager issuer.
+type IssuerKind string
+
+const (
+	ClusterIssuerKind IssuerKind = "ClusterIssuer"
+	// NamespacedIssuerKind is an Issuer, it must exist in the namespace of certificates.
+	NamespacedIssuerKind IssuerKind = "Issuer"
+)
+
+// ACMESolverType is a type of ACME challenges a certificate is validated with.
+type ACMESolverType string
+
+const (
+	HTTP01SolverType ACMESolverType = "http01"
+	DNS01SolverType  ACMESolverType = "dns01"
+)
+
+// IssuerRef references a cert-manager issuer.
+type IssuerRef struct {
+	Name string `json:"name"`
+
+	// Kind is either "ClusterIssuer" or "Issuer", it defaults to "ClusterIssuer".
+	// +kubebuilder:validation:Enum=ClusterIssuer;Issuer
+	Kind IssuerKind `json:"kind,omitempty"`
+}
+
+// CertificateIssuerSpec configures how cert-manager issues certificates of secure cnames.
+type CertificateIssuerSpec struct {
+	// IssuerRef references the issuer of certificates.
+	// A namespaced Issuer must exist in namespaces of apps, or in istio-system with istio.
+	IssuerRef IssuerRef `json:"issuerRef"`
+
+	// Solver is the preferred ACME solver of cnames, "http01" or "dns01".
+	// Wildcard cnames are always validated with "dns01" since http01 can't validate them.
+	// +kubebuilder:validation:Enum=http01;dns01
+	Solver ACMESolverType `json:"solver,omitempty"`
+
+	// SolverLabels are labels of certificates by solver type,
+	// the issuer selects its solver of a certificate with solvers' selector.matchLabels.
+	SolverLabels map[ACMESolverType]map[string]string `json:"solverLabels,omitempty"`
+}
+
+// ParseCertificateIssuer returns the certificate issuer of the ingress configmap's certificateIssuer, nil if it's empty.
+func ParseCertificateIssuer(data string) (*CertificateIssuerSpec, error) {
+	if strings.TrimSpace(data) == "" {
+		return nil, nil
+	}
+	var issuer CertificateIssuerSpec
+	if err := yaml.UnmarshalStrict([]byte(data), &issuer); err != nil {
+		return nil, fmt.Errorf("invalid certificate issuer: %w", err)
+	}
+	if issuer.IssuerRef.Name == "" {
+		return nil, fmt.Errorf("invalid certificate issuer: issuerRef.name is required")
+	}
+	if issuer.IssuerRef.Kind == "" {
+		issuer.IssuerRef.Kind = ClusterIssuerKind
+	}
+	if issuer.IssuerRef.Kind != ClusterIssuerKind && issuer.IssuerRef.Kind != NamespacedIssuerKind {
+		return nil, fmt.Errorf("invalid certificate issuer: unsupported kind %q, it must be ClusterIssuer or Issuer", issuer.IssuerRef.Kind)
+	}
+	solvers := []ACMESolverType{}
+	if issuer.Solver != "" {
+		solvers = append(solvers, issuer.Solver)
+	}
+	for solver := range issuer.SolverLabels {
+		solvers = append(solvers, solver)
+	}
+	for _, solver := range solvers {
+		if solver != HTTP01SolverType && solver != DNS01SolverType {
+			return nil, fmt.Errorf("invalid certificate issuer: unsupported solver %q, it must be http01 or dns01", solver)
+		}
+	}
+	return &issuer, nil
+}
+
+// IssuerRef returns the issuer of certificates of secure cnames, nil if neither CertificateIssuer nor ClusterIssuer is set.
+// CertificateIssuer takes precedence over ClusterIssuer.
+func (s IngressControllerSpec) IssuerRef() *IssuerRef {
+	if s.CertificateIssuer != nil && s.CertificateIssuer.IssuerRef.Name != "" {
+		ref := s.CertificateIssuer.IssuerRef
+		if ref.Kind == "" {
+			ref.Kind = ClusterIssuerKind
+		}
+		return &ref
+	}
+	if s.ClusterIssuer != "" {
+		return &IssuerRef{Name: s.ClusterIssuer, Kind: ClusterIssuerKind}
+	}
+	return nil
+}
+
+// CertificateSolver returns the ACME solver type of the cname's certificate and labels selecting the solver.
+func (s IngressControllerSpec) CertificateSolver(cname string) (ACMESolverType, map[string]string) {
+	solver := HTTP01SolverType
+	if strings.HasPrefix(cname, "*.") {
+		solver = DNS01SolverType
+	} else if s.CertificateIssuer != nil && s.CertificateIssuer.Solver != "" {
+		solver = s.CertificateIssuer.Solver
+	}
+	if s.CertificateIssuer == nil {
+		return solver, nil
+	}
+	return solver, s.CertificateIssuer.SolverLabels[solver]
+}
@@ -0,0 +1,89 @@
+package v1beta1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCertificateIssuer(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    *CertificateIssuerSpec
+		wantErr string
+	}{
+		{
+			name: "empty",
+			data: "\n",
+		},
+		{
+			name: "issuer with solver labels",
+			data: `
+issuerRef:
+  name: letsencrypt
+  kind: Issuer
+solver: dns01
+solverLabels:
+  dns01:
+    acme-solver: route53
+`,
+			want: &CertificateIssuerSpec{
+				IssuerRef:    IssuerRef{Name: "letsencrypt", Kind: NamespacedIssuerKind},
+				Solver:       DNS01SolverType,
+				SolverLabels: map[ACMESolverType]map[string]string{DNS01SolverType: {"acme-solver": "route53"}},
+			},
+		},
+		{
+			name: "cluster issuer by default",
+			data: "issuerRef:\n  name: letsencrypt\n",
+			want: &CertificateIssuerSpec{IssuerRef: IssuerRef{Name: "letsencrypt", Kind: ClusterIssuerKind}},
+		},
+		{
+			name:    "no issuer name",
+			data:    "solver: dns01\n",
+			wantErr: "invalid certificate issuer: issuerRef.name is required",
+		},
+		{
+			name:    "unsupported solver",
+			data:    "issuerRef:\n  name: letsencrypt\nsolverLabels:\n  tlsalpn01:\n    acme-solver: alpn\n",
+			wantErr: `invalid certificate issuer: unsupported solver "tlsalpn01", it must be http01 or dns01`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCertificateIssuer(tt.data)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestIngressControllerSpec_IssuerRef(t *testing.T) {
+	require.Nil(t, IngressControllerSpec{}.IssuerRef())
+	require.Equal(t, &IssuerRef{Name: "letsencrypt", Kind: ClusterIssuerKind}, IngressControllerSpec{ClusterIssuer: "letsencrypt"}.IssuerRef())
+
+	spec := IngressControllerSpec{
+		ClusterIssuer: "letsencrypt",
+		CertificateIssuer: &CertificateIssuerSpec{
+			IssuerRef:    IssuerRef{Name: "internal-ca", Kind: NamespacedIssuerKind},
+			SolverLabels: map[ACMESolverType]map[string]string{DNS01SolverType: {"acme-solver": "route53"}},
+		},
+	}
+	require.Equal(t, &IssuerRef{Name: "internal-ca", Kind: NamespacedIssuerKind}, spec.IssuerRef())
+
+	solver, labels := spec.CertificateSolver("theketch.io")
+	require.Equal(t, HTTP01SolverType, solver)
+	require.Nil(t, labels)
+	solver, labels = spec.CertificateSolver("*.apps.theketch.io")
+	require.Equal(t, DNS01SolverType, solver)
+	require.Equal(t, map[string]string{"acme-solver": "route53"}, labels)
+
+	spec.CertificateIssuer.Solver = DNS01SolverType
+	solver, _ = spec.CertificateSolver("theketch.io")
+	require.Equal(t, DNS01SolverType, solver)
+}
@@ -12,6 +12,26 @@ const (
 
 	// Scheduled indicates whether the has been processed by ketch-controller.
 	Scheduled ConditionType = "Scheduled"
+
+	// ReleaseReady indicates whether the app's helm release accepts new operations or is locked by a pending one.
+	ReleaseReady ConditionType = "ReleaseReady"
+
+	// IngressMigrated indicates whether resources of the previous ingress controller have been removed
+	// after the app's ingress controller type was changed.
+	IngressMigrated ConditionType = "IngressMigrated"
+
+	// Deploying indicates whether the latest deployment of the app is rolling out.
+	// It becomes false when the rollout finishes, fails or doesn't finish in time.
+	// "ketch app deploy" doesn't start another deployment of the app while it's true unless it's run with --queue.
+	Deploying ConditionType = "Deploying"
+
+	// Removed indicates whether resources of a removed app have been cleaned up.
+	// It's false while the cleanup fails and the app can't disappear.
+	Removed ConditionType = "Removed"
+
+	// Drifted indicates whether resources of the app were edited and diverge from the app's chart.
+	// It's only true for apps with the "report" drift policy, edits are reverted with the "revert" policy.
+	Drifted ConditionType = "Drifted"
 )
 
 // Condition contains details for the current condition of this app.
@@ -0,0 +1,153 @@
+package v1beta1
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	k8svalidation "k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/yaml"
+)
+
+// DependencyProvisionersKey is a key of the ingress configmap with a YAML list of dependency provisioners.
+const DependencyProvisionersKey = "dependencyProvisioners"
+
+// ProvisionerType is a type of a dependency provisioner.
+type ProvisionerType string
+
+const (
+	// CrossplaneProvisionerType creates a Crossplane claim that writes connection details of the dependency to a secret.
+	CrossplaneProvisionerType ProvisionerType = "crossplane"
+	// ExternalSecretsProvisionerType creates an ExternalSecret that syncs connection details of an existing dependency to a secret.
+	ExternalSecretsProvisionerType ProvisionerType = "externalSecrets"
+)
+
+// DependencyProvisioner configures how a dependency apps declare in "requires" of ketch.yaml is provisioned.
+type DependencyProvisioner struct {
+	// Name of the dependency, e.g. "postgres".
+	Name string `json:"name"`
+
+	// Type is either "crossplane" or "externalSecrets".
+	Type ProvisionerType `json:"type"`
+
+	// APIVersion of the Crossplane claim, e.g. "database.example.org/v1alpha1".
+	APIVersion string `json:"apiVersion,omitempty"`
+
+	// Kind of the Crossplane claim, e.g. "PostgreSQLInstance".
+	Kind string `json:"kind,omitempty"`
+
+	// Parameters are set as spec.parameters of the Crossplane claim.
+	// +optional
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Parameters *runtime.RawExtension `json:"parameters,omitempty"`
+
+	// SecretStore is a name of the ClusterSecretStore an ExternalSecret reads connection details from.
+	SecretStore string `json:"secretStore,omitempty"`
+
+	// RemoteKey is a key of connection details in the secret store, "{{app}}" is replaced with the app's name.
+	RemoteKey string `json:"remoteKey,omitempty"`
+}
+
+// DependencyStatus is the status of a dependency of the app.
+type DependencyStatus struct {
+	Name string `json:"name"`
+
+	// Provisioner is the type of the provisioner of the dependency.
+	Provisioner ProvisionerType `json:"provisioner,omitempty"`
+
+	// SecretName is a name of the secret in the app's namespace with connection details of the dependency.
+	SecretName string `json:"secretName,omitempty"`
+
+	// Ready is true once connection details of the dependency are available.
+	Ready bool `json:"ready"`
+
+	// Message explains why the dependency isn't ready.
+	Message string `json:"message,omitempty"`
+}
+
+// ParseDependencyProvisioners returns dependency provisioners of the ingress configmap's dependencyProvisioners.
+func ParseDependencyProvisioners(data string) ([]DependencyProvisioner, error) {
+	var provisioners []DependencyProvisioner
+	if err := yaml.Unmarshal([]byte(data), &provisioners); err != nil {
+		return nil, err
+	}
+	return provisioners, nil
+}
+
+// DependencyProvisioner returns the provisioner of the dependency.
+func (s IngressControllerSpec) DependencyProvisioner(name string) (DependencyProvisioner, bool) {
+	for _, provisioner := range s.DependencyProvisioners {
+		if provisioner.Name == name {
+			return provisioner, true
+		}
+	}
+	return DependencyProvisioner{}, false
+}
+
+// RequiredDependencies returns dependencies the latest deployment of the app requires in its ketch.yaml.
+func (app *App) RequiredDependencies() []string {
+	n := len(app.Spec.Deployments)
+	if n == 0 || app.Spec.Deployments[n-1].KetchYaml == nil {
+		return nil
+	}
+	return app.Spec.Deployments[n-1].KetchYaml.Requires
+}
+
+// DependencySecretName returns a name of the secret with connection details of the app's dependency.
+// It's also the name of resources created to provision the dependency.
+func DependencySecretName(appName, dependency string) string {
+	return appName + "-" + dependency
+}
+
+// Dependency returns the status of the dependency with the given name.
+func (s AppStatus) Dependency(name string) *DependencyStatus {
+	for i := range s.Dependencies {
+		if s.Dependencies[i].Name == name {
+			return &s.Dependencies[i]
+		}
+	}
+	return nil
+}
+
+// PendingDependencies returns names of required dependencies that aren't ready yet.
+func (app *App) PendingDependencies() []string {
+	var pending []string
+	for _, name := range app.RequiredDependencies() {
+		if status := app.Status.Dependency(name); status == nil || !status.Ready {
+			pending = append(pending, name)
+		}
+	}
+	return pending
+}
+
+// Bindings returns service bindings of the app and bindings of its ready dependencies,
+// connection details of a dependency are set as env variables prefixed with its name, e.g. POSTGRES_HOST.
+func (app *App) Bindings() []ServiceBinding {
+	bindings := append([]ServiceBinding{}, app.Spec.ServiceBindings...)
+	for _, dependency := range app.Status.Dependencies {
+		if !dependency.Ready {
+			continue
+		}
+		bindings = append(bindings, ServiceBinding{
+			Name:       dependency.Name,
+			SecretName: dependency.SecretName,
+			EnvPrefix:  nonEnvNameChars.ReplaceAllString(strings.ToUpper(dependency.Name), "_") + "_",
+		})
+	}
+	return bindings
+}
+
+func validateRequires(requires []string, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	names := map[string]bool{}
+	for i, name := range requires {
+		if msgs := k8svalidation.IsDNS1123Label(name); len(msgs) > 0 {
+			errs = append(errs, field.Invalid(path.Index(i), name, "dependency name is used in names of kubernetes objects: "+strings.Join(msgs, ", ")))
+		}
+		if names[name] {
+			errs = append(errs, field.Duplicate(path.Index(i), name))
+		}
+		names[name] = true
+	}
+	return errs
+}
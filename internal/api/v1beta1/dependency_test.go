@@ -0,0 +1,74 @@
+package v1beta1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestParseDependencyProvisioners(t *testing.T) {
+	provisioners, err := ParseDependencyProvisioners(`
+- name: postgres
+  type: crossplane
+  apiVersion: database.example.org/v1alpha1
+  kind: PostgreSQLInstance
+  parameters:
+    storageGB: 20
+- name: redis
+  type: externalSecrets
+  secretStore: vault
+  remoteKey: apps/{{app}}/redis
+`)
+	require.Nil(t, err)
+	require.Equal(t, []DependencyProvisioner{
+		{
+			Name:       "postgres",
+			Type:       CrossplaneProvisionerType,
+			APIVersion: "database.example.org/v1alpha1",
+			Kind:       "PostgreSQLInstance",
+			Parameters: &runtime.RawExtension{Raw: []byte(`{"storageGB":20}`)},
+		},
+		{Name: "redis", Type: ExternalSecretsProvisionerType, SecretStore: "vault", RemoteKey: "apps/{{app}}/redis"},
+	}, provisioners)
+
+	spec := IngressControllerSpec{DependencyProvisioners: provisioners}
+	redis, ok := spec.DependencyProvisioner("redis")
+	require.True(t, ok)
+	require.Equal(t, "vault", redis.SecretStore)
+	_, ok = spec.DependencyProvisioner("mysql")
+	require.False(t, ok)
+
+	_, err = ParseDependencyProvisioners("name: postgres")
+	require.NotNil(t, err)
+}
+
+func TestApp_Dependencies(t *testing.T) {
+	app := &App{
+		Spec: AppSpec{
+			Deployments: []AppDeploymentSpec{
+				{Version: 1},
+				{Version: 2, KetchYaml: &KetchYamlData{Requires: []string{"postgres", "redis-cache"}}},
+			},
+			ServiceBindings: []ServiceBinding{{Name: "queue", SecretName: "rabbitmq"}},
+		},
+		Status: AppStatus{
+			Dependencies: []DependencyStatus{
+				{Name: "postgres", Provisioner: CrossplaneProvisionerType, SecretName: "app-postgres", Ready: true},
+				{Name: "redis-cache", Provisioner: ExternalSecretsProvisionerType, SecretName: "app-redis-cache"},
+			},
+		},
+	}
+	require.Equal(t, []string{"postgres", "redis-cache"}, app.RequiredDependencies())
+	require.Equal(t, []string{"redis-cache"}, app.PendingDependencies())
+	require.Equal(t, []ServiceBinding{
+		{Name: "queue", SecretName: "rabbitmq"},
+		{Name: "postgres", SecretName: "app-postgres", EnvPrefix: "POSTGRES_"},
+	}, app.Bindings())
+
+	app.Status.Dependencies[1].Ready = true
+	require.Empty(t, app.PendingDependencies())
+	require.Equal(t, "REDIS_CACHE_", app.Bindings()[2].EnvPrefix)
+	require.Equal(t, "app-redis-cache", app.Status.Dependency("redis-cache").SecretName)
+	require.Nil(t, app.Status.Dependency("mysql"))
+}
@@ -0,0 +1,68 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// EnvSetSpec defines the desired state of EnvSet.
+type EnvSetSpec struct {
+	// Env is a list of environment variables shared by applications referencing the set.
+	Env []Env `json:"env,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+
+// EnvSet is a named group of environment variables applications reference by name in their envSets.
+type EnvSet struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec EnvSetSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// EnvSetList contains a list of EnvSet.
+type EnvSetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []EnvSet `json:"items"`
+}
+
+// objectGetter gets objects, it's implemented by controller-runtime clients.
+type objectGetter interface {
+	Get(ctx context.Context, key client.ObjectKey, obj client.Object) error
+}
+
+// GetAppEnvSets gets the env sets referenced by the app in the order it references them.
+func GetAppEnvSets(ctx context.Context, getter objectGetter, app *App) ([]EnvSet, error) {
+	envSets := make([]EnvSet, 0, len(app.Spec.EnvSets))
+	for _, name := range app.Spec.EnvSets {
+		var envSet EnvSet
+		if err := getter.Get(ctx, types.NamespacedName{Name: name}, &envSet); err != nil {
+			return nil, fmt.Errorf("failed to get env set %q: %w", name, err)
+		}
+		envSets = append(envSets, envSet)
+	}
+	return envSets, nil
+}
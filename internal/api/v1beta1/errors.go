@@ -12,6 +12,12 @@ const (
 	// ErrDeploymentNotFound is returned when an operation can not be completed because there is no such deployment.
 	ErrDeploymentNotFound Error = "deployment not found"
 
+	// ErrNegativeUnits is returned when an operation would leave a process with a negative number of units.
+	ErrNegativeUnits Error = "the number of units can't be negative"
+
+	// ErrUnknownProcessKind is returned when ketch.yaml configures a process with an unsupported kind of workload.
+	ErrUnknownProcessKind Error = "unknown process kind"
+
 	// ErrJobExists
 	ErrJobExists Error = "failed to create job because the job already exists"
 )
@@ -0,0 +1,25 @@
+package v1beta1
+
+import "fmt"
+
+// ExposeMode describes how clients reach an application.
+type ExposeMode string
+
+const (
+	// ExposePublic apps are reached through cnames served by the ingress controller.
+	ExposePublic ExposeMode = "public"
+
+	// ExposeInternal apps are reached only inside the cluster through their services,
+	// ketch doesn't create ingress objects and certificates of their cnames.
+	ExposeInternal ExposeMode = "internal"
+)
+
+// IsInternal returns true if the app is reached only inside the cluster.
+func (s AppSpec) IsInternal() bool {
+	return s.Expose == ExposeInternal
+}
+
+// ServiceDNSName returns the in-cluster DNS name of a service of the app.
+func (app *App) ServiceDNSName(service string) string {
+	return fmt.Sprintf("%s.%s.svc.cluster.local", service, app.Spec.Namespace)
+}
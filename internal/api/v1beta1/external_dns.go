@@ -0,0 +1,112 @@
+package v1beta1
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// ExternalDNSKey is a key of the ingress configmap with a YAML configuration of external-dns annotations.
+	ExternalDNSKey = "externalDNS"
+
+	// ExternalDNSAnnotationPrefix is a prefix of annotations external-dns reads from ingress objects.
+	ExternalDNSAnnotationPrefix = "external-dns.alpha.kubernetes.io/"
+)
+
+// ExternalDNSSpec configures external-dns annotations of ingress objects of apps,
+// so external-dns creates DNS records of apps' cnames.
+type ExternalDNSSpec struct {
+	// Target of DNS records, it defaults to the ingress controller's ServiceEndpoint.
+	Target string `json:"target,omitempty"`
+
+	// TTL of DNS records in seconds, external-dns uses the provider's default if it's 0.
+	// +kubebuilder:validation:Minimum=0
+	TTL int64 `json:"ttl,omitempty"`
+
+	// ProviderHints are provider-specific annotations without the "external-dns.alpha.kubernetes.io/" prefix,
+	// e.g. "cloudflare-proxied".
+	ProviderHints map[string]string `json:"providerHints,omitempty"`
+}
+
+// CnameDNS overrides external-dns settings of the cluster for a cname.
+type CnameDNS struct {
+	// Target of the cname's DNS record.
+	Target string `json:"target,omitempty"`
+
+	// TTL of the cname's DNS record in seconds.
+	// +kubebuilder:validation:Minimum=0
+	TTL int64 `json:"ttl,omitempty"`
+
+	// ProviderHints are merged with provider hints of the cluster, the cname's hints take precedence.
+	ProviderHints map[string]string `json:"providerHints,omitempty"`
+}
+
+// ParseExternalDNS returns external-dns settings of the ingress configmap's externalDNS, nil if it's empty.
+func ParseExternalDNS(data string) (*ExternalDNSSpec, error) {
+	if strings.TrimSpace(data) == "" {
+		return nil, nil
+	}
+	var spec ExternalDNSSpec
+	if err := yaml.UnmarshalStrict([]byte(data), &spec); err != nil {
+		return nil, fmt.Errorf("invalid external-dns settings: %w", err)
+	}
+	if spec.TTL < 0 {
+		return nil, fmt.Errorf("invalid external-dns settings: ttl must be greater than or equal to 0")
+	}
+	return &spec, nil
+}
+
+// ExternalDNSAnnotations returns external-dns annotations of ingress objects serving the cname,
+// nil if external-dns settings of the cluster aren't configured.
+func (s IngressControllerSpec) ExternalDNSAnnotations(cname Cname) map[string]string {
+	if s.ExternalDNS == nil {
+		return nil
+	}
+	annotations := map[string]string{}
+	target, ttl := s.ExternalDNS.Target, s.ExternalDNS.TTL
+	if target == "" {
+		target = s.ServiceEndpoint
+	}
+	for name, value := range s.ExternalDNS.ProviderHints {
+		annotations[ExternalDNSAnnotationPrefix+name] = value
+	}
+	if cname.DNS != nil {
+		if cname.DNS.Target != "" {
+			target = cname.DNS.Target
+		}
+		if cname.DNS.TTL > 0 {
+			ttl = cname.DNS.TTL
+		}
+		for name, value := range cname.DNS.ProviderHints {
+			annotations[ExternalDNSAnnotationPrefix+name] = value
+		}
+	}
+	if target != "" {
+		annotations[ExternalDNSAnnotationPrefix+"target"] = target
+	}
+	if ttl > 0 {
+		annotations[ExternalDNSAnnotationPrefix+"ttl"] = strconv.FormatInt(ttl, 10)
+	}
+	return annotations
+}
+
+// String returns the cname's DNS overrides in a short form, e.g. "target lb.example.com, ttl 60".
+func (d CnameDNS) String() string {
+	var parts []string
+	if d.Target != "" {
+		parts = append(parts, "target "+d.Target)
+	}
+	if d.TTL > 0 {
+		parts = append(parts, fmt.Sprintf("ttl %d", d.TTL))
+	}
+	hints := make([]string, 0, len(d.ProviderHints))
+	for name, value := range d.ProviderHints {
+		hints = append(hints, name+"="+value)
+	}
+	sort.Strings(hints)
+	return strings.Join(append(parts, hints...), ", ")
+}
@@ -0,0 +1,111 @@
+package v1beta1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseExternalDNS(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    *ExternalDNSSpec
+		wantErr string
+	}{
+		{
+			name: "empty",
+			data: "\n",
+		},
+		{
+			name: "target, ttl and provider hints",
+			data: `
+target: lb.theketch.io
+ttl: 300
+providerHints:
+  cloudflare-proxied: "true"
+`,
+			want: &ExternalDNSSpec{
+				Target:        "lb.theketch.io",
+				TTL:           300,
+				ProviderHints: map[string]string{"cloudflare-proxied": "true"},
+			},
+		},
+		{
+			name:    "negative ttl",
+			data:    "ttl: -1\n",
+			wantErr: "invalid external-dns settings: ttl must be greater than or equal to 0",
+		},
+		{
+			name:    "unknown field",
+			data:    "hostname: theketch.io\n",
+			wantErr: `invalid external-dns settings: error unmarshaling JSON: while decoding JSON: json: unknown field "hostname"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseExternalDNS(tt.data)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestIngressControllerSpec_ExternalDNSAnnotations(t *testing.T) {
+	tests := []struct {
+		name  string
+		spec  IngressControllerSpec
+		cname Cname
+		want  map[string]string
+	}{
+		{
+			name:  "external-dns isn't configured",
+			spec:  IngressControllerSpec{ServiceEndpoint: "10.10.10.10"},
+			cname: Cname{Name: "theketch.io"},
+		},
+		{
+			name: "target defaults to the service endpoint",
+			spec: IngressControllerSpec{
+				ServiceEndpoint: "10.10.10.10",
+				ExternalDNS:     &ExternalDNSSpec{TTL: 300, ProviderHints: map[string]string{"cloudflare-proxied": "true"}},
+			},
+			cname: Cname{Name: "theketch.io"},
+			want: map[string]string{
+				"external-dns.alpha.kubernetes.io/target":             "10.10.10.10",
+				"external-dns.alpha.kubernetes.io/ttl":                "300",
+				"external-dns.alpha.kubernetes.io/cloudflare-proxied": "true",
+			},
+		},
+		{
+			name: "cname overrides",
+			spec: IngressControllerSpec{
+				ServiceEndpoint: "10.10.10.10",
+				ExternalDNS: &ExternalDNSSpec{
+					Target:        "lb.theketch.io",
+					ProviderHints: map[string]string{"cloudflare-proxied": "true", "aws-weight": "10"},
+				},
+			},
+			cname: Cname{Name: "theketch.io", DNS: &CnameDNS{Target: "edge.theketch.io", TTL: 60, ProviderHints: map[string]string{"cloudflare-proxied": "false"}}},
+			want: map[string]string{
+				"external-dns.alpha.kubernetes.io/target":             "edge.theketch.io",
+				"external-dns.alpha.kubernetes.io/ttl":                "60",
+				"external-dns.alpha.kubernetes.io/cloudflare-proxied": "false",
+				"external-dns.alpha.kubernetes.io/aws-weight":         "10",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.spec.ExternalDNSAnnotations(tt.cname))
+		})
+	}
+}
+
+func TestCnameDNS_String(t *testing.T) {
+	dns := CnameDNS{Target: "lb.theketch.io", TTL: 60, ProviderHints: map[string]string{"cloudflare-proxied": "false", "aws-weight": "10"}}
+	require.Equal(t, "target lb.theketch.io, ttl 60, aws-weight=10, cloudflare-proxied=false", dns.String())
+}
@@ -0,0 +1,63 @@
+package v1beta1
+
+import (
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// GrafanaDashboardsKey is a key of the ingress configmap with a YAML configuration of grafana dashboards of apps.
+	GrafanaDashboardsKey = "grafanaDashboards"
+
+	// grafanaDashboardLabel is a label of configmaps the grafana sidecar provisions dashboards of by default.
+	grafanaDashboardLabel = "grafana_dashboard"
+
+	// defaultGrafanaDatasource is the name of a prometheus datasource panels of dashboards query by default.
+	defaultGrafanaDatasource = "Prometheus"
+)
+
+// GrafanaDashboardsSpec tells ketch to create a configmap with a grafana dashboard of every app,
+// the grafana sidecar provisions dashboards of configmaps with its label.
+// Panels query metrics of the ingress controller, kube-state-metrics and cAdvisor,
+// kube-state-metrics must expose the app-name and app-process labels of pods.
+type GrafanaDashboardsSpec struct {
+	// Labels of dashboard configmaps, they default to "grafana_dashboard: 1".
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Annotations of dashboard configmaps, e.g. "grafana_folder: apps".
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Datasource is the default prometheus datasource of dashboards, it defaults to "Prometheus".
+	Datasource string `json:"datasource,omitempty"`
+}
+
+// ParseGrafanaDashboards returns grafana dashboard settings of the ingress configmap's grafanaDashboards, nil if it's empty.
+// "{}" turns dashboards on with default settings.
+func ParseGrafanaDashboards(data string) (*GrafanaDashboardsSpec, error) {
+	if strings.TrimSpace(data) == "" {
+		return nil, nil
+	}
+	var spec GrafanaDashboardsSpec
+	if err := yaml.UnmarshalStrict([]byte(data), &spec); err != nil {
+		return nil, fmt.Errorf("invalid grafana dashboards settings: %w", err)
+	}
+	return &spec, nil
+}
+
+// ConfigMapLabels returns labels of dashboard configmaps.
+func (s GrafanaDashboardsSpec) ConfigMapLabels() map[string]string {
+	if len(s.Labels) == 0 {
+		return map[string]string{grafanaDashboardLabel: "1"}
+	}
+	return s.Labels
+}
+
+// DatasourceName returns the default prometheus datasource of dashboards.
+func (s GrafanaDashboardsSpec) DatasourceName() string {
+	if s.Datasource == "" {
+		return defaultGrafanaDatasource
+	}
+	return s.Datasource
+}
@@ -0,0 +1,67 @@
+package v1beta1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGrafanaDashboards(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    *GrafanaDashboardsSpec
+		wantErr string
+	}{
+		{
+			name: "empty",
+			data: "\n",
+		},
+		{
+			name: "default settings",
+			data: "{}\n",
+			want: &GrafanaDashboardsSpec{},
+		},
+		{
+			name: "labels, annotations and datasource",
+			data: `
+labels:
+  dashboards: ketch
+annotations:
+  grafana_folder: apps
+datasource: Thanos
+`,
+			want: &GrafanaDashboardsSpec{
+				Labels:      map[string]string{"dashboards": "ketch"},
+				Annotations: map[string]string{"grafana_folder": "apps"},
+				Datasource:  "Thanos",
+			},
+		},
+		{
+			name:    "unknown field",
+			data:    "folder: apps\n",
+			wantErr: "invalid grafana dashboards settings",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseGrafanaDashboards(tt.data)
+			if len(tt.wantErr) > 0 {
+				require.NotNil(t, err)
+				require.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestGrafanaDashboardsSpec_Defaults(t *testing.T) {
+	require.Equal(t, map[string]string{"grafana_dashboard": "1"}, GrafanaDashboardsSpec{}.ConfigMapLabels())
+	require.Equal(t, "Prometheus", GrafanaDashboardsSpec{}.DatasourceName())
+
+	spec := GrafanaDashboardsSpec{Labels: map[string]string{"dashboards": "ketch"}, Datasource: "Thanos"}
+	require.Equal(t, map[string]string{"dashboards": "ketch"}, spec.ConfigMapLabels())
+	require.Equal(t, "Thanos", spec.DatasourceName())
+}
@@ -67,6 +67,7 @@ func AddToScheme(opts ...schemeOption) func(s *runtime.Scheme) error {
 	builder := &scheme.Builder{GroupVersion: groupVersion}
 	builder.Register(&App{}, &AppList{})
 	builder.Register(&Job{}, &JobList{})
+	builder.Register(&EnvSet{}, &EnvSetList{})
 	Group = options.group
 	return builder.AddToScheme
 }
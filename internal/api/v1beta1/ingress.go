@@ -19,6 +19,9 @@ package v1beta1
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 
 	"k8s.io/apimachinery/pkg/types"
 
@@ -38,6 +41,19 @@ const (
 
 	IngressConfigmapNamespace = "default"
 	IngressConfigmapName      = "ketch-ingress"
+
+	// DefaultEnvsKey is a key of the ingress configmap with env variables of all apps.
+	DefaultEnvsKey = "defaultEnvs"
+	// RegistryMirrorsKey is a key of the ingress configmap with pull-through caches of upstream registries.
+	RegistryMirrorsKey = "registryMirrors"
+	// ServiceAccountRolesKey is a key of the ingress configmap with roles apps can bind to their service accounts.
+	ServiceAccountRolesKey = "serviceAccountRoles"
+	// EngineKey is a key of the ingress configmap with the engine installing charts of new apps and jobs,
+	// either "helm" or "server-side-apply". Charts are installed with helm if it's not set.
+	EngineKey = "engine"
+	// DriftPolicyKey is a key of the ingress configmap with what ketch-controller does when resources of apps are edited,
+	// either "revert" or "report". Edits are reported if it's not set.
+	DriftPolicyKey = "driftPolicy"
 )
 
 // IngressControllerSpec contains configuration for an ingress controller.
@@ -46,6 +62,122 @@ type IngressControllerSpec struct {
 	ServiceEndpoint string                `json:"serviceEndpoint,omitempty"`
 	IngressType     IngressControllerType `json:"type,omitempty"`
 	ClusterIssuer   string                `json:"clusterIssuer,omitempty"`
+	// ForceHTTPS is a default of apps that don't set IngressSpec.ForceHTTPS.
+	ForceHTTPS bool `json:"forceHTTPS,omitempty"`
+	// Namespace where the ingress controller's pods run.
+	// If not set, the namespace of the controller's default installation is used.
+	Namespace string `json:"namespace,omitempty"`
+	// NetworkPolicy is a default of apps that don't set NetworkPolicySpec.Enabled.
+	NetworkPolicy bool `json:"networkPolicy,omitempty"`
+	// Templates is a name of a configmap in ketch's namespace with chart templates
+	// that replace or extend the built-in templates of the ingress controller type.
+	Templates string `json:"templates,omitempty"`
+	// PreStopSleepSeconds is the number of seconds routable processes sleep before they are stopped,
+	// so the ingress controller stops sending requests to them before they shut down.
+	PreStopSleepSeconds int64 `json:"preStopSleepSeconds,omitempty"`
+	// PodSecurityProfile is a level of the Pod Security Standards pods of apps comply with,
+	// processes get the profile's security context defaults and apps violating the profile are rejected.
+	PodSecurityProfile PodSecurityProfile `json:"podSecurityProfile,omitempty"`
+	// Registry is a docker registry shared by all apps.
+	Registry *RegistrySpec `json:"registry,omitempty"`
+	// AllowedTeams is a list of teams that can deploy apps to the cluster, any team can if it's empty.
+	AllowedTeams []string `json:"allowedTeams,omitempty"`
+	// DefaultEnvs are env variables of all apps, e.g. a region or a telemetry endpoint.
+	// Variables set by an app or its processes take precedence.
+	DefaultEnvs []Env `json:"defaultEnvs,omitempty"`
+	// DependencyProvisioners provision dependencies apps declare in "requires" of their ketch.yaml.
+	DependencyProvisioners []DependencyProvisioner `json:"dependencyProvisioners,omitempty"`
+	// CertificateIssuer configures the issuer and ACME solvers of certificates of secure cnames,
+	// it takes precedence over ClusterIssuer.
+	CertificateIssuer *CertificateIssuerSpec `json:"certificateIssuer,omitempty"`
+	// ExternalDNS enables external-dns annotations of ingress objects of apps, so DNS records of their cnames are created.
+	ExternalDNS *ExternalDNSSpec `json:"externalDNS,omitempty"`
+	// MaintenanceImage is an nginx image serving maintenance pages of apps in maintenance mode.
+	MaintenanceImage string `json:"maintenanceImage,omitempty"`
+	// MaintenancePage is an HTML page of apps in maintenance mode that don't have a page of their own.
+	MaintenancePage string `json:"maintenancePage,omitempty"`
+	// ServiceMesh is a service mesh pods of apps are part of.
+	ServiceMesh ServiceMeshType `json:"serviceMesh,omitempty"`
+	// OpenTelemetry instruments apps with OpenTelemetry agents and collector sidecars.
+	OpenTelemetry *OpenTelemetrySpec `json:"openTelemetry,omitempty"`
+	// Logging describes how the cluster's log agent ships logs of apps.
+	Logging *LoggingSpec `json:"logging,omitempty"`
+	// GrafanaDashboards if set, every app gets a configmap with a grafana dashboard.
+	GrafanaDashboards *GrafanaDashboardsSpec `json:"grafanaDashboards,omitempty"`
+	// Notifications are receivers notified about events of all apps in addition to receivers of each app.
+	Notifications []NotificationSpec `json:"notifications,omitempty"`
+	// ServiceAccountRoles are roles apps can bind to their service accounts with serviceAccount.roles of ketch.yaml,
+	// apps can't bind any role if it's empty.
+	ServiceAccountRoles []KetchYamlRoleRef `json:"serviceAccountRoles,omitempty"`
+}
+
+// TeamAllowed returns true if apps of the team can be deployed to the cluster.
+func (s IngressControllerSpec) TeamAllowed(team string) bool {
+	if len(s.AllowedTeams) == 0 {
+		return true
+	}
+	for _, allowed := range s.AllowedTeams {
+		if allowed == team {
+			return true
+		}
+	}
+	return false
+}
+
+// RoleAllowed returns true if apps can bind the role to their service accounts.
+func (s IngressControllerSpec) RoleAllowed(role KetchYamlRoleRef) bool {
+	for _, allowed := range s.ServiceAccountRoles {
+		if allowed == role {
+			return true
+		}
+	}
+	return false
+}
+
+// RegistrySpec describes a docker registry shared by all apps of the cluster.
+type RegistrySpec struct {
+	// URL is the registry host optionally followed by a path prefix, e.g. "registry.example.com/apps".
+	// Images of apps built from source without an explicit image are pushed to this location.
+	URL string `json:"url,omitempty"`
+	// SecretName is a docker-registry secret used to pull images of apps that don't set their own secret.
+	SecretName string `json:"secretName,omitempty"`
+	// Mirror is a pull-through cache of the registry, images hosted by the registry are pulled from it instead.
+	// It takes precedence over an entry of Mirrors for the host of the registry.
+	Mirror string `json:"mirror,omitempty"`
+	// Mirrors are pull-through caches of upstream registries like docker.io used by all apps.
+	Mirrors []RegistryMirror `json:"mirrors,omitempty"`
+	// BuildCache is a registry path build caches of apps built from source are pushed to, e.g. "registry.example.com/cache".
+	BuildCache string `json:"buildCache,omitempty"`
+}
+
+// Host returns the host of the registry.
+func (s RegistrySpec) Host() string {
+	return strings.SplitN(strings.TrimSuffix(s.URL, "/"), "/", 2)[0]
+}
+
+// Image returns the image an app built from source is pushed to.
+func (s RegistrySpec) Image(appName string) string {
+	return strings.TrimSuffix(s.URL, "/") + "/" + appName
+}
+
+// CacheImage returns the image the build cache of an app is pushed to.
+func (s RegistrySpec) CacheImage(appName string) string {
+	return strings.TrimSuffix(s.BuildCache, "/") + "/" + appName
+}
+
+// defaultControllerNamespaces are namespaces of default installations of ingress controllers.
+var defaultControllerNamespaces = map[IngressControllerType]string{
+	NginxIngressControllerType:   "ingress-nginx",
+	TraefikIngressControllerType: "traefik",
+	IstioIngressControllerType:   "istio-system",
+}
+
+// GetNamespace returns the namespace where the ingress controller's pods run.
+func (s IngressControllerSpec) GetNamespace() string {
+	if s.Namespace != "" {
+		return s.Namespace
+	}
+	return defaultControllerNamespaces[s.IngressType]
 }
 
 // GetIngressControllerSpec gets the ketch-ingress configmap and returns an IngressControllerSpec from the configmap's data
@@ -65,10 +197,135 @@ func NewIngressControllerSpec(configmap v1.ConfigMap) *IngressControllerSpec {
 	if len(controllerType) == 0 {
 		controllerType = IngressControllerType(configmap.Data["ingressType"])
 	}
+	// an invalid value turns the pre-stop sleep off.
+	preStopSleepSeconds, _ := strconv.ParseInt(configmap.Data["preStopSleepSeconds"], 10, 64)
+	// an unsupported profile turns the pod security profile off.
+	podSecurityProfile, _ := ParsePodSecurityProfile(configmap.Data["podSecurityProfile"])
+	// an unsupported service mesh turns the mesh integration off.
+	serviceMesh, _ := ParseServiceMesh(configmap.Data[ServiceMeshKey])
+	// "ketch ingress set" validates registry mirrors, invalid lines are skipped.
+	registryMirrors, _ := ParseRegistryMirrors(configmap.Data[RegistryMirrorsKey])
+	var registry *RegistrySpec
+	if len(configmap.Data["registry"]) > 0 || len(configmap.Data["registrySecret"]) > 0 || len(configmap.Data["buildCache"]) > 0 || len(registryMirrors) > 0 {
+		registry = &RegistrySpec{
+			URL:        configmap.Data["registry"],
+			SecretName: configmap.Data["registrySecret"],
+			Mirror:     configmap.Data["registryMirror"],
+			Mirrors:    registryMirrors,
+			BuildCache: configmap.Data["buildCache"],
+		}
+	}
+	var allowedTeams []string
+	for _, team := range strings.Split(configmap.Data["allowedTeams"], ",") {
+		if team = strings.TrimSpace(team); team != "" {
+			allowedTeams = append(allowedTeams, team)
+		}
+	}
+	// invalid provisioners are reported by apps requiring dependencies.
+	dependencyProvisioners, _ := ParseDependencyProvisioners(configmap.Data[DependencyProvisionersKey])
+	// "ketch ingress set" validates the certificate issuer, an invalid one falls back to ClusterIssuer.
+	certificateIssuer, _ := ParseCertificateIssuer(configmap.Data[CertificateIssuerKey])
+	// "ketch ingress set" validates external-dns settings too, invalid ones turn the annotations off.
+	externalDNS, _ := ParseExternalDNS(configmap.Data[ExternalDNSKey])
+	// invalid OpenTelemetry settings turn the instrumentation off.
+	openTelemetry, _ := ParseOpenTelemetry(configmap.Data[OpenTelemetryKey])
+	// invalid logging settings turn log forwarding metadata off.
+	logging, _ := ParseLogging(configmap.Data[LoggingKey])
+	// invalid grafana dashboards settings turn dashboards off.
+	grafanaDashboards, _ := ParseGrafanaDashboards(configmap.Data[GrafanaDashboardsKey])
+	// "ketch ingress set" validates notifications, invalid ones turn cluster-wide notifications off.
+	notifications, _ := ParseNotifications(configmap.Data[NotificationsKey])
+	// invalid lines of roles are skipped, so they don't allow any role.
+	serviceAccountRoles, _ := ParseServiceAccountRoles(configmap.Data[ServiceAccountRolesKey])
 	return &IngressControllerSpec{
-		ClassName:       configmap.Data["className"],
-		ServiceEndpoint: configmap.Data["serviceEndpoint"],
-		IngressType:     controllerType,
-		ClusterIssuer:   configmap.Data["clusterIssuer"],
+		ClassName:              configmap.Data["className"],
+		ServiceEndpoint:        configmap.Data["serviceEndpoint"],
+		IngressType:            controllerType,
+		ClusterIssuer:          configmap.Data["clusterIssuer"],
+		ForceHTTPS:             configmap.Data["forceHTTPS"] == "true",
+		Namespace:              configmap.Data["namespace"],
+		NetworkPolicy:          configmap.Data["networkPolicy"] == "true",
+		Templates:              configmap.Data["templates"],
+		PreStopSleepSeconds:    preStopSleepSeconds,
+		PodSecurityProfile:     podSecurityProfile,
+		Registry:               registry,
+		AllowedTeams:           allowedTeams,
+		DefaultEnvs:            ParseDefaultEnvs(configmap.Data[DefaultEnvsKey]),
+		DependencyProvisioners: dependencyProvisioners,
+		CertificateIssuer:      certificateIssuer,
+		ExternalDNS:            externalDNS,
+		MaintenanceImage:       configmap.Data["maintenanceImage"],
+		MaintenancePage:        configmap.Data["maintenancePage"],
+		ServiceMesh:            serviceMesh,
+		OpenTelemetry:          openTelemetry,
+		Logging:                logging,
+		GrafanaDashboards:      grafanaDashboards,
+		Notifications:          notifications,
+		ServiceAccountRoles:    serviceAccountRoles,
+	}
+}
+
+// ParseRegistryMirrors returns mirrors of the ingress configmap's registryMirrors, a REGISTRY=ENDPOINT pair per line, sorted by registry.
+// Empty lines and lines starting with '#' are skipped, mirrors of valid lines are returned along with an error of the first invalid line.
+func ParseRegistryMirrors(data string) ([]RegistryMirror, error) {
+	var mirrors []RegistryMirror
+	var err error
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" || strings.TrimSpace(parts[1]) == "" {
+			if err == nil {
+				err = fmt.Errorf("invalid registry mirror %q, registry mirrors should have REGISTRY=ENDPOINT format", line)
+			}
+			continue
+		}
+		mirrors = append(mirrors, RegistryMirror{Registry: strings.TrimSpace(parts[0]), Endpoint: strings.TrimSpace(parts[1])})
+	}
+	sort.Slice(mirrors, func(i, j int) bool {
+		return mirrors[i].Registry < mirrors[j].Registry
+	})
+	return mirrors, err
+}
+
+// ParseServiceAccountRoles returns roles of the ingress configmap's serviceAccountRoles, a KIND/NAME pair per line, e.g. "ClusterRole/view".
+// Empty lines and lines starting with '#' are skipped, roles of valid lines are returned along with an error of the first invalid line.
+func ParseServiceAccountRoles(data string) ([]KetchYamlRoleRef, error) {
+	var roles []KetchYamlRoleRef
+	var err error
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(line, "/", 2)
+		if len(parts) != 2 || parts[0] != "Role" && parts[0] != "ClusterRole" || parts[1] == "" {
+			if err == nil {
+				err = fmt.Errorf("invalid service account role %q, roles should have Role/NAME or ClusterRole/NAME format", line)
+			}
+			continue
+		}
+		roles = append(roles, KetchYamlRoleRef{Kind: parts[0], Name: parts[1]})
+	}
+	return roles, err
+}
+
+// ParseDefaultEnvs returns env variables of the ingress configmap's defaultEnvs, a NAME=VALUE pair per line.
+// Empty lines, lines starting with '#' and lines without '=' are skipped.
+func ParseDefaultEnvs(data string) []Env {
+	var envs []Env
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			continue
+		}
+		envs = append(envs, Env{Name: strings.TrimSpace(parts[0]), Value: strings.TrimSpace(parts[1])})
 	}
+	return envs
 }
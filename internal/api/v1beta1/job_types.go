@@ -31,10 +31,16 @@ type JobSpec struct {
 	Completions int    `json:"completions,omitempty"`
 	Suspend     bool   `json:"suspend,omitempty"`
 	//+kubebuilder:validation:Minimum=0
-	BackoffLimit *int        `json:"backoffLimit,omitempty"`
-	Containers   []Container `json:"containers,omitempty"`
-	Policy       Policy      `json:"policy,omitempty"`
-	Namespace    string      `json:"namespace"`
+	BackoffLimit *int `json:"backoffLimit,omitempty"`
+	// ActiveDeadlineSeconds limits the duration of the job, its pods are terminated once the deadline is reached.
+	//+kubebuilder:validation:Minimum=1
+	ActiveDeadlineSeconds *int `json:"activeDeadlineSeconds,omitempty"`
+	// TTLSecondsAfterFinished is the time after which a finished job and its pods are deleted.
+	//+kubebuilder:validation:Minimum=0
+	TTLSecondsAfterFinished *int        `json:"ttlSecondsAfterFinished,omitempty"`
+	Containers              []Container `json:"containers,omitempty"`
+	Policy                  Policy      `json:"policy,omitempty"`
+	Namespace               string      `json:"namespace"`
 
 	// CronJob-specific
 	Schedule                   string `json:"schedule,omitempty"`
@@ -47,6 +53,9 @@ type JobSpec struct {
 type JobStatus struct {
 	Conditions []Condition `json:"conditions,omitempty"`
 
+	// Engine installed the job's chart, the job keeps it when the engine of the cluster changes.
+	Engine string `json:"engine,omitempty"`
+
 	// CronJob-specific
 	Active             bool         `json:"active"`
 	LastScheduleTime   *metav1.Time `json:"lastScheduleTime,omitempty"`
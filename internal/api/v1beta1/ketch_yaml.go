@@ -1,6 +1,13 @@
 package v1beta1
 
-import v1 "k8s.io/api/core/v1"
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
 
 // KetchYamlData describes certain aspects of the application deployment being deployed.
 type KetchYamlData struct {
@@ -13,6 +20,110 @@ type KetchYamlData struct {
 
 	// Kubernetes contains specific configurations for Kubernetes.
 	Kubernetes *KetchYamlKubernetesConfig `json:"kubernetes,omitempty"`
+
+	// VolumeClaims describe persistent volume claims created for the application and mounted to its processes.
+	VolumeClaims []KetchYamlVolumeClaim `json:"volumeClaims,omitempty"`
+
+	// IngressPolicy limits incoming requests of the application,
+	// ketch translates it to annotations or custom resources of the cluster's ingress controller.
+	IngressPolicy *KetchYamlIngressPolicy `json:"ingressPolicy,omitempty"`
+
+	// ServiceAccount configures the service account ketch creates for the application.
+	// It's ignored if the app uses an existing service account set with AppSpec.ServiceAccountName.
+	ServiceAccount *KetchYamlServiceAccount `json:"serviceAccount,omitempty"`
+
+	// Scalers describe event-driven autoscaling of processes with KEDA, it must be installed in the cluster.
+	Scalers []KetchYamlScaler `json:"scalers,omitempty"`
+
+	// Requires is a list of dependencies of the application, like "postgres" or "redis".
+	// They are provisioned by provisioners of the cluster and their connection details are set as env variables.
+	Requires []string `json:"requires,omitempty"`
+
+	// Language of the app, e.g. "java", the OpenTelemetry operator injects an agent of the language into pods of the app.
+	Language string `json:"language,omitempty"`
+}
+
+// KetchYamlScaler describes a KEDA ScaledObject that scales a deployment or statefulset process.
+type KetchYamlScaler struct {
+	// Process is the name of the scaled process.
+	Process string `json:"process"`
+
+	// MinUnits is the minimum number of units, with 0 KEDA stops the process while there are no events.
+	// Defaults to 0.
+	MinUnits *int `json:"minUnits,omitempty"`
+
+	// MaxUnits is the maximum number of units. Defaults to 100.
+	MaxUnits *int `json:"maxUnits,omitempty"`
+
+	// PollingInterval is the interval to check each trigger on, in seconds. Defaults to 30.
+	PollingInterval *int `json:"pollingInterval,omitempty"`
+
+	// CooldownPeriod is the period to wait after the last trigger reported active before scaling to 0, in seconds.
+	// Defaults to 300.
+	CooldownPeriod *int `json:"cooldownPeriod,omitempty"`
+
+	// Triggers activate scaling of the process.
+	Triggers []KetchYamlScalerTrigger `json:"triggers"`
+}
+
+// KetchYamlScalerTrigger is a KEDA trigger,
+// for example "kafka" scales on consumer group lag, "aws-sqs-queue" on queue depth and "prometheus" on request rate.
+type KetchYamlScalerTrigger struct {
+	// Type is the type of the KEDA scaler.
+	Type string `json:"type"`
+
+	// Metadata configures the scaler, its keys depend on the type.
+	Metadata map[string]string `json:"metadata"`
+
+	// AuthenticationRef is the name of a TriggerAuthentication in the app's namespace with credentials of the scaler.
+	AuthenticationRef string `json:"authenticationRef,omitempty"`
+}
+
+// KetchYamlServiceAccount describes the service account of the application.
+type KetchYamlServiceAccount struct {
+	// Annotations are added to the service account, for example,
+	// "eks.amazonaws.com/role-arn" of IAM roles for service accounts or "iam.gke.io/gcp-service-account" of Workload Identity.
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Roles are bound to the service account in the app's namespace.
+	// Only roles allowed by serviceAccountRoles of the ketch-ingress configmap can be bound.
+	Roles []KetchYamlRoleRef `json:"roles,omitempty"`
+}
+
+// KetchYamlRoleRef references a Role in the app's namespace or a ClusterRole.
+type KetchYamlRoleRef struct {
+	// Kind is either Role or ClusterRole.
+	// +kubebuilder:validation:Enum=Role;ClusterRole
+	Kind string `json:"kind"`
+
+	// Name of the role.
+	Name string `json:"name"`
+}
+
+// KetchYamlIngressPolicy describes limits of incoming requests independently of an ingress controller.
+type KetchYamlIngressPolicy struct {
+	// RateLimit limits the rate of incoming requests.
+	RateLimit *KetchYamlRateLimit `json:"rateLimit,omitempty"`
+
+	// MaxBodySize is the maximum size of a request body, for example "10Mi".
+	// Requests with a larger body are rejected.
+	MaxBodySize string `json:"maxBodySize,omitempty"`
+
+	// Timeout is the maximum time to wait for a response of the application, for example "30s".
+	Timeout string `json:"timeout,omitempty"`
+
+	// ConnectTimeout is the maximum time to establish a connection to the application, for example "5s".
+	ConnectTimeout string `json:"connectTimeout,omitempty"`
+}
+
+// KetchYamlRateLimit describes a rate limit of incoming requests.
+// nginx and traefik limit requests of each client IP, istio limits requests of each unit of the application.
+type KetchYamlRateLimit struct {
+	// RequestsPerSecond is the average number of requests per second.
+	RequestsPerSecond int `json:"requestsPerSecond"`
+
+	// Burst is the maximum number of requests allowed at once. Defaults to RequestsPerSecond.
+	Burst int `json:"burst,omitempty"`
 }
 
 // KetchYamlHooks describes commands to run during different stages of the application deployment.
@@ -20,6 +131,17 @@ type KetchYamlHooks struct {
 
 	// Restart describes commands to run during different stages of the application deployment.
 	Restart KetchYamlRestartHooks `json:"restart,omitempty"`
+
+	// Release contains commands that run once per deployment before it gets traffic, e.g. database migrations.
+	// The commands are used if the Procfile has no release process.
+	Release []string `json:"release,omitempty"`
+
+	// PreDeploy contains commands that run once per deployment before the release process,
+	// the deployment isn't rolled out if they fail.
+	PreDeploy []string `json:"preDeploy,omitempty"`
+
+	// PostDeploy contains commands that run once per deployment after its processes are updated.
+	PostDeploy []string `json:"postDeploy,omitempty"`
 }
 
 // KetchYamlRestartHooks describes commands to run during different stages of the application deployment.
@@ -67,6 +189,131 @@ type KetchYamlKubernetesConfig struct {
 // KetchYamlKubernetesConfig contains specific configurations of a process.
 type KetchYamlProcessConfig struct {
 	Ports []KetchYamlProcessPortConfig `json:"ports,omitempty"`
+
+	// Kind is the kind of workload running the process: deployment, statefulset or daemonset.
+	// If omitted, the process uses the type of the application.
+	Kind string `json:"kind,omitempty"`
+
+	// VolumeClaimTemplates are claims that pods of a statefulset process reference, every pod gets its own volume.
+	VolumeClaimTemplates []KetchYamlVolumeClaimTemplate `json:"volumeClaimTemplates,omitempty"`
+
+	// TerminationGracePeriodSeconds is the time pods of the process get to shut down gracefully.
+	// If omitted, the kubernetes default of 30 seconds is used.
+	TerminationGracePeriodSeconds *int64 `json:"terminationGracePeriodSeconds,omitempty"`
+
+	// PriorityClassName is the name of a PriorityClass of pods of the process.
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+
+	// RuntimeClassName is the name of a RuntimeClass running pods of the process, e.g. gVisor or Kata Containers.
+	RuntimeClassName string `json:"runtimeClassName,omitempty"`
+
+	// StickySessions if set, requests of a client keep going to the same pod of a routable process,
+	// e.g. for legacy applications keeping sessions in memory. Ingress controllers use a cookie of the app
+	// and services of the process use the client IP. It's ignored by processes that aren't routable.
+	StickySessions bool `json:"stickySessions,omitempty"`
+
+	// Timeout is the maximum time ingress controllers wait for a response of a routable process,
+	// for example "10m" for streaming endpoints. It overrides the timeout of ingressPolicy.
+	Timeout string `json:"timeout,omitempty"`
+
+	// WebSocket if set, ingress controllers keep websocket connections of a routable process open,
+	// its timeout defaults to 1 hour.
+	WebSocket bool `json:"websocket,omitempty"`
+
+	// HeadlessService if set, the process gets a headless service resolving to addresses of its pods for peer discovery,
+	// e.g. of clustered caches. "alongside" keeps the service of the process, "instead" replaces it,
+	// routable processes can't replace their service. Statefulset processes always have a headless service.
+	// +kubebuilder:validation:Enum=alongside;instead
+	HeadlessService HeadlessServiceMode `json:"headlessService,omitempty"`
+
+	// Routes describe requests the process serves, the linkerd service mesh uses them
+	// for per-route metrics, retries and timeouts.
+	Routes []KetchYamlRoute `json:"routes,omitempty"`
+
+	// ExcludeLogs if set, the cluster's log agent drops logs of the process, e.g. of a noisy worker.
+	ExcludeLogs bool `json:"excludeLogs,omitempty"`
+
+	// Metrics describes a prometheus endpoint of the process. Prometheus Operator scrapes it with a PodMonitor
+	// if it's installed in the cluster, otherwise pods of the process get prometheus.io scrape annotations.
+	Metrics *KetchYamlMetrics `json:"metrics,omitempty"`
+
+	// Build builds an image of the process from its own directory of the source code,
+	// it's used by deployments from source only.
+	Build *KetchYamlProcessBuild `json:"build,omitempty"`
+
+	// Healthcheck overrides probes of the healthcheck of ketch.yaml for the process,
+	// its probes are used by processes without ports too, e.g. exec probes of workers.
+	Healthcheck *KetchYamlHealthcheck `json:"healthcheck,omitempty"`
+
+	// PodSpecPatch is a strategic merge patch applied to the pod spec of the process,
+	// it sets fields of a pod that ketch doesn't model.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	PodSpecPatch *runtime.RawExtension `json:"podSpecPatch,omitempty"`
+}
+
+// KetchYamlRoute describes requests of a process matching a method and a path.
+type KetchYamlRoute struct {
+	// Name of the route, e.g. "GET /books/{id}".
+	Name string `json:"name"`
+
+	// Method is an HTTP method of requests of the route, requests with any method match if it's omitted.
+	Method string `json:"method,omitempty"`
+
+	// PathRegex is a regular expression the whole path of requests of the route matches, e.g. "/books/[^/]+".
+	PathRegex string `json:"pathRegex"`
+
+	// Retryable if set, failed requests of the route are retried, it's safe for idempotent routes only.
+	Retryable bool `json:"retryable,omitempty"`
+
+	// Timeout of requests of the route, e.g. "5s".
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// KetchYamlMetrics describes a prometheus endpoint of a process.
+type KetchYamlMetrics struct {
+	// Port is a container port serving metrics.
+	Port int `json:"port"`
+
+	// Path of the metrics endpoint, it defaults to "/metrics".
+	Path string `json:"path,omitempty"`
+
+	// Interval between scrapes, e.g. "30s". Prometheus uses its global interval if it's omitted.
+	Interval string `json:"interval,omitempty"`
+}
+
+// HeadlessServiceMode tells whether a headless service of a process is created alongside or instead of its service.
+type HeadlessServiceMode string
+
+const (
+	HeadlessServiceAlongside HeadlessServiceMode = "alongside"
+	HeadlessServiceInstead   HeadlessServiceMode = "instead"
+)
+
+// KetchYamlProcessBuild describes an image of a process built from a directory of the source code.
+type KetchYamlProcessBuild struct {
+	// Source is a directory with the code of the process relative to the root of the source code.
+	Source string `json:"source"`
+
+	// BuildPacks replaces the build packs of the app for the process's image.
+	BuildPacks []string `json:"buildPacks,omitempty"`
+}
+
+// KetchYamlVolumeClaimTemplate describes a volume claim template of a statefulset process.
+type KetchYamlVolumeClaimTemplate struct {
+	// Name of the claim template.
+	Name string `json:"name"`
+
+	// Size is the requested storage, for example "1Gi".
+	Size string `json:"size"`
+
+	// StorageClass is the name of the StorageClass required by the claim. If omitted, the default StorageClass is used.
+	StorageClass *string `json:"storageClass,omitempty"`
+
+	// AccessModes contains the desired access modes the volume should have. Defaults to ReadWriteOnce.
+	AccessModes []v1.PersistentVolumeAccessMode `json:"accessModes,omitempty"`
+
+	// Path within the container at which the volume should be mounted.
+	Path string `json:"path"`
 }
 
 // KetchYamlKubernetesConfig contains configuration of an exposed port.
@@ -83,3 +330,70 @@ type KetchYamlProcessPortConfig struct {
 	// TargetPort is the port that the process is listening on. If omitted, the port value is used.
 	TargetPort int `json:"target_port,omitempty"`
 }
+
+// KetchYamlVolumeClaim describes a persistent volume claim owned by the application.
+type KetchYamlVolumeClaim struct {
+	// Name of the claim. The PersistentVolumeClaim is named <app name>-<claim name>.
+	Name string `json:"name"`
+
+	// Size is the requested storage, for example "1Gi".
+	Size string `json:"size"`
+
+	// StorageClass is the name of the StorageClass required by the claim. If omitted, the default StorageClass is used.
+	StorageClass *string `json:"storageClass,omitempty"`
+
+	// AccessModes contains the desired access modes the volume should have. Defaults to ReadWriteOnce.
+	AccessModes []v1.PersistentVolumeAccessMode `json:"accessModes,omitempty"`
+
+	// Mounts describe where the claim is mounted in the containers of the application processes.
+	Mounts []KetchYamlVolumeClaimMount `json:"mounts,omitempty"`
+}
+
+// KetchYamlVolumeClaimMount describes a mount of a persistent volume claim into a process.
+type KetchYamlVolumeClaimMount struct {
+	// Process is the name of the process the claim is mounted to.
+	Process string `json:"process"`
+
+	// Path within the container at which the volume should be mounted.
+	Path string `json:"path"`
+
+	// ReadOnly mounts the volume read-only.
+	ReadOnly bool `json:"readOnly,omitempty"`
+}
+
+// ProcessConfig returns the configuration of the given process or nil if ketch.yaml doesn't configure it.
+func (d *KetchYamlData) ProcessConfig(process string) *KetchYamlProcessConfig {
+	if d == nil || d.Kubernetes == nil {
+		return nil
+	}
+	config, ok := d.Kubernetes.Processes[process]
+	if !ok {
+		return nil
+	}
+	return &config
+}
+
+// Scaler returns the scaler of the process or nil if the process isn't scaled by KEDA.
+func (d *KetchYamlData) Scaler(process string) *KetchYamlScaler {
+	if d == nil {
+		return nil
+	}
+	for _, scaler := range d.Scalers {
+		if scaler.Process == process {
+			return &scaler
+		}
+	}
+	return nil
+}
+
+// Hash returns a sha256 hash of ketch.yaml, it's empty if there is no ketch.yaml.
+func (k *KetchYamlData) Hash() string {
+	if k == nil {
+		return ""
+	}
+	data, err := json.Marshal(k)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(data))
+}
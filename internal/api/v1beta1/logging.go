@@ -0,0 +1,78 @@
+package v1beta1
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// LoggingKey is a key of the ingress configmap with a YAML configuration of log forwarding of apps.
+	LoggingKey = "logging"
+
+	// FluentBitExcludeAnnotation tells fluent-bit to drop logs of a pod.
+	FluentBitExcludeAnnotation = "fluentbit.io/exclude"
+)
+
+// LoggingSpec describes how the cluster's log agent ships logs of apps, e.g. fluent-bit reading annotations of pods,
+// so logs of all apps get the same metadata.
+type LoggingSpec struct {
+	// Annotations are added to pods of apps, e.g. "fluentbit.io/parser: json".
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// AppLabel is a label with the app's name added to pods of apps, e.g. "app.kubernetes.io/name".
+	AppLabel string `json:"appLabel,omitempty"`
+
+	// ProcessLabel is a label with the process's name added to pods of apps, e.g. "app.kubernetes.io/component".
+	ProcessLabel string `json:"processLabel,omitempty"`
+
+	// ExcludeAnnotations are added to pods of processes excluded from log forwarding instead of Annotations.
+	// It defaults to "fluentbit.io/exclude: true".
+	ExcludeAnnotations map[string]string `json:"excludeAnnotations,omitempty"`
+}
+
+// ParseLogging returns log forwarding settings of the ingress configmap's logging, nil if it's empty.
+func ParseLogging(data string) (*LoggingSpec, error) {
+	if strings.TrimSpace(data) == "" {
+		return nil, nil
+	}
+	var spec LoggingSpec
+	if err := yaml.UnmarshalStrict([]byte(data), &spec); err != nil {
+		return nil, fmt.Errorf("invalid logging settings: %w", err)
+	}
+	for _, label := range []string{spec.AppLabel, spec.ProcessLabel} {
+		if label == "" {
+			continue
+		}
+		if errs := validation.IsQualifiedName(label); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid logging settings: invalid label %q: %s", label, strings.Join(errs, ", "))
+		}
+	}
+	return &spec, nil
+}
+
+// PodAnnotations returns annotations of pods of a process, excluded processes get annotations
+// telling the log agent to drop their logs.
+func (s LoggingSpec) PodAnnotations(excluded bool) map[string]string {
+	if !excluded {
+		return s.Annotations
+	}
+	if len(s.ExcludeAnnotations) == 0 {
+		return map[string]string{FluentBitExcludeAnnotation: "true"}
+	}
+	return s.ExcludeAnnotations
+}
+
+// PodLabels returns labels with names of the app and the process.
+func (s LoggingSpec) PodLabels(appName, process string) map[string]string {
+	labels := map[string]string{}
+	if s.AppLabel != "" {
+		labels[s.AppLabel] = appName
+	}
+	if s.ProcessLabel != "" {
+		labels[s.ProcessLabel] = process
+	}
+	return labels
+}
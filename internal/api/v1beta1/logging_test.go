@@ -0,0 +1,75 @@
+package v1beta1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLogging(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    *LoggingSpec
+		wantErr string
+	}{
+		{
+			name: "empty",
+			data: "\n",
+		},
+		{
+			name: "annotations and labels",
+			data: `
+annotations:
+  fluentbit.io/parser: json
+appLabel: app.kubernetes.io/name
+processLabel: app.kubernetes.io/component
+`,
+			want: &LoggingSpec{
+				Annotations:  map[string]string{"fluentbit.io/parser": "json"},
+				AppLabel:     "app.kubernetes.io/name",
+				ProcessLabel: "app.kubernetes.io/component",
+			},
+		},
+		{
+			name:    "invalid label",
+			data:    "appLabel: app name\n",
+			wantErr: `invalid logging settings: invalid label "app name"`,
+		},
+		{
+			name:    "unknown field",
+			data:    "sidecar: fluent-bit\n",
+			wantErr: "invalid logging settings",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLogging(tt.data)
+			if len(tt.wantErr) > 0 {
+				require.NotNil(t, err)
+				require.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestLoggingSpec_PodAnnotations(t *testing.T) {
+	spec := LoggingSpec{Annotations: map[string]string{"fluentbit.io/parser": "json"}}
+	require.Equal(t, map[string]string{"fluentbit.io/parser": "json"}, spec.PodAnnotations(false))
+	require.Equal(t, map[string]string{FluentBitExcludeAnnotation: "true"}, spec.PodAnnotations(true))
+
+	spec.ExcludeAnnotations = map[string]string{"co.elastic.logs/enabled": "false"}
+	require.Equal(t, map[string]string{"co.elastic.logs/enabled": "false"}, spec.PodAnnotations(true))
+}
+
+func TestLoggingSpec_PodLabels(t *testing.T) {
+	spec := LoggingSpec{AppLabel: "app.kubernetes.io/name", ProcessLabel: "app.kubernetes.io/component"}
+	require.Equal(t, map[string]string{
+		"app.kubernetes.io/name":      "dashboard",
+		"app.kubernetes.io/component": "web",
+	}, spec.PodLabels("dashboard", "web"))
+	require.Equal(t, map[string]string{}, LoggingSpec{}.PodLabels("dashboard", "web"))
+}
@@ -0,0 +1,47 @@
+package v1beta1
+
+const (
+	// DefaultMaintenanceImage is an image serving maintenance pages if the ingress configmap doesn't set maintenanceImage.
+	DefaultMaintenanceImage = "nginxinc/nginx-unprivileged:1.23-alpine"
+
+	// DefaultMaintenancePage is a maintenance page of apps if neither the app nor the ingress configmap sets one.
+	DefaultMaintenancePage = `<!DOCTYPE html>
+<html>
+<head><title>Under maintenance</title></head>
+<body>
+<h1>Under maintenance</h1>
+<p>The application is temporarily unavailable, please try again later.</p>
+</body>
+</html>
+`
+)
+
+// MaintenanceSpec puts an application in maintenance mode,
+// requests to its cnames are answered with 503 and a static page until the mode is turned off.
+type MaintenanceSpec struct {
+	// Page is an HTML page served to requests, it defaults to the maintenance page of the cluster.
+	Page string `json:"page,omitempty"`
+
+	// ProcessesStopped is set if the application's processes were stopped when the maintenance mode was turned on,
+	// they are started again when it's turned off.
+	ProcessesStopped bool `json:"processesStopped,omitempty"`
+}
+
+// MaintenancePage returns the page served to requests of the application in maintenance mode.
+func (s AppSpec) MaintenancePage() string {
+	if s.Maintenance != nil && s.Maintenance.Page != "" {
+		return s.Maintenance.Page
+	}
+	if s.Ingress.Controller.MaintenancePage != "" {
+		return s.Ingress.Controller.MaintenancePage
+	}
+	return DefaultMaintenancePage
+}
+
+// GetMaintenanceImage returns the image serving maintenance pages of apps.
+func (s IngressControllerSpec) GetMaintenanceImage() string {
+	if s.MaintenanceImage != "" {
+		return s.MaintenanceImage
+	}
+	return DefaultMaintenanceImage
+}
@@ -0,0 +1,47 @@
+package v1beta1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppSpec_MaintenancePage(t *testing.T) {
+	tests := []struct {
+		name string
+		spec AppSpec
+		want string
+	}{
+		{
+			name: "page of the app",
+			spec: AppSpec{
+				Maintenance: &MaintenanceSpec{Page: "<h1>app</h1>"},
+				Ingress:     IngressSpec{Controller: IngressControllerSpec{MaintenancePage: "<h1>cluster</h1>"}},
+			},
+			want: "<h1>app</h1>",
+		},
+		{
+			name: "page of the cluster",
+			spec: AppSpec{
+				Maintenance: &MaintenanceSpec{},
+				Ingress:     IngressSpec{Controller: IngressControllerSpec{MaintenancePage: "<h1>cluster</h1>"}},
+			},
+			want: "<h1>cluster</h1>",
+		},
+		{
+			name: "default page",
+			spec: AppSpec{Maintenance: &MaintenanceSpec{}},
+			want: DefaultMaintenancePage,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.spec.MaintenancePage())
+		})
+	}
+}
+
+func TestIngressControllerSpec_GetMaintenanceImage(t *testing.T) {
+	require.Equal(t, DefaultMaintenanceImage, IngressControllerSpec{}.GetMaintenanceImage())
+	require.Equal(t, "registry.example.com/nginx:1.23", IngressControllerSpec{MaintenanceImage: "registry.example.com/nginx:1.23"}.GetMaintenanceImage())
+}
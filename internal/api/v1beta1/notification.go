@@ -0,0 +1,115 @@
+package v1beta1
+
+import (
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// NotificationsKey is a key of the ingress configmap with a YAML list of receivers notified about events of all apps.
+const NotificationsKey = "notifications"
+
+// NotificationEvent is a lifecycle event of an application that ketch can notify about.
+type NotificationEvent string
+
+const (
+	DeployStartedEvent    NotificationEvent = "DeployStarted"
+	DeploySucceededEvent  NotificationEvent = "DeploySucceeded"
+	DeployFailedEvent     NotificationEvent = "DeployFailed"
+	CanaryPromotedEvent   NotificationEvent = "CanaryPromoted"
+	CanaryRolledBackEvent NotificationEvent = "CanaryRolledBack"
+	AppRemovedEvent       NotificationEvent = "AppRemoved"
+)
+
+// NotificationType is a kind of a receiver of notifications.
+// +kubebuilder:validation:Enum=slack;webhook
+type NotificationType string
+
+const (
+	// SlackNotification posts a message to a Slack incoming webhook.
+	SlackNotification NotificationType = "slack"
+	// WebhookNotification posts a JSON document to an arbitrary HTTP endpoint.
+	WebhookNotification NotificationType = "webhook"
+)
+
+// NotificationSpec describes where and when to send notifications about an application.
+type NotificationSpec struct {
+	Name string           `json:"name"`
+	Type NotificationType `json:"type"`
+
+	// URL is an address to POST notifications to.
+	URL string `json:"url,omitempty"`
+
+	// URLSecret references a key of a secret in the app's namespace containing the URL.
+	// It takes precedence over URL and should be used when the URL contains credentials, like Slack webhooks do.
+	URLSecret *v1.SecretKeySelector `json:"urlSecret,omitempty"`
+
+	// Events is a list of events to notify about, all events are sent if the list is empty.
+	// +kubebuilder:validation:items:Enum=DeployStarted;DeploySucceeded;DeployFailed;CanaryPromoted;CanaryRolledBack;AppRemoved
+	Events []NotificationEvent `json:"events,omitempty"`
+
+	// Template is a go template of the request body.
+	// It is executed with NotificationPayload and can use the "json" function to quote strings.
+	Template string `json:"template,omitempty"`
+}
+
+// Subscribed returns true if the notification should be sent for the given event.
+func (n NotificationSpec) Subscribed(event NotificationEvent) bool {
+	if len(n.Events) == 0 {
+		return true
+	}
+	for _, e := range n.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseNotifications returns receivers of the ingress configmap's notifications, nil if it's empty.
+// A receiver's urlSecret references a secret in the namespace of the ingress configmap.
+func ParseNotifications(data string) ([]NotificationSpec, error) {
+	if strings.TrimSpace(data) == "" {
+		return nil, nil
+	}
+	var specs []NotificationSpec
+	if err := yaml.UnmarshalStrict([]byte(data), &specs); err != nil {
+		return nil, fmt.Errorf("invalid notifications: %w", err)
+	}
+	names := map[string]bool{}
+	for _, spec := range specs {
+		if spec.Name == "" {
+			return nil, fmt.Errorf("invalid notifications: a receiver has no name")
+		}
+		if names[spec.Name] {
+			return nil, fmt.Errorf("invalid notifications: receiver %q is defined twice", spec.Name)
+		}
+		names[spec.Name] = true
+		if spec.Type != SlackNotification && spec.Type != WebhookNotification {
+			return nil, fmt.Errorf("invalid notifications: receiver %q has unsupported type %q, use %q or %q", spec.Name, spec.Type, SlackNotification, WebhookNotification)
+		}
+		if spec.URL == "" && spec.URLSecret == nil {
+			return nil, fmt.Errorf("invalid notifications: receiver %q has neither url nor urlSecret", spec.Name)
+		}
+		for _, event := range spec.Events {
+			switch event {
+			case DeployStartedEvent, DeploySucceededEvent, DeployFailedEvent, CanaryPromotedEvent, CanaryRolledBackEvent, AppRemovedEvent:
+			default:
+				return nil, fmt.Errorf("invalid notifications: receiver %q has unsupported event %q", spec.Name, event)
+			}
+		}
+	}
+	return specs, nil
+}
+
+// NotificationPayload contains information about an event passed to notification templates.
+type NotificationPayload struct {
+	App       string            `json:"app"`
+	Namespace string            `json:"namespace"`
+	Event     NotificationEvent `json:"event"`
+	Version   int               `json:"version,omitempty"`
+	Message   string            `json:"message"`
+	Time      string            `json:"time"`
+}
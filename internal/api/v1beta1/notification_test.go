@@ -0,0 +1,78 @@
+package v1beta1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestParseNotifications(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    []NotificationSpec
+		wantErr string
+	}{
+		{
+			name: "empty",
+			data: "\n",
+		},
+		{
+			name: "receivers",
+			data: `
+- name: platform
+  type: slack
+  urlSecret:
+    name: platform-webhooks
+    key: slack
+  events:
+    - DeployFailed
+- name: audit
+  type: webhook
+  url: https://audit.example.com/ketch
+`,
+			want: []NotificationSpec{
+				{
+					Name:      "platform",
+					Type:      SlackNotification,
+					URLSecret: &v1.SecretKeySelector{LocalObjectReference: v1.LocalObjectReference{Name: "platform-webhooks"}, Key: "slack"},
+					Events:    []NotificationEvent{DeployFailedEvent},
+				},
+				{Name: "audit", Type: WebhookNotification, URL: "https://audit.example.com/ketch"},
+			},
+		},
+		{
+			name:    "duplicated receiver",
+			data:    "- {name: audit, type: webhook, url: https://a.example.com}\n- {name: audit, type: webhook, url: https://b.example.com}\n",
+			wantErr: `invalid notifications: receiver "audit" is defined twice`,
+		},
+		{
+			name:    "no url",
+			data:    "- {name: audit, type: webhook}\n",
+			wantErr: `invalid notifications: receiver "audit" has neither url nor urlSecret`,
+		},
+		{
+			name:    "unsupported event",
+			data:    "- {name: audit, type: webhook, url: https://a.example.com, events: [AppCreated]}\n",
+			wantErr: `invalid notifications: receiver "audit" has unsupported event "AppCreated"`,
+		},
+		{
+			name:    "unknown field",
+			data:    "- {name: audit, type: webhook, channel: ops}\n",
+			wantErr: "invalid notifications",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseNotifications(tt.data)
+			if len(tt.wantErr) > 0 {
+				require.NotNil(t, err)
+				require.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
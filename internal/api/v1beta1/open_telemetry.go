@@ -0,0 +1,93 @@
+package v1beta1
+
+import (
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// OpenTelemetryKey is a key of the ingress configmap with a YAML configuration of OpenTelemetry instrumentation of apps.
+	OpenTelemetryKey = "openTelemetry"
+
+	// OpenTelemetrySidecarAnnotation tells the OpenTelemetry operator to inject a collector sidecar into a pod.
+	OpenTelemetrySidecarAnnotation = "sidecar.opentelemetry.io/inject"
+
+	// openTelemetrySidecarEndpoint is the OTLP endpoint of a collector sidecar.
+	openTelemetrySidecarEndpoint = "http://localhost:4317"
+)
+
+// OpenTelemetryLanguages are languages the OpenTelemetry operator injects agents of.
+var OpenTelemetryLanguages = []string{"dotnet", "java", "nodejs", "python"}
+
+// OpenTelemetrySpec instruments apps with OpenTelemetry, so their traces are exported without changes of the apps.
+type OpenTelemetrySpec struct {
+	// Endpoint is an OTLP endpoint apps export telemetry to, it's passed to apps in OTEL_EXPORTER_OTLP_ENDPOINT.
+	// It defaults to the collector sidecar if Sidecar is set.
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// Instrumentation is an Instrumentation of the OpenTelemetry operator injecting an agent of the app's language,
+	// "name" in the app's namespace or "namespace/name". Apps declare their language in the "language" of ketch.yaml.
+	Instrumentation string `json:"instrumentation,omitempty"`
+
+	// Sidecar is an OpenTelemetryCollector of the OpenTelemetry operator in sidecar mode injected into pods of apps,
+	// "name" in the app's namespace or "namespace/name".
+	Sidecar string `json:"sidecar,omitempty"`
+}
+
+// ParseOpenTelemetry returns OpenTelemetry settings of the ingress configmap's openTelemetry, nil if it's empty.
+func ParseOpenTelemetry(data string) (*OpenTelemetrySpec, error) {
+	if strings.TrimSpace(data) == "" {
+		return nil, nil
+	}
+	var spec OpenTelemetrySpec
+	if err := yaml.UnmarshalStrict([]byte(data), &spec); err != nil {
+		return nil, fmt.Errorf("invalid open telemetry settings: %w", err)
+	}
+	if spec.Endpoint == "" && spec.Instrumentation == "" && spec.Sidecar == "" {
+		return nil, fmt.Errorf("invalid open telemetry settings: endpoint, instrumentation or sidecar is required")
+	}
+	if spec.Endpoint != "" {
+		if err := validateURL("open telemetry endpoint", spec.Endpoint); err != nil {
+			return nil, fmt.Errorf("invalid open telemetry settings: %w", err)
+		}
+	}
+	return &spec, nil
+}
+
+// ValidateOpenTelemetryLanguage returns an error if the OpenTelemetry operator doesn't inject agents of the language.
+func ValidateOpenTelemetryLanguage(language string) error {
+	for _, supported := range OpenTelemetryLanguages {
+		if language == supported {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported language %q, supported languages: %s", language, strings.Join(OpenTelemetryLanguages, ", "))
+}
+
+// Envs returns env variables configuring the OpenTelemetry SDK of the app.
+func (s OpenTelemetrySpec) Envs(appName string) []Env {
+	endpoint := s.Endpoint
+	if endpoint == "" && s.Sidecar != "" {
+		endpoint = openTelemetrySidecarEndpoint
+	}
+	envs := []Env{{Name: "OTEL_SERVICE_NAME", Value: appName}}
+	if endpoint != "" {
+		envs = append(envs, Env{Name: "OTEL_EXPORTER_OTLP_ENDPOINT", Value: endpoint})
+	}
+	return envs
+}
+
+// PodAnnotations returns annotations of the OpenTelemetry operator injecting the collector sidecar
+// and the agent of the language into pods, an unsupported language gets no agent.
+func (s OpenTelemetrySpec) PodAnnotations(language string) map[string]string {
+	annotations := map[string]string{}
+	if s.Sidecar != "" {
+		annotations[OpenTelemetrySidecarAnnotation] = s.Sidecar
+	}
+	if s.Instrumentation != "" && ValidateOpenTelemetryLanguage(language) == nil {
+		annotations["instrumentation.opentelemetry.io/inject-"+language] = s.Instrumentation
+	}
+	return annotations
+}
@@ -0,0 +1,84 @@
+package v1beta1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseOpenTelemetry(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    *OpenTelemetrySpec
+		wantErr string
+	}{
+		{
+			name: "empty",
+			data: "\n",
+		},
+		{
+			name: "endpoint, instrumentation and sidecar",
+			data: `
+endpoint: http://otel-collector.monitoring:4317
+instrumentation: monitoring/default
+sidecar: "true"
+`,
+			want: &OpenTelemetrySpec{
+				Endpoint:        "http://otel-collector.monitoring:4317",
+				Instrumentation: "monitoring/default",
+				Sidecar:         "true",
+			},
+		},
+		{
+			name:    "invalid endpoint",
+			data:    "endpoint: otel-collector:4317\n",
+			wantErr: `invalid open telemetry settings: invalid open telemetry endpoint "otel-collector:4317"`,
+		},
+		{
+			name:    "nothing to instrument",
+			data:    "endpoint: \"\"\n",
+			wantErr: "invalid open telemetry settings: endpoint, instrumentation or sidecar is required",
+		},
+		{
+			name:    "unknown field",
+			data:    "exporter: otlp\n",
+			wantErr: `invalid open telemetry settings: error unmarshaling JSON: while decoding JSON: json: unknown field "exporter"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseOpenTelemetry(tt.data)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestOpenTelemetrySpec_Envs(t *testing.T) {
+	require.Equal(t, []Env{
+		{Name: "OTEL_SERVICE_NAME", Value: "dashboard"},
+		{Name: "OTEL_EXPORTER_OTLP_ENDPOINT", Value: "http://otel-collector.monitoring:4317"},
+	}, OpenTelemetrySpec{Endpoint: "http://otel-collector.monitoring:4317", Sidecar: "true"}.Envs("dashboard"))
+	require.Equal(t, []Env{
+		{Name: "OTEL_SERVICE_NAME", Value: "dashboard"},
+		{Name: "OTEL_EXPORTER_OTLP_ENDPOINT", Value: "http://localhost:4317"},
+	}, OpenTelemetrySpec{Sidecar: "true"}.Envs("dashboard"))
+	require.Equal(t, []Env{
+		{Name: "OTEL_SERVICE_NAME", Value: "dashboard"},
+	}, OpenTelemetrySpec{Instrumentation: "default"}.Envs("dashboard"))
+}
+
+func TestOpenTelemetrySpec_PodAnnotations(t *testing.T) {
+	spec := OpenTelemetrySpec{Instrumentation: "monitoring/default", Sidecar: "true"}
+	require.Equal(t, map[string]string{
+		"sidecar.opentelemetry.io/inject":              "true",
+		"instrumentation.opentelemetry.io/inject-java": "monitoring/default",
+	}, spec.PodAnnotations("java"))
+	require.Equal(t, map[string]string{"sidecar.opentelemetry.io/inject": "true"}, spec.PodAnnotations(""))
+	require.Equal(t, map[string]string{}, OpenTelemetrySpec{Instrumentation: "default"}.PodAnnotations("cobol"))
+}
@@ -0,0 +1,141 @@
+package v1beta1
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// PodSecurityProfile is a level of the Kubernetes Pod Security Standards pods of apps comply with.
+// +kubebuilder:validation:Enum=baseline;restricted
+type PodSecurityProfile string
+
+const (
+	// PodSecurityProfileBaseline prevents known privilege escalations.
+	PodSecurityProfileBaseline PodSecurityProfile = "baseline"
+	// PodSecurityProfileRestricted follows pod hardening best practices,
+	// processes run as non-root users without capabilities and privilege escalation.
+	PodSecurityProfileRestricted PodSecurityProfile = "restricted"
+)
+
+// baselineCapabilities are capabilities the baseline profile allows to add.
+var baselineCapabilities = map[v1.Capability]bool{
+	"AUDIT_WRITE":      true,
+	"CHOWN":            true,
+	"DAC_OVERRIDE":     true,
+	"FOWNER":           true,
+	"FSETID":           true,
+	"KILL":             true,
+	"MKNOD":            true,
+	"NET_BIND_SERVICE": true,
+	"SETFCAP":          true,
+	"SETGID":           true,
+	"SETPCAP":          true,
+	"SETUID":           true,
+	"SYS_CHROOT":       true,
+}
+
+// ParsePodSecurityProfile returns the profile with the given name, an empty name means no profile.
+func ParsePodSecurityProfile(name string) (PodSecurityProfile, error) {
+	switch profile := PodSecurityProfile(name); profile {
+	case "", PodSecurityProfileBaseline, PodSecurityProfileRestricted:
+		return profile, nil
+	}
+	return "", fmt.Errorf("unsupported pod security profile %q, supported profiles: baseline, restricted", name)
+}
+
+// SecurityContext returns the security context of a process with the profile's defaults,
+// a default never overrides a value set by the process.
+func (p PodSecurityProfile) SecurityContext(securityContext *v1.SecurityContext) *v1.SecurityContext {
+	if p != PodSecurityProfileRestricted {
+		return securityContext
+	}
+	result := &v1.SecurityContext{}
+	if securityContext != nil {
+		result = securityContext.DeepCopy()
+	}
+	if result.AllowPrivilegeEscalation == nil {
+		allowPrivilegeEscalation := false
+		result.AllowPrivilegeEscalation = &allowPrivilegeEscalation
+	}
+	if result.RunAsNonRoot == nil {
+		runAsNonRoot := true
+		result.RunAsNonRoot = &runAsNonRoot
+	}
+	if result.SeccompProfile == nil {
+		result.SeccompProfile = &v1.SeccompProfile{Type: v1.SeccompProfileTypeRuntimeDefault}
+	}
+	if result.Capabilities == nil {
+		result.Capabilities = &v1.Capabilities{}
+	}
+	if len(result.Capabilities.Drop) == 0 {
+		result.Capabilities.Drop = []v1.Capability{"ALL"}
+	}
+	return result
+}
+
+// ValidatePodSecurityContext returns fields of the app's pod security context that violate the profile.
+func (p PodSecurityProfile) ValidatePodSecurityContext(securityContext *v1.PodSecurityContext, path *field.Path) field.ErrorList {
+	if p == "" || securityContext == nil {
+		return nil
+	}
+	var errs field.ErrorList
+	errs = append(errs, p.validateSeccompProfile(securityContext.SeccompProfile, path.Child("seccompProfile"))...)
+	if p == PodSecurityProfileRestricted {
+		errs = append(errs, p.validateUser(securityContext.RunAsNonRoot, securityContext.RunAsUser, path)...)
+	}
+	return errs
+}
+
+// ValidateSecurityContext returns fields of a process' security context that violate the profile.
+func (p PodSecurityProfile) ValidateSecurityContext(securityContext *v1.SecurityContext, path *field.Path) field.ErrorList {
+	if p == "" || securityContext == nil {
+		return nil
+	}
+	var errs field.ErrorList
+	if securityContext.Privileged != nil && *securityContext.Privileged {
+		errs = append(errs, p.forbidden(path.Child("privileged"), "privileged containers"))
+	}
+	if securityContext.Capabilities != nil {
+		for i, capability := range securityContext.Capabilities.Add {
+			allowed := baselineCapabilities[capability]
+			if p == PodSecurityProfileRestricted {
+				allowed = capability == "NET_BIND_SERVICE"
+			}
+			if !allowed {
+				errs = append(errs, p.forbidden(path.Child("capabilities", "add").Index(i), fmt.Sprintf("capability %s", capability)))
+			}
+		}
+	}
+	errs = append(errs, p.validateSeccompProfile(securityContext.SeccompProfile, path.Child("seccompProfile"))...)
+	if p == PodSecurityProfileRestricted {
+		if securityContext.AllowPrivilegeEscalation != nil && *securityContext.AllowPrivilegeEscalation {
+			errs = append(errs, p.forbidden(path.Child("allowPrivilegeEscalation"), "privilege escalation"))
+		}
+		errs = append(errs, p.validateUser(securityContext.RunAsNonRoot, securityContext.RunAsUser, path)...)
+	}
+	return errs
+}
+
+func (p PodSecurityProfile) validateSeccompProfile(profile *v1.SeccompProfile, path *field.Path) field.ErrorList {
+	if profile != nil && profile.Type == v1.SeccompProfileTypeUnconfined {
+		return field.ErrorList{p.forbidden(path.Child("type"), "unconfined seccomp profiles")}
+	}
+	return nil
+}
+
+func (p PodSecurityProfile) validateUser(runAsNonRoot *bool, runAsUser *int64, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if runAsNonRoot != nil && !*runAsNonRoot {
+		errs = append(errs, p.forbidden(path.Child("runAsNonRoot"), "containers running as root"))
+	}
+	if runAsUser != nil && *runAsUser == 0 {
+		errs = append(errs, p.forbidden(path.Child("runAsUser"), "containers running as root"))
+	}
+	return errs
+}
+
+func (p PodSecurityProfile) forbidden(path *field.Path, what string) *field.Error {
+	return field.Forbidden(path, fmt.Sprintf("%s are not allowed by the %s pod security profile", what, p))
+}
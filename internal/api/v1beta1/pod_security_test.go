@@ -0,0 +1,121 @@
+package v1beta1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func TestParsePodSecurityProfile(t *testing.T) {
+	for _, name := range []string{"", "baseline", "restricted"} {
+		profile, err := ParsePodSecurityProfile(name)
+		require.Nil(t, err)
+		require.Equal(t, PodSecurityProfile(name), profile)
+	}
+	_, err := ParsePodSecurityProfile("privileged")
+	require.EqualError(t, err, `unsupported pod security profile "privileged", supported profiles: baseline, restricted`)
+}
+
+func TestPodSecurityProfile_SecurityContext(t *testing.T) {
+	boolPtr := func(b bool) *bool { return &b }
+	localhostProfile := "profiles/app.json"
+	tests := []struct {
+		name            string
+		profile         PodSecurityProfile
+		securityContext *v1.SecurityContext
+		want            *v1.SecurityContext
+	}{
+		{
+			name:            "no profile",
+			securityContext: &v1.SecurityContext{Privileged: boolPtr(true)},
+			want:            &v1.SecurityContext{Privileged: boolPtr(true)},
+		},
+		{
+			name:    "baseline profile has no defaults",
+			profile: PodSecurityProfileBaseline,
+		},
+		{
+			name:    "restricted profile",
+			profile: PodSecurityProfileRestricted,
+			want: &v1.SecurityContext{
+				AllowPrivilegeEscalation: boolPtr(false),
+				RunAsNonRoot:             boolPtr(true),
+				SeccompProfile:           &v1.SeccompProfile{Type: v1.SeccompProfileTypeRuntimeDefault},
+				Capabilities:             &v1.Capabilities{Drop: []v1.Capability{"ALL"}},
+			},
+		},
+		{
+			name:    "values of the process win",
+			profile: PodSecurityProfileRestricted,
+			securityContext: &v1.SecurityContext{
+				SeccompProfile: &v1.SeccompProfile{Type: v1.SeccompProfileTypeLocalhost, LocalhostProfile: &localhostProfile},
+				Capabilities:   &v1.Capabilities{Add: []v1.Capability{"NET_BIND_SERVICE"}, Drop: []v1.Capability{"NET_RAW"}},
+			},
+			want: &v1.SecurityContext{
+				AllowPrivilegeEscalation: boolPtr(false),
+				RunAsNonRoot:             boolPtr(true),
+				SeccompProfile:           &v1.SeccompProfile{Type: v1.SeccompProfileTypeLocalhost, LocalhostProfile: &localhostProfile},
+				Capabilities:             &v1.Capabilities{Add: []v1.Capability{"NET_BIND_SERVICE"}, Drop: []v1.Capability{"NET_RAW"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := tt.securityContext.DeepCopy()
+			require.Equal(t, tt.want, tt.profile.SecurityContext(tt.securityContext))
+			require.Equal(t, original, tt.securityContext)
+		})
+	}
+}
+
+func TestPodSecurityProfile_ValidateSecurityContext(t *testing.T) {
+	boolPtr := func(b bool) *bool { return &b }
+	int64Ptr := func(i int64) *int64 { return &i }
+	securityContext := &v1.SecurityContext{
+		Privileged:               boolPtr(true),
+		AllowPrivilegeEscalation: boolPtr(true),
+		RunAsNonRoot:             boolPtr(false),
+		RunAsUser:                int64Ptr(0),
+		Capabilities:             &v1.Capabilities{Add: []v1.Capability{"CHOWN", "SYS_ADMIN"}},
+		SeccompProfile:           &v1.SeccompProfile{Type: v1.SeccompProfileTypeUnconfined},
+	}
+	tests := []struct {
+		profile    PodSecurityProfile
+		wantFields []string
+	}{
+		{
+			profile: "",
+		},
+		{
+			profile: PodSecurityProfileBaseline,
+			wantFields: []string{
+				"securityContext.privileged",
+				"securityContext.capabilities.add[1]",
+				"securityContext.seccompProfile.type",
+			},
+		},
+		{
+			profile: PodSecurityProfileRestricted,
+			wantFields: []string{
+				"securityContext.privileged",
+				"securityContext.capabilities.add[0]",
+				"securityContext.capabilities.add[1]",
+				"securityContext.seccompProfile.type",
+				"securityContext.allowPrivilegeEscalation",
+				"securityContext.runAsNonRoot",
+				"securityContext.runAsUser",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.profile), func(t *testing.T) {
+			var fields []string
+			for _, err := range tt.profile.ValidateSecurityContext(securityContext, field.NewPath("securityContext")) {
+				fields = append(fields, err.Field)
+			}
+			require.Equal(t, tt.wantFields, fields)
+		})
+	}
+}
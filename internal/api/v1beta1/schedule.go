@@ -0,0 +1,154 @@
+package v1beta1
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ScalingSchedule sets units of processes of the app's latest deployment at times given by a cron expression,
+// for example, 10 units of "web" during business hours and 2 units overnight.
+type ScalingSchedule struct {
+	// +kubebuilder:validation:MinLength=1
+	// Name identifies the schedule in the app's status.
+	Name string `json:"name"`
+
+	// Schedule is a cron expression, for example, "0 8 * * 1-5" is 8am on weekdays.
+	Schedule string `json:"schedule"`
+
+	// TimeZone is a name of the time zone of the schedule, like "Europe/Berlin". Defaults to UTC.
+	TimeZone string `json:"timeZone,omitempty"`
+
+	// Units is a number of units by process name.
+	Units map[string]int `json:"units"`
+}
+
+// ScheduleStatus is the status of a scaling schedule.
+type ScheduleStatus struct {
+	Name string `json:"name"`
+
+	// LastScheduleTime is the last time the schedule was due, or the time ketch started to follow the schedule.
+	LastScheduleTime metav1.Time `json:"lastScheduleTime"`
+}
+
+// cronParser parses standard cron expressions with five fields and descriptors like "@daily".
+var cronParser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// Parse returns the cron schedule in the schedule's time zone.
+func (s ScalingSchedule) Parse() (cron.Schedule, error) {
+	location := time.UTC
+	if len(s.TimeZone) > 0 {
+		var err error
+		if location, err = time.LoadLocation(s.TimeZone); err != nil {
+			return nil, fmt.Errorf("invalid time zone %q: %w", s.TimeZone, err)
+		}
+	}
+	schedule, err := cronParser.Parse(s.Schedule)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: %w", s.Schedule, err)
+	}
+	if spec, ok := schedule.(*cron.SpecSchedule); ok {
+		spec.Location = location
+	}
+	return schedule, nil
+}
+
+// Schedule returns the status of the scaling schedule with the given name.
+func (s AppStatus) Schedule(name string) *ScheduleStatus {
+	for _, schedule := range s.Schedules {
+		if schedule.Name == name {
+			return &schedule
+		}
+	}
+	return nil
+}
+
+// ApplySchedules sets units of scaling schedules that have been due since they were checked last time
+// and returns names of the applied schedules. A schedule added to the app is followed from now on.
+// Schedules are not applied during a canary deployment, they are applied once the canary is over.
+func (app *App) ApplySchedules(now time.Time) ([]string, error) {
+	if app.Spec.Canary.Active {
+		return nil, nil
+	}
+	type dueSchedule struct {
+		name  string
+		units map[string]int
+		at    time.Time
+	}
+	var due []dueSchedule
+	statuses := make([]ScheduleStatus, 0, len(app.Spec.Schedules))
+	for _, s := range app.Spec.Schedules {
+		schedule, err := s.Parse()
+		if err != nil {
+			return nil, fmt.Errorf("schedule %s: %w", s.Name, err)
+		}
+		status := ScheduleStatus{Name: s.Name, LastScheduleTime: metav1.NewTime(now)}
+		if previous := app.Status.Schedule(s.Name); previous != nil {
+			status.LastScheduleTime = previous.LastScheduleTime
+			if at := lastActivation(schedule, previous.LastScheduleTime.Time, now); !at.IsZero() {
+				status.LastScheduleTime = metav1.NewTime(at)
+				due = append(due, dueSchedule{name: s.Name, units: s.Units, at: at})
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	app.Status.Schedules = statuses
+
+	// the schedule that was due last wins.
+	sort.SliceStable(due, func(i, j int) bool { return due[i].at.Before(due[j].at) })
+	names := make([]string, 0, len(due))
+	for _, s := range due {
+		app.setScheduledUnits(s.units)
+		names = append(names, s.name)
+	}
+	return names, nil
+}
+
+// NextScheduleTime returns the earliest time one of the app's scaling schedules is due after now,
+// or zero time if the app has no valid schedules.
+func (app *App) NextScheduleTime(now time.Time) time.Time {
+	var next time.Time
+	for _, s := range app.Spec.Schedules {
+		schedule, err := s.Parse()
+		if err != nil {
+			continue
+		}
+		if at := schedule.Next(now); !at.IsZero() && (next.IsZero() || at.Before(next)) {
+			next = at
+		}
+	}
+	return next
+}
+
+// lastActivation returns the last time the schedule was due after since and not after now, or zero time.
+func lastActivation(schedule cron.Schedule, since, now time.Time) time.Time {
+	var last time.Time
+	for at := schedule.Next(since); !at.IsZero() && !at.After(now); at = schedule.Next(at) {
+		last = at
+	}
+	return last
+}
+
+// setScheduledUnits sets units of processes of the latest deployment.
+// A stopped process stays stopped and gets the units once it is started.
+func (app *App) setScheduledUnits(units map[string]int) {
+	if len(app.Spec.Deployments) == 0 {
+		return
+	}
+	deployment := app.Spec.Deployments[len(app.Spec.Deployments)-1]
+	for i := range deployment.Processes {
+		process := &deployment.Processes[i]
+		value, ok := units[process.Name]
+		if !ok {
+			continue
+		}
+		if process.Units != nil && *process.Units == 0 && process.StoppedUnits != nil {
+			process.StoppedUnits = &value
+			continue
+		}
+		process.Units = &value
+	}
+}
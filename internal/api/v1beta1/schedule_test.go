@@ -0,0 +1,166 @@
+package v1beta1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestScalingSchedule_Parse(t *testing.T) {
+	schedule, err := ScalingSchedule{Schedule: "0 8 * * 1-5", TimeZone: "Europe/Berlin"}.Parse()
+	require.Nil(t, err)
+	// 2021-02-01 is Monday, 8am in Berlin is 7am UTC.
+	next := schedule.Next(time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC))
+	require.True(t, next.Equal(time.Date(2021, 2, 1, 7, 0, 0, 0, time.UTC)), next)
+
+	schedule, err = ScalingSchedule{Schedule: "@daily"}.Parse()
+	require.Nil(t, err)
+	next = schedule.Next(time.Date(2021, 2, 1, 10, 0, 0, 0, time.UTC))
+	require.True(t, next.Equal(time.Date(2021, 2, 2, 0, 0, 0, 0, time.UTC)), next)
+
+	_, err = ScalingSchedule{Schedule: "0 8 * *"}.Parse()
+	require.NotNil(t, err)
+	_, err = ScalingSchedule{Schedule: "0 8 * * *", TimeZone: "Mars/Olympus"}.Parse()
+	require.NotNil(t, err)
+}
+
+func TestApp_ApplySchedules(t *testing.T) {
+	intPtr := func(i int) *int { return &i }
+	lastCheck := time.Date(2021, 2, 1, 7, 0, 0, 0, time.UTC)
+	schedules := []ScalingSchedule{
+		{Name: "day", Schedule: "0 8 * * *", Units: map[string]int{"web": 10}},
+		{Name: "night", Schedule: "0 20 * * *", Units: map[string]int{"web": 2, "worker": 1}},
+	}
+	newApp := func() *App {
+		return &App{
+			Spec: AppSpec{
+				Deployments: []AppDeploymentSpec{
+					{Version: 1, Processes: []ProcessSpec{{Name: "web", Units: intPtr(1)}}},
+					{Version: 2, Processes: []ProcessSpec{{Name: "web", Units: intPtr(1)}, {Name: "worker", Units: intPtr(3)}}},
+				},
+				Schedules: schedules,
+			},
+			Status: AppStatus{
+				Schedules: []ScheduleStatus{
+					{Name: "day", LastScheduleTime: metav1.NewTime(lastCheck)},
+					{Name: "night", LastScheduleTime: metav1.NewTime(lastCheck)},
+					{Name: "removed", LastScheduleTime: metav1.NewTime(lastCheck)},
+				},
+			},
+		}
+	}
+	tests := []struct {
+		name         string
+		modify       func(app *App)
+		now          time.Time
+		wantApplied  []string
+		wantUnits    map[string]int
+		wantStopped  map[string]int
+		wantStatuses []ScheduleStatus
+	}{
+		{
+			name:        "nothing is due",
+			now:         lastCheck.Add(30 * time.Minute),
+			wantApplied: []string{},
+			wantUnits:   map[string]int{"web": 1, "worker": 3},
+			wantStatuses: []ScheduleStatus{
+				{Name: "day", LastScheduleTime: metav1.NewTime(lastCheck)},
+				{Name: "night", LastScheduleTime: metav1.NewTime(lastCheck)},
+			},
+		},
+		{
+			name:        "day schedule is due",
+			now:         lastCheck.Add(90 * time.Minute),
+			wantApplied: []string{"day"},
+			wantUnits:   map[string]int{"web": 10, "worker": 3},
+			wantStatuses: []ScheduleStatus{
+				{Name: "day", LastScheduleTime: metav1.NewTime(time.Date(2021, 2, 1, 8, 0, 0, 0, time.UTC))},
+				{Name: "night", LastScheduleTime: metav1.NewTime(lastCheck)},
+			},
+		},
+		{
+			name:        "the schedule due last wins",
+			now:         time.Date(2021, 2, 2, 9, 0, 0, 0, time.UTC),
+			wantApplied: []string{"night", "day"},
+			wantUnits:   map[string]int{"web": 10, "worker": 1},
+			wantStatuses: []ScheduleStatus{
+				{Name: "day", LastScheduleTime: metav1.NewTime(time.Date(2021, 2, 2, 8, 0, 0, 0, time.UTC))},
+				{Name: "night", LastScheduleTime: metav1.NewTime(time.Date(2021, 2, 1, 20, 0, 0, 0, time.UTC))},
+			},
+		},
+		{
+			name: "new schedule is followed from now on",
+			modify: func(app *App) {
+				app.Status.Schedules = nil
+			},
+			now:         time.Date(2021, 2, 2, 9, 0, 0, 0, time.UTC),
+			wantApplied: []string{},
+			wantUnits:   map[string]int{"web": 1, "worker": 3},
+			wantStatuses: []ScheduleStatus{
+				{Name: "day", LastScheduleTime: metav1.NewTime(time.Date(2021, 2, 2, 9, 0, 0, 0, time.UTC))},
+				{Name: "night", LastScheduleTime: metav1.NewTime(time.Date(2021, 2, 2, 9, 0, 0, 0, time.UTC))},
+			},
+		},
+		{
+			name: "stopped process stays stopped",
+			modify: func(app *App) {
+				app.Spec.Deployments[1].Processes[0].Units = intPtr(0)
+				app.Spec.Deployments[1].Processes[0].StoppedUnits = intPtr(1)
+			},
+			now:         lastCheck.Add(90 * time.Minute),
+			wantApplied: []string{"day"},
+			wantUnits:   map[string]int{"web": 0, "worker": 3},
+			wantStopped: map[string]int{"web": 10},
+			wantStatuses: []ScheduleStatus{
+				{Name: "day", LastScheduleTime: metav1.NewTime(time.Date(2021, 2, 1, 8, 0, 0, 0, time.UTC))},
+				{Name: "night", LastScheduleTime: metav1.NewTime(lastCheck)},
+			},
+		},
+		{
+			name: "canary is active",
+			modify: func(app *App) {
+				app.Spec.Canary.Active = true
+			},
+			now:       lastCheck.Add(90 * time.Minute),
+			wantUnits: map[string]int{"web": 1, "worker": 3},
+			wantStatuses: []ScheduleStatus{
+				{Name: "day", LastScheduleTime: metav1.NewTime(lastCheck)},
+				{Name: "night", LastScheduleTime: metav1.NewTime(lastCheck)},
+				{Name: "removed", LastScheduleTime: metav1.NewTime(lastCheck)},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newApp()
+			if tt.modify != nil {
+				tt.modify(app)
+			}
+			applied, err := app.ApplySchedules(tt.now)
+			require.Nil(t, err)
+			require.Equal(t, tt.wantApplied, applied)
+			require.Equal(t, tt.wantStatuses, app.Status.Schedules)
+			for _, process := range app.Spec.Deployments[1].Processes {
+				require.Equal(t, tt.wantUnits[process.Name], *process.Units, process.Name)
+				if stopped, ok := tt.wantStopped[process.Name]; ok {
+					require.Equal(t, stopped, *process.StoppedUnits, process.Name)
+				}
+			}
+			// only the latest deployment is scaled.
+			require.Equal(t, 1, *app.Spec.Deployments[0].Processes[0].Units)
+		})
+	}
+}
+
+func TestApp_NextScheduleTime(t *testing.T) {
+	app := &App{Spec: AppSpec{Schedules: []ScalingSchedule{
+		{Name: "day", Schedule: "0 8 * * *"},
+		{Name: "night", Schedule: "0 20 * * *"},
+	}}}
+	next := app.NextScheduleTime(time.Date(2021, 2, 1, 10, 0, 0, 0, time.UTC))
+	require.True(t, next.Equal(time.Date(2021, 2, 1, 20, 0, 0, 0, time.UTC)), next)
+
+	require.True(t, (&App{}).NextScheduleTime(time.Now()).IsZero())
+}
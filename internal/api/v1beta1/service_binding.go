@@ -0,0 +1,120 @@
+package v1beta1
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// ServiceBinding binds the application to an instance of an external service, like a database or a queue.
+// Connection details of the instance are keys of a secret in the app's namespace, each key is set as an env variable.
+type ServiceBinding struct {
+	// +kubebuilder:validation:MinLength=1
+	// Name identifies the binding.
+	Name string `json:"name"`
+
+	// +kubebuilder:validation:MinLength=1
+	// SecretName is a name of a secret in the app's namespace with connection details of the service instance.
+	SecretName string `json:"secretName"`
+
+	// EnvPrefix is prepended to names of env variables, e.g. "DATABASE_" sets the "host" key as DATABASE_HOST.
+	EnvPrefix string `json:"envPrefix,omitempty"`
+
+	// Processes are names of processes the service is bound to, all processes if empty.
+	// Pods of bound processes are restarted when the secret changes.
+	Processes []string `json:"processes,omitempty"`
+}
+
+var nonEnvNameChars = regexp.MustCompile(`[^A-Z0-9_]`)
+
+// EnvName returns a name of the env variable of the secret's key.
+func (b ServiceBinding) EnvName(key string) string {
+	return b.EnvPrefix + nonEnvNameChars.ReplaceAllString(strings.ToUpper(key), "_")
+}
+
+// BindsProcess returns true if the service is bound to the process.
+func (b ServiceBinding) BindsProcess(process string) bool {
+	if len(b.Processes) == 0 {
+		return true
+	}
+	for _, name := range b.Processes {
+		if name == process {
+			return true
+		}
+	}
+	return false
+}
+
+// Envs returns env variables reading the keys of the secret.
+func (b ServiceBinding) Envs(secret v1.Secret) []Env {
+	keys := make([]string, 0, len(secret.Data))
+	for key := range secret.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	envs := make([]Env, 0, len(keys))
+	for _, key := range keys {
+		envs = append(envs, Env{
+			Name: b.EnvName(key),
+			ValueFrom: &v1.EnvVarSource{
+				SecretKeyRef: &v1.SecretKeySelector{LocalObjectReference: v1.LocalObjectReference{Name: secret.Name}, Key: key},
+			},
+		})
+	}
+	return envs
+}
+
+// SecretChecksum returns a checksum of the secret's data, it changes when credentials of the service are rotated.
+func SecretChecksum(secret v1.Secret) string {
+	keys := make([]string, 0, len(secret.Data))
+	for key := range secret.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	hash := sha256.New()
+	for _, key := range keys {
+		fmt.Fprintf(hash, "%s=%x\n", key, secret.Data[key])
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// GetAppBindingSecrets gets secrets of the app's service bindings by name.
+func GetAppBindingSecrets(ctx context.Context, getter objectGetter, app *App) (map[string]v1.Secret, error) {
+	bindings := app.Bindings()
+	secrets := make(map[string]v1.Secret, len(bindings))
+	for _, binding := range bindings {
+		if _, ok := secrets[binding.SecretName]; ok {
+			continue
+		}
+		var secret v1.Secret
+		if err := getter.Get(ctx, types.NamespacedName{Name: binding.SecretName, Namespace: app.Spec.Namespace}, &secret); err != nil {
+			return nil, fmt.Errorf("failed to get secret %q of service binding %q: %w", binding.SecretName, binding.Name, err)
+		}
+		secrets[binding.SecretName] = secret
+	}
+	return secrets, nil
+}
+
+func validateServiceBindings(bindings []ServiceBinding, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	names := map[string]bool{}
+	for i, binding := range bindings {
+		bindingPath := path.Index(i)
+		if names[binding.Name] {
+			errs = append(errs, field.Duplicate(bindingPath.Child("name"), binding.Name))
+		}
+		names[binding.Name] = true
+		if len(binding.EnvPrefix) > 0 && nonEnvNameChars.MatchString(strings.ToUpper(binding.EnvPrefix)) {
+			errs = append(errs, field.Invalid(bindingPath.Child("envPrefix"), binding.EnvPrefix, "must consist of letters, digits and '_'"))
+		}
+	}
+	return errs
+}
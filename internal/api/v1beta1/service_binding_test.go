@@ -0,0 +1,36 @@
+package v1beta1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestServiceBinding_Envs(t *testing.T) {
+	secret := v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "postgres"},
+		Data:       map[string][]byte{"host": []byte("db.example.com"), "db-password": []byte("secret")},
+	}
+	binding := ServiceBinding{Name: "db", SecretName: "postgres", EnvPrefix: "DATABASE_"}
+	require.Equal(t, []Env{
+		{Name: "DATABASE_DB_PASSWORD", ValueFrom: &v1.EnvVarSource{SecretKeyRef: &v1.SecretKeySelector{LocalObjectReference: v1.LocalObjectReference{Name: "postgres"}, Key: "db-password"}}},
+		{Name: "DATABASE_HOST", ValueFrom: &v1.EnvVarSource{SecretKeyRef: &v1.SecretKeySelector{LocalObjectReference: v1.LocalObjectReference{Name: "postgres"}, Key: "host"}}},
+	}, binding.Envs(secret))
+
+	require.True(t, binding.BindsProcess("web"))
+	binding.Processes = []string{"worker"}
+	require.False(t, binding.BindsProcess("web"))
+	require.True(t, binding.BindsProcess("worker"))
+}
+
+func TestSecretChecksum(t *testing.T) {
+	secret := v1.Secret{Data: map[string][]byte{"host": []byte("db.example.com"), "password": []byte("secret")}}
+	checksum := SecretChecksum(secret)
+	require.Len(t, checksum, 64)
+	require.Equal(t, checksum, SecretChecksum(*secret.DeepCopy()))
+
+	secret.Data["password"] = []byte("rotated")
+	require.NotEqual(t, checksum, SecretChecksum(secret))
+}
@@ -0,0 +1,33 @@
+package v1beta1
+
+import "fmt"
+
+// ServiceMeshType is a service mesh pods of apps are part of.
+type ServiceMeshType string
+
+const (
+	// LinkerdServiceMesh injects the linkerd proxy into pods of apps,
+	// ketch creates ServiceProfiles of routes of their processes and TrafficSplits of their canary deployments.
+	LinkerdServiceMesh ServiceMeshType = "linkerd"
+
+	// ServiceMeshKey is a key of the ingress configmap with the service mesh of apps.
+	ServiceMeshKey = "serviceMesh"
+
+	// LinkerdInjectAnnotation tells linkerd to inject its proxy into a pod.
+	LinkerdInjectAnnotation = "linkerd.io/inject"
+)
+
+// ParseServiceMesh returns the service mesh with the given name, an empty name means no service mesh.
+func ParseServiceMesh(name string) (ServiceMeshType, error) {
+	switch mesh := ServiceMeshType(name); mesh {
+	case "", LinkerdServiceMesh:
+		return mesh, nil
+	}
+	return "", fmt.Errorf("unsupported service mesh %q, supported service meshes: linkerd", name)
+}
+
+// LinkerdEnabled returns true if pods of apps are part of the linkerd service mesh.
+// istio is a service mesh itself, so linkerd is ignored with the istio ingress controller.
+func (s IngressControllerSpec) LinkerdEnabled() bool {
+	return s.ServiceMesh == LinkerdServiceMesh && s.IngressType != IstioIngressControllerType
+}
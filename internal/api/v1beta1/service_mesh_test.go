@@ -0,0 +1,24 @@
+package v1beta1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseServiceMesh(t *testing.T) {
+	for _, name := range []string{"", "linkerd"} {
+		mesh, err := ParseServiceMesh(name)
+		require.Nil(t, err)
+		require.Equal(t, ServiceMeshType(name), mesh)
+	}
+	_, err := ParseServiceMesh("consul")
+	require.EqualError(t, err, `unsupported service mesh "consul", supported service meshes: linkerd`)
+}
+
+func TestIngressControllerSpec_LinkerdEnabled(t *testing.T) {
+	require.True(t, IngressControllerSpec{IngressType: NginxIngressControllerType, ServiceMesh: LinkerdServiceMesh}.LinkerdEnabled())
+	require.True(t, IngressControllerSpec{IngressType: TraefikIngressControllerType, ServiceMesh: LinkerdServiceMesh}.LinkerdEnabled())
+	require.False(t, IngressControllerSpec{IngressType: IstioIngressControllerType, ServiceMesh: LinkerdServiceMesh}.LinkerdEnabled())
+	require.False(t, IngressControllerSpec{IngressType: NginxIngressControllerType}.LinkerdEnabled())
+}
@@ -0,0 +1,74 @@
+package v1beta2
+
+import (
+	"encoding/json"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+)
+
+var _ conversion.Convertible = &App{}
+
+// ConvertTo converts the app to a v1beta1 app.
+func (src *App) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*ketchv1.App)
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Status = src.Status
+	// fields that didn't change are copied as is, fields that changed are unknown to the other version and skipped.
+	if err := convertSpec(src.Spec, &dst.Spec); err != nil {
+		return err
+	}
+	dst.Spec.Canary.StepTimeInteval = src.Spec.Canary.StepInterval.Duration
+	for i, deployment := range src.Spec.Deployments {
+		if deployment.Probes == nil {
+			continue
+		}
+		if dst.Spec.Deployments[i].KetchYaml == nil {
+			dst.Spec.Deployments[i].KetchYaml = &ketchv1.KetchYamlData{}
+		}
+		dst.Spec.Deployments[i].KetchYaml.Healthcheck = &ketchv1.KetchYamlHealthcheck{
+			LivenessProbe:  deployment.Probes.Liveness.DeepCopy(),
+			ReadinessProbe: deployment.Probes.Readiness.DeepCopy(),
+			StartupProbe:   deployment.Probes.Startup.DeepCopy(),
+		}
+	}
+	return nil
+}
+
+// ConvertFrom converts a v1beta1 app to this version.
+func (dst *App) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*ketchv1.App)
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Status = src.Status
+	if err := convertSpec(src.Spec, &dst.Spec); err != nil {
+		return err
+	}
+	dst.Spec.Canary.StepInterval = metav1.Duration{Duration: src.Spec.Canary.StepTimeInteval}
+	for i, deployment := range src.Spec.Deployments {
+		if deployment.KetchYaml == nil || deployment.KetchYaml.Healthcheck == nil {
+			continue
+		}
+		healthcheck := deployment.KetchYaml.Healthcheck
+		dst.Spec.Deployments[i].Probes = &Probes{
+			Liveness:  healthcheck.LivenessProbe.DeepCopy(),
+			Readiness: healthcheck.ReadinessProbe.DeepCopy(),
+			Startup:   healthcheck.StartupProbe.DeepCopy(),
+		}
+		dst.Spec.Deployments[i].KetchYaml.Healthcheck = nil
+	}
+	return nil
+}
+
+func convertSpec(src, dst interface{}) error {
+	data, err := json.Marshal(src)
+	if err != nil {
+		return fmt.Errorf("failed to convert app spec: %w", err)
+	}
+	if err := json.Unmarshal(data, dst); err != nil {
+		return fmt.Errorf("failed to convert app spec: %w", err)
+	}
+	return nil
+}
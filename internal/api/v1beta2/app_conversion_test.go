@@ -0,0 +1,108 @@
+package v1beta2
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+)
+
+func TestApp_Conversion(t *testing.T) {
+	liveness := &v1.Probe{ProbeHandler: v1.ProbeHandler{HTTPGet: &v1.HTTPGetAction{Path: "/healthz", Port: intstr.FromInt(8080)}}}
+	readiness := &v1.Probe{ProbeHandler: v1.ProbeHandler{TCPSocket: &v1.TCPSocketAction{Port: intstr.FromInt(8080)}}, PeriodSeconds: 5}
+	units := 3
+
+	tests := []struct {
+		name    string
+		v1beta1 ketchv1.App
+		v1beta2 App
+	}{
+		{
+			name: "probes and canary interval",
+			v1beta1: ketchv1.App{
+				ObjectMeta: metav1.ObjectMeta{Name: "go-app", Labels: map[string]string{"team": "a"}},
+				Spec: ketchv1.AppSpec{
+					Description: "my app",
+					Namespace:   "ketch-go-app",
+					Canary:      ketchv1.CanarySpec{Steps: 4, StepWeight: 25, StepTimeInteval: 5 * time.Minute, Active: true},
+					Deployments: []ketchv1.AppDeploymentSpec{
+						{
+							Image:   "shipasoftware/go-app:v1",
+							Version: 1,
+							KetchYaml: &ketchv1.KetchYamlData{
+								Hooks:       &ketchv1.KetchYamlHooks{Restart: ketchv1.KetchYamlRestartHooks{Before: []string{"migrate"}}},
+								Healthcheck: &ketchv1.KetchYamlHealthcheck{LivenessProbe: liveness, ReadinessProbe: readiness},
+							},
+						},
+						{Image: "shipasoftware/go-app:v2", Version: 2},
+					},
+					Ingress: ketchv1.IngressSpec{Cnames: ketchv1.CnameList{{Name: "theketch.io"}}},
+				},
+				Status: ketchv1.AppStatus{Units: &units},
+			},
+			v1beta2: App{
+				ObjectMeta: metav1.ObjectMeta{Name: "go-app", Labels: map[string]string{"team": "a"}},
+				Spec: AppSpec{
+					Description: "my app",
+					Namespace:   "ketch-go-app",
+					Canary:      CanarySpec{Steps: 4, StepWeight: 25, StepInterval: metav1.Duration{Duration: 5 * time.Minute}, Active: true},
+					Deployments: []AppDeploymentSpec{
+						{
+							Image:   "shipasoftware/go-app:v1",
+							Version: 1,
+							Probes:  &Probes{Liveness: liveness, Readiness: readiness},
+							KetchYaml: &ketchv1.KetchYamlData{
+								Hooks: &ketchv1.KetchYamlHooks{Restart: ketchv1.KetchYamlRestartHooks{Before: []string{"migrate"}}},
+							},
+						},
+						{Image: "shipasoftware/go-app:v2", Version: 2},
+					},
+					Ingress: ketchv1.IngressSpec{Cnames: ketchv1.CnameList{{Name: "theketch.io"}}},
+				},
+				Status: ketchv1.AppStatus{Units: &units},
+			},
+		},
+		{
+			name: "probes without ketch.yaml",
+			v1beta1: ketchv1.App{
+				ObjectMeta: metav1.ObjectMeta{Name: "go-app"},
+				Spec: ketchv1.AppSpec{
+					Deployments: []ketchv1.AppDeploymentSpec{
+						{
+							Version:   1,
+							KetchYaml: &ketchv1.KetchYamlData{Healthcheck: &ketchv1.KetchYamlHealthcheck{StartupProbe: liveness}},
+						},
+					},
+				},
+			},
+			v1beta2: App{
+				ObjectMeta: metav1.ObjectMeta{Name: "go-app"},
+				Spec: AppSpec{
+					Deployments: []AppDeploymentSpec{
+						{
+							Version:   1,
+							Probes:    &Probes{Startup: liveness},
+							KetchYaml: &ketchv1.KetchYamlData{},
+						},
+					},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var converted App
+			require.Nil(t, converted.ConvertFrom(tt.v1beta1.DeepCopy()))
+			require.Equal(t, tt.v1beta2, converted)
+
+			var hub ketchv1.App
+			require.Nil(t, tt.v1beta2.DeepCopy().ConvertTo(&hub))
+			require.Equal(t, tt.v1beta1, hub)
+		})
+	}
+}
@@ -0,0 +1,211 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta2
+
+import (
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+)
+
+// AppSpec defines the desired state of App.
+// Fields that didn't change since v1beta1 use v1beta1 types.
+type AppSpec struct {
+	Version *string `json:"version,omitempty"`
+
+	// ID is an additional unique identifier of this application besides the app's name if needed.
+	ID string `json:"id,omitempty"`
+
+	// +kubebuilder:validation:MaxLength=140
+	Description string `json:"description,omitempty"`
+
+	// Team owning the application, it's added as a "theketch.io/team" label to all resources of the app.
+	// +kubebuilder:validation:MaxLength=63
+	Team string `json:"team,omitempty"`
+
+	// Owner is a person or a group responsible for the application, e.g. a username,
+	// it's added as a "theketch.io/owner" label to all resources of the app.
+	// +kubebuilder:validation:MaxLength=63
+	Owner string `json:"owner,omitempty"`
+
+	// Canary contains a configuration which will be required for canary deployments.
+	Canary CanarySpec `json:"canary,omitempty"`
+
+	// Deployments is a list of running deployments.
+	Deployments []AppDeploymentSpec `json:"deployments"`
+
+	// DeploymentsCount is incremented every time a new deployment is added to Deployments and used as a version for new deployments.
+	DeploymentsCount int `json:"deploymentsCount,omitempty"`
+
+	// List of environment variables of the application.
+	Env []ketchv1.Env `json:"env,omitempty"`
+
+	// EnvSets are names of EnvSets whose environment variables are set in pods of the application,
+	// variables of the application and its processes take precedence, a later set takes precedence over an earlier one.
+	EnvSets []string `json:"envSets,omitempty"`
+
+	// Ingress contains configuration of entrypoints to access the application.
+	Ingress ketchv1.IngressSpec `json:"ingress"`
+
+	// DockerRegistry contains docker registry configuration of the application.
+	DockerRegistry ketchv1.DockerRegistrySpec `json:"dockerRegistry,omitempty"`
+
+	// Builder is the name of the builder used to build source code.
+	Builder string `json:"builder,omitempty"`
+
+	// BuildPacks is a list of build packs to use when building from source.
+	BuildPacks []string `json:"buildPacks,omitempty"`
+
+	// Labels is a list of labels that will be applied to Services/Deployments/Pods.
+	Labels []ketchv1.MetadataItem `json:"labels,omitempty"`
+
+	// Annotations is a list of annotations that will be applied to Services/Deployments/Pods/Gateways/Ingresses/IngressRoutes.
+	Annotations []ketchv1.MetadataItem `json:"annotations,omitempty"`
+
+	// Namespace sets the namespace in which the app is run
+	Namespace string `json:"namespace"`
+
+	// NamespaceStrategy defines whether the app runs in its own namespace managed by ketch. Defaults to "shared".
+	NamespaceStrategy ketchv1.NamespaceStrategy `json:"namespaceStrategy,omitempty"`
+
+	// NamespaceQuota limits resources of the app's namespace when NamespaceStrategy is "perApp".
+	NamespaceQuota v1.ResourceList `json:"namespaceQuota,omitempty"`
+
+	// NetworkPolicy configures a NetworkPolicy isolating the app's pods.
+	NetworkPolicy *ketchv1.NetworkPolicySpec `json:"networkPolicy,omitempty"`
+
+	// ServiceAccountName specifies a service account name to be used for this application.
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+
+	// SecurityContext specifies security settings for a pod/app, which get applied to all containers.
+	SecurityContext *v1.PodSecurityContext `json:"securityContext,omitempty"`
+
+	// Extensions can be used by third-parties to keep additional information.
+	// +optional
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Extensions []runtime.RawExtension `json:"extensions,omitempty"`
+
+	// VolumeClaimTemplates is a list of an app's volumeClaimTemplates
+	VolumeClaimTemplates []ketchv1.PersistentVolumeClaim `json:"volumeClaimTemplates,omitempty"`
+
+	// Type specifies whether an app should be a deployment, a statefulset or a daemonset
+	Type *ketchv1.AppType `json:"type,omitempty"`
+
+	// Notifications is a list of receivers notified about deployments, canary releases and removal of the app.
+	Notifications []ketchv1.NotificationSpec `json:"notifications,omitempty"`
+
+	// Schedules change units of processes of the latest deployment at given times.
+	Schedules []ketchv1.ScalingSchedule `json:"schedules,omitempty"`
+
+	// ServiceBindings bind the application to instances of external services, like databases or queues.
+	ServiceBindings []ketchv1.ServiceBinding `json:"serviceBindings,omitempty"`
+
+	// Maintenance if set, the application's cnames are routed to a static maintenance page.
+	Maintenance *ketchv1.MaintenanceSpec `json:"maintenance,omitempty"`
+
+	// Expose is "public" by default, "internal" apps get services but no ingress objects of their cnames,
+	// they are reached only inside the cluster.
+	// +kubebuilder:validation:Enum=public;internal
+	Expose ketchv1.ExposeMode `json:"expose,omitempty"`
+
+	// InternalLoadBalancerAnnotations if set, the service of an internal app becomes a load balancer with these annotations,
+	// e.g. "networking.gke.io/load-balancer-type: Internal", to reach the app from the cloud network.
+	InternalLoadBalancerAnnotations map[string]string `json:"internalLoadBalancerAnnotations,omitempty"`
+}
+
+// CanarySpec represents configuration for a canary deployment.
+type CanarySpec struct {
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	Steps int `json:"steps,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	StepWeight uint8 `json:"stepWeight,omitempty"`
+	// StepInterval is the time between canary steps, like "5m".
+	// It replaces v1beta1's stepTimeInterval kept in nanoseconds.
+	StepInterval metav1.Duration `json:"stepInterval,omitempty"`
+	// NextScheduledTime holds time of the next step.
+	NextScheduledTime *metav1.Time `json:"nextScheduledTime,omitempty"`
+	// CurrentStep is the count for current step for a canary deployment.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	CurrentStep int `json:"currentStep,omitempty"`
+	// Active shows if canary deployment is active for this application.
+	Active bool `json:"active,omitempty"`
+	// Started holds time when canary started
+	Started *metav1.Time `json:"started,omitempty"`
+	// Target map of processes and target units value
+	Target map[string]uint16 `json:"target,omitempty"`
+	// Routing if set, requests matching it are routed to the canary deployment regardless of its weight.
+	Routing *ketchv1.CanaryRouting `json:"routing,omitempty"`
+}
+
+type AppDeploymentSpec struct {
+	// ImagePullSecrets contains a list of secrets to pull the image of this deployment.
+	// If this list is defined, app.Spec.DockerRegistrySpec is not used.
+	ImagePullSecrets []v1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+	Image            string                    `json:"image"`
+	Version          ketchv1.DeploymentVersion `json:"version"`
+	Processes        []ketchv1.ProcessSpec     `json:"processes,omitempty"`
+	// Probes are liveness, readiness and startup probes of the deployment's processes.
+	// They replace v1beta1's ketchYaml.healthcheck.
+	Probes *Probes `json:"probes,omitempty"`
+	// KetchYaml is the deployment's ketch.yaml, its healthcheck is kept in Probes.
+	KetchYaml       *ketchv1.KetchYamlData  `json:"ketchYaml,omitempty"`
+	Labels          []ketchv1.Label         `json:"labels,omitempty"`
+	RoutingSettings ketchv1.RoutingSettings `json:"routingSettings,omitempty"`
+	ExposedPorts    []ketchv1.ExposedPort   `json:"exposedPorts,omitempty"`
+	// ReleaseCmd is the command of the release process of the Procfile.
+	// It runs once before the deployment's processes are updated, a deployment whose release fails gets no traffic.
+	ReleaseCmd []string `json:"releaseCmd,omitempty"`
+}
+
+// Probes describes probes of containers of a deployment.
+// More info: https://kubernetes.io/docs/concepts/workloads/pods/pod-lifecycle#container-probes
+type Probes struct {
+	// Liveness restarts a container if the probe fails.
+	Liveness *v1.Probe `json:"liveness,omitempty"`
+	// Readiness removes a container from service endpoints if the probe fails.
+	Readiness *v1.Probe `json:"readiness,omitempty"`
+	// Startup delays other probes until the container has started.
+	Startup *v1.Probe `json:"startup,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Description",type=string,JSONPath=`.spec.description`
+
+// App is the Schema for the apps API.
+type App struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   AppSpec           `json:"spec,omitempty"`
+	Status ketchv1.AppStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// AppList contains a list of App.
+type AppList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []App `json:"items"`
+}
@@ -0,0 +1,41 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package v1beta2 contains API Schema definitions for the resources v1beta2 API group.
+// Apps are stored as v1beta1, v1beta2 apps are converted by the conversion webhook.
+// +kubebuilder:object:generate=true
+// +groupName=theketch.io
+package v1beta2
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/scheme"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+)
+
+// AddToScheme registers v1beta2 types in the given group, the group must be the group of v1beta1 types.
+// An empty group means theketch.io.
+func AddToScheme(group string) func(s *runtime.Scheme) error {
+	if group == "" {
+		group = ketchv1.TheKetchGroup
+	}
+	groupVersion := schema.GroupVersion{Group: group, Version: "v1beta2"}
+	builder := &scheme.Builder{GroupVersion: groupVersion}
+	builder.Register(&App{}, &AppList{})
+	return builder.AddToScheme
+}
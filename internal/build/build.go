@@ -3,6 +3,7 @@ package build
 
 import (
 	"context"
+	"io"
 	"os"
 
 	"github.com/theketchio/ketch/internal/errors"
@@ -24,9 +25,14 @@ type CreateImageFromSourceRequest struct {
 	Builder string
 	// BuildPacks list of build packs to include in the build
 	BuildPacks []string
+	// CacheImage is the name of an image the build cache is pushed to and restored from, so builds reuse layers.
+	// If it's empty, the cache is kept in a docker volume of the host running the build.
+	CacheImage string
 	// defaults to current working directory, use WithWorkingDirectory to override. Typically the
 	// working directory would be the root of the source code that will be built.
 	workingDir string
+	// out receives logs of the build, defaults to the output of the pack client.
+	out io.Writer
 }
 
 // Option is the signature of options used in GetSourceHandler
@@ -39,6 +45,13 @@ func WithWorkingDirectory(workingDirectory string) Option {
 	}
 }
 
+// WithOutput writes logs of the build to the writer instead of the output of the pack client.
+func WithOutput(out io.Writer) Option {
+	return func(o *CreateImageFromSourceRequest) {
+		o.out = out
+	}
+}
+
 // GetSourceHandler returns a build function. It takes a pack client as an argument.
 func GetSourceHandler(packCLI builder) func(context.Context, *CreateImageFromSourceRequest, ...Option) error {
 	return func(ctx context.Context, req *CreateImageFromSourceRequest, opts ...Option) error {
@@ -59,6 +72,8 @@ func GetSourceHandler(packCLI builder) func(context.Context, *CreateImageFromSou
 			Builder:    req.Builder,
 			WorkingDir: req.workingDir,
 			BuildPacks: req.BuildPacks,
+			CacheImage: req.CacheImage,
+			Out:        req.out,
 		}
 		if err := packCLI.BuildAndPushImage(ctx, packRequest); err != nil {
 			return errors.Wrap(err, "could not build image from source")
@@ -53,12 +53,14 @@ func TestGetSourceHandler(t *testing.T) {
 			builderFn: func(ctx context.Context, req pack.BuildRequest) error {
 				assert.Equal(t, req.Image, "acme/superimage")
 				assert.Equal(t, req.WorkingDir, workingDir)
+				assert.Equal(t, req.CacheImage, "acme/superimage-cache")
 				return nil
 			},
 			request: &CreateImageFromSourceRequest{
-				Image:   "acme/superimage",
-				AppName: "acmeapp",
-				Builder: "heroku/buildpacks:18",
+				Image:      "acme/superimage",
+				AppName:    "acmeapp",
+				Builder:    "heroku/buildpacks:18",
+				CacheImage: "acme/superimage-cache",
 			},
 		},
 		{
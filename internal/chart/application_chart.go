@@ -12,12 +12,15 @@ import (
 	"text/template"
 	"time"
 
+	"github.com/google/go-containerregistry/pkg/name"
 	"helm.sh/helm/v3/pkg/chartutil"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"sigs.k8s.io/yaml"
 
 	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
 	"github.com/theketchio/ketch/internal/templates"
+	"github.com/theketchio/ketch/internal/utils"
 )
 
 // ApplicationChart is an internal representation of a helm chart converted from the App CRD
@@ -61,8 +64,121 @@ type app struct {
 	SecurityContext *v1.PodSecurityContext `json:"securityContext,omitempty"`
 	// VolumeClaimTemplates is a list of an app's volumeClaimTemplates
 	VolumeClaimTemplates []ketchv1.PersistentVolumeClaim `json:"volumeClaimTemplates,omitempty"`
+	// VolumeClaims is a list of persistent volume claims declared in ketch.yaml.
+	VolumeClaims []ketchv1.PersistentVolumeClaim `json:"volumeClaims,omitempty"`
 	// Type specifies whether the app should be a deployment or a statefulset
 	Type ketchv1.AppType `json:"type"`
+	// NetworkPolicy if set, ketch creates a NetworkPolicy that isolates the app's pods.
+	NetworkPolicy *networkPolicy `json:"networkPolicy,omitempty"`
+	// ServiceAccount if set, ketch creates a service account used by the app's pods.
+	ServiceAccount *serviceAccount `json:"serviceAccount,omitempty"`
+	// OwnershipLabels are labels with the app's team and owner added to all k8s resources of the app.
+	OwnershipLabels map[string]string `json:"ownershipLabels,omitempty"`
+	// Maintenance if set, services of the app route requests to a backend serving a maintenance page.
+	Maintenance *maintenance `json:"maintenance,omitempty"`
+	// Mirror if set, ingress objects copy requests to the app to its shadow deployment.
+	Mirror *mirror `json:"mirror,omitempty"`
+	// CanaryRouting if set, ingress objects route requests matching its rules to the canary deployment.
+	CanaryRouting *canaryRouting `json:"canaryRouting,omitempty"`
+	// InternalLoadBalancer if set, the service of the app is a load balancer with these annotations.
+	InternalLoadBalancer *internalLoadBalancer `json:"internalLoadBalancer,omitempty"`
+	// Linkerd if set, ketch creates linkerd objects of the app in the linkerd service mesh.
+	Linkerd *linkerd `json:"linkerd,omitempty"`
+	// GrafanaDashboard if set, ketch creates a configmap with a grafana dashboard of the app.
+	GrafanaDashboard *grafanaDashboard `json:"grafanaDashboard,omitempty"`
+}
+
+// internalLoadBalancer contains values of the service of an internal app reached from the cloud network.
+type internalLoadBalancer struct {
+	Annotations map[string]string `json:"annotations"`
+}
+
+func ownershipLabels(spec ketchv1.AppSpec) map[string]string {
+	labels := map[string]string{}
+	if spec.Team != "" {
+		labels[utils.KetchTeamLabel] = spec.Team
+	}
+	if spec.Owner != "" {
+		labels[utils.KetchOwnerLabel] = spec.Owner
+	}
+	if len(labels) == 0 {
+		return nil
+	}
+	return labels
+}
+
+// serviceAccount contains values for populating the service_account.yaml.
+type serviceAccount struct {
+	Name        string                     `json:"name"`
+	Annotations map[string]string          `json:"annotations,omitempty"`
+	Roles       []ketchv1.KetchYamlRoleRef `json:"roles,omitempty"`
+}
+
+// newServiceAccount returns a service account of the app, roles must be allowed by the ingress controller's ServiceAccountRoles.
+func newServiceAccount(name string, spec ketchv1.KetchYamlServiceAccount, controller ketchv1.IngressControllerSpec) (*serviceAccount, error) {
+	for _, role := range spec.Roles {
+		if role.Kind != "Role" && role.Kind != "ClusterRole" {
+			return nil, fmt.Errorf("serviceAccount.roles: invalid kind %q of %s, must be either Role or ClusterRole", role.Kind, role.Name)
+		}
+		if role.Name == "" {
+			return nil, fmt.Errorf("serviceAccount.roles: name of %s is required", role.Kind)
+		}
+		if !controller.RoleAllowed(role) {
+			return nil, fmt.Errorf("serviceAccount.roles: %s %s isn't allowed, roles are allowed with \"ketch ingress set --service-account-role\"", role.Kind, role.Name)
+		}
+	}
+	return &serviceAccount{Name: name, Annotations: spec.Annotations, Roles: spec.Roles}, nil
+}
+
+// mirror contains values of the routable services of a deployment getting traffic and of a shadow deployment,
+// istio and traefik templates use it to copy a percentage of requests to the shadow deployment.
+type mirror struct {
+	Source     backendService `json:"source"`
+	Target     backendService `json:"target"`
+	Percentage uint8          `json:"percentage"`
+}
+
+// canaryRouting contains values of the routable service of a canary deployment and rules of requests routed to it,
+// ingress templates route matching requests to the canary deployment regardless of its weight.
+type canaryRouting struct {
+	Header      string         `json:"header,omitempty"`
+	HeaderValue string         `json:"headerValue,omitempty"`
+	Cookie      string         `json:"cookie,omitempty"`
+	CookieValue string         `json:"cookieValue,omitempty"`
+	Target      backendService `json:"target"`
+}
+
+// backendService contains values of a routable service of a deployment.
+type backendService struct {
+	Name    string                    `json:"name"`
+	Port    int32                     `json:"port"`
+	Version ketchv1.DeploymentVersion `json:"version"`
+}
+
+func newBackendService(appName string, deployment deployment, process process) backendService {
+	return backendService{
+		Name:    fmt.Sprintf("%s-%s-%v", appName, process.Name, deployment.Version),
+		Port:    process.PublicServicePort,
+		Version: deployment.Version,
+	}
+}
+
+// maintenancePort is a port the maintenance backend listens on.
+const maintenancePort = 8080
+
+// maintenance contains values for populating the maintenance.yaml.
+type maintenance struct {
+	Image string `json:"image"`
+	Page  string `json:"page"`
+	Port  int    `json:"port"`
+}
+
+// networkPolicy contains values for populating the network_policy.yaml.
+type networkPolicy struct {
+	// IngressControllerNamespace is the namespace of the ingress controller's pods.
+	IngressControllerNamespace string `json:"ingressControllerNamespace"`
+	// AllowFromNamespaces is a list of other namespaces allowed to reach the app.
+	AllowFromNamespaces []string `json:"allowFromNamespaces,omitempty"`
 }
 
 type deployment struct {
@@ -72,6 +188,8 @@ type deployment struct {
 	Processes        []process                 `json:"processes"`
 	Labels           []ketchv1.Label           `json:"labels"`
 	RoutingSettings  ketchv1.RoutingSettings   `json:"routingSettings"`
+	// Hooks are set only until the deployment is rolled out, so they run once.
+	Hooks []deployHook `json:"hooks,omitempty"`
 }
 
 type Option func(opts *Options)
@@ -80,6 +198,10 @@ type Options struct {
 	// ExposedPorts are ports exposed by an image of each deployment.
 	ExposedPorts map[ketchv1.DeploymentVersion][]ketchv1.ExposedPort
 	Templates    templates.Templates
+	// EnvSets are env sets referenced by the application.
+	EnvSets []ketchv1.EnvSet
+	// BindingSecrets are secrets of the application's service bindings by name.
+	BindingSecrets map[string]v1.Secret
 }
 
 func WithExposedPorts(ports map[ketchv1.DeploymentVersion][]ketchv1.ExposedPort) Option {
@@ -101,6 +223,20 @@ func WithTemplates(tpls templates.Templates) Option {
 	}
 }
 
+// WithEnvSets sets env sets the application references, variables of sets the application doesn't reference are ignored.
+func WithEnvSets(envSets []ketchv1.EnvSet) Option {
+	return func(opts *Options) {
+		opts.EnvSets = envSets
+	}
+}
+
+// WithServiceBindingSecrets sets secrets of the application's service bindings by name.
+func WithServiceBindingSecrets(secrets map[string]v1.Secret) Option {
+	return func(opts *Options) {
+		opts.BindingSecrets = secrets
+	}
+}
+
 func imagePullSecrets(deploymentImagePullSecrets []v1.LocalObjectReference, spec ketchv1.DockerRegistrySpec) []v1.LocalObjectReference {
 	if len(deploymentImagePullSecrets) > 0 {
 		// imagePullSecrets defined for this particular deployment is higher priority.
@@ -114,6 +250,32 @@ func imagePullSecrets(deploymentImagePullSecrets []v1.LocalObjectReference, spec
 	}
 }
 
+// mirroredImage rewrites the image reference to point to the mirror of its registry if there is one.
+// The repository, tag and digest of the image are kept as is.
+func mirroredImage(image string, mirrors []ketchv1.RegistryMirror) string {
+	if len(mirrors) == 0 {
+		return image
+	}
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return image
+	}
+	for _, mirror := range mirrors {
+		registry, err := name.NewRegistry(mirror.Registry)
+		if err != nil || registry.RegistryStr() != ref.Context().RegistryStr() {
+			continue
+		}
+		mirrored := strings.TrimSuffix(mirror.Endpoint, "/") + "/" + ref.Context().RepositoryStr()
+		switch r := ref.(type) {
+		case name.Digest:
+			return mirrored + "@" + r.DigestStr()
+		case name.Tag:
+			return mirrored + ":" + r.TagStr()
+		}
+	}
+	return image
+}
+
 // New returns an ApplicationChart instance.
 func New(application *ketchv1.App, opts ...Option) (*ApplicationChart, error) {
 	ingressController := application.Spec.Ingress.Controller
@@ -122,9 +284,19 @@ func New(application *ketchv1.App, opts ...Option) (*ApplicationChart, error) {
 		opt(options)
 	}
 
-	ingress, err := newIngress(*application, ingressController)
-	if err != nil {
-		return nil, err
+	// internal apps are reached only through their services, they don't get ingress objects.
+	ingress := &ingress{}
+	var err error
+	if !application.Spec.IsInternal() {
+		if ingress, err = newIngress(*application, ingressController); err != nil {
+			return nil, err
+		}
+	}
+
+	// OpenTelemetry variables come after the cluster's default variables, so an admin can override them.
+	defaultEnvs := ingressController.DefaultEnvs
+	if otel := ingressController.OpenTelemetry; otel != nil {
+		defaultEnvs = append(append([]ketchv1.Env{}, defaultEnvs...), otel.Envs(application.Name)...)
 	}
 
 	values := &values{
@@ -132,16 +304,36 @@ func New(application *ketchv1.App, opts ...Option) (*ApplicationChart, error) {
 			ID:                  application.Spec.ID,
 			Name:                application.Name,
 			Ingress:             *ingress,
-			Env:                 application.Spec.Env,
+			Env:                 podEnvs(appEnvs(application.Spec, envSetEnvs(application.Spec.EnvSets, options.EnvSets), defaultEnvs)),
 			Group:               ketchv1.Group,
 			MetadataLabels:      application.Spec.Labels,
 			MetadataAnnotations: application.Spec.Annotations,
 			ServiceAccountName:  application.Spec.ServiceAccountName,
 			Type:                application.Spec.GetType(),
+			OwnershipLabels:     ownershipLabels(application.Spec),
 		},
 		IngressController: &ingressController,
 	}
 
+	if application.Spec.NetworkPolicyEnabled() {
+		values.App.NetworkPolicy = &networkPolicy{IngressControllerNamespace: ingressController.GetNamespace()}
+		if application.Spec.NetworkPolicy != nil {
+			values.App.NetworkPolicy.AllowFromNamespaces = application.Spec.NetworkPolicy.AllowFromNamespaces
+		}
+	}
+
+	if application.Spec.IsInternal() && len(application.Spec.InternalLoadBalancerAnnotations) > 0 {
+		values.App.InternalLoadBalancer = &internalLoadBalancer{Annotations: application.Spec.InternalLoadBalancerAnnotations}
+	}
+
+	if application.Spec.Maintenance != nil {
+		values.App.Maintenance = &maintenance{
+			Image: mirroredImage(ingressController.GetMaintenanceImage(), application.Spec.DockerRegistryConfig().Mirrors),
+			Page:  application.Spec.MaintenancePage(),
+			Port:  maintenancePort,
+		}
+	}
+
 	if application.Spec.SecurityContext != nil {
 		values.App.SecurityContext = application.Spec.SecurityContext
 	}
@@ -150,15 +342,16 @@ func New(application *ketchv1.App, opts ...Option) (*ApplicationChart, error) {
 		values.App.VolumeClaimTemplates = application.Spec.VolumeClaimTemplates
 	}
 
-	for _, deploymentSpec := range application.Spec.Deployments {
+	dockerRegistry := application.Spec.DockerRegistryConfig()
+	for i, deploymentSpec := range application.Spec.Deployments {
 		deployment := deployment{
-			Image:   deploymentSpec.Image,
+			Image:   mirroredImage(deploymentSpec.Image, dockerRegistry.Mirrors),
 			Version: deploymentSpec.Version,
 			Labels:  deploymentSpec.Labels,
 			RoutingSettings: ketchv1.RoutingSettings{
 				Weight: deploymentSpec.RoutingSettings.Weight,
 			},
-			ImagePullSecrets: imagePullSecrets(deploymentSpec.ImagePullSecrets, application.Spec.DockerRegistry),
+			ImagePullSecrets: imagePullSecrets(deploymentSpec.ImagePullSecrets, dockerRegistry),
 		}
 		procfile, err := ProcfileFromProcesses(deploymentSpec.Processes)
 		if err != nil {
@@ -166,21 +359,41 @@ func New(application *ketchv1.App, opts ...Option) (*ApplicationChart, error) {
 		}
 		exposedPorts := options.ExposedPorts[deployment.Version]
 		c := NewConfigurator(deploymentSpec.KetchYaml, *procfile, exposedPorts, DefaultApplicationPort)
+		if application.Status.DeploymentRecord(deploymentSpec.Version) == nil {
+			deployment.Hooks = newDeployHooks(deploymentSpec, c, application.Spec.Ingress.Controller.PodSecurityProfile)
+		}
 		for _, processSpec := range deploymentSpec.Processes {
 			name := processSpec.Name
 			isRoutable := procfile.IsRoutable(name)
 			process, err := newProcess(name, isRoutable,
+				withType(values.App.Type, c.ProcessKind(name)),
 				withCmd(c.procfile.Processes[name]),
+				withImage(mirroredImage(processSpec.Image, dockerRegistry.Mirrors)),
 				withUnits(processSpec.Units),
 				withEnvs(processSpec.Env),
+				withServiceBindings(application.Bindings(), options.BindingSecrets, values.App.Env),
 				withPortsAndProbes(c),
 				withLifecycle(c.Lifecycle()),
-				withSecurityContext(processSpec.SecurityContext),
+				withPreStopSleep(application.Spec.Ingress.Controller.PreStopSleepSeconds),
+				withTerminationGracePeriod(c.TerminationGracePeriodSeconds(name)),
+				withPodClasses(c.PriorityClassName(name), c.RuntimeClassName(name)),
+				withStickySessions(c.StickySessions(name)),
+				withTimeout(c.Timeout(name), c.WebSocket(name)),
+				withHeadlessService(c.HeadlessService(name)),
+				withSecurityContext(application.Spec.Ingress.Controller.PodSecurityProfile.SecurityContext(processSpec.SecurityContext)),
 				withResourceRequirements(processSpec.Resources),
 				withVolumes(processSpec.Volumes),
 				withVolumeMounts(processSpec.VolumeMounts),
+				withVolumeClaims(application.Name, c.VolumeClaims()),
+				withVolumeClaimTemplates(c.VolumeClaimTemplates(name)),
+				withScaler(c.Scaler(name)),
+				withAutoscale(processSpec.Autoscale),
 				withLabels(application.Spec.Labels, deployment.Version),
 				withAnnotations(application.Spec.Annotations, deployment.Version),
+				withLinkerd(ingressController.LinkerdEnabled(), c.Routes(name)),
+				withOpenTelemetry(ingressController.OpenTelemetry, c.Language()),
+				withLogging(ingressController.Logging, application.Name, c.ExcludeLogs(name)),
+				withMetrics(c.Metrics(name)),
 			)
 			if err != nil {
 				return nil, err
@@ -194,12 +407,63 @@ func New(application *ketchv1.App, opts ...Option) (*ApplicationChart, error) {
 					Process:    *process,
 				}
 			}
+			if isRoutable && deploymentSpec.IsShadow() {
+				target := newBackendService(application.Name, deployment, *process)
+				values.App.Mirror = &mirror{Target: target, Percentage: deploymentSpec.RoutingSettings.MirrorPercentage}
+			}
+			// the canary deployment is the last one, it gets requests matching the rules once it gets traffic.
+			if routing := application.Spec.Canary.Routing; isRoutable && routing != nil && application.Spec.Canary.Active &&
+				i > 0 && i == len(application.Spec.Deployments)-1 && deploymentSpec.RoutingSettings.Weight > 0 {
+				values.App.CanaryRouting = &canaryRouting{
+					Header:      routing.Header,
+					HeaderValue: routing.HeaderValue,
+					Cookie:      routing.Cookie,
+					Target:      newBackendService(application.Name, deployment, *process),
+				}
+				if routing.Cookie != "" {
+					values.App.CanaryRouting.CookieValue = ketchv1.CanaryCookieValue
+				}
+			}
 
 			deployment.Processes = append(deployment.Processes, *process)
 		}
 		values.App.Deployments = append(values.App.Deployments, deployment)
+		if values.App.VolumeClaims, err = addVolumeClaims(values.App.VolumeClaims, application.Name, c.VolumeClaims()); err != nil {
+			return nil, err
+		}
 	}
 	values.App.IsAccessible = isAppAccessible(values.App)
+	if ingressController.LinkerdEnabled() {
+		values.App.Linkerd = newLinkerd(application.Spec.Namespace, values.App)
+	}
+	if ingressController.GrafanaDashboards != nil {
+		if values.App.GrafanaDashboard, err = newGrafanaDashboard(*ingressController.GrafanaDashboards, ingressController.IngressType, application); err != nil {
+			return nil, err
+		}
+	}
+	// requests are mirrored to a shadow deployment only when there's a deployment serving them.
+	if values.App.Mirror != nil && values.App.Service != nil && values.App.Maintenance == nil {
+		values.App.Mirror.Source = newBackendService(application.Name, values.App.Service.Deployment, values.App.Service.Process)
+	} else {
+		values.App.Mirror = nil
+	}
+
+	// the ingress and the service account are shared by all deployments,
+	// so they follow ketch.yaml of the most recent one.
+	ketchYaml := &ketchv1.KetchYamlData{}
+	if n := len(application.Spec.Deployments); n > 0 && application.Spec.Deployments[n-1].KetchYaml != nil {
+		ketchYaml = application.Spec.Deployments[n-1].KetchYaml
+	}
+	if values.App.Ingress.Policy, err = newIngressPolicy(ketchYaml.IngressPolicy); err != nil {
+		return nil, err
+	}
+	// pods run with a service account of the app if ketch.yaml configures one, unless the app uses an existing one.
+	if application.Spec.ServiceAccountName == "" && ketchYaml.ServiceAccount != nil {
+		if values.App.ServiceAccount, err = newServiceAccount(application.Name, *ketchYaml.ServiceAccount, application.Spec.Ingress.Controller); err != nil {
+			return nil, err
+		}
+		values.App.ServiceAccountName = values.App.ServiceAccount.Name
+	}
 
 	return &ApplicationChart{
 		values:    *values,
@@ -238,6 +502,8 @@ type ChartConfig struct {
 	AppName            string
 	AppVersion         string
 	DeploymentVersions []int
+	// PodSpecPatches are strategic merge patches applied to pod templates of workloads by their names.
+	PodSpecPatches map[string][]byte
 }
 
 // NewChartConfig returns a ChartConfig instance based on the given application.
@@ -253,6 +519,7 @@ func NewChartConfig(app ketchv1.App) ChartConfig {
 		AppName:            app.Name,
 		AppVersion:         version,
 		DeploymentVersions: deploymentVersions(app),
+		PodSpecPatches:     podSpecPatches(app),
 	}
 }
 
@@ -278,6 +545,15 @@ func (config ChartConfig) render() ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// RenderApplication renders the manifests of the app's helm chart without installing it.
+func RenderApplication(app *ketchv1.App, tpls templates.Templates, opts ...Option) (string, error) {
+	appChrt, err := New(app, append([]Option{WithExposedPorts(app.ExposedPorts()), WithTemplates(tpls)}, opts...)...)
+	if err != nil {
+		return "", err
+	}
+	return RenderChart(*appChrt, NewChartConfig(*app), app.Spec.Namespace)
+}
+
 // ExportToDirectory saves the chart to the provided directory inside a folder with app_Name_TIMESTAMP
 //
 //	for example, for any app with name `hello`, it will save chart inside a folder with name `hello_11_Dec_20_12_30_IST`
@@ -285,8 +561,15 @@ func (chrt ApplicationChart) ExportToDirectory(directory string, chartConfig Cha
 	timestamp := time.Now().Format(time.RFC822)
 	replacer := strings.NewReplacer(" ", "_", ":", "_")
 	chartDir := chartConfig.AppName + "_" + replacer.Replace(timestamp)
-	targetDir := filepath.Join(directory, chartDir)
+	return chrt.WriteToDirectory(filepath.Join(directory, chartDir), chartConfig)
+}
 
+// WriteToDirectory saves the chart to the provided directory replacing templates of a chart saved there before,
+// so the same directory can be kept in a git repository and updated on every change of the app.
+func (chrt ApplicationChart) WriteToDirectory(targetDir string, chartConfig ChartConfig) error {
+	if err := os.RemoveAll(filepath.Join(targetDir, "templates")); err != nil {
+		return err
+	}
 	err := os.MkdirAll(filepath.Join(targetDir, "templates"), os.ModePerm)
 	if err != nil {
 		return err
@@ -332,6 +615,47 @@ func (a ApplicationChart) GetValues() interface{} {
 	return a.values
 }
 
+func volumeClaimName(appName, claimName string) string {
+	return fmt.Sprintf("%s-%s", appName, claimName)
+}
+
+// addVolumeClaims converts claims declared in ketch.yaml of a deployment to PersistentVolumeClaims.
+// Deployments of the same app share claims with the same name, the latest deployment defines their spec.
+func addVolumeClaims(pvcs []ketchv1.PersistentVolumeClaim, appName string, claims []ketchv1.KetchYamlVolumeClaim) ([]ketchv1.PersistentVolumeClaim, error) {
+	for _, claim := range claims {
+		pvc, err := newPersistentVolumeClaim(volumeClaimName(appName, claim.Name), claim.Size, claim.StorageClass, claim.AccessModes)
+		if err != nil {
+			return nil, err
+		}
+		replaced := false
+		for i := range pvcs {
+			if pvcs[i].Name == pvc.Name {
+				pvcs[i] = pvc
+				replaced = true
+			}
+		}
+		if !replaced {
+			pvcs = append(pvcs, pvc)
+		}
+	}
+	return pvcs, nil
+}
+
+func newPersistentVolumeClaim(name, size string, storageClass *string, accessModes []v1.PersistentVolumeAccessMode) (ketchv1.PersistentVolumeClaim, error) {
+	if _, err := resource.ParseQuantity(size); err != nil {
+		return ketchv1.PersistentVolumeClaim{}, fmt.Errorf("invalid size of volume claim %q: %w", name, err)
+	}
+	if len(accessModes) == 0 {
+		accessModes = []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce}
+	}
+	return ketchv1.PersistentVolumeClaim{
+		Name:             name,
+		AccessModes:      accessModes,
+		StorageClassName: storageClass,
+		Storage:          size,
+	}, nil
+}
+
 func isAppAccessible(a *app) bool {
 	if len(a.Ingress.Http)+len(a.Ingress.Https) == 0 {
 		return false
@@ -3,19 +3,23 @@ package chart
 import (
 	"fmt"
 	"io/ioutil"
+	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chartutil"
 	"helm.sh/helm/v3/pkg/kube/fake"
 	"helm.sh/helm/v3/pkg/storage"
 	"helm.sh/helm/v3/pkg/storage/driver"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/yaml"
 
 	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
 	"github.com/theketchio/ketch/internal/templates"
@@ -38,6 +42,43 @@ func TestNewApplicationChart(t *testing.T) {
 		ClusterIssuer:   "letsencrypt-production"}
 	ingressControllerWithoutClusterIssuer := ketchv1.IngressControllerSpec{ClassName: "gke",
 		ServiceEndpoint: "20.20.20.20"}
+	ingressControllerWithExternalDNS := ingressController
+	ingressControllerWithExternalDNS.ExternalDNS = &ketchv1.ExternalDNSSpec{
+		TTL:           300,
+		ProviderHints: map[string]string{"cloudflare-proxied": "true"},
+	}
+	istioControllerWithExternalDNS := ingressControllerWithExternalDNS
+	istioControllerWithExternalDNS.IngressType = ketchv1.IstioIngressControllerType
+	nginxController := ingressController
+	nginxController.IngressType = ketchv1.NginxIngressControllerType
+	traefikController := ingressController
+	traefikController.IngressType = ketchv1.TraefikIngressControllerType
+	linkerdController := nginxController
+	linkerdController.ServiceMesh = ketchv1.LinkerdServiceMesh
+	openTelemetryController := nginxController
+	openTelemetryController.OpenTelemetry = &ketchv1.OpenTelemetrySpec{
+		Endpoint:        "http://otel-collector.monitoring:4317",
+		Instrumentation: "monitoring/default",
+	}
+	loggingController := nginxController
+	loggingController.Logging = &ketchv1.LoggingSpec{
+		Annotations:  map[string]string{"fluentbit.io/parser": "json"},
+		AppLabel:     "app.kubernetes.io/name",
+		ProcessLabel: "app.kubernetes.io/component",
+	}
+	grafanaController := nginxController
+	grafanaController.GrafanaDashboards = &ketchv1.GrafanaDashboardsSpec{
+		Annotations: map[string]string{"grafana_folder": "apps"},
+	}
+	istioController := ingressController
+	istioController.IngressType = ketchv1.IstioIngressControllerType
+	ingressControllerWithIssuer := ingressController
+	ingressControllerWithIssuer.CertificateIssuer = &ketchv1.CertificateIssuerSpec{
+		IssuerRef: ketchv1.IssuerRef{Name: "letsencrypt", Kind: ketchv1.NamespacedIssuerKind},
+		SolverLabels: map[ketchv1.ACMESolverType]map[string]string{
+			ketchv1.DNS01SolverType: {"acme-solver": "route53"},
+		},
+	}
 
 	dashboard := &ketchv1.App{
 		ObjectMeta: metav1.ObjectMeta{
@@ -227,6 +268,390 @@ func TestNewApplicationChart(t *testing.T) {
 		}
 		return &out
 	}
+	setVolumeClaims := func(app *ketchv1.App) *ketchv1.App {
+		out := app.DeepCopy()
+		storageClass := "standard"
+		out.Spec.Deployments[0].KetchYaml.VolumeClaims = []ketchv1.KetchYamlVolumeClaim{
+			{
+				Name:         "uploads",
+				Size:         "5Gi",
+				StorageClass: &storageClass,
+				AccessModes:  []v1.PersistentVolumeAccessMode{"ReadWriteMany"},
+				Mounts: []ketchv1.KetchYamlVolumeClaimMount{
+					{Process: "web", Path: "/uploads"},
+					{Process: "worker", Path: "/uploads", ReadOnly: true},
+				},
+			},
+			{
+				Name: "cache",
+				Size: "1Gi",
+				Mounts: []ketchv1.KetchYamlVolumeClaimMount{
+					{Process: "worker", Path: "/var/cache"},
+				},
+			},
+		}
+		return out
+	}
+	setStatefulSetProcess := func(app *ketchv1.App) *ketchv1.App {
+		out := app.DeepCopy()
+		out.Spec.Deployments[0].KetchYaml.Kubernetes = &ketchv1.KetchYamlKubernetesConfig{
+			Processes: map[string]ketchv1.KetchYamlProcessConfig{
+				"worker": {
+					Kind: "statefulset",
+					VolumeClaimTemplates: []ketchv1.KetchYamlVolumeClaimTemplate{
+						{Name: "queue", Size: "2Gi", Path: "/var/lib/queue"},
+					},
+				},
+			},
+		}
+		return out
+	}
+	setDaemonSetProcess := func(app *ketchv1.App) *ketchv1.App {
+		out := app.DeepCopy()
+		out.Spec.Deployments[0].KetchYaml.Kubernetes = &ketchv1.KetchYamlKubernetesConfig{
+			Processes: map[string]ketchv1.KetchYamlProcessConfig{
+				"worker": {Kind: "daemonset"},
+			},
+		}
+		return out
+	}
+	setEnvValueFrom := func(app *ketchv1.App) *ketchv1.App {
+		out := app.DeepCopy()
+		out.Spec.Env = append(out.Spec.Env,
+			ketchv1.Env{Name: "DB_PASSWORD", ValueFrom: &v1.EnvVarSource{
+				SecretKeyRef: &v1.SecretKeySelector{LocalObjectReference: v1.LocalObjectReference{Name: "db-credentials"}, Key: "password"},
+			}},
+			ketchv1.Env{Name: "POD_NAME", ValueFrom: &v1.EnvVarSource{
+				FieldRef: &v1.ObjectFieldSelector{FieldPath: "metadata.name"},
+			}},
+		)
+		return out
+	}
+	setCnamePaths := func(app *ketchv1.App) *ketchv1.App {
+		out := app.DeepCopy()
+		out.Spec.Ingress.Cnames = []ketchv1.Cname{
+			{Name: "theketch.io", Path: "/dashboard"},
+			{Name: "*.apps.theketch.io"},
+			{Name: "admin.theketch.io", Secure: true, Path: "/dashboard"},
+		}
+		return out
+	}
+	setSecureWildcardCnames := func(app *ketchv1.App) *ketchv1.App {
+		out := app.DeepCopy()
+		out.Spec.Ingress.Cnames = []ketchv1.Cname{
+			{Name: "theketch.io", Secure: true},
+			{Name: "*.apps.theketch.io", Secure: true},
+		}
+		return out
+	}
+	setCnameDNS := func(app *ketchv1.App) *ketchv1.App {
+		out := app.DeepCopy()
+		out.Spec.Ingress.Cnames = []ketchv1.Cname{
+			{Name: "theketch.io"},
+			{Name: "www.theketch.io"},
+			{Name: "admin.theketch.io", Secure: true, DNS: &ketchv1.CnameDNS{
+				Target:        "lb.theketch.io",
+				TTL:           60,
+				ProviderHints: map[string]string{"cloudflare-proxied": "false"},
+			}},
+		}
+		return out
+	}
+	setShadowDeployment := func(app *ketchv1.App) *ketchv1.App {
+		out := app.DeepCopy()
+		out.Spec.Deployments[0].RoutingSettings = ketchv1.RoutingSettings{Weight: 100}
+		out.Spec.Deployments[1].RoutingSettings = ketchv1.RoutingSettings{MirrorPercentage: 10}
+		return out
+	}
+	setCanaryRouting := func(app *ketchv1.App) *ketchv1.App {
+		out := app.DeepCopy()
+		out.Spec.Canary = ketchv1.CanarySpec{
+			Steps:      4,
+			StepWeight: 25,
+			Active:     true,
+			Routing:    &ketchv1.CanaryRouting{Header: "X-Canary", HeaderValue: "true", Cookie: "canary"},
+		}
+		return out
+	}
+	setMaintenance := func(app *ketchv1.App) *ketchv1.App {
+		out := app.DeepCopy()
+		out.Spec.Maintenance = &ketchv1.MaintenanceSpec{Page: "<h1>Back soon</h1>\n"}
+		return out
+	}
+	setIngressPolicy := func(app *ketchv1.App) *ketchv1.App {
+		out := app.DeepCopy()
+		out.Spec.Deployments[1].KetchYaml = &ketchv1.KetchYamlData{
+			IngressPolicy: &ketchv1.KetchYamlIngressPolicy{
+				RateLimit:      &ketchv1.KetchYamlRateLimit{RequestsPerSecond: 10, Burst: 50},
+				MaxBodySize:    "8Mi",
+				Timeout:        "1m",
+				ConnectTimeout: "1500ms",
+			},
+		}
+		return out
+	}
+	setNetworkPolicy := func(app *ketchv1.App) *ketchv1.App {
+		out := app.DeepCopy()
+		out.Spec.NetworkPolicy = &ketchv1.NetworkPolicySpec{AllowFromNamespaces: []string{"monitoring"}}
+		return out
+	}
+	setOwnership := func(app *ketchv1.App) *ketchv1.App {
+		out := app.DeepCopy()
+		out.Spec.Team = "payments"
+		out.Spec.Owner = "alice"
+		return out
+	}
+	setEnvSets := func(app *ketchv1.App) *ketchv1.App {
+		out := app.DeepCopy()
+		out.Spec.EnvSets = []string{"observability", "database"}
+		return out
+	}
+	envSets := []ketchv1.EnvSet{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "database"},
+			Spec:       ketchv1.EnvSetSpec{Env: []ketchv1.Env{{Name: "DATABASE_HOST", Value: "db.example.com"}, {Name: "LOG_LEVEL", Value: "debug"}}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "observability"},
+			Spec:       ketchv1.EnvSetSpec{Env: []ketchv1.Env{{Name: "LOG_LEVEL", Value: "info"}, {Name: "VAR", Value: "env set"}, {Name: "REGION", Value: "us-east-1"}}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "unused"},
+			Spec:       ketchv1.EnvSetSpec{Env: []ketchv1.Env{{Name: "UNUSED", Value: "1"}}},
+		},
+	}
+	setServiceBindings := func(app *ketchv1.App) *ketchv1.App {
+		out := app.DeepCopy()
+		out.Spec.ServiceBindings = []ketchv1.ServiceBinding{
+			{Name: "db", SecretName: "postgres", EnvPrefix: "DATABASE_"},
+			{Name: "queue", SecretName: "rabbitmq", EnvPrefix: "QUEUE_", Processes: []string{"worker"}},
+		}
+		return out
+	}
+	bindingSecrets := map[string]v1.Secret{
+		"postgres": {ObjectMeta: metav1.ObjectMeta{Name: "postgres"}, Data: map[string][]byte{"host": []byte("db.example.com"), "password": []byte("secret")}},
+		"rabbitmq": {ObjectMeta: metav1.ObjectMeta{Name: "rabbitmq"}, Data: map[string][]byte{"url": []byte("amqp://queue.example.com")}},
+	}
+	setKetchYamlServiceAccount := func(app *ketchv1.App) *ketchv1.App {
+		out := app.DeepCopy()
+		out.Spec.Deployments[1].KetchYaml = &ketchv1.KetchYamlData{
+			ServiceAccount: &ketchv1.KetchYamlServiceAccount{
+				Annotations: map[string]string{"eks.amazonaws.com/role-arn": "arn:aws:iam::111122223333:role/dashboard"},
+				Roles: []ketchv1.KetchYamlRoleRef{
+					{Kind: "Role", Name: "app-reader"},
+					{Kind: "ClusterRole", Name: "system:aggregate-to-view"},
+				},
+			},
+		}
+		return out
+	}
+	setPodSpecPatch := func(app *ketchv1.App) *ketchv1.App {
+		out := app.DeepCopy()
+		out.Spec.Deployments[1].KetchYaml = &ketchv1.KetchYamlData{
+			Kubernetes: &ketchv1.KetchYamlKubernetesConfig{
+				Processes: map[string]ketchv1.KetchYamlProcessConfig{
+					"worker": {
+						PodSpecPatch: &runtime.RawExtension{
+							Raw: []byte(`{"hostAliases":[{"ip":"10.0.0.1","hostnames":["db.local"]}],"containers":[{"name":"dashboard-worker-4","stdin":true}]}`),
+						},
+					},
+				},
+			},
+		}
+		return out
+	}
+	setScalers := func(app *ketchv1.App) *ketchv1.App {
+		out := app.DeepCopy()
+		minUnits, maxUnits, cooldownPeriod := 0, 20, 120
+		out.Spec.Deployments[1].KetchYaml = &ketchv1.KetchYamlData{
+			Scalers: []ketchv1.KetchYamlScaler{
+				{
+					Process:        "worker",
+					MinUnits:       &minUnits,
+					MaxUnits:       &maxUnits,
+					CooldownPeriod: &cooldownPeriod,
+					Triggers: []ketchv1.KetchYamlScalerTrigger{
+						{
+							Type:     "kafka",
+							Metadata: map[string]string{"bootstrapServers": "kafka:9092", "consumerGroup": "dashboard", "topic": "events", "lagThreshold": "50"},
+						},
+						{
+							Type:              "aws-sqs-queue",
+							Metadata:          map[string]string{"queueURL": "https://sqs.eu-west-1.amazonaws.com/account_id/jobs", "queueLength": "5", "awsRegion": "eu-west-1"},
+							AuthenticationRef: "aws-credentials",
+						},
+					},
+				},
+			},
+		}
+		return out
+	}
+	setAutoscale := func(app *ketchv1.App) *ketchv1.App {
+		out := app.DeepCopy()
+		out.Spec.Deployments[1].Processes[0].Autoscale = &ketchv1.AutoscaleSpec{MinUnits: 2, MaxUnits: 8, CPUPercent: 75}
+		return out
+	}
+	setDeployHooks := func(app *ketchv1.App) *ketchv1.App {
+		out := app.DeepCopy()
+		for i := range out.Spec.Deployments {
+			out.Spec.Deployments[i].ReleaseCmd = []string{"/bin/sh", "-c", "rake db:migrate"}
+		}
+		out.Spec.Deployments[1].KetchYaml = &ketchv1.KetchYamlData{
+			Hooks: &ketchv1.KetchYamlHooks{
+				PreDeploy:  []string{"./bin/check-config"},
+				PostDeploy: []string{"./bin/warm-cache", "./bin/notify"},
+			},
+		}
+		// version 3 is already rolled out, its release process doesn't run again.
+		out.Status.DeploymentHistory = []ketchv1.DeploymentRecord{
+			{AppDeploymentSpec: out.Spec.Deployments[0]},
+		}
+		return out
+	}
+	setTerminationGracePeriod := func(app *ketchv1.App) *ketchv1.App {
+		out := app.DeepCopy()
+		gracePeriod := int64(60)
+		out.Spec.Deployments[1].KetchYaml = &ketchv1.KetchYamlData{
+			Kubernetes: &ketchv1.KetchYamlKubernetesConfig{
+				Processes: map[string]ketchv1.KetchYamlProcessConfig{
+					"web": {TerminationGracePeriodSeconds: &gracePeriod},
+				},
+			},
+		}
+		return out
+	}
+	setPodClasses := func(app *ketchv1.App) *ketchv1.App {
+		out := app.DeepCopy()
+		out.Spec.Deployments[1].KetchYaml = &ketchv1.KetchYamlData{
+			Kubernetes: &ketchv1.KetchYamlKubernetesConfig{
+				Processes: map[string]ketchv1.KetchYamlProcessConfig{
+					"web":    {PriorityClassName: "high-priority"},
+					"worker": {RuntimeClassName: "gvisor"},
+				},
+			},
+		}
+		return out
+	}
+	setStickySessions := func(app *ketchv1.App) *ketchv1.App {
+		out := app.DeepCopy()
+		out.Spec.Deployments[1].KetchYaml = &ketchv1.KetchYamlData{
+			Kubernetes: &ketchv1.KetchYamlKubernetesConfig{
+				Processes: map[string]ketchv1.KetchYamlProcessConfig{
+					"web":    {StickySessions: true},
+					"worker": {StickySessions: true},
+				},
+			},
+		}
+		return out
+	}
+	setHeadlessService := func(app *ketchv1.App) *ketchv1.App {
+		out := app.DeepCopy()
+		out.Spec.Deployments[1].KetchYaml = &ketchv1.KetchYamlData{
+			Kubernetes: &ketchv1.KetchYamlKubernetesConfig{
+				Processes: map[string]ketchv1.KetchYamlProcessConfig{
+					"web":    {HeadlessService: ketchv1.HeadlessServiceAlongside},
+					"worker": {HeadlessService: ketchv1.HeadlessServiceInstead},
+				},
+			},
+		}
+		return out
+	}
+	setRoutes := func(app *ketchv1.App) *ketchv1.App {
+		out := app.DeepCopy()
+		out.Spec.Deployments[1].KetchYaml = &ketchv1.KetchYamlData{
+			Kubernetes: &ketchv1.KetchYamlKubernetesConfig{
+				Processes: map[string]ketchv1.KetchYamlProcessConfig{
+					"web": {
+						Routes: []ketchv1.KetchYamlRoute{
+							{Name: "GET /books/{id}", Method: "get", PathRegex: "/books/[^/]+", Retryable: true, Timeout: "5s"},
+							{Name: "/health", PathRegex: "/health"},
+						},
+					},
+				},
+			},
+		}
+		return out
+	}
+	setLanguage := func(app *ketchv1.App) *ketchv1.App {
+		out := app.DeepCopy()
+		out.Spec.Deployments[1].KetchYaml = &ketchv1.KetchYamlData{Language: "java"}
+		return out
+	}
+	setExcludeLogs := func(app *ketchv1.App) *ketchv1.App {
+		out := app.DeepCopy()
+		out.Spec.Deployments[1].KetchYaml = &ketchv1.KetchYamlData{
+			Kubernetes: &ketchv1.KetchYamlKubernetesConfig{
+				Processes: map[string]ketchv1.KetchYamlProcessConfig{
+					"worker": {ExcludeLogs: true},
+				},
+			},
+		}
+		return out
+	}
+	setMetrics := func(app *ketchv1.App) *ketchv1.App {
+		out := app.DeepCopy()
+		out.Spec.Deployments[1].KetchYaml = &ketchv1.KetchYamlData{
+			Kubernetes: &ketchv1.KetchYamlKubernetesConfig{
+				Processes: map[string]ketchv1.KetchYamlProcessConfig{
+					"web":    {Metrics: &ketchv1.KetchYamlMetrics{Port: 9102, Interval: "30s"}},
+					"worker": {Metrics: &ketchv1.KetchYamlMetrics{Port: 9103, Path: "/stats/prometheus"}},
+				},
+			},
+		}
+		return out
+	}
+	setBasicAuth := func(app *ketchv1.App) *ketchv1.App {
+		out := app.DeepCopy()
+		out.Spec.Ingress.Auth = &ketchv1.AuthSpec{BasicAuthSecret: "dashboard-users"}
+		return out
+	}
+	setCnameAuth := func(app *ketchv1.App) *ketchv1.App {
+		out := setBasicAuth(app)
+		out.Spec.Ingress.Cnames[2].Auth = &ketchv1.AuthSpec{ForwardAuthURL: "http://oauth2-proxy.auth.svc/oauth2/auth"}
+		return out
+	}
+	setOIDCAuth := func(app *ketchv1.App) *ketchv1.App {
+		out := setCnamePaths(app)
+		out.Spec.Ingress.Auth = &ketchv1.AuthSpec{OIDC: &ketchv1.OIDCAuth{Issuer: "https://accounts.google.com", JWKSURI: "https://www.googleapis.com/oauth2/v3/certs"}}
+		out.Spec.Ingress.Cnames[1].Auth = &ketchv1.AuthSpec{OIDC: &ketchv1.OIDCAuth{Issuer: "https://auth.theketch.io"}}
+		return out
+	}
+	setAllowedCIDRs := func(app *ketchv1.App) *ketchv1.App {
+		out := app.DeepCopy()
+		out.Spec.Ingress.AllowedCIDRs = []string{"10.0.0.0/8", "172.16.0.0/12"}
+		return out
+	}
+	setCnameAllowedCIDRs := func(app *ketchv1.App) *ketchv1.App {
+		out := setAllowedCIDRs(app)
+		out.Spec.Ingress.Cnames[2].AllowedCIDRs = []string{"192.168.0.0/16"}
+		return out
+	}
+	setInternal := func(app *ketchv1.App) *ketchv1.App {
+		out := app.DeepCopy()
+		out.Spec.Expose = ketchv1.ExposeInternal
+		out.Spec.InternalLoadBalancerAnnotations = map[string]string{"networking.gke.io/load-balancer-type": "Internal"}
+		return out
+	}
+	setProcessTimeout := func(app *ketchv1.App) *ketchv1.App {
+		out := app.DeepCopy()
+		out.Spec.Deployments[1].KetchYaml = &ketchv1.KetchYamlData{
+			Kubernetes: &ketchv1.KetchYamlKubernetesConfig{
+				Processes: map[string]ketchv1.KetchYamlProcessConfig{
+					"web": {Timeout: "10m", WebSocket: true},
+				},
+			},
+		}
+		return out
+	}
+	setProcessImage := func(app *ketchv1.App) *ketchv1.App {
+		out := app.DeepCopy()
+		out.Spec.Deployments[1].Processes[1].Image = "shipasoftware/go-app-worker:v1"
+		return out
+	}
+	ingressControllerWithPreStopSleep := ingressController
+	ingressControllerWithPreStopSleep.PreStopSleepSeconds = 15
+	ingressControllerWithRestrictedProfile := ingressController
+	ingressControllerWithRestrictedProfile.PodSecurityProfile = ketchv1.PodSecurityProfileRestricted
 	setStatefulSet := func(app *ketchv1.App) *ketchv1.App {
 		out := *app
 		appType := ketchv1.StatefulSetAppType
@@ -240,8 +665,13 @@ func TestNewApplicationChart(t *testing.T) {
 		ingressController ketchv1.IngressControllerSpec
 		opts              []Option
 		group             string
+		// apiVersions are API versions of the cluster besides the built-in ones, e.g. of installed CRDs.
+		apiVersions []string
 
+		// wantYamlsFilename is the golden file of the whole chart, kept for the baselines of ingress controllers.
+		// wantObjects checks only the rendered objects of a feature.
 		wantYamlsFilename string
+		wantObjects       func(t *testing.T, objects renderedObjects)
 		wantErr           bool
 	}{
 		{
@@ -295,96 +725,1362 @@ func TestNewApplicationChart(t *testing.T) {
 			wantYamlsFilename: "dashboard-istio-cluster-issuer-volume-claim-templates",
 		},
 		{
-			name: "istio templates without cluster issuer",
+			name: "nginx templates with volume claims",
+			opts: []Option{
+				WithTemplates(templates.NginxDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+			},
+			application:       setVolumeClaims(dashboard),
+			ingressController: ingressController,
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				objects.requireField(t, "PersistentVolumeClaim/dashboard-uploads", `{accessModes: [ReadWriteMany], storageClassName: standard, resources: {requests: {storage: 5Gi}}}`, "spec")
+				objects.requireField(t, "PersistentVolumeClaim/dashboard-cache", `{accessModes: [ReadWriteOnce], resources: {requests: {storage: 1Gi}}}`, "spec")
+				objects.requireField(t, "Deployment/dashboard-web-3", `[{mountPath: /test-ebs, name: test-volume}, {mountPath: /uploads, name: uploads}]`, "spec", "template", "spec", "containers", 0, "volumeMounts")
+				objects.requireField(t, "Deployment/dashboard-worker-3", `[{mountPath: /uploads, name: uploads, readOnly: true}, {mountPath: /var/cache, name: cache}]`, "spec", "template", "spec", "containers", 0, "volumeMounts")
+				objects.requireField(t, "Deployment/dashboard-worker-3", `[{name: uploads, persistentVolumeClaim: {claimName: dashboard-uploads}}, {name: cache, persistentVolumeClaim: {claimName: dashboard-cache}}]`, "spec", "template", "spec", "volumes")
+			},
+		},
+		{
+			name: "nginx templates with a statefulset process",
+			opts: []Option{
+				WithTemplates(templates.NginxDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+			},
+			application:       setStatefulSetProcess(dashboard),
+			ingressController: ingressController,
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				objects.requireMissing(t, "Deployment/dashboard-worker-3")
+				objects.requireField(t, "StatefulSet/dashboard-worker-3", `dashboard-worker-3-headless`, "spec", "serviceName")
+				objects.requireField(t, "StatefulSet/dashboard-worker-3", `[{metadata: {name: queue}, spec: {accessModes: [ReadWriteOnce], resources: {requests: {storage: 2Gi}}}}]`, "spec", "volumeClaimTemplates")
+				objects.requireField(t, "StatefulSet/dashboard-worker-3", `[{mountPath: /var/lib/queue, name: queue}]`, "spec", "template", "spec", "containers", 0, "volumeMounts")
+				objects.requireField(t, "Service/dashboard-worker-3-headless", `None`, "spec", "clusterIP")
+			},
+		},
+		{
+			name: "nginx templates with a daemonset process",
+			opts: []Option{
+				WithTemplates(templates.NginxDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+			},
+			application:       setDaemonSetProcess(dashboard),
+			ingressController: ingressController,
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				objects.requireMissing(t, "Deployment/dashboard-worker-3")
+				require.NotContains(t, objects.field(t, "DaemonSet/dashboard-worker-3", "spec"), "replicas")
+				objects.requireField(t, "DaemonSet/dashboard-worker-3", `[celery]`, "spec", "template", "spec", "containers", 0, "command")
+			},
+		},
+		{
+			name: "nginx templates with env variables read from a secret and a pod field",
+			opts: []Option{
+				WithTemplates(templates.NginxDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+			},
+			application:       setEnvValueFrom(dashboard),
+			ingressController: ingressController,
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				objects.requireField(t, "Deployment/dashboard-web-3", `[
+					{name: TEST_API_KEY, value: SECRET}, {name: TEST_API_URL, value: example.com},
+					{name: port, value: "9090"}, {name: PORT, value: "9090"}, {name: PORT_web, value: "9090"}, {name: VAR, value: VALUE},
+					{name: DB_PASSWORD, valueFrom: {secretKeyRef: {key: password, name: db-credentials}}},
+					{name: POD_NAME, valueFrom: {fieldRef: {fieldPath: metadata.name}}}]`, "spec", "template", "spec", "containers", 0, "env")
+			},
+		},
+		{
+			name: "nginx templates with wildcard and path prefix cnames",
+			opts: []Option{
+				WithTemplates(templates.NginxDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+			},
+			application:       setCnamePaths(dashboard),
+			ingressController: ingressController,
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				objects.requireField(t, "Ingress/dashboard-0-http-ingress", `[
+					{host: theketch.io, http: {paths: [{backend: {service: {name: dashboard-web-3, port: {number: 9090}}}, path: /dashboard, pathType: Prefix}]}},
+					{host: "*.apps.theketch.io", http: {paths: [{backend: {service: {name: dashboard-web-3, port: {number: 9090}}}, pathType: ImplementationSpecific}]}},
+					{host: dashboard.10.10.10.10.shipa.cloud, http: {paths: [{backend: {service: {name: dashboard-web-3, port: {number: 9090}}}, pathType: ImplementationSpecific}]}}]`, "spec", "rules")
+				objects.requireField(t, "Ingress/dashboard-0-https-ingress", `[{hosts: [admin.theketch.io], secretName: dashboard-cname-admin-theketch-io-dashboard}]`, "spec", "tls")
+				objects.requireField(t, "Ingress/dashboard-0-https-ingress", `[
+					{host: admin.theketch.io, http: {paths: [{backend: {service: {name: dashboard-web-3, port: {number: 9090}}}, path: /dashboard, pathType: Prefix}]}}]`, "spec", "rules")
+				objects.requireField(t, "Certificate/dashboard-cname-admin-theketch-io-dashboard", `[admin.theketch.io]`, "spec", "dnsNames")
+			},
+		},
+		{
+			name: "istio templates with wildcard and path prefix cnames",
 			opts: []Option{
 				WithTemplates(templates.IstioDefaultTemplates),
 				WithExposedPorts(exportedPorts),
 			},
-			application:       convertSecureEndpoints(dashboard),
-			ingressController: ingressControllerWithoutClusterIssuer,
-			wantYamlsFilename: "dashboard-istio",
+			application:       setCnamePaths(dashboard),
+			ingressController: ingressController,
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				objects.requireField(t, "VirtualService/dashboard-http", `["theketch.io", "*.apps.theketch.io", "dashboard.10.10.10.10.shipa.cloud", "admin.theketch.io"]`, "spec", "hosts")
+				objects.requireField(t, "VirtualService/dashboard-http", `[
+					{authority: {regex: "^theketch\\.io(:[0-9]+)?$"}, uri: {prefix: /dashboard}},
+					{authority: {regex: "^[^.]+\\.apps\\.theketch\\.io(:[0-9]+)?$"}},
+					{authority: {regex: "^dashboard\\.10\\.10\\.10\\.10\\.shipa\\.cloud(:[0-9]+)?$"}},
+					{authority: {regex: "^admin\\.theketch\\.io(:[0-9]+)?$"}, uri: {prefix: /dashboard}}]`, "spec", "http", 0, "match")
+				objects.requireField(t, "Gateway/dashboard-http-gateway", `["theketch.io", "*.apps.theketch.io", "dashboard.10.10.10.10.shipa.cloud"]`, "spec", "servers", 0, "hosts")
+				objects.requireField(t, "Gateway/dashboard-http-gateway", `{port: {number: 443, name: https-3-admin.theketch.io, protocol: HTTPS}, tls: {mode: SIMPLE, credentialName: dashboard-cname-admin-theketch-io-dashboard}, hosts: [admin.theketch.io]}`, "spec", "servers", 1)
+			},
 		},
 		{
-			name: "traefik templates with cluster issuer",
+			name: "traefik templates with wildcard and path prefix cnames",
 			opts: []Option{
 				WithTemplates(templates.TraefikDefaultTemplates),
 				WithExposedPorts(exportedPorts),
 			},
-			application:       dashboard,
+			application:       setCnamePaths(dashboard),
 			ingressController: ingressController,
-			wantYamlsFilename: "dashboard-traefik-cluster-issuer",
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				objects.requireField(t, "IngressRoute/dashboard-http-ingressroute", `Host("theketch.io") && PathPrefix("/dashboard")`, "spec", "routes", 0, "match")
+				objects.requireField(t, "IngressRoute/dashboard-http-ingressroute", `HostRegexp("{subdomain:[a-z0-9-]+}.apps.theketch.io")`, "spec", "routes", 1, "match")
+				objects.requireField(t, "IngressRoute/dashboard-https-admin-theketch-io-dashboard", `Host("admin.theketch.io") && PathPrefix("/dashboard")`, "spec", "routes", 0, "match")
+				objects.requireField(t, "IngressRoute/dashboard-https-admin-theketch-io-dashboard", `{secretName: dashboard-cname-admin-theketch-io-dashboard}`, "spec", "tls")
+			},
 		},
 		{
-			name: "traefik templates without cluster issuer",
+			name: "nginx templates with external-dns annotations",
 			opts: []Option{
-				WithTemplates(templates.TraefikDefaultTemplates),
+				WithTemplates(templates.NginxDefaultTemplates),
 				WithExposedPorts(exportedPorts),
 			},
-			application:       convertSecureEndpoints(dashboard),
-			ingressController: ingressControllerWithoutClusterIssuer,
-			wantYamlsFilename: "dashboard-traefik",
+			application:       setCnameDNS(dashboard),
+			ingressController: ingressControllerWithExternalDNS,
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				objects.requireEntries(t, "Ingress/dashboard-0-http-ingress", `{external-dns.alpha.kubernetes.io/cloudflare-proxied: "true", external-dns.alpha.kubernetes.io/target: 10.10.10.10, external-dns.alpha.kubernetes.io/ttl: "300"}`, "metadata", "annotations")
+				objects.requireEntries(t, "Ingress/dashboard-0-https-ingress", `{external-dns.alpha.kubernetes.io/cloudflare-proxied: "false", external-dns.alpha.kubernetes.io/target: lb.theketch.io, external-dns.alpha.kubernetes.io/ttl: "60"}`, "metadata", "annotations")
+				objects.requireField(t, "Ingress/dashboard-0-https-ingress", `[{hosts: [admin.theketch.io], secretName: dashboard-cname-admin-theketch-io}]`, "spec", "tls")
+			},
 		},
 		{
-			name: "traefik templates with cluster issuer and resource requirements",
+			name: "traefik templates with external-dns annotations",
 			opts: []Option{
 				WithTemplates(templates.TraefikDefaultTemplates),
 				WithExposedPorts(exportedPorts),
 			},
+			application:       setCnameDNS(dashboard),
+			ingressController: ingressControllerWithExternalDNS,
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				objects.requireEntries(t, "IngressRoute/dashboard-http-ingressroute", `{external-dns.alpha.kubernetes.io/cloudflare-proxied: "true", external-dns.alpha.kubernetes.io/target: 10.10.10.10, external-dns.alpha.kubernetes.io/ttl: "300"}`, "metadata", "annotations")
+				for _, name := range []string{"IngressRoute/dashboard-https-admin-theketch-io", "IngressRoute/dashboard-https-admin-theketch-io-http-redirect"} {
+					objects.requireEntries(t, name, `{external-dns.alpha.kubernetes.io/cloudflare-proxied: "false", external-dns.alpha.kubernetes.io/target: lb.theketch.io, external-dns.alpha.kubernetes.io/ttl: "60"}`, "metadata", "annotations")
+				}
+			},
+		},
+		{
+			name: "istio templates with external-dns annotations",
+			opts: []Option{
+				WithTemplates(templates.IstioDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+			},
 			application:       dashboard,
+			ingressController: istioControllerWithExternalDNS,
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				objects.requireEntries(t, "Gateway/dashboard-http-gateway", `{external-dns.alpha.kubernetes.io/cloudflare-proxied: "true", external-dns.alpha.kubernetes.io/target: 10.10.10.10, external-dns.alpha.kubernetes.io/ttl: "300"}`, "metadata", "annotations")
+			},
+		},
+		{
+			name: "istio templates with cnames of different dns settings",
+			opts: []Option{
+				WithTemplates(templates.IstioDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+			},
+			application:       setCnameDNS(dashboard),
+			ingressController: istioControllerWithExternalDNS,
+			wantErr:           true,
+		},
+		{
+			name: "istio templates with a shadow deployment",
+			opts: []Option{
+				WithTemplates(templates.IstioDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+			},
+			application:       setShadowDeployment(dashboard),
 			ingressController: ingressController,
-			wantYamlsFilename: "dashboard-traefik-cluster-issuer",
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				objects.requireField(t, "VirtualService/dashboard-http", `[{destination: {host: dashboard-web-3, port: {number: 9090}, subset: v3}, weight: 100}]`, "spec", "http", 0, "route")
+				objects.requireField(t, "VirtualService/dashboard-http", `{host: dashboard-web-4, port: {number: 9091}, subset: v4}`, "spec", "http", 0, "mirror")
+				objects.requireField(t, "VirtualService/dashboard-http", `{value: 10}`, "spec", "http", 0, "mirrorPercentage")
+			},
 		},
 		{
-			name: "traefik templates with cluster issuer w/ alternate group",
+			name: "traefik templates with a shadow deployment",
 			opts: []Option{
 				WithTemplates(templates.TraefikDefaultTemplates),
 				WithExposedPorts(exportedPorts),
 			},
-			application:       dashboard,
+			application:       setShadowDeployment(dashboard),
 			ingressController: ingressController,
-			group:             "shipa.io",
-			wantYamlsFilename: "dashboard-traefik-cluster-issuer-shipa",
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				objects.requireField(t, "TraefikService/dashboard-mirroring", `{mirroring: {name: dashboard-web-3, port: 9090, mirrors: [{name: dashboard-web-4, port: 9091, percent: 10}]}}`, "spec")
+				objects.requireField(t, "IngressRoute/dashboard-http-ingressroute", `[{name: dashboard-mirroring, kind: TraefikService}]`, "spec", "routes", 0, "services")
+				objects.requireField(t, "IngressRoute/dashboard-https-theketch-io", `[{name: dashboard-mirroring, kind: TraefikService}]`, "spec", "routes", 0, "services")
+			},
 		},
+		{
+			name: "nginx templates with canary routing",
+			opts: []Option{
+				WithTemplates(templates.NginxDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+			},
+			application:       setCanaryRouting(dashboard),
+			ingressController: ingressController,
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				for _, name := range []string{"Ingress/dashboard-1-http-ingress", "Ingress/dashboard-1-https-ingress"} {
+					objects.requireEntries(t, name, `{nginx.ingress.kubernetes.io/canary-by-header: X-Canary, nginx.ingress.kubernetes.io/canary-by-header-value: "true", nginx.ingress.kubernetes.io/canary-by-cookie: canary}`, "metadata", "annotations")
+				}
+				require.NotContains(t, objects.field(t, "Ingress/dashboard-0-http-ingress", "metadata", "annotations"), "nginx.ingress.kubernetes.io/canary-by-header")
+			},
+		},
+		{
+			name: "istio templates with canary routing",
+			opts: []Option{
+				WithTemplates(templates.IstioDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+			},
+			application:       setCanaryRouting(dashboard),
+			ingressController: ingressController,
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				objects.requireField(t, "VirtualService/dashboard-http", `{
+					match: [{headers: {x-canary: {exact: "true"}}}, {headers: {cookie: {regex: "^(.*; *)?canary=always(;.*)?$"}}}],
+					route: [{destination: {host: dashboard-web-4, port: {number: 9091}, subset: v4}}]}`, "spec", "http", 0)
+			},
+		},
+		{
+			name: "istio templates with canary routing of cname paths",
+			opts: []Option{
+				WithTemplates(templates.IstioDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+			},
+			application:       setCanaryRouting(setCnamePaths(dashboard)),
+			ingressController: ingressController,
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				objects.requireField(t, "VirtualService/dashboard-http", `[
+					{headers: {x-canary: {exact: "true"}}, authority: {regex: "^theketch\\.io(:[0-9]+)?$"}, uri: {prefix: /dashboard}},
+					{headers: {x-canary: {exact: "true"}}, authority: {regex: "^[^.]+\\.apps\\.theketch\\.io(:[0-9]+)?$"}},
+					{headers: {x-canary: {exact: "true"}}, authority: {regex: "^dashboard\\.10\\.10\\.10\\.10\\.shipa\\.cloud(:[0-9]+)?$"}},
+					{headers: {x-canary: {exact: "true"}}, authority: {regex: "^admin\\.theketch\\.io(:[0-9]+)?$"}, uri: {prefix: /dashboard}},
+					{headers: {cookie: {regex: "^(.*; *)?canary=always(;.*)?$"}}, authority: {regex: "^theketch\\.io(:[0-9]+)?$"}, uri: {prefix: /dashboard}},
+					{headers: {cookie: {regex: "^(.*; *)?canary=always(;.*)?$"}}, authority: {regex: "^[^.]+\\.apps\\.theketch\\.io(:[0-9]+)?$"}},
+					{headers: {cookie: {regex: "^(.*; *)?canary=always(;.*)?$"}}, authority: {regex: "^dashboard\\.10\\.10\\.10\\.10\\.shipa\\.cloud(:[0-9]+)?$"}},
+					{headers: {cookie: {regex: "^(.*; *)?canary=always(;.*)?$"}}, authority: {regex: "^admin\\.theketch\\.io(:[0-9]+)?$"}, uri: {prefix: /dashboard}}]`, "spec", "http", 0, "match")
+				objects.requireField(t, "VirtualService/dashboard-http", `[{destination: {host: dashboard-web-4, port: {number: 9091}, subset: v4}}]`, "spec", "http", 0, "route")
+				objects.requireField(t, "VirtualService/dashboard-http", `{authority: {regex: "^theketch\\.io(:[0-9]+)?$"}, uri: {prefix: /dashboard}}`, "spec", "http", 1, "match", 0)
+			},
+		},
+		{
+			name: "traefik templates with canary routing",
+			opts: []Option{
+				WithTemplates(templates.TraefikDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+			},
+			application:       setCanaryRouting(dashboard),
+			ingressController: ingressController,
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				objects.requireField(t, "IngressRoute/dashboard-http-ingressroute", `{
+					match: "Host(\"dashboard.10.10.10.10.shipa.cloud\") && (Headers(\"X-Canary\", \"true\") || HeadersRegexp(\"Cookie\", \"(^|; *)canary=always(;|$)\"))",
+					kind: Rule, services: [{name: dashboard-web-4, port: 9091}]}`, "spec", "routes", 0)
+				objects.requireField(t, "IngressRoute/dashboard-https-darkweb-theketch-io", `Host("darkweb.theketch.io") && (Headers("X-Canary", "true") || HeadersRegexp("Cookie", "(^|; *)canary=always(;|$)"))`, "spec", "routes", 0, "match")
+			},
+		},
+		{
+			name: "nginx templates with sticky sessions",
+			opts: []Option{
+				WithTemplates(templates.NginxDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+			},
+			application:       setStickySessions(dashboard),
+			ingressController: ingressController,
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				objects.requireField(t, "Service/app-dashboard", `ClientIP`, "spec", "sessionAffinity")
+				objects.requireField(t, "Service/dashboard-web-4", `ClientIP`, "spec", "sessionAffinity")
+				objects.requireEntries(t, "Ingress/dashboard-1-http-ingress", `{nginx.ingress.kubernetes.io/affinity: cookie, nginx.ingress.kubernetes.io/session-cookie-name: dashboard-affinity}`, "metadata", "annotations")
+			},
+		},
+		{
+			name: "istio templates with sticky sessions",
+			opts: []Option{
+				WithTemplates(templates.IstioDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+			},
+			application:       setStickySessions(dashboard),
+			ingressController: ingressController,
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				objects.requireField(t, "Service/dashboard-web-4", `ClientIP`, "spec", "sessionAffinity")
+				objects.requireField(t, "DestinationRule/shipa-dashboard-rule-4", `{loadBalancer: {consistentHash: {httpCookie: {name: dashboard-affinity, ttl: 0s}}}}`, "spec", "trafficPolicy")
+				require.NotContains(t, objects.field(t, "DestinationRule/shipa-dashboard-rule-3", "spec"), "trafficPolicy")
+			},
+		},
+		{
+			name: "traefik templates with sticky sessions",
+			opts: []Option{
+				WithTemplates(templates.TraefikDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+			},
+			application:       setStickySessions(dashboard),
+			ingressController: ingressController,
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				objects.requireField(t, "Service/dashboard-web-4", `ClientIP`, "spec", "sessionAffinity")
+				objects.requireField(t, "IngressRoute/dashboard-http-ingressroute", `{cookie: {name: dashboard-affinity, httpOnly: true}}`, "spec", "routes", 0, "services", 1, "sticky")
+				require.NotContains(t, objects.field(t, "IngressRoute/dashboard-http-ingressroute", "spec", "routes", 0, "services", 0), "sticky")
+			},
+		},
+		{
+			name: "nginx templates with process timeout",
+			opts: []Option{
+				WithTemplates(templates.NginxDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+			},
+			application:       setProcessTimeout(dashboard),
+			ingressController: ingressController,
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				for _, name := range []string{"Ingress/dashboard-1-http-ingress", "Ingress/dashboard-1-https-ingress"} {
+					objects.requireEntries(t, name, `{nginx.ingress.kubernetes.io/proxy-read-timeout: "600", nginx.ingress.kubernetes.io/proxy-send-timeout: "600"}`, "metadata", "annotations")
+				}
+			},
+		},
+		{
+			name: "istio templates with process timeout",
+			opts: []Option{
+				WithTemplates(templates.IstioDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+			},
+			application:       setProcessTimeout(dashboard),
+			ingressController: ingressController,
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				objects.requireField(t, "DestinationRule/shipa-dashboard-rule-4", `{connectionPool: {http: {h2UpgradePolicy: DO_NOT_UPGRADE, idleTimeout: 600s}}}`, "spec", "trafficPolicy")
+				objects.requireField(t, "VirtualService/dashboard-http", `600s`, "spec", "http", 0, "timeout")
+			},
+		},
+		{
+			name: "traefik templates with process timeout",
+			opts: []Option{
+				WithTemplates(templates.TraefikDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+			},
+			application:       setProcessTimeout(dashboard),
+			ingressController: ingressController,
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				objects.requireField(t, "ServersTransport/dashboard-web-4-transport", `{forwardingTimeouts: {responseHeaderTimeout: 600s, idleConnTimeout: 600s}}`, "spec")
+				objects.requireField(t, "IngressRoute/dashboard-http-ingressroute", `dashboard-web-4-transport`, "spec", "routes", 0, "services", 1, "serversTransport")
+			},
+		},
+		{
+			name: "nginx templates with basic auth",
+			opts: []Option{
+				WithTemplates(templates.NginxDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+			},
+			application:       setBasicAuth(dashboard),
+			ingressController: nginxController,
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				for _, name := range []string{"Ingress/dashboard-0-http-ingress", "Ingress/dashboard-1-https-ingress"} {
+					objects.requireEntries(t, name, `{nginx.ingress.kubernetes.io/auth-type: basic, nginx.ingress.kubernetes.io/auth-secret: dashboard-users, nginx.ingress.kubernetes.io/auth-realm: Authentication Required}`, "metadata", "annotations")
+				}
+			},
+		},
+		{
+			name: "traefik templates with basic and forward auth",
+			opts: []Option{
+				WithTemplates(templates.TraefikDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+			},
+			application:       setCnameAuth(dashboard),
+			ingressController: traefikController,
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				objects.requireField(t, "IngressRoute/dashboard-http-ingressroute", `[{name: dashboard-http-dashboard-10-10-10-10-shipa-cloud-auth}]`, "spec", "routes", 0, "middlewares")
+				objects.requireField(t, "Middleware/dashboard-http-dashboard-10-10-10-10-shipa-cloud-auth", `{basicAuth: {secret: dashboard-users}}`, "spec")
+				objects.requireField(t, "IngressRoute/dashboard-https-darkweb-theketch-io", `[{name: dashboard-https-darkweb-theketch-io-auth}]`, "spec", "routes", 0, "middlewares")
+				objects.requireField(t, "Middleware/dashboard-https-darkweb-theketch-io-auth", `{forwardAuth: {address: "http://oauth2-proxy.auth.svc/oauth2/auth"}}`, "spec")
+			},
+		},
+		{
+			name: "istio templates with oidc auth",
+			opts: []Option{
+				WithTemplates(templates.IstioDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+			},
+			application:       setOIDCAuth(dashboard),
+			ingressController: istioController,
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				objects.requireField(t, "RequestAuthentication/dashboard-auth", `[{issuer: "https://accounts.google.com", jwksUri: "https://www.googleapis.com/oauth2/v3/certs"}, {issuer: "https://auth.theketch.io"}]`, "spec", "jwtRules")
+				objects.requireField(t, "AuthorizationPolicy/dashboard-auth", `DENY`, "spec", "action")
+				objects.requireField(t, "AuthorizationPolicy/dashboard-auth", `[
+					{from: [{source: {notRequestPrincipals: ["https://accounts.google.com/*"]}}], to: [{operation: {hosts: [theketch.io, "theketch.io:*"], paths: [/dashboard, /dashboard/*]}}]},
+					{from: [{source: {notRequestPrincipals: ["https://auth.theketch.io/*"]}}], to: [{operation: {hosts: ["*.apps.theketch.io"]}}]},
+					{from: [{source: {notRequestPrincipals: ["https://accounts.google.com/*"]}}], to: [{operation: {hosts: [dashboard.10.10.10.10.shipa.cloud, "dashboard.10.10.10.10.shipa.cloud:*"]}}]},
+					{from: [{source: {notRequestPrincipals: ["https://accounts.google.com/*"]}}], to: [{operation: {hosts: [admin.theketch.io, "admin.theketch.io:*"], paths: [/dashboard, /dashboard/*]}}]}]`, "spec", "rules")
+			},
+		},
+		{
+			name: "nginx templates with allowed cidrs",
+			opts: []Option{
+				WithTemplates(templates.NginxDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+			},
+			application:       setAllowedCIDRs(dashboard),
+			ingressController: nginxController,
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				for _, name := range []string{"Ingress/dashboard-0-http-ingress", "Ingress/dashboard-1-https-ingress"} {
+					objects.requireEntries(t, name, `{nginx.ingress.kubernetes.io/whitelist-source-range: "10.0.0.0/8,172.16.0.0/12"}`, "metadata", "annotations")
+				}
+			},
+		},
+		{
+			name: "traefik templates with allowed cidrs",
+			opts: []Option{
+				WithTemplates(templates.TraefikDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+			},
+			application:       setCnameAllowedCIDRs(dashboard),
+			ingressController: traefikController,
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				objects.requireField(t, "IngressRoute/dashboard-http-ingressroute", `[{name: dashboard-http-dashboard-10-10-10-10-shipa-cloud-allowlist}]`, "spec", "routes", 0, "middlewares")
+				objects.requireField(t, "Middleware/dashboard-http-dashboard-10-10-10-10-shipa-cloud-allowlist", `{ipWhiteList: {sourceRange: [10.0.0.0/8, 172.16.0.0/12]}}`, "spec")
+				objects.requireField(t, "Middleware/dashboard-https-darkweb-theketch-io-allowlist", `{ipWhiteList: {sourceRange: [192.168.0.0/16]}}`, "spec")
+			},
+		},
+		{
+			name: "istio templates with allowed cidrs",
+			opts: []Option{
+				WithTemplates(templates.IstioDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+			},
+			application:       setCnameAllowedCIDRs(dashboard),
+			ingressController: istioController,
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				objects.requireField(t, "AuthorizationPolicy/dashboard-allowlist", `DENY`, "spec", "action")
+				objects.requireField(t, "AuthorizationPolicy/dashboard-allowlist", `{from: [{source: {notRemoteIpBlocks: [10.0.0.0/8, 172.16.0.0/12]}}], to: [{operation: {hosts: [theketch.io, "theketch.io:*"]}}]}`, "spec", "rules", 1)
+				objects.requireField(t, "AuthorizationPolicy/dashboard-allowlist", `{from: [{source: {notRemoteIpBlocks: [192.168.0.0/16]}}], to: [{operation: {hosts: [darkweb.theketch.io, "darkweb.theketch.io:*"]}}]}`, "spec", "rules", 3)
+			},
+		},
+		{
+			name: "nginx templates with linkerd",
+			opts: []Option{
+				WithTemplates(templates.NginxDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+			},
+			application:       setRoutes(dashboard),
+			ingressController: linkerdController,
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				objects.requireEntries(t, "Deployment/dashboard-worker-4", `{linkerd.io/inject: enabled}`, "spec", "template", "metadata", "annotations")
+				objects.requireEntries(t, "Ingress/dashboard-1-http-ingress", `{nginx.ingress.kubernetes.io/service-upstream: "true"}`, "metadata", "annotations")
+				objects.requireField(t, "ServiceProfile/dashboard-web-4.test-ns.svc.cluster.local", `[
+					{name: "GET /books/{id}", condition: {method: GET, pathRegex: "/books/[^/]+"}, isRetryable: true, timeout: 5s},
+					{name: /health, condition: {pathRegex: /health}}]`, "spec", "routes")
+				objects.requireField(t, "TrafficSplit/dashboard", `{service: app-dashboard, backends: [{service: dashboard-web-3, weight: 30}, {service: dashboard-web-4, weight: 70}]}`, "spec")
+			},
+		},
+		{
+			name: "nginx templates with open telemetry",
+			opts: []Option{
+				WithTemplates(templates.NginxDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+			},
+			application:       setLanguage(dashboard),
+			ingressController: openTelemetryController,
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				objects.requireEntries(t, "Deployment/dashboard-worker-4", `{instrumentation.opentelemetry.io/inject-java: monitoring/default}`, "spec", "template", "metadata", "annotations")
+				objects.requireField(t, "Deployment/dashboard-worker-4", `[
+					{name: port, value: "9091"}, {name: PORT, value: "9091"}, {name: PORT_worker, value: "9091"}, {name: VAR, value: VALUE},
+					{name: OTEL_SERVICE_NAME, value: dashboard}, {name: OTEL_EXPORTER_OTLP_ENDPOINT, value: "http://otel-collector.monitoring:4317"}]`, "spec", "template", "spec", "containers", 0, "env")
+				require.NotContains(t, objects.field(t, "Deployment/dashboard-worker-3", "spec", "template", "metadata"), "annotations")
+			},
+		},
+		{
+			name: "nginx templates with logging",
+			opts: []Option{
+				WithTemplates(templates.NginxDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+			},
+			application:       setExcludeLogs(dashboard),
+			ingressController: loggingController,
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				objects.requireEntries(t, "Deployment/dashboard-web-3", `{app.kubernetes.io/component: web, app.kubernetes.io/name: dashboard}`, "spec", "template", "metadata", "labels")
+				objects.requireEntries(t, "Deployment/dashboard-web-3", `{fluentbit.io/parser: json}`, "spec", "template", "metadata", "annotations")
+				objects.requireField(t, "Deployment/dashboard-worker-4", `{fluentbit.io/exclude: "true"}`, "spec", "template", "metadata", "annotations")
+			},
+		},
+		{
+			name: "nginx templates with metrics",
+			opts: []Option{
+				WithTemplates(templates.NginxDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+			},
+			application:       setMetrics(dashboard),
+			ingressController: nginxController,
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				objects.requireField(t, "Deployment/dashboard-web-4", `{prometheus.io/path: /metrics, prometheus.io/port: "9102", prometheus.io/scrape: "true"}`, "spec", "template", "metadata", "annotations")
+				objects.requireField(t, "Deployment/dashboard-worker-4", `{prometheus.io/path: /stats/prometheus, prometheus.io/port: "9103", prometheus.io/scrape: "true"}`, "spec", "template", "metadata", "annotations")
+				objects.requireMissing(t, "PodMonitor/dashboard-web-4", "PodMonitor/dashboard-worker-4")
+			},
+		},
+		{
+			name: "nginx templates with metrics and the prometheus operator",
+			opts: []Option{
+				WithTemplates(templates.NginxDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+			},
+			application:       setMetrics(dashboard),
+			ingressController: nginxController,
+			apiVersions:       []string{"monitoring.coreos.com/v1", "monitoring.coreos.com/v1/PodMonitor"},
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				objects.requireField(t, "PodMonitor/dashboard-web-4", `[{targetPort: 9102, path: /metrics, interval: 30s}]`, "spec", "podMetricsEndpoints")
+				objects.requireField(t, "PodMonitor/dashboard-worker-4", `[{targetPort: 9103, path: /stats/prometheus}]`, "spec", "podMetricsEndpoints")
+				objects.requireField(t, "PodMonitor/dashboard-worker-4", `{theketch.io/app-name: dashboard, theketch.io/app-process: worker, theketch.io/app-deployment-version: "4", theketch.io/is-isolated-run: "false"}`, "spec", "selector", "matchLabels")
+			},
+		},
+		{
+			name: "nginx templates with a grafana dashboard",
+			opts: []Option{
+				WithTemplates(templates.NginxDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+			},
+			application:       dashboard,
+			ingressController: grafanaController,
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				objects.requireEntries(t, "ConfigMap/dashboard-grafana-dashboard", `{grafana_dashboard: "1"}`, "metadata", "labels")
+				objects.requireField(t, "ConfigMap/dashboard-grafana-dashboard", `{grafana_folder: apps}`, "metadata", "annotations")
+				require.Contains(t, objects.field(t, "ConfigMap/dashboard-grafana-dashboard", "data"), "dashboard.json")
+			},
+		},
+		{
+			name: "nginx templates with headless services",
+			opts: []Option{
+				WithTemplates(templates.NginxDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+			},
+			application:       setHeadlessService(dashboard),
+			ingressController: nginxController,
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				objects.requireField(t, "Service/dashboard-web-4-headless", `{clusterIP: None, ports: [{name: http-default-1, port: 9091, protocol: TCP, targetPort: 9091}], selector: {theketch.io/app-name: dashboard, theketch.io/app-process: web, theketch.io/app-deployment-version: "4", theketch.io/is-isolated-run: "false"}}`, "spec")
+				objects.requireField(t, "Service/dashboard-worker-4-headless", `None`, "spec", "clusterIP")
+				objects.requireField(t, "Service/dashboard-web-4", `ClusterIP`, "spec", "type")
+				objects.requireMissing(t, "Service/dashboard-worker-4", "Service/dashboard-web-3-headless")
+			},
+		},
+		{
+			name: "nginx templates of an internal app",
+			opts: []Option{
+				WithTemplates(templates.NginxDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+			},
+			application:       setInternal(dashboard),
+			ingressController: nginxController,
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				objects.requireField(t, "Service/app-dashboard", `LoadBalancer`, "spec", "type")
+				objects.requireField(t, "Service/app-dashboard", `{networking.gke.io/load-balancer-type: Internal}`, "metadata", "annotations")
+				objects.requireMissing(t, "Ingress/dashboard-0-http-ingress", "Ingress/dashboard-1-http-ingress", "Ingress/dashboard-0-https-ingress", "Ingress/dashboard-1-https-ingress", "Certificate/dashboard-cname-theketch-io")
+			},
+		},
+		{
+			name: "istio templates of an internal app",
+			opts: []Option{
+				WithTemplates(templates.IstioDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+			},
+			application:       setInternal(dashboard),
+			ingressController: istioController,
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				objects.requireField(t, "Service/app-dashboard", `LoadBalancer`, "spec", "type")
+				objects.requireField(t, "Service/app-dashboard", `{networking.gke.io/load-balancer-type: Internal}`, "metadata", "annotations")
+				objects.requireMissing(t, "Gateway/dashboard-http-gateway", "VirtualService/dashboard-http", "Certificate/dashboard-cname-theketch-io")
+			},
+		},
+		{
+			name: "nginx templates in maintenance mode",
+			opts: []Option{
+				WithTemplates(templates.NginxDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+			},
+			application:       setMaintenance(dashboard),
+			ingressController: ingressController,
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				objects.requireField(t, "ConfigMap/dashboard-maintenance", `"<h1>Back soon</h1>\n"`, "data", "index.html")
+				objects.requireField(t, "Deployment/dashboard-maintenance", `nginxinc/nginx-unprivileged:1.23-alpine`, "spec", "template", "spec", "containers", 0, "image")
+				for _, name := range []string{"Service/app-dashboard", "Service/dashboard-web-3", "Service/dashboard-worker-4"} {
+					objects.requireField(t, name, `{theketch.io/app-name: dashboard, theketch.io/app-maintenance: "true"}`, "spec", "selector")
+					objects.requireField(t, name, `8080`, "spec", "ports", 0, "targetPort")
+				}
+			},
+		},
+		{
+			name: "istio templates in maintenance mode",
+			opts: []Option{
+				WithTemplates(templates.IstioDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+			},
+			application:       setMaintenance(dashboard),
+			ingressController: ingressController,
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				objects.requireField(t, "Service/app-dashboard", `{theketch.io/app-name: dashboard, theketch.io/app-maintenance: "true"}`, "spec", "selector")
+				objects.requireField(t, "Deployment/dashboard-maintenance", `{theketch.io/app-name: dashboard, theketch.io/app-maintenance: "true"}`, "spec", "selector", "matchLabels")
+				require.NotContains(t, objects.field(t, "DestinationRule/shipa-dashboard-rule-3", "spec"), "subsets")
+				objects.requireField(t, "VirtualService/dashboard-http", `[{destination: {host: dashboard-web-3, port: {number: 9090}}, weight: 30}, {destination: {host: dashboard-web-4, port: {number: 9091}}, weight: 70}]`, "spec", "http", 0, "route")
+			},
+		},
+		{
+			name: "nginx templates with a certificate issuer and wildcard cnames",
+			opts: []Option{
+				WithTemplates(templates.NginxDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+			},
+			application:       setSecureWildcardCnames(dashboard),
+			ingressController: ingressControllerWithIssuer,
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				objects.requireField(t, "Certificate/dashboard-cname--apps-theketch-io", `{name: letsencrypt, kind: Issuer}`, "spec", "issuerRef")
+				objects.requireField(t, "Certificate/dashboard-cname--apps-theketch-io", `["*.apps.theketch.io"]`, "spec", "dnsNames")
+				objects.requireEntries(t, "Certificate/dashboard-cname--apps-theketch-io", `{acme-solver: route53}`, "metadata", "labels")
+				objects.requireField(t, "Ingress/dashboard-0-https-ingress", `[{hosts: [theketch.io], secretName: dashboard-cname-theketch-io}, {hosts: ["*.apps.theketch.io"], secretName: dashboard-cname--apps-theketch-io}]`, "spec", "tls")
+			},
+		},
+		{
+			name: "traefik templates with a certificate issuer and wildcard cnames",
+			opts: []Option{
+				WithTemplates(templates.TraefikDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+			},
+			application:       setSecureWildcardCnames(dashboard),
+			ingressController: ingressControllerWithIssuer,
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				objects.requireField(t, "Certificate/dashboard-cname--apps-theketch-io", `{name: letsencrypt, kind: Issuer}`, "spec", "issuerRef")
+				objects.requireEntries(t, "IngressRoute/dashboard-https--apps-theketch-io", `{cert-manager.io/issuer: letsencrypt}`, "metadata", "annotations")
+				require.NotContains(t, objects.field(t, "IngressRoute/dashboard-https--apps-theketch-io", "metadata", "annotations"), "cert-manager.io/cluster-issuer")
+				objects.requireField(t, "IngressRoute/dashboard-https--apps-theketch-io", `HostRegexp("{subdomain:[a-z0-9-]+}.apps.theketch.io")`, "spec", "routes", 0, "match")
+				objects.requireField(t, "IngressRoute/dashboard-https--apps-theketch-io", `{secretName: dashboard-cname--apps-theketch-io}`, "spec", "tls")
+			},
+		},
+		{
+			name: "nginx templates with an ingress policy",
+			opts: []Option{
+				WithTemplates(templates.NginxDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+			},
+			application:       setIngressPolicy(dashboard),
+			ingressController: ingressController,
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				objects.requireEntries(t, "Ingress/dashboard-1-http-ingress", `{
+					nginx.ingress.kubernetes.io/limit-rps: "10", nginx.ingress.kubernetes.io/limit-burst-multiplier: "5",
+					nginx.ingress.kubernetes.io/proxy-body-size: "8388608", nginx.ingress.kubernetes.io/proxy-read-timeout: "60",
+					nginx.ingress.kubernetes.io/proxy-send-timeout: "60", nginx.ingress.kubernetes.io/proxy-connect-timeout: "2"}`, "metadata", "annotations")
+			},
+		},
+		{
+			name: "istio templates with an ingress policy",
+			opts: []Option{
+				WithTemplates(templates.IstioDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+			},
+			application:       setIngressPolicy(dashboard),
+			ingressController: ingressController,
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				objects.requireField(t, "DestinationRule/shipa-dashboard-rule-4", `{connectionPool: {tcp: {connectTimeout: 2s}}}`, "spec", "trafficPolicy")
+				objects.requireField(t, "VirtualService/dashboard-http", `60s`, "spec", "http", 0, "timeout")
+				objects.requireField(t, "EnvoyFilter/dashboard-ingress-policy", `{max_tokens: 50, tokens_per_fill: 10, fill_interval: 1s}`, "spec", "configPatches", 0, "patch", "value", "typed_config", "value", "token_bucket")
+				objects.requireField(t, "EnvoyFilter/dashboard-ingress-policy", `8388608`, "spec", "configPatches", 1, "patch", "value", "typed_config", "max_request_bytes")
+			},
+		},
+		{
+			name: "traefik templates with an ingress policy",
+			opts: []Option{
+				WithTemplates(templates.TraefikDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+			},
+			application:       setIngressPolicy(dashboard),
+			ingressController: ingressController,
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				objects.requireField(t, "Middleware/dashboard-rate-limit", `{rateLimit: {average: 10, burst: 50}}`, "spec")
+				objects.requireField(t, "Middleware/dashboard-buffering", `{buffering: {maxRequestBodyBytes: 8388608}}`, "spec")
+				objects.requireField(t, "ServersTransport/dashboard-transport", `{forwardingTimeouts: {dialTimeout: 2s, responseHeaderTimeout: 60s}}`, "spec")
+				objects.requireField(t, "IngressRoute/dashboard-https-theketch-io", `[{name: dashboard-rate-limit}, {name: dashboard-buffering}]`, "spec", "routes", 0, "middlewares")
+				objects.requireField(t, "IngressRoute/dashboard-https-theketch-io", `dashboard-transport`, "spec", "routes", 0, "services", 0, "serversTransport")
+			},
+		},
+		{
+			name: "nginx templates with a network policy",
+			opts: []Option{
+				WithTemplates(templates.NginxDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+			},
+			application: setNetworkPolicy(dashboard),
+			ingressController: ketchv1.IngressControllerSpec{
+				ClassName:       "ingress-class",
+				ServiceEndpoint: "10.10.10.10",
+				ClusterIssuer:   "letsencrypt-production",
+				IngressType:     ketchv1.NginxIngressControllerType,
+				NetworkPolicy:   true,
+			},
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				objects.requireField(t, "NetworkPolicy/dashboard-network-policy", `{
+					podSelector: {matchLabels: {theketch.io/app-name: dashboard}},
+					policyTypes: [Ingress],
+					ingress: [{from: [
+						{podSelector: {matchLabels: {theketch.io/app-name: dashboard}}},
+						{namespaceSelector: {matchLabels: {kubernetes.io/metadata.name: ingress-nginx}}},
+						{namespaceSelector: {matchLabels: {kubernetes.io/metadata.name: monitoring}}}]}]}`, "spec")
+			},
+		},
+		{
+			name: "nginx templates with a team and an owner",
+			opts: []Option{
+				WithTemplates(templates.NginxDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+			},
+			application: setOwnership(dashboard),
+			ingressController: ketchv1.IngressControllerSpec{
+				ClassName:       "ingress-class",
+				ServiceEndpoint: "10.10.10.10",
+				ClusterIssuer:   "letsencrypt-production",
+				IngressType:     ketchv1.NginxIngressControllerType,
+			},
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				ownership := `{theketch.io/owner: alice, theketch.io/team: payments}`
+				objects.requireEntries(t, "Service/app-dashboard", ownership, "metadata", "labels")
+				objects.requireEntries(t, "Deployment/dashboard-web-3", ownership, "metadata", "labels")
+				objects.requireEntries(t, "Deployment/dashboard-web-3", ownership, "spec", "template", "metadata", "labels")
+				objects.requireEntries(t, "Ingress/dashboard-0-https-ingress", ownership, "metadata", "labels")
+				objects.requireEntries(t, "Certificate/dashboard-cname-theketch-io", ownership, "spec", "secretTemplate", "labels")
+			},
+		},
+		{
+			name: "nginx templates with default env variables of the cluster",
+			opts: []Option{
+				WithTemplates(templates.NginxDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+			},
+			application: dashboard,
+			ingressController: ketchv1.IngressControllerSpec{
+				ClassName:       "ingress-class",
+				ServiceEndpoint: "10.10.10.10",
+				ClusterIssuer:   "letsencrypt-production",
+				IngressType:     ketchv1.NginxIngressControllerType,
+				DefaultEnvs:     []ketchv1.Env{{Name: "REGION", Value: "eu-west-1"}, {Name: "VAR", Value: "cluster default"}, {Name: "TEST_API_URL", Value: "cluster.example.com"}},
+			},
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				objects.requireField(t, "Deployment/dashboard-web-3", `[
+					{name: TEST_API_KEY, value: SECRET}, {name: TEST_API_URL, value: example.com},
+					{name: port, value: "9090"}, {name: PORT, value: "9090"}, {name: PORT_web, value: "9090"}, {name: VAR, value: VALUE},
+					{name: REGION, value: eu-west-1}]`, "spec", "template", "spec", "containers", 0, "env")
+			},
+		},
+		{
+			name: "nginx templates with env sets",
+			opts: []Option{
+				WithTemplates(templates.NginxDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+				WithEnvSets(envSets),
+			},
+			application: setEnvSets(dashboard),
+			ingressController: ketchv1.IngressControllerSpec{
+				ClassName:       "ingress-class",
+				ServiceEndpoint: "10.10.10.10",
+				ClusterIssuer:   "letsencrypt-production",
+				IngressType:     ketchv1.NginxIngressControllerType,
+				DefaultEnvs:     []ketchv1.Env{{Name: "REGION", Value: "eu-west-1"}, {Name: "CLUSTER", Value: "main"}},
+			},
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				objects.requireField(t, "Deployment/dashboard-web-3", `[
+					{name: TEST_API_KEY, value: SECRET}, {name: TEST_API_URL, value: example.com},
+					{name: port, value: "9090"}, {name: PORT, value: "9090"}, {name: PORT_web, value: "9090"}, {name: VAR, value: VALUE},
+					{name: LOG_LEVEL, value: debug}, {name: REGION, value: us-east-1}, {name: DATABASE_HOST, value: db.example.com}, {name: CLUSTER, value: main}]`, "spec", "template", "spec", "containers", 0, "env")
+			},
+		},
+		{
+			name: "nginx templates with service bindings",
+			opts: []Option{
+				WithTemplates(templates.NginxDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+				WithServiceBindingSecrets(bindingSecrets),
+			},
+			application:       setServiceBindings(dashboard),
+			ingressController: ingressController,
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				objects.requireField(t, "Deployment/dashboard-web-4", `[
+					{name: DATABASE_HOST, valueFrom: {secretKeyRef: {key: host, name: postgres}}},
+					{name: DATABASE_PASSWORD, valueFrom: {secretKeyRef: {key: password, name: postgres}}},
+					{name: port, value: "9091"}, {name: PORT, value: "9091"}, {name: PORT_web, value: "9091"}, {name: VAR, value: VALUE}]`, "spec", "template", "spec", "containers", 0, "env")
+				objects.requireField(t, "Deployment/dashboard-worker-4", `[
+					{name: DATABASE_HOST, valueFrom: {secretKeyRef: {key: host, name: postgres}}},
+					{name: DATABASE_PASSWORD, valueFrom: {secretKeyRef: {key: password, name: postgres}}},
+					{name: QUEUE_URL, valueFrom: {secretKeyRef: {key: url, name: rabbitmq}}},
+					{name: port, value: "9091"}, {name: PORT, value: "9091"}, {name: PORT_worker, value: "9091"}, {name: VAR, value: VALUE}]`, "spec", "template", "spec", "containers", 0, "env")
+				objects.requireField(t, "Deployment/dashboard-worker-4", `{theketch.io/service-bindings-checksum: e86d27708181c2008f89814aaea54d4d1094a0d18380b8e3df9b94fe8e9c6c21}`, "spec", "template", "metadata", "annotations")
+			},
+		},
+		{
+			name: "nginx templates with a service account",
+			opts: []Option{
+				WithTemplates(templates.NginxDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+			},
+			application: setKetchYamlServiceAccount(dashboard),
+			ingressController: ketchv1.IngressControllerSpec{
+				ClassName:       "ingress-class",
+				ServiceEndpoint: "10.10.10.10",
+				ClusterIssuer:   "letsencrypt-production",
+				IngressType:     ketchv1.NginxIngressControllerType,
+				ServiceAccountRoles: []ketchv1.KetchYamlRoleRef{
+					{Kind: "Role", Name: "app-reader"},
+					{Kind: "ClusterRole", Name: "system:aggregate-to-view"},
+				},
+			},
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				objects.requireField(t, "ServiceAccount/dashboard", `{eks.amazonaws.com/role-arn: "arn:aws:iam::111122223333:role/dashboard"}`, "metadata", "annotations")
+				objects.requireField(t, "RoleBinding/dashboard-role-app-reader", `{apiGroup: rbac.authorization.k8s.io, kind: Role, name: app-reader}`, "roleRef")
+				objects.requireField(t, "RoleBinding/dashboard-role-app-reader", `[{kind: ServiceAccount, name: dashboard}]`, "subjects")
+				objects.requireField(t, "RoleBinding/dashboard-clusterrole-system-aggregate-to-view", `{apiGroup: rbac.authorization.k8s.io, kind: ClusterRole, name: "system:aggregate-to-view"}`, "roleRef")
+				objects.requireField(t, "Deployment/dashboard-web-3", `dashboard`, "spec", "template", "spec", "serviceAccountName")
+			},
+		},
+		{
+			name: "nginx templates with a pod spec patch",
+			opts: []Option{
+				WithTemplates(templates.NginxDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+			},
+			application: setPodSpecPatch(dashboard),
+			ingressController: ketchv1.IngressControllerSpec{
+				ClassName:       "ingress-class",
+				ServiceEndpoint: "10.10.10.10",
+				ClusterIssuer:   "letsencrypt-production",
+				IngressType:     ketchv1.NginxIngressControllerType,
+			},
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				objects.requireField(t, "Deployment/dashboard-worker-4", `[{hostnames: [db.local], ip: 10.0.0.1}]`, "spec", "template", "spec", "hostAliases")
+				objects.requireField(t, "Deployment/dashboard-worker-4", `true`, "spec", "template", "spec", "containers", 0, "stdin")
+				objects.requireField(t, "Deployment/dashboard-worker-4", `[celery]`, "spec", "template", "spec", "containers", 0, "command")
+				require.NotContains(t, objects.field(t, "Deployment/dashboard-web-4", "spec", "template", "spec"), "hostAliases")
+			},
+		},
+		{
+			name: "nginx templates with a KEDA scaler",
+			opts: []Option{
+				WithTemplates(templates.NginxDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+			},
+			application:       setScalers(dashboard),
+			ingressController: ingressController,
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				require.NotContains(t, objects.field(t, "Deployment/dashboard-worker-4", "spec"), "replicas")
+				objects.requireField(t, "ScaledObject/dashboard-worker-4", `{
+					scaleTargetRef: {apiVersion: apps/v1, kind: Deployment, name: dashboard-worker-4},
+					minReplicaCount: 0, maxReplicaCount: 20, cooldownPeriod: 120,
+					triggers: [
+						{type: kafka, metadata: {bootstrapServers: "kafka:9092", consumerGroup: dashboard, lagThreshold: "50", topic: events}},
+						{type: aws-sqs-queue, metadata: {awsRegion: eu-west-1, queueLength: "5", queueURL: "https://sqs.eu-west-1.amazonaws.com/account_id/jobs"}, authenticationRef: {name: aws-credentials}}]}`, "spec")
+			},
+		},
+		{
+			name: "nginx templates with an autoscaled process",
+			opts: []Option{
+				WithTemplates(templates.NginxDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+			},
+			application:       setAutoscale(dashboard),
+			ingressController: ingressController,
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				require.NotContains(t, objects.field(t, "Deployment/dashboard-web-4", "spec"), "replicas")
+				objects.requireField(t, "HorizontalPodAutoscaler/dashboard-web-4", `{
+					scaleTargetRef: {apiVersion: apps/v1, kind: Deployment, name: dashboard-web-4},
+					minReplicas: 2, maxReplicas: 8,
+					metrics: [{type: Resource, resource: {name: cpu, targetAverageUtilization: 75}}]}`, "spec")
+			},
+		},
+		{
+			name: "nginx templates with deploy hooks",
+			opts: []Option{
+				WithTemplates(templates.NginxDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+			},
+			application:       setDeployHooks(dashboard),
+			ingressController: ingressController,
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				objects.requireEntries(t, "Job/dashboard-pre-deploy-4", `{helm.sh/hook: "pre-install,pre-upgrade", helm.sh/hook-weight: "-1"}`, "metadata", "annotations")
+				objects.requireField(t, "Job/dashboard-pre-deploy-4", `[sh, -c, ./bin/check-config]`, "spec", "template", "spec", "containers", 0, "command")
+				objects.requireEntries(t, "Job/dashboard-release-4", `{helm.sh/hook: "pre-install,pre-upgrade", helm.sh/hook-weight: "0"}`, "metadata", "annotations")
+				objects.requireField(t, "Job/dashboard-release-4", `[/bin/sh, -c, rake db:migrate]`, "spec", "template", "spec", "containers", 0, "command")
+				objects.requireEntries(t, "Job/dashboard-post-deploy-4", `{helm.sh/hook: "post-install,post-upgrade"}`, "metadata", "annotations")
+				objects.requireField(t, "Job/dashboard-post-deploy-4", `[sh, -c, ./bin/warm-cache && ./bin/notify]`, "spec", "template", "spec", "containers", 0, "command")
+				objects.requireMissing(t, "Job/dashboard-release-3")
+			},
+		},
+		{
+			name: "nginx templates with a termination grace period and a pre-stop sleep",
+			opts: []Option{
+				WithTemplates(templates.NginxDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+			},
+			application:       setTerminationGracePeriod(dashboard),
+			ingressController: ingressControllerWithPreStopSleep,
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				objects.requireField(t, "Deployment/dashboard-web-4", `60`, "spec", "template", "spec", "terminationGracePeriodSeconds")
+				require.NotContains(t, objects.field(t, "Deployment/dashboard-web-3", "spec", "template", "spec"), "terminationGracePeriodSeconds")
+				for _, name := range []string{"Deployment/dashboard-web-3", "Deployment/dashboard-web-4"} {
+					objects.requireField(t, name, `{preStop: {exec: {command: [sleep, "15"]}}}`, "spec", "template", "spec", "containers", 0, "lifecycle")
+				}
+				require.NotContains(t, objects.field(t, "Deployment/dashboard-worker-4", "spec", "template", "spec", "containers", 0), "lifecycle")
+			},
+		},
+		{
+			name: "nginx templates with priority and runtime classes",
+			opts: []Option{
+				WithTemplates(templates.NginxDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+			},
+			application:       setPodClasses(dashboard),
+			ingressController: ingressController,
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				objects.requireField(t, "Deployment/dashboard-web-4", `high-priority`, "spec", "template", "spec", "priorityClassName")
+				objects.requireField(t, "Deployment/dashboard-worker-4", `gvisor`, "spec", "template", "spec", "runtimeClassName")
+			},
+		},
+		{
+			name: "nginx templates with an image of a process",
+			opts: []Option{
+				WithTemplates(templates.NginxDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+			},
+			application:       setProcessImage(dashboard),
+			ingressController: ingressController,
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				objects.requireField(t, "Deployment/dashboard-worker-4", `shipasoftware/go-app-worker:v1`, "spec", "template", "spec", "containers", 0, "image")
+				objects.requireField(t, "Deployment/dashboard-web-4", `shipasoftware/go-app:v2`, "spec", "template", "spec", "containers", 0, "image")
+			},
+		},
+		{
+			name: "nginx templates with the restricted pod security profile",
+			opts: []Option{
+				WithTemplates(templates.NginxDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+			},
+			application:       setDeployHooks(dashboard),
+			ingressController: ingressControllerWithRestrictedProfile,
+			wantObjects: func(t *testing.T, objects renderedObjects) {
+				restricted := `{allowPrivilegeEscalation: false, capabilities: {drop: [ALL]}, runAsNonRoot: true, seccompProfile: {type: RuntimeDefault}}`
+				for _, name := range []string{"Deployment/dashboard-web-3", "Deployment/dashboard-worker-4", "Job/dashboard-pre-deploy-4", "Job/dashboard-post-deploy-4"} {
+					objects.requireField(t, name, restricted, "spec", "template", "spec", "containers", 0, "securityContext")
+				}
+			},
+		},
+		{
+			name: "istio templates without cluster issuer",
+			opts: []Option{
+				WithTemplates(templates.IstioDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+			},
+			application:       convertSecureEndpoints(dashboard),
+			ingressController: ingressControllerWithoutClusterIssuer,
+			wantYamlsFilename: "dashboard-istio",
+		},
+		{
+			name: "traefik templates with cluster issuer",
+			opts: []Option{
+				WithTemplates(templates.TraefikDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+			},
+			application:       dashboard,
+			ingressController: ingressController,
+			wantYamlsFilename: "dashboard-traefik-cluster-issuer",
+		},
+		{
+			name: "traefik templates without cluster issuer",
+			opts: []Option{
+				WithTemplates(templates.TraefikDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+			},
+			application:       convertSecureEndpoints(dashboard),
+			ingressController: ingressControllerWithoutClusterIssuer,
+			wantYamlsFilename: "dashboard-traefik",
+		},
+		{
+			name: "traefik templates with cluster issuer and resource requirements",
+			opts: []Option{
+				WithTemplates(templates.TraefikDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+			},
+			application:       dashboard,
+			ingressController: ingressController,
+			wantYamlsFilename: "dashboard-traefik-cluster-issuer",
+		},
+		{
+			name: "traefik templates with cluster issuer w/ alternate group",
+			opts: []Option{
+				WithTemplates(templates.TraefikDefaultTemplates),
+				WithExposedPorts(exportedPorts),
+			},
+			application:       dashboard,
+			ingressController: ingressController,
+			group:             "shipa.io",
+			wantYamlsFilename: "dashboard-traefik-cluster-issuer-shipa",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.group != "" {
+				original := ketchv1.Group
+				ketchv1.Group = tt.group
+				defer func() {
+					ketchv1.Group = original
+				}()
+			}
+			tt.application.Spec.Ingress.Controller = tt.ingressController
+			got, err := New(tt.application, tt.opts...)
+			if tt.wantErr {
+				require.NotNil(t, err, "New() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			require.Nil(t, err)
+
+			chartConfig := ChartConfig{
+				Version:            "0.0.1",
+				AppName:            tt.application.Name,
+				DeploymentVersions: deploymentVersions(*tt.application),
+				PodSpecPatches:     podSpecPatches(*tt.application),
+			}
+
+			client := HelmClient{cfg: &action.Configuration{KubeClient: &fake.PrintingKubeClient{}, Releases: storage.Init(driver.NewMemory())}, namespace: tt.application.Spec.Namespace, c: clientfake.NewClientBuilder().Build()}
+			release, err := client.UpdateChart(*got, chartConfig, func(install *action.Install) {
+				install.DryRun = true
+				install.ClientOnly = true
+				install.APIVersions = chartutil.VersionSet(tt.apiVersions)
+			})
+			require.Nil(t, err, "error = %v", err)
+
+			actualManifests := strings.TrimSpace(releaseManifests(release))
+			if tt.wantObjects != nil {
+				tt.wantObjects(t, newRenderedObjects(t, actualManifests))
+				return
+			}
+			expectedFilename := filepath.Join(chartDirectory, fmt.Sprintf("%s.yaml", tt.wantYamlsFilename))
+			actualFilename := filepath.Join(chartDirectory, fmt.Sprintf("%s.output.yaml", tt.wantYamlsFilename))
+			err = ioutil.WriteFile(actualFilename, []byte(actualManifests), 0755)
+			require.Nil(t, err)
+			expected, err := ioutil.ReadFile(expectedFilename)
+			require.Nil(t, err)
+			require.Equal(t, string(expected), actualManifests)
+		})
+	}
+}
+
+// renderedObjects are the objects of rendered manifests by "<kind>/<name>".
+type renderedObjects map[string]map[string]interface{}
+
+func newRenderedObjects(t *testing.T, manifests string) renderedObjects {
+	objects := renderedObjects{}
+	for _, doc := range strings.Split(manifests, "\n---\n") {
+		var obj map[string]interface{}
+		require.Nil(t, yaml.Unmarshal([]byte(doc+"\n"), &obj))
+		if len(obj) == 0 {
+			continue
+		}
+		metadata, _ := obj["metadata"].(map[string]interface{})
+		objects[fmt.Sprintf("%v/%v", obj["kind"], metadata["name"])] = obj
+	}
+	return objects
+}
+
+// field returns the field of an object at path, keys of maps and indexes of lists.
+func (o renderedObjects) field(t *testing.T, object string, path ...interface{}) interface{} {
+	t.Helper()
+	obj, ok := o[object]
+	require.True(t, ok, "%s isn't rendered", object)
+	var field interface{} = obj
+	for i, key := range path {
+		switch key := key.(type) {
+		case string:
+			m, ok := field.(map[string]interface{})
+			require.True(t, ok, "%s %v isn't a map", object, path[:i])
+			field, ok = m[key]
+			require.True(t, ok, "%s has no %v", object, path[:i+1])
+		case int:
+			list, ok := field.([]interface{})
+			require.True(t, ok, "%s %v isn't a list", object, path[:i])
+			require.Less(t, key, len(list), "%s has no %v", object, path[:i+1])
+			field = list[key]
+		}
+	}
+	return field
+}
+
+// requireField asserts the field of an object at path is the YAML want.
+func (o renderedObjects) requireField(t *testing.T, object string, want string, path ...interface{}) {
+	t.Helper()
+	got, err := yaml.Marshal(o.field(t, object, path...))
+	require.Nil(t, err)
+	require.YAMLEq(t, want, string(got), "%s %v", object, path)
+}
+
+// requireEntries asserts the map of an object at path, e.g. of annotations, has the entries of the YAML want.
+func (o renderedObjects) requireEntries(t *testing.T, object string, want string, path ...interface{}) {
+	t.Helper()
+	var entries map[string]interface{}
+	require.Nil(t, yaml.Unmarshal([]byte(want), &entries))
+	got, ok := o.field(t, object, path...).(map[string]interface{})
+	require.True(t, ok, "%s %v isn't a map", object, path)
+	for key, value := range entries {
+		require.Equal(t, value, got[key], "%s %v %s", object, path, key)
+	}
+}
+
+func (o renderedObjects) requireMissing(t *testing.T, objects ...string) {
+	t.Helper()
+	for _, object := range objects {
+		_, ok := o[object]
+		require.False(t, ok, "%s is rendered", object)
+	}
+}
+
+func TestAddVolumeClaims(t *testing.T) {
+	existing := []ketchv1.PersistentVolumeClaim{
+		{Name: "hello-data", AccessModes: []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce}, Storage: "1Gi"},
+	}
+	tests := []struct {
+		name    string
+		claims  []ketchv1.KetchYamlVolumeClaim
+		want    []ketchv1.PersistentVolumeClaim
+		wantErr string
+	}{
+		{
+			name:   "claim of a newer deployment replaces the claim with the same name",
+			claims: []ketchv1.KetchYamlVolumeClaim{{Name: "data", Size: "2Gi"}, {Name: "logs", Size: "500Mi", AccessModes: []v1.PersistentVolumeAccessMode{v1.ReadWriteMany}}},
+			want: []ketchv1.PersistentVolumeClaim{
+				{Name: "hello-data", AccessModes: []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce}, Storage: "2Gi"},
+				{Name: "hello-logs", AccessModes: []v1.PersistentVolumeAccessMode{v1.ReadWriteMany}, Storage: "500Mi"},
+			},
+		},
+		{
+			name:    "invalid size",
+			claims:  []ketchv1.KetchYamlVolumeClaim{{Name: "data", Size: "a lot"}},
+			wantErr: `invalid size of volume claim "hello-data": quantities must match the regular expression '^([+-]?[0-9.]+)([eEinumkKMGTP]*[-+]?[0-9]*)$'`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pvcs := append([]ketchv1.PersistentVolumeClaim{}, existing...)
+			got, err := addVolumeClaims(pvcs, "hello", tt.claims)
+			if len(tt.wantErr) > 0 {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestMirroredImage(t *testing.T) {
+	mirrors := []ketchv1.RegistryMirror{
+		{Registry: "docker.io", Endpoint: "cache.example.com/dockerhub/"},
+		{Registry: "quay.io", Endpoint: "quay-cache.example.com"},
+	}
+	tests := []struct {
+		name    string
+		image   string
+		mirrors []ketchv1.RegistryMirror
+		want    string
+	}{
+		{
+			name:    "no mirrors",
+			image:   "nginx:1.21",
+			mirrors: nil,
+			want:    "nginx:1.21",
+		},
+		{
+			name:    "short docker hub image",
+			image:   "nginx",
+			mirrors: mirrors,
+			want:    "cache.example.com/dockerhub/library/nginx:latest",
+		},
+		{
+			name:    "docker hub image with tag",
+			image:   "docker.io/shipasoftware/go-app:v1",
+			mirrors: mirrors,
+			want:    "cache.example.com/dockerhub/shipasoftware/go-app:v1",
+		},
+		{
+			name:    "digest is preserved",
+			image:   "quay.io/prometheus/node-exporter@sha256:0be9f0b0ae8a7c8b1b0e0c5c1b1f1e36e3d8e7c50b3a2b64f16c3b4bd6a3d6a9",
+			mirrors: mirrors,
+			want:    "quay-cache.example.com/prometheus/node-exporter@sha256:0be9f0b0ae8a7c8b1b0e0c5c1b1f1e36e3d8e7c50b3a2b64f16c3b4bd6a3d6a9",
+		},
+		{
+			name:    "registry without mirror",
+			image:   "gcr.io/project/app:v2",
+			mirrors: mirrors,
+			want:    "gcr.io/project/app:v2",
+		},
+		{
+			name:    "invalid reference is kept",
+			image:   "Invalid Image",
+			mirrors: mirrors,
+			want:    "Invalid Image",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, mirroredImage(tt.image, tt.mirrors))
+		})
+	}
+}
+
+func TestNewServiceAccount(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    ketchv1.KetchYamlServiceAccount
+		want    *serviceAccount
+		wantErr string
+	}{
+		{
+			name: "no roles",
+			want: &serviceAccount{Name: "hello"},
+		},
+		{
+			name: "annotations and roles",
+			spec: ketchv1.KetchYamlServiceAccount{
+				Annotations: map[string]string{"iam.gke.io/gcp-service-account": "hello@project.iam.gserviceaccount.com"},
+				Roles:       []ketchv1.KetchYamlRoleRef{{Kind: "ClusterRole", Name: "view"}},
+			},
+			want: &serviceAccount{
+				Name:        "hello",
+				Annotations: map[string]string{"iam.gke.io/gcp-service-account": "hello@project.iam.gserviceaccount.com"},
+				Roles:       []ketchv1.KetchYamlRoleRef{{Kind: "ClusterRole", Name: "view"}},
+			},
+		},
+		{
+			name:    "invalid kind",
+			spec:    ketchv1.KetchYamlServiceAccount{Roles: []ketchv1.KetchYamlRoleRef{{Kind: "Group", Name: "admins"}}},
+			wantErr: `serviceAccount.roles: invalid kind "Group" of admins, must be either Role or ClusterRole`,
+		},
+		{
+			name:    "missing name",
+			spec:    ketchv1.KetchYamlServiceAccount{Roles: []ketchv1.KetchYamlRoleRef{{Kind: "Role"}}},
+			wantErr: "serviceAccount.roles: name of Role is required",
+		},
+		{
+			name:    "role not allowed",
+			spec:    ketchv1.KetchYamlServiceAccount{Roles: []ketchv1.KetchYamlRoleRef{{Kind: "ClusterRole", Name: "cluster-admin"}}},
+			wantErr: `serviceAccount.roles: ClusterRole cluster-admin isn't allowed, roles are allowed with "ketch ingress set --service-account-role"`,
+		},
+	}
+	controller := ketchv1.IngressControllerSpec{ServiceAccountRoles: []ketchv1.KetchYamlRoleRef{{Kind: "ClusterRole", Name: "view"}}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newServiceAccount("hello", tt.spec, controller)
+			if len(tt.wantErr) > 0 {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestApplicationChart_DeployHooksVersion(t *testing.T) {
+	hooks := &ketchv1.KetchYamlData{Hooks: &ketchv1.KetchYamlHooks{
+		PreDeploy:  []string{"./check-config"},
+		Release:    []string{"rake db:migrate", "rake db:seed"},
+		PostDeploy: []string{"./notify"},
+	}}
+	tests := []struct {
+		name        string
+		deployment  ketchv1.AppDeploymentSpec
+		recorded    bool
+		wantVersion ketchv1.DeploymentVersion
+		wantHooks   []deployHook
+	}{
+		{
+			name:       "no hooks",
+			deployment: ketchv1.AppDeploymentSpec{Version: 2},
+		},
+		{
+			name:        "release process of the procfile",
+			deployment:  ketchv1.AppDeploymentSpec{Version: 2, ReleaseCmd: []string{"release"}, KetchYaml: hooks},
+			wantVersion: 2,
+			wantHooks: []deployHook{
+				{Process: "pre-deploy", Cmd: []string{"sh", "-c", "./check-config"}, Events: "pre-install,pre-upgrade", Weight: -1},
+				{Process: "release", Cmd: []string{"release"}, Events: "pre-install,pre-upgrade"},
+				{Process: "post-deploy", Cmd: []string{"sh", "-c", "./notify"}, Events: "post-install,post-upgrade"},
+			},
+		},
+		{
+			name:        "release hooks of ketch.yaml",
+			deployment:  ketchv1.AppDeploymentSpec{Version: 2, KetchYaml: &ketchv1.KetchYamlData{Hooks: &ketchv1.KetchYamlHooks{Release: hooks.Hooks.Release}}},
+			wantVersion: 2,
+			wantHooks: []deployHook{
+				{Process: "release", Cmd: []string{"sh", "-c", "rake db:migrate && rake db:seed"}, Events: "pre-install,pre-upgrade"},
+			},
+		},
+		{
+			name:       "deployment already rolled out",
+			deployment: ketchv1.AppDeploymentSpec{Version: 2, ReleaseCmd: []string{"release"}, KetchYaml: hooks},
+			recorded:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.deployment.Processes = []ketchv1.ProcessSpec{{Name: "web", Cmd: []string{"web"}}}
+			app := &ketchv1.App{
+				ObjectMeta: metav1.ObjectMeta{Name: "go-app"},
+				Spec:       ketchv1.AppSpec{Deployments: []ketchv1.AppDeploymentSpec{tt.deployment}},
+			}
+			if tt.recorded {
+				app.Status.DeploymentHistory = []ketchv1.DeploymentRecord{{AppDeploymentSpec: tt.deployment}}
+			}
+			got, err := New(app, WithExposedPorts(app.ExposedPorts()))
+			require.Nil(t, err)
+			version, ok := got.DeployHooksVersion()
+			require.Equal(t, tt.wantHooks != nil, ok)
+			require.Equal(t, tt.wantVersion, version)
+			require.Equal(t, tt.wantHooks, got.values.App.Deployments[0].Hooks)
+		})
+	}
+}
+
+func TestRenderApplication(t *testing.T) {
+	app := &ketchv1.App{
+		ObjectMeta: metav1.ObjectMeta{Name: "hello"},
+		Spec: ketchv1.AppSpec{
+			Namespace:        "default",
+			DeploymentsCount: 1,
+			Deployments: []ketchv1.AppDeploymentSpec{
+				{
+					Image:           "nginx:latest",
+					Version:         1,
+					Processes:       []ketchv1.ProcessSpec{{Name: "web", Cmd: []string{"nginx"}}},
+					ExposedPorts:    []ketchv1.ExposedPort{{Port: 80, Protocol: "TCP"}},
+					RoutingSettings: ketchv1.RoutingSettings{Weight: 100},
+				},
+			},
+			Ingress: ketchv1.IngressSpec{
+				Controller: ketchv1.IngressControllerSpec{IngressType: ketchv1.NginxIngressControllerType, ClassName: "nginx", ServiceEndpoint: "10.10.10.10"},
+			},
+		},
+	}
+	manifests, err := RenderApplication(app, templates.NginxDefaultTemplates)
+	require.Nil(t, err)
+	require.Contains(t, manifests, "kind: Deployment")
+	require.Contains(t, manifests, "name: hello-web-1")
+	require.Contains(t, manifests, "image: nginx:latest")
+}
+
+func TestApplicationChart_WriteToDirectory(t *testing.T) {
+	appChrt := ApplicationChart{
+		values:    values{App: &app{Name: "hello"}},
+		templates: map[string]string{"deployment.yaml": "kind: Deployment"},
+	}
+	dir := t.TempDir()
+	stale := filepath.Join(dir, "templates", "removed.yaml")
+	require.Nil(t, os.MkdirAll(filepath.Dir(stale), 0755))
+	require.Nil(t, ioutil.WriteFile(stale, []byte("kind: Service"), 0644))
+
+	err := appChrt.WriteToDirectory(dir, ChartConfig{Version: "v0.0.1", AppName: "hello", AppVersion: "v1"})
+	require.Nil(t, err)
+	for _, name := range []string{"Chart.yaml", "values.yaml", "templates/deployment.yaml"} {
+		_, err := os.Stat(filepath.Join(dir, name))
+		require.Nil(t, err, name)
 	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			if tt.group != "" {
-				original := ketchv1.Group
-				ketchv1.Group = tt.group
-				defer func() {
-					ketchv1.Group = original
-				}()
-			}
-			tt.application.Spec.Ingress.Controller = tt.ingressController
-			got, err := New(tt.application, tt.opts...)
-			if tt.wantErr {
-				require.NotNil(t, err, "New() error = %v, wantErr %v", err, tt.wantErr)
-				return
-			}
-			require.Nil(t, err)
-
-			expectedFilename := filepath.Join(chartDirectory, fmt.Sprintf("%s.yaml", tt.wantYamlsFilename))
-			actualFilename := filepath.Join(chartDirectory, fmt.Sprintf("%s.output.yaml", tt.wantYamlsFilename))
-
-			chartConfig := ChartConfig{
-				Version:            "0.0.1",
-				AppName:            tt.application.Name,
-				DeploymentVersions: deploymentVersions(*tt.application),
-			}
-
-			client := HelmClient{cfg: &action.Configuration{KubeClient: &fake.PrintingKubeClient{}, Releases: storage.Init(driver.NewMemory())}, namespace: tt.application.Spec.Namespace, c: clientfake.NewClientBuilder().Build()}
-			release, err := client.UpdateChart(*got, chartConfig, func(install *action.Install) {
-				install.DryRun = true
-				install.ClientOnly = true
-			})
-			require.Nil(t, err, "error = %v", err)
-
-			actualManifests := strings.TrimSpace(release.Manifest)
-			err = ioutil.WriteFile(actualFilename, []byte(actualManifests), 0755)
-			require.Nil(t, err)
-			expected, err := ioutil.ReadFile(expectedFilename)
-			require.Nil(t, err)
-			require.Equal(t, string(expected), actualManifests)
-		})
-	}
+	_, err = os.Stat(stale)
+	require.True(t, os.IsNotExist(err))
 }
@@ -0,0 +1,425 @@
+package chart
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/releaseutil"
+	helmTime "helm.sh/helm/v3/pkg/time"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// HelmEngine installs charts of apps and jobs as helm releases, it's the default engine.
+	HelmEngine = "helm"
+	// ServerSideApplyEngine installs charts of apps and jobs with server-side apply without helm releases.
+	ServerSideApplyEngine = "server-side-apply"
+
+	// FieldManager is the field manager owning fields of objects applied by ApplyClient.
+	FieldManager = "ketch"
+	// inventoryKey is the key of the inventory configmap listing objects applied for an app or a job.
+	inventoryKey = "objects"
+)
+
+// hookPollInterval is the time between two checks of a Job of a deploy hook.
+var hookPollInterval = 2 * time.Second
+
+// optionalAPIVersions are APIs templates check with .Capabilities.APIVersions.Has,
+// charts are rendered without a connection to the cluster so ApplyClient looks them up.
+var optionalAPIVersions = []schema.GroupVersionKind{
+	{Group: "monitoring.coreos.com", Version: "v1", Kind: "PodMonitor"},
+}
+
+// ValidateEngine returns an error if the engine isn't supported.
+func ValidateEngine(engine string) error {
+	switch engine {
+	case "", HelmEngine, ServerSideApplyEngine:
+		return nil
+	}
+	return fmt.Errorf("unsupported engine %q, use %q or %q", engine, HelmEngine, ServerSideApplyEngine)
+}
+
+// ApplyClient installs charts of apps and jobs with server-side apply instead of helm releases.
+// It has no release secrets, objects of a chart are listed in an inventory configmap,
+// so objects the chart doesn't render anymore are removed on update.
+type ApplyClient struct {
+	namespace string
+	c         client.Client
+	log       logr.Logger
+}
+
+// NewApplyClient returns an ApplyClient installing charts to the namespace.
+func NewApplyClient(namespace string, c client.Client, log logr.Logger) *ApplyClient {
+	return &ApplyClient{namespace: namespace, c: c, log: log.WithValues("apply-client", namespace)}
+}
+
+// objectRef identifies an object listed in an inventory configmap.
+type objectRef struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+}
+
+func newObjectRef(obj *unstructured.Unstructured) objectRef {
+	return objectRef{APIVersion: obj.GetAPIVersion(), Kind: obj.GetKind(), Namespace: obj.GetNamespace(), Name: obj.GetName()}
+}
+
+func (r objectRef) object() *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(r.APIVersion)
+	obj.SetKind(r.Kind)
+	obj.SetNamespace(r.Namespace)
+	obj.SetName(r.Name)
+	return obj
+}
+
+// UpdateChart renders the chart and applies its objects, deploy hooks run before and after the objects are applied
+// the way helm runs them. The returned release has the manifests and the hooks of the chart, it isn't stored.
+func (c ApplyClient) UpdateChart(tv TemplateValuer, config ChartConfig, opts ...InstallOption) (*release.Release, error) {
+	ctx := context.Background()
+	start := time.Now()
+	rel, err := c.updateChart(ctx, tv, config, opts...)
+	observeHelmOperation("apply", start, err)
+	return rel, err
+}
+
+func (c ApplyClient) updateChart(ctx context.Context, tv TemplateValuer, config ChartConfig, opts ...InstallOption) (*release.Release, error) {
+	appName := tv.GetName()
+	rel, err := c.render(tv, config, opts...)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := c.decode(rel.Manifest)
+	if err != nil {
+		return nil, err
+	}
+	inventory, err := c.inventory(ctx, appName)
+	if err != nil {
+		return nil, err
+	}
+	installed := inventory != nil
+
+	preEvent, postEvent := release.HookPreUpgrade, release.HookPostUpgrade
+	preFailed, postFailed := helmPreUpgradeFailed, helmPostUpgradeFailed
+	if !installed {
+		preEvent, postEvent = release.HookPreInstall, release.HookPostInstall
+		preFailed, postFailed = helmPreInstallFailed, helmPostInstallFailed
+	}
+	if err := c.runHooks(ctx, rel, preEvent); err != nil {
+		return rel, fmt.Errorf("%s: %w", preFailed, err)
+	}
+
+	applied := make([]objectRef, 0, len(objects))
+	for _, obj := range objects {
+		if err := c.apply(ctx, obj); err != nil {
+			return rel, err
+		}
+		applied = append(applied, newObjectRef(obj))
+	}
+	if err := c.prune(ctx, inventory, applied); err != nil {
+		return rel, err
+	}
+	if err := c.saveInventory(ctx, appName, applied); err != nil {
+		return rel, err
+	}
+	rel.SetStatus(release.StatusDeployed, "Apply complete")
+
+	if err := c.runHooks(ctx, rel, postEvent); err != nil {
+		return rel, fmt.Errorf("%s: %w", postFailed, err)
+	}
+	return rel, nil
+}
+
+// render renders the chart's manifests and hooks with the post-render patches the helm client applies.
+func (c ApplyClient) render(tv TemplateValuer, config ChartConfig, opts ...InstallOption) (*release.Release, error) {
+	chrt, vals, err := loadChart(tv, config)
+	if err != nil {
+		return nil, err
+	}
+	install := action.NewInstall(&action.Configuration{Log: func(string, ...interface{}) {}})
+	install.ReleaseName = tv.GetName()
+	install.Namespace = c.namespace
+	install.PostRenderer = &postRender{
+		log:                c.log,
+		cli:                c.c,
+		namespace:          c.namespace,
+		appName:            config.AppName,
+		deploymentVersions: config.DeploymentVersions,
+		podSpecPatches:     config.PodSpecPatches,
+	}
+	for _, opt := range opts {
+		opt(install)
+	}
+	install.DryRun = true
+	install.ClientOnly = true
+	install.APIVersions = c.optionalAPIVersions()
+	return install.Run(chrt, vals)
+}
+
+func (c ApplyClient) optionalAPIVersions() []string {
+	var versions []string
+	for _, gvk := range optionalAPIVersions {
+		if _, err := c.c.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version); err == nil {
+			versions = append(versions, gvk.GroupVersion().String(), fmt.Sprintf("%s/%s", gvk.GroupVersion(), gvk.Kind))
+		}
+	}
+	return versions
+}
+
+// decode returns objects of the manifests in the order helm installs them,
+// namespaced objects without a namespace get the client's namespace.
+func (c ApplyClient) decode(manifests string) ([]*unstructured.Unstructured, error) {
+	var objects []*unstructured.Unstructured
+	for _, manifest := range releaseutil.SplitManifests(manifests) {
+		var content map[string]interface{}
+		if err := yaml.Unmarshal([]byte(manifest), &content); err != nil {
+			return nil, fmt.Errorf("failed to decode manifest: %w", err)
+		}
+		if len(content) == 0 {
+			continue
+		}
+		obj := &unstructured.Unstructured{Object: content}
+		gvk := obj.GroupVersionKind()
+		mapping, err := c.c.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find resource of %s %s: %w", gvk.Kind, obj.GetName(), err)
+		}
+		if mapping.Scope.Name() == meta.RESTScopeNameNamespace && obj.GetNamespace() == "" {
+			obj.SetNamespace(c.namespace)
+		}
+		objects = append(objects, obj)
+	}
+	sortByInstallOrder(objects)
+	return objects, nil
+}
+
+// sortByInstallOrder sorts objects by kind in the order helm installs them, objects of the same kind keep their order.
+func sortByInstallOrder(objects []*unstructured.Unstructured) {
+	order := make(map[string]int, len(releaseutil.InstallOrder))
+	for i, kind := range releaseutil.InstallOrder {
+		order[kind] = i
+	}
+	rank := func(kind string) int {
+		if i, ok := order[kind]; ok {
+			return i
+		}
+		return len(order)
+	}
+	sort.SliceStable(objects, func(i, j int) bool { return rank(objects[i].GetKind()) < rank(objects[j].GetKind()) })
+}
+
+func (c ApplyClient) apply(ctx context.Context, obj *unstructured.Unstructured) error {
+	if err := c.c.Patch(ctx, obj, client.Apply, client.FieldOwner(FieldManager), client.ForceOwnership); err != nil {
+		return fmt.Errorf("failed to apply %s %s: %w", obj.GetKind(), obj.GetName(), err)
+	}
+	return nil
+}
+
+// prune removes objects of the inventory that aren't applied anymore.
+func (c ApplyClient) prune(ctx context.Context, inventory []objectRef, applied []objectRef) error {
+	keep := make(map[objectRef]bool, len(applied))
+	for _, ref := range applied {
+		keep[ref] = true
+	}
+	for _, ref := range inventory {
+		if keep[ref] {
+			continue
+		}
+		if err := c.delete(ctx, ref.object()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c ApplyClient) delete(ctx context.Context, obj client.Object) error {
+	err := c.c.Delete(ctx, obj, client.PropagationPolicy(metav1.DeletePropagationBackground))
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete %s %s: %w", obj.GetObjectKind().GroupVersionKind().Kind, obj.GetName(), err)
+	}
+	return nil
+}
+
+func inventoryName(appName string) string {
+	return fmt.Sprintf("%s-ketch-inventory", appName)
+}
+
+// inventory returns objects applied for the app, nil if the app's chart isn't installed.
+func (c ApplyClient) inventory(ctx context.Context, appName string) ([]objectRef, error) {
+	var cm v1.ConfigMap
+	if err := c.c.Get(ctx, types.NamespacedName{Namespace: c.namespace, Name: inventoryName(appName)}, &cm); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	refs := []objectRef{}
+	if err := json.Unmarshal([]byte(cm.Data[inventoryKey]), &refs); err != nil {
+		return nil, fmt.Errorf("failed to read inventory %s: %w", cm.Name, err)
+	}
+	return refs, nil
+}
+
+func (c ApplyClient) saveInventory(ctx context.Context, appName string, refs []objectRef) error {
+	data, err := json.Marshal(refs)
+	if err != nil {
+		return err
+	}
+	cm := &v1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: c.namespace, Name: inventoryName(appName)},
+		Data:       map[string]string{inventoryKey: string(data)},
+	}
+	if err := c.c.Patch(ctx, cm, client.Apply, client.FieldOwner(FieldManager), client.ForceOwnership); err != nil {
+		return fmt.Errorf("failed to save inventory %s: %w", cm.Name, err)
+	}
+	return nil
+}
+
+// runHooks runs hooks of the event ordered by weight, it waits for Jobs to complete.
+func (c ApplyClient) runHooks(ctx context.Context, rel *release.Release, event release.HookEvent) error {
+	var hooks []*release.Hook
+	for _, hook := range rel.Hooks {
+		for _, e := range hook.Events {
+			if e == event {
+				hooks = append(hooks, hook)
+				break
+			}
+		}
+	}
+	sort.SliceStable(hooks, func(i, j int) bool {
+		if hooks[i].Weight != hooks[j].Weight {
+			return hooks[i].Weight < hooks[j].Weight
+		}
+		return hooks[i].Name < hooks[j].Name
+	})
+	for _, hook := range hooks {
+		if err := c.runHook(ctx, hook); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c ApplyClient) runHook(ctx context.Context, hook *release.Hook) error {
+	objects, err := c.decode(hook.Manifest)
+	if err != nil {
+		return err
+	}
+	hook.LastRun = release.HookExecution{StartedAt: helmTime.Now(), Phase: release.HookPhaseRunning}
+	for _, obj := range objects {
+		if hasDeletePolicy(hook, release.HookBeforeHookCreation) {
+			if err := c.delete(ctx, obj.DeepCopy()); err != nil {
+				return err
+			}
+		}
+		if err := c.apply(ctx, obj); err != nil {
+			hook.LastRun.Phase = release.HookPhaseFailed
+			return err
+		}
+	}
+	for _, obj := range objects {
+		if obj.GetKind() != "Job" {
+			continue
+		}
+		if err := c.waitForJob(ctx, obj.GetNamespace(), obj.GetName()); err != nil {
+			hook.LastRun.CompletedAt = helmTime.Now()
+			hook.LastRun.Phase = release.HookPhaseFailed
+			return fmt.Errorf("job %s failed: %w", obj.GetName(), err)
+		}
+	}
+	hook.LastRun.CompletedAt = helmTime.Now()
+	hook.LastRun.Phase = release.HookPhaseSucceeded
+	if hasDeletePolicy(hook, release.HookSucceeded) {
+		for _, obj := range objects {
+			if err := c.delete(ctx, obj); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func hasDeletePolicy(hook *release.Hook, policy release.HookDeletePolicy) bool {
+	for _, p := range hook.DeletePolicies {
+		if p == policy {
+			return true
+		}
+	}
+	return false
+}
+
+// waitForJob blocks until the Job completes or fails, the way helm waits for a Job of a hook.
+func (c ApplyClient) waitForJob(ctx context.Context, namespace, name string) error {
+	ctx, cancel := context.WithTimeout(ctx, deployHookTimeout)
+	defer cancel()
+	for {
+		var job batchv1.Job
+		if err := c.c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, &job); err != nil {
+			return err
+		}
+		for _, condition := range job.Status.Conditions {
+			if condition.Status != v1.ConditionTrue {
+				continue
+			}
+			switch condition.Type {
+			case batchv1.JobComplete:
+				return nil
+			case batchv1.JobFailed:
+				return fmt.Errorf("%s", strings.ToLower(condition.Message))
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(hookPollInterval):
+		}
+	}
+}
+
+// DeleteChart removes objects applied for the app and its inventory.
+// It doesn't return an error if the app's chart isn't installed.
+func (c ApplyClient) DeleteChart(appName string) error {
+	ctx := context.Background()
+	inventory, err := c.inventory(ctx, appName)
+	if err != nil || inventory == nil {
+		return err
+	}
+	for i := len(inventory) - 1; i >= 0; i-- {
+		if err := c.delete(ctx, inventory[i].object()); err != nil {
+			return err
+		}
+	}
+	return c.delete(ctx, &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: c.namespace, Name: inventoryName(appName)}})
+}
+
+// ReleaseRevision returns the resource version of the app's inventory, an empty string if the app's chart isn't installed.
+func (c ApplyClient) ReleaseRevision(appName string) (string, error) {
+	var cm v1.ConfigMap
+	if err := c.c.Get(context.Background(), types.NamespacedName{Namespace: c.namespace, Name: inventoryName(appName)}, &cm); err != nil {
+		return "", client.IgnoreNotFound(err)
+	}
+	return cm.ResourceVersion, nil
+}
+
+// RepairChart does nothing, server-side apply has no releases to unlock.
+func (c ApplyClient) RepairChart(appName string) error {
+	return nil
+}
@@ -0,0 +1,197 @@
+package chart
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/release"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// applyRecorder emulates server-side apply the fake client doesn't support, applied objects are created or replaced.
+type applyRecorder struct {
+	client.Client
+	applied  []string
+	failJobs bool
+}
+
+func (c *applyRecorder) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if patch.Type() != types.ApplyPatchType {
+		return c.Client.Patch(ctx, obj, patch, opts...)
+	}
+	c.applied = append(c.applied, fmt.Sprintf("%s/%s", obj.GetObjectKind().GroupVersionKind().Kind, obj.GetName()))
+	if u, ok := obj.(*unstructured.Unstructured); ok && u.GetKind() == "Job" {
+		condition := map[string]interface{}{"type": string(batchv1.JobComplete), "status": "True"}
+		if c.failJobs {
+			condition = map[string]interface{}{"type": string(batchv1.JobFailed), "status": "True", "message": "Job has reached the specified backoff limit"}
+		}
+		unstructured.SetNestedSlice(u.Object, []interface{}{condition}, "status", "conditions")
+	}
+	existing := obj.DeepCopyObject().(client.Object)
+	err := c.Client.Get(ctx, client.ObjectKeyFromObject(obj), existing)
+	if k8serrors.IsNotFound(err) {
+		return c.Client.Create(ctx, obj)
+	}
+	if err != nil {
+		return err
+	}
+	obj.SetResourceVersion(existing.GetResourceVersion())
+	return c.Client.Update(ctx, obj)
+}
+
+type testChart struct {
+	values map[string]interface{}
+}
+
+func (c testChart) GetName() string {
+	return "hello"
+}
+
+func (c testChart) GetValues() interface{} {
+	return c.values
+}
+
+func (c testChart) GetTemplates() map[string]string {
+	return map[string]string{
+		"configmap.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: hello-config
+data:
+  version: "{{ .Values.version }}"
+`,
+		"service.yaml": `{{- if .Values.service }}
+apiVersion: v1
+kind: Service
+metadata:
+  name: hello
+spec:
+  ports:
+  - port: 80
+{{- end }}
+`,
+		"hook.yaml": `apiVersion: batch/v1
+kind: Job
+metadata:
+  name: hello-release-{{ .Values.version }}
+  annotations:
+    helm.sh/hook: pre-install,pre-upgrade
+    helm.sh/hook-delete-policy: before-hook-creation,hook-succeeded
+spec:
+  template:
+    spec:
+      restartPolicy: Never
+      containers:
+      - name: release
+        image: hello
+`,
+	}
+}
+
+func newApplyRecorder() *applyRecorder {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	for _, gvk := range []schema.GroupVersionKind{
+		v1.SchemeGroupVersion.WithKind("ConfigMap"),
+		v1.SchemeGroupVersion.WithKind("Service"),
+		batchv1.SchemeGroupVersion.WithKind("Job"),
+	} {
+		mapper.Add(gvk, meta.RESTScopeNamespace)
+	}
+	return &applyRecorder{Client: fake.NewClientBuilder().WithRESTMapper(mapper).Build()}
+}
+
+func TestApplyClient_UpdateChart(t *testing.T) {
+	recorder := newApplyRecorder()
+	c := NewApplyClient("gke", recorder, logr.Discard())
+	config := ChartConfig{Version: "v0.0.1", AppName: "hello", AppVersion: "v1"}
+
+	rel, err := c.UpdateChart(testChart{values: map[string]interface{}{"version": 1, "service": true}}, config)
+	require.Nil(t, err)
+	require.Equal(t, release.StatusDeployed, rel.Info.Status)
+	require.Equal(t, []string{"Job/hello-release-1", "ConfigMap/hello-config", "Service/hello", "ConfigMap/hello-ketch-inventory"}, recorder.applied)
+	require.Equal(t, release.HookPhaseSucceeded, rel.Hooks[0].LastRun.Phase)
+
+	ctx := context.Background()
+	var service v1.Service
+	require.Nil(t, recorder.Get(ctx, types.NamespacedName{Namespace: "gke", Name: "hello"}, &service))
+	var job batchv1.Job
+	err = recorder.Get(ctx, types.NamespacedName{Namespace: "gke", Name: "hello-release-1"}, &job)
+	require.True(t, k8serrors.IsNotFound(err))
+
+	recorder.applied = nil
+	_, err = c.UpdateChart(testChart{values: map[string]interface{}{"version": 2}}, config)
+	require.Nil(t, err)
+	require.Equal(t, []string{"Job/hello-release-2", "ConfigMap/hello-config", "ConfigMap/hello-ketch-inventory"}, recorder.applied)
+	err = recorder.Get(ctx, types.NamespacedName{Namespace: "gke", Name: "hello"}, &service)
+	require.True(t, k8serrors.IsNotFound(err))
+	var cm v1.ConfigMap
+	require.Nil(t, recorder.Get(ctx, types.NamespacedName{Namespace: "gke", Name: "hello-ketch-inventory"}, &cm))
+	require.Equal(t, `[{"apiVersion":"v1","kind":"ConfigMap","namespace":"gke","name":"hello-config"}]`, cm.Data[inventoryKey])
+
+	recorder.applied = nil
+	recorder.failJobs = true
+	rel, err = c.UpdateChart(testChart{values: map[string]interface{}{"version": 3}}, config)
+	require.True(t, IsPreDeployHookFailed(err))
+	require.EqualError(t, err, "pre-upgrade hooks failed: job hello-release-3 failed: job has reached the specified backoff limit")
+	require.Equal(t, release.HookPhaseFailed, rel.Hooks[0].LastRun.Phase)
+	require.Equal(t, []string{"Job/hello-release-3"}, recorder.applied)
+	require.Nil(t, recorder.Get(ctx, types.NamespacedName{Namespace: "gke", Name: "hello-config"}, &cm))
+	require.Equal(t, "2", cm.Data["version"])
+}
+
+func TestApplyClient_ReleaseRevision(t *testing.T) {
+	recorder := newApplyRecorder()
+	c := NewApplyClient("gke", recorder, logr.Discard())
+	revision, err := c.ReleaseRevision("hello")
+	require.Nil(t, err)
+	require.Equal(t, "", revision)
+
+	_, err = c.UpdateChart(testChart{values: map[string]interface{}{"version": 1}}, ChartConfig{Version: "v0.0.1", AppName: "hello"})
+	require.Nil(t, err)
+	revision, err = c.ReleaseRevision("hello")
+	require.Nil(t, err)
+	require.NotEqual(t, "", revision)
+
+	require.Nil(t, c.DeleteChart("hello"))
+	revision, err = c.ReleaseRevision("hello")
+	require.Nil(t, err)
+	require.Equal(t, "", revision)
+}
+
+func TestApplyClient_DeleteChart(t *testing.T) {
+	recorder := newApplyRecorder()
+	c := NewApplyClient("gke", recorder, logr.Discard())
+	require.Nil(t, c.DeleteChart("hello"))
+
+	_, err := c.UpdateChart(testChart{values: map[string]interface{}{"version": 1, "service": true}}, ChartConfig{Version: "v0.0.1", AppName: "hello"})
+	require.Nil(t, err)
+	require.Nil(t, c.DeleteChart("hello"))
+
+	ctx := context.Background()
+	for _, obj := range []client.Object{
+		&v1.Service{}, &v1.ConfigMap{},
+	} {
+		for _, name := range []string{"hello", "hello-config", "hello-ketch-inventory"} {
+			err := recorder.Get(ctx, types.NamespacedName{Namespace: "gke", Name: name}, obj)
+			require.True(t, k8serrors.IsNotFound(err), name)
+		}
+	}
+}
+
+func TestValidateEngine(t *testing.T) {
+	require.Nil(t, ValidateEngine(""))
+	require.Nil(t, ValidateEngine(HelmEngine))
+	require.Nil(t, ValidateEngine(ServerSideApplyEngine))
+	require.EqualError(t, ValidateEngine("kapp"), `unsupported engine "kapp", use "helm" or "server-side-apply"`)
+}
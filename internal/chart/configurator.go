@@ -57,26 +57,75 @@ type Probes struct {
 	StartupProbe *apiv1.Probe
 }
 
-func (c Configurator) Probes() (Probes, error) {
-	var result Probes
-	hc := c.data.Healthcheck
+// Probes returns probes of the process, probes of the process' healthcheck override probes of the healthcheck of ketch.yaml.
+func (c Configurator) Probes(process string) (Probes, error) {
+	result := healthcheckProbes(c.data.Healthcheck)
+	processProbes, err := c.ProcessProbes(process)
+	if err != nil {
+		return Probes{}, err
+	}
+	if processProbes.Readiness != nil {
+		result.Readiness = processProbes.Readiness
+	}
+	if processProbes.Liveness != nil {
+		result.Liveness = processProbes.Liveness
+	}
+	if processProbes.StartupProbe != nil {
+		result.StartupProbe = processProbes.StartupProbe
+	}
+	return result, nil
+}
+
+// ProcessProbes returns probes of the process' healthcheck only.
+func (c Configurator) ProcessProbes(process string) (Probes, error) {
+	config := c.data.ProcessConfig(process)
+	if config == nil {
+		return Probes{}, nil
+	}
+	return healthcheckProbes(config.Healthcheck), nil
+}
+
+func healthcheckProbes(hc *ketchv1.KetchYamlHealthcheck) Probes {
 	if hc == nil {
-		return result, nil
+		return Probes{}
+	}
+	return Probes{
+		Readiness:    hc.ReadinessProbe,
+		Liveness:     hc.LivenessProbe,
+		StartupProbe: hc.StartupProbe,
 	}
+}
 
-	if hc.ReadinessProbe != nil {
-		result.Readiness = hc.ReadinessProbe
+// ReleaseCmd returns the command running the release hooks of ketch.yaml.
+func (c Configurator) ReleaseCmd() []string {
+	if c.data.Hooks == nil {
+		return nil
 	}
+	return hookCmd(c.data.Hooks.Release)
+}
 
-	if hc.LivenessProbe != nil {
-		result.Liveness = hc.LivenessProbe
+// PreDeployCmd returns the command running the pre-deploy hooks of ketch.yaml.
+func (c Configurator) PreDeployCmd() []string {
+	if c.data.Hooks == nil {
+		return nil
 	}
+	return hookCmd(c.data.Hooks.PreDeploy)
+}
 
-	if hc.StartupProbe != nil {
-		result.StartupProbe = hc.StartupProbe
+// PostDeployCmd returns the command running the post-deploy hooks of ketch.yaml.
+func (c Configurator) PostDeployCmd() []string {
+	if c.data.Hooks == nil {
+		return nil
 	}
+	return hookCmd(c.data.Hooks.PostDeploy)
+}
 
-	return result, nil
+// hookCmd returns a command running the commands of a hook one by one until one of them fails.
+func hookCmd(cmds []string) []string {
+	if len(cmds) == 0 {
+		return nil
+	}
+	return []string{"sh", "-c", strings.Join(cmds, " && ")}
 }
 
 func (c Configurator) Lifecycle() *apiv1.Lifecycle {
@@ -99,10 +148,122 @@ func (c Configurator) Lifecycle() *apiv1.Lifecycle {
 	}
 }
 
+// VolumeClaims returns persistent volume claims declared in ketch.yaml.
+func (c Configurator) VolumeClaims() []ketchv1.KetchYamlVolumeClaim {
+	return c.data.VolumeClaims
+}
+
+// ProcessKind returns the kind of workload configured for the process in ketch.yaml or an empty string.
+func (c Configurator) ProcessKind(process string) string {
+	if config := c.data.ProcessConfig(process); config != nil {
+		return config.Kind
+	}
+	return ""
+}
+
+// VolumeClaimTemplates returns volume claim templates of a statefulset process declared in ketch.yaml.
+func (c Configurator) VolumeClaimTemplates(process string) []ketchv1.KetchYamlVolumeClaimTemplate {
+	if config := c.data.ProcessConfig(process); config != nil {
+		return config.VolumeClaimTemplates
+	}
+	return nil
+}
+
+// TerminationGracePeriodSeconds returns the termination grace period of the process declared in ketch.yaml.
+func (c Configurator) TerminationGracePeriodSeconds(process string) *int64 {
+	if config := c.data.ProcessConfig(process); config != nil {
+		return config.TerminationGracePeriodSeconds
+	}
+	return nil
+}
+
+// PriorityClassName returns the priority class of the process declared in ketch.yaml or an empty string.
+func (c Configurator) PriorityClassName(process string) string {
+	if config := c.data.ProcessConfig(process); config != nil {
+		return config.PriorityClassName
+	}
+	return ""
+}
+
+// RuntimeClassName returns the runtime class of the process declared in ketch.yaml or an empty string.
+func (c Configurator) RuntimeClassName(process string) string {
+	if config := c.data.ProcessConfig(process); config != nil {
+		return config.RuntimeClassName
+	}
+	return ""
+}
+
+// StickySessions returns true if ketch.yaml enables sticky sessions of the process.
+func (c Configurator) StickySessions(process string) bool {
+	if config := c.data.ProcessConfig(process); config != nil {
+		return config.StickySessions
+	}
+	return false
+}
+
+// Timeout returns the timeout of requests of the process declared in ketch.yaml or an empty string.
+func (c Configurator) Timeout(process string) string {
+	if config := c.data.ProcessConfig(process); config != nil {
+		return config.Timeout
+	}
+	return ""
+}
+
+// WebSocket returns true if ketch.yaml enables websocket connections of the process.
+func (c Configurator) WebSocket(process string) bool {
+	if config := c.data.ProcessConfig(process); config != nil {
+		return config.WebSocket
+	}
+	return false
+}
+
+// HeadlessService returns the mode of a headless service of the process declared in ketch.yaml or an empty string.
+func (c Configurator) HeadlessService(process string) ketchv1.HeadlessServiceMode {
+	if config := c.data.ProcessConfig(process); config != nil {
+		return config.HeadlessService
+	}
+	return ""
+}
+
+// Routes returns routes of the process declared in ketch.yaml.
+func (c Configurator) Routes(process string) []ketchv1.KetchYamlRoute {
+	if config := c.data.ProcessConfig(process); config != nil {
+		return config.Routes
+	}
+	return nil
+}
+
+// ExcludeLogs returns true if ketch.yaml excludes logs of the process from log forwarding.
+func (c Configurator) ExcludeLogs(process string) bool {
+	if config := c.data.ProcessConfig(process); config != nil {
+		return config.ExcludeLogs
+	}
+	return false
+}
+
+// Metrics returns the prometheus endpoint of the process declared in ketch.yaml.
+func (c Configurator) Metrics(process string) *ketchv1.KetchYamlMetrics {
+	if config := c.data.ProcessConfig(process); config != nil {
+		return config.Metrics
+	}
+	return nil
+}
+
+// Language returns the language of the app declared in ketch.yaml or an empty string.
+func (c Configurator) Language() string {
+	return c.data.Language
+}
+
+// Scaler returns the KEDA scaler of the process declared in ketch.yaml.
+func (c Configurator) Scaler(process string) *ketchv1.KetchYamlScaler {
+	return c.data.Scaler(process)
+}
+
 func (c Configurator) ProcessPortConfigs(process string) []ketchv1.KetchYamlProcessPortConfig {
 	if c.data.Kubernetes != nil {
 		podConfig, ok := c.data.Kubernetes.Processes[process]
-		if ok {
+		// a process can be configured without ports, e.g. to set its kind only.
+		if ok && podConfig.Ports != nil {
 			return podConfig.Ports
 		}
 	}
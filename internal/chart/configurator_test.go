@@ -0,0 +1,66 @@
+package chart
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+)
+
+func TestConfigurator_Probes(t *testing.T) {
+	httpProbe := &v1.Probe{ProbeHandler: v1.ProbeHandler{HTTPGet: &v1.HTTPGetAction{
+		Path:        "/health",
+		Port:        intstr.FromInt(8080),
+		HTTPHeaders: []v1.HTTPHeader{{Name: "X-Probe", Value: "ketch"}},
+	}}}
+	grpcProbe := &v1.Probe{ProbeHandler: v1.ProbeHandler{GRPC: &v1.GRPCAction{Port: 9090}}}
+	execProbe := &v1.Probe{ProbeHandler: v1.ProbeHandler{Exec: &v1.ExecAction{Command: []string{"cat", "/tmp/healthy"}}}}
+	data := &ketchv1.KetchYamlData{
+		Healthcheck: &ketchv1.KetchYamlHealthcheck{LivenessProbe: httpProbe, ReadinessProbe: httpProbe},
+		Kubernetes: &ketchv1.KetchYamlKubernetesConfig{
+			Processes: map[string]ketchv1.KetchYamlProcessConfig{
+				"grpc":   {Healthcheck: &ketchv1.KetchYamlHealthcheck{LivenessProbe: grpcProbe}},
+				"worker": {Healthcheck: &ketchv1.KetchYamlHealthcheck{LivenessProbe: execProbe}},
+				"web":    {Kind: "deployment"},
+			},
+		},
+	}
+	tests := []struct {
+		process           string
+		wantProbes        Probes
+		wantProcessProbes Probes
+	}{
+		{
+			process:    "web",
+			wantProbes: Probes{Liveness: httpProbe, Readiness: httpProbe},
+		},
+		{
+			process:           "grpc",
+			wantProbes:        Probes{Liveness: grpcProbe, Readiness: httpProbe},
+			wantProcessProbes: Probes{Liveness: grpcProbe},
+		},
+		{
+			process:           "worker",
+			wantProbes:        Probes{Liveness: execProbe, Readiness: httpProbe},
+			wantProcessProbes: Probes{Liveness: execProbe},
+		},
+		{
+			process:    "unknown",
+			wantProbes: Probes{Liveness: httpProbe, Readiness: httpProbe},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.process, func(t *testing.T) {
+			c := NewConfigurator(data, Procfile{}, nil, DefaultApplicationPort)
+			probes, err := c.Probes(tt.process)
+			require.Nil(t, err)
+			require.Equal(t, tt.wantProbes, probes)
+			processProbes, err := c.ProcessProbes(tt.process)
+			require.Nil(t, err)
+			require.Equal(t, tt.wantProcessProbes, processProbes)
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package chart
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"helm.sh/helm/v3/pkg/release"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+)
+
+const (
+	// PreDeployProcessName is the name of the Job running the pre-deploy hooks of ketch.yaml.
+	PreDeployProcessName = "pre-deploy"
+	// PostDeployProcessName is the name of the Job running the post-deploy hooks of ketch.yaml.
+	PostDeployProcessName = "post-deploy"
+
+	// deployHookTimeout is how long helm waits for the Job of a deploy hook to complete.
+	deployHookTimeout = 10 * time.Minute
+
+	// parts of the error messages helm returns when a hook fails, pre hooks run before the chart's resources are updated.
+	helmPreInstallFailed  = "failed pre-install"
+	helmPreUpgradeFailed  = "pre-upgrade hooks failed"
+	helmPostInstallFailed = "failed post-install"
+	helmPostUpgradeFailed = "post-upgrade hooks failed"
+)
+
+// deployHook is a Job rendered as a helm hook, it runs once per deployment.
+type deployHook struct {
+	Process string   `json:"process"`
+	Cmd     []string `json:"cmd"`
+	// Events are helm hook events of the Job.
+	Events string `json:"events"`
+	// Weight orders hooks of the same events.
+	Weight int `json:"weight"`
+	// SecurityContext of the Job's container, it complies with the pod security profile of the app.
+	SecurityContext *v1.SecurityContext `json:"securityContext,omitempty"`
+}
+
+// newDeployHooks returns the deployment's hooks in the order they run:
+// pre-deploy hooks, the release process of the Procfile or of ketch.yaml and post-deploy hooks.
+func newDeployHooks(spec ketchv1.AppDeploymentSpec, c Configurator, profile ketchv1.PodSecurityProfile) []deployHook {
+	releaseCmd := spec.ReleaseCmd
+	if len(releaseCmd) == 0 {
+		releaseCmd = c.ReleaseCmd()
+	}
+	var hooks []deployHook
+	for _, hook := range []deployHook{
+		{Process: PreDeployProcessName, Cmd: c.PreDeployCmd(), Events: "pre-install,pre-upgrade", Weight: -1},
+		{Process: ReleaseProcessName, Cmd: releaseCmd, Events: "pre-install,pre-upgrade"},
+		{Process: PostDeployProcessName, Cmd: c.PostDeployCmd(), Events: "post-install,post-upgrade"},
+	} {
+		if len(hook.Cmd) > 0 {
+			hook.SecurityContext = profile.SecurityContext(nil)
+			hooks = append(hooks, hook)
+		}
+	}
+	return hooks
+}
+
+// DeployHooksVersion returns the version of the deployment whose hooks run when the chart is updated.
+func (chrt ApplicationChart) DeployHooksVersion() (ketchv1.DeploymentVersion, bool) {
+	for _, deployment := range chrt.values.App.Deployments {
+		if len(deployment.Hooks) > 0 {
+			return deployment.Version, true
+		}
+	}
+	return 0, false
+}
+
+// DeployHookStatuses returns results of the deploy hooks executed by the helm release.
+func DeployHookStatuses(rel *release.Release, appName string) []ketchv1.DeployHookStatus {
+	if rel == nil {
+		return nil
+	}
+	var statuses []ketchv1.DeployHookStatus
+	for _, hook := range rel.Hooks {
+		if hook.Kind != "Job" || hook.LastRun.Phase == "" {
+			continue
+		}
+		for _, process := range []string{PreDeployProcessName, ReleaseProcessName, PostDeployProcessName} {
+			prefix := fmt.Sprintf("%s-%s-", appName, process)
+			if !strings.HasPrefix(hook.Name, prefix) {
+				continue
+			}
+			var version ketchv1.DeploymentVersion
+			if _, err := fmt.Sscanf(strings.TrimPrefix(hook.Name, prefix), "%d", &version); err != nil {
+				continue
+			}
+			status := ketchv1.DeployHookStatus{
+				Process: process,
+				Version: version,
+				Phase:   hook.LastRun.Phase.String(),
+			}
+			if !hook.LastRun.StartedAt.IsZero() {
+				status.StartedAt = &metav1.Time{Time: hook.LastRun.StartedAt.Time}
+			}
+			if !hook.LastRun.CompletedAt.IsZero() {
+				status.CompletedAt = &metav1.Time{Time: hook.LastRun.CompletedAt.Time}
+			}
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses
+}
+
+// IsPreDeployHookFailed returns true if the error is caused by a failed pre-deploy hook or release process of a deployment.
+func IsPreDeployHookFailed(err error) bool {
+	if err == nil {
+		return false
+	}
+	return strings.Contains(err.Error(), helmPreInstallFailed) || strings.Contains(err.Error(), helmPreUpgradeFailed)
+}
+
+// IsPostDeployHookFailed returns true if the error is caused by a failed post-deploy hook of a deployment.
+func IsPostDeployHookFailed(err error) bool {
+	if err == nil {
+		return false
+	}
+	return strings.Contains(err.Error(), helmPostInstallFailed) || strings.Contains(err.Error(), helmPostUpgradeFailed)
+}
@@ -0,0 +1,64 @@
+package chart
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/release"
+	helmTime "helm.sh/helm/v3/pkg/time"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+)
+
+func TestDeployHookStatuses(t *testing.T) {
+	started := time.Date(2022, 6, 1, 10, 0, 0, 0, time.UTC)
+	completed := started.Add(time.Minute)
+	rel := &release.Release{
+		Hooks: []*release.Hook{
+			{
+				Name: "go-app-pre-deploy-3",
+				Kind: "Job",
+				LastRun: release.HookExecution{
+					StartedAt:   helmTime.Time{Time: started},
+					CompletedAt: helmTime.Time{Time: completed},
+					Phase:       release.HookPhaseSucceeded,
+				},
+			},
+			{
+				Name:    "go-app-release-3",
+				Kind:    "Job",
+				LastRun: release.HookExecution{StartedAt: helmTime.Time{Time: completed}, Phase: release.HookPhaseFailed},
+			},
+			// the post-deploy hook didn't run.
+			{Name: "go-app-post-deploy-3", Kind: "Job"},
+			{Name: "go-app-release-notes", Kind: "ConfigMap", LastRun: release.HookExecution{Phase: release.HookPhaseSucceeded}},
+		},
+	}
+	statuses := DeployHookStatuses(rel, "go-app")
+	require.Len(t, statuses, 2)
+	require.Equal(t, "pre-deploy", statuses[0].Process)
+	require.Equal(t, ketchv1.DeploymentVersion(3), statuses[0].Version)
+	require.Equal(t, "Succeeded", statuses[0].Phase)
+	require.True(t, statuses[0].StartedAt.Equal(&metav1.Time{Time: started}))
+	require.True(t, statuses[0].CompletedAt.Equal(&metav1.Time{Time: completed}))
+	require.Equal(t, "release", statuses[1].Process)
+	require.Equal(t, "Failed", statuses[1].Phase)
+	require.Nil(t, statuses[1].CompletedAt)
+
+	require.Nil(t, DeployHookStatuses(nil, "go-app"))
+}
+
+func TestIsDeployHookFailed(t *testing.T) {
+	require.False(t, IsPreDeployHookFailed(nil))
+	require.False(t, IsPreDeployHookFailed(errors.New("post-upgrade hooks failed: job failed: BackoffLimitExceeded")))
+	require.True(t, IsPreDeployHookFailed(errors.New("pre-upgrade hooks failed: job failed: BackoffLimitExceeded")))
+	require.True(t, IsPreDeployHookFailed(errors.New("failed pre-install: timed out waiting for the condition")))
+
+	require.False(t, IsPostDeployHookFailed(nil))
+	require.False(t, IsPostDeployHookFailed(errors.New("pre-upgrade hooks failed: job failed: BackoffLimitExceeded")))
+	require.True(t, IsPostDeployHookFailed(errors.New("post-upgrade hooks failed: job failed: BackoffLimitExceeded")))
+	require.True(t, IsPostDeployHookFailed(errors.New("failed post-install: job failed: BackoffLimitExceeded")))
+}
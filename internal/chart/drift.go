@@ -0,0 +1,173 @@
+package chart
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/go-logr/logr"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+)
+
+const (
+	// RevertDriftPolicy upgrades the chart of an app whose resources were edited, so the edits are reverted.
+	RevertDriftPolicy = "revert"
+	// ReportDriftPolicy keeps edits of an app's resources and lists the edited resources in the app's status.
+	// It's the default drift policy.
+	ReportDriftPolicy = "report"
+)
+
+// driftKinds are kinds of objects whose edits are detected.
+var driftKinds = map[string]bool{
+	"Deployment":  true,
+	"StatefulSet": true,
+	"DaemonSet":   true,
+	"Service":     true,
+}
+
+// ValidateDriftPolicy returns an error if the drift policy isn't supported, an empty policy is the default one.
+func ValidateDriftPolicy(policy string) error {
+	switch policy {
+	case "", RevertDriftPolicy, ReportDriftPolicy:
+		return nil
+	}
+	return fmt.Errorf("unsupported drift policy %q, use %q or %q", policy, RevertDriftPolicy, ReportDriftPolicy)
+}
+
+// DetectDrift returns objects of the chart that are missing and Deployments, StatefulSets, DaemonSets and Services of the chart
+// whose live state diverges from their rendered manifests.
+// Only fields of the manifests are compared, fields defaulted by the API server or added by other controllers are not drift.
+// Replicas of autoscaled workloads, keyed by name, are not compared.
+func DetectDrift(ctx context.Context, tv TemplateValuer, config ChartConfig, namespace string, c client.Client, log logr.Logger, autoscaled map[string]bool) ([]ketchv1.DriftedResource, error) {
+	applyClient := NewApplyClient(namespace, c, log)
+	rel, err := applyClient.render(tv, config)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := applyClient.decode(rel.Manifest)
+	if err != nil {
+		return nil, err
+	}
+	var drifted []ketchv1.DriftedResource
+	for _, obj := range objects {
+		live := &unstructured.Unstructured{}
+		live.SetGroupVersionKind(obj.GroupVersionKind())
+		err := c.Get(ctx, client.ObjectKeyFromObject(obj), live)
+		if k8serrors.IsNotFound(err) {
+			drifted = append(drifted, ketchv1.DriftedResource{Kind: obj.GetKind(), Name: obj.GetName(), Missing: true})
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s %s: %w", obj.GetKind(), obj.GetName(), err)
+		}
+		if !driftKinds[obj.GetKind()] {
+			continue
+		}
+		if autoscaled[obj.GetName()] {
+			unstructured.RemoveNestedField(obj.Object, "spec", "replicas")
+		}
+		var fields []string
+		for _, key := range []string{"labels", "annotations"} {
+			expected, _, _ := unstructured.NestedFieldNoCopy(obj.Object, "metadata", key)
+			actual, _, _ := unstructured.NestedFieldNoCopy(live.Object, "metadata", key)
+			fields = append(fields, divergingFields("metadata."+key, expected, actual)...)
+		}
+		fields = append(fields, divergingFields("spec", obj.Object["spec"], live.Object["spec"])...)
+		if len(fields) > 0 {
+			drifted = append(drifted, ketchv1.DriftedResource{Kind: obj.GetKind(), Name: obj.GetName(), Fields: fields})
+		}
+	}
+	return drifted, nil
+}
+
+// divergingFields returns paths of fields of expected whose values differ in actual, fields only actual has are ignored.
+// Empty values match missing fields because the API server drops them.
+func divergingFields(path string, expected, actual interface{}) []string {
+	if expected == nil || actual == nil && isEmptyValue(expected) {
+		return nil
+	}
+	switch e := expected.(type) {
+	case map[string]interface{}:
+		a, ok := actual.(map[string]interface{})
+		if !ok {
+			return []string{path}
+		}
+		keys := make([]string, 0, len(e))
+		for key := range e {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		var fields []string
+		for _, key := range keys {
+			fields = append(fields, divergingFields(path+"."+key, e[key], a[key])...)
+		}
+		return fields
+	case []interface{}:
+		a, ok := actual.([]interface{})
+		if !ok || len(a) != len(e) {
+			return []string{path}
+		}
+		var fields []string
+		for i := range e {
+			fields = append(fields, divergingFields(fmt.Sprintf("%s[%d]", path, i), e[i], a[i])...)
+		}
+		return fields
+	case string:
+		if a, ok := actual.(string); ok && a != e {
+			// resource quantities like "0.5" and "500m" are equal.
+			eq, eErr := resource.ParseQuantity(e)
+			aq, aErr := resource.ParseQuantity(a)
+			if eErr == nil && aErr == nil && eq.Cmp(aq) == 0 {
+				return nil
+			}
+		}
+	}
+	// numbers of manifests are decoded as float64 and numbers of live objects as int64.
+	if e, ok := toFloat64(expected); ok {
+		if a, ok := toFloat64(actual); ok {
+			if e != a {
+				return []string{path}
+			}
+			return nil
+		}
+	}
+	if fmt.Sprint(expected) != fmt.Sprint(actual) {
+		return []string{path}
+	}
+	return nil
+}
+
+func toFloat64(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+func isEmptyValue(value interface{}) bool {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	case string:
+		return v == ""
+	case bool:
+		return !v
+	case float64:
+		return v == 0
+	case int64:
+		return v == 0
+	}
+	return false
+}
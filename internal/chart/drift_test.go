@@ -0,0 +1,100 @@
+package chart
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+)
+
+func TestValidateDriftPolicy(t *testing.T) {
+	require.Nil(t, ValidateDriftPolicy(""))
+	require.Nil(t, ValidateDriftPolicy(RevertDriftPolicy))
+	require.Nil(t, ValidateDriftPolicy(ReportDriftPolicy))
+	require.EqualError(t, ValidateDriftPolicy("ignore"), `unsupported drift policy "ignore", use "revert" or "report"`)
+}
+
+func TestDetectDrift(t *testing.T) {
+	recorder := newApplyRecorder()
+	chrt := testChart{values: map[string]interface{}{"version": 1, "service": true}}
+	config := ChartConfig{Version: "v0.0.1", AppName: "hello"}
+	ctx := context.Background()
+
+	drifted, err := DetectDrift(ctx, chrt, config, "gke", recorder, logr.Discard(), nil)
+	require.Nil(t, err)
+	require.Equal(t, []ketchv1.DriftedResource{{Kind: "ConfigMap", Name: "hello-config", Missing: true}, {Kind: "Service", Name: "hello", Missing: true}}, drifted)
+
+	_, err = NewApplyClient("gke", recorder, logr.Discard()).UpdateChart(chrt, config)
+	require.Nil(t, err)
+	drifted, err = DetectDrift(ctx, chrt, config, "gke", recorder, logr.Discard(), nil)
+	require.Nil(t, err)
+	require.Empty(t, drifted)
+
+	var service v1.Service
+	require.Nil(t, recorder.Get(ctx, types.NamespacedName{Namespace: "gke", Name: "hello"}, &service))
+	service.Labels = map[string]string{"edited": "true"}
+	service.Spec.Ports[0].Port = 8080
+	require.Nil(t, recorder.Update(ctx, &service))
+	drifted, err = DetectDrift(ctx, chrt, config, "gke", recorder, logr.Discard(), nil)
+	require.Nil(t, err)
+	require.Equal(t, []ketchv1.DriftedResource{{Kind: "Service", Name: "hello", Fields: []string{"spec.ports[0].port"}}}, drifted)
+
+	require.Nil(t, recorder.Delete(ctx, &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "gke", Name: "hello-config"}}))
+	drifted, err = DetectDrift(ctx, chrt, config, "gke", recorder, logr.Discard(), nil)
+	require.Nil(t, err)
+	require.Equal(t, []ketchv1.DriftedResource{{Kind: "ConfigMap", Name: "hello-config", Missing: true}, {Kind: "Service", Name: "hello", Fields: []string{"spec.ports[0].port"}}}, drifted)
+}
+
+func Test_divergingFields(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected interface{}
+		actual   interface{}
+		want     []string
+	}{
+		{
+			name:     "defaulted fields",
+			expected: map[string]interface{}{"replicas": float64(2)},
+			actual:   map[string]interface{}{"replicas": int64(2), "revisionHistoryLimit": int64(10)},
+		},
+		{
+			name:     "edited fields",
+			expected: map[string]interface{}{"replicas": float64(2), "selector": map[string]interface{}{"app": "hello"}},
+			actual:   map[string]interface{}{"replicas": int64(3), "selector": map[string]interface{}{"app": "world"}},
+			want:     []string{"spec.replicas", "spec.selector.app"},
+		},
+		{
+			name:     "removed list item",
+			expected: map[string]interface{}{"ports": []interface{}{map[string]interface{}{"port": float64(80)}, map[string]interface{}{"port": float64(443)}}},
+			actual:   map[string]interface{}{"ports": []interface{}{map[string]interface{}{"port": int64(80)}}},
+			want:     []string{"spec.ports"},
+		},
+		{
+			name:     "empty values dropped by the API server",
+			expected: map[string]interface{}{"hostNetwork": false, "env": []interface{}{}, "nodeName": ""},
+			actual:   map[string]interface{}{},
+		},
+		{
+			name:     "large numbers",
+			expected: map[string]interface{}{"terminationGracePeriodSeconds": float64(1e6), "activeDeadlineSeconds": float64(2e6)},
+			actual:   map[string]interface{}{"terminationGracePeriodSeconds": int64(1000000), "activeDeadlineSeconds": int64(3000000)},
+			want:     []string{"spec.activeDeadlineSeconds"},
+		},
+		{
+			name:     "equal quantities",
+			expected: map[string]interface{}{"cpu": "0.5", "memory": "1Gi"},
+			actual:   map[string]interface{}{"cpu": "500m", "memory": "1Gi"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, divergingFields("spec", tt.expected, tt.actual))
+		})
+	}
+}
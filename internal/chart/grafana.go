@@ -0,0 +1,142 @@
+package chart
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+)
+
+// grafanaDashboard contains values for populating the grafana_dashboard.yaml.
+type grafanaDashboard struct {
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// Dashboard is the JSON model of the dashboard.
+	Dashboard string `json:"dashboard"`
+}
+
+// grafanaPanel is a time series panel of a grafana dashboard.
+type grafanaPanel struct {
+	ID          int                    `json:"id"`
+	Type        string                 `json:"type"`
+	Title       string                 `json:"title"`
+	Datasource  map[string]string      `json:"datasource"`
+	GridPos     map[string]int         `json:"gridPos"`
+	FieldConfig map[string]interface{} `json:"fieldConfig"`
+	Targets     []grafanaTarget        `json:"targets"`
+}
+
+type grafanaTarget struct {
+	RefID        string `json:"refId"`
+	Expr         string `json:"expr"`
+	LegendFormat string `json:"legendFormat"`
+}
+
+// invalidPrometheusLabelChars matches characters kube-state-metrics replaces in names of pod labels.
+var invalidPrometheusLabelChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// kubePodLabel returns the name of a label of kube_pod_labels with a value of the pod label.
+func kubePodLabel(label string) string {
+	return "label_" + invalidPrometheusLabelChars.ReplaceAllString(label, "_")
+}
+
+// requestQueries returns prometheus queries of the rate of requests and the ratio of failed requests to the app
+// reported by the ingress controller.
+func requestQueries(ingressType ketchv1.IngressControllerType, namespace string, app *ketchv1.App) (string, string) {
+	var metric, selector, statusLabel string
+	switch ingressType {
+	case ketchv1.TraefikIngressControllerType:
+		metric = "traefik_service_requests_total"
+		selector = fmt.Sprintf(`service=~"%s-%s-.+"`, namespace, app.Name)
+		statusLabel = "code"
+	case ketchv1.IstioIngressControllerType:
+		appLabel := app.Spec.ID
+		if appLabel == "" {
+			appLabel = app.Name
+		}
+		metric = "istio_requests_total"
+		selector = fmt.Sprintf(`destination_workload_namespace=%q,destination_app=%q`, namespace, appLabel)
+		statusLabel = "response_code"
+	default:
+		metric = "nginx_ingress_controller_requests"
+		selector = fmt.Sprintf(`namespace=%q,ingress=~"%s-[0-9]+-.+"`, namespace, app.Name)
+		statusLabel = "status"
+	}
+	requests := fmt.Sprintf("sum(rate(%s{%s}[5m]))", metric, selector)
+	errors := fmt.Sprintf(`sum(rate(%s{%s,%s=~"5.."}[5m])) / %s`, metric, selector, statusLabel, requests)
+	return requests, errors
+}
+
+// newGrafanaDashboard returns a configmap with a grafana dashboard of requests, error rates, pod restarts and
+// resource usage of the app. Pods of the app are matched by ketch labels exposed by kube-state-metrics.
+func newGrafanaDashboard(spec ketchv1.GrafanaDashboardsSpec, ingressType ketchv1.IngressControllerType, app *ketchv1.App) (*grafanaDashboard, error) {
+	namespace := app.Spec.Namespace
+	processLabel := kubePodLabel(ketchv1.Group + "/app-process")
+	pods := fmt.Sprintf(`kube_pod_labels{namespace=%q,%s=%q}`, namespace, kubePodLabel(ketchv1.Group+"/app-name"), app.Name)
+	byProcess := func(expr string) string {
+		return fmt.Sprintf("sum by (%s) (%s * on (namespace, pod) group_left (%s) %s)", processLabel, expr, processLabel, pods)
+	}
+	processLegend := fmt.Sprintf("{{%s}}", processLabel)
+	requests, errors := requestQueries(ingressType, namespace, app)
+
+	panels := []grafanaPanel{
+		{Title: "Requests", Targets: []grafanaTarget{{Expr: requests, LegendFormat: "requests/s"}}},
+		{Title: "Error rate", Targets: []grafanaTarget{{Expr: errors, LegendFormat: "5xx"}}},
+		{Title: "Pod restarts", Targets: []grafanaTarget{{
+			Expr:         byProcess(fmt.Sprintf("increase(kube_pod_container_status_restarts_total{namespace=%q}[1h])", namespace)),
+			LegendFormat: processLegend,
+		}}},
+		{Title: "CPU usage", Targets: []grafanaTarget{{
+			Expr:         byProcess(fmt.Sprintf(`rate(container_cpu_usage_seconds_total{namespace=%q,container!=""}[5m])`, namespace)),
+			LegendFormat: processLegend,
+		}}},
+		{Title: "Memory usage", Targets: []grafanaTarget{{
+			Expr:         byProcess(fmt.Sprintf(`container_memory_working_set_bytes{namespace=%q,container!=""}`, namespace)),
+			LegendFormat: processLegend,
+		}}},
+	}
+	units := []string{"reqps", "percentunit", "short", "short", "bytes"}
+	for i := range panels {
+		panels[i].ID = i + 1
+		panels[i].Type = "timeseries"
+		panels[i].Datasource = map[string]string{"type": "prometheus", "uid": "${datasource}"}
+		panels[i].GridPos = map[string]int{"x": (i % 2) * 12, "y": (i / 2) * 8, "w": 12, "h": 8}
+		panels[i].FieldConfig = map[string]interface{}{"defaults": map[string]string{"unit": units[i]}}
+		for j := range panels[i].Targets {
+			panels[i].Targets[j].RefID = string(rune('A' + j))
+		}
+	}
+
+	uid := "ketch-" + namespace + "-" + app.Name
+	if len(uid) > 40 {
+		uid = uid[:40]
+	}
+	dashboard := map[string]interface{}{
+		"uid":           uid,
+		"title":         fmt.Sprintf("%s (%s)", app.Name, namespace),
+		"tags":          []string{"ketch"},
+		"schemaVersion": 36,
+		"refresh":       "1m",
+		"time":          map[string]string{"from": "now-6h", "to": "now"},
+		"templating": map[string]interface{}{
+			"list": []map[string]interface{}{{
+				"name":    "datasource",
+				"label":   "Datasource",
+				"type":    "datasource",
+				"query":   "prometheus",
+				"current": map[string]string{"text": spec.DatasourceName(), "value": spec.DatasourceName()},
+			}},
+		},
+		"panels": panels,
+	}
+	bs, err := json.MarshalIndent(dashboard, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return &grafanaDashboard{
+		Labels:      spec.ConfigMapLabels(),
+		Annotations: spec.Annotations,
+		Dashboard:   string(bs),
+	}, nil
+}
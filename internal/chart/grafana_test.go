@@ -0,0 +1,74 @@
+package chart
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+)
+
+func Test_requestQueries(t *testing.T) {
+	app := &ketchv1.App{ObjectMeta: metav1.ObjectMeta{Name: "dashboard"}}
+	tests := []struct {
+		name         string
+		ingressType  ketchv1.IngressControllerType
+		wantRequests string
+		wantErrors   string
+	}{
+		{
+			name:         "nginx",
+			ingressType:  ketchv1.NginxIngressControllerType,
+			wantRequests: `sum(rate(nginx_ingress_controller_requests{namespace="test-ns",ingress=~"dashboard-[0-9]+-.+"}[5m]))`,
+			wantErrors:   `sum(rate(nginx_ingress_controller_requests{namespace="test-ns",ingress=~"dashboard-[0-9]+-.+",status=~"5.."}[5m])) / sum(rate(nginx_ingress_controller_requests{namespace="test-ns",ingress=~"dashboard-[0-9]+-.+"}[5m]))`,
+		},
+		{
+			name:         "traefik",
+			ingressType:  ketchv1.TraefikIngressControllerType,
+			wantRequests: `sum(rate(traefik_service_requests_total{service=~"test-ns-dashboard-.+"}[5m]))`,
+			wantErrors:   `sum(rate(traefik_service_requests_total{service=~"test-ns-dashboard-.+",code=~"5.."}[5m])) / sum(rate(traefik_service_requests_total{service=~"test-ns-dashboard-.+"}[5m]))`,
+		},
+		{
+			name:         "istio",
+			ingressType:  ketchv1.IstioIngressControllerType,
+			wantRequests: `sum(rate(istio_requests_total{destination_workload_namespace="test-ns",destination_app="dashboard"}[5m]))`,
+			wantErrors:   `sum(rate(istio_requests_total{destination_workload_namespace="test-ns",destination_app="dashboard",response_code=~"5.."}[5m])) / sum(rate(istio_requests_total{destination_workload_namespace="test-ns",destination_app="dashboard"}[5m]))`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests, errors := requestQueries(tt.ingressType, "test-ns", app)
+			require.Equal(t, tt.wantRequests, requests)
+			require.Equal(t, tt.wantErrors, errors)
+		})
+	}
+}
+
+func Test_newGrafanaDashboard(t *testing.T) {
+	app := &ketchv1.App{
+		ObjectMeta: metav1.ObjectMeta{Name: "dashboard"},
+		Spec:       ketchv1.AppSpec{Namespace: "test-ns"},
+	}
+	spec := ketchv1.GrafanaDashboardsSpec{Annotations: map[string]string{"grafana_folder": "apps"}}
+	got, err := newGrafanaDashboard(spec, ketchv1.NginxIngressControllerType, app)
+	require.Nil(t, err)
+	require.Equal(t, map[string]string{"grafana_dashboard": "1"}, got.Labels)
+	require.Equal(t, map[string]string{"grafana_folder": "apps"}, got.Annotations)
+
+	var dashboard struct {
+		UID    string         `json:"uid"`
+		Title  string         `json:"title"`
+		Panels []grafanaPanel `json:"panels"`
+	}
+	require.Nil(t, json.Unmarshal([]byte(got.Dashboard), &dashboard))
+	require.Equal(t, "ketch-test-ns-dashboard", dashboard.UID)
+	require.Equal(t, "dashboard (test-ns)", dashboard.Title)
+	var titles []string
+	for _, panel := range dashboard.Panels {
+		titles = append(titles, panel.Title)
+	}
+	require.Equal(t, []string{"Requests", "Error rate", "Pod restarts", "CPU usage", "Memory usage"}, titles)
+	require.Equal(t, `sum by (label_theketch_io_app_process) (increase(kube_pod_container_status_restarts_total{namespace="test-ns"}[1h]) * on (namespace, pod) group_left (label_theketch_io_app_process) kube_pod_labels{namespace="test-ns",label_theketch_io_app_name="dashboard"})`, dashboard.Panels[2].Targets[0].Expr)
+}
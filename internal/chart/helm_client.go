@@ -1,12 +1,17 @@
 package chart
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
 	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/chart/loader"
 	"helm.sh/helm/v3/pkg/release"
 	"helm.sh/helm/v3/pkg/storage/driver"
@@ -55,8 +60,34 @@ var helmStatusActionMapDelete = map[release.Status]int{
 
 const (
 	defaultDeploymentTimeout = 10 * time.Minute
+
+	// helmOperationInProgress is a part of the error message helm returns when a release is locked by another operation.
+	helmOperationInProgress = "another operation (install/upgrade/rollback) is in progress"
 )
 
+// ReleaseLockedError is returned when a helm release can't be updated or deleted
+// because it is locked by a pending install, upgrade or rollback.
+type ReleaseLockedError struct {
+	AppName string
+	Status  release.Status
+}
+
+func (e ReleaseLockedError) Error() string {
+	return fmt.Sprintf("helm chart for app %s in non-actionable status %s", e.AppName, e.Status)
+}
+
+// IsReleaseLocked returns true if the error is caused by a helm release locked by another operation.
+func IsReleaseLocked(err error) bool {
+	if err == nil {
+		return false
+	}
+	var lockedErr ReleaseLockedError
+	if errors.As(err, &lockedErr) {
+		return true
+	}
+	return strings.Contains(err.Error(), helmOperationInProgress)
+}
+
 // HelmClient performs helm install and uninstall operations for provided application helm charts.
 type HelmClient struct {
 	cfg        *action.Configuration
@@ -64,6 +95,8 @@ type HelmClient struct {
 	c          client.Client
 	log        logr.Logger
 	statusFunc statusFunc
+	// maxHistory is the number of releases kept including the latest one.
+	maxHistory int
 }
 
 // TemplateValuer is an interface that permits types that implement it (e.g. Application, Job)
@@ -80,15 +113,7 @@ type InstallOption func(install *action.Install)
 // UpdateChart checks if the app chart is already installed and performs "helm install" or "helm update" operation.
 func (c HelmClient) UpdateChart(tv TemplateValuer, config ChartConfig, opts ...InstallOption) (*release.Release, error) {
 	appName := tv.GetName()
-	files, err := bufferedFiles(config, tv.GetTemplates(), tv.GetValues())
-	if err != nil {
-		return nil, err
-	}
-	chrt, err := loader.LoadFiles(files)
-	if err != nil {
-		return nil, err
-	}
-	vals, err := getValuesMap(tv.GetValues())
+	chrt, vals, err := loadChart(tv, config)
 	if err != nil {
 		return nil, err
 	}
@@ -99,40 +124,124 @@ func (c HelmClient) UpdateChart(tv TemplateValuer, config ChartConfig, opts ...I
 		clientInstall := action.NewInstall(c.cfg)
 		clientInstall.ReleaseName = appName
 		clientInstall.Namespace = c.namespace
+		clientInstall.Timeout = deployHookTimeout
 		clientInstall.PostRenderer = &postRender{
 			log:                c.log,
 			cli:                c.c,
 			namespace:          c.namespace,
 			appName:            config.AppName,
 			deploymentVersions: config.DeploymentVersions,
+			podSpecPatches:     config.PodSpecPatches,
 		}
 		for _, opt := range opts {
 			opt(clientInstall)
 		}
-		return clientInstall.Run(chrt, vals)
+		start := time.Now()
+		rel, err := clientInstall.Run(chrt, vals)
+		observeHelmOperation("install", start, err)
+		return rel, releaseLockedError(appName, err)
 	}
 	if err != nil {
 		return nil, err
 	}
 	updateClient := action.NewUpgrade(c.cfg)
 	updateClient.Namespace = c.namespace
+	updateClient.Timeout = deployHookTimeout
 
 	// MaxHistory specifies the maximum number of historical releases that will be retained, including the most recent release.
-	// Values of 0 or less are ignored (meaning no limits are imposed).
-	// Let's set it to minimal to disable "helm rollback".
-	updateClient.MaxHistory = 1
+	// Values of 0 or less are ignored (meaning no limits are imposed), so it's at least 1 to keep the number of releases bounded.
+	// Releases are kept to be listed by "ketch app releases", "helm rollback" is reverted by the controller.
+	updateClient.MaxHistory = c.maxHistory
+	if updateClient.MaxHistory < 1 {
+		updateClient.MaxHistory = 1
+	}
 	updateClient.PostRenderer = &postRender{
 		cli:                c.c,
 		log:                c.log,
 		namespace:          c.namespace,
 		appName:            config.AppName,
 		deploymentVersions: config.DeploymentVersions,
+		podSpecPatches:     config.PodSpecPatches,
 	}
 	shouldUpdate, err := c.isHelmChartStatusActionable(c.statusFunc, appName, helmStatusActionMapUpdate)
 	if err != nil || !shouldUpdate {
 		return nil, err
 	}
-	return updateClient.Run(appName, chrt, vals)
+	start := time.Now()
+	rel, err := updateClient.Run(appName, chrt, vals)
+	observeHelmOperation("upgrade", start, err)
+	return rel, releaseLockedError(appName, err)
+}
+
+// RenderChart renders the chart's manifests without connecting to a cluster, the way "helm template" does.
+// Post-render patches stored in configmaps are not applied, pod spec patches are.
+func RenderChart(tv TemplateValuer, config ChartConfig, namespace string) (string, error) {
+	chrt, vals, err := loadChart(tv, config)
+	if err != nil {
+		return "", err
+	}
+	clientInstall := action.NewInstall(&action.Configuration{Log: func(string, ...interface{}) {}})
+	clientInstall.ReleaseName = tv.GetName()
+	clientInstall.Namespace = namespace
+	clientInstall.DryRun = true
+	clientInstall.ClientOnly = true
+	clientInstall.PostRenderer = podSpecPostRender(config.PodSpecPatches)
+	rel, err := clientInstall.Run(chrt, vals)
+	if err != nil {
+		return "", err
+	}
+	return releaseManifests(rel), nil
+}
+
+// ManifestHash returns a hash of the chart's manifests and hooks rendered with the post-render patches the engine applies.
+// The chart doesn't need to be upgraded when neither the hash, the engine nor the storage of helm releases changed
+// since its last successful upgrade.
+func ManifestHash(tv TemplateValuer, config ChartConfig, engine string, storage HelmStorage, namespace string, c client.Client, log logr.Logger) (string, error) {
+	rel, err := NewApplyClient(namespace, c, log).render(tv, config)
+	if err != nil {
+		return "", err
+	}
+	installedBy := engine
+	if engine != ServerSideApplyEngine {
+		// a release moved to another driver or namespace is installed again.
+		installedBy += "\n" + storage.driver() + "\n" + storage.namespace(namespace)
+	}
+	sum := sha256.Sum256([]byte(installedBy + "\n" + releaseManifests(rel)))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// releaseManifests returns the release's manifests followed by its hooks, the way "helm template" prints them.
+func releaseManifests(rel *release.Release) string {
+	var b strings.Builder
+	b.WriteString(rel.Manifest)
+	for _, hook := range rel.Hooks {
+		fmt.Fprintf(&b, "---\n# Source: %s\n%s\n", hook.Path, hook.Manifest)
+	}
+	return b.String()
+}
+
+func loadChart(tv TemplateValuer, config ChartConfig) (*chart.Chart, map[string]interface{}, error) {
+	files, err := bufferedFiles(config, tv.GetTemplates(), tv.GetValues())
+	if err != nil {
+		return nil, nil, err
+	}
+	chrt, err := loader.LoadFiles(files)
+	if err != nil {
+		return nil, nil, err
+	}
+	vals, err := getValuesMap(tv.GetValues())
+	if err != nil {
+		return nil, nil, err
+	}
+	return chrt, vals, nil
+}
+
+// releaseLockedError converts helm's "another operation is in progress" error to ReleaseLockedError.
+func releaseLockedError(appName string, err error) error {
+	if err != nil && strings.Contains(err.Error(), helmOperationInProgress) {
+		return ReleaseLockedError{AppName: appName, Status: release.StatusUnknown}
+	}
+	return err
 }
 
 // DeleteChart uninstalls the app's helm release. It doesn't return an error if the release is not found.
@@ -149,6 +258,18 @@ func (c HelmClient) DeleteChart(appName string) error {
 	return err
 }
 
+// ReleaseRevision returns the version of the app's helm release, an empty string if the release isn't deployed.
+func (c HelmClient) ReleaseRevision(appName string) (string, error) {
+	rel, status, err := c.statusFunc(c.cfg, appName)
+	if err != nil {
+		return "", err
+	}
+	if status != release.StatusDeployed {
+		return "", nil
+	}
+	return strconv.Itoa(rel.Version), nil
+}
+
 // getHelmStatus returns the latest Release, Status, and error for an app
 func getHelmStatus(cfg *action.Configuration, appName string) (*release.Release, release.Status, error) {
 	statusClient := action.NewStatus(cfg)
@@ -179,15 +300,44 @@ func (c HelmClient) isHelmChartStatusActionable(statusFunc statusFunc, appName s
 	default:
 		c.log.Info(fmt.Sprintf("Found pending helm release: %d", lastRelease.Version))
 		timeoutLimit := time.Now().Add(-defaultDeploymentTimeout)
-		if lastRelease.Info.FirstDeployed.Before(helmTime.Time{Time: timeoutLimit}) {
-			newStatus := release.StatusDeployed
-			c.log.Info(fmt.Sprintf("Setting status of release that has timeouted to: %s", newStatus))
-			lastRelease.SetStatus(newStatus, "manually canceled")
-			if err := c.cfg.Releases.Update(lastRelease); err != nil {
+		if status.IsPending() && releaseStartedAt(lastRelease).Before(helmTime.Time{Time: timeoutLimit}) {
+			if err := c.unlockRelease(lastRelease); err != nil {
 				return false, err
 			}
 			return true, nil
 		}
-		return false, fmt.Errorf("helm chart for app %s in non-actionable status %s", appName, status)
+		return false, ReleaseLockedError{AppName: appName, Status: status}
+	}
+}
+
+// RepairChart unlocks the app's helm release if it is stuck in a pending status without waiting for the timeout.
+func (c HelmClient) RepairChart(appName string) error {
+	lastRelease, status, err := c.statusFunc(c.cfg, appName)
+	if err != nil {
+		return err
+	}
+	if !status.IsPending() {
+		return nil
+	}
+	return c.unlockRelease(lastRelease)
+}
+
+// unlockRelease changes the status of a pending release so helm accepts new operations on it.
+// A pending install has nothing to go back to, so it is marked as failed and the next update installs it again.
+func (c HelmClient) unlockRelease(lastRelease *release.Release) error {
+	newStatus := release.StatusDeployed
+	if lastRelease.Info.Status == release.StatusPendingInstall {
+		newStatus = release.StatusFailed
+	}
+	c.log.Info(fmt.Sprintf("Setting status of pending release %d to: %s", lastRelease.Version, newStatus))
+	lastRelease.SetStatus(newStatus, "manually canceled")
+	return c.cfg.Releases.Update(lastRelease)
+}
+
+// releaseStartedAt returns the time the latest operation on the release started.
+func releaseStartedAt(r *release.Release) helmTime.Time {
+	if r.Info.LastDeployed.IsZero() {
+		return r.Info.FirstDeployed
 	}
+	return r.Info.LastDeployed
 }
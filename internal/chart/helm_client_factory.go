@@ -2,7 +2,6 @@ package chart
 
 import (
 	"log"
-	"os"
 	"sync"
 	"time"
 
@@ -24,13 +23,18 @@ type HelmClientFactory struct {
 	lastCleanupTime time.Time
 
 	getActionConfig func(namespace string) (*action.Configuration, error)
+	storage         HelmStorage
 }
 
-func NewHelmClientFactory() *HelmClientFactory {
+// NewHelmClientFactory returns a factory of helm clients keeping releases in the storage.
+func NewHelmClientFactory(storage HelmStorage) *HelmClientFactory {
 	return &HelmClientFactory{
 		configurations:              map[string]*action.Configuration{},
 		configurationsLastUsedTimes: map[string]time.Time{},
-		getActionConfig:             getActionConfig,
+		getActionConfig: func(namespace string) (*action.Configuration, error) {
+			return getActionConfig(namespace, storage)
+		},
+		storage: storage,
 	}
 }
 
@@ -51,7 +55,14 @@ func (f *HelmClientFactory) NewHelmClient(namespace string, c client.Client, log
 		f.configurations[namespace] = cfg
 	}
 	f.configurationsLastUsedTimes[namespace] = time.Now()
-	return &HelmClient{cfg: cfg, namespace: namespace, c: c, log: log.WithValues("helm-client", namespace), statusFunc: getHelmStatus}, nil
+	return &HelmClient{
+		cfg:        cfg,
+		namespace:  namespace,
+		c:          c,
+		log:        log.WithValues("helm-client", namespace),
+		statusFunc: getHelmStatus,
+		maxHistory: f.storage.maxHistory(),
+	}, nil
 }
 
 func (f *HelmClientFactory) cleanup() {
@@ -67,7 +78,7 @@ func (f *HelmClientFactory) cleanup() {
 	f.lastCleanupTime = time.Now()
 }
 
-func getActionConfig(namespace string) (*action.Configuration, error) {
+func getActionConfig(namespace string, storage HelmStorage) (*action.Configuration, error) {
 	actionConfig := new(action.Configuration)
 
 	config := ctrl.GetConfigOrDie()
@@ -78,7 +89,8 @@ func getActionConfig(namespace string) (*action.Configuration, error) {
 	kubeConfig.BearerToken = &config.BearerToken
 	kubeConfig.CAFile = &config.CAFile
 	kubeConfig.Namespace = &namespace
-	if err := actionConfig.Init(kubeConfig, namespace, os.Getenv("HELM_DRIVER"), log.Printf); err != nil {
+	// releases are kept in the storage namespace while resources of the chart are installed to the namespace.
+	if err := actionConfig.Init(kubeConfig, storage.namespace(namespace), storage.driver(), log.Printf); err != nil {
 		return nil, err
 	}
 	return actionConfig, nil
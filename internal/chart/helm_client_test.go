@@ -1,6 +1,8 @@
 package chart
 
 import (
+	"errors"
+	"fmt"
 	"testing"
 	"time"
 
@@ -8,6 +10,8 @@ import (
 	"github.com/stretchr/testify/require"
 	"helm.sh/helm/v3/pkg/action"
 	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage"
+	"helm.sh/helm/v3/pkg/storage/driver"
 	helmTime "helm.sh/helm/v3/pkg/time"
 )
 
@@ -91,3 +95,180 @@ func TestIsHelmChartStatusActionable(t *testing.T) {
 		})
 	}
 }
+
+// lastReleaseStatus reads the latest release directly from the storage, action.Status requires a reachable cluster.
+func lastReleaseStatus(cfg *action.Configuration, appName string) (*release.Release, release.Status, error) {
+	r, err := cfg.Releases.Last(appName)
+	if err != nil {
+		return nil, "", err
+	}
+	return r, r.Info.Status, nil
+}
+
+func TestIsHelmChartStatusActionable_PendingTimeout(t *testing.T) {
+	tests := []struct {
+		description string
+		status      release.Status
+		startedAt   time.Time
+		expected    bool
+		wantStatus  release.Status
+		wantLocked  bool
+	}{
+		{
+			description: "pending install is marked as failed after the timeout",
+			status:      release.StatusPendingInstall,
+			startedAt:   time.Now().Add(-2 * defaultDeploymentTimeout),
+			expected:    true,
+			wantStatus:  release.StatusFailed,
+		},
+		{
+			description: "pending upgrade is marked as deployed after the timeout",
+			status:      release.StatusPendingUpgrade,
+			startedAt:   time.Now().Add(-2 * defaultDeploymentTimeout),
+			expected:    true,
+			wantStatus:  release.StatusDeployed,
+		},
+		{
+			description: "pending upgrade within the timeout",
+			status:      release.StatusPendingUpgrade,
+			startedAt:   time.Now(),
+			expected:    false,
+			wantStatus:  release.StatusPendingUpgrade,
+			wantLocked:  true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			rel := &release.Release{
+				Name:    "testapp",
+				Version: 2,
+				Info: &release.Info{
+					Status:        tc.status,
+					FirstDeployed: helmTime.Time{Time: time.Now().Add(-time.Hour)},
+					LastDeployed:  helmTime.Time{Time: tc.startedAt},
+				},
+			}
+			cfg := &action.Configuration{Releases: storage.Init(driver.NewMemory())}
+			require.Nil(t, cfg.Releases.Create(rel))
+			c := &HelmClient{cfg: cfg, log: log.Discard()}
+
+			ok, err := c.isHelmChartStatusActionable(lastReleaseStatus, "testapp", helmStatusActionMapUpdate)
+			require.Equal(t, tc.wantLocked, IsReleaseLocked(err))
+			if !tc.wantLocked {
+				require.Nil(t, err)
+			}
+			require.Equal(t, tc.expected, ok)
+
+			last, err := cfg.Releases.Last("testapp")
+			require.Nil(t, err)
+			require.Equal(t, tc.wantStatus, last.Info.Status)
+		})
+	}
+}
+
+func TestRepairChart(t *testing.T) {
+	tests := []struct {
+		description string
+		status      release.Status
+		wantStatus  release.Status
+	}{
+		{
+			description: "pending install",
+			status:      release.StatusPendingInstall,
+			wantStatus:  release.StatusFailed,
+		},
+		{
+			description: "pending rollback",
+			status:      release.StatusPendingRollback,
+			wantStatus:  release.StatusDeployed,
+		},
+		{
+			description: "failed release is not changed",
+			status:      release.StatusFailed,
+			wantStatus:  release.StatusFailed,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.description, func(t *testing.T) {
+			rel := &release.Release{
+				Name:    "testapp",
+				Version: 1,
+				Info: &release.Info{
+					Status:       tc.status,
+					LastDeployed: helmTime.Time{Time: time.Now()},
+				},
+			}
+			cfg := &action.Configuration{Releases: storage.Init(driver.NewMemory())}
+			require.Nil(t, cfg.Releases.Create(rel))
+			c := &HelmClient{cfg: cfg, log: log.Discard(), statusFunc: lastReleaseStatus}
+
+			require.Nil(t, c.RepairChart("testapp"))
+
+			last, err := cfg.Releases.Last("testapp")
+			require.Nil(t, err)
+			require.Equal(t, tc.wantStatus, last.Info.Status)
+		})
+	}
+}
+
+func TestReleaseRevision(t *testing.T) {
+	cfg := &action.Configuration{Releases: storage.Init(driver.NewMemory())}
+	statusFunc := func(cfg *action.Configuration, appName string) (*release.Release, release.Status, error) {
+		r, err := cfg.Releases.Last(appName)
+		if errors.Is(err, driver.ErrReleaseNotFound) {
+			return nil, notFound, nil
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		return r, r.Info.Status, nil
+	}
+	c := &HelmClient{cfg: cfg, log: log.Discard(), statusFunc: statusFunc}
+	revision, err := c.ReleaseRevision("testapp")
+	require.Nil(t, err)
+	require.Equal(t, "", revision)
+
+	for version, status := range []release.Status{release.StatusSuperseded, release.StatusDeployed} {
+		require.Nil(t, cfg.Releases.Create(&release.Release{Name: "testapp", Version: version + 1, Info: &release.Info{Status: status}}))
+	}
+	revision, err = c.ReleaseRevision("testapp")
+	require.Nil(t, err)
+	require.Equal(t, "2", revision)
+
+	require.Nil(t, cfg.Releases.Create(&release.Release{Name: "testapp", Version: 3, Info: &release.Info{Status: release.StatusUninstalled}}))
+	revision, err = c.ReleaseRevision("testapp")
+	require.Nil(t, err)
+	require.Equal(t, "", revision)
+}
+
+func TestIsReleaseLocked(t *testing.T) {
+	require.False(t, IsReleaseLocked(nil))
+	require.False(t, IsReleaseLocked(errors.New("release not found")))
+	require.True(t, IsReleaseLocked(fmt.Errorf("failed to update helm chart: %w", ReleaseLockedError{AppName: "testapp"})))
+	require.True(t, IsReleaseLocked(errors.New("UPGRADE FAILED: another operation (install/upgrade/rollback) is in progress")))
+}
+
+func TestManifestHash(t *testing.T) {
+	c := newApplyRecorder()
+	config := ChartConfig{Version: "v0.0.1", AppName: "hello"}
+	hashWithStorage := func(engine string, storage HelmStorage, values map[string]interface{}) string {
+		h, err := ManifestHash(testChart{values: values}, config, engine, storage, "gke", c, log.Discard())
+		require.Nil(t, err)
+		return h
+	}
+	hash := func(engine string, values map[string]interface{}) string {
+		return hashWithStorage(engine, HelmStorage{}, values)
+	}
+	h := hash(HelmEngine, map[string]interface{}{"version": 1})
+	require.Len(t, h, 64)
+	require.Equal(t, h, hash(HelmEngine, map[string]interface{}{"version": 1}))
+	require.NotEqual(t, h, hash(HelmEngine, map[string]interface{}{"version": 1, "service": true}))
+	require.NotEqual(t, h, hash(ServerSideApplyEngine, map[string]interface{}{"version": 1}))
+
+	values := map[string]interface{}{"version": 1}
+	require.Equal(t, h, hashWithStorage(HelmEngine, HelmStorage{Driver: SecretStorageDriver, Namespace: "gke"}, values))
+	require.NotEqual(t, h, hashWithStorage(HelmEngine, HelmStorage{Driver: ConfigMapStorageDriver}, values))
+	require.NotEqual(t, h, hashWithStorage(HelmEngine, HelmStorage{Namespace: "ketch-releases"}, values))
+	// server-side apply doesn't keep releases.
+	require.Equal(t, hash(ServerSideApplyEngine, values), hashWithStorage(ServerSideApplyEngine, HelmStorage{Namespace: "ketch-releases"}, values))
+}
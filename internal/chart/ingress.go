@@ -2,9 +2,12 @@ package chart
 
 import (
 	"fmt"
+	"reflect"
 	"regexp"
+	"time"
 
 	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 
 	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
 )
@@ -17,12 +20,28 @@ const (
 	certManager sslCertificateManager = "cert-manager"
 )
 
+// httpEndpoint holds configuration of a http endpoint.
+type httpEndpoint struct {
+	// Cname of this endpoint, it can be a wildcard like "*.example.com".
+	Cname string `json:"cname"`
+	// Path is an optional path prefix, only requests with the prefix are routed to the app.
+	Path string `json:"path,omitempty"`
+	// DNSAnnotations are external-dns annotations of the cname.
+	DNSAnnotations map[string]string `json:"dnsAnnotations,omitempty"`
+	// Auth protects the cname.
+	Auth *endpointAuth `json:"auth,omitempty"`
+	// Allowlist restricts clients of the cname.
+	Allowlist *endpointAllowlist `json:"allowlist,omitempty"`
+}
+
 // httpsEndpoint holds configuration of a https endpoint.
 type httpsEndpoint struct {
 	// UniqueName is a unique and deterministic identifier that can be used to name a k8s resource for this https endpoint.
 	UniqueName string `json:"uniqueName"`
-	// Cname of this endpoint.
+	// Cname of this endpoint, it can be a wildcard like "*.example.com".
 	Cname string `json:"cname"`
+	// Path is an optional path prefix, only requests with the prefix are routed to the app.
+	Path string `json:"path,omitempty"`
 	// SecretName is a name of a k8s Secret containing an SSL certificate for the cname.
 	// If the ManagedBy field is "cert-manager",
 	// then cert-manager will use this name to store a Lets Encrypt certificate.
@@ -31,66 +50,359 @@ type httpsEndpoint struct {
 	SecretName string `json:"secretName"`
 	// ManagedBy specifies who is responsible for getting an SSL certificate and storing it in the secret.
 	ManagedBy sslCertificateManager `json:"managedBy"`
+	// Certificate configures the cert-manager Certificate of the cname, it's set when ManagedBy is "cert-manager".
+	Certificate *certificate `json:"certificate,omitempty"`
+	// DNSAnnotations are external-dns annotations of the cname.
+	DNSAnnotations map[string]string `json:"dnsAnnotations,omitempty"`
+	// Auth protects the cname.
+	Auth *endpointAuth `json:"auth,omitempty"`
+	// Allowlist restricts clients of the cname.
+	Allowlist *endpointAllowlist `json:"allowlist,omitempty"`
+}
+
+// endpointAuth is an auth of an endpoint with a unique name of objects enforcing it, like traefik middlewares.
+type endpointAuth struct {
+	ketchv1.AuthSpec
+	Name string `json:"name"`
+}
+
+func newEndpointAuth(auth *ketchv1.AuthSpec, name string) *endpointAuth {
+	if auth == nil {
+		return nil
+	}
+	return &endpointAuth{AuthSpec: *auth, Name: name}
+}
+
+// endpointAllowlist is a list of CIDRs of clients allowed to reach an endpoint
+// with a unique name of objects enforcing it, like traefik middlewares.
+type endpointAllowlist struct {
+	SourceRange []string `json:"sourceRange"`
+	Name        string   `json:"name"`
+}
+
+func newEndpointAllowlist(cidrs []string, name string) *endpointAllowlist {
+	if len(cidrs) == 0 {
+		return nil
+	}
+	return &endpointAllowlist{SourceRange: cidrs, Name: name}
+}
+
+// certificate holds the issuer and the ACME solver cert-manager obtains a certificate with.
+type certificate struct {
+	IssuerName string             `json:"issuerName"`
+	IssuerKind ketchv1.IssuerKind `json:"issuerKind"`
+	// Solver is "dns01" for wildcard cnames.
+	Solver ketchv1.ACMESolverType `json:"solver"`
+	// SolverLabels are labels of the Certificate selecting the issuer's solver.
+	SolverLabels map[string]string `json:"solverLabels,omitempty"`
 }
 
 // Ingress contains information about entrypoints of an application.
 // istio, traefik and nginx templates use "ingress" to render Kubernetes Ingress objects.
 type ingress struct {
 
-	// Https is a list of http entrypoints.
-	Http []string `json:"http"`
+	// Http is a list of http entrypoints.
+	Http []httpEndpoint `json:"http"`
 
 	// Https is a list of https entrypoints.
 	Https []httpsEndpoint `json:"https"`
+
+	// Policy limits incoming requests, each template translates it to its controller's annotations or CRDs.
+	Policy *ingressPolicy `json:"policy,omitempty"`
+
+	// HttpDNSAnnotations are external-dns annotations of objects serving all http entrypoints.
+	HttpDNSAnnotations map[string]string `json:"httpDNSAnnotations,omitempty"`
+
+	// HttpsDNSAnnotations are external-dns annotations of objects serving all https entrypoints.
+	HttpsDNSAnnotations map[string]string `json:"httpsDNSAnnotations,omitempty"`
+
+	// HttpAuth protects objects serving all http entrypoints, it's set only for nginx.
+	HttpAuth *ketchv1.AuthSpec `json:"httpAuth,omitempty"`
+
+	// HttpsAuth protects objects serving all https entrypoints, it's set only for nginx.
+	HttpsAuth *ketchv1.AuthSpec `json:"httpsAuth,omitempty"`
+
+	// HttpAllowedCIDRs restricts clients of objects serving all http entrypoints, it's set only for nginx.
+	HttpAllowedCIDRs []string `json:"httpAllowedCIDRs,omitempty"`
+
+	// HttpsAllowedCIDRs restricts clients of objects serving all https entrypoints, it's set only for nginx.
+	HttpsAllowedCIDRs []string `json:"httpsAllowedCIDRs,omitempty"`
 }
 
-func newIngress(app ketchv1.App, ingressController ketchv1.IngressControllerSpec) (*ingress, error) {
+// dnsGroup collects external-dns annotations of cnames served by one ingress object.
+// external-dns applies annotations of an object to all its hosts, so the cnames must have the same annotations.
+type dnsGroup struct {
+	cname       string
+	annotations map[string]string
+}
 
-	// CNAMEs contain only:
-	// A to Z ; upper case characters
-	// a to z ; lower case characters
-	// 0 to 9 ; numeric characters 0 to 9
-	// - ; dash
-	// Max length of a cname is 63 characters.
-	// so here we are transforming each CNAME in a way that we can use them to name k8s resources.
-	regex := regexp.MustCompile("[^a-z0-9]+")
+func (g *dnsGroup) add(cname string, annotations map[string]string) error {
+	if g.cname == "" {
+		g.cname, g.annotations = cname, annotations
+		return nil
+	}
+	if !reflect.DeepEqual(g.annotations, annotations) {
+		return fmt.Errorf("cnames %s and %s are served by the same ingress object and must have the same dns settings", g.cname, cname)
+	}
+	return nil
+}
+
+// nginxGroup collects auth and allowed CIDRs of cnames served by one nginx ingress, annotations of the ingress apply
+// to all its hosts, so the cnames must have the same settings. traefik and istio apply them to each cname on its own.
+type nginxGroup struct {
+	cname        string
+	auth         *ketchv1.AuthSpec
+	allowedCIDRs []string
+}
+
+func (g *nginxGroup) add(cname string, auth *ketchv1.AuthSpec, allowedCIDRs []string) error {
+	if g.cname == "" {
+		g.cname, g.auth, g.allowedCIDRs = cname, auth, allowedCIDRs
+		return nil
+	}
+	if !reflect.DeepEqual(g.auth, auth) {
+		return fmt.Errorf("cnames %s and %s are served by the same ingress object and must have the same auth settings", g.cname, cname)
+	}
+	if !reflect.DeepEqual(g.allowedCIDRs, allowedCIDRs) {
+		return fmt.Errorf("cnames %s and %s are served by the same ingress object and must have the same allowed cidrs", g.cname, cname)
+	}
+	return nil
+}
+
+// checkAuth returns an error if the ingress controller can't enforce the auth of the cname.
+func checkAuth(controller ketchv1.IngressControllerType, cname string, auth *ketchv1.AuthSpec) error {
+	if auth == nil {
+		return nil
+	}
+	if err := auth.Validate(); err != nil {
+		return fmt.Errorf("auth of %s: %w", cname, err)
+	}
+	istio := controller == ketchv1.IstioIngressControllerType
+	if istio && auth.OIDC == nil {
+		return fmt.Errorf("auth of %s: istio supports only oidc auth", cname)
+	}
+	if !istio && auth.OIDC != nil {
+		return fmt.Errorf("auth of %s: %s doesn't support oidc auth, use a forward auth url of an oidc proxy instead", cname, controller)
+	}
+	return nil
+}
+
+// ingressPolicy is a controller-neutral form of ketch.yaml's ingressPolicy with values converted to plain numbers.
+type ingressPolicy struct {
+	RateLimit             *ingressRateLimit `json:"rateLimit,omitempty"`
+	MaxBodySizeBytes      int64             `json:"maxBodySizeBytes,omitempty"`
+	TimeoutSeconds        int64             `json:"timeoutSeconds,omitempty"`
+	ConnectTimeoutSeconds int64             `json:"connectTimeoutSeconds,omitempty"`
+}
 
-	var http []string
+type ingressRateLimit struct {
+	RequestsPerSecond int `json:"requestsPerSecond"`
+	Burst             int `json:"burst"`
+}
+
+// CNAMEs contain only:
+// A to Z ; upper case characters
+// a to z ; lower case characters
+// 0 to 9 ; numeric characters 0 to 9
+// - ; dash
+// Max length of a cname is 63 characters.
+// so here we are transforming each CNAME in a way that we can use them to name k8s resources.
+var cnameRegex = regexp.MustCompile("[^a-z0-9]+")
+
+// CnameSecretName returns a name of the secret cert-manager issues a certificate of the cname to
+// when the cname doesn't use a secret of its own.
+func CnameSecretName(appName string, cname ketchv1.Cname) string {
+	return fmt.Sprintf("%s-cname-%s", appName, cnameRegex.ReplaceAllString(cname.Address(), "-"))
+}
+
+func newIngress(app ketchv1.App, ingressController ketchv1.IngressControllerSpec) (*ingress, error) {
+	var http []httpEndpoint
 	var https []httpsEndpoint
 
+	// nginx serves http and https cnames with an ingress of each scheme, istio serves all cnames with one gateway
+	// and traefik serves https cnames with an ingress route of each cname.
+	var httpDNS, httpsDNS dnsGroup
+	httpsGroup := &httpsDNS
+	switch ingressController.IngressType {
+	case ketchv1.IstioIngressControllerType:
+		httpsGroup = &httpDNS
+	case ketchv1.TraefikIngressControllerType:
+		httpsGroup = nil
+	}
+
+	// only nginx serves several cnames with one set of auth and allowlist annotations.
+	var httpNginx, httpsNginx *nginxGroup
+	if ingressController.IngressType == ketchv1.NginxIngressControllerType {
+		httpNginx, httpsNginx = &nginxGroup{}, &nginxGroup{}
+	}
+
+	forceHTTPS := app.Spec.Ingress.HTTPSForced()
 	for _, cname := range app.Spec.Ingress.Cnames {
+		// https endpoints redirect http requests to https.
+		cname.Secure = cname.Secure || forceHTTPS
+		dnsAnnotations := ingressController.ExternalDNSAnnotations(cname)
+		auth := app.Spec.Ingress.CnameAuth(cname)
+		if err := checkAuth(ingressController.IngressType, cname.Address(), auth); err != nil {
+			return nil, err
+		}
+		allowedCIDRs := app.Spec.Ingress.CnameAllowedCIDRs(cname)
+		if err := ketchv1.ValidateCIDRs(allowedCIDRs); err != nil {
+			return nil, fmt.Errorf("allowed cidrs of %s: %w", cname.Address(), err)
+		}
+		strippedCname := cnameRegex.ReplaceAllString(cname.Address(), "-")
 		if !cname.Secure {
-			http = append(http, cname.Name)
+			if err := httpDNS.add(cname.Address(), dnsAnnotations); err != nil {
+				return nil, err
+			}
+			if httpNginx != nil {
+				if err := httpNginx.add(cname.Address(), auth, allowedCIDRs); err != nil {
+					return nil, err
+				}
+			}
+			http = append(http, httpEndpoint{
+				Cname:          cname.Name,
+				Path:           cname.Path,
+				DNSAnnotations: dnsAnnotations,
+				Auth:           newEndpointAuth(auth, fmt.Sprintf("%s-http-%s-auth", app.Name, strippedCname)),
+				Allowlist:      newEndpointAllowlist(allowedCIDRs, fmt.Sprintf("%s-http-%s-allowlist", app.Name, strippedCname)),
+			})
 			continue
 		}
+		if httpsGroup != nil {
+			if err := httpsGroup.add(cname.Address(), dnsAnnotations); err != nil {
+				return nil, err
+			}
+		}
+		if httpsNginx != nil {
+			if err := httpsNginx.add(cname.Address(), auth, allowedCIDRs); err != nil {
+				return nil, err
+			}
+		}
 
-		if len(ingressController.ClusterIssuer) == 0 {
+		issuerRef := ingressController.IssuerRef()
+		if cname.NeedsClusterIssuer() && issuerRef == nil {
 			return nil, errors.New("secure cnames require a Ingress.ClusterIssuer to be specified")
 		}
 
-		strippedCname := regex.ReplaceAllString(cname.Name, "-")
+		uniqueName := fmt.Sprintf("%s-https-%s", app.Name, strippedCname)
 		if len(cname.SecretName) > 0 {
 			https = append(https, httpsEndpoint{
-				Cname:      cname.Name,
-				SecretName: cname.SecretName,
-				UniqueName: fmt.Sprintf("%s-https-%s", app.Name, strippedCname),
-				ManagedBy:  user,
+				Cname:          cname.Name,
+				Path:           cname.Path,
+				SecretName:     cname.SecretName,
+				UniqueName:     uniqueName,
+				ManagedBy:      user,
+				DNSAnnotations: dnsAnnotations,
+				Auth:           newEndpointAuth(auth, uniqueName+"-auth"),
+				Allowlist:      newEndpointAllowlist(allowedCIDRs, uniqueName+"-allowlist"),
 			})
 		} else {
 			https = append(https, httpsEndpoint{
-				Cname:      cname.Name,
-				SecretName: fmt.Sprintf("%s-cname-%s", app.Name, strippedCname),
-				UniqueName: fmt.Sprintf("%s-https-%s", app.Name, strippedCname),
-				ManagedBy:  certManager,
+				Cname:          cname.Name,
+				Path:           cname.Path,
+				SecretName:     CnameSecretName(app.Name, cname),
+				UniqueName:     uniqueName,
+				ManagedBy:      certManager,
+				Certificate:    newCertificate(*issuerRef, ingressController, cname.Name),
+				DNSAnnotations: dnsAnnotations,
+				Auth:           newEndpointAuth(auth, uniqueName+"-auth"),
+				Allowlist:      newEndpointAllowlist(allowedCIDRs, uniqueName+"-allowlist"),
 			})
 		}
 	}
 	defaultCname := app.DefaultCname()
 	if defaultCname != nil {
-		http = append(http, *defaultCname)
+		auth, allowedCIDRs := app.Spec.Ingress.Auth, app.Spec.Ingress.AllowedCIDRs
+		if err := checkAuth(ingressController.IngressType, *defaultCname, auth); err != nil {
+			return nil, err
+		}
+		if err := ketchv1.ValidateCIDRs(allowedCIDRs); err != nil {
+			return nil, fmt.Errorf("allowed cidrs of %s: %w", *defaultCname, err)
+		}
+		if httpNginx != nil {
+			if err := httpNginx.add(*defaultCname, auth, allowedCIDRs); err != nil {
+				return nil, err
+			}
+		}
+		strippedCname := cnameRegex.ReplaceAllString(*defaultCname, "-")
+		http = append(http, httpEndpoint{
+			Cname:     *defaultCname,
+			Auth:      newEndpointAuth(auth, fmt.Sprintf("%s-http-%s-auth", app.Name, strippedCname)),
+			Allowlist: newEndpointAllowlist(allowedCIDRs, fmt.Sprintf("%s-http-%s-allowlist", app.Name, strippedCname)),
+		})
+	}
+	result := &ingress{
+		Http:               http,
+		Https:              https,
+		HttpDNSAnnotations: httpDNS.annotations,
+	}
+	if httpsGroup != nil {
+		result.HttpsDNSAnnotations = httpsGroup.annotations
+	}
+	if httpNginx != nil {
+		result.HttpAuth, result.HttpsAuth = httpNginx.auth, httpsNginx.auth
+		result.HttpAllowedCIDRs, result.HttpsAllowedCIDRs = httpNginx.allowedCIDRs, httpsNginx.allowedCIDRs
 	}
-	return &ingress{
-		Http:  http,
-		Https: https,
-	}, nil
+	return result, nil
+}
+
+func newCertificate(issuerRef ketchv1.IssuerRef, ingressController ketchv1.IngressControllerSpec, cname string) *certificate {
+	solver, labels := ingressController.CertificateSolver(cname)
+	return &certificate{
+		IssuerName:   issuerRef.Name,
+		IssuerKind:   issuerRef.Kind,
+		Solver:       solver,
+		SolverLabels: labels,
+	}
+}
+
+// newIngressPolicy validates the policy of ketch.yaml and converts it to the form used by templates.
+func newIngressPolicy(policy *ketchv1.KetchYamlIngressPolicy) (*ingressPolicy, error) {
+	if policy == nil {
+		return nil, nil
+	}
+	result := ingressPolicy{}
+	if policy.RateLimit != nil {
+		if policy.RateLimit.RequestsPerSecond <= 0 || policy.RateLimit.Burst < 0 {
+			return nil, errors.New("ingressPolicy.rateLimit: requestsPerSecond must be positive and burst must not be negative")
+		}
+		result.RateLimit = &ingressRateLimit{
+			RequestsPerSecond: policy.RateLimit.RequestsPerSecond,
+			Burst:             policy.RateLimit.Burst,
+		}
+		if result.RateLimit.Burst < result.RateLimit.RequestsPerSecond {
+			result.RateLimit.Burst = result.RateLimit.RequestsPerSecond
+		}
+	}
+	if len(policy.MaxBodySize) > 0 {
+		size, err := resource.ParseQuantity(policy.MaxBodySize)
+		if err != nil || size.Value() <= 0 {
+			return nil, fmt.Errorf("ingressPolicy.maxBodySize: invalid size %q", policy.MaxBodySize)
+		}
+		result.MaxBodySizeBytes = size.Value()
+	}
+	var err error
+	if result.TimeoutSeconds, err = policySeconds("timeout", policy.Timeout); err != nil {
+		return nil, err
+	}
+	if result.ConnectTimeoutSeconds, err = policySeconds("connectTimeout", policy.ConnectTimeout); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// policySeconds parses a duration and rounds it up to whole seconds, the precision all ingress controllers support.
+func policySeconds(field, value string) (int64, error) {
+	if len(value) == 0 {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("ingressPolicy.%s: invalid duration %q", field, value)
+	}
+	return ceilSeconds(d), nil
+}
+
+func ceilSeconds(d time.Duration) int64 {
+	return int64((d + time.Second - 1) / time.Second)
 }
@@ -12,11 +12,16 @@ import (
 
 func TestNewIngress(t *testing.T) {
 	tests := []struct {
-		name          string
-		cnames        ketchv1.CnameList
-		clusterIssuer string
-		expected      *ingress
-		expectedError error
+		name              string
+		cnames            ketchv1.CnameList
+		clusterIssuer     string
+		certificateIssuer *ketchv1.CertificateIssuerSpec
+		externalDNS       *ketchv1.ExternalDNSSpec
+		controllerType    ketchv1.IngressControllerType
+		auth              *ketchv1.AuthSpec
+		forceHTTPS        bool
+		expected          *ingress
+		expectedError     error
 	}{
 		{
 			name: "happy",
@@ -27,9 +32,9 @@ func TestNewIngress(t *testing.T) {
 			},
 			clusterIssuer: "test-cluster-issuer",
 			expected: &ingress{
-				Http: []string{"a.name"},
+				Http: []httpEndpoint{{Cname: "a.name"}},
 				Https: []httpsEndpoint{
-					{Cname: "b.name", SecretName: "my-app-cname-b-name", UniqueName: "my-app-https-b-name", ManagedBy: certManager},
+					{Cname: "b.name", SecretName: "my-app-cname-b-name", UniqueName: "my-app-https-b-name", ManagedBy: certManager, Certificate: &certificate{IssuerName: "test-cluster-issuer", IssuerKind: ketchv1.ClusterIssuerKind, Solver: ketchv1.HTTP01SolverType}},
 					{Cname: "c.name", SecretName: "c-ssl", UniqueName: "my-app-https-c-name", ManagedBy: user},
 				},
 			},
@@ -45,9 +50,178 @@ func TestNewIngress(t *testing.T) {
 				},
 			},
 			expected: &ingress{
-				Http: []string{"a.name", "b.name"},
+				Http: []httpEndpoint{{Cname: "a.name"}, {Cname: "b.name"}},
 			},
 		},
+		{
+			name: "wildcards and path prefixes",
+			cnames: ketchv1.CnameList{
+				{Name: "*.apps.name"},
+				{Name: "a.name", Path: "/api"},
+				{Name: "*.apps.name", Secure: true, Path: "/admin"},
+			},
+			clusterIssuer: "test-cluster-issuer",
+			expected: &ingress{
+				Http: []httpEndpoint{{Cname: "*.apps.name"}, {Cname: "a.name", Path: "/api"}},
+				Https: []httpsEndpoint{
+					{Cname: "*.apps.name", Path: "/admin", SecretName: "my-app-cname--apps-name-admin", UniqueName: "my-app-https--apps-name-admin", ManagedBy: certManager, Certificate: &certificate{IssuerName: "test-cluster-issuer", IssuerKind: ketchv1.ClusterIssuerKind, Solver: ketchv1.DNS01SolverType}},
+				},
+			},
+		},
+		{
+			name: "secure cname with a secret, no cluster issuer",
+			cnames: ketchv1.CnameList{
+				{Name: "a.name", Secure: true, SecretName: "a-ssl"},
+			},
+			expected: &ingress{
+				Https: []httpsEndpoint{
+					{Cname: "a.name", SecretName: "a-ssl", UniqueName: "my-app-https-a-name", ManagedBy: user},
+				},
+			},
+		},
+		{
+			name: "force https",
+			cnames: ketchv1.CnameList{
+				{Name: "a.name"},
+				{Name: "b.name", Secure: true, SecretName: "b-ssl"},
+			},
+			forceHTTPS:    true,
+			clusterIssuer: "test-cluster-issuer",
+			expected: &ingress{
+				Https: []httpsEndpoint{
+					{Cname: "a.name", SecretName: "my-app-cname-a-name", UniqueName: "my-app-https-a-name", ManagedBy: certManager, Certificate: &certificate{IssuerName: "test-cluster-issuer", IssuerKind: ketchv1.ClusterIssuerKind, Solver: ketchv1.HTTP01SolverType}},
+					{Cname: "b.name", SecretName: "b-ssl", UniqueName: "my-app-https-b-name", ManagedBy: user},
+				},
+			},
+		},
+		{
+			name: "namespaced issuer with solver labels",
+			cnames: ketchv1.CnameList{
+				{Name: "a.name", Secure: true},
+				{Name: "*.apps.name", Secure: true},
+			},
+			clusterIssuer: "test-cluster-issuer",
+			certificateIssuer: &ketchv1.CertificateIssuerSpec{
+				IssuerRef: ketchv1.IssuerRef{Name: "letsencrypt", Kind: ketchv1.NamespacedIssuerKind},
+				SolverLabels: map[ketchv1.ACMESolverType]map[string]string{
+					ketchv1.DNS01SolverType: {"acme-solver": "route53"},
+				},
+			},
+			expected: &ingress{
+				Https: []httpsEndpoint{
+					{
+						Cname: "a.name", SecretName: "my-app-cname-a-name", UniqueName: "my-app-https-a-name", ManagedBy: certManager,
+						Certificate: &certificate{IssuerName: "letsencrypt", IssuerKind: ketchv1.NamespacedIssuerKind, Solver: ketchv1.HTTP01SolverType},
+					},
+					{
+						Cname: "*.apps.name", SecretName: "my-app-cname--apps-name", UniqueName: "my-app-https--apps-name", ManagedBy: certManager,
+						Certificate: &certificate{IssuerName: "letsencrypt", IssuerKind: ketchv1.NamespacedIssuerKind, Solver: ketchv1.DNS01SolverType, SolverLabels: map[string]string{"acme-solver": "route53"}},
+					},
+				},
+			},
+		},
+		{
+			name: "external-dns annotations",
+			cnames: ketchv1.CnameList{
+				{Name: "a.name"},
+				{Name: "b.name", Secure: true, SecretName: "b-ssl", DNS: &ketchv1.CnameDNS{Target: "lb.name", TTL: 60}},
+			},
+			externalDNS: &ketchv1.ExternalDNSSpec{Target: "ingress.name"},
+			expected: &ingress{
+				Http: []httpEndpoint{
+					{Cname: "a.name", DNSAnnotations: map[string]string{"external-dns.alpha.kubernetes.io/target": "ingress.name"}},
+				},
+				Https: []httpsEndpoint{
+					{
+						Cname: "b.name", SecretName: "b-ssl", UniqueName: "my-app-https-b-name", ManagedBy: user,
+						DNSAnnotations: map[string]string{"external-dns.alpha.kubernetes.io/target": "lb.name", "external-dns.alpha.kubernetes.io/ttl": "60"},
+					},
+				},
+				HttpDNSAnnotations:  map[string]string{"external-dns.alpha.kubernetes.io/target": "ingress.name"},
+				HttpsDNSAnnotations: map[string]string{"external-dns.alpha.kubernetes.io/target": "lb.name", "external-dns.alpha.kubernetes.io/ttl": "60"},
+			},
+		},
+		{
+			name: "sad - cnames of one ingress with different dns settings",
+			cnames: ketchv1.CnameList{
+				{Name: "a.name"},
+				{Name: "b.name", DNS: &ketchv1.CnameDNS{TTL: 60}},
+			},
+			externalDNS:   &ketchv1.ExternalDNSSpec{Target: "ingress.name"},
+			expectedError: errors.New("cnames a.name and b.name are served by the same ingress object and must have the same dns settings"),
+		},
+		{
+			name: "nginx auth",
+			cnames: ketchv1.CnameList{
+				{Name: "a.name"},
+				{Name: "b.name", Secure: true, SecretName: "b-ssl", Auth: &ketchv1.AuthSpec{ForwardAuthURL: "https://auth.name"}},
+			},
+			controllerType: ketchv1.NginxIngressControllerType,
+			auth:           &ketchv1.AuthSpec{BasicAuthSecret: "users"},
+			expected: &ingress{
+				Http: []httpEndpoint{
+					{Cname: "a.name", Auth: &endpointAuth{AuthSpec: ketchv1.AuthSpec{BasicAuthSecret: "users"}, Name: "my-app-http-a-name-auth"}},
+				},
+				Https: []httpsEndpoint{
+					{
+						Cname: "b.name", SecretName: "b-ssl", UniqueName: "my-app-https-b-name", ManagedBy: user,
+						Auth: &endpointAuth{AuthSpec: ketchv1.AuthSpec{ForwardAuthURL: "https://auth.name"}, Name: "my-app-https-b-name-auth"},
+					},
+				},
+				HttpAuth:  &ketchv1.AuthSpec{BasicAuthSecret: "users"},
+				HttpsAuth: &ketchv1.AuthSpec{ForwardAuthURL: "https://auth.name"},
+			},
+		},
+		{
+			name: "sad - cnames of one nginx ingress with different auth",
+			cnames: ketchv1.CnameList{
+				{Name: "a.name"},
+				{Name: "b.name", Auth: &ketchv1.AuthSpec{ForwardAuthURL: "https://auth.name"}},
+			},
+			controllerType: ketchv1.NginxIngressControllerType,
+			expectedError:  errors.New("cnames a.name and b.name are served by the same ingress object and must have the same auth settings"),
+		},
+		{
+			name: "sad - cnames of one nginx ingress with different allowed cidrs",
+			cnames: ketchv1.CnameList{
+				{Name: "a.name", Secure: true, SecretName: "a-ssl", AllowedCIDRs: []string{"10.0.0.0/8"}},
+				{Name: "b.name", Secure: true, SecretName: "b-ssl"},
+			},
+			controllerType: ketchv1.NginxIngressControllerType,
+			expectedError:  errors.New("cnames a.name and b.name are served by the same ingress object and must have the same allowed cidrs"),
+		},
+		{
+			name:          "sad - invalid allowed cidrs",
+			cnames:        ketchv1.CnameList{{Name: "a.name", AllowedCIDRs: []string{"10.0.0.1"}}},
+			expectedError: errors.New(`allowed cidrs of a.name: invalid cidr "10.0.0.1"`),
+		},
+		{
+			name: "traefik protects each cname on its own",
+			cnames: ketchv1.CnameList{
+				{Name: "a.name"},
+				{Name: "b.name", Auth: &ketchv1.AuthSpec{ForwardAuthURL: "https://auth.name"}},
+			},
+			controllerType: ketchv1.TraefikIngressControllerType,
+			expected: &ingress{
+				Http: []httpEndpoint{
+					{Cname: "a.name"},
+					{Cname: "b.name", Auth: &endpointAuth{AuthSpec: ketchv1.AuthSpec{ForwardAuthURL: "https://auth.name"}, Name: "my-app-http-b-name-auth"}},
+				},
+			},
+		},
+		{
+			name:           "sad - basic auth with istio",
+			cnames:         ketchv1.CnameList{{Name: "a.name"}},
+			controllerType: ketchv1.IstioIngressControllerType,
+			auth:           &ketchv1.AuthSpec{BasicAuthSecret: "users"},
+			expectedError:  errors.New("auth of a.name: istio supports only oidc auth"),
+		},
+		{
+			name:           "sad - oidc with nginx",
+			cnames:         ketchv1.CnameList{{Name: "a.name", Auth: &ketchv1.AuthSpec{OIDC: &ketchv1.OIDCAuth{Issuer: "https://accounts.name"}}}},
+			controllerType: ketchv1.NginxIngressControllerType,
+			expectedError:  errors.New("auth of a.name: nginx doesn't support oidc auth, use a forward auth url of an oidc proxy instead"),
+		},
 		{
 			name: "sad - no cluster issuer",
 			cnames: ketchv1.CnameList{
@@ -68,10 +242,12 @@ func TestNewIngress(t *testing.T) {
 				Spec: ketchv1.AppSpec{
 					Ingress: ketchv1.IngressSpec{
 						Cnames: tt.cnames,
+						Auth:   tt.auth,
 					},
 				},
 			}
-			ingressController := ketchv1.IngressControllerSpec{ClusterIssuer: tt.clusterIssuer}
+			ingressController := ketchv1.IngressControllerSpec{ClusterIssuer: tt.clusterIssuer, CertificateIssuer: tt.certificateIssuer, ExternalDNS: tt.externalDNS, IngressType: tt.controllerType}
+			app.Spec.Ingress.Controller.ForceHTTPS = tt.forceHTTPS
 			issuer, err := newIngress(app, ingressController)
 			if tt.expectedError != nil {
 				require.EqualError(t, err, tt.expectedError.Error())
@@ -81,3 +257,62 @@ func TestNewIngress(t *testing.T) {
 		})
 	}
 }
+
+func TestNewIngressPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  *ketchv1.KetchYamlIngressPolicy
+		want    *ingressPolicy
+		wantErr string
+	}{
+		{
+			name: "no policy",
+		},
+		{
+			name: "all limits",
+			policy: &ketchv1.KetchYamlIngressPolicy{
+				RateLimit:      &ketchv1.KetchYamlRateLimit{RequestsPerSecond: 10, Burst: 20},
+				MaxBodySize:    "1Mi",
+				Timeout:        "30s",
+				ConnectTimeout: "100ms",
+			},
+			want: &ingressPolicy{
+				RateLimit:             &ingressRateLimit{RequestsPerSecond: 10, Burst: 20},
+				MaxBodySizeBytes:      1048576,
+				TimeoutSeconds:        30,
+				ConnectTimeoutSeconds: 1,
+			},
+		},
+		{
+			name:   "burst defaults to the rate",
+			policy: &ketchv1.KetchYamlIngressPolicy{RateLimit: &ketchv1.KetchYamlRateLimit{RequestsPerSecond: 5}},
+			want:   &ingressPolicy{RateLimit: &ingressRateLimit{RequestsPerSecond: 5, Burst: 5}},
+		},
+		{
+			name:    "invalid rate",
+			policy:  &ketchv1.KetchYamlIngressPolicy{RateLimit: &ketchv1.KetchYamlRateLimit{}},
+			wantErr: "ingressPolicy.rateLimit: requestsPerSecond must be positive and burst must not be negative",
+		},
+		{
+			name:    "invalid size",
+			policy:  &ketchv1.KetchYamlIngressPolicy{MaxBodySize: "big"},
+			wantErr: `ingressPolicy.maxBodySize: invalid size "big"`,
+		},
+		{
+			name:    "invalid timeout",
+			policy:  &ketchv1.KetchYamlIngressPolicy{Timeout: "30"},
+			wantErr: `ingressPolicy.timeout: invalid duration "30"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newIngressPolicy(tt.policy)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -18,15 +18,17 @@ var (
 			Generation: 1,
 		},
 		Spec: ketchv1.JobSpec{
-			Version:      "v1",
-			Type:         "Job",
-			Name:         "testjob",
-			Namespace:    "mynamespace",
-			Description:  "this is a test",
-			Parallelism:  2,
-			Completions:  2,
-			Suspend:      false,
-			BackoffLimit: conversions.IntPtr(4),
+			Version:                 "v1",
+			Type:                    "Job",
+			Name:                    "testjob",
+			Namespace:               "mynamespace",
+			Description:             "this is a test",
+			Parallelism:             2,
+			Completions:             2,
+			Suspend:                 false,
+			BackoffLimit:            conversions.IntPtr(4),
+			ActiveDeadlineSeconds:   conversions.IntPtr(600),
+			TTLSecondsAfterFinished: conversions.IntPtr(3600),
 			Containers: []ketchv1.Container{
 				{
 					Name:    "test",
@@ -0,0 +1,76 @@
+package chart
+
+import (
+	"fmt"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+)
+
+// linkerd contains values for populating the linkerd.yaml of an app in the linkerd service mesh.
+type linkerd struct {
+	// ServiceProfiles describe routes of services of processes.
+	ServiceProfiles []serviceProfile `json:"serviceProfiles,omitempty"`
+	// TrafficSplit if set, splits requests to the app's service between its deployments by their weights.
+	TrafficSplit *trafficSplit `json:"trafficSplit,omitempty"`
+}
+
+type serviceProfile struct {
+	// Name is the fully qualified name of the service.
+	Name   string                   `json:"name"`
+	Routes []ketchv1.KetchYamlRoute `json:"routes"`
+}
+
+type trafficSplit struct {
+	// Service is the apex service clients of the app send requests to.
+	Service  string         `json:"service"`
+	Backends []splitBackend `json:"backends"`
+}
+
+type splitBackend struct {
+	Service string `json:"service"`
+	Weight  int    `json:"weight"`
+}
+
+// withLinkerd injects the linkerd proxy into pods of the process, unless an annotation of the app opts out,
+// and sets routes of the process's service.
+func withLinkerd(enabled bool, routes []ketchv1.KetchYamlRoute) processOption {
+	return func(p *process) error {
+		if !enabled {
+			return nil
+		}
+		if _, ok := p.PodMetadata.Annotations[ketchv1.LinkerdInjectAnnotation]; !ok {
+			if p.PodMetadata.Annotations == nil {
+				p.PodMetadata.Annotations = make(map[string]string)
+			}
+			p.PodMetadata.Annotations[ketchv1.LinkerdInjectAnnotation] = "enabled"
+		}
+		if len(p.ServicePorts) > 0 && !p.HeadlessOnly {
+			p.Routes = routes
+		}
+		return nil
+	}
+}
+
+// newLinkerd returns ServiceProfiles of processes with routes and a TrafficSplit of an app with several deployments getting requests.
+func newLinkerd(namespace string, a *app) *linkerd {
+	l := &linkerd{}
+	var backends []splitBackend
+	for _, deployment := range a.Deployments {
+		for _, process := range deployment.Processes {
+			name := fmt.Sprintf("%s-%s-%v", a.Name, process.Name, deployment.Version)
+			if len(process.Routes) > 0 {
+				l.ServiceProfiles = append(l.ServiceProfiles, serviceProfile{
+					Name:   fmt.Sprintf("%s.%s.svc.cluster.local", name, namespace),
+					Routes: process.Routes,
+				})
+			}
+			if process.Routable && deployment.RoutingSettings.Weight > 0 {
+				backends = append(backends, splitBackend{Service: name, Weight: int(deployment.RoutingSettings.Weight)})
+			}
+		}
+	}
+	if a.Service != nil && len(backends) > 1 {
+		l.TrafficSplit = &trafficSplit{Service: "app-" + a.Name, Backends: backends}
+	}
+	return l
+}
@@ -0,0 +1,57 @@
+package chart
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+)
+
+func Test_withLinkerd(t *testing.T) {
+	routes := []ketchv1.KetchYamlRoute{{Name: "GET /books", Method: "GET", PathRegex: "/books"}}
+	ports := []v1.ServicePort{{Name: "http-default-1", Port: 9090}}
+	tests := []struct {
+		name        string
+		process     process
+		enabled     bool
+		wantProcess process
+	}{
+		{
+			name:        "no service mesh",
+			process:     process{Name: "web", ServicePorts: ports},
+			wantProcess: process{Name: "web", ServicePorts: ports},
+		},
+		{
+			name:    "injected process with routes",
+			process: process{Name: "web", ServicePorts: ports},
+			enabled: true,
+			wantProcess: process{
+				Name:         "web",
+				ServicePorts: ports,
+				Routes:       routes,
+				PodMetadata:  extraMetadata{Annotations: map[string]string{"linkerd.io/inject": "enabled"}},
+			},
+		},
+		{
+			name: "app opts out of injection",
+			process: process{
+				Name:        "worker",
+				PodMetadata: extraMetadata{Annotations: map[string]string{"linkerd.io/inject": "disabled"}},
+			},
+			enabled: true,
+			wantProcess: process{
+				Name:        "worker",
+				PodMetadata: extraMetadata{Annotations: map[string]string{"linkerd.io/inject": "disabled"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := tt.process
+			require.Nil(t, withLinkerd(tt.enabled, routes)(&p))
+			require.Equal(t, tt.wantProcess, p)
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package chart
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// helmOperationDuration observes how long helm takes to install or upgrade a release.
+// Labels: operation is either "install" or "upgrade", result is either "success" or "error".
+var helmOperationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "ketch_helm_operation_duration_seconds",
+	Help:    "Duration of helm install and upgrade operations in seconds.",
+	Buckets: prometheus.ExponentialBuckets(0.1, 2, 10),
+}, []string{"operation", "result"})
+
+func init() {
+	metrics.Registry.MustRegister(helmOperationDuration)
+}
+
+// observeHelmOperation records the duration of a helm operation that started at the given time.
+func observeHelmOperation(operation string, start time.Time, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	helmOperationDuration.WithLabelValues(operation, result).Observe(time.Since(start).Seconds())
+}
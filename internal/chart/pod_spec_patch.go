@@ -0,0 +1,101 @@
+package chart
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"sigs.k8s.io/yaml"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+)
+
+var manifestSeparator = regexp.MustCompile(`(?m)^---\s*$`)
+
+// podSpecPatches returns the podSpecPatch of each process in the app's ketch.yaml
+// by the name of the process' workload, <app name>-<process name>-<deployment version>.
+func podSpecPatches(app ketchv1.App) map[string][]byte {
+	patches := map[string][]byte{}
+	for _, deployment := range app.Spec.Deployments {
+		if deployment.KetchYaml == nil || deployment.KetchYaml.Kubernetes == nil {
+			continue
+		}
+		for name, process := range deployment.KetchYaml.Kubernetes.Processes {
+			if process.PodSpecPatch == nil || len(process.PodSpecPatch.Raw) == 0 {
+				continue
+			}
+			patches[fmt.Sprintf("%s-%s-%d", app.Name, name, deployment.Version)] = process.PodSpecPatch.Raw
+		}
+	}
+	return patches
+}
+
+// podSpecPostRender applies pod spec patches to rendered manifests.
+type podSpecPostRender map[string][]byte
+
+func (p podSpecPostRender) Run(renderedManifests *bytes.Buffer) (*bytes.Buffer, error) {
+	return applyPodSpecPatches(renderedManifests, p)
+}
+
+// applyPodSpecPatches applies a strategic merge patch to the pod template of each workload with a patch.
+// Manifests of other objects are kept as is.
+func applyPodSpecPatches(manifests *bytes.Buffer, patches map[string][]byte) (*bytes.Buffer, error) {
+	if len(patches) == 0 {
+		return manifests, nil
+	}
+	docs := manifestSeparator.Split(manifests.String(), -1)
+	for i, doc := range docs {
+		var obj map[string]interface{}
+		if err := yaml.Unmarshal([]byte(doc), &obj); err != nil {
+			return nil, err
+		}
+		u := unstructured.Unstructured{Object: obj}
+		switch u.GetKind() {
+		case "Deployment", "StatefulSet", "DaemonSet":
+		default:
+			continue
+		}
+		patch, ok := patches[u.GetName()]
+		if !ok {
+			continue
+		}
+		podSpec, _, err := unstructured.NestedMap(obj, "spec", "template", "spec")
+		if err != nil {
+			return nil, err
+		}
+		original, err := json.Marshal(podSpec)
+		if err != nil {
+			return nil, err
+		}
+		patched, err := strategicpatch.StrategicMergePatch(original, patch, v1.PodSpec{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply podSpecPatch to %s %s: %w", u.GetKind(), u.GetName(), err)
+		}
+		var patchedSpec map[string]interface{}
+		if err := json.Unmarshal(patched, &patchedSpec); err != nil {
+			return nil, err
+		}
+		if err := unstructured.SetNestedMap(obj, patchedSpec, "spec", "template", "spec"); err != nil {
+			return nil, err
+		}
+		content, err := yaml.Marshal(obj)
+		if err != nil {
+			return nil, err
+		}
+		// keep comments like "# Source: <template>" helm adds to every object.
+		var comments []string
+		for _, line := range strings.Split(strings.TrimLeft(doc, "\n"), "\n") {
+			if !strings.HasPrefix(line, "#") {
+				break
+			}
+			comments = append(comments, line+"\n")
+		}
+		docs[i] = "\n" + strings.Join(comments, "") + string(content)
+	}
+	return bytes.NewBufferString(strings.Join(docs, "---")), nil
+}
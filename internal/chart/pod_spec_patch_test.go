@@ -0,0 +1,112 @@
+package chart
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+)
+
+func TestPodSpecPatches(t *testing.T) {
+	patch := &runtime.RawExtension{Raw: []byte(`{"hostNetwork":true}`)}
+	app := ketchv1.App{}
+	app.Name = "hello"
+	app.Spec.Deployments = []ketchv1.AppDeploymentSpec{
+		{Version: 1},
+		{
+			Version: 2,
+			KetchYaml: &ketchv1.KetchYamlData{
+				Kubernetes: &ketchv1.KetchYamlKubernetesConfig{
+					Processes: map[string]ketchv1.KetchYamlProcessConfig{
+						"web":    {PodSpecPatch: patch},
+						"worker": {},
+					},
+				},
+			},
+		},
+	}
+	require.Equal(t, map[string][]byte{"hello-web-2": patch.Raw}, podSpecPatches(app))
+}
+
+func TestApplyPodSpecPatches(t *testing.T) {
+	manifests := `---
+# Source: hello/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: hello-web-1
+---
+# Source: hello/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: hello-web-1
+spec:
+  template:
+    spec:
+      containers:
+        - name: hello-web-1
+          image: nginx
+`
+	tests := []struct {
+		name    string
+		patches map[string][]byte
+		want    string
+		wantErr string
+	}{
+		{
+			name: "no patches",
+			want: manifests,
+		},
+		{
+			name:    "patch of another workload",
+			patches: map[string][]byte{"hello-worker-1": []byte(`{"hostNetwork":true}`)},
+			want:    manifests,
+		},
+		{
+			name:    "patch a deployment",
+			patches: map[string][]byte{"hello-web-1": []byte(`{"hostNetwork":true,"containers":[{"name":"hello-web-1","tty":true}]}`)},
+			want: `---
+# Source: hello/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: hello-web-1
+---
+# Source: hello/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: hello-web-1
+spec:
+  template:
+    spec:
+      containers:
+      - image: nginx
+        name: hello-web-1
+        tty: true
+      hostNetwork: true
+`,
+		},
+		{
+			name:    "invalid patch",
+			patches: map[string][]byte{"hello-web-1": []byte(`{"containers":`)},
+			wantErr: "failed to apply podSpecPatch to Deployment hello-web-1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := applyPodSpecPatches(bytes.NewBufferString(manifests), tt.patches)
+			if len(tt.wantErr) > 0 {
+				require.NotNil(t, err)
+				require.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.want, got.String())
+		})
+	}
+}
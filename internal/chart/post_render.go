@@ -24,6 +24,7 @@ type postRender struct {
 	appName            string
 	deploymentVersions []int
 	namespace          string
+	podSpecPatches     map[string][]byte
 }
 
 func (p *postRender) Run(renderedManifests *bytes.Buffer) (modifiedManifests *bytes.Buffer, err error) {
@@ -89,7 +90,7 @@ func (p *postRender) Run(renderedManifests *bytes.Buffer) (modifiedManifests *by
 		}
 	}
 
-	return finalBuffer, nil
+	return applyPodSpecPatches(finalBuffer, p.podSpecPatches)
 }
 
 func (p postRender) localPath(fwPatch bool, name string) string {
@@ -1,22 +1,31 @@
 package chart
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	v1 "k8s.io/api/core/v1"
 
 	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/utils"
 )
 
 var (
-	ErrPortsNotFound = errors.New("routable process should have at least one container port and one service port")
+	ErrPortsNotFound                    = errors.New("routable process should have at least one container port and one service port")
+	ErrVolumeClaimTemplatesNotSupported = errors.New("volume claim templates are supported by statefulset processes only")
+	ErrScalerNotSupported               = errors.New("scalers are supported by deployment and statefulset processes only")
 )
 
 type process struct {
 	Name              string             `json:"name"`
+	Type              ketchv1.AppType    `json:"type"`
 	Cmd               []string           `json:"cmd"`
+	Image             string             `json:"image,omitempty"`
 	Units             int                `json:"units"`
 	Routable          bool               `json:"routable"`
 	ContainerPorts    []v1.ContainerPort `json:"containerPorts"`
@@ -33,6 +42,32 @@ type process struct {
 	LivenessProbe        *v1.Probe                `json:"livenessProbe,omitempty"`
 	StartupProbe         *v1.Probe                `json:"startupProbe,omitempty"`
 	Lifecycle            *v1.Lifecycle            `json:"lifecycle,omitempty"`
+	// TerminationGracePeriodSeconds is the time pods of the process get to shut down gracefully.
+	TerminationGracePeriodSeconds *int64 `json:"terminationGracePeriodSeconds,omitempty"`
+	// PriorityClassName is the name of a PriorityClass of pods of the process.
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+	// RuntimeClassName is the name of a RuntimeClass running pods of the process.
+	RuntimeClassName string `json:"runtimeClassName,omitempty"`
+	// StickySessions if set, services and ingress objects route requests of a client to the same pod of the process.
+	StickySessions bool `json:"stickySessions,omitempty"`
+	// TimeoutSeconds if set, overrides the timeout of the ingress policy for requests of the process.
+	TimeoutSeconds int64 `json:"timeoutSeconds,omitempty"`
+	// WebSocket if set, ingress objects keep websocket connections of the process open.
+	WebSocket bool `json:"websocket,omitempty"`
+	// HeadlessService if set, a headless service of the process resolves to addresses of its pods.
+	HeadlessService bool `json:"headlessService,omitempty"`
+	// HeadlessOnly if set, the process doesn't get a service with a cluster IP.
+	HeadlessOnly bool `json:"headlessOnly,omitempty"`
+	// Routes are routes of the process's service described by a linkerd ServiceProfile.
+	Routes []ketchv1.KetchYamlRoute `json:"routes,omitempty"`
+	// Metrics is a prometheus endpoint of the process scraped by a PodMonitor or by prometheus.io annotations.
+	Metrics *processMetrics `json:"metrics,omitempty"`
+	// VolumeClaimTemplates are claim templates of a StatefulSet of this process.
+	VolumeClaimTemplates []ketchv1.PersistentVolumeClaim `json:"volumeClaimTemplates,omitempty"`
+	// Scaler is a KEDA scaler of the process, KEDA sets the number of units of the process instead of ketch.
+	Scaler *ketchv1.KetchYamlScaler `json:"scaler,omitempty"`
+	// Autoscale is a HorizontalPodAutoscaler of the process, it sets the number of units of the process instead of ketch.
+	Autoscale *ketchv1.AutoscaleSpec `json:"autoscale,omitempty"`
 	// ServiceMetadata contains Labels and Annotations to be added to a k8s Service of this process.
 	ServiceMetadata extraMetadata `json:"serviceMetadata,omitempty"`
 	// DeploymentMetadata contains Labels and Annotations to be added to a k8s Deployment of this process.
@@ -41,6 +76,16 @@ type process struct {
 	PodMetadata extraMetadata `json:"podMetadata,omitempty"`
 }
 
+// processMetrics is a prometheus endpoint of a process.
+type processMetrics struct {
+	Port     int    `json:"port"`
+	Path     string `json:"path"`
+	Interval string `json:"interval,omitempty"`
+	// ScrapeAnnotations are prometheus.io annotations of pods of the process,
+	// they're used if the Prometheus Operator isn't installed in the cluster.
+	ScrapeAnnotations map[string]string `json:"scrapeAnnotations,omitempty"`
+}
+
 type extraMetadata struct {
 	Labels      map[string]string `json:"labels,omitempty"`
 	Annotations map[string]string `json:"annotations,omitempty"`
@@ -61,11 +106,72 @@ func withUnits(units *int) processOption {
 // Additionally, the process will have port-related envs like "PORT". Check out "portEnvVariables" below.
 func withEnvs(envs []ketchv1.Env) processOption {
 	return func(p *process) error {
-		p.Env = envs
+		p.Env = podEnvs(envs)
 		return nil
 	}
 }
 
+// appEnvs returns env variables of the app followed by variables of its env sets and the default variables of the cluster
+// the app and its processes don't set.
+func appEnvs(spec ketchv1.AppSpec, envSetEnvs []ketchv1.Env, defaults []ketchv1.Env) []ketchv1.Env {
+	if len(envSetEnvs) == 0 && len(defaults) == 0 {
+		return spec.Env
+	}
+	names := map[string]bool{}
+	for _, env := range spec.Env {
+		names[env.Name] = true
+	}
+	for _, deployment := range spec.Deployments {
+		for _, process := range deployment.Processes {
+			for _, env := range process.Env {
+				names[env.Name] = true
+			}
+		}
+	}
+	envs := append([]ketchv1.Env{}, spec.Env...)
+	for _, env := range append(append([]ketchv1.Env{}, envSetEnvs...), defaults...) {
+		if !names[env.Name] {
+			names[env.Name] = true
+			envs = append(envs, env)
+		}
+	}
+	return envs
+}
+
+// envSetEnvs returns variables of the env sets in the order the app references them,
+// a variable of a later set replaces the variable of an earlier set.
+func envSetEnvs(names []string, envSets []ketchv1.EnvSet) []ketchv1.Env {
+	byName := make(map[string]ketchv1.EnvSet, len(envSets))
+	for _, envSet := range envSets {
+		byName[envSet.Name] = envSet
+	}
+	var envs []ketchv1.Env
+	index := map[string]int{}
+	for _, name := range names {
+		for _, env := range byName[name].Spec.Env {
+			if i, ok := index[env.Name]; ok {
+				envs[i] = env
+				continue
+			}
+			index[env.Name] = len(envs)
+			envs = append(envs, env)
+		}
+	}
+	return envs
+}
+
+// podEnvs drops fields that are used by ketch only and aren't part of a container's env spec.
+func podEnvs(envs []ketchv1.Env) []ketchv1.Env {
+	if envs == nil {
+		return nil
+	}
+	result := make([]ketchv1.Env, 0, len(envs))
+	for _, env := range envs {
+		result = append(result, ketchv1.Env{Name: env.Name, Value: env.Value, ValueFrom: env.ValueFrom})
+	}
+	return result
+}
+
 func withCmd(cmd []string) processOption {
 	return func(p *process) error {
 		p.Cmd = cmd
@@ -77,7 +183,8 @@ func withCmd(cmd []string) processOption {
 type portConfigurator interface {
 	ContainerPortsForProcess(process string) []v1.ContainerPort
 	ServicePortsForProcess(process string) []v1.ServicePort
-	Probes() (Probes, error)
+	Probes(process string) (Probes, error)
+	ProcessProbes(process string) (Probes, error)
 }
 
 func withPortsAndProbes(c portConfigurator) processOption {
@@ -85,9 +192,18 @@ func withPortsAndProbes(c portConfigurator) processOption {
 		p.ServicePorts = c.ServicePortsForProcess(p.Name)
 		p.ContainerPorts = c.ContainerPortsForProcess(p.Name)
 		if len(p.ContainerPorts) == 0 || len(p.ServicePorts) == 0 {
+			// the healthcheck of ketch.yaml probes ports of web processes,
+			// a process without ports gets probes of its own healthcheck only.
+			probes, err := c.ProcessProbes(p.Name)
+			if err != nil {
+				return err
+			}
+			p.LivenessProbe = probes.Liveness
+			p.ReadinessProbe = probes.Readiness
+			p.StartupProbe = probes.StartupProbe
 			return nil
 		}
-		probes, err := c.Probes()
+		probes, err := c.Probes(p.Name)
 		if err != nil {
 			return err
 		}
@@ -113,6 +229,107 @@ func withLifecycle(lc *v1.Lifecycle) processOption {
 	}
 }
 
+// withPreStopSleep makes a routable process sleep before it gets SIGTERM,
+// so the ingress controller stops sending requests to the process' pods before they shut down.
+// A preStop hook of the process is kept.
+func withPreStopSleep(seconds int64) processOption {
+	return func(p *process) error {
+		if !p.Routable || seconds <= 0 {
+			return nil
+		}
+		if p.Lifecycle != nil && p.Lifecycle.PreStop != nil {
+			return nil
+		}
+		lifecycle := &v1.Lifecycle{}
+		if p.Lifecycle != nil {
+			lifecycle = p.Lifecycle.DeepCopy()
+		}
+		lifecycle.PreStop = &v1.LifecycleHandler{
+			Exec: &v1.ExecAction{Command: []string{"sleep", strconv.FormatInt(seconds, 10)}},
+		}
+		p.Lifecycle = lifecycle
+		return nil
+	}
+}
+
+// withImage sets an image running the process instead of the image of the deployment.
+func withImage(image string) processOption {
+	return func(p *process) error {
+		p.Image = image
+		return nil
+	}
+}
+
+func withTerminationGracePeriod(seconds *int64) processOption {
+	return func(p *process) error {
+		p.TerminationGracePeriodSeconds = seconds
+		return nil
+	}
+}
+
+// withPodClasses sets the priority class and the runtime class of pods of the process.
+func withPodClasses(priorityClassName, runtimeClassName string) processOption {
+	return func(p *process) error {
+		p.PriorityClassName = priorityClassName
+		p.RuntimeClassName = runtimeClassName
+		return nil
+	}
+}
+
+// withStickySessions enables sticky sessions of a routable process.
+func withStickySessions(enabled bool) processOption {
+	return func(p *process) error {
+		p.StickySessions = enabled && p.Routable
+		return nil
+	}
+}
+
+// withHeadlessService adds a headless service to the process, statefulsets get one anyway.
+func withHeadlessService(mode ketchv1.HeadlessServiceMode) processOption {
+	return func(p *process) error {
+		switch mode {
+		case "":
+			return nil
+		case ketchv1.HeadlessServiceAlongside, ketchv1.HeadlessServiceInstead:
+		default:
+			return fmt.Errorf("process %s: invalid headless service %q", p.Name, mode)
+		}
+		if mode == ketchv1.HeadlessServiceInstead {
+			if p.Routable {
+				return fmt.Errorf("process %s: a routable process can't replace its service with a headless one", p.Name)
+			}
+			p.HeadlessOnly = true
+		}
+		p.HeadlessService = p.Type != ketchv1.StatefulSetAppType
+		return nil
+	}
+}
+
+// websocketTimeoutSeconds is the timeout of a routable process accepting websockets if ketch.yaml doesn't set one,
+// ingress controllers close connections idle for 60 seconds by default.
+const websocketTimeoutSeconds = 3600
+
+// withTimeout configures the timeout of requests and websocket connections of a routable process.
+func withTimeout(timeout string, websocket bool) processOption {
+	return func(p *process) error {
+		if !p.Routable {
+			return nil
+		}
+		if len(timeout) > 0 {
+			d, err := time.ParseDuration(timeout)
+			if err != nil || d <= 0 {
+				return fmt.Errorf("process %s: invalid timeout %q", p.Name, timeout)
+			}
+			p.TimeoutSeconds = ceilSeconds(d)
+		}
+		p.WebSocket = websocket
+		if websocket && p.TimeoutSeconds == 0 {
+			p.TimeoutSeconds = websocketTimeoutSeconds
+		}
+		return nil
+	}
+}
+
 func withResourceRequirements(rr *v1.ResourceRequirements) processOption {
 	return func(p *process) error {
 		p.ResourceRequirements = rr
@@ -134,6 +351,106 @@ func withVolumeMounts(vm []v1.VolumeMount) processOption {
 	}
 }
 
+// withType sets the kind of workload running the process.
+// kind comes from ketch.yaml and overrides appType if it is set.
+func withType(appType ketchv1.AppType, kind string) processOption {
+	return func(p *process) error {
+		p.Type = appType
+		if kind == "" {
+			return nil
+		}
+		t, err := ketchv1.ParseAppType(kind)
+		if err != nil {
+			return fmt.Errorf("process %s: %w", p.Name, err)
+		}
+		p.Type = t
+		return nil
+	}
+}
+
+// withVolumeClaimTemplates adds volume claim templates and their mounts to a statefulset process.
+// It must be applied after withType and withVolumeMounts.
+func withVolumeClaimTemplates(templates []ketchv1.KetchYamlVolumeClaimTemplate) processOption {
+	return func(p *process) error {
+		if len(templates) == 0 {
+			return nil
+		}
+		if p.Type != ketchv1.StatefulSetAppType {
+			return fmt.Errorf("process %s: %w", p.Name, ErrVolumeClaimTemplatesNotSupported)
+		}
+		for _, template := range templates {
+			pvc, err := newPersistentVolumeClaim(template.Name, template.Size, template.StorageClass, template.AccessModes)
+			if err != nil {
+				return err
+			}
+			p.VolumeClaimTemplates = append(p.VolumeClaimTemplates, pvc)
+			p.VolumeMounts = append(p.VolumeMounts, v1.VolumeMount{
+				Name:      template.Name,
+				MountPath: template.Path,
+			})
+		}
+		return nil
+	}
+}
+
+// withScaler makes KEDA scale the process.
+// It must be applied after withType.
+func withScaler(scaler *ketchv1.KetchYamlScaler) processOption {
+	return func(p *process) error {
+		if scaler == nil {
+			return nil
+		}
+		if p.Type == ketchv1.DaemonSetAppType {
+			return fmt.Errorf("process %s: %w", p.Name, ErrScalerNotSupported)
+		}
+		p.Scaler = scaler
+		return nil
+	}
+}
+
+// withAutoscale makes a HorizontalPodAutoscaler scale the process, a KEDA scaler of the process takes precedence.
+// It must be applied after withType and withScaler.
+func withAutoscale(autoscale *ketchv1.AutoscaleSpec) processOption {
+	return func(p *process) error {
+		if autoscale == nil || p.Scaler != nil {
+			return nil
+		}
+		if p.Type == ketchv1.DaemonSetAppType {
+			return fmt.Errorf("process %s: %w", p.Name, ErrScalerNotSupported)
+		}
+		p.Autoscale = autoscale
+		return nil
+	}
+}
+
+// withVolumeClaims adds volumes and volume mounts for the claims mounted to the process.
+// It must be applied after withVolumes and withVolumeMounts.
+func withVolumeClaims(appName string, claims []ketchv1.KetchYamlVolumeClaim) processOption {
+	return func(p *process) error {
+		for _, claim := range claims {
+			for _, mount := range claim.Mounts {
+				if mount.Process != p.Name {
+					continue
+				}
+				p.Volumes = append(p.Volumes, v1.Volume{
+					Name: claim.Name,
+					VolumeSource: v1.VolumeSource{
+						PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{
+							ClaimName: volumeClaimName(appName, claim.Name),
+						},
+					},
+				})
+				p.VolumeMounts = append(p.VolumeMounts, v1.VolumeMount{
+					Name:      claim.Name,
+					MountPath: mount.Path,
+					ReadOnly:  mount.ReadOnly,
+				})
+			}
+		}
+		return nil
+	}
+}
+
 // withLabels returns a function that populates Kind labels.
 func withLabels(labels []ketchv1.MetadataItem, deploymentVersion ketchv1.DeploymentVersion) processOption {
 	return func(p *process) error {
@@ -168,6 +485,125 @@ func withLabels(labels []ketchv1.MetadataItem, deploymentVersion ketchv1.Deploym
 }
 
 // withAnnotations returns a function that populates Kind annotations.
+// withServiceBindings adds env variables of the services bound to the process, variables set by the app or the process take precedence.
+// The checksum of the bindings' secrets is added as a pod annotation, so pods are restarted when credentials are rotated.
+func withServiceBindings(bindings []ketchv1.ServiceBinding, secrets map[string]v1.Secret, appEnvs []ketchv1.Env) processOption {
+	return func(p *process) error {
+		names := map[string]bool{}
+		for _, env := range append(append([]ketchv1.Env{}, appEnvs...), p.Env...) {
+			names[env.Name] = true
+		}
+		hash := sha256.New()
+		bound := false
+		for _, binding := range bindings {
+			secret, ok := secrets[binding.SecretName]
+			if !ok || !binding.BindsProcess(p.Name) {
+				continue
+			}
+			bound = true
+			fmt.Fprintf(hash, "%s=%s\n", binding.Name, ketchv1.SecretChecksum(secret))
+			for _, env := range binding.Envs(secret) {
+				if !names[env.Name] {
+					names[env.Name] = true
+					p.Env = append(p.Env, env)
+				}
+			}
+		}
+		if !bound {
+			return nil
+		}
+		if p.PodMetadata.Annotations == nil {
+			p.PodMetadata.Annotations = make(map[string]string)
+		}
+		p.PodMetadata.Annotations[utils.KetchServiceBindingsChecksumAnnotation] = hex.EncodeToString(hash.Sum(nil))
+		return nil
+	}
+}
+
+// withOpenTelemetry adds annotations of the OpenTelemetry operator to pods of the process,
+// an annotation of the app with the same name takes precedence.
+func withOpenTelemetry(spec *ketchv1.OpenTelemetrySpec, language string) processOption {
+	return func(p *process) error {
+		if spec == nil {
+			return nil
+		}
+		for k, v := range spec.PodAnnotations(language) {
+			if _, ok := p.PodMetadata.Annotations[k]; ok {
+				continue
+			}
+			if p.PodMetadata.Annotations == nil {
+				p.PodMetadata.Annotations = make(map[string]string)
+			}
+			p.PodMetadata.Annotations[k] = v
+		}
+		return nil
+	}
+}
+
+// withLogging adds labels and annotations read by the cluster's log agent to pods of the process,
+// labels and annotations of the app with the same names take precedence.
+func withLogging(spec *ketchv1.LoggingSpec, appName string, excluded bool) processOption {
+	return func(p *process) error {
+		if spec == nil {
+			return nil
+		}
+		for k, v := range spec.PodLabels(appName, p.Name) {
+			if _, ok := p.PodMetadata.Labels[k]; ok {
+				continue
+			}
+			if p.PodMetadata.Labels == nil {
+				p.PodMetadata.Labels = make(map[string]string)
+			}
+			p.PodMetadata.Labels[k] = v
+		}
+		for k, v := range spec.PodAnnotations(excluded) {
+			if _, ok := p.PodMetadata.Annotations[k]; ok {
+				continue
+			}
+			if p.PodMetadata.Annotations == nil {
+				p.PodMetadata.Annotations = make(map[string]string)
+			}
+			p.PodMetadata.Annotations[k] = v
+		}
+		return nil
+	}
+}
+
+// defaultMetricsPath is the path of a metrics endpoint if ketch.yaml doesn't set one.
+const defaultMetricsPath = "/metrics"
+
+// withMetrics configures a prometheus endpoint of the process,
+// annotations of the app with the same names take precedence over the prometheus.io scrape annotations.
+func withMetrics(metrics *ketchv1.KetchYamlMetrics) processOption {
+	return func(p *process) error {
+		if metrics == nil {
+			return nil
+		}
+		path := metrics.Path
+		if path == "" {
+			path = defaultMetricsPath
+		}
+		p.Metrics = &processMetrics{
+			Port:              metrics.Port,
+			Path:              path,
+			Interval:          metrics.Interval,
+			ScrapeAnnotations: map[string]string{},
+		}
+		scrapeAnnotations := map[string]string{
+			"prometheus.io/scrape": "true",
+			"prometheus.io/port":   strconv.Itoa(metrics.Port),
+			"prometheus.io/path":   path,
+		}
+		for k, v := range scrapeAnnotations {
+			if _, ok := p.PodMetadata.Annotations[k]; ok {
+				continue
+			}
+			p.Metrics.ScrapeAnnotations[k] = v
+		}
+		return nil
+	}
+}
+
 func withAnnotations(annotations []ketchv1.MetadataItem, deploymentVersion ketchv1.DeploymentVersion) processOption {
 	return func(p *process) error {
 		for _, annotation := range annotations {
@@ -17,10 +17,19 @@ import (
 type mockConfigurator struct {
 	servicePorts   map[string][]v1.ServicePort
 	containerPorts map[string][]v1.ContainerPort
+	probes         Probes
+	processProbes  map[string]Probes
 }
 
-func (m mockConfigurator) Probes() (Probes, error) {
-	return Probes{}, nil
+func (m mockConfigurator) Probes(process string) (Probes, error) {
+	if probes, ok := m.processProbes[process]; ok {
+		return probes, nil
+	}
+	return m.probes, nil
+}
+
+func (m mockConfigurator) ProcessProbes(process string) (Probes, error) {
+	return m.processProbes[process], nil
 }
 
 func (m mockConfigurator) ServicePortsForProcess(process string) []v1.ServicePort {
@@ -211,6 +220,40 @@ func TestNewProcess(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:        "worker without ports gets probes of its healthcheck only",
+			processName: "worker",
+			options: []processOption{
+				withPortsAndProbes(
+					mockConfigurator{
+						probes: Probes{Liveness: &v1.Probe{ProbeHandler: v1.ProbeHandler{HTTPGet: &v1.HTTPGetAction{Path: "/health"}}}},
+						processProbes: map[string]Probes{
+							"worker": {Liveness: &v1.Probe{ProbeHandler: v1.ProbeHandler{Exec: &v1.ExecAction{Command: []string{"cat", "/tmp/healthy"}}}}},
+						},
+					},
+				),
+			},
+			want: &process{
+				Name:          "worker",
+				Units:         ketchv1.DefaultNumberOfUnits,
+				LivenessProbe: &v1.Probe{ProbeHandler: v1.ProbeHandler{Exec: &v1.ExecAction{Command: []string{"cat", "/tmp/healthy"}}}},
+			},
+		},
+		{
+			name:        "worker without ports and healthcheck",
+			processName: "worker",
+			options: []processOption{
+				withPortsAndProbes(
+					mockConfigurator{
+						probes: Probes{Liveness: &v1.Probe{ProbeHandler: v1.ProbeHandler{HTTPGet: &v1.HTTPGetAction{Path: "/health"}}}},
+					},
+				),
+			},
+			want: &process{
+				Name:  "worker",
+				Units: ketchv1.DefaultNumberOfUnits,
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -465,3 +508,320 @@ func Test_withLabels(t *testing.T) {
 		})
 	}
 }
+
+func Test_withTypeAndVolumeClaimTemplates(t *testing.T) {
+	templates := []ketchv1.KetchYamlVolumeClaimTemplate{{Name: "data", Size: "1Gi", Path: "/data"}}
+	tests := []struct {
+		name    string
+		options []processOption
+		want    *process
+		wantErr string
+	}{
+		{
+			name:    "app type is used by default",
+			options: []processOption{withType(ketchv1.DeploymentAppType, "")},
+			want:    &process{Name: "worker", Type: ketchv1.DeploymentAppType, Units: 1},
+		},
+		{
+			name: "statefulset process with volume claim templates",
+			options: []processOption{
+				withType(ketchv1.DeploymentAppType, "statefulset"),
+				withVolumeClaimTemplates(templates),
+			},
+			want: &process{
+				Name:  "worker",
+				Type:  ketchv1.StatefulSetAppType,
+				Units: 1,
+				VolumeClaimTemplates: []ketchv1.PersistentVolumeClaim{
+					{Name: "data", AccessModes: []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce}, Storage: "1Gi"},
+				},
+				VolumeMounts: []v1.VolumeMount{{Name: "data", MountPath: "/data"}},
+			},
+		},
+		{
+			name:    "daemonset process",
+			options: []processOption{withType(ketchv1.DeploymentAppType, "DaemonSet")},
+			want:    &process{Name: "worker", Type: ketchv1.DaemonSetAppType, Units: 1},
+		},
+		{
+			name:    "unknown kind",
+			options: []processOption{withType(ketchv1.DeploymentAppType, "cronjob")},
+			wantErr: "process worker: unknown process kind: cronjob",
+		},
+		{
+			name: "volume claim templates of a deployment process",
+			options: []processOption{
+				withType(ketchv1.DeploymentAppType, ""),
+				withVolumeClaimTemplates(templates),
+			},
+			wantErr: "process worker: volume claim templates are supported by statefulset processes only",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newProcess("worker", false, tt.options...)
+			if len(tt.wantErr) > 0 {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_withScaler(t *testing.T) {
+	scaler := &ketchv1.KetchYamlScaler{Process: "worker", Triggers: []ketchv1.KetchYamlScalerTrigger{{Type: "kafka"}}}
+	got, err := newProcess("worker", false, withType(ketchv1.DeploymentAppType, "statefulset"), withScaler(scaler))
+	require.Nil(t, err)
+	require.Equal(t, &process{Name: "worker", Type: ketchv1.StatefulSetAppType, Units: 1, Scaler: scaler}, got)
+
+	_, err = newProcess("worker", false, withType(ketchv1.DeploymentAppType, "daemonset"), withScaler(scaler))
+	require.EqualError(t, err, "process worker: scalers are supported by deployment and statefulset processes only")
+}
+
+func Test_withStickySessions(t *testing.T) {
+	web := &process{Name: "web", Routable: true}
+	require.Nil(t, withStickySessions(true)(web))
+	require.True(t, web.StickySessions)
+
+	worker := &process{Name: "worker"}
+	require.Nil(t, withStickySessions(true)(worker))
+	require.False(t, worker.StickySessions)
+}
+
+func Test_withTimeout(t *testing.T) {
+	tests := []struct {
+		name        string
+		process     process
+		timeout     string
+		websocket   bool
+		wantProcess process
+		wantErr     string
+	}{
+		{
+			name:        "timeout is rounded up to seconds",
+			process:     process{Name: "web", Routable: true},
+			timeout:     "1m30.5s",
+			wantProcess: process{Name: "web", Routable: true, TimeoutSeconds: 91},
+		},
+		{
+			name:        "websocket with default timeout",
+			process:     process{Name: "web", Routable: true},
+			websocket:   true,
+			wantProcess: process{Name: "web", Routable: true, TimeoutSeconds: 3600, WebSocket: true},
+		},
+		{
+			name:        "websocket with timeout",
+			process:     process{Name: "web", Routable: true},
+			timeout:     "10m",
+			websocket:   true,
+			wantProcess: process{Name: "web", Routable: true, TimeoutSeconds: 600, WebSocket: true},
+		},
+		{
+			name:        "not routable process",
+			process:     process{Name: "worker"},
+			timeout:     "10m",
+			websocket:   true,
+			wantProcess: process{Name: "worker"},
+		},
+		{
+			name:    "invalid timeout",
+			process: process{Name: "web", Routable: true},
+			timeout: "10",
+			wantErr: `process web: invalid timeout "10"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := tt.process
+			err := withTimeout(tt.timeout, tt.websocket)(&p)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.wantProcess, p)
+		})
+	}
+}
+
+func Test_withEnvs(t *testing.T) {
+	envs := []ketchv1.Env{{Name: "API_TOKEN", Value: "token", Sensitive: true}, {Name: "DEBUG", Value: "true"}}
+	p := &process{Name: "web"}
+	require.Nil(t, withEnvs(envs)(p))
+	require.Equal(t, []ketchv1.Env{{Name: "API_TOKEN", Value: "token"}, {Name: "DEBUG", Value: "true"}}, p.Env)
+}
+
+func Test_withPreStopSleep(t *testing.T) {
+	sleep := &v1.LifecycleHandler{Exec: &v1.ExecAction{Command: []string{"sleep", "15"}}}
+	postStart := &v1.LifecycleHandler{Exec: &v1.ExecAction{Command: []string{"sh", "-c", "./warm-up"}}}
+	drain := &v1.LifecycleHandler{HTTPGet: &v1.HTTPGetAction{Path: "/drain"}}
+	tests := []struct {
+		name          string
+		process       process
+		seconds       int64
+		wantLifecycle *v1.Lifecycle
+	}{
+		{
+			name:          "routable process",
+			process:       process{Name: "web", Routable: true},
+			seconds:       15,
+			wantLifecycle: &v1.Lifecycle{PreStop: sleep},
+		},
+		{
+			name:          "post-start hook is kept",
+			process:       process{Name: "web", Routable: true, Lifecycle: &v1.Lifecycle{PostStart: postStart}},
+			seconds:       15,
+			wantLifecycle: &v1.Lifecycle{PostStart: postStart, PreStop: sleep},
+		},
+		{
+			name:          "pre-stop hook of the process wins",
+			process:       process{Name: "web", Routable: true, Lifecycle: &v1.Lifecycle{PreStop: drain}},
+			seconds:       15,
+			wantLifecycle: &v1.Lifecycle{PreStop: drain},
+		},
+		{
+			name:    "not routable process",
+			process: process{Name: "worker"},
+			seconds: 15,
+		},
+		{
+			name:    "no sleep",
+			process: process{Name: "web", Routable: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := tt.process
+			require.Nil(t, withPreStopSleep(tt.seconds)(&p))
+			require.Equal(t, tt.wantLifecycle, p.Lifecycle)
+		})
+	}
+}
+
+func Test_withHeadlessService(t *testing.T) {
+	tests := []struct {
+		name        string
+		process     process
+		mode        ketchv1.HeadlessServiceMode
+		wantProcess process
+		wantErr     string
+	}{
+		{
+			name:        "no headless service",
+			process:     process{Name: "worker", Type: ketchv1.DeploymentAppType},
+			wantProcess: process{Name: "worker", Type: ketchv1.DeploymentAppType},
+		},
+		{
+			name:        "alongside the service",
+			process:     process{Name: "web", Type: ketchv1.DeploymentAppType, Routable: true},
+			mode:        ketchv1.HeadlessServiceAlongside,
+			wantProcess: process{Name: "web", Type: ketchv1.DeploymentAppType, Routable: true, HeadlessService: true},
+		},
+		{
+			name:        "instead of the service",
+			process:     process{Name: "worker", Type: ketchv1.DeploymentAppType},
+			mode:        ketchv1.HeadlessServiceInstead,
+			wantProcess: process{Name: "worker", Type: ketchv1.DeploymentAppType, HeadlessService: true, HeadlessOnly: true},
+		},
+		{
+			name:        "statefulset has a headless service of its own",
+			process:     process{Name: "worker", Type: ketchv1.StatefulSetAppType},
+			mode:        ketchv1.HeadlessServiceInstead,
+			wantProcess: process{Name: "worker", Type: ketchv1.StatefulSetAppType, HeadlessOnly: true},
+		},
+		{
+			name:    "routable process replacing its service",
+			process: process{Name: "web", Type: ketchv1.DeploymentAppType, Routable: true},
+			mode:    ketchv1.HeadlessServiceInstead,
+			wantErr: "process web: a routable process can't replace its service with a headless one",
+		},
+		{
+			name:    "invalid mode",
+			process: process{Name: "worker"},
+			mode:    "only",
+			wantErr: `process worker: invalid headless service "only"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := tt.process
+			err := withHeadlessService(tt.mode)(&p)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.wantProcess, p)
+		})
+	}
+}
+
+func Test_withOpenTelemetry(t *testing.T) {
+	spec := &ketchv1.OpenTelemetrySpec{Instrumentation: "default", Sidecar: "true"}
+	p := &process{Name: "web"}
+	require.Nil(t, withOpenTelemetry(nil, "java")(p))
+	require.Nil(t, p.PodMetadata.Annotations)
+
+	require.Nil(t, withOpenTelemetry(spec, "java")(p))
+	require.Equal(t, map[string]string{
+		"instrumentation.opentelemetry.io/inject-java": "default",
+		"sidecar.opentelemetry.io/inject":              "true",
+	}, p.PodMetadata.Annotations)
+
+	// the app opts out of the sidecar with an annotation of its own.
+	p = &process{Name: "web", PodMetadata: extraMetadata{Annotations: map[string]string{"sidecar.opentelemetry.io/inject": "false"}}}
+	require.Nil(t, withOpenTelemetry(spec, "")(p))
+	require.Equal(t, map[string]string{"sidecar.opentelemetry.io/inject": "false"}, p.PodMetadata.Annotations)
+}
+
+func Test_withLogging(t *testing.T) {
+	spec := &ketchv1.LoggingSpec{
+		Annotations:  map[string]string{"fluentbit.io/parser": "json"},
+		AppLabel:     "app.kubernetes.io/name",
+		ProcessLabel: "app.kubernetes.io/component",
+	}
+	p := &process{Name: "web"}
+	require.Nil(t, withLogging(nil, "dashboard", false)(p))
+	require.Nil(t, p.PodMetadata.Labels)
+	require.Nil(t, p.PodMetadata.Annotations)
+
+	require.Nil(t, withLogging(spec, "dashboard", false)(p))
+	require.Equal(t, map[string]string{
+		"app.kubernetes.io/name":      "dashboard",
+		"app.kubernetes.io/component": "web",
+	}, p.PodMetadata.Labels)
+	require.Equal(t, map[string]string{"fluentbit.io/parser": "json"}, p.PodMetadata.Annotations)
+
+	// an excluded process keeps an annotation of the app with the same name.
+	p = &process{Name: "worker", PodMetadata: extraMetadata{Annotations: map[string]string{"fluentbit.io/exclude": "false"}}}
+	require.Nil(t, withLogging(spec, "dashboard", true)(p))
+	require.Equal(t, map[string]string{"fluentbit.io/exclude": "false"}, p.PodMetadata.Annotations)
+}
+
+func Test_withMetrics(t *testing.T) {
+	p := &process{Name: "web"}
+	require.Nil(t, withMetrics(nil)(p))
+	require.Nil(t, p.Metrics)
+
+	require.Nil(t, withMetrics(&ketchv1.KetchYamlMetrics{Port: 9102, Interval: "30s"})(p))
+	require.Equal(t, &processMetrics{
+		Port:     9102,
+		Path:     "/metrics",
+		Interval: "30s",
+		ScrapeAnnotations: map[string]string{
+			"prometheus.io/scrape": "true",
+			"prometheus.io/port":   "9102",
+			"prometheus.io/path":   "/metrics",
+		},
+	}, p.Metrics)
+
+	// the app turns scraping off with an annotation of its own.
+	p = &process{Name: "web", PodMetadata: extraMetadata{Annotations: map[string]string{"prometheus.io/scrape": "false"}}}
+	require.Nil(t, withMetrics(&ketchv1.KetchYamlMetrics{Port: 9102, Path: "/stats"})(p))
+	require.Equal(t, map[string]string{
+		"prometheus.io/port": "9102",
+		"prometheus.io/path": "/stats",
+	}, p.Metrics.ScrapeAnnotations)
+}
@@ -3,6 +3,7 @@ package chart
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"regexp"
 	"sort"
 	"strings"
@@ -14,12 +15,20 @@ var (
 	ErrEmptyProcfile = errors.New("procfile should contain at least one process name with a command")
 
 	processNameRegex = regexp.MustCompile(`^([A-Za-z0-9_-]+)$`)
+
+	// shellRegex matches commands that need a shell: env variable references and shell operators.
+	shellRegex = regexp.MustCompile(`\$[A-Za-z_{]|[|&;<>()` + "`" + `*?~]`)
 )
 
+// ReleaseProcessName is the Procfile process type that runs once per deployment before the deployment gets traffic.
+const ReleaseProcessName = "release"
+
 // Procfile represents a parsed Procfile.
 type Procfile struct {
 	Processes           map[string][]string
 	RoutableProcessName string
+	// ReleaseCmd is the command of the release process, it isn't a long-running process.
+	ReleaseCmd []string
 }
 
 func (p *Procfile) IsRoutable(processName string) bool {
@@ -54,6 +63,7 @@ func CreateProcfile(buildMetadata string) (*Procfile, error) {
 	}
 	processes := make(map[string][]string, len(meta.Processes))
 	var names []string
+	var releaseCmd []string
 	for _, process := range meta.Processes {
 		if p := processNameRegex.FindStringSubmatch(process.Type); p != nil {
 			name := p[1]
@@ -62,6 +72,10 @@ func CreateProcfile(buildMetadata string) (*Procfile, error) {
 			// in the procfile will be added to /cnb/process. These executables run the commands
 			// specified in the procfile. Trying to run the commands as they are in the Procfile
 			// will result in an executable file not found in $PATH: unknown error
+			if name == ReleaseProcessName {
+				releaseCmd = []string{name}
+				continue
+			}
 			processes[name] = []string{strings.TrimSpace(name)}
 			names = append(names, name)
 		}
@@ -72,9 +86,127 @@ func CreateProcfile(buildMetadata string) (*Procfile, error) {
 	return &Procfile{
 		Processes:           processes,
 		RoutableProcessName: routableProcess(names),
+		ReleaseCmd:          releaseCmd,
+	}, nil
+}
+
+// ParseProcfile parses a Procfile in the format of Heroku: a "name: command" line per process.
+// Empty lines and lines starting with '#' are ignored, a line ending with '\' continues on the next line.
+// A command is split into arguments the way a shell does it, respecting quotes,
+// a command with env variable references like $PORT or shell operators runs in /bin/sh.
+func ParseProcfile(content string) (*Procfile, error) {
+	processes := map[string][]string{}
+	var names []string
+	var releaseCmd []string
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		lineNumber := i + 1
+		line := lines[i]
+		for strings.HasSuffix(line, "\\") && i+1 < len(lines) {
+			i++
+			line = strings.TrimSuffix(line, "\\") + " " + strings.TrimSpace(lines[i])
+		}
+		line = strings.TrimSpace(line)
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(line, ":", 2)
+		name := strings.TrimSpace(parts[0])
+		if len(parts) != 2 || !processNameRegex.MatchString(name) {
+			return nil, fmt.Errorf("procfile line %d: expected \"<process name>: <command>\"", lineNumber)
+		}
+		cmd, err := procfileCommand(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, fmt.Errorf("procfile line %d: %w", lineNumber, err)
+		}
+		if name == ReleaseProcessName {
+			if releaseCmd != nil {
+				return nil, fmt.Errorf("procfile line %d: process %q is defined more than once", lineNumber, name)
+			}
+			releaseCmd = cmd
+			continue
+		}
+		if _, ok := processes[name]; ok {
+			return nil, fmt.Errorf("procfile line %d: process %q is defined more than once", lineNumber, name)
+		}
+		processes[name] = cmd
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return nil, ErrEmptyProcfile
+	}
+	return &Procfile{
+		Processes:           processes,
+		RoutableProcessName: routableProcess(names),
+		ReleaseCmd:          releaseCmd,
 	}, nil
 }
 
+// procfileCommand returns arguments of a command of a Procfile process.
+func procfileCommand(command string) ([]string, error) {
+	args, err := splitArgs(command)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 {
+		return nil, errors.New("command is empty")
+	}
+	if shellRegex.MatchString(command) {
+		return []string{"/bin/sh", "-c", command}, nil
+	}
+	return args, nil
+}
+
+// SplitCommand splits a command into arguments the way a shell does it, respecting quotes, without running a shell.
+func SplitCommand(command string) ([]string, error) {
+	return splitArgs(command)
+}
+
+// splitArgs splits a command into arguments separated by spaces,
+// single and double quotes group arguments with spaces and a backslash escapes the next character.
+func splitArgs(command string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inArg := false
+	var quote rune
+	escaped := false
+	for _, c := range command {
+		switch {
+		case escaped:
+			current.WriteRune(c)
+			escaped = false
+		case c == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else {
+				current.WriteRune(c)
+			}
+		case c == '\'' || c == '"':
+			quote = c
+			inArg = true
+		case c == ' ' || c == '\t':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(c)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
+
 // ProcfileFromProcesses construct a Procfile instance from a list of ProcessSpec and returns it.
 func ProcfileFromProcesses(processes []ketchv1.ProcessSpec) (*Procfile, error) {
 	if len(processes) == 0 {
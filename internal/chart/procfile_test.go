@@ -5,11 +5,12 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/require"
 
 	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
 )
 
-func TestParseProcfile(t *testing.T) {
+func TestCreateProcfile(t *testing.T) {
 	tests := []struct {
 		name    string
 		content string
@@ -80,6 +81,17 @@ func TestParseProcfile(t *testing.T) {
 				RoutableProcessName: "web",
 			},
 		},
+		{
+			name:    "release process",
+			content: "{\"processes\":[{\"type\":\"web\"},{\"type\":\"release\"}]}",
+			want: &Procfile{
+				Processes: map[string][]string{
+					"web": {"web"},
+				},
+				RoutableProcessName: "web",
+				ReleaseCmd:          []string{"release"},
+			},
+		},
 		{
 			name:    "broken json",
 			content: "",
@@ -95,12 +107,107 @@ func TestParseProcfile(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			got, err := CreateProcfile(tt.content)
 			if (err != nil) != tt.wantErr {
-				t.Errorf("ParseProcfile() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("CreateProcfile() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("ParseProcfile() got = %v, want %v", got, tt.want)
+				t.Errorf("CreateProcfile() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseProcfile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    *Procfile
+		wantErr string
+	}{
+		{
+			name:    "commands with arguments",
+			content: "web: ./server --addr 0.0.0.0\nworker: celery worker -Q 'high, low'\n",
+			want: &Procfile{
+				Processes: map[string][]string{
+					"web":    {"./server", "--addr", "0.0.0.0"},
+					"worker": {"celery", "worker", "-Q", "high, low"},
+				},
+				RoutableProcessName: "web",
+			},
+		},
+		{
+			name:    "comments, empty lines and continued lines",
+			content: "# processes of the app\n\nweb: gunicorn app:app \\\n    --workers 4\r\n  # worker: celery\n",
+			want: &Procfile{
+				Processes: map[string][]string{
+					"web": {"gunicorn", "app:app", "--workers", "4"},
+				},
+				RoutableProcessName: "web",
+			},
+		},
+		{
+			name:    "env variables and shell operators run in a shell",
+			content: "web: bundle exec puma -p $PORT\nworker: sidekiq 2>&1 | tee /tmp/log\nclock: echo \"\\$PORT\"",
+			want: &Procfile{
+				Processes: map[string][]string{
+					"web":    {"/bin/sh", "-c", "bundle exec puma -p $PORT"},
+					"worker": {"/bin/sh", "-c", "sidekiq 2>&1 | tee /tmp/log"},
+					"clock":  {"/bin/sh", "-c", "echo \"\\$PORT\""},
+				},
+				RoutableProcessName: "web",
+			},
+		},
+		{
+			name:    "release process",
+			content: "release: rake db:migrate\nworker: rake jobs:work",
+			want: &Procfile{
+				Processes: map[string][]string{
+					"worker": {"rake", "jobs:work"},
+				},
+				RoutableProcessName: "worker",
+				ReleaseCmd:          []string{"rake", "db:migrate"},
+			},
+		},
+		{
+			name:    "only a release process",
+			content: "release: rake db:migrate",
+			wantErr: ErrEmptyProcfile.Error(),
+		},
+		{
+			name:    "invalid line",
+			content: "web: server\nworker celery",
+			wantErr: `procfile line 2: expected "<process name>: <command>"`,
+		},
+		{
+			name:    "invalid process name",
+			content: "web.1: server",
+			wantErr: `procfile line 1: expected "<process name>: <command>"`,
+		},
+		{
+			name:    "empty command",
+			content: "web:   ",
+			wantErr: "procfile line 1: command is empty",
+		},
+		{
+			name:    "unterminated quote",
+			content: "web: echo 'hello",
+			wantErr: "procfile line 1: unterminated ' quote",
+		},
+		{
+			name:    "duplicated process",
+			content: "web: server\n\nweb: server --debug",
+			wantErr: `procfile line 3: process "web" is defined more than once`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseProcfile(tt.content)
+			if len(tt.wantErr) > 0 {
+				require.EqualError(t, err, tt.wantErr)
+				return
 			}
+			require.Nil(t, err)
+			require.Equal(t, tt.want, got)
 		})
 	}
 }
@@ -0,0 +1,152 @@
+package chart
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage"
+	"helm.sh/helm/v3/pkg/storage/driver"
+	"k8s.io/client-go/kubernetes"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+)
+
+const (
+	// SecretStorageDriver keeps helm releases in secrets, it's the default driver.
+	SecretStorageDriver = "secret"
+	// ConfigMapStorageDriver keeps helm releases in configmaps.
+	ConfigMapStorageDriver = "configmap"
+
+	// DefaultMaxHistory is the number of helm releases of an app kept by default, the way "helm upgrade --history-max" does.
+	DefaultMaxHistory = 10
+)
+
+// HelmStorage configures where helm keeps releases of apps and jobs and how many of them it keeps.
+type HelmStorage struct {
+	// Driver is either "secret" or "configmap", HELM_DRIVER or "secret" if it's empty.
+	Driver string
+	// Namespace keeps releases, the namespace of an app or a job if it's empty.
+	Namespace string
+	// MaxHistory is the number of releases kept per app or job including the latest one,
+	// older releases are removed on upgrade so their secrets don't pile up. Values below 1 keep only the latest release.
+	MaxHistory int
+}
+
+// Validate returns an error if the driver isn't supported.
+func (s HelmStorage) Validate() error {
+	switch s.Driver {
+	case "", SecretStorageDriver, ConfigMapStorageDriver:
+		return nil
+	}
+	return fmt.Errorf("unsupported helm storage driver %q, use %q or %q", s.Driver, SecretStorageDriver, ConfigMapStorageDriver)
+}
+
+func (s HelmStorage) driver() string {
+	if s.Driver != "" {
+		return s.Driver
+	}
+	if d := os.Getenv("HELM_DRIVER"); d != "" {
+		return d
+	}
+	return SecretStorageDriver
+}
+
+func (s HelmStorage) namespace(namespace string) string {
+	if s.Namespace != "" {
+		return s.Namespace
+	}
+	return namespace
+}
+
+func (s HelmStorage) maxHistory() int {
+	if s.MaxHistory < 1 {
+		return 1
+	}
+	return s.MaxHistory
+}
+
+// ReleaseDeployment is a deployment of an app installed by a helm release.
+type ReleaseDeployment struct {
+	Version ketchv1.DeploymentVersion `json:"version"`
+	Image   string                    `json:"image"`
+}
+
+// ReleaseRevision is a revision of the helm release of an app.
+type ReleaseRevision struct {
+	Revision    int
+	Updated     time.Time
+	Status      release.Status
+	Description string
+	Deployments []ReleaseDeployment
+}
+
+// LatestDeployment returns the most recent deployment installed by the revision or nil if it has no deployments.
+func (r ReleaseRevision) LatestDeployment() *ReleaseDeployment {
+	if len(r.Deployments) == 0 {
+		return nil
+	}
+	return &r.Deployments[len(r.Deployments)-1]
+}
+
+// ReleaseHistory returns revisions of the helm release of an app kept in the storage, the oldest first.
+func ReleaseHistory(kubeClient kubernetes.Interface, s HelmStorage, namespace string, appName string) ([]ReleaseRevision, error) {
+	var d driver.Driver
+	switch s.Driver {
+	case ConfigMapStorageDriver:
+		d = driver.NewConfigMaps(kubeClient.CoreV1().ConfigMaps(s.namespace(namespace)))
+	default:
+		d = driver.NewSecrets(kubeClient.CoreV1().Secrets(s.namespace(namespace)))
+	}
+	releases, err := storage.Init(d).History(appName)
+	if errors.Is(err, driver.ErrReleaseNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(releases, func(i, j int) bool { return releases[i].Version < releases[j].Version })
+	revisions := make([]ReleaseRevision, 0, len(releases))
+	for _, rel := range releases {
+		revision := ReleaseRevision{
+			Revision:    rel.Version,
+			Deployments: releaseDeployments(rel),
+		}
+		if rel.Info != nil {
+			revision.Updated = rel.Info.LastDeployed.Time
+			revision.Status = rel.Info.Status
+			revision.Description = rel.Info.Description
+		}
+		revisions = append(revisions, revision)
+	}
+	return revisions, nil
+}
+
+// releaseDeployments returns deployments of the app chart's values of the release sorted by version.
+func releaseDeployments(rel *release.Release) []ReleaseDeployment {
+	appValues, _ := rel.Config["app"].(map[string]interface{})
+	items, _ := appValues["deployments"].([]interface{})
+	deployments := make([]ReleaseDeployment, 0, len(items))
+	for _, item := range items {
+		values, _ := item.(map[string]interface{})
+		image, _ := values["image"].(string)
+		var version ketchv1.DeploymentVersion
+		switch v := values["version"].(type) {
+		case float64:
+			version = ketchv1.DeploymentVersion(v)
+		case int64:
+			version = ketchv1.DeploymentVersion(v)
+		case int:
+			version = ketchv1.DeploymentVersion(v)
+		}
+		if version == 0 {
+			continue
+		}
+		deployments = append(deployments, ReleaseDeployment{Version: version, Image: image})
+	}
+	sort.Slice(deployments, func(i, j int) bool { return deployments[i].Version < deployments[j].Version })
+	return deployments
+}
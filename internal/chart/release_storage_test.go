@@ -0,0 +1,78 @@
+package chart
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage/driver"
+	helmTime "helm.sh/helm/v3/pkg/time"
+	"k8s.io/client-go/kubernetes/fake"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+)
+
+func testRelease(name, namespace string, revision int, status release.Status, versions ...int) *release.Release {
+	deployments := make([]interface{}, 0, len(versions))
+	for _, version := range versions {
+		deployments = append(deployments, map[string]interface{}{"version": version, "image": fmt.Sprintf("nginx:%d.0", version)})
+	}
+	return &release.Release{
+		Name:      name,
+		Namespace: namespace,
+		Version:   revision,
+		Info: &release.Info{
+			Status:       status,
+			Description:  "Upgrade complete",
+			LastDeployed: helmTime.Date(2022, time.June, revision, 9, 0, 0, 0, time.UTC),
+		},
+		Config: map[string]interface{}{"app": map[string]interface{}{"deployments": deployments}},
+	}
+}
+
+func TestHelmStorage_Validate(t *testing.T) {
+	require.Nil(t, HelmStorage{}.Validate())
+	require.Nil(t, HelmStorage{Driver: ConfigMapStorageDriver}.Validate())
+	require.EqualError(t, HelmStorage{Driver: "sql"}.Validate(), `unsupported helm storage driver "sql", use "secret" or "configmap"`)
+}
+
+func TestReleaseHistory(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	secrets := driver.NewSecrets(clientset.CoreV1().Secrets("ketch-releases"))
+	require.Nil(t, secrets.Create("dashboard.v2", testRelease("dashboard", "gke", 2, release.StatusDeployed, 4, 3)))
+	require.Nil(t, secrets.Create("dashboard.v1", testRelease("dashboard", "gke", 1, release.StatusSuperseded, 3)))
+	require.Nil(t, secrets.Create("go-app.v1", testRelease("go-app", "gke", 1, release.StatusDeployed, 1)))
+	configMaps := driver.NewConfigMaps(clientset.CoreV1().ConfigMaps("gke"))
+	require.Nil(t, configMaps.Create("dashboard.v7", testRelease("dashboard", "gke", 7, release.StatusDeployed, 9)))
+
+	revisions, err := ReleaseHistory(clientset, HelmStorage{Namespace: "ketch-releases"}, "gke", "dashboard")
+	require.Nil(t, err)
+	require.Equal(t, []ReleaseRevision{
+		{
+			Revision:    1,
+			Updated:     time.Date(2022, time.June, 1, 9, 0, 0, 0, time.UTC),
+			Status:      release.StatusSuperseded,
+			Description: "Upgrade complete",
+			Deployments: []ReleaseDeployment{{Version: 3, Image: "nginx:3.0"}},
+		},
+		{
+			Revision:    2,
+			Updated:     time.Date(2022, time.June, 2, 9, 0, 0, 0, time.UTC),
+			Status:      release.StatusDeployed,
+			Description: "Upgrade complete",
+			Deployments: []ReleaseDeployment{{Version: 3, Image: "nginx:3.0"}, {Version: 4, Image: "nginx:4.0"}},
+		},
+	}, revisions)
+	require.Equal(t, &ReleaseDeployment{Version: 4, Image: "nginx:4.0"}, revisions[1].LatestDeployment())
+
+	revisions, err = ReleaseHistory(clientset, HelmStorage{Driver: ConfigMapStorageDriver}, "gke", "dashboard")
+	require.Nil(t, err)
+	require.Len(t, revisions, 1)
+	require.Equal(t, ketchv1.DeploymentVersion(9), revisions[0].LatestDeployment().Version)
+
+	revisions, err = ReleaseHistory(clientset, HelmStorage{}, "gke", "dashboard")
+	require.Nil(t, err)
+	require.Empty(t, revisions)
+}
@@ -29,21 +29,33 @@ import (
 	"helm.sh/helm/v3/pkg/release"
 	"k8s.io/api/autoscaling/v2beta1"
 	v1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
 	"github.com/theketchio/ketch/internal/chart"
+	"github.com/theketchio/ketch/internal/provisioner"
 	"github.com/theketchio/ketch/internal/templates"
+	"github.com/theketchio/ketch/internal/utils"
+	"github.com/theketchio/ketch/internal/validation"
 )
 
 // AppReconciler reconciles a App object.
@@ -60,6 +72,18 @@ type AppReconciler struct {
 	Config *rest.Config
 	// CancelMap tracks cancelFunc functions for goroutines AppReconciler starts to watch deployment events.
 	CancelMap *CancelMap
+	// Notifier sends notifications configured in an app's spec, notifications are disabled if it's nil.
+	Notifier Notifier
+	// Resolver is used to check DNS records of an app's cnames, the check is skipped if it's nil.
+	Resolver validation.Resolver
+	// Provisioners provision dependencies apps require in their ketch.yaml.
+	Provisioners provisioner.Provisioners
+	// Options tune the concurrency of the controller.
+	Options ControllerOptions
+	// ApplyFactoryFn returns clients installing charts with server-side apply for apps whose engine is server-side-apply.
+	ApplyFactoryFn helmFactoryFn
+	// HelmStorage is the storage of helm releases of apps, the hash of an app's manifests changes with it.
+	HelmStorage chart.HelmStorage
 }
 
 // timeNowFn knows how to get the current time.
@@ -72,6 +96,8 @@ type helmFactoryFn func(namespace string) (Helm, error)
 type Helm interface {
 	UpdateChart(tv chart.TemplateValuer, config chart.ChartConfig, opts ...chart.InstallOption) (*release.Release, error)
 	DeleteChart(appName string) error
+	RepairChart(appName string) error
+	ReleaseRevision(appName string) (string, error)
 }
 
 const (
@@ -79,38 +105,54 @@ const (
 	deadlineExeceededProgressCond = "ProgressDeadlineExceeded"
 	DefaultPodRunningTimeout      = 10 * time.Minute
 	maxWaitTimeDuration           = time.Duration(120) * time.Second
+
+	releaseLockedMessage    = `helm release is locked by another operation, it is unlocked automatically after a timeout or run "ketch app repair %s"`
+	deployHookFailedMessage = `%s process of version %d failed, run "ketch app log %s --process %s --version %d" to see its logs`
 )
 
 // +kubebuilder:rbac:groups=theketch.io,resources=apps,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=theketch.io,resources=apps/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=theketch.io,resources=envsets,verbs=get;list;watch
 // +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="apps",resources=deployments,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="apps",resources=deployments/scale,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="apps",resources=statefulsets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="apps",resources=daemonsets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="apps",resources=replicasets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=pods/exec,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=serviceaccounts,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="networking.k8s.io",resources=ingresses,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="networking.k8s.io",resources=networkpolicies,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=configmaps/status,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=persistentvolumes,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=resourcequotas,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="networking.istio.io",resources=gateways,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="networking.istio.io",resources=virtualservices,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="networking.istio.io",resources=destinationrules,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="networking.istio.io",resources=envoyfilters,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="cert-manager.io",resources=certificates,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="rbac.authorization.k8s.io",resources=clusterroles,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="rbac.authorization.k8s.io",resources=clusterrolebindings,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="rbac.authorization.k8s.io",resources=rolebindings,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="traefik.containo.us",resources=ingressroutes,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="traefik.containo.us",resources=ingressroutes/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups="traefik.containo.us",resources=traefikservices,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="traefik.containo.us",resources=traefikservices/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups="traefik.containo.us",resources=middlewares,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="traefik.containo.us",resources=serverstransports,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=events,verbs=create;patch;update;delete;list;watch
 // +kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,verbs=get;list;create;update
 // +kubebuilder:rbac:groups="autoscaling",resources=horizontalpodautoscalers,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="keda.sh",resources=scaledobjects,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="linkerd.io",resources=serviceprofiles,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="split.smi-spec.io",resources=trafficsplits,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="monitoring.coreos.com",resources=podmonitors,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="external-secrets.io",resources=externalsecrets,verbs=get;list;watch;create;update;patch;delete
 
 func (r *AppReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := r.Log.WithValues("app", req.NamespacedName)
@@ -143,7 +185,9 @@ func (r *AppReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.R
 		return ctrl.Result{}, err
 	}
 
+	start := time.Now()
 	scheduleResult := r.reconcile(ctx, &app, logger)
+	observeReconcile(start, scheduleResult.err)
 	if scheduleResult.isConflictError() || isCanceledError(scheduleResult.err) {
 		// we don't want to create an event with this conflict error and show it to the user.
 		// ketch will eventually reconcile the app.
@@ -158,15 +202,43 @@ func (r *AppReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.R
 
 	if scheduleResult.err != nil {
 		err = scheduleResult.err
+		// notify only once when the app stops being scheduled, not on every retry.
+		if c := app.Status.Condition(ketchv1.Scheduled); !scheduleResult.useTimeout && (c == nil || c.Status != v1.ConditionFalse) {
+			r.notify(&app, ketchv1.DeployFailedEvent, scheduleResult.err.Error())
+			appDeploymentsTotal.WithLabelValues(app.Spec.Namespace, metricsResultError).Inc()
+		}
 		outcome := ketchv1.AppReconcileOutcome{AppName: app.Name, DeploymentCount: app.Spec.DeploymentsCount}
 		r.Recorder.Event(&app, v1.EventTypeWarning, ketchv1.AppReconcileOutcomeReason, outcome.String(err))
 		app.SetCondition(ketchv1.Scheduled, v1.ConditionFalse, scheduleResult.err.Error(), metav1.NewTime(time.Now()))
+		if chart.IsReleaseLocked(scheduleResult.err) {
+			app.SetCondition(ketchv1.ReleaseReady, v1.ConditionFalse, fmt.Sprintf(releaseLockedMessage, app.Name), metav1.NewTime(time.Now()))
+		}
+		if _, deploying := app.DeployInProgress(); deploying {
+			setDeployingCondition(&app, false, "failed to roll out", metav1.NewTime(time.Now()))
+		}
 	} else {
 		outcome := ketchv1.AppReconcileOutcome{AppName: app.Name, DeploymentCount: app.Spec.DeploymentsCount}
 		r.Recorder.Event(&app, v1.EventTypeNormal, ketchv1.AppReconcileOutcomeReason, outcome.String())
 		app.SetCondition(ketchv1.Scheduled, v1.ConditionTrue, "", metav1.NewTime(time.Now()))
+		if app.Status.Condition(ketchv1.ReleaseReady) != nil {
+			app.SetCondition(ketchv1.ReleaseReady, v1.ConditionTrue, "", metav1.NewTime(time.Now()))
+		}
+		r.migrateIngress(ctx, &app)
+		deployed := false
+		for _, deployment := range app.Spec.Deployments {
+			if app.Status.DeploymentRecord(deployment.Version) == nil {
+				deployed = true
+				appDeploymentsTotal.WithLabelValues(app.Spec.Namespace, metricsResultSuccess).Inc()
+				r.Recorder.Eventf(&app, v1.EventTypeNormal, ketchv1.AppDeployed, "version %d deployed with image %s", deployment.Version, deployment.Image)
+			}
+		}
+		r.recordScaling(&app, deployed)
+		app.RecordDeployments(metav1.NewTime(time.Now()), app.Annotations[utils.KetchDeployedByAnnotation])
+		updateDeployingCondition(&app, scheduleResult, metav1.NewTime(time.Now()))
 	}
 
+	r.validateCnames(ctx, &app)
+
 	if err := r.Status().Update(context.Background(), &app); err != nil {
 		if k8sErrors.IsConflict(err) {
 			// we don't want to create an event with this conflict error and show it to the user.
@@ -188,6 +260,18 @@ func (r *AppReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.R
 		// set default timeout
 		result = ctrl.Result{RequeueAfter: reconcileTimeout}
 	}
+
+	// come back soon to clear the Deploying condition a queued deploy waits for.
+	if scheduleResult.rollingOut && (result.RequeueAfter == 0 || result.RequeueAfter > rolloutRequeueInterval) {
+		result.RequeueAfter = rolloutRequeueInterval
+	}
+
+	// come back when the next scaling schedule is due.
+	if next := app.NextScheduleTime(time.Now()); !next.IsZero() {
+		if after := time.Until(next); result.RequeueAfter == 0 || after < result.RequeueAfter {
+			result.RequeueAfter = after
+		}
+	}
 	return result, err
 }
 
@@ -203,8 +287,8 @@ func hpaTargetMap(app *ketchv1.App, hpaList v2beta1.HorizontalPodAutoscalerList)
 
 			deploymentName := fmt.Sprintf("%s-%s-%s", app.Name, process.Name, deployment.Version)
 			if details, ok := targets[deploymentName]; ok {
-				// even if a target name is a match, it could be targeting a different kind than deployment
-				if details.Kind == "Deployment" && details.APIVersion == "apps/v1" {
+				// even if a target name is a match, it could be targeting a different kind than the app workload
+				if details.Kind == string(app.Spec.ProcessType(deployment, process.Name)) && details.APIVersion == "apps/v1" {
 					hpaTargets[process.Name] = true
 				}
 			}
@@ -215,7 +299,11 @@ func hpaTargetMap(app *ketchv1.App, hpaList v2beta1.HorizontalPodAutoscalerList)
 
 type appReconcileResult struct {
 	useTimeout bool
-	err        error
+	// rollingOut is true if workloads of the latest deployment aren't updated and ready yet.
+	rollingOut bool
+	// rolloutFailure describes why workloads of the latest deployment failed to roll out.
+	rolloutFailure string
+	err            error
 }
 
 // isConflictError returns true if AppReconciler was trying to update an App CR and got a conflict error.
@@ -339,6 +427,33 @@ func (cli workloadClient) Get(ctx context.Context) (*workload, error) {
 			}
 		}
 		return &w, nil
+	case ketchv1.DaemonSetAppType:
+		o, err := cli.k8sClient.AppsV1().DaemonSets(cli.workloadNamespace).Get(ctx, cli.workloadName, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		// a daemonset runs one pod per eligible node, so the desired number of replicas is the number of such nodes.
+		w := workload{
+			Name:               o.Name,
+			Replicas:           int(o.Status.DesiredNumberScheduled),
+			UpdatedReplicas:    int(o.Status.UpdatedNumberScheduled),
+			ReadyReplicas:      int(o.Status.NumberReady),
+			Generation:         int(o.Generation),
+			ObservedGeneration: int(o.Status.ObservedGeneration),
+		}
+		for _, c := range o.Status.Conditions {
+			w.Conditions = append(w.Conditions, condition{Type: string(c.Type), Reason: c.Reason})
+		}
+		e, err := cli.k8sClient.CoreV1().Events(cli.workloadNamespace).List(ctx, metav1.ListOptions{FieldSelector: "involvedObject.name=" + o.Name, TypeMeta: metav1.TypeMeta{Kind: "DaemonSet"}})
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range e.Items {
+			if e.FirstTimestamp == o.ObjectMeta.CreationTimestamp {
+				w.Events = append(w.Events, eventCondition{Type: e.Type, Reason: e.Reason, Message: e.Message})
+			}
+		}
+		return &w, nil
 	}
 	return nil, fmt.Errorf("unknown workload type")
 }
@@ -349,24 +464,79 @@ func (r *AppReconciler) reconcile(ctx context.Context, app *ketchv1.App, logger
 			err: fmt.Errorf(`app "%s" must be linked to a kubernetes namespace`, app.Name),
 		}
 	}
-	tpls, err := r.TemplateReader.Get(templates.IngressConfigMapName(app.Spec.Ingress.Controller.IngressType.String()))
-	if err != nil {
+	if app.Spec.NamespaceStrategy == ketchv1.PerAppNamespace {
+		if err := r.ensureAppNamespace(ctx, app); err != nil {
+			return appReconcileResult{err: err}
+		}
+	}
+	if err := r.applySchedules(ctx, app); err != nil {
+		return appReconcileResult{err: err}
+	}
+	if err := r.provisionDependencies(ctx, app); err != nil {
+		return appReconcileResult{err: err}
+	}
+	if pending := app.PendingDependencies(); len(pending) > 0 {
 		return appReconcileResult{
-			err: fmt.Errorf(`failed to read configmap with the app's chart templates: %w`, err),
+			err:        fmt.Errorf("waiting for dependencies %s", strings.Join(pending, ", ")),
+			useTimeout: true,
 		}
 	}
+	// a failed pre-deploy or release process isn't retried until the app is deployed again, so its logs are kept.
+	if app.ReleaseFailed() {
+		return appReconcileResult{
+			err: errors.New(deployHookFailed(app, app.Status.ReleaseFailure.Process, app.Status.ReleaseFailure.Version)),
+		}
+	}
+	tpls, err := templates.AppTemplates(r.TemplateReader, app.Spec.Ingress.Controller.IngressType.String(), app.Spec.Ingress.Controller.Templates)
+	if err != nil {
+		return appReconcileResult{err: err}
+	}
+
+	envSets, err := ketchv1.GetAppEnvSets(ctx, r.Client, app)
+	if err != nil {
+		return appReconcileResult{err: err}
+	}
+	bindingSecrets, err := ketchv1.GetAppBindingSecrets(ctx, r.Client, app)
+	if err != nil {
+		return appReconcileResult{err: err}
+	}
 
 	appChrt, err := chart.New(app,
 		chart.WithExposedPorts(app.ExposedPorts()),
-		chart.WithTemplates(*tpls))
+		chart.WithTemplates(*tpls),
+		chart.WithEnvSets(envSets),
+		chart.WithServiceBindingSecrets(bindingSecrets))
 	if err != nil {
 		return appReconcileResult{err: err}
 	}
+	r.recordDeployProgress(app, "helm chart rendered")
 
-	helmClient, err := r.HelmFactoryFn(app.Spec.Namespace)
+	engine, err := chartEngine(ctx, r.Client, installedEngine(app.Status.Engine, app.Status.Conditions))
 	if err != nil {
 		return appReconcileResult{err: err}
 	}
+	app.Status.Engine = engine
+	helmClient, err := newChartClient(engine, app.Spec.Namespace, r.HelmFactoryFn, r.ApplyFactoryFn)
+	if err != nil {
+		return appReconcileResult{err: err}
+	}
+
+	// "ketch app repair" asks to unlock a stuck helm release without waiting for the timeout.
+	if _, ok := app.Annotations[utils.KetchRepairRequestedAnnotation]; ok {
+		if err := helmClient.RepairChart(app.Name); err != nil {
+			return appReconcileResult{
+				err: fmt.Errorf("failed to repair helm chart: %w", err),
+			}
+		}
+		// the chart is upgraded again even if it didn't change.
+		app.Status.ManifestHash = ""
+		delete(app.Annotations, utils.KetchRepairRequestedAnnotation)
+		if err := r.Update(ctx, app); err != nil {
+			return appReconcileResult{
+				err: fmt.Errorf("failed to update app crd: %w", err),
+			}
+		}
+	}
 
 	// check for canary deployment
 	if app.Spec.Canary.Active {
@@ -396,6 +566,9 @@ func (r *AppReconciler) reconcile(ctx context.Context, app *ketchv1.App, logger
 					err: fmt.Errorf("failed to update app crd: %w", err),
 				}
 			}
+			canaryRollbacksTotal.WithLabelValues(app.Spec.Namespace).Inc()
+			r.Recorder.Eventf(app, v1.EventTypeWarning, ketchv1.CanaryRolledBack, "canary pods are not running: %v, traffic is routed back to version %d", err, app.Spec.Deployments[0].Version)
+			r.notify(app, ketchv1.CanaryRolledBackEvent, fmt.Sprintf("canary pods are not running: %v, traffic is routed back to version %d", err, app.Spec.Deployments[0].Version))
 		}
 
 		var hpaList v2beta1.HorizontalPodAutoscalerList
@@ -416,20 +589,172 @@ func (r *AppReconciler) reconcile(ctx context.Context, app *ketchv1.App, logger
 				err: fmt.Errorf("canary update failed: %w", err),
 			}
 		}
+		if !app.Spec.Canary.Active {
+			r.notify(app, ketchv1.CanaryPromotedEvent, fmt.Sprintf("version %d receives all traffic", app.Spec.Deployments[0].Version))
+		}
 	}
 
-	_, err = helmClient.UpdateChart(*appChrt, chart.NewChartConfig(*app))
+	chartConfig := chart.NewChartConfig(*app)
+	manifestHash, err := chart.ManifestHash(*appChrt, chartConfig, engine, r.HelmStorage, app.Spec.Namespace, r.Client, logger)
+	if err != nil {
+		return appReconcileResult{
+			err: fmt.Errorf("failed to render helm chart: %w", err),
+		}
+	}
+	hooksVersion, runsHooks := appChrt.DeployHooksVersion()
+	// periodic resyncs don't upgrade a chart whose manifests didn't change since its last successful upgrade
+	// unless its release changed, its resources are missing or its resources were edited and the drift policy reverts the edits.
+	if !runsHooks && manifestHash == app.Status.ManifestHash {
+		revision, err := helmClient.ReleaseRevision(app.Name)
+		if err != nil {
+			return appReconcileResult{
+				err: fmt.Errorf("failed to get helm release: %w", err),
+			}
+		}
+		if revision == "" || revision != app.Status.ReleaseRevision {
+			if app.Status.ReleaseRevision != "" {
+				r.Recorder.Event(app, v1.EventTypeNormal, ketchv1.AppDriftReverted, "upgrading the chart, its release was rolled back, uninstalled or changed since the last upgrade")
+			}
+		} else {
+			drifted, err := r.detectDrift(ctx, app, appChrt, chartConfig, logger)
+			if err != nil {
+				return appReconcileResult{
+					err: fmt.Errorf("failed to detect drift: %w", err),
+				}
+			}
+			policy, err := driftPolicy(ctx, r.Client)
+			if err != nil {
+				return appReconcileResult{err: err}
+			}
+			missing := missingResources(drifted)
+			if len(missing) == 0 && (len(drifted) == 0 || policy == chart.ReportDriftPolicy) {
+				r.setDrift(app, drifted, metav1.NewTime(r.Now()))
+				return r.watchRollout(ctx, app)
+			}
+			if len(missing) > 0 {
+				r.Recorder.Eventf(app, v1.EventTypeNormal, ketchv1.AppDriftReverted, "recreating %s", driftedNames(missing))
+			} else {
+				r.Recorder.Eventf(app, v1.EventTypeNormal, ketchv1.AppDriftReverted, "reverting edits of %s", driftedNames(drifted))
+			}
+		}
+	}
+	if runsHooks {
+		r.recordDeployProgress(app, "running deploy hooks")
+	}
+	// a failed upgrade may leave the chart partially applied, so it's upgraded again whatever the hash is.
+	app.Status.ManifestHash = ""
+	rel, err := helmClient.UpdateChart(*appChrt, chartConfig)
+	if runsHooks {
+		if statuses := chart.DeployHookStatuses(rel, app.Name); len(statuses) > 0 {
+			app.Status.DeployHooks = statuses
+		}
+	}
+	if runsHooks && chart.IsPreDeployHookFailed(err) {
+		process := chart.ReleaseProcessName
+		if failed := failedDeployHook(app.Status.DeployHooks, hooksVersion); failed != nil {
+			process = failed.Process
+		}
+		app.Status.ReleaseFailure = &ketchv1.ReleaseFailure{
+			Version:            hooksVersion,
+			Process:            process,
+			ObservedGeneration: app.Generation,
+			Message:            err.Error(),
+		}
+		message := deployHookFailed(app, process, hooksVersion)
+		r.Recorder.Event(app, v1.EventTypeWarning, ketchv1.AppReleaseFailed, message)
+		return appReconcileResult{
+			err: fmt.Errorf("%s: %w", message, err),
+		}
+	}
+	// the processes are already updated when a post-deploy hook fails, so the deployment goes on.
+	if runsHooks && chart.IsPostDeployHookFailed(err) {
+		r.Recorder.Event(app, v1.EventTypeWarning, ketchv1.AppDeployHookFailed, deployHookFailed(app, chart.PostDeployProcessName, hooksVersion))
+		err = nil
+	}
 	if err != nil {
+		r.Recorder.Event(app, v1.EventTypeWarning, ketchv1.AppHelmUpgradeFailed, err.Error())
 		return appReconcileResult{
 			err: fmt.Errorf("failed to update helm chart: %w", err),
 		}
 	}
+	app.Status.ReleaseFailure = nil
+	if rel != nil {
+		revision, err := helmClient.ReleaseRevision(app.Name)
+		if err != nil {
+			return appReconcileResult{
+				err: fmt.Errorf("failed to get helm release: %w", err),
+			}
+		}
+		app.Status.ManifestHash = manifestHash
+		app.Status.ReleaseRevision = revision
+		r.setDrift(app, nil, metav1.NewTime(r.Now()))
+	}
+	r.recordDeployProgress(app, "helm release upgraded")
+	if cnames := app.CNames(); len(cnames) > 0 {
+		r.recordDeployProgress(app, fmt.Sprintf("ingress ready for %s", strings.Join(cnames, ", ")))
+	}
+	return r.watchRollout(ctx, app)
+}
+
+// detectDrift returns resources of the app whose live state diverges from the app's chart,
+// replicas of workloads targeted by HPAs are expected to change.
+func (r *AppReconciler) detectDrift(ctx context.Context, app *ketchv1.App, appChrt *chart.ApplicationChart, config chart.ChartConfig, logger logr.Logger) ([]ketchv1.DriftedResource, error) {
+	var hpaList v2beta1.HorizontalPodAutoscalerList
+	if err := r.List(ctx, &hpaList, &client.ListOptions{Namespace: app.Spec.Namespace}); err != nil {
+		return nil, err
+	}
+	autoscaled := make(map[string]bool, len(hpaList.Items))
+	for _, hpa := range hpaList.Items {
+		autoscaled[hpa.Spec.ScaleTargetRef.Name] = true
+	}
+	return chart.DetectDrift(ctx, *appChrt, config, app.Spec.Namespace, r.Client, logger, autoscaled)
+}
+
+// setDrift records the app's drifted resources, the Drifted condition is only set once the app has drifted.
+func (r *AppReconciler) setDrift(app *ketchv1.App, drifted []ketchv1.DriftedResource, now metav1.Time) {
+	if len(drifted) > 0 {
+		if c := app.Status.Condition(ketchv1.Drifted); c == nil || c.Status != v1.ConditionTrue {
+			r.Recorder.Eventf(app, v1.EventTypeWarning, ketchv1.AppDrifted, "%s diverge from the app's chart", driftedNames(drifted))
+		}
+		app.Status.DriftedResources = drifted
+		app.SetCondition(ketchv1.Drifted, v1.ConditionTrue, fmt.Sprintf("%s diverge from the app's chart", driftedNames(drifted)), now)
+		return
+	}
+	app.Status.DriftedResources = nil
+	if app.Status.Condition(ketchv1.Drifted) != nil {
+		app.SetCondition(ketchv1.Drifted, v1.ConditionFalse, "", now)
+	}
+}
+
+// missingResources returns drifted resources that don't exist anymore, they are recreated whatever the drift policy is.
+func missingResources(drifted []ketchv1.DriftedResource) []ketchv1.DriftedResource {
+	var missing []ketchv1.DriftedResource
+	for _, d := range drifted {
+		if d.Missing {
+			missing = append(missing, d)
+		}
+	}
+	return missing
+}
+
+// driftedNames returns kinds and names of drifted resources, e.g. "Deployment/app-web-1, Service/app-web-1".
+func driftedNames(drifted []ketchv1.DriftedResource) string {
+	names := make([]string, 0, len(drifted))
+	for _, d := range drifted {
+		names = append(names, fmt.Sprintf("%s/%s", d.Kind, d.Name))
+	}
+	return strings.Join(names, ", ")
+}
 
+// watchRollout records events of the app's latest deployment while its workloads are rolling out.
+func (r *AppReconciler) watchRollout(ctx context.Context, app *ketchv1.App) appReconcileResult {
 	UpdateAppLabelsForIngress(app)
 
 	if len(app.Spec.Deployments) > 0 && !app.Spec.Canary.Active {
 		// use latest deployment and watch events for each process
 		latestDeployment := app.Spec.Deployments[len(app.Spec.Deployments)-1]
+		rollingOut := false
+		rolloutFailure := ""
 		for _, process := range latestDeployment.Processes {
 
 			cli, err := kubernetes.NewForConfig(r.Config)
@@ -442,7 +767,7 @@ func (r *AppReconciler) reconcile(ctx context.Context, app *ketchv1.App, logger
 				k8sClient:         cli,
 				workloadName:      fmt.Sprintf("%s-%s-%d", app.GetName(), process.Name, latestDeployment.Version),
 				workloadNamespace: app.Spec.Namespace,
-				workloadType:      app.Spec.GetType(),
+				workloadType:      app.Spec.ProcessType(latestDeployment, process.Name),
 			}
 
 			wl, err := wc.Get(ctx)
@@ -451,6 +776,12 @@ func (r *AppReconciler) reconcile(ctx context.Context, app *ketchv1.App, logger
 					err: err,
 				}
 			}
+			rollingOut = rollingOut || wl.ObservedGeneration < wl.Generation || wl.UpdatedReplicas != wl.Replicas || wl.ReadyReplicas != wl.Replicas
+			for _, c := range wl.Conditions {
+				if c.Type == DeploymentProgressing && c.Reason == deadlineExeceededProgressCond {
+					rolloutFailure = fmt.Sprintf("deployment %q exceeded its progress deadline", wl.Name)
+				}
+			}
 
 			err = r.watchDeployEvents(ctx, app, &wc, wl, &process, r.Recorder)
 			if err != nil {
@@ -463,13 +794,92 @@ func (r *AppReconciler) reconcile(ctx context.Context, app *ketchv1.App, logger
 		// in order to ensure events actually get sent. It seems the lazyRecorder we use
 		// can stop with unhandled messages if the reconciler rapidly requeues.
 		return appReconcileResult{
-			useTimeout: true,
+			useTimeout:     true,
+			rollingOut:     rollingOut,
+			rolloutFailure: rolloutFailure,
 		}
 	}
 
 	return appReconcileResult{}
 }
 
+// setDeployingCondition sets the Deploying condition of the app's latest deployment, e.g. "version 3 is rolling out".
+func setDeployingCondition(app *ketchv1.App, deploying bool, state string, now metav1.Time) {
+	if len(app.Spec.Deployments) == 0 {
+		return
+	}
+	status := v1.ConditionFalse
+	if deploying {
+		status = v1.ConditionTrue
+	}
+	latest := app.Spec.Deployments[len(app.Spec.Deployments)-1]
+	app.SetCondition(ketchv1.Deploying, status, fmt.Sprintf("version %d %s", latest.Version, state), now)
+}
+
+// updateDeployingCondition sets the Deploying condition according to the rollout of the app's latest deployment.
+// A rollout that fails or doesn't finish within DefaultPodRunningTimeout stops being in progress,
+// so it doesn't block other deployments of the app forever.
+func updateDeployingCondition(app *ketchv1.App, result appReconcileResult, now metav1.Time) {
+	if len(app.Spec.Deployments) == 0 {
+		return
+	}
+	if result.rolloutFailure != "" {
+		setDeployingCondition(app, false, "failed to roll out: "+result.rolloutFailure, now)
+		return
+	}
+	if !result.rollingOut {
+		setDeployingCondition(app, false, "is deployed", now)
+		return
+	}
+	latest := app.Spec.Deployments[len(app.Spec.Deployments)-1]
+	c := app.Status.Condition(ketchv1.Deploying)
+	if c != nil && c.Status == v1.ConditionFalse && c.Message != fmt.Sprintf("version %d is deployed", latest.Version) &&
+		strings.HasPrefix(c.Message, fmt.Sprintf("version %d ", latest.Version)) {
+		// the rollout has already failed, keep its message.
+		return
+	}
+	if c != nil && c.Status == v1.ConditionTrue && c.LastTransitionTime != nil &&
+		c.Message == fmt.Sprintf("version %d is rolling out", latest.Version) &&
+		now.Sub(c.LastTransitionTime.Time) > DefaultPodRunningTimeout {
+		setDeployingCondition(app, false, fmt.Sprintf("didn't roll out in %s", DefaultPodRunningTimeout), now)
+		return
+	}
+	setDeployingCondition(app, true, "is rolling out", now)
+}
+
+// deployHookFailed returns a message about a failed deploy hook telling how to get its logs.
+func deployHookFailed(app *ketchv1.App, process string, version ketchv1.DeploymentVersion) string {
+	if process == "" {
+		process = chart.ReleaseProcessName
+	}
+	return fmt.Sprintf(deployHookFailedMessage, process, version, app.Name, process, version)
+}
+
+// failedDeployHook returns the failed deploy hook of the deployment version.
+func failedDeployHook(statuses []ketchv1.DeployHookStatus, version ketchv1.DeploymentVersion) *ketchv1.DeployHookStatus {
+	for i := range statuses {
+		if statuses[i].Version == version && statuses[i].Phase == string(release.HookPhaseFailed) {
+			return &statuses[i]
+		}
+	}
+	return nil
+}
+
+// recordDeployProgress records a step of rolling out the app's latest deployment,
+// "ketch app deploy --wait" prints these events while waiting for the deployment.
+// Reconciles of an app without a new deployment don't record steps.
+func (r *AppReconciler) recordDeployProgress(app *ketchv1.App, message string) {
+	if len(app.Spec.Deployments) == 0 {
+		return
+	}
+	latest := app.Spec.Deployments[len(app.Spec.Deployments)-1]
+	if app.Status.DeploymentRecord(latest.Version) != nil {
+		return
+	}
+	event := newAppDeploymentEvent(app, ketchv1.AppReconcileUpdate, message, "", "")
+	r.Recorder.AnnotatedEventf(app, event.Annotations, v1.EventTypeNormal, event.Reason, event.Description)
+}
+
 // watchDeployEvents watches a namespace for events and, after a deployment has started updating, records events
 // with updated deployment status and/or healthcheck and timeout failures
 func (r *AppReconciler) watchDeployEvents(ctx context.Context, app *ketchv1.App, cli *workloadClient, wl *workload, process *ketchv1.ProcessSpec, recorder record.EventRecorder) error {
@@ -487,6 +897,10 @@ func (r *AppReconciler) watchDeployEvents(ctx context.Context, app *ketchv1.App,
 		return err
 	}
 
+	// the workload is being rolled out if its spec has been changed or its units aren't updated yet.
+	// Otherwise, the app is reconciled without changes and there is nothing to notify about.
+	rollingOut := wl.ObservedGeneration < wl.Generation || wl.UpdatedReplicas != wl.Replicas || wl.ReadyReplicas != wl.Replicas
+
 	// wait for Deployment Generation
 	timeout := time.After(DefaultPodRunningTimeout)
 	for wl.ObservedGeneration < wl.Generation {
@@ -520,11 +934,14 @@ func (r *AppReconciler) watchDeployEvents(ctx context.Context, app *ketchv1.App,
 
 	reconcileStartedEvent := newAppDeploymentEvent(app, ketchv1.AppReconcileStarted, fmt.Sprintf("Updating units [%s]", process.Name), process.Name, "")
 	recorder.AnnotatedEventf(app, reconcileStartedEvent.Annotations, v1.EventTypeNormal, reconcileStartedEvent.Reason, reconcileStartedEvent.Description)
-	go r.watchFunc(ctx, cleanup, app, process.Name, recorder, watcher, cli, wl, timeout)
+	if rollingOut {
+		r.notify(app, ketchv1.DeployStartedEvent, reconcileStartedEvent.Description)
+	}
+	go r.watchFunc(ctx, cleanup, app, process.Name, recorder, watcher, cli, wl, timeout, rollingOut)
 	return nil
 }
 
-func (r *AppReconciler) watchFunc(ctx context.Context, cleanup cleanupFunc, app *ketchv1.App, processName string, recorder record.EventRecorder, watcher watch.Interface, cli *workloadClient, wl *workload, timeout <-chan time.Time) error {
+func (r *AppReconciler) watchFunc(ctx context.Context, cleanup cleanupFunc, app *ketchv1.App, processName string, recorder record.EventRecorder, watcher watch.Interface, cli *workloadClient, wl *workload, timeout <-chan time.Time, notify bool) error {
 	defer cleanup()
 
 	var err error
@@ -544,6 +961,9 @@ func (r *AppReconciler) watchFunc(ctx context.Context, cleanup cleanupFunc, app
 			if c.Type == DeploymentProgressing && c.Reason == deadlineExeceededProgressCond {
 				deadlineExceededEvent := newAppDeploymentEvent(app, ketchv1.AppReconcileError, fmt.Sprintf("deployment %q exceeded its progress deadline", wl.Name), processName, "")
 				recorder.AnnotatedEventf(app, deadlineExceededEvent.Annotations, v1.EventTypeWarning, deadlineExceededEvent.Reason, deadlineExceededEvent.Description)
+				if notify {
+					r.notify(app, ketchv1.DeployFailedEvent, deadlineExceededEvent.Description)
+				}
 				return errors.Errorf("deployment %q exceeded its progress deadline", wl.Name)
 			}
 		}
@@ -596,12 +1016,18 @@ func (r *AppReconciler) watchFunc(ctx context.Context, cleanup cleanupFunc, app
 			err = createDeployTimeoutError(ctx, cli.k8sClient, app, time.Since(now), cli.workloadNamespace, app.GroupVersionKind().Group, "healthcheck")
 			healthcheckTimeoutEvent := newAppDeploymentEvent(app, ketchv1.AppReconcileError, fmt.Sprintf("error waiting for healthcheck: %s", err.Error()), processName, podName)
 			recorder.AnnotatedEventf(app, healthcheckTimeoutEvent.Annotations, v1.EventTypeWarning, healthcheckTimeoutEvent.Reason, healthcheckTimeoutEvent.Description)
+			if notify {
+				r.notify(app, ketchv1.DeployFailedEvent, healthcheckTimeoutEvent.Description)
+			}
 			return err
 		case <-timeout:
 			podName, _ := checkPodStatus(r.Group, r.Client, app.Name, app.Spec.Deployments[len(app.Spec.Deployments)-1].Version)
 			err = createDeployTimeoutError(ctx, cli.k8sClient, app, time.Since(now), cli.workloadNamespace, app.GroupVersionKind().Group, "full rollout")
 			timeoutEvent := newAppDeploymentEvent(app, ketchv1.AppReconcileError, fmt.Sprintf("deployment timeout: %s", err.Error()), processName, podName)
 			recorder.AnnotatedEventf(app, timeoutEvent.Annotations, v1.EventTypeWarning, timeoutEvent.Reason, timeoutEvent.Description)
+			if notify {
+				r.notify(app, ketchv1.DeployFailedEvent, timeoutEvent.Description)
+			}
 			return err
 		case <-ctx.Done():
 			return ctx.Err()
@@ -628,9 +1054,248 @@ func (r *AppReconciler) watchFunc(ctx context.Context, cleanup cleanupFunc, app
 	outcome := ketchv1.AppReconcileOutcome{AppName: app.Name, DeploymentCount: wl.ReadyReplicas}
 	outcomeEvent := newAppDeploymentEvent(app, ketchv1.AppReconcileComplete, outcome.String(), processName, "")
 	recorder.AnnotatedEventf(app, outcomeEvent.Annotations, v1.EventTypeNormal, outcomeEvent.Reason, outcomeEvent.Description)
+	if notify {
+		r.notify(app, ketchv1.DeploySucceededEvent, fmt.Sprintf("%d of %d units of process %s are ready", wl.ReadyReplicas, specReplicas, processName))
+	}
+	return nil
+}
+
+// validateCnames records in the app's status whether DNS records of its cnames point to the ingress controller.
+func (r *AppReconciler) validateCnames(ctx context.Context, app *ketchv1.App) {
+	endpoint := app.Spec.Ingress.Controller.ServiceEndpoint
+	if r.Resolver == nil || endpoint == "" {
+		return
+	}
+	wasInvalid := map[string]bool{}
+	for _, status := range app.Status.Cnames {
+		wasInvalid[status.Name] = !status.Valid
+	}
+	statuses := make([]ketchv1.CnameStatus, 0, len(app.Spec.Ingress.Cnames))
+	checked := map[string]bool{}
+	for _, cname := range app.Spec.Ingress.Cnames {
+		// cnames with different path prefixes share a DNS record.
+		if checked[cname.Name] {
+			continue
+		}
+		checked[cname.Name] = true
+		status := ketchv1.CnameStatus{Name: cname.Name, Valid: true, LastCheckTime: metav1.NewTime(r.Now())}
+		lookupCtx, cancel := context.WithTimeout(ctx, dnsLookupTimeout)
+		if err := validation.ValidateCnameDNS(lookupCtx, r.Resolver, cname.Name, endpoint); err != nil {
+			status.Valid = false
+			status.Message = err.Error()
+			// report a cname once when it becomes invalid, not on every reconcile.
+			if !wasInvalid[cname.Name] {
+				r.Recorder.Event(app, v1.EventTypeWarning, ketchv1.AppIngressError, err.Error())
+			}
+		}
+		cancel()
+		statuses = append(statuses, status)
+	}
+	app.Status.Cnames = statuses
+}
+
+// applySchedules sets units of the app's scaling schedules that are due and saves the app,
+// so the chart is rendered with the scheduled units.
+func (r *AppReconciler) applySchedules(ctx context.Context, app *ketchv1.App) error {
+	if len(app.Spec.Schedules) == 0 && len(app.Status.Schedules) == 0 {
+		return nil
+	}
+	applied, err := app.ApplySchedules(r.Now())
+	if err != nil {
+		return fmt.Errorf("failed to apply scaling schedules: %w", err)
+	}
+	if len(applied) == 0 {
+		return nil
+	}
+	// updating the app overwrites its status with the stored one.
+	statuses := app.Status.Schedules
+	if err := r.Update(ctx, app); err != nil {
+		return fmt.Errorf("failed to update app crd: %w", err)
+	}
+	app.Status.Schedules = statuses
+	r.Recorder.Eventf(app, v1.EventTypeNormal, ketchv1.AppScheduledScaling, "units set by schedules %s", strings.Join(applied, ", "))
+	return nil
+}
+
+// provisionDependencies provisions dependencies the app requires in its ketch.yaml and updates their statuses.
+func (r *AppReconciler) provisionDependencies(ctx context.Context, app *ketchv1.App) error {
+	required := app.RequiredDependencies()
+	if len(required) == 0 {
+		app.Status.Dependencies = nil
+		return nil
+	}
+	statuses := make([]ketchv1.DependencyStatus, 0, len(required))
+	for _, name := range required {
+		config, ok := app.Spec.Ingress.Controller.DependencyProvisioner(name)
+		if !ok {
+			return fmt.Errorf("no provisioner of dependency %q is configured in %s of the ingress configmap", name, ketchv1.DependencyProvisionersKey)
+		}
+		status, err := r.Provisioners.Provision(ctx, app, config)
+		if err != nil {
+			return fmt.Errorf("failed to provision dependency %q: %w", name, err)
+		}
+		statuses = append(statuses, status)
+	}
+	app.Status.Dependencies = statuses
+	return nil
+}
+
+// recordScaling records an event when the number of the app's units changes without a new deployment,
+// canary steps and new deployments change units on their own and have their own events.
+func (r *AppReconciler) recordScaling(app *ketchv1.App, deployed bool) {
+	units := app.Units()
+	previous := app.Status.Units
+	app.Status.Units = &units
+	if previous == nil || *previous == units || deployed || app.Spec.Canary.Active {
+		return
+	}
+	reason := ketchv1.AppScaledUp
+	if units < *previous {
+		reason = ketchv1.AppScaledDown
+	}
+	r.Recorder.Eventf(app, v1.EventTypeNormal, reason, "scaled from %d to %d units", *previous, units)
+}
+
+// ensureAppNamespace creates a dedicated namespace of an app with the "perApp" namespace strategy
+// along with its resource quota and a network policy that accepts traffic only from the namespace itself
+// and from namespaces that don't belong to apps, like the ones of ingress controllers.
+func (r *AppReconciler) ensureAppNamespace(ctx context.Context, app *ketchv1.App) error {
+	appNameLabel := r.Group + "/app-name"
+	var namespace v1.Namespace
+	err := r.Get(ctx, client.ObjectKey{Name: app.Spec.Namespace}, &namespace)
+	switch {
+	case k8sErrors.IsNotFound(err):
+		namespace = v1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:   app.Spec.Namespace,
+			Labels: map[string]string{appNameLabel: app.Name},
+		}}
+		if err := r.Create(ctx, &namespace); err != nil {
+			return fmt.Errorf("failed to create the app's namespace: %w", err)
+		}
+	case err != nil:
+		return fmt.Errorf("failed to get the app's namespace: %w", err)
+	case namespace.Labels[appNameLabel] != app.Name:
+		return fmt.Errorf(`namespace "%s" already exists and is not dedicated to app "%s"`, namespace.Name, app.Name)
+	}
+
+	quota := v1.ResourceQuota{ObjectMeta: metav1.ObjectMeta{Name: app.Name + "-quota", Namespace: app.Spec.Namespace}}
+	if len(app.Spec.NamespaceQuota) == 0 {
+		if err := r.Delete(ctx, &quota); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to remove the app's resource quota: %w", err)
+		}
+	} else if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, &quota, func() error {
+		quota.Labels = map[string]string{appNameLabel: app.Name}
+		quota.Spec.Hard = app.Spec.NamespaceQuota
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to update the app's resource quota: %w", err)
+	}
+
+	policy := networkingv1.NetworkPolicy{ObjectMeta: metav1.ObjectMeta{Name: app.Name + "-isolation", Namespace: app.Spec.Namespace}}
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, &policy, func() error {
+		policy.Labels = map[string]string{appNameLabel: app.Name}
+		policy.Spec = networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+			Ingress: []networkingv1.NetworkPolicyIngressRule{{
+				From: []networkingv1.NetworkPolicyPeer{
+					{PodSelector: &metav1.LabelSelector{}},
+					{NamespaceSelector: &metav1.LabelSelector{
+						MatchExpressions: []metav1.LabelSelectorRequirement{{Key: appNameLabel, Operator: metav1.LabelSelectorOpDoesNotExist}},
+					}},
+				},
+			}},
+		}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to update the app's network policy: %w", err)
+	}
+	return nil
+}
+
+// ingressObjectKind is a kind of resources rendered for an ingress controller.
+type ingressObjectKind struct {
+	gvk schema.GroupVersionKind
+	// namespace of the resources, empty means the app's namespace.
+	namespace string
+}
+
+// ingressObjectKinds lists kinds of resources rendered by templates of each ingress controller type.
+var ingressObjectKinds = map[ketchv1.IngressControllerType][]ingressObjectKind{
+	ketchv1.NginxIngressControllerType: {
+		{gvk: schema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1", Kind: "Ingress"}},
+		{gvk: schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"}},
+	},
+	ketchv1.TraefikIngressControllerType: {
+		{gvk: schema.GroupVersionKind{Group: "traefik.containo.us", Version: "v1alpha1", Kind: "IngressRoute"}},
+		{gvk: schema.GroupVersionKind{Group: "traefik.containo.us", Version: "v1alpha1", Kind: "Middleware"}},
+		{gvk: schema.GroupVersionKind{Group: "traefik.containo.us", Version: "v1alpha1", Kind: "ServersTransport"}},
+		{gvk: schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"}},
+	},
+	ketchv1.IstioIngressControllerType: {
+		{gvk: schema.GroupVersionKind{Group: "networking.istio.io", Version: "v1alpha3", Kind: "Gateway"}},
+		{gvk: schema.GroupVersionKind{Group: "networking.istio.io", Version: "v1alpha3", Kind: "VirtualService"}},
+		{gvk: schema.GroupVersionKind{Group: "networking.istio.io", Version: "v1alpha3", Kind: "DestinationRule"}},
+		{gvk: schema.GroupVersionKind{Group: "networking.istio.io", Version: "v1alpha3", Kind: "EnvoyFilter"}},
+		{gvk: schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"}, namespace: "istio-system"},
+	},
+}
+
+// migrateIngress removes resources of the previous ingress controller once the app's chart has been rendered
+// for a new ingress controller type. Helm removes resources it tracks itself,
+// this catches resources left behind, for example, by a release that failed to upgrade.
+func (r *AppReconciler) migrateIngress(ctx context.Context, app *ketchv1.App) {
+	current := app.Spec.Ingress.Controller.IngressType
+	previous := app.Status.IngressType
+	if previous == "" || previous == current {
+		app.Status.IngressType = current
+		return
+	}
+	if err := r.removeIngressObjects(ctx, app, previous, current); err != nil {
+		// keep the previous type in the status to retry on the next reconcile.
+		message := fmt.Sprintf("failed to remove %s resources: %v", previous, err)
+		app.SetCondition(ketchv1.IngressMigrated, v1.ConditionFalse, message, metav1.NewTime(r.Now()))
+		r.Recorder.Event(app, v1.EventTypeWarning, ketchv1.AppIngressError, message)
+		return
+	}
+	message := fmt.Sprintf("ingress migrated from %s to %s", previous, current)
+	app.SetCondition(ketchv1.IngressMigrated, v1.ConditionTrue, message, metav1.NewTime(r.Now()))
+	r.Recorder.Event(app, v1.EventTypeNormal, ketchv1.AppReconcileOutcomeReason, message)
+	app.Status.IngressType = current
+}
+
+func (r *AppReconciler) removeIngressObjects(ctx context.Context, app *ketchv1.App, previous, current ketchv1.IngressControllerType) error {
+	inUse := map[ingressObjectKind]bool{}
+	for _, kind := range ingressObjectKinds[current] {
+		inUse[kind] = true
+	}
+	for _, kind := range ingressObjectKinds[previous] {
+		if inUse[kind] {
+			continue
+		}
+		namespace := kind.namespace
+		if namespace == "" {
+			namespace = app.Spec.Namespace
+		}
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(kind.gvk)
+		err := r.DeleteAllOf(ctx, obj, client.InNamespace(namespace), client.MatchingLabels{r.Group + "/app-name": app.Name})
+		// the CRD of the previous ingress controller may be uninstalled already.
+		if err != nil && !meta.IsNoMatchError(err) && !k8sErrors.IsNotFound(err) {
+			return fmt.Errorf("%s: %w", kind.gvk.Kind, err)
+		}
+	}
 	return nil
 }
 
+// notify sends a notification about the event to the receivers configured in the app's spec and cluster-wide receivers.
+func (r *AppReconciler) notify(app *ketchv1.App, event ketchv1.NotificationEvent, message string) {
+	if r.Notifier == nil || len(app.Spec.Notifications) == 0 && len(app.Spec.Ingress.Controller.Notifications) == 0 {
+		return
+	}
+	r.Notifier.Notify(app, event, message)
+}
+
 // appDeploymentEventFromWatchEvent converts a watch.Event into an AppDeploymentEvent
 func appDeploymentEventFromWatchEvent(watchEvent watch.Event, app *ketchv1.App, processName string) *ketchv1.AppDeploymentEvent {
 	event, ok := watchEvent.Object.(*v1.Event)
@@ -801,15 +1466,17 @@ func checkPodStatus(group string, c client.Client, appName string, depVersion ke
 	return "", nil
 }
 
+// deleteChart removes resources of the app before its finalizer is removed, so nothing is left behind once the app is gone.
+// While the cleanup fails, the app's Removed condition explains why the app still exists.
 func (r *AppReconciler) deleteChart(ctx context.Context, app *ketchv1.App) error {
 	if uninstallHelmChart(r.Group, app.Annotations) {
-		targetNamespace := app.Spec.Namespace
-
-		helmClient, err := r.HelmFactoryFn(targetNamespace)
-		if err != nil {
-			return err
-		}
-		if err = helmClient.DeleteChart(app.Name); err != nil {
+		if err := r.cleanup(ctx, app); err != nil {
+			message := fmt.Sprintf("failed to remove resources of the app: %v", err)
+			r.Recorder.Event(app, v1.EventTypeWarning, ketchv1.AppRemoveFailed, message)
+			app.SetCondition(ketchv1.Removed, v1.ConditionFalse, message, metav1.NewTime(r.Now()))
+			if updateErr := r.Status().Update(ctx, app); updateErr != nil {
+				r.Log.Error(updateErr, "failed to update app status", "app", app.Name)
+			}
 			return err
 		}
 	}
@@ -818,8 +1485,124 @@ func (r *AppReconciler) deleteChart(ctx context.Context, app *ketchv1.App) error
 	if err := r.Update(ctx, app); err != nil {
 		return err
 	}
+	r.notify(app, ketchv1.AppRemovedEvent, "app is removed")
+	return nil
+}
+
+// cleanup uninstalls the app's helm release with its services and ingress objects,
+// then removes what helm doesn't track: ingress objects left by a failed upgrade, certificates of cnames,
+// volumes if the app was removed with --delete-volumes, and the app's own namespace along with its jobs.
+func (r *AppReconciler) cleanup(ctx context.Context, app *ketchv1.App) error {
+	targetNamespace := app.Spec.Namespace
+
+	helmClient, err := r.chartClient(ctx, app, targetNamespace)
+	if err != nil {
+		return err
+	}
+	if err = helmClient.DeleteChart(app.Name); err != nil {
+		return fmt.Errorf("failed to uninstall helm chart: %w", err)
+	}
+	ingressTypes := []ketchv1.IngressControllerType{app.Spec.Ingress.Controller.IngressType}
+	if app.Status.IngressType != "" && app.Status.IngressType != app.Spec.Ingress.Controller.IngressType {
+		// the app was removed before its ingress was migrated.
+		ingressTypes = append(ingressTypes, app.Status.IngressType)
+	}
+	for _, ingressType := range ingressTypes {
+		if err := r.removeIngressObjects(ctx, app, ingressType, ""); err != nil {
+			return fmt.Errorf("failed to remove ingress objects: %w", err)
+		}
+		if err := r.deleteCnameSecrets(ctx, app, ingressType); err != nil {
+			return err
+		}
+	}
+	if deleteVolumes(r.Group, app.Annotations) {
+		if err := r.deleteVolumeClaims(ctx, app); err != nil {
+			return err
+		}
+	}
+	if app.Spec.NamespaceStrategy == ketchv1.PerAppNamespace {
+		var namespace v1.Namespace
+		if err := r.Get(ctx, client.ObjectKey{Name: targetNamespace}, &namespace); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+		if namespace.Labels[r.Group+"/app-name"] != app.Name {
+			// the namespace wasn't created for the app, it's kept with everything else in it.
+			return nil
+		}
+		// jobs are removed first, so their charts are uninstalled before the namespace is gone.
+		jobs, err := r.deleteNamespaceJobs(ctx, targetNamespace)
+		if err != nil {
+			return err
+		}
+		if jobs > 0 {
+			return fmt.Errorf("waiting for %d jobs in namespace %s to be removed", jobs, targetNamespace)
+		}
+		if err := r.Delete(ctx, &namespace); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to remove namespace %s: %w", targetNamespace, err)
+		}
+	}
+	return nil
+}
+
+// deleteCnameSecrets removes secrets cert-manager issued for the app's cnames,
+// they are kept by cert-manager when certificates are removed. Secrets provided by users are kept too.
+func (r *AppReconciler) deleteCnameSecrets(ctx context.Context, app *ketchv1.App, ingressType ketchv1.IngressControllerType) error {
+	for _, kind := range ingressObjectKinds[ingressType] {
+		if kind.gvk.Kind != "Certificate" {
+			continue
+		}
+		namespace := kind.namespace
+		if namespace == "" {
+			namespace = app.Spec.Namespace
+		}
+		for _, cname := range app.Spec.Ingress.Cnames {
+			if len(cname.SecretName) > 0 {
+				continue
+			}
+			secret := v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: chart.CnameSecretName(app.Name, cname), Namespace: namespace}}
+			if err := r.Delete(ctx, &secret); client.IgnoreNotFound(err) != nil {
+				return fmt.Errorf("failed to remove secret %s of cname %s: %w", secret.Name, cname.Name, err)
+			}
+		}
+	}
 	return nil
+}
 
+// deleteVolumeClaims removes persistent volume claims created for the app's statefulsets.
+func (r *AppReconciler) deleteVolumeClaims(ctx context.Context, app *ketchv1.App) error {
+	var claims v1.PersistentVolumeClaimList
+	if err := r.List(ctx, &claims, client.InNamespace(app.Spec.Namespace), client.MatchingLabels{r.Group + "/app-name": app.Name}); err != nil {
+		return fmt.Errorf("failed to list persistent volume claims: %w", err)
+	}
+	for i := range claims.Items {
+		if err := r.Delete(ctx, &claims.Items[i]); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to remove persistent volume claim %s: %w", claims.Items[i].Name, err)
+		}
+	}
+	return nil
+}
+
+// deleteNamespaceJobs removes ketch jobs running in the namespace and returns how many jobs were found there.
+func (r *AppReconciler) deleteNamespaceJobs(ctx context.Context, namespace string) (int, error) {
+	var jobs ketchv1.JobList
+	if err := r.List(ctx, &jobs); err != nil {
+		return 0, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	found := 0
+	for i := range jobs.Items {
+		job := &jobs.Items[i]
+		if job.Spec.Namespace != namespace {
+			continue
+		}
+		found++
+		if !job.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if err := r.Delete(ctx, job); client.IgnoreNotFound(err) != nil {
+			return 0, fmt.Errorf("failed to remove job %s: %w", job.Name, err)
+		}
+	}
+	return found, nil
 }
 
 // UpdateAppLabelsForIngress updates an app's namespace labels to account for different ingresses.
@@ -833,11 +1616,70 @@ func UpdateAppLabelsForIngress(app *ketchv1.App) {
 	}
 }
 
+// chartClient returns a client installing the app's chart to the namespace with the app's engine.
+func (r *AppReconciler) chartClient(ctx context.Context, app *ketchv1.App, namespace string) (Helm, error) {
+	engine, err := chartEngine(ctx, r.Client, installedEngine(app.Status.Engine, app.Status.Conditions))
+	if err != nil {
+		return nil, err
+	}
+	return newChartClient(engine, namespace, r.HelmFactoryFn, r.ApplyFactoryFn)
+}
+
 func (r *AppReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	// to avoid re-queueing when app.status is changed
 	pred := predicate.GenerationChangedPredicate{}
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&ketchv1.App{}).
-		WithEventFilter(pred).
+		WithOptions(r.Options.controllerOptions()).
+		For(&ketchv1.App{}, builder.WithPredicates(pred)).
+		Watches(&source.Kind{Type: &ketchv1.EnvSet{}}, handler.EnqueueRequestsFromMapFunc(r.envSetApps), builder.WithPredicates(pred)).
+		// secrets have no generation, their data changes when credentials of bound services are rotated.
+		Watches(&source.Kind{Type: &v1.Secret{}}, handler.EnqueueRequestsFromMapFunc(r.bindingSecretApps), builder.WithPredicates(predicate.ResourceVersionChangedPredicate{})).
 		Complete(r)
 }
+
+// bindingSecretApps returns requests to reconcile apps with service bindings of the secret, so their pods get rotated credentials.
+func (r *AppReconciler) bindingSecretApps(obj client.Object) []reconcile.Request {
+	var apps ketchv1.AppList
+	if err := r.List(context.Background(), &apps); err != nil {
+		r.Log.Error(err, "failed to list apps bound to secret", "secret", obj.GetName(), "namespace", obj.GetNamespace())
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, app := range apps.Items {
+		if app.Spec.Namespace != obj.GetNamespace() {
+			continue
+		}
+		for _, binding := range app.Spec.ServiceBindings {
+			if binding.SecretName == obj.GetName() {
+				requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: app.Name}})
+				break
+			}
+		}
+		for _, dependency := range app.Status.Dependencies {
+			if dependency.SecretName == obj.GetName() {
+				requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: app.Name}})
+				break
+			}
+		}
+	}
+	return requests
+}
+
+// envSetApps returns requests to reconcile apps referencing the env set, so they get its changes.
+func (r *AppReconciler) envSetApps(obj client.Object) []reconcile.Request {
+	var apps ketchv1.AppList
+	if err := r.List(context.Background(), &apps); err != nil {
+		r.Log.Error(err, "failed to list apps referencing env set", "envSet", obj.GetName())
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, app := range apps.Items {
+		for _, name := range app.Spec.EnvSets {
+			if name == obj.GetName() {
+				requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: app.Name}})
+				break
+			}
+		}
+	}
+	return requests
+}
@@ -12,8 +12,11 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	"k8s.io/api/autoscaling/v2beta1"
 	v1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
@@ -26,10 +29,13 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	ctrlFake "sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
 	"github.com/theketchio/ketch/internal/chart"
+	"github.com/theketchio/ketch/internal/provisioner"
 	"github.com/theketchio/ketch/internal/templates"
+	"github.com/theketchio/ketch/internal/utils/conversions"
 )
 
 type templateReader struct {
@@ -47,6 +53,8 @@ func (t *templateReader) Get(name string) (*templates.Templates, error) {
 type helm struct {
 	updateChartResults map[string]error
 	deleteChartCalled  []string
+	repairChartCalled  []string
+	revisions          map[string]string
 }
 
 func (h *helm) UpdateChart(tv chart.TemplateValuer, config chart.ChartConfig, opts ...chart.InstallOption) (*release.Release, error) {
@@ -58,6 +66,15 @@ func (h *helm) DeleteChart(appName string) error {
 	return nil
 }
 
+func (h *helm) RepairChart(appName string) error {
+	h.repairChartCalled = append(h.repairChartCalled, appName)
+	return nil
+}
+
+func (h *helm) ReleaseRevision(appName string) (string, error) {
+	return h.revisions[appName], nil
+}
+
 type watchReactor struct {
 	action  clientTest.Action
 	watcher watch.Interface
@@ -361,7 +378,7 @@ func TestWatchDeployEvents(t *testing.T) {
 				workloadName:      "test",
 			}
 
-			err := r.watchFunc(ctx, cleanupFn, app, process.Name, recorder, watcher, &wc, &wl, timeout)
+			err := r.watchFunc(ctx, cleanupFn, app, process.Name, recorder, watcher, &wc, &wl, timeout, true)
 			if tc.expectedError != "" {
 				require.Equal(t, tc.expectedError, err.Error())
 				return
@@ -495,7 +512,7 @@ func TestCancelWatchDeployEvents(t *testing.T) {
 				workloadName:      "test",
 			}
 
-			err := r.watchFunc(ctx, func() {}, app, process.Name, recorder, watcher, &wc, &wl, timeout)
+			err := r.watchFunc(ctx, func() {}, app, process.Name, recorder, watcher, &wc, &wl, timeout, true)
 			require.EqualError(t, err, "context canceled")
 
 			// assert that watchFunc() ended early via context cancelation and that not all events were processed.
@@ -925,3 +942,684 @@ func TestUpdateNamespaceLabelsForIngress(t *testing.T) {
 		})
 	}
 }
+
+// staticResolver resolves every name to the given addresses.
+type staticResolver map[string][]string
+
+func (r staticResolver) LookupHost(_ context.Context, host string) ([]string, error) {
+	if addrs, ok := r[host]; ok {
+		return addrs, nil
+	}
+	return nil, fmt.Errorf("no such host %s", host)
+}
+
+func (r staticResolver) LookupCNAME(_ context.Context, host string) (string, error) {
+	return host + ".", nil
+}
+
+func TestAppReconciler_validateCnames(t *testing.T) {
+	now := time.Date(2021, 1, 1, 10, 0, 0, 0, time.UTC)
+	recorder := record.NewFakeRecorder(10)
+	r := AppReconciler{
+		Resolver: staticResolver{"theketch.io": {"10.10.10.10"}, "app.theketch.io": {"20.20.20.20"}},
+		Recorder: recorder,
+		Now:      func() time.Time { return now },
+	}
+	app := &ketchv1.App{
+		Spec: ketchv1.AppSpec{
+			Ingress: ketchv1.IngressSpec{
+				Controller: ketchv1.IngressControllerSpec{ServiceEndpoint: "10.10.10.10"},
+				Cnames:     ketchv1.CnameList{{Name: "theketch.io"}, {Name: "app.theketch.io"}},
+			},
+		},
+	}
+	r.validateCnames(context.Background(), app)
+	require.Equal(t, []ketchv1.CnameStatus{
+		{Name: "theketch.io", Valid: true, LastCheckTime: metav1.NewTime(now)},
+		{Name: "app.theketch.io", Message: "cname does not point to the ingress controller: app.theketch.io resolves to 20.20.20.20, expected 10.10.10.10", LastCheckTime: metav1.NewTime(now)},
+	}, app.Status.Cnames)
+	require.Equal(t, "Warning IngressError cname does not point to the ingress controller: app.theketch.io resolves to 20.20.20.20, expected 10.10.10.10", <-recorder.Events)
+
+	// an invalid cname is reported once.
+	r.validateCnames(context.Background(), app)
+	require.Len(t, recorder.Events, 0)
+}
+
+func TestAppReconciler_recordDeployProgress(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	r := AppReconciler{Recorder: recorder}
+	app := &ketchv1.App{
+		ObjectMeta: metav1.ObjectMeta{Name: "go-app"},
+		Spec: ketchv1.AppSpec{
+			Deployments: []ketchv1.AppDeploymentSpec{{Version: 1}, {Version: 2}},
+		},
+	}
+	app.RecordDeployments(metav1.NewTime(time.Now()), "")
+	// the latest deployment is already rolled out.
+	r.recordDeployProgress(app, "helm chart rendered")
+	require.Len(t, recorder.Events, 0)
+
+	app.Spec.Deployments = append(app.Spec.Deployments, ketchv1.AppDeploymentSpec{Version: 3})
+	r.recordDeployProgress(app, "helm chart rendered")
+	require.Equal(t, "Normal AppReconcileUpdate helm chart rendered", <-recorder.Events)
+}
+
+func TestAppReconciler_setDrift(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	r := AppReconciler{Recorder: recorder}
+	app := &ketchv1.App{ObjectMeta: metav1.ObjectMeta{Name: "go-app"}}
+	now := metav1.NewTime(time.Now())
+
+	r.setDrift(app, nil, now)
+	require.Nil(t, app.Status.Condition(ketchv1.Drifted))
+
+	drifted := []ketchv1.DriftedResource{
+		{Kind: "Deployment", Name: "go-app-web-1", Fields: []string{"spec.replicas"}},
+		{Kind: "Service", Name: "go-app-web-1", Missing: true},
+	}
+	r.setDrift(app, drifted, now)
+	require.Equal(t, drifted, app.Status.DriftedResources)
+	require.Equal(t, v1.ConditionTrue, app.Status.Condition(ketchv1.Drifted).Status)
+	require.Equal(t, "Warning Drifted Deployment/go-app-web-1, Service/go-app-web-1 diverge from the app's chart", <-recorder.Events)
+	// the app keeps drifting, the event isn't recorded again.
+	r.setDrift(app, drifted, now)
+	require.Len(t, recorder.Events, 0)
+
+	r.setDrift(app, nil, now)
+	require.Nil(t, app.Status.DriftedResources)
+	require.Equal(t, v1.ConditionFalse, app.Status.Condition(ketchv1.Drifted).Status)
+}
+
+func TestAppReconciler_recordScaling(t *testing.T) {
+	tests := []struct {
+		name      string
+		previous  *int
+		units     int
+		deployed  bool
+		canary    bool
+		wantEvent string
+	}{
+		{
+			name:  "first reconcile",
+			units: 2,
+		},
+		{
+			name:      "scaled up",
+			previous:  conversions.IntPtr(1),
+			units:     3,
+			wantEvent: "Normal ScaledUp scaled from 1 to 3 units",
+		},
+		{
+			name:      "scaled down",
+			previous:  conversions.IntPtr(3),
+			units:     2,
+			wantEvent: "Normal ScaledDown scaled from 3 to 2 units",
+		},
+		{
+			name:     "new deployment",
+			previous: conversions.IntPtr(1),
+			units:    2,
+			deployed: true,
+		},
+		{
+			name:     "canary",
+			previous: conversions.IntPtr(1),
+			units:    2,
+			canary:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(10)
+			r := AppReconciler{Recorder: recorder}
+			app := &ketchv1.App{
+				Spec: ketchv1.AppSpec{
+					Deployments: []ketchv1.AppDeploymentSpec{
+						{Processes: []ketchv1.ProcessSpec{{Name: "web", Units: conversions.IntPtr(tt.units)}}},
+					},
+					Canary: ketchv1.CanarySpec{Active: tt.canary},
+				},
+				Status: ketchv1.AppStatus{Units: tt.previous},
+			}
+			r.recordScaling(app, tt.deployed)
+			require.Equal(t, tt.units, *app.Status.Units)
+			if tt.wantEvent == "" {
+				require.Len(t, recorder.Events, 0)
+				return
+			}
+			require.Equal(t, tt.wantEvent, <-recorder.Events)
+		})
+	}
+}
+
+func TestAppReconciler_migrateIngress(t *testing.T) {
+	now := time.Date(2021, 1, 1, 10, 0, 0, 0, time.UTC)
+	newObject := func(gvk schema.GroupVersionKind, namespace, name, appName string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(gvk)
+		obj.SetNamespace(namespace)
+		obj.SetName(name)
+		obj.SetLabels(map[string]string{"theketch.io/app-name": appName})
+		return obj
+	}
+	ingressGVK := schema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1", Kind: "Ingress"}
+	certificateGVK := schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"}
+	gatewayGVK := schema.GroupVersionKind{Group: "networking.istio.io", Version: "v1alpha3", Kind: "Gateway"}
+
+	tests := []struct {
+		name            string
+		previous        ketchv1.IngressControllerType
+		wantRemaining   []schema.GroupVersionKind
+		wantCondition   *ketchv1.Condition
+		wantIngressType ketchv1.IngressControllerType
+	}{
+		{
+			name:            "first reconcile",
+			wantRemaining:   []schema.GroupVersionKind{ingressGVK, certificateGVK, gatewayGVK},
+			wantIngressType: ketchv1.IstioIngressControllerType,
+		},
+		{
+			name:            "same ingress type",
+			previous:        ketchv1.IstioIngressControllerType,
+			wantRemaining:   []schema.GroupVersionKind{ingressGVK, certificateGVK, gatewayGVK},
+			wantIngressType: ketchv1.IstioIngressControllerType,
+		},
+		{
+			name:          "nginx to istio",
+			previous:      ketchv1.NginxIngressControllerType,
+			wantRemaining: []schema.GroupVersionKind{gatewayGVK},
+			wantCondition: &ketchv1.Condition{
+				Type:               ketchv1.IngressMigrated,
+				Status:             v1.ConditionTrue,
+				Message:            "ingress migrated from nginx to istio",
+				LastTransitionTime: &metav1.Time{Time: now},
+			},
+			wantIngressType: ketchv1.IstioIngressControllerType,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cli := ctrlFake.NewClientBuilder().WithObjects(
+				newObject(ingressGVK, "ketch-go-app", "go-app-http-ingress", "go-app"),
+				newObject(certificateGVK, "ketch-go-app", "go-app-cname-theketch-io", "go-app"),
+				newObject(gatewayGVK, "ketch-go-app", "go-app-http-gateway", "go-app"),
+				newObject(ingressGVK, "ketch-go-app", "other-app-http-ingress", "other-app"),
+			).Build()
+			r := AppReconciler{
+				Client:   cli,
+				Group:    "theketch.io",
+				Recorder: record.NewFakeRecorder(10),
+				Now:      func() time.Time { return now },
+			}
+			app := &ketchv1.App{
+				ObjectMeta: metav1.ObjectMeta{Name: "go-app"},
+				Spec: ketchv1.AppSpec{
+					Namespace: "ketch-go-app",
+					Ingress: ketchv1.IngressSpec{
+						Controller: ketchv1.IngressControllerSpec{IngressType: ketchv1.IstioIngressControllerType},
+					},
+				},
+				Status: ketchv1.AppStatus{IngressType: tt.previous},
+			}
+			r.migrateIngress(context.Background(), app)
+
+			require.Equal(t, tt.wantIngressType, app.Status.IngressType)
+			require.Equal(t, tt.wantCondition, app.Status.Condition(ketchv1.IngressMigrated))
+			for _, gvk := range []schema.GroupVersionKind{ingressGVK, certificateGVK, gatewayGVK} {
+				list := &unstructured.UnstructuredList{}
+				list.SetGroupVersionKind(gvk)
+				require.Nil(t, cli.List(context.Background(), list, client.MatchingLabels{"theketch.io/app-name": "go-app"}))
+				want := 0
+				for _, remaining := range tt.wantRemaining {
+					if remaining == gvk {
+						want = 1
+					}
+				}
+				require.Len(t, list.Items, want, gvk.Kind)
+			}
+			// resources of other apps are kept.
+			other := newObject(ingressGVK, "", "", "")
+			require.Nil(t, cli.Get(context.Background(), types.NamespacedName{Namespace: "ketch-go-app", Name: "other-app-http-ingress"}, other))
+		})
+	}
+}
+
+func TestAppReconciler_ensureAppNamespace(t *testing.T) {
+	app := &ketchv1.App{
+		ObjectMeta: metav1.ObjectMeta{Name: "go-app"},
+		Spec: ketchv1.AppSpec{
+			Namespace:         "ketch-go-app",
+			NamespaceStrategy: ketchv1.PerAppNamespace,
+			NamespaceQuota:    v1.ResourceList{v1.ResourcePods: resource.MustParse("20")},
+		},
+	}
+	tests := []struct {
+		name    string
+		objects []client.Object
+		wantErr string
+	}{
+		{
+			name: "new namespace",
+		},
+		{
+			name: "namespace of the app",
+			objects: []client.Object{
+				&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ketch-go-app", Labels: map[string]string{"theketch.io/app-name": "go-app"}}},
+			},
+		},
+		{
+			name: "namespace of another app",
+			objects: []client.Object{
+				&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ketch-go-app", Labels: map[string]string{"theketch.io/app-name": "other-app"}}},
+			},
+			wantErr: `namespace "ketch-go-app" already exists and is not dedicated to app "go-app"`,
+		},
+		{
+			name: "namespace created outside of ketch",
+			objects: []client.Object{
+				&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ketch-go-app"}},
+			},
+			wantErr: `namespace "ketch-go-app" already exists and is not dedicated to app "go-app"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cli := ctrlFake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(tt.objects...).Build()
+			r := AppReconciler{Client: cli, Group: "theketch.io"}
+			err := r.ensureAppNamespace(context.Background(), app)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.Nil(t, err)
+
+			var namespace v1.Namespace
+			require.Nil(t, cli.Get(context.Background(), types.NamespacedName{Name: "ketch-go-app"}, &namespace))
+			require.Equal(t, "go-app", namespace.Labels["theketch.io/app-name"])
+
+			var quota v1.ResourceQuota
+			require.Nil(t, cli.Get(context.Background(), types.NamespacedName{Namespace: "ketch-go-app", Name: "go-app-quota"}, &quota))
+			require.Equal(t, app.Spec.NamespaceQuota, quota.Spec.Hard)
+
+			var policy networkingv1.NetworkPolicy
+			require.Nil(t, cli.Get(context.Background(), types.NamespacedName{Namespace: "ketch-go-app", Name: "go-app-isolation"}, &policy))
+			require.Equal(t, []networkingv1.PolicyType{networkingv1.PolicyTypeIngress}, policy.Spec.PolicyTypes)
+			require.Len(t, policy.Spec.Ingress[0].From, 2)
+		})
+	}
+}
+
+func TestAppReconciler_deleteNamespaceJobs(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.Nil(t, clientgoscheme.AddToScheme(scheme))
+	require.Nil(t, ketchv1.AddToScheme()(scheme))
+	cli := ctrlFake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&ketchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "migrate"}, Spec: ketchv1.JobSpec{Namespace: "ketch-go-app"}},
+		&ketchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "backup", Finalizers: []string{ketchv1.KetchFinalizer}}, Spec: ketchv1.JobSpec{Namespace: "ketch-go-app"}},
+		&ketchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "report"}, Spec: ketchv1.JobSpec{Namespace: "default"}},
+	).Build()
+	r := AppReconciler{Client: cli}
+
+	found, err := r.deleteNamespaceJobs(context.Background(), "ketch-go-app")
+	require.Nil(t, err)
+	require.Equal(t, 2, found)
+
+	// the job with a finalizer is still being removed by the job controller.
+	found, err = r.deleteNamespaceJobs(context.Background(), "ketch-go-app")
+	require.Nil(t, err)
+	require.Equal(t, 1, found)
+
+	var job ketchv1.Job
+	require.Nil(t, cli.Get(context.Background(), types.NamespacedName{Name: "report"}, &job))
+}
+
+func TestAppReconciler_deleteChart(t *testing.T) {
+	now := time.Date(2022, 6, 1, 10, 0, 0, 0, time.UTC)
+	scheme := runtime.NewScheme()
+	require.Nil(t, clientgoscheme.AddToScheme(scheme))
+	require.Nil(t, ketchv1.AddToScheme()(scheme))
+	certificateGVK := schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"}
+	scheme.AddKnownTypeWithName(certificateGVK, &unstructured.Unstructured{})
+	scheme.AddKnownTypeWithName(certificateGVK.GroupVersion().WithKind("CertificateList"), &unstructured.UnstructuredList{})
+	app := &ketchv1.App{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "go-app",
+			DeletionTimestamp: &metav1.Time{Time: now},
+			Finalizers:        []string{ketchv1.KetchFinalizer},
+			Annotations:       map[string]string{"theketch.io/delete-volumes": "true"},
+		},
+		Spec: ketchv1.AppSpec{
+			Namespace:         "ketch-go-app",
+			NamespaceStrategy: ketchv1.PerAppNamespace,
+			Ingress: ketchv1.IngressSpec{
+				Controller: ketchv1.IngressControllerSpec{IngressType: ketchv1.NginxIngressControllerType},
+				Cnames:     ketchv1.CnameList{{Name: "theketch.io", Secure: true}, {Name: "app.theketch.io", Secure: true, SecretName: "my-cert"}},
+			},
+		},
+	}
+	appLabels := map[string]string{"theketch.io/app-name": "go-app"}
+	certificate := &unstructured.Unstructured{}
+	certificate.SetGroupVersionKind(certificateGVK)
+	certificate.SetNamespace("ketch-go-app")
+	certificate.SetName("go-app-cname-theketch-io")
+	certificate.SetLabels(appLabels)
+	cli := ctrlFake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		app,
+		certificate,
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ketch-go-app", Labels: appLabels}},
+		&v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "go-app-cname-theketch-io", Namespace: "ketch-go-app"}},
+		&v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "my-cert", Namespace: "ketch-go-app"}},
+		&v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "data-go-app-web-1-0", Namespace: "ketch-go-app", Labels: appLabels}},
+		&v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "data-other-app-web-1-0", Namespace: "ketch-go-app"}},
+		&ketchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "migrate", Finalizers: []string{ketchv1.KetchFinalizer}}, Spec: ketchv1.JobSpec{Namespace: "ketch-go-app"}},
+	).Build()
+	helmMock := &helm{}
+	recorder := record.NewFakeRecorder(10)
+	r := AppReconciler{
+		Client:        cli,
+		Group:         "theketch.io",
+		Recorder:      recorder,
+		Now:           func() time.Time { return now },
+		HelmFactoryFn: func(namespace string) (Helm, error) { return helmMock, nil },
+	}
+	ctx := context.Background()
+	exists := func(obj client.Object, name, namespace string) bool {
+		err := cli.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, obj)
+		require.True(t, err == nil || k8serrors.IsNotFound(err), "unexpected error: %v", err)
+		return err == nil
+	}
+
+	// the job's chart is being uninstalled, so the app's namespace can't be removed yet.
+	var stored ketchv1.App
+	require.Nil(t, cli.Get(ctx, types.NamespacedName{Name: "go-app"}, &stored))
+	require.EqualError(t, r.deleteChart(ctx, &stored), "waiting for 1 jobs in namespace ketch-go-app to be removed")
+	require.Equal(t, "Warning RemoveFailed failed to remove resources of the app: waiting for 1 jobs in namespace ketch-go-app to be removed", <-recorder.Events)
+	require.Nil(t, cli.Get(ctx, types.NamespacedName{Name: "go-app"}, &stored))
+	require.Equal(t, v1.ConditionFalse, stored.Status.Condition(ketchv1.Removed).Status)
+	require.Equal(t, []string{"go-app"}, helmMock.deleteChartCalled)
+	require.False(t, exists(certificate.DeepCopy(), "go-app-cname-theketch-io", "ketch-go-app"))
+	require.False(t, exists(&v1.Secret{}, "go-app-cname-theketch-io", "ketch-go-app"))
+	require.True(t, exists(&v1.Secret{}, "my-cert", "ketch-go-app"))
+	require.False(t, exists(&v1.PersistentVolumeClaim{}, "data-go-app-web-1-0", "ketch-go-app"))
+	require.True(t, exists(&v1.PersistentVolumeClaim{}, "data-other-app-web-1-0", "ketch-go-app"))
+	require.True(t, exists(&v1.Namespace{}, "ketch-go-app", ""))
+
+	var job ketchv1.Job
+	require.Nil(t, cli.Get(ctx, types.NamespacedName{Name: "migrate"}, &job))
+	job.Finalizers = nil
+	require.Nil(t, cli.Update(ctx, &job))
+
+	require.Nil(t, r.deleteChart(ctx, &stored))
+	require.False(t, exists(&v1.Namespace{}, "ketch-go-app", ""))
+	require.False(t, exists(&ketchv1.App{}, "go-app", ""))
+}
+
+func TestAppReconciler_cleanupKeepsNamespacesOfOthers(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.Nil(t, clientgoscheme.AddToScheme(scheme))
+	require.Nil(t, ketchv1.AddToScheme()(scheme))
+	certificateGVK := schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"}
+	scheme.AddKnownTypeWithName(certificateGVK, &unstructured.Unstructured{})
+	scheme.AddKnownTypeWithName(certificateGVK.GroupVersion().WithKind("CertificateList"), &unstructured.UnstructuredList{})
+	app := &ketchv1.App{
+		ObjectMeta: metav1.ObjectMeta{Name: "go-app"},
+		Spec: ketchv1.AppSpec{
+			Namespace:         "default",
+			NamespaceStrategy: ketchv1.PerAppNamespace,
+			Ingress:           ketchv1.IngressSpec{Controller: ketchv1.IngressControllerSpec{IngressType: ketchv1.NginxIngressControllerType}},
+		},
+	}
+	cli := ctrlFake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+		&ketchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "report"}, Spec: ketchv1.JobSpec{Namespace: "default"}},
+	).Build()
+	r := AppReconciler{
+		Client:        cli,
+		Group:         "theketch.io",
+		HelmFactoryFn: func(namespace string) (Helm, error) { return &helm{}, nil },
+	}
+	require.Nil(t, r.cleanup(context.Background(), app))
+
+	// the namespace wasn't created for the app, neither it nor its jobs are removed.
+	require.Nil(t, cli.Get(context.Background(), types.NamespacedName{Name: "default"}, &v1.Namespace{}))
+	require.Nil(t, cli.Get(context.Background(), types.NamespacedName{Name: "report"}, &ketchv1.Job{}))
+}
+
+func TestAppReconciler_applySchedules(t *testing.T) {
+	now := time.Date(2022, 6, 1, 10, 0, 0, 0, time.UTC)
+	scheme := runtime.NewScheme()
+	require.Nil(t, clientgoscheme.AddToScheme(scheme))
+	require.Nil(t, ketchv1.AddToScheme()(scheme))
+	units := 2
+	app := &ketchv1.App{
+		ObjectMeta: metav1.ObjectMeta{Name: "go-app"},
+		Spec: ketchv1.AppSpec{
+			Deployments: []ketchv1.AppDeploymentSpec{
+				{Version: 1, Processes: []ketchv1.ProcessSpec{{Name: "web", Units: &units}}},
+			},
+			Schedules: []ketchv1.ScalingSchedule{
+				{Name: "business-hours", Schedule: "0 8 * * *", Units: map[string]int{"web": 10}},
+			},
+		},
+		Status: ketchv1.AppStatus{
+			Schedules: []ketchv1.ScheduleStatus{
+				{Name: "business-hours", LastScheduleTime: metav1.NewTime(now.Add(-3 * time.Hour))},
+			},
+		},
+	}
+	cli := ctrlFake.NewClientBuilder().WithScheme(scheme).WithObjects(app).Build()
+	recorder := record.NewFakeRecorder(10)
+	r := AppReconciler{Client: cli, Recorder: recorder, Now: func() time.Time { return now }}
+
+	require.Nil(t, cli.Get(context.Background(), types.NamespacedName{Name: "go-app"}, app))
+	require.Nil(t, r.applySchedules(context.Background(), app))
+	require.Len(t, app.Status.Schedules, 1)
+	require.True(t, app.Status.Schedules[0].LastScheduleTime.Equal(&metav1.Time{Time: time.Date(2022, 6, 1, 8, 0, 0, 0, time.UTC)}))
+	require.Equal(t, "Normal ScheduledScaling units set by schedules business-hours", <-recorder.Events)
+
+	var stored ketchv1.App
+	require.Nil(t, cli.Get(context.Background(), types.NamespacedName{Name: "go-app"}, &stored))
+	require.Equal(t, 10, *stored.Spec.Deployments[0].Processes[0].Units)
+
+	// the schedule isn't applied again until it is due.
+	require.Nil(t, r.applySchedules(context.Background(), app))
+	require.Len(t, recorder.Events, 0)
+}
+
+func TestAppReconciler_reconcileReleaseFailure(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.Nil(t, clientgoscheme.AddToScheme(scheme))
+	require.Nil(t, ketchv1.AddToScheme()(scheme))
+	app := &ketchv1.App{
+		ObjectMeta: metav1.ObjectMeta{Name: "go-app", Generation: 3},
+		Spec: ketchv1.AppSpec{
+			Namespace: "default",
+			Deployments: []ketchv1.AppDeploymentSpec{
+				{
+					Version:    2,
+					Image:      "go-app:v2",
+					Processes:  []ketchv1.ProcessSpec{{Name: "web", Cmd: []string{"web"}}},
+					ReleaseCmd: []string{"/bin/sh", "-c", "rake db:migrate"},
+				},
+			},
+		},
+	}
+	cli := ctrlFake.NewClientBuilder().WithScheme(scheme).WithObjects(app).Build()
+	recorder := record.NewFakeRecorder(10)
+	helmMock := &helm{updateChartResults: map[string]error{
+		"go-app": errors.New("UPGRADE FAILED: pre-upgrade hooks failed: job failed: BackoffLimitExceeded"),
+	}}
+	r := AppReconciler{
+		Client:         cli,
+		Recorder:       recorder,
+		TemplateReader: &templateReader{},
+		HelmFactoryFn:  func(namespace string) (Helm, error) { return helmMock, nil },
+		Now:            time.Now,
+	}
+
+	result := r.reconcile(context.Background(), app, ctrl.Log)
+	require.EqualError(t, result.err, `release process of version 2 failed, run "ketch app log go-app --process release --version 2" to see its logs: UPGRADE FAILED: pre-upgrade hooks failed: job failed: BackoffLimitExceeded`)
+	require.Equal(t, &ketchv1.ReleaseFailure{Version: 2, Process: "release", ObservedGeneration: 3, Message: "UPGRADE FAILED: pre-upgrade hooks failed: job failed: BackoffLimitExceeded"}, app.Status.ReleaseFailure)
+	require.True(t, app.ReleaseFailed())
+
+	// the release process doesn't run again until the app changes.
+	helmMock.updateChartResults = nil
+	result = r.reconcile(context.Background(), app, ctrl.Log)
+	require.EqualError(t, result.err, `release process of version 2 failed, run "ketch app log go-app --process release --version 2" to see its logs`)
+
+	// a changed app updates the helm chart again.
+	app.Generation = 4
+	helmMock.updateChartResults = map[string]error{"go-app": errors.New("cluster unreachable")}
+	result = r.reconcile(context.Background(), app, ctrl.Log)
+	require.EqualError(t, result.err, "failed to update helm chart: cluster unreachable")
+
+}
+
+func Test_failedDeployHook(t *testing.T) {
+	statuses := []ketchv1.DeployHookStatus{
+		{Process: "pre-deploy", Version: 1, Phase: "Failed"},
+		{Process: "pre-deploy", Version: 2, Phase: "Succeeded"},
+		{Process: "release", Version: 2, Phase: "Failed"},
+	}
+	require.Equal(t, &statuses[2], failedDeployHook(statuses, 2))
+	require.Nil(t, failedDeployHook(statuses, 3))
+
+	app := &ketchv1.App{ObjectMeta: metav1.ObjectMeta{Name: "go-app"}}
+	require.Equal(t, `pre-deploy process of version 2 failed, run "ketch app log go-app --process pre-deploy --version 2" to see its logs`, deployHookFailed(app, "pre-deploy", 2))
+	require.Equal(t, `release process of version 3 failed, run "ketch app log go-app --process release --version 3" to see its logs`, deployHookFailed(app, "", 3))
+}
+
+func Test_setDeployingCondition(t *testing.T) {
+	now := metav1.Now()
+	app := &ketchv1.App{}
+	setDeployingCondition(app, true, "is rolling out", now)
+	require.Nil(t, app.Status.Condition(ketchv1.Deploying))
+
+	app.Spec.Deployments = []ketchv1.AppDeploymentSpec{{Version: 2}, {Version: 3}}
+	setDeployingCondition(app, true, "is rolling out", now)
+	message, ok := app.DeployInProgress()
+	require.True(t, ok)
+	require.Equal(t, "version 3 is rolling out", message)
+
+	setDeployingCondition(app, false, "is deployed", now)
+	_, ok = app.DeployInProgress()
+	require.False(t, ok)
+	require.Equal(t, "version 3 is deployed", app.Status.Condition(ketchv1.Deploying).Message)
+}
+
+func Test_updateDeployingCondition(t *testing.T) {
+	now := metav1.Now()
+	app := &ketchv1.App{Spec: ketchv1.AppSpec{Deployments: []ketchv1.AppDeploymentSpec{{Version: 3}}}}
+
+	updateDeployingCondition(app, appReconcileResult{rollingOut: true}, now)
+	message, ok := app.DeployInProgress()
+	require.True(t, ok)
+	require.Equal(t, "version 3 is rolling out", message)
+
+	updateDeployingCondition(app, appReconcileResult{rollingOut: true}, metav1.NewTime(now.Add(DefaultPodRunningTimeout+time.Second)))
+	_, ok = app.DeployInProgress()
+	require.False(t, ok)
+	require.Equal(t, "version 3 didn't roll out in 10m0s", app.Status.Condition(ketchv1.Deploying).Message)
+	require.NotEqual(t, ketchv1.AppError, app.Phase())
+
+	// a failed rollout isn't in progress again on the next reconciliation.
+	updateDeployingCondition(app, appReconcileResult{rollingOut: true}, now)
+	_, ok = app.DeployInProgress()
+	require.False(t, ok)
+
+	app.Spec.Deployments = append(app.Spec.Deployments, ketchv1.AppDeploymentSpec{Version: 4})
+	updateDeployingCondition(app, appReconcileResult{rollingOut: true}, now)
+	_, ok = app.DeployInProgress()
+	require.True(t, ok)
+
+	updateDeployingCondition(app, appReconcileResult{rollingOut: true, rolloutFailure: `deployment "app-web-4" exceeded its progress deadline`}, now)
+	_, ok = app.DeployInProgress()
+	require.False(t, ok)
+	require.Equal(t, `version 4 failed to roll out: deployment "app-web-4" exceeded its progress deadline`, app.Status.Condition(ketchv1.Deploying).Message)
+
+	updateDeployingCondition(app, appReconcileResult{}, now)
+	require.Equal(t, "version 4 is deployed", app.Status.Condition(ketchv1.Deploying).Message)
+}
+
+func TestAppReconciler_envSetApps(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.Nil(t, ketchv1.AddToScheme()(scheme))
+	cli := ctrlFake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&ketchv1.App{ObjectMeta: metav1.ObjectMeta{Name: "shop"}, Spec: ketchv1.AppSpec{EnvSets: []string{"database", "observability"}}},
+		&ketchv1.App{ObjectMeta: metav1.ObjectMeta{Name: "blog"}, Spec: ketchv1.AppSpec{EnvSets: []string{"observability"}}},
+		&ketchv1.App{ObjectMeta: metav1.ObjectMeta{Name: "wiki"}},
+	).Build()
+	r := AppReconciler{Client: cli}
+
+	requests := r.envSetApps(&ketchv1.EnvSet{ObjectMeta: metav1.ObjectMeta{Name: "observability"}})
+	require.ElementsMatch(t, []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Name: "shop"}},
+		{NamespacedName: types.NamespacedName{Name: "blog"}},
+	}, requests)
+	require.Nil(t, r.envSetApps(&ketchv1.EnvSet{ObjectMeta: metav1.ObjectMeta{Name: "unused"}}))
+}
+
+func TestAppReconciler_bindingSecretApps(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.Nil(t, ketchv1.AddToScheme()(scheme))
+	bindings := []ketchv1.ServiceBinding{{Name: "db", SecretName: "postgres"}}
+	cli := ctrlFake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&ketchv1.App{ObjectMeta: metav1.ObjectMeta{Name: "shop"}, Spec: ketchv1.AppSpec{Namespace: "shop", ServiceBindings: bindings}},
+		&ketchv1.App{ObjectMeta: metav1.ObjectMeta{Name: "blog"}, Spec: ketchv1.AppSpec{Namespace: "blog", ServiceBindings: bindings}},
+		&ketchv1.App{ObjectMeta: metav1.ObjectMeta{Name: "wiki"}, Spec: ketchv1.AppSpec{Namespace: "shop"}},
+		&ketchv1.App{
+			ObjectMeta: metav1.ObjectMeta{Name: "cart"},
+			Spec:       ketchv1.AppSpec{Namespace: "shop"},
+			Status:     ketchv1.AppStatus{Dependencies: []ketchv1.DependencyStatus{{Name: "redis", SecretName: "cart-redis"}}},
+		},
+	).Build()
+	r := AppReconciler{Client: cli}
+
+	requests := r.bindingSecretApps(&v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "postgres", Namespace: "shop"}})
+	require.Equal(t, []reconcile.Request{{NamespacedName: types.NamespacedName{Name: "shop"}}}, requests)
+	requests = r.bindingSecretApps(&v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "cart-redis", Namespace: "shop"}})
+	require.Equal(t, []reconcile.Request{{NamespacedName: types.NamespacedName{Name: "cart"}}}, requests)
+	require.Nil(t, r.bindingSecretApps(&v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "redis", Namespace: "shop"}}))
+}
+
+type fakeProvisioner struct {
+	ready bool
+}
+
+func (p fakeProvisioner) Provision(_ context.Context, app *ketchv1.App, config ketchv1.DependencyProvisioner) (ketchv1.DependencyStatus, error) {
+	return ketchv1.DependencyStatus{
+		Name:        config.Name,
+		Provisioner: config.Type,
+		SecretName:  ketchv1.DependencySecretName(app.Name, config.Name),
+		Ready:       p.ready,
+	}, nil
+}
+
+func TestAppReconciler_provisionDependencies(t *testing.T) {
+	app := &ketchv1.App{
+		ObjectMeta: metav1.ObjectMeta{Name: "shop"},
+		Spec: ketchv1.AppSpec{
+			Deployments: []ketchv1.AppDeploymentSpec{
+				{Version: 1, KetchYaml: &ketchv1.KetchYamlData{Requires: []string{"postgres"}}},
+			},
+			Ingress: ketchv1.IngressSpec{
+				Controller: ketchv1.IngressControllerSpec{
+					DependencyProvisioners: []ketchv1.DependencyProvisioner{{Name: "postgres", Type: ketchv1.CrossplaneProvisionerType}},
+				},
+			},
+		},
+	}
+	r := AppReconciler{Provisioners: provisioner.Provisioners{ketchv1.CrossplaneProvisionerType: fakeProvisioner{ready: true}}}
+	require.Nil(t, r.provisionDependencies(context.Background(), app))
+	require.Equal(t, []ketchv1.DependencyStatus{
+		{Name: "postgres", Provisioner: ketchv1.CrossplaneProvisionerType, SecretName: "shop-postgres", Ready: true},
+	}, app.Status.Dependencies)
+
+	app.Spec.Deployments[0].KetchYaml.Requires = []string{"postgres", "redis"}
+	err := r.provisionDependencies(context.Background(), app)
+	require.EqualError(t, err, `no provisioner of dependency "redis" is configured in dependencyProvisioners of the ingress configmap`)
+
+	app.Spec.Deployments[0].KetchYaml = nil
+	require.Nil(t, r.provisionDependencies(context.Background(), app))
+	require.Nil(t, app.Status.Dependencies)
+}
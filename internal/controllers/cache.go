@@ -0,0 +1,41 @@
+package controllers
+
+import (
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+)
+
+// NewCache returns a function to create the manager's cache.
+// Controllers only read pods of ketch apps, so the cache keeps just these pods
+// instead of every pod of the cluster. Apps and jobs are limited to those of the shard,
+// so controllers of different shards reconcile different apps.
+func NewCache(group, shard string) (cache.NewCacheFunc, error) {
+	appPods, err := appPodsSelector(group)
+	if err != nil {
+		return nil, err
+	}
+	shardObjects, err := shardSelector(group, shard)
+	if err != nil {
+		return nil, err
+	}
+	return cache.BuilderWithOptions(cache.Options{
+		SelectorsByObject: cache.SelectorsByObject{
+			&v1.Pod{}:      {Label: appPods},
+			&ketchv1.App{}: {Label: shardObjects},
+			&ketchv1.Job{}: {Label: shardObjects},
+		},
+	}), nil
+}
+
+// appPodsSelector returns a selector of pods that belong to ketch apps.
+func appPodsSelector(group string) (labels.Selector, error) {
+	requirement, err := labels.NewRequirement(group+"/app-name", selection.Exists, nil)
+	if err != nil {
+		return nil, err
+	}
+	return labels.NewSelector().Add(*requirement), nil
+}
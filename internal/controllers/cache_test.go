@@ -0,0 +1,19 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func Test_appPodsSelector(t *testing.T) {
+	selector, err := appPodsSelector("theketch.io")
+	require.Nil(t, err)
+	require.Equal(t, "theketch.io/app-name", selector.String())
+	require.True(t, selector.Matches(labels.Set{"theketch.io/app-name": "hello"}))
+	require.False(t, selector.Matches(labels.Set{"app": "hello"}))
+
+	_, err = appPodsSelector("invalid group!")
+	require.NotNil(t, err)
+}
@@ -6,4 +6,8 @@ const (
 	KetchNamespace = "ketch-system"
 	// reconcileTimeout is the default timeout to trigger Operator reconcile
 	reconcileTimeout = 10 * time.Minute
+	// rolloutRequeueInterval is the time between reconciles of an app whose latest deployment is rolling out.
+	rolloutRequeueInterval = 15 * time.Second
+	// dnsLookupTimeout limits the time spent on checking DNS records of a cname.
+	dnsLookupTimeout = 5 * time.Second
 )
@@ -44,6 +44,10 @@ type JobReconciler struct {
 	HelmFactoryFn  helmFactoryFn
 	Recorder       record.EventRecorder
 	TemplateReader templates.Reader
+	// Options tune the concurrency of the controller.
+	Options ControllerOptions
+	// ApplyFactoryFn returns clients installing charts with server-side apply for jobs whose engine is server-side-apply.
+	ApplyFactoryFn helmFactoryFn
 }
 
 // JobReconcileReason contains information about job reconcile
@@ -101,10 +105,21 @@ func (r *JobReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.R
 // SetupWithManager sets up the controller with the Manager.
 func (r *JobReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
+		WithOptions(r.Options.controllerOptions()).
 		For(&ketchv1.Job{}).
 		Complete(r)
 }
 
+// chartClient returns a client installing the job's chart with the job's engine and records the engine in the job's status.
+func (r *JobReconciler) chartClient(ctx context.Context, job *ketchv1.Job) (Helm, error) {
+	engine, err := chartEngine(ctx, r.Client, installedEngine(job.Status.Engine, job.Status.Conditions))
+	if err != nil {
+		return nil, err
+	}
+	job.Status.Engine = engine
+	return newChartClient(engine, job.Spec.Namespace, r.HelmFactoryFn, r.ApplyFactoryFn)
+}
+
 type reconcileResult struct {
 	status  v1.ConditionStatus
 	message string
@@ -137,7 +152,7 @@ func (r *JobReconciler) reconcile(ctx context.Context, job *ketchv1.Job) reconci
 	jobChartConfig := chart.NewJobChartConfig(*job)
 	jobChart := chart.NewJobChart(job, options...)
 
-	helmClient, err := r.HelmFactoryFn(job.Spec.Namespace)
+	helmClient, err := r.chartClient(ctx, job)
 	if err != nil {
 		return reconcileResult{
 			status:  v1.ConditionFalse,
@@ -160,7 +175,7 @@ func (r *JobReconciler) reconcile(ctx context.Context, job *ketchv1.Job) reconci
 
 func (r *JobReconciler) deleteChart(ctx context.Context, job *ketchv1.Job) error {
 	if uninstallHelmChart(ketchv1.Group, job.Annotations) {
-		helmClient, err := r.HelmFactoryFn(job.Spec.Namespace)
+		helmClient, err := r.chartClient(ctx, job)
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	metricsResultSuccess = "success"
+	metricsResultError   = "error"
+)
+
+var (
+	// appReconcileDuration observes how long it takes to reconcile an app.
+	// Labels: result is either "success" or "error".
+	appReconcileDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "ketch_app_reconcile_duration_seconds",
+		Help:    "Duration of app reconciliations in seconds.",
+		Buckets: prometheus.ExponentialBuckets(0.1, 2, 10),
+	}, []string{"result"})
+
+	// appDeploymentsTotal counts deployments of apps.
+	// A deployment succeeds once its helm chart is installed and fails when the app stops being scheduled.
+	// Labels: namespace is the app's namespace, result is either "success" or "error".
+	appDeploymentsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ketch_app_deployments_total",
+		Help: "Number of app deployments by namespace and result.",
+	}, []string{"namespace", "result"})
+
+	// canaryRollbacksTotal counts canary deployments rolled back because their pods didn't start in time.
+	// Labels: namespace is the app's namespace.
+	canaryRollbacksTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ketch_app_canary_rollbacks_total",
+		Help: "Number of canary deployments rolled back by namespace.",
+	}, []string{"namespace"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(appReconcileDuration, appDeploymentsTotal, canaryRollbacksTotal)
+}
+
+// observeReconcile records the duration of an app reconciliation that started at the given time.
+func observeReconcile(start time.Time, err error) {
+	result := metricsResultSuccess
+	if err != nil {
+		result = metricsResultError
+	}
+	appReconcileDuration.WithLabelValues(result).Observe(time.Since(start).Seconds())
+}
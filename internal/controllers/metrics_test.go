@@ -0,0 +1,31 @@
+package controllers
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+func Test_observeReconcile(t *testing.T) {
+	appReconcileDuration.Reset()
+
+	observeReconcile(time.Now(), nil)
+	observeReconcile(time.Now(), nil)
+	observeReconcile(time.Now(), errors.New("failed to update helm chart"))
+
+	families, err := metrics.Registry.Gather()
+	require.Nil(t, err)
+	got := map[string]uint64{}
+	for _, family := range families {
+		if family.GetName() != "ketch_app_reconcile_duration_seconds" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			got[metric.GetLabel()[0].GetValue()] = metric.GetHistogram().GetSampleCount()
+		}
+	}
+	require.Equal(t, map[string]uint64{"success": 2, "error": 1}, got)
+}
@@ -0,0 +1,210 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/go-logr/logr"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+)
+
+const (
+	defaultSlackTemplate   = `{"text": {{ printf "[%s] %s: %s" .App .Event .Message | json }}}`
+	defaultWebhookTemplate = `{{ json . }}`
+
+	notificationTimeout = 10 * time.Second
+)
+
+// defaultNotificationBackoff retries a failed notification for about half a minute.
+var defaultNotificationBackoff = wait.Backoff{
+	Steps:    5,
+	Duration: time.Second,
+	Factor:   2.0,
+	Jitter:   0.1,
+}
+
+// Notifier sends notifications about lifecycle events of an app to the receivers configured in its spec
+// and to cluster-wide receivers of the ingress configmap.
+type Notifier interface {
+	Notify(app *ketchv1.App, event ketchv1.NotificationEvent, message string)
+}
+
+// HTTPNotifier posts notifications to Slack incoming webhooks and generic HTTP endpoints.
+// Requests are sent in background so a slow receiver doesn't block reconciliation,
+// a failed request is retried with exponential backoff.
+type HTTPNotifier struct {
+	Client     client.Reader
+	HTTPClient *http.Client
+	Backoff    wait.Backoff
+	Log        logr.Logger
+	Now        timeNowFn
+
+	// wg tracks notifications being sent, tests use it to wait for them.
+	wg sync.WaitGroup
+}
+
+var _ Notifier = &HTTPNotifier{}
+
+func NewHTTPNotifier(c client.Reader, logger logr.Logger) *HTTPNotifier {
+	return &HTTPNotifier{
+		Client:     c,
+		HTTPClient: &http.Client{Timeout: notificationTimeout},
+		Backoff:    defaultNotificationBackoff,
+		Log:        logger,
+		Now:        time.Now,
+	}
+}
+
+// Notify renders notifications for the receivers subscribed to the event and sends them.
+func (n *HTTPNotifier) Notify(app *ketchv1.App, event ketchv1.NotificationEvent, message string) {
+	payload := ketchv1.NotificationPayload{
+		App:       app.Name,
+		Namespace: app.Spec.Namespace,
+		Event:     event,
+		Message:   message,
+		Time:      n.Now().UTC().Format(time.RFC3339),
+	}
+	if len(app.Spec.Deployments) > 0 {
+		payload.Version = int(app.Spec.Deployments[len(app.Spec.Deployments)-1].Version)
+	}
+	for _, target := range notificationTargets(app) {
+		spec, namespace := target.spec, target.namespace
+		if !spec.Subscribed(event) {
+			continue
+		}
+		logger := n.Log.WithValues("app", app.Name, "notification", spec.Name, "event", event)
+		body, err := renderNotification(spec, payload)
+		if err != nil {
+			logger.Error(err, "failed to render notification")
+			continue
+		}
+		n.wg.Add(1)
+		go func() {
+			defer n.wg.Done()
+			if err := n.send(context.Background(), spec, namespace, body); err != nil {
+				logger.Error(err, "failed to send notification")
+			}
+		}()
+	}
+}
+
+// notificationTarget is a receiver of notifications about an app and the namespace of the secret with its URL.
+type notificationTarget struct {
+	spec      ketchv1.NotificationSpec
+	namespace string
+}
+
+// notificationTargets returns receivers of the app's spec followed by cluster-wide receivers of the ingress configmap.
+// Secrets of the app's receivers are in the app's namespace and secrets of cluster-wide receivers are in the configmap's namespace.
+func notificationTargets(app *ketchv1.App) []notificationTarget {
+	var targets []notificationTarget
+	for _, spec := range app.Spec.Notifications {
+		targets = append(targets, notificationTarget{spec: spec, namespace: app.Spec.Namespace})
+	}
+	for _, spec := range app.Spec.Ingress.Controller.Notifications {
+		targets = append(targets, notificationTarget{spec: spec, namespace: ketchv1.IngressConfigmapNamespace})
+	}
+	return targets
+}
+
+func (n *HTTPNotifier) send(ctx context.Context, spec ketchv1.NotificationSpec, namespace string, body []byte) error {
+	url, err := n.notificationURL(ctx, spec, namespace)
+	if err != nil {
+		return err
+	}
+	return retry.OnError(n.Backoff, isRetriableNotificationError, func() error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := n.HTTPClient.Do(req)
+		if err != nil {
+			return &notificationError{err: err, retriable: true}
+		}
+		defer resp.Body.Close()
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return nil
+		}
+		return &notificationError{
+			err:       fmt.Errorf("receiver responded with %s", resp.Status),
+			retriable: resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500,
+		}
+	})
+}
+
+func (n *HTTPNotifier) notificationURL(ctx context.Context, spec ketchv1.NotificationSpec, namespace string) (string, error) {
+	if spec.URLSecret == nil {
+		if spec.URL == "" {
+			return "", fmt.Errorf("notification %q has neither url nor urlSecret", spec.Name)
+		}
+		return spec.URL, nil
+	}
+	var secret v1.Secret
+	if err := n.Client.Get(ctx, types.NamespacedName{Name: spec.URLSecret.Name, Namespace: namespace}, &secret); err != nil {
+		return "", fmt.Errorf("failed to get secret %q of notification %q: %w", spec.URLSecret.Name, spec.Name, err)
+	}
+	url, ok := secret.Data[spec.URLSecret.Key]
+	if !ok {
+		return "", fmt.Errorf("secret %q has no key %q", spec.URLSecret.Name, spec.URLSecret.Key)
+	}
+	return string(url), nil
+}
+
+// notificationError is returned when a receiver can't be reached or rejects a notification.
+type notificationError struct {
+	err       error
+	retriable bool
+}
+
+func (e *notificationError) Error() string { return e.err.Error() }
+
+func isRetriableNotificationError(err error) bool {
+	if e, ok := err.(*notificationError); ok {
+		return e.retriable
+	}
+	return false
+}
+
+// renderNotification executes the template of the notification, the default one depends on the notification's type.
+func renderNotification(spec ketchv1.NotificationSpec, payload ketchv1.NotificationPayload) ([]byte, error) {
+	text := spec.Template
+	if text == "" {
+		text = defaultWebhookTemplate
+		if spec.Type == ketchv1.SlackNotification {
+			text = defaultSlackTemplate
+		}
+	}
+	tpl, err := template.New(spec.Name).Funcs(template.FuncMap{"json": toJSON}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, payload); err != nil {
+		return nil, fmt.Errorf("failed to execute template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func toJSON(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,180 @@
+package controllers
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	ctrlFake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+)
+
+func Test_renderNotification(t *testing.T) {
+	payload := ketchv1.NotificationPayload{
+		App:       "go-app",
+		Namespace: "ketch-go-app",
+		Event:     ketchv1.DeployFailedEvent,
+		Version:   3,
+		Message:   `deployment "go-app-web-3" exceeded its progress deadline`,
+		Time:      "2021-01-01T10:00:00Z",
+	}
+	tests := []struct {
+		name    string
+		spec    ketchv1.NotificationSpec
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "slack",
+			spec: ketchv1.NotificationSpec{Name: "team", Type: ketchv1.SlackNotification},
+			want: `{"text": "[go-app] DeployFailed: deployment \"go-app-web-3\" exceeded its progress deadline"}`,
+		},
+		{
+			name: "webhook",
+			spec: ketchv1.NotificationSpec{Name: "ci", Type: ketchv1.WebhookNotification},
+			want: `{"app":"go-app","namespace":"ketch-go-app","event":"DeployFailed","version":3,"message":"deployment \"go-app-web-3\" exceeded its progress deadline","time":"2021-01-01T10:00:00Z"}`,
+		},
+		{
+			name: "custom template",
+			spec: ketchv1.NotificationSpec{Name: "ci", Type: ketchv1.WebhookNotification, Template: `{"summary": {{ printf "%s v%d" .App .Version | json }}}`},
+			want: `{"summary": "go-app v3"}`,
+		},
+		{
+			name:    "invalid template",
+			spec:    ketchv1.NotificationSpec{Name: "ci", Type: ketchv1.WebhookNotification, Template: `{{ .App `},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := renderNotification(tt.spec, payload)
+			if tt.wantErr {
+				require.NotNil(t, err)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.want, string(got))
+		})
+	}
+}
+
+// notificationReceiver responds with the given statuses in order and records bodies of the requests.
+type notificationReceiver struct {
+	sync.Mutex
+	statuses []int
+	bodies   []string
+}
+
+func (n *notificationReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n.Lock()
+	defer n.Unlock()
+	body, _ := ioutil.ReadAll(r.Body)
+	n.bodies = append(n.bodies, string(body))
+	status := http.StatusOK
+	if len(n.statuses) > 0 {
+		status, n.statuses = n.statuses[0], n.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func TestHTTPNotifier_Notify(t *testing.T) {
+	tests := []struct {
+		name       string
+		event      ketchv1.NotificationEvent
+		events     []ketchv1.NotificationEvent
+		statuses   []int
+		urlSecret  bool
+		cluster    bool
+		wantBodies int
+	}{
+		{
+			name:       "subscribed to all events",
+			event:      ketchv1.AppRemovedEvent,
+			wantBodies: 1,
+		},
+		{
+			name:       "not subscribed",
+			event:      ketchv1.DeployStartedEvent,
+			events:     []ketchv1.NotificationEvent{ketchv1.DeployFailedEvent},
+			wantBodies: 0,
+		},
+		{
+			name:       "url in a secret",
+			event:      ketchv1.DeployFailedEvent,
+			events:     []ketchv1.NotificationEvent{ketchv1.DeployFailedEvent},
+			urlSecret:  true,
+			wantBodies: 1,
+		},
+		{
+			name:       "cluster-wide receiver with url in a secret",
+			event:      ketchv1.DeploySucceededEvent,
+			urlSecret:  true,
+			cluster:    true,
+			wantBodies: 1,
+		},
+		{
+			name:       "retry server errors",
+			event:      ketchv1.CanaryPromotedEvent,
+			statuses:   []int{http.StatusBadGateway, http.StatusTooManyRequests},
+			wantBodies: 3,
+		},
+		{
+			name:       "don't retry rejected notifications",
+			event:      ketchv1.CanaryRolledBackEvent,
+			statuses:   []int{http.StatusBadRequest},
+			wantBodies: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			receiver := &notificationReceiver{statuses: tt.statuses}
+			server := httptest.NewServer(receiver)
+			defer server.Close()
+
+			spec := ketchv1.NotificationSpec{Name: "ci", Type: ketchv1.WebhookNotification, Events: tt.events, URL: server.URL}
+			secretNamespace := "ketch-go-app"
+			if tt.cluster {
+				secretNamespace = ketchv1.IngressConfigmapNamespace
+			}
+			secret := &v1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "webhooks", Namespace: secretNamespace},
+				Data:       map[string][]byte{"ci": []byte(server.URL)},
+			}
+			if tt.urlSecret {
+				spec.URL = ""
+				spec.URLSecret = &v1.SecretKeySelector{LocalObjectReference: v1.LocalObjectReference{Name: "webhooks"}, Key: "ci"}
+			}
+			app := &ketchv1.App{
+				ObjectMeta: metav1.ObjectMeta{Name: "go-app"},
+				Spec: ketchv1.AppSpec{
+					Namespace:     "ketch-go-app",
+					Notifications: []ketchv1.NotificationSpec{spec},
+				},
+			}
+			if tt.cluster {
+				app.Spec.Notifications = nil
+				app.Spec.Ingress.Controller.Notifications = []ketchv1.NotificationSpec{spec}
+			}
+			n := NewHTTPNotifier(ctrlFake.NewClientBuilder().WithObjects(secret).Build(), logr.Discard())
+			n.Backoff = wait.Backoff{Steps: 3, Duration: time.Millisecond}
+			n.Now = func() time.Time { return time.Date(2021, 1, 1, 10, 0, 0, 0, time.UTC) }
+
+			n.Notify(app, tt.event, "message")
+			n.wg.Wait()
+
+			require.Len(t, receiver.bodies, tt.wantBodies)
+			for _, body := range receiver.bodies {
+				require.Equal(t, `{"app":"go-app","namespace":"ketch-go-app","event":"`+string(tt.event)+`","message":"message","time":"2021-01-01T10:00:00Z"}`, body)
+			}
+		})
+	}
+}
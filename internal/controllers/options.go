@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+)
+
+// ControllerOptions tune how many objects a controller reconciles in parallel and how fast failed objects are retried.
+// The zero value keeps the defaults of controller-runtime.
+type ControllerOptions struct {
+	// MaxConcurrentReconciles is the number of objects reconciled in parallel, 1 if it's 0.
+	MaxConcurrentReconciles int
+	// BaseRetryDelay is the delay before the first retry of a failed object, the delay doubles with each failure up to MaxRetryDelay.
+	BaseRetryDelay time.Duration
+	MaxRetryDelay  time.Duration
+	// QPS and Burst limit the overall rate of reconciles of the controller's queue.
+	QPS   float64
+	Burst int
+}
+
+const (
+	defaultBaseRetryDelay = 5 * time.Millisecond
+	defaultMaxRetryDelay  = 1000 * time.Second
+	defaultQPS            = 10
+	defaultBurst          = 100
+)
+
+func (o ControllerOptions) controllerOptions() controller.Options {
+	return controller.Options{
+		MaxConcurrentReconciles: o.MaxConcurrentReconciles,
+		RateLimiter:             o.rateLimiter(),
+	}
+}
+
+// rateLimiter returns the rate limiter of workqueue.DefaultControllerRateLimiter with the configured settings.
+func (o ControllerOptions) rateLimiter() workqueue.RateLimiter {
+	baseDelay, maxDelay := o.BaseRetryDelay, o.MaxRetryDelay
+	if baseDelay <= 0 {
+		baseDelay = defaultBaseRetryDelay
+	}
+	if maxDelay <= 0 {
+		maxDelay = defaultMaxRetryDelay
+	}
+	qps, burst := o.QPS, o.Burst
+	if qps <= 0 {
+		qps = defaultQPS
+	}
+	if burst <= 0 {
+		burst = defaultBurst
+	}
+	return workqueue.NewMaxOfRateLimiter(
+		workqueue.NewItemExponentialFailureRateLimiter(baseDelay, maxDelay),
+		&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(qps), burst)},
+	)
+}
+
+// shardSelector returns a selector of apps and jobs reconciled by the controller of the shard.
+// Objects are assigned to a shard with the <group>/shard label set by "ketch app deploy --shard" and "ketch job deploy --shard",
+// the controller without a shard reconciles objects without the label.
+func shardSelector(group, shard string) (labels.Selector, error) {
+	key := ShardLabel(group)
+	op, values := selection.Equals, []string{shard}
+	if shard == "" {
+		op, values = selection.DoesNotExist, nil
+	}
+	requirement, err := labels.NewRequirement(key, op, values)
+	if err != nil {
+		return nil, err
+	}
+	return labels.NewSelector().Add(*requirement), nil
+}
+
+// ShardLabel returns the label assigning apps and jobs to a shard of controllers.
+func ShardLabel(group string) string {
+	return group + "/shard"
+}
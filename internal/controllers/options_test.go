@@ -0,0 +1,44 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestControllerOptions_rateLimiter(t *testing.T) {
+	limiter := ControllerOptions{}.rateLimiter()
+	require.Equal(t, defaultBaseRetryDelay, limiter.When("app"))
+	require.Equal(t, 2*defaultBaseRetryDelay, limiter.When("app"))
+	limiter.Forget("app")
+	require.Equal(t, defaultBaseRetryDelay, limiter.When("app"))
+
+	limiter = ControllerOptions{BaseRetryDelay: time.Second, MaxRetryDelay: 3 * time.Second}.rateLimiter()
+	require.Equal(t, time.Second, limiter.When("app"))
+	require.Equal(t, 2*time.Second, limiter.When("app"))
+	require.Equal(t, 3*time.Second, limiter.When("app"))
+	require.Equal(t, 3*time.Second, limiter.When("app"))
+
+	options := ControllerOptions{MaxConcurrentReconciles: 8}.controllerOptions()
+	require.Equal(t, 8, options.MaxConcurrentReconciles)
+	require.NotNil(t, options.RateLimiter)
+}
+
+func Test_shardSelector(t *testing.T) {
+	selector, err := shardSelector("theketch.io", "")
+	require.Nil(t, err)
+	require.True(t, selector.Matches(labels.Set{"app": "hello"}))
+	require.False(t, selector.Matches(labels.Set{"theketch.io/shard": "a"}))
+
+	selector, err = shardSelector("theketch.io", "a")
+	require.Nil(t, err)
+	require.Equal(t, "theketch.io/shard=a", selector.String())
+	require.True(t, selector.Matches(labels.Set{"theketch.io/shard": "a"}))
+	require.False(t, selector.Matches(labels.Set{"theketch.io/shard": "b"}))
+	require.False(t, selector.Matches(labels.Set{}))
+
+	_, err = shardSelector("theketch.io", "invalid shard!")
+	require.NotNil(t, err)
+}
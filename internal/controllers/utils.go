@@ -1,9 +1,15 @@
 package controllers
 
 import (
+	"context"
 	"strconv"
 
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
 	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/chart"
 )
 
 // uninstallHelmChart checks if there is a special annotation that
@@ -22,3 +28,72 @@ func uninstallHelmChart(group string, annotations map[string]string) bool {
 	}
 	return !keepChart
 }
+
+// deleteVolumes checks if there is a special annotation that
+// makes ketch-controller remove persistent volume claims of a removed App.
+func deleteVolumes(group string, annotations map[string]string) bool {
+	deleteVolumes, err := strconv.ParseBool(annotations[ketchv1.DeleteVolumesAnnotation(group)])
+	return err == nil && deleteVolumes
+}
+
+// chartEngine returns the engine installing the chart of an App or a Job.
+// A chart keeps the engine it was installed with, so it isn't installed twice or orphaned when the engine
+// of the ingress configmap changes, installed is empty if the chart hasn't been installed yet.
+func chartEngine(ctx context.Context, c client.Client, installed string) (string, error) {
+	if installed != "" {
+		return installed, nil
+	}
+	var configmap v1.ConfigMap
+	err := c.Get(ctx, types.NamespacedName{Name: ketchv1.IngressConfigmapName, Namespace: ketchv1.IngressConfigmapNamespace}, &configmap)
+	if client.IgnoreNotFound(err) != nil {
+		return "", err
+	}
+	engine := configmap.Data[ketchv1.EngineKey]
+	if err := chart.ValidateEngine(engine); err != nil {
+		return "", err
+	}
+	if engine == "" {
+		return chart.HelmEngine, nil
+	}
+	return engine, nil
+}
+
+// installedEngine returns the engine recorded in the status of an App or a Job.
+// Charts reconciled before their engine was recorded were installed with helm.
+func installedEngine(engine string, conditions []ketchv1.Condition) string {
+	if engine != "" {
+		return engine
+	}
+	for _, c := range conditions {
+		if c.Type == ketchv1.Scheduled {
+			return chart.HelmEngine
+		}
+	}
+	return ""
+}
+
+// driftPolicy returns the drift policy of the ingress configmap, report if it isn't set.
+func driftPolicy(ctx context.Context, c client.Client) (string, error) {
+	var configmap v1.ConfigMap
+	err := c.Get(ctx, types.NamespacedName{Name: ketchv1.IngressConfigmapName, Namespace: ketchv1.IngressConfigmapNamespace}, &configmap)
+	if client.IgnoreNotFound(err) != nil {
+		return "", err
+	}
+	policy := configmap.Data[ketchv1.DriftPolicyKey]
+	if err := chart.ValidateDriftPolicy(policy); err != nil {
+		return "", err
+	}
+	if policy == "" {
+		return chart.ReportDriftPolicy, nil
+	}
+	return policy, nil
+}
+
+// newChartClient returns a client installing charts to the namespace with the engine,
+// helm is used if there is no factory of server-side apply clients.
+func newChartClient(engine string, namespace string, helmFn, applyFn helmFactoryFn) (Helm, error) {
+	if engine == chart.ServerSideApplyEngine && applyFn != nil {
+		return applyFn(namespace)
+	}
+	return helmFn(namespace)
+}
@@ -1,9 +1,17 @@
 package controllers
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlFake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
 )
 
 func Test_uninstallHelmChart(t *testing.T) {
@@ -43,3 +51,123 @@ func Test_uninstallHelmChart(t *testing.T) {
 		})
 	}
 }
+
+func Test_deleteVolumes(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        bool
+	}{
+		{
+			name:        "delete volumes",
+			annotations: map[string]string{"theketch.io/delete-volumes": "true"},
+			want:        true,
+		},
+		{
+			name:        "invalid value - keep volumes",
+			annotations: map[string]string{"theketch.io/delete-volumes": "some-value"},
+		},
+		{
+			name: "no annotation - keep volumes",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, deleteVolumes("theketch.io", tt.annotations))
+		})
+	}
+}
+
+func Test_chartEngine(t *testing.T) {
+	configmap := func(engine string) *v1.ConfigMap {
+		return &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: ketchv1.IngressConfigmapName, Namespace: ketchv1.IngressConfigmapNamespace},
+			Data:       map[string]string{ketchv1.EngineKey: engine},
+		}
+	}
+	tests := []struct {
+		name      string
+		objects   []client.Object
+		installed string
+		want      string
+		wantErr   bool
+	}{
+		{
+			name: "helm without the ingress configmap",
+			want: "helm",
+		},
+		{
+			name:    "engine of the ingress configmap",
+			objects: []client.Object{configmap("server-side-apply")},
+			want:    "server-side-apply",
+		},
+		{
+			name:      "installed charts keep their engine",
+			objects:   []client.Object{configmap("server-side-apply")},
+			installed: "helm",
+			want:      "helm",
+		},
+		{
+			name:    "invalid engine",
+			objects: []client.Object{configmap("kapp")},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cli := ctrlFake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(tt.objects...).Build()
+			engine, err := chartEngine(context.Background(), cli, tt.installed)
+			require.Equal(t, tt.wantErr, err != nil, err)
+			require.Equal(t, tt.want, engine)
+		})
+	}
+}
+
+func Test_installedEngine(t *testing.T) {
+	require.Equal(t, "", installedEngine("", nil))
+	require.Equal(t, "server-side-apply", installedEngine("server-side-apply", nil))
+	require.Equal(t, "helm", installedEngine("", []ketchv1.Condition{{Type: ketchv1.Scheduled, Status: v1.ConditionTrue}}))
+}
+
+func Test_driftPolicy(t *testing.T) {
+	configmap := func(policy string) *v1.ConfigMap {
+		return &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: ketchv1.IngressConfigmapName, Namespace: ketchv1.IngressConfigmapNamespace},
+			Data:       map[string]string{ketchv1.DriftPolicyKey: policy},
+		}
+	}
+	tests := []struct {
+		name    string
+		objects []client.Object
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "report without the ingress configmap",
+			want: "report",
+		},
+		{
+			name:    "report by default",
+			objects: []client.Object{configmap("")},
+			want:    "report",
+		},
+		{
+			name:    "policy of the ingress configmap",
+			objects: []client.Object{configmap("revert")},
+			want:    "revert",
+		},
+		{
+			name:    "invalid policy",
+			objects: []client.Object{configmap("ignore")},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cli := ctrlFake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(tt.objects...).Build()
+			policy, err := driftPolicy(context.Background(), cli)
+			require.Equal(t, tt.wantErr, err != nil, err)
+			require.Equal(t, tt.want, policy)
+		})
+	}
+}
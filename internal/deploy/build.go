@@ -0,0 +1,177 @@
+package deploy
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/build"
+)
+
+// maxParallelBuilds is the number of images built from source at the same time.
+const maxParallelBuilds = 4
+
+// sourceBuild is an image built from a directory of the app's source code.
+type sourceBuild struct {
+	// process is a process built from its own directory, it's empty for the image of the app.
+	process    string
+	image      string
+	cacheImage string
+	sourcePath string
+	buildPacks []string
+}
+
+// sourceBuilds returns the build of the app's image followed by builds of processes of ketch.yaml
+// with their own directories of the source code.
+func sourceBuilds(app *ketchv1.App, ketchYaml *ketchv1.KetchYamlData, image, cacheImage, sourcePath string) ([]sourceBuild, error) {
+	builds := []sourceBuild{
+		{image: image, cacheImage: cacheImage, sourcePath: sourcePath, buildPacks: app.Spec.BuildPacks},
+	}
+	if ketchYaml == nil || ketchYaml.Kubernetes == nil {
+		return builds, nil
+	}
+	names := make([]string, 0, len(ketchYaml.Kubernetes.Processes))
+	for name, process := range ketchYaml.Kubernetes.Processes {
+		if process.Build != nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		processBuild := ketchYaml.Kubernetes.Processes[name].Build
+		dir := path.Join(sourcePath, processBuild.Source)
+		if err := directoryExists(dir); err != nil {
+			return nil, fmt.Errorf("source of process %q: %w", name, err)
+		}
+		b := sourceBuild{
+			process:    name,
+			image:      processImage(image, name),
+			sourcePath: dir,
+			buildPacks: app.Spec.BuildPacks,
+		}
+		if len(processBuild.BuildPacks) > 0 {
+			b.buildPacks = processBuild.BuildPacks
+		}
+		if len(cacheImage) > 0 {
+			b.cacheImage = processImage(cacheImage, name)
+		}
+		builds = append(builds, b)
+	}
+	return builds, nil
+}
+
+// processImage returns the image of a process built from its own directory,
+// the process name is appended to the repository of the app's image and the tag is kept.
+func processImage(image, process string) string {
+	repository, tag := image, ""
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		repository, tag = image[:i], image[i:]
+	}
+	return repository + "-" + process + tag
+}
+
+// buildImages builds the images concurrently. Logs of each build are prefixed with the name of the app or the process
+// when there are several builds.
+func buildImages(ctx context.Context, svc *Services, app *ketchv1.App, builds []sourceBuild) error {
+	if len(builds) == 1 {
+		var opts []build.Option
+		if svc.Writer != nil {
+			opts = append(opts, build.WithOutput(svc.Writer))
+		}
+		return buildFromSource(ctx, svc, app, builds[0], opts...)
+	}
+	var out io.Writer = ioutil.Discard
+	if svc.Writer != nil {
+		out = svc.Writer
+	}
+	var mu sync.Mutex
+	errs := make([]error, len(builds))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < maxParallelBuilds && w < len(builds); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				name := builds[i].process
+				if name == "" {
+					name = app.Name
+				}
+				logs := &prefixWriter{mu: &mu, out: out, prefix: fmt.Sprintf("[%s] ", name)}
+				errs[i] = buildFromSource(ctx, svc, app, builds[i], build.WithOutput(logs))
+				logs.Flush()
+			}
+		}()
+	}
+	for i := range builds {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		if builds[i].process != "" {
+			return fmt.Errorf("failed to build image of process %q: %w", builds[i].process, err)
+		}
+		return err
+	}
+	return nil
+}
+
+func buildFromSource(ctx context.Context, svc *Services, app *ketchv1.App, b sourceBuild, opts ...build.Option) error {
+	return svc.Builder(
+		ctx,
+		&build.CreateImageFromSourceRequest{
+			Image:      b.image,
+			AppName:    app.Name,
+			Builder:    app.Spec.Builder,
+			BuildPacks: b.buildPacks,
+			CacheImage: b.cacheImage,
+		},
+		append([]build.Option{build.WithWorkingDirectory(b.sourcePath)}, opts...)...,
+	)
+}
+
+// prefixWriter writes complete lines with a prefix, so logs of builds running at the same time can be told apart.
+// Writers sharing an output share the mutex, it guards their buffers too.
+type prefixWriter struct {
+	mu     *sync.Mutex
+	out    io.Writer
+	prefix string
+	buf    []byte
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		if _, err := fmt.Fprintf(w.out, "%s%s", w.prefix, w.buf[:i+1]); err != nil {
+			return 0, err
+		}
+		w.buf = w.buf[i+1:]
+	}
+}
+
+// Flush writes the last line if it doesn't end with a newline.
+func (w *prefixWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.buf) > 0 {
+		fmt.Fprintf(w.out, "%s%s\n", w.prefix, w.buf)
+		w.buf = nil
+	}
+}
@@ -0,0 +1,148 @@
+package deploy
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/build"
+)
+
+func Test_processImage(t *testing.T) {
+	tests := []struct {
+		image string
+		want  string
+	}{
+		{image: "shipa/go-sample", want: "shipa/go-sample-worker"},
+		{image: "shipa/go-sample:0.1", want: "shipa/go-sample-worker:0.1"},
+		{image: "localhost:5000/go-sample:0.1", want: "localhost:5000/go-sample-worker:0.1"},
+		{image: "localhost:5000/go-sample", want: "localhost:5000/go-sample-worker"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			require.Equal(t, tt.want, processImage(tt.image, "worker"))
+		})
+	}
+}
+
+func Test_sourceBuilds(t *testing.T) {
+	sourcePath := t.TempDir()
+	require.Nil(t, os.MkdirAll(path.Join(sourcePath, "services", "worker"), 0700))
+	app := &ketchv1.App{Spec: ketchv1.AppSpec{BuildPacks: []string{"heroku/go"}}}
+
+	tests := []struct {
+		name       string
+		ketchYaml  *ketchv1.KetchYamlData
+		cacheImage string
+		want       []sourceBuild
+		wantErr    string
+	}{
+		{
+			name: "no ketch.yaml",
+			want: []sourceBuild{
+				{image: "shipa/go-sample:0.1", sourcePath: sourcePath, buildPacks: []string{"heroku/go"}},
+			},
+		},
+		{
+			name: "process with its own source",
+			ketchYaml: &ketchv1.KetchYamlData{
+				Kubernetes: &ketchv1.KetchYamlKubernetesConfig{
+					Processes: map[string]ketchv1.KetchYamlProcessConfig{
+						"web":    {},
+						"worker": {Build: &ketchv1.KetchYamlProcessBuild{Source: "services/worker", BuildPacks: []string{"heroku/nodejs"}}},
+					},
+				},
+			},
+			cacheImage: "shipa/cache",
+			want: []sourceBuild{
+				{image: "shipa/go-sample:0.1", cacheImage: "shipa/cache", sourcePath: sourcePath, buildPacks: []string{"heroku/go"}},
+				{process: "worker", image: "shipa/go-sample-worker:0.1", cacheImage: "shipa/cache-worker", sourcePath: path.Join(sourcePath, "services", "worker"), buildPacks: []string{"heroku/nodejs"}},
+			},
+		},
+		{
+			name: "missing source of a process",
+			ketchYaml: &ketchv1.KetchYamlData{
+				Kubernetes: &ketchv1.KetchYamlKubernetesConfig{
+					Processes: map[string]ketchv1.KetchYamlProcessConfig{
+						"worker": {Build: &ketchv1.KetchYamlProcessBuild{Source: "services/queue"}},
+					},
+				},
+			},
+			wantErr: fmt.Sprintf(`source of process "worker": "%s" invalid value directory doesn't exist`, path.Join(sourcePath, "services", "queue")),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builds, err := sourceBuilds(app, tt.ketchYaml, "shipa/go-sample:0.1", tt.cacheImage, sourcePath)
+			if len(tt.wantErr) > 0 {
+				require.NotNil(t, err)
+				require.Equal(t, tt.wantErr, err.Error())
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.want, builds)
+		})
+	}
+}
+
+func Test_buildImages(t *testing.T) {
+	var mu sync.Mutex
+	var images []string
+	svc := &Services{
+		Builder: func(_ context.Context, req *build.CreateImageFromSourceRequest, _ ...build.Option) error {
+			mu.Lock()
+			defer mu.Unlock()
+			images = append(images, req.Image)
+			if req.Image == "shipa/go-sample-broken" {
+				return fmt.Errorf("build failed")
+			}
+			return nil
+		},
+		Writer: &bytes.Buffer{},
+	}
+	app := &ketchv1.App{}
+	app.Name = "go-sample"
+
+	builds := []sourceBuild{{image: "shipa/go-sample"}}
+	for _, process := range []string{"a", "b", "c", "d", "e"} {
+		builds = append(builds, sourceBuild{process: process, image: processImage("shipa/go-sample", process)})
+	}
+	require.Nil(t, buildImages(context.Background(), svc, app, builds))
+	sort.Strings(images)
+	require.Equal(t, []string{
+		"shipa/go-sample",
+		"shipa/go-sample-a",
+		"shipa/go-sample-b",
+		"shipa/go-sample-c",
+		"shipa/go-sample-d",
+		"shipa/go-sample-e",
+	}, images)
+
+	builds = append(builds, sourceBuild{process: "broken", image: "shipa/go-sample-broken"})
+	err := buildImages(context.Background(), svc, app, builds)
+	require.NotNil(t, err)
+	require.Equal(t, `failed to build image of process "broken": build failed`, err.Error())
+}
+
+func Test_prefixWriter(t *testing.T) {
+	var mu sync.Mutex
+	out := &bytes.Buffer{}
+	web := &prefixWriter{mu: &mu, out: out, prefix: "[web] "}
+	worker := &prefixWriter{mu: &mu, out: out, prefix: "[worker] "}
+
+	fmt.Fprint(web, "===> DETECTING\nheroku/go ")
+	fmt.Fprint(worker, "===> DETECTING\n")
+	fmt.Fprint(web, "0.1.0\n===> BUILDING")
+	web.Flush()
+	worker.Flush()
+
+	require.Equal(t, "[web] ===> DETECTING\n[worker] ===> DETECTING\n[web] heroku/go 0.1.0\n[web] ===> BUILDING\n", out.String())
+}
@@ -0,0 +1,442 @@
+package deploy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	registryv1 "github.com/google/go-containerregistry/pkg/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/yaml"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/chart"
+)
+
+// defaultComposeVolumeSize is the size of claims of named volumes of a docker-compose file,
+// a claim with the same name in ketch.yaml overrides it.
+const defaultComposeVolumeSize = "1Gi"
+
+// composeFile is the part of a docker-compose file that ketch deploys.
+type composeFile struct {
+	Services map[string]composeService `json:"services"`
+}
+
+// composeService is a service of a docker-compose file, it's deployed as a process of the app.
+type composeService struct {
+	Image       string             `json:"image"`
+	Build       interface{}        `json:"build"`
+	Entrypoint  *composeCommand    `json:"entrypoint"`
+	Command     *composeCommand    `json:"command"`
+	Environment composeEnvironment `json:"environment"`
+	Ports       []composePort      `json:"ports"`
+	Expose      []composePort      `json:"expose"`
+	Volumes     []composeVolume    `json:"volumes"`
+	DependsOn   composeDependsOn   `json:"depends_on"`
+}
+
+// composeCommand is a command in the list form, a command in the string form is split the way a shell does it.
+type composeCommand []string
+
+func (c *composeCommand) UnmarshalJSON(data []byte) error {
+	var command string
+	if err := json.Unmarshal(data, &command); err == nil {
+		args, err := chart.SplitCommand(command)
+		if err != nil {
+			return err
+		}
+		*c = args
+		return nil
+	}
+	var args []string
+	if err := json.Unmarshal(data, &args); err != nil {
+		return fmt.Errorf("command must be a string or a list of strings")
+	}
+	*c = args
+	return nil
+}
+
+// composeEnvironment is the environment of a service either in the list form or in the map form.
+// A variable without a value gets its value from the environment ketch runs in, like docker-compose does.
+type composeEnvironment []ketchv1.Env
+
+func (e *composeEnvironment) UnmarshalJSON(data []byte) error {
+	var list []string
+	if err := json.Unmarshal(data, &list); err == nil {
+		for _, item := range list {
+			parts := strings.SplitN(item, "=", 2)
+			if len(parts) == 2 {
+				*e = append(*e, ketchv1.Env{Name: parts[0], Value: parts[1]})
+				continue
+			}
+			if value, ok := os.LookupEnv(parts[0]); ok {
+				*e = append(*e, ketchv1.Env{Name: parts[0], Value: value})
+			}
+		}
+		return nil
+	}
+	var values map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	// numbers are kept as they are written instead of being converted to floats.
+	decoder.UseNumber()
+	if err := decoder.Decode(&values); err != nil {
+		return fmt.Errorf("environment must be a list or a map")
+	}
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if values[name] == nil {
+			if value, ok := os.LookupEnv(name); ok {
+				*e = append(*e, ketchv1.Env{Name: name, Value: value})
+			}
+			continue
+		}
+		*e = append(*e, ketchv1.Env{Name: name, Value: fmt.Sprint(values[name])})
+	}
+	return nil
+}
+
+// composePort is a port of a service either in the short syntax "[host_ip:][published:]target[/protocol]"
+// or in the long syntax.
+type composePort ketchv1.KetchYamlProcessPortConfig
+
+func (p *composePort) UnmarshalJSON(data []byte) error {
+	var number int
+	if err := json.Unmarshal(data, &number); err == nil {
+		*p = composePort{Port: number, TargetPort: number}
+		return nil
+	}
+	var short string
+	if err := json.Unmarshal(data, &short); err == nil {
+		port, err := parseComposePort(short)
+		if err != nil {
+			return err
+		}
+		*p = port
+		return nil
+	}
+	var long struct {
+		Target    int         `json:"target"`
+		Published interface{} `json:"published"`
+		Protocol  string      `json:"protocol"`
+	}
+	if err := json.Unmarshal(data, &long); err != nil {
+		return fmt.Errorf("port must be a number, a string or a map")
+	}
+	port := composePort{Port: long.Target, TargetPort: long.Target, Protocol: strings.ToUpper(long.Protocol)}
+	if long.Published != nil {
+		published, err := strconv.Atoi(fmt.Sprint(long.Published))
+		if err != nil {
+			return fmt.Errorf("unsupported published port %v, port ranges aren't supported", long.Published)
+		}
+		port.Port = published
+	}
+	*p = port
+	return nil
+}
+
+func parseComposePort(value string) (composePort, error) {
+	var port composePort
+	spec := value
+	if i := strings.LastIndex(spec, "/"); i >= 0 {
+		port.Protocol = strings.ToUpper(spec[i+1:])
+		spec = spec[:i]
+	}
+	parts := strings.Split(spec, ":")
+	target, err := strconv.Atoi(parts[len(parts)-1])
+	if err != nil {
+		return port, fmt.Errorf("unsupported port %q, port ranges aren't supported", value)
+	}
+	port.Port, port.TargetPort = target, target
+	if len(parts) > 1 && parts[len(parts)-2] != "" {
+		published, err := strconv.Atoi(parts[len(parts)-2])
+		if err != nil {
+			return port, fmt.Errorf("unsupported port %q, port ranges aren't supported", value)
+		}
+		port.Port = published
+	}
+	return port, nil
+}
+
+// composeVolume is a volume of a service either in the short syntax "[source:]target[:mode]" or in the long syntax.
+// Only named volumes are deployed, ketch can't mount directories of the host.
+type composeVolume struct {
+	Named    bool
+	Source   string
+	Target   string
+	ReadOnly bool
+}
+
+func (v *composeVolume) UnmarshalJSON(data []byte) error {
+	var short string
+	if err := json.Unmarshal(data, &short); err == nil {
+		parts := strings.Split(short, ":")
+		switch len(parts) {
+		case 1:
+			v.Target = parts[0]
+		default:
+			v.Source, v.Target = parts[0], parts[1]
+			if len(parts) > 2 {
+				v.ReadOnly = strings.Contains(","+parts[2]+",", ",ro,")
+			}
+		}
+		v.Named = v.Source != "" && !strings.HasPrefix(v.Source, "/") && !strings.HasPrefix(v.Source, ".") && !strings.HasPrefix(v.Source, "~")
+		return nil
+	}
+	var long struct {
+		Type     string `json:"type"`
+		Source   string `json:"source"`
+		Target   string `json:"target"`
+		ReadOnly bool   `json:"read_only"`
+	}
+	if err := json.Unmarshal(data, &long); err != nil {
+		return fmt.Errorf("volume must be a string or a map")
+	}
+	*v = composeVolume{
+		Named:    long.Type == "volume" && long.Source != "",
+		Source:   long.Source,
+		Target:   long.Target,
+		ReadOnly: long.ReadOnly,
+	}
+	return nil
+}
+
+// composeDependsOn lists services a service depends on either in the list form or in the map form.
+type composeDependsOn []string
+
+func (d *composeDependsOn) UnmarshalJSON(data []byte) error {
+	var list []string
+	if err := json.Unmarshal(data, &list); err == nil {
+		*d = list
+		return nil
+	}
+	var conditions map[string]interface{}
+	if err := json.Unmarshal(data, &conditions); err != nil {
+		return fmt.Errorf("depends_on must be a list or a map")
+	}
+	for name := range conditions {
+		*d = append(*d, name)
+	}
+	sort.Strings(*d)
+	return nil
+}
+
+// composeApp is a docker-compose file converted to processes of an app.
+type composeApp struct {
+	// image is the image of the routable process, it's the image of the deployment.
+	image string
+	// processImages contains images of processes that differ from the image of the deployment.
+	processImages map[string]string
+	processEnvs   map[string][]ketchv1.Env
+	procfile      *chart.Procfile
+	ports         map[string][]ketchv1.KetchYamlProcessPortConfig
+	volumeClaims  []ketchv1.KetchYamlVolumeClaim
+	// notes describe parts of the docker-compose file that ketch doesn't deploy.
+	notes []string
+}
+
+// newComposeApp converts services of a docker-compose file to processes.
+// imageConfig returns the config of an image of a service that doesn't set its entrypoint or command.
+func newComposeApp(file composeFile, imageConfig func(image string) (*registryv1.ConfigFile, error)) (*composeApp, error) {
+	if len(file.Services) == 0 {
+		return nil, fmt.Errorf("docker-compose file has no services")
+	}
+	names := make([]string, 0, len(file.Services))
+	for name := range file.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	app := &composeApp{
+		processImages: map[string]string{},
+		processEnvs:   map[string][]ketchv1.Env{},
+		ports:         map[string][]ketchv1.KetchYamlProcessPortConfig{},
+	}
+	images := map[string]string{}
+	processes := make([]ketchv1.ProcessSpec, 0, len(names))
+	claims := map[string]int{}
+	for _, name := range names {
+		service := file.Services[name]
+		if errs := validation.IsDNS1123Label(name); len(errs) > 0 {
+			return nil, fmt.Errorf("service %q can't be a process: %s", name, strings.Join(errs, ", "))
+		}
+		if service.Image == "" {
+			if service.Build != nil {
+				return nil, fmt.Errorf("service %q is built by docker-compose, push its image and set the image of the service", name)
+			}
+			return nil, fmt.Errorf("service %q has no image", name)
+		}
+		images[name] = service.Image
+
+		cmd, err := composeServiceCmd(service, imageConfig)
+		if err != nil {
+			return nil, fmt.Errorf("service %q: %w", name, err)
+		}
+		processes = append(processes, ketchv1.ProcessSpec{Name: name, Cmd: cmd})
+
+		if len(service.Environment) > 0 {
+			app.processEnvs[name] = service.Environment
+		}
+		for _, port := range append(service.Ports, service.Expose...) {
+			app.ports[name] = append(app.ports[name], ketchv1.KetchYamlProcessPortConfig(port))
+		}
+		for _, volume := range service.Volumes {
+			if !volume.Named {
+				app.notes = append(app.notes, fmt.Sprintf("volume %s of service %s isn't a named volume, it isn't deployed", volume.Target, name))
+				continue
+			}
+			if errs := validation.IsDNS1123Label(volume.Source); len(errs) > 0 {
+				return nil, fmt.Errorf("volume %q of service %q can't be a volume claim: %s", volume.Source, name, strings.Join(errs, ", "))
+			}
+			i, ok := claims[volume.Source]
+			if !ok {
+				i = len(app.volumeClaims)
+				claims[volume.Source] = i
+				app.volumeClaims = append(app.volumeClaims, ketchv1.KetchYamlVolumeClaim{Name: volume.Source, Size: defaultComposeVolumeSize})
+			}
+			app.volumeClaims[i].Mounts = append(app.volumeClaims[i].Mounts, ketchv1.KetchYamlVolumeClaimMount{
+				Process:  name,
+				Path:     volume.Target,
+				ReadOnly: volume.ReadOnly,
+			})
+		}
+		for _, dependency := range service.DependsOn {
+			if _, ok := file.Services[dependency]; !ok {
+				return nil, fmt.Errorf("service %q depends on unknown service %q", name, dependency)
+			}
+			app.notes = append(app.notes, fmt.Sprintf("service %s depends on %s, processes start independently, %s must wait for %s itself", name, dependency, name, dependency))
+		}
+	}
+
+	procfile, err := chart.ProcfileFromProcesses(processes)
+	if err != nil {
+		return nil, err
+	}
+	app.procfile = procfile
+	app.image = images[procfile.RoutableProcessName]
+	for name, image := range images {
+		if image != app.image {
+			app.processImages[name] = image
+		}
+	}
+	return app, nil
+}
+
+// composeServiceCmd returns the command of a service, the entrypoint and the command of its image are used
+// the way docker-compose uses them when the service doesn't override them.
+func composeServiceCmd(service composeService, imageConfig func(image string) (*registryv1.ConfigFile, error)) ([]string, error) {
+	var entrypoint, command []string
+	if service.Entrypoint == nil || service.Command == nil {
+		cfg, err := imageConfig(service.Image)
+		if err != nil {
+			return nil, err
+		}
+		entrypoint, command = cfg.Config.Entrypoint, cfg.Config.Cmd
+	}
+	if service.Entrypoint != nil {
+		entrypoint = *service.Entrypoint
+		// an entrypoint of the service resets the command of the image.
+		command = nil
+	}
+	if service.Command != nil {
+		command = *service.Command
+	}
+	cmd := append(append([]string{}, entrypoint...), command...)
+	if len(cmd) == 0 {
+		return nil, fmt.Errorf("no entrypoint or command")
+	}
+	return cmd, nil
+}
+
+// mergeKetchYaml adds ports and volume claims of the docker-compose file to ketch.yaml.
+// Ports of processes and volume claims declared in ketch.yaml take precedence.
+func (a *composeApp) mergeKetchYaml(ketchYaml *ketchv1.KetchYamlData) {
+	if len(a.ports) > 0 && ketchYaml.Kubernetes == nil {
+		ketchYaml.Kubernetes = &ketchv1.KetchYamlKubernetesConfig{}
+	}
+	if len(a.ports) > 0 && ketchYaml.Kubernetes.Processes == nil {
+		ketchYaml.Kubernetes.Processes = map[string]ketchv1.KetchYamlProcessConfig{}
+	}
+	for name, ports := range a.ports {
+		process := ketchYaml.Kubernetes.Processes[name]
+		if len(process.Ports) == 0 {
+			process.Ports = ports
+			ketchYaml.Kubernetes.Processes[name] = process
+		}
+	}
+	declared := map[string]bool{}
+	for _, claim := range ketchYaml.VolumeClaims {
+		declared[claim.Name] = true
+	}
+	for _, claim := range a.volumeClaims {
+		if !declared[claim.Name] {
+			ketchYaml.VolumeClaims = append(ketchYaml.VolumeClaims, claim)
+		}
+	}
+}
+
+// applyCompose converts the docker-compose file of the change set to the image, processes and ketch.yaml of the deployment.
+func applyCompose(ctx context.Context, svc *Services, cs *ChangeSet) error {
+	composePath, err := cs.getComposeFile()
+	if err != nil {
+		return err
+	}
+	switch {
+	case cs.sourcePath != nil:
+		return fmt.Errorf("%w %s can't be used to deploy from source", newInvalidUsageError(FlagCompose), FlagCompose)
+	case cs.image != nil:
+		return fmt.Errorf("%w %s can't be used with %s, images of the services are deployed", newInvalidUsageError(FlagCompose), FlagCompose, FlagImage)
+	case cs.procfileName != nil:
+		return fmt.Errorf("%w %s can't be used with %s, services are deployed as processes", newInvalidUsageError(FlagCompose), FlagCompose, FlagProcfile)
+	}
+	content, err := ioutil.ReadFile(composePath)
+	if err != nil {
+		return err
+	}
+	var file composeFile
+	if err := yaml.Unmarshal(content, &file); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", composePath, err)
+	}
+	secretName, _ := cs.getDockerRegistrySecret()
+	namespace, _ := cs.getNamespace()
+	app, err := newComposeApp(file, func(image string) (*registryv1.ConfigFile, error) {
+		return svc.GetImageConfig(ctx, ImageConfigRequest{
+			imageName:       image,
+			secretName:      secretName,
+			secretNamespace: namespace,
+			client:          svc.KubeClient,
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to convert %s: %w", composePath, err)
+	}
+	ketchYaml, err := cs.getKetchYaml()
+	if err != nil {
+		return err
+	}
+	if ketchYaml == nil {
+		ketchYaml = &ketchv1.KetchYamlData{}
+	}
+	app.mergeKetchYaml(ketchYaml)
+
+	cs.image = &app.image
+	cs.procfile = app.procfile
+	cs.processImages = app.processImages
+	cs.processEnvs = app.processEnvs
+	cs.ketchYamlData = ketchYaml
+	if svc.Writer != nil {
+		for _, note := range app.notes {
+			fmt.Fprintln(svc.Writer, note)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,251 @@
+package deploy
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"testing"
+
+	registryv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/chart"
+)
+
+const testComposeFile = `
+version: "3.9"
+services:
+  web:
+    image: shipa/go-sample:0.1
+    command: ./web --port 8080
+    ports:
+      - "80:8080"
+      - 127.0.0.1:9090:9090/udp
+    environment:
+      DATABASE_HOST: db
+      WORKERS: 4
+    depends_on:
+      - db
+    volumes:
+      - uploads:/app/uploads
+      - ./static:/app/static
+  worker:
+    image: shipa/go-sample:0.1
+    entrypoint: ["./worker"]
+    environment:
+      - QUEUE=jobs
+    volumes:
+      - type: volume
+        source: uploads
+        target: /data/uploads
+        read_only: true
+  db:
+    image: postgres:14
+    expose:
+      - "5432"
+    volumes:
+      - pgdata:/var/lib/postgresql/data
+`
+
+func testImageConfig(image string) (*registryv1.ConfigFile, error) {
+	switch image {
+	case "postgres:14":
+		return &registryv1.ConfigFile{Config: registryv1.Config{Entrypoint: []string{"docker-entrypoint.sh"}, Cmd: []string{"postgres"}}}, nil
+	case "shipa/go-sample:0.1":
+		return &registryv1.ConfigFile{Config: registryv1.Config{Cmd: []string{"./web"}}}, nil
+	}
+	return nil, fmt.Errorf("image %s not found", image)
+}
+
+func Test_newComposeApp(t *testing.T) {
+	var file composeFile
+	require.Nil(t, yaml.Unmarshal([]byte(testComposeFile), &file))
+
+	app, err := newComposeApp(file, testImageConfig)
+	require.Nil(t, err)
+	require.Equal(t, &composeApp{
+		image:         "shipa/go-sample:0.1",
+		processImages: map[string]string{"db": "postgres:14"},
+		processEnvs: map[string][]ketchv1.Env{
+			"web":    {{Name: "DATABASE_HOST", Value: "db"}, {Name: "WORKERS", Value: "4"}},
+			"worker": {{Name: "QUEUE", Value: "jobs"}},
+		},
+		procfile: &chart.Procfile{
+			Processes: map[string][]string{
+				"db":     {"docker-entrypoint.sh", "postgres"},
+				"web":    {"./web", "--port", "8080"},
+				"worker": {"./worker"},
+			},
+			RoutableProcessName: "web",
+		},
+		ports: map[string][]ketchv1.KetchYamlProcessPortConfig{
+			"db":  {{Port: 5432, TargetPort: 5432}},
+			"web": {{Port: 80, TargetPort: 8080}, {Protocol: "UDP", Port: 9090, TargetPort: 9090}},
+		},
+		volumeClaims: []ketchv1.KetchYamlVolumeClaim{
+			{Name: "pgdata", Size: "1Gi", Mounts: []ketchv1.KetchYamlVolumeClaimMount{{Process: "db", Path: "/var/lib/postgresql/data"}}},
+			{Name: "uploads", Size: "1Gi", Mounts: []ketchv1.KetchYamlVolumeClaimMount{
+				{Process: "web", Path: "/app/uploads"},
+				{Process: "worker", Path: "/data/uploads", ReadOnly: true},
+			}},
+		},
+		notes: []string{
+			"volume /app/static of service web isn't a named volume, it isn't deployed",
+			"service web depends on db, processes start independently, web must wait for db itself",
+		},
+	}, app)
+}
+
+func Test_newComposeAppErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		compose string
+		wantErr string
+	}{
+		{
+			name:    "no services",
+			compose: `version: "3.9"`,
+			wantErr: "docker-compose file has no services",
+		},
+		{
+			name: "service built by docker-compose",
+			compose: `
+services:
+  web:
+    build: .`,
+			wantErr: `service "web" is built by docker-compose, push its image and set the image of the service`,
+		},
+		{
+			name: "invalid process name",
+			compose: `
+services:
+  my_web:
+    image: shipa/go-sample:0.1`,
+			wantErr: `service "my_web" can't be a process`,
+		},
+		{
+			name: "unknown dependency",
+			compose: `
+services:
+  web:
+    image: shipa/go-sample:0.1
+    depends_on:
+      db:
+        condition: service_healthy`,
+			wantErr: `service "web" depends on unknown service "db"`,
+		},
+		{
+			name: "image without a command",
+			compose: `
+services:
+  web:
+    image: shipa/unknown`,
+			wantErr: `service "web": image shipa/unknown not found`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var file composeFile
+			require.Nil(t, yaml.Unmarshal([]byte(tt.compose), &file))
+			_, err := newComposeApp(file, testImageConfig)
+			require.NotNil(t, err)
+			require.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func Test_parseComposePort(t *testing.T) {
+	tests := []struct {
+		port    string
+		want    composePort
+		wantErr string
+	}{
+		{port: "8080", want: composePort{Port: 8080, TargetPort: 8080}},
+		{port: "80:8080", want: composePort{Port: 80, TargetPort: 8080}},
+		{port: "127.0.0.1::8080", want: composePort{Port: 8080, TargetPort: 8080}},
+		{port: "[::1]:53:5353/udp", want: composePort{Protocol: "UDP", Port: 53, TargetPort: 5353}},
+		{port: "8000-8010:8000-8010", wantErr: `unsupported port "8000-8010:8000-8010", port ranges aren't supported`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.port, func(t *testing.T) {
+			port, err := parseComposePort(tt.port)
+			if len(tt.wantErr) > 0 {
+				require.NotNil(t, err)
+				require.Equal(t, tt.wantErr, err.Error())
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.want, port)
+		})
+	}
+}
+
+func Test_composeEnvironment(t *testing.T) {
+	t.Setenv("COMPOSE_TEST_TOKEN", "secret")
+	var service composeService
+	require.Nil(t, yaml.Unmarshal([]byte(`
+environment:
+  - DEBUG=true
+  - COMPOSE_TEST_TOKEN
+  - COMPOSE_TEST_UNSET`), &service))
+	require.Equal(t, composeEnvironment{{Name: "DEBUG", Value: "true"}, {Name: "COMPOSE_TEST_TOKEN", Value: "secret"}}, service.Environment)
+
+	service = composeService{}
+	require.Nil(t, yaml.Unmarshal([]byte(`
+environment:
+  PORT: 8080
+  DEBUG: true
+  COMPOSE_TEST_TOKEN:`), &service))
+	require.Equal(t, composeEnvironment{{Name: "COMPOSE_TEST_TOKEN", Value: "secret"}, {Name: "DEBUG", Value: "true"}, {Name: "PORT", Value: "8080"}}, service.Environment)
+}
+
+func Test_applyCompose(t *testing.T) {
+	dir := t.TempDir()
+	composePath := path.Join(dir, "docker-compose.yaml")
+	require.Nil(t, os.WriteFile(composePath, []byte(testComposeFile), 0600))
+	ketchYamlPath := path.Join(dir, "ketch.yaml")
+	require.Nil(t, os.WriteFile(ketchYamlPath, []byte(`
+kubernetes:
+  processes:
+    web:
+      ports:
+        - target_port: 8080
+volumeClaims:
+  - name: pgdata
+    size: 10Gi`), 0600))
+
+	out := &bytes.Buffer{}
+	svc := &Services{
+		GetImageConfig: func(_ context.Context, args ImageConfigRequest) (*registryv1.ConfigFile, error) {
+			return testImageConfig(args.imageName)
+		},
+		Writer: out,
+	}
+	cs := &ChangeSet{composeFile: &composePath, ketchYamlFileName: &ketchYamlPath}
+	require.Nil(t, applyCompose(context.Background(), svc, cs))
+
+	image, _ := cs.getImage()
+	require.Equal(t, "shipa/go-sample:0.1", image)
+	procfile, err := cs.getProcfile()
+	require.Nil(t, err)
+	require.Equal(t, "web", procfile.RoutableProcessName)
+	require.Equal(t, map[string]string{"db": "postgres:14"}, cs.processImages)
+	ketchYaml, err := cs.getKetchYaml()
+	require.Nil(t, err)
+	require.Equal(t, []ketchv1.KetchYamlProcessPortConfig{{TargetPort: 8080}}, ketchYaml.Kubernetes.Processes["web"].Ports)
+	require.Equal(t, []ketchv1.KetchYamlProcessPortConfig{{Port: 5432, TargetPort: 5432}}, ketchYaml.Kubernetes.Processes["db"].Ports)
+	require.Len(t, ketchYaml.VolumeClaims, 2)
+	require.Equal(t, "10Gi", ketchYaml.VolumeClaims[0].Size)
+	require.Equal(t, "uploads", ketchYaml.VolumeClaims[1].Name)
+	require.Contains(t, out.String(), "service web depends on db")
+
+	image = "shipa/go-sample:0.2"
+	cs = &ChangeSet{composeFile: &composePath, image: &image}
+	err = applyCompose(context.Background(), svc, cs)
+	require.NotNil(t, err)
+	require.Equal(t, `"compose" used improperly compose can't be used with image, images of the services are deployed`, err.Error())
+}
@@ -5,6 +5,7 @@ package deploy
 import (
 	"context"
 	"fmt"
+	"os"
 	"strings"
 	"time"
 
@@ -20,6 +21,7 @@ import (
 	"github.com/theketchio/ketch/internal/build"
 	"github.com/theketchio/ketch/internal/chart"
 	"github.com/theketchio/ketch/internal/errors"
+	"github.com/theketchio/ketch/internal/utils"
 )
 
 const (
@@ -53,13 +55,88 @@ func New(params *ChangeSet) *Runner {
 // Run executes the deployment. This includes creating the application CRD if it doesn't already exist, possibly building
 // source code and creating an image and creating and applying a deployment CRD to the cluster.
 func (r Runner) Run(ctx context.Context, svc *Services) error {
+	if err := validateDryRun(r.params); err != nil {
+		return err
+	}
+	if r.params.composeFile != nil {
+		if err := applyCompose(ctx, svc, r.params); err != nil {
+			return err
+		}
+	}
+	if dryRun, _ := r.params.getDryRun(); dryRun {
+		return r.dryRun(ctx, svc)
+	}
+	if source, ok := r.params.getGitSource(); ok {
+		dir, err := cloneSource(ctx, svc, r.params, *source)
+		if dir != "" {
+			defer os.RemoveAll(dir)
+		}
+		if err != nil {
+			return err
+		}
+		r.params.sourcePath = &dir
+	}
+	if r.params.sourcePath != nil && (r.params.image == nil || r.params.cacheImage == nil) {
+		registry, err := clusterRegistry(ctx, svc)
+		if err != nil {
+			return err
+		}
+		// an image built from source and its build cache are pushed to the cluster-wide registry by default.
+		if r.params.image == nil && len(registry.URL) > 0 {
+			image := registry.Image(r.params.appName)
+			r.params.image = &image
+		}
+		if r.params.cacheImage == nil && len(registry.BuildCache) > 0 {
+			cacheImage := registry.CacheImage(r.params.appName)
+			r.params.cacheImage = &cacheImage
+		}
+	}
 	app, err := getUpdatedApp(ctx, svc.Client, r.params)
+	for queue, _ := r.params.getQueue(); queue && isDeployInProgress(err); {
+		if _, err := waitForDeployInProgress(ctx, svc, r.params); err != nil {
+			return err
+		}
+		app, err = getUpdatedApp(ctx, svc.Client, r.params)
+	}
 	if err != nil {
 		return err
 	}
 	return deployImage(ctx, svc, app, r.params)
 }
 
+// cloneSource clones the app's git repository, a git secret is looked up in the namespace of the app.
+func cloneSource(ctx context.Context, svc *Services, cs *ChangeSet, source gitSource) (string, error) {
+	req := CloneSourceRequest{
+		source: source,
+		client: svc.KubeClient,
+	}
+	secret, err := cs.getGitSecret()
+	if err := assign(err, func() error {
+		namespace, err := cs.getNamespace()
+		if isMissing(err) {
+			var app ketchv1.App
+			err := svc.Client.Get(ctx, types.NamespacedName{Name: cs.appName}, &app)
+			if apierrors.IsNotFound(err) {
+				return fmt.Errorf("%w %s is required to find %s of a new app", newMissingError(FlagNamespace), FlagNamespace, FlagGitSecret)
+			}
+			if err != nil {
+				return errors.Wrap(err, "could not get app %q", cs.appName)
+			}
+			namespace, err = app.Spec.Namespace, nil
+		}
+		if err != nil {
+			return err
+		}
+		req.secretName = secret
+		req.secretNamespace = namespace
+		return nil
+	}); err != nil {
+		return "", err
+	}
+	fmt.Fprintf(svc.Writer, "Cloning %s\n", source.url)
+	return svc.CloneSource(ctx, req)
+}
+
 type appUpdater func(ctx context.Context, app *ketchv1.App, changed bool) error
 
 func getAppWithUpdater(ctx context.Context, client Client, cs *ChangeSet) (*ketchv1.App, appUpdater, error) {
@@ -82,6 +159,7 @@ func getAppWithUpdater(ctx context.Context, client Client, cs *ChangeSet) (*ketc
 			app.Spec.Ingress = ketchv1.IngressSpec{
 				GenerateDefaultCname: generateDefaultCName,
 				Cnames:               cname,
+				ForceHTTPS:           app.Spec.Ingress.ForceHTTPS,
 			}
 			return client.Create(ctx, app)
 		}, nil
@@ -131,6 +209,9 @@ func getUpdatedApp(ctx context.Context, client Client, cs *ChangeSet) (*ketchv1.
 		if err := validateDeploy(cs, app); err != nil {
 			return err
 		}
+		if err := checkDeployInProgress(app); err != nil {
+			return err
+		}
 
 		namespace, err := cs.getNamespace()
 		if err := assign(err, func() error {
@@ -141,6 +222,29 @@ func getUpdatedApp(ctx context.Context, client Client, cs *ChangeSet) (*ketchv1.
 			return err
 		}
 
+		strategy, err := cs.getNamespaceStrategy()
+		if err := assign(err, func() error {
+			app.Spec.NamespaceStrategy = strategy
+			changed = true
+			return nil
+		}); err != nil {
+			return err
+		}
+		if app.Spec.NamespaceStrategy == ketchv1.PerAppNamespace && (cs.namespace != nil || cs.namespaceStrategy != nil) {
+			if err := validateAppNamespace(ctx, client, cs.appName, app.Spec.Namespace); err != nil {
+				return err
+			}
+		}
+
+		quota, err := cs.getNamespaceQuota()
+		if err := assign(err, func() error {
+			app.Spec.NamespaceQuota = quota
+			changed = true
+			return nil
+		}); err != nil {
+			return err
+		}
+
 		desc, err := cs.getDescription()
 		if err := assign(err, func() error {
 			app.Spec.Description = desc
@@ -150,9 +254,57 @@ func getUpdatedApp(ctx context.Context, client Client, cs *ChangeSet) (*ketchv1.
 			return err
 		}
 
+		team, err := cs.getTeam()
+		if err := assign(err, func() error {
+			app.Spec.Team = team
+			changed = true
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		owner, err := cs.getOwner()
+		if err := assign(err, func() error {
+			app.Spec.Owner = owner
+			changed = true
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		shard, err := cs.getShard()
+		if err := assign(err, func() error {
+			// an empty shard moves the app to the controller without a shard.
+			if shard == "" {
+				delete(app.Labels, utils.KetchShardLabel)
+			} else {
+				if app.Labels == nil {
+					app.Labels = map[string]string{}
+				}
+				app.Labels[utils.KetchShardLabel] = shard
+			}
+			changed = true
+			return nil
+		}); err != nil {
+			return err
+		}
+
 		envs, err := cs.getEnvironments()
 		if err := assign(err, func() error {
-			app.Spec.Env = envs
+			for i := range envs {
+				// a variable stays sensitive when its value is changed.
+				envs[i].Sensitive = app.IsSensitiveEnv(envs[i].Name)
+			}
+			app.SetEnvs(envs)
+			changed = true
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		envSets, err := cs.getEnvSets()
+		if err := assign(err, func() error {
+			app.Spec.EnvSets = envSets
 			changed = true
 			return nil
 		}); err != nil {
@@ -192,22 +344,57 @@ func getUpdatedApp(ctx context.Context, client Client, cs *ChangeSet) (*ketchv1.
 			return err
 		}
 
+		forceHTTPS, err := cs.getForceHTTPS()
+		if err := assign(err, func() error {
+			app.Spec.Ingress.ForceHTTPS = &forceHTTPS
+			changed = true
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		networkPolicy, err := cs.getNetworkPolicy()
+		if err := assign(err, func() error {
+			if app.Spec.NetworkPolicy == nil {
+				app.Spec.NetworkPolicy = &ketchv1.NetworkPolicySpec{}
+			}
+			app.Spec.NetworkPolicy.Enabled = &networkPolicy
+			changed = true
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		allowFrom, err := cs.getAllowFrom()
+		if err := assign(err, func() error {
+			if app.Spec.NetworkPolicy == nil {
+				app.Spec.NetworkPolicy = &ketchv1.NetworkPolicySpec{}
+			}
+			app.Spec.NetworkPolicy.AllowFromNamespaces = allowFrom
+			changed = true
+			return nil
+		}); err != nil {
+			return err
+		}
+
 		return updater(ctx, app, changed)
 	})
 	return app, err
 }
 
-func buildFromSource(ctx context.Context, svc *Services, app *ketchv1.App, appName, image, sourcePath string) error {
-	return svc.Builder(
-		ctx,
-		&build.CreateImageFromSourceRequest{
-			Image:      image,
-			AppName:    appName,
-			Builder:    app.Spec.Builder,
-			BuildPacks: app.Spec.BuildPacks,
-		},
-		build.WithWorkingDirectory(sourcePath),
-	)
+// clusterRegistry returns the docker registry shared by all apps, it's empty if there is no such registry.
+func clusterRegistry(ctx context.Context, svc *Services) (ketchv1.RegistrySpec, error) {
+	configmap, err := svc.KubeClient.CoreV1().ConfigMaps(ketchv1.IngressConfigmapNamespace).Get(ctx, ketchv1.IngressConfigmapName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return ketchv1.RegistrySpec{}, nil
+	}
+	if err != nil {
+		return ketchv1.RegistrySpec{}, err
+	}
+	if registry := ketchv1.NewIngressControllerSpec(*configmap).Registry; registry != nil {
+		return *registry, nil
+	}
+	return ketchv1.RegistrySpec{}, nil
 }
 
 func deployImage(ctx context.Context, svc *Services, app *ketchv1.App, params *ChangeSet) error {
@@ -216,24 +403,44 @@ func deployImage(ctx context.Context, svc *Services, app *ketchv1.App, params *C
 		return err
 	}
 
-	if len(app.Spec.Ingress.Controller.ClusterIssuer) == 0 && params.hasSecureCnames() {
+	if app.Spec.Ingress.Controller.IssuerRef() == nil && params.hasSecureCnames() {
 		return errors.New("secure cnames require a framework.Ingress.ClusterIssuer to be specified")
 	}
 
 	image, _ := params.getImage()
 
 	fromSource := params.sourcePath != nil
+	processImages := map[string]string{}
+	for process, image := range params.processImages {
+		processImages[process] = image
+	}
 	// build image from source if valid path provided
 	if fromSource {
 		sourcePath, _ := params.getSourceDirectory()
-		if err := buildFromSource(ctx, svc, app, params.appName, image, sourcePath); err != nil {
+		cacheImage, _ := params.getCacheImage()
+		builds, err := sourceBuilds(app, ketchYaml, image, cacheImage, sourcePath)
+		if err != nil {
+			return err
+		}
+		if err := buildImages(ctx, svc, app, builds); err != nil {
 			return errors.Wrap(err, "failed to build image from source path %q", sourcePath)
 		}
+		for _, b := range builds[1:] {
+			processImages[b.process] = b.image
+		}
 	}
 
+	secretName := app.Spec.DockerRegistry.SecretName
+	if len(secretName) == 0 {
+		registry, err := clusterRegistry(ctx, svc)
+		if err != nil {
+			return err
+		}
+		secretName = registry.SecretName
+	}
 	imageRequest := ImageConfigRequest{
 		imageName:       image,
-		secretName:      app.Spec.DockerRegistry.SecretName,
+		secretName:      secretName,
 		secretNamespace: app.Spec.Namespace,
 		client:          svc.KubeClient,
 	}
@@ -241,8 +448,14 @@ func deployImage(ctx context.Context, svc *Services, app *ketchv1.App, params *C
 	if err != nil {
 		return err
 	}
+	if wait, _ := params.getWait(); wait && svc.Writer != nil {
+		fmt.Fprintf(svc.Writer, "image %s validated\n", image)
+	}
 
-	procfile, err := makeProcfile(imgConfig)
+	procfile, err := params.getProcfile()
+	if isMissing(err) {
+		procfile, err = makeProcfile(imgConfig)
+	}
 	if err != nil {
 		return err
 	}
@@ -260,17 +473,24 @@ func deployImage(ctx context.Context, svc *Services, app *ketchv1.App, params *C
 	steps, _ := params.getSteps()
 	stepWeight, _ := params.getStepWeight()
 	interval, _ := params.getStepInterval()
+	canaryRouting, _ := params.getCanaryRouting()
+	shadow, _ := params.getShadow()
 	units, _ := params.getUnits()
 	version, _ := params.getVersion()
 	process, _ := params.getProcess()
+	cmds, _ := params.getCmds()
 
 	currentTime := time.Now()
 
 	updateRequest := updateAppCRDRequest{
 		appVersion:        params.appVersion,
 		image:             image,
+		processImages:     processImages,
+		processEnvs:       params.processEnvs,
 		steps:             steps,
 		stepWeight:        stepWeight,
+		canaryRouting:     canaryRouting,
+		shadow:            shadow,
 		procFile:          procfile,
 		fromSource:        fromSource,
 		ketchYaml:         ketchYaml,
@@ -282,12 +502,24 @@ func deployImage(ctx context.Context, svc *Services, app *ketchv1.App, params *C
 		version:           version,
 		process:           process,
 		processes:         params.processes,
+		cmds:              cmds,
 		volume:            volume,
 		volumes:           volumes,
 		volumeMounts:      volumeMounts,
+		deploymentsCount:  &app.Spec.DeploymentsCount,
 	}
 
-	if app, err = updateAppCRD(ctx, svc, params.appName, updateRequest); err != nil {
+	app, err = updateAppCRD(ctx, svc, params.appName, updateRequest)
+	for queue, _ := params.getQueue(); queue && isDeployInProgress(err); {
+		current, waitErr := waitForDeployInProgress(ctx, svc, params)
+		if waitErr != nil {
+			return waitErr
+		}
+		// the deployment is made on top of the one it has waited for.
+		updateRequest.deploymentsCount = &current.Spec.DeploymentsCount
+		app, err = updateAppCRD(ctx, svc, params.appName, updateRequest)
+	}
+	if err != nil {
 		deploymentType := "image"
 		if fromSource {
 			deploymentType = "source"
@@ -296,7 +528,8 @@ func deployImage(ctx context.Context, svc *Services, app *ketchv1.App, params *C
 	}
 
 	wait, _ := params.getWait()
-	if wait {
+	dryRun, _ := params.getDryRun()
+	if wait && !dryRun {
 		timeout, _ := params.getTimeout()
 		return svc.Wait(ctx, svc, app, timeout)
 	}
@@ -326,8 +559,12 @@ func makeProcfile(cfg *registryv1.ConfigFile) (*chart.Procfile, error) {
 type updateAppCRDRequest struct {
 	appVersion        *string
 	image             string
+	processImages     map[string]string
+	processEnvs       map[string][]ketchv1.Env
 	steps             int
 	stepWeight        uint8
+	canaryRouting     *ketchv1.CanaryRouting
+	shadow            uint8
 	procFile          *chart.Procfile
 	fromSource        bool
 	ketchYaml         *ketchv1.KetchYamlData
@@ -339,9 +576,12 @@ type updateAppCRDRequest struct {
 	version           int
 	process           string
 	processes         *[]ketchv1.ProcessSpec
+	cmds              map[string][]string
 	volume            string
 	volumes           []v1.Volume
 	volumeMounts      []v1.VolumeMount
+	// deploymentsCount is the number of deployments of the app when the deploy started, nil skips the check.
+	deploymentsCount *int
 }
 
 func updateAppCRD(ctx context.Context, svc *Services, appName string, args updateAppCRDRequest) (*ketchv1.App, error) {
@@ -350,7 +590,27 @@ func updateAppCRD(ctx context.Context, svc *Services, appName string, args updat
 		if err := svc.Client.Get(ctx, types.NamespacedName{Name: appName}, &updated); err != nil {
 			return errors.Wrap(err, "could not get app to deploy %q", appName)
 		}
+		if args.deploymentsCount != nil {
+			if err := checkDeployedConcurrently(&updated, *args.deploymentsCount); err != nil {
+				return err
+			}
+		}
 		updated.Spec.Version = args.appVersion
+		if updated.Annotations == nil {
+			updated.Annotations = map[string]string{}
+		}
+		updated.Annotations[utils.KetchDeployedByAnnotation] = utils.CurrentUser()
+
+		// a new deployment replaces the shadow deployment, if any.
+		if updated.Spec.ShadowDeployment() != nil && !updated.Spec.Canary.Active {
+			deployments := make([]ketchv1.AppDeploymentSpec, 0, len(updated.Spec.Deployments)-1)
+			for _, deployment := range updated.Spec.Deployments {
+				if !deployment.IsShadow() {
+					deployments = append(deployments, deployment)
+				}
+			}
+			updated.Spec.Deployments = deployments
+		}
 
 		if len(updated.Spec.Deployments) > 1 && !updated.Spec.Canary.Active {
 			return errors.New("cannot have more than one deployment per app, unless canary")
@@ -383,12 +643,33 @@ func updateAppCRD(ctx context.Context, svc *Services, appName string, args updat
 			}
 		}
 
+		releaseCmd := args.procFile.ReleaseCmd
+		for processName, cmd := range args.cmds {
+			if processName == chart.ReleaseProcessName && releaseCmd != nil {
+				releaseCmd = cmd
+				continue
+			}
+			if _, ok := args.procFile.Processes[processName]; !ok {
+				return fmt.Errorf("can't override the command of process %q, the image has no such process", processName)
+			}
+		}
+		for processName := range args.processImages {
+			if _, ok := args.procFile.Processes[processName]; !ok {
+				return fmt.Errorf("can't set the image of process %q, the Procfile has no such process", processName)
+			}
+		}
+
 		processes := make([]ketchv1.ProcessSpec, 0, len(args.procFile.Processes))
 		for _, processName := range args.procFile.SortedNames() {
 			cmd := args.procFile.Processes[processName]
+			if override, ok := args.cmds[processName]; ok {
+				cmd = override
+			}
 			ps := ketchv1.ProcessSpec{
-				Name: processName,
-				Cmd:  cmd,
+				Name:  processName,
+				Cmd:   cmd,
+				Image: args.processImages[processName],
+				Env:   args.processEnvs[processName],
 			}
 
 			if args.process == "" || args.process == processName {
@@ -396,6 +677,16 @@ func updateAppCRD(ctx context.Context, svc *Services, appName string, args updat
 				ps.VolumeMounts = args.volumeMounts
 			}
 
+			// autoscaling of a process is kept by new deployments whatever their image is.
+			if len(updated.Spec.Deployments) > 0 {
+				latest := updated.Spec.Deployments[len(updated.Spec.Deployments)-1]
+				for _, previousProcess := range latest.Processes {
+					if previousProcess.Name == processName {
+						ps.Autoscale = previousProcess.Autoscale
+					}
+				}
+			}
+
 			if usePreviousDeploymentSpecs {
 				for _, previousProcess := range updated.Spec.Deployments[0].Processes {
 					// if the process names for the new and previous deployments match update units to
@@ -421,18 +712,19 @@ func updateAppCRD(ctx context.Context, svc *Services, appName string, args updat
 
 		// default deployment spec for an app
 		deploymentSpec := ketchv1.AppDeploymentSpec{
-			Image:     args.image,
-			Version:   ketchv1.DeploymentVersion(updated.Spec.DeploymentsCount),
-			Processes: processes,
-			KetchYaml: args.ketchYaml,
+			Image:      args.image,
+			Version:    ketchv1.DeploymentVersion(updated.Spec.DeploymentsCount),
+			Processes:  processes,
+			KetchYaml:  args.ketchYaml,
+			ReleaseCmd: releaseCmd,
 			RoutingSettings: ketchv1.RoutingSettings{
 				Weight: defaultTrafficWeight,
 			},
 			ExposedPorts: exposedPorts,
 		}
 
-		// update deployment and version only for canary deployment or a new deployment
-		if !usePreviousDeploymentSpecs || args.steps > 1 {
+		// update deployment and version only for canary or shadow deployment or a new deployment
+		if !usePreviousDeploymentSpecs || args.steps > 1 || args.shadow > 0 {
 			deploymentSpec.Version += 1
 			updated.Spec.DeploymentsCount += 1
 		}
@@ -448,6 +740,7 @@ func updateAppCRD(ctx context.Context, svc *Services, appName string, args updat
 				CurrentStep:       1,
 				Active:            true,
 				Started:           &started,
+				Routing:           args.canaryRouting,
 			}
 
 			// set initial weight for canary deployment to zero.
@@ -456,6 +749,10 @@ func updateAppCRD(ctx context.Context, svc *Services, appName string, args updat
 
 			// For a canary deployment, canary should be enabled by adding another deployment to the deployment list.
 			updated.Spec.Deployments = append(updated.Spec.Deployments, deploymentSpec)
+		} else if args.shadow > 0 {
+			// a shadow deployment gets no traffic of its own, a percentage of requests is copied to it.
+			deploymentSpec.RoutingSettings = ketchv1.RoutingSettings{MirrorPercentage: args.shadow}
+			updated.Spec.Deployments = append(updated.Spec.Deployments, deploymentSpec)
 		} else {
 			updated.Spec.Deployments = []ketchv1.AppDeploymentSpec{deploymentSpec}
 		}
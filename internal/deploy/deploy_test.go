@@ -290,6 +290,168 @@ func Test_updateAppCRD(t *testing.T) {
 				require.Equal(t, mock.app.Spec.Deployments[0].Version, ketchv1.DeploymentVersion(1))
 			},
 		},
+		{
+			name: "canary deployment with routing rules",
+			args: args{
+				ctx:     context.Background(),
+				appName: "test-app",
+				args: updateAppCRDRequest{
+					image:         "test/pack-test:v2",
+					steps:         4,
+					stepWeight:    25,
+					canaryRouting: &ketchv1.CanaryRouting{Header: "X-Canary", HeaderValue: "true"},
+					procFile: &chart.Procfile{
+						Processes:           map[string][]string{"web": {"web"}},
+						RoutableProcessName: "web",
+					},
+					configFile: &registryv1.ConfigFile{
+						Config: registryv1.Config{
+							ExposedPorts: make(map[string]struct{}),
+						},
+					},
+				},
+				svc: &Services{
+					Client: func() *mockClient {
+						m := newMockClient()
+						m.app.Spec.DeploymentsCount = 1
+						m.app.Spec.Deployments = []ketchv1.AppDeploymentSpec{
+							{
+								Image:           "test/pack-test:v1",
+								Version:         1,
+								Processes:       []ketchv1.ProcessSpec{{Name: "web", Cmd: []string{"web"}}},
+								RoutingSettings: ketchv1.RoutingSettings{Weight: 100},
+							},
+						}
+						return m
+					}(),
+				},
+			},
+			validate: func(t *testing.T, mock *mockClient) {
+				require.Len(t, mock.app.Spec.Deployments, 2)
+				require.True(t, mock.app.Spec.Canary.Active)
+				require.Equal(t, &ketchv1.CanaryRouting{Header: "X-Canary", HeaderValue: "true"}, mock.app.Spec.Canary.Routing)
+			},
+		},
+		{
+			name: "shadow deployment replaces the previous shadow deployment",
+			args: args{
+				ctx:     context.Background(),
+				appName: "test-app",
+				args: updateAppCRDRequest{
+					image:  "test/pack-test:v3",
+					shadow: 20,
+					procFile: &chart.Procfile{
+						Processes:           map[string][]string{"web": {"web"}},
+						RoutableProcessName: "web",
+					},
+					configFile: &registryv1.ConfigFile{
+						Config: registryv1.Config{
+							ExposedPorts: make(map[string]struct{}),
+						},
+					},
+				},
+				svc: &Services{
+					Client: func() *mockClient {
+						m := newMockClient()
+						m.app.Spec.DeploymentsCount = 2
+						m.app.Spec.Deployments = []ketchv1.AppDeploymentSpec{
+							{
+								Image:           "test/pack-test:v1",
+								Version:         1,
+								Processes:       []ketchv1.ProcessSpec{{Name: "web", Cmd: []string{"web"}}},
+								RoutingSettings: ketchv1.RoutingSettings{Weight: 100},
+							},
+							{
+								Image:           "test/pack-test:v2",
+								Version:         2,
+								Processes:       []ketchv1.ProcessSpec{{Name: "web", Cmd: []string{"web"}}},
+								RoutingSettings: ketchv1.RoutingSettings{MirrorPercentage: 10},
+							},
+						}
+						return m
+					}(),
+				},
+			},
+			validate: func(t *testing.T, mock *mockClient) {
+				require.Len(t, mock.app.Spec.Deployments, 2)
+				require.Equal(t, "test/pack-test:v1", mock.app.Spec.Deployments[0].Image)
+				require.Equal(t, ketchv1.RoutingSettings{Weight: 100}, mock.app.Spec.Deployments[0].RoutingSettings)
+				require.Equal(t, "test/pack-test:v3", mock.app.Spec.Deployments[1].Image)
+				require.Equal(t, ketchv1.DeploymentVersion(3), mock.app.Spec.Deployments[1].Version)
+				require.Equal(t, ketchv1.RoutingSettings{MirrorPercentage: 20}, mock.app.Spec.Deployments[1].RoutingSettings)
+				require.True(t, mock.app.Spec.Deployments[1].IsShadow())
+			},
+		},
+		{
+			name: "command of a process is overridden",
+			args: args{
+				ctx:     context.Background(),
+				appName: "test-app",
+				args: updateAppCRDRequest{
+					image: "test/pack-test:latest",
+					procFile: &chart.Procfile{
+						Processes:           map[string][]string{"web": {"web"}, "worker": {"worker"}},
+						RoutableProcessName: "web",
+					},
+					cmds: map[string][]string{"web": {"/bin/sh", "-c", "bundle exec puma -p $PORT"}},
+					configFile: &registryv1.ConfigFile{
+						Config: registryv1.Config{
+							ExposedPorts: make(map[string]struct{}),
+						},
+					},
+				},
+				svc: &Services{Client: newMockClient()},
+			},
+			validate: func(t *testing.T, mock *mockClient) {
+				require.Equal(t, []ketchv1.ProcessSpec{
+					{Name: "web", Cmd: []string{"/bin/sh", "-c", "bundle exec puma -p $PORT"}},
+					{Name: "worker", Cmd: []string{"worker"}},
+				}, mock.app.Spec.Deployments[0].Processes)
+			},
+		},
+		{
+			name: "command of an unknown process",
+			args: args{
+				ctx:     context.Background(),
+				appName: "test-app",
+				args: updateAppCRDRequest{
+					image: "test/pack-test:latest",
+					procFile: &chart.Procfile{
+						Processes:           map[string][]string{"web": {"web"}},
+						RoutableProcessName: "web",
+					},
+					cmds: map[string][]string{"worker": {"/bin/sh", "-c", "sidekiq"}},
+				},
+				svc: &Services{Client: newMockClient()},
+			},
+			wantErr: true,
+		},
+		{
+			name: "release process",
+			args: args{
+				ctx:     context.Background(),
+				appName: "test-app",
+				args: updateAppCRDRequest{
+					image: "test/pack-test:latest",
+					procFile: &chart.Procfile{
+						Processes:           map[string][]string{"web": {"web"}},
+						RoutableProcessName: "web",
+						ReleaseCmd:          []string{"release"},
+					},
+					cmds: map[string][]string{"release": {"/bin/sh", "-c", "rake db:migrate"}},
+					configFile: &registryv1.ConfigFile{
+						Config: registryv1.Config{
+							ExposedPorts: make(map[string]struct{}),
+						},
+					},
+				},
+				svc: &Services{Client: newMockClient()},
+			},
+			validate: func(t *testing.T, mock *mockClient) {
+				require.Equal(t, []string{"/bin/sh", "-c", "rake db:migrate"}, mock.app.Spec.Deployments[0].ReleaseCmd)
+				require.Equal(t, []ketchv1.ProcessSpec{{Name: "web", Cmd: []string{"web"}}}, mock.app.Spec.Deployments[0].Processes)
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -0,0 +1,284 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/chart"
+	"github.com/theketchio/ketch/internal/templates"
+)
+
+// dryRun performs the deployment against an in-memory copy of the app and prints the manifests the app would get,
+// or a diff between the manifests of the app in the cluster and the updated app if --diff is set.
+func (r Runner) dryRun(ctx context.Context, svc *Services) error {
+	diff, _ := r.params.getDiff()
+	currentManifests, updatedManifests, err := r.renderDryRun(ctx, svc, diff)
+	if err != nil {
+		return err
+	}
+	if !diff {
+		fmt.Fprintln(svc.Writer, strings.TrimSpace(updatedManifests))
+		return nil
+	}
+	return writeManifestsDiff(svc.Writer, currentManifests, updatedManifests)
+}
+
+// Diff writes a diff between the manifests of the app in the cluster and the manifests the deployment gives it
+// without changing the app, it returns false if the deployment changes no manifest.
+func (r Runner) Diff(ctx context.Context, svc *Services) (bool, error) {
+	currentManifests, updatedManifests, err := r.renderDryRun(ctx, svc, true)
+	if err != nil {
+		return false, err
+	}
+	return writeChangedManifestsDiff(svc.Writer, currentManifests, updatedManifests)
+}
+
+// WriteAppDiff writes a diff between the manifests of the current app and the updated one,
+// the current app is nil if it doesn't exist yet. It returns false if no manifest changes.
+func WriteAppDiff(ctx context.Context, svc *Services, current, updated *ketchv1.App) (bool, error) {
+	var currentManifests string
+	var err error
+	if current != nil {
+		if currentManifests, err = renderManifests(ctx, svc, current.DeepCopy()); err != nil {
+			return false, err
+		}
+	}
+	updatedManifests, err := renderManifests(ctx, svc, updated.DeepCopy())
+	if err != nil {
+		return false, err
+	}
+	return writeChangedManifestsDiff(svc.Writer, currentManifests, updatedManifests)
+}
+
+// writeChangedManifestsDiff writes a diff of the manifests and returns false if no object changes.
+func writeChangedManifestsDiff(out io.Writer, current, updated string) (bool, error) {
+	if err := writeManifestsDiff(out, current, updated); err != nil {
+		return false, err
+	}
+	currentObjects, err := splitManifests(current)
+	if err != nil {
+		return false, err
+	}
+	updatedObjects, err := splitManifests(updated)
+	if err != nil {
+		return false, err
+	}
+	return !reflect.DeepEqual(currentObjects, updatedObjects), nil
+}
+
+// renderDryRun performs the deployment against an in-memory copy of the app and renders manifests of the updated app,
+// manifests of the app in the cluster are rendered too if withCurrent is set, they're empty for a new app.
+func (r Runner) renderDryRun(ctx context.Context, svc *Services, withCurrent bool) (string, string, error) {
+	var current *ketchv1.App
+	var app ketchv1.App
+	err := svc.Client.Get(ctx, types.NamespacedName{Name: r.params.appName}, &app)
+	switch {
+	case err == nil:
+		current = &app
+	case !apierrors.IsNotFound(err):
+		return "", "", err
+	}
+
+	dryRunSvc := *svc
+	dryRunSvc.Client = newDryRunClient(svc.Client)
+	updated, err := getUpdatedApp(ctx, dryRunSvc.Client, r.params)
+	if err != nil {
+		return "", "", err
+	}
+	if err := deployImage(ctx, &dryRunSvc, updated, r.params); err != nil {
+		return "", "", err
+	}
+	if err := dryRunSvc.Client.Get(ctx, types.NamespacedName{Name: r.params.appName}, updated); err != nil {
+		return "", "", err
+	}
+	updatedManifests, err := renderManifests(ctx, svc, updated)
+	if err != nil {
+		return "", "", err
+	}
+	var currentManifests string
+	if withCurrent && current != nil {
+		if currentManifests, err = renderManifests(ctx, svc, current); err != nil {
+			return "", "", err
+		}
+	}
+	return currentManifests, updatedManifests, nil
+}
+
+// dryRunClient keeps apps created or updated during a dry run in memory instead of sending them to the cluster.
+type dryRunClient struct {
+	Client
+	apps map[string]*ketchv1.App
+}
+
+func newDryRunClient(c Client) *dryRunClient {
+	return &dryRunClient{Client: c, apps: map[string]*ketchv1.App{}}
+}
+
+func (c *dryRunClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	if app, ok := obj.(*ketchv1.App); ok {
+		if stored, ok := c.apps[key.Name]; ok {
+			stored.DeepCopyInto(app)
+			return nil
+		}
+	}
+	return c.Client.Get(ctx, key, obj)
+}
+
+func (c *dryRunClient) Create(_ context.Context, obj client.Object, _ ...client.CreateOption) error {
+	return c.store(obj)
+}
+
+func (c *dryRunClient) Update(_ context.Context, obj client.Object, _ ...client.UpdateOption) error {
+	return c.store(obj)
+}
+
+func (c *dryRunClient) store(obj client.Object) error {
+	app, ok := obj.(*ketchv1.App)
+	if !ok {
+		return fmt.Errorf("dry run can't store %T", obj)
+	}
+	c.apps[app.Name] = app.DeepCopy()
+	return nil
+}
+
+// renderManifests renders the app's helm chart the way the app controller does.
+func renderManifests(ctx context.Context, svc *Services, app *ketchv1.App) (string, error) {
+	controller := app.Spec.Ingress.Controller
+	if controller.IngressType == "" || controller.ServiceEndpoint == "" || controller.ClassName == "" {
+		configmap, err := svc.KubeClient.CoreV1().ConfigMaps(ketchv1.IngressConfigmapNamespace).Get(ctx, ketchv1.IngressConfigmapName, metav1.GetOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return "", err
+		}
+		if err == nil {
+			app.Spec.Ingress.Controller = *ketchv1.NewIngressControllerSpec(*configmap)
+		}
+	}
+	tpls, err := templates.AppTemplates(svc.Templates, app.Spec.Ingress.Controller.IngressType.String(), app.Spec.Ingress.Controller.Templates)
+	if err != nil {
+		return "", err
+	}
+	envSets, err := ketchv1.GetAppEnvSets(ctx, svc.Client, app)
+	if err != nil {
+		return "", err
+	}
+	bindingSecrets, err := ketchv1.GetAppBindingSecrets(ctx, svc.Client, app)
+	if err != nil {
+		return "", err
+	}
+	return chart.RenderApplication(app, *tpls, chart.WithEnvSets(envSets), chart.WithServiceBindingSecrets(bindingSecrets))
+}
+
+var manifestSeparator = regexp.MustCompile(`(?m)^---\s*$`)
+
+// splitManifests returns the manifests' objects by "Kind namespace/name".
+func splitManifests(manifests string) (map[string]string, error) {
+	objects := map[string]string{}
+	for _, doc := range manifestSeparator.Split(manifests, -1) {
+		var lines []string
+		for _, line := range strings.Split(strings.TrimSpace(doc), "\n") {
+			// helm marks each object with a template it comes from.
+			if !strings.HasPrefix(line, "# Source:") {
+				lines = append(lines, line)
+			}
+		}
+		content := strings.TrimSpace(strings.Join(lines, "\n"))
+		if len(content) == 0 {
+			continue
+		}
+		var object struct {
+			Kind     string `json:"kind"`
+			Metadata struct {
+				Name      string `json:"name"`
+				Namespace string `json:"namespace"`
+			} `json:"metadata"`
+		}
+		if err := yaml.Unmarshal([]byte(content), &object); err != nil {
+			return nil, fmt.Errorf("failed to parse rendered manifests: %w", err)
+		}
+		key := object.Kind + " " + object.Metadata.Name
+		if len(object.Metadata.Namespace) > 0 {
+			key = object.Kind + " " + object.Metadata.Namespace + "/" + object.Metadata.Name
+		}
+		objects[key] = content
+	}
+	return objects, nil
+}
+
+// writeManifestsDiff writes a unified diff of every object added, changed or removed by the updated manifests.
+func writeManifestsDiff(out io.Writer, current, updated string) error {
+	currentObjects, err := splitManifests(current)
+	if err != nil {
+		return err
+	}
+	updatedObjects, err := splitManifests(updated)
+	if err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(currentObjects)+len(updatedObjects))
+	for key := range currentObjects {
+		keys = append(keys, key)
+	}
+	for key := range updatedObjects {
+		if _, ok := currentObjects[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var added, changed, removed int
+	for _, key := range keys {
+		a, inCurrent := currentObjects[key]
+		b, inUpdated := updatedObjects[key]
+		if a == b {
+			continue
+		}
+		status := "changed"
+		switch {
+		case !inCurrent:
+			status = "added"
+			added++
+		case !inUpdated:
+			status = "removed"
+			removed++
+		default:
+			changed++
+		}
+		diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        splitLines(a),
+			B:        splitLines(b),
+			FromFile: "current",
+			ToFile:   "new",
+			Context:  3,
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "%s (%s)\n%s", key, status, diff)
+	}
+	if added+changed+removed == 0 {
+		fmt.Fprintln(out, "No changes.")
+		return nil
+	}
+	fmt.Fprintf(out, "%d added, %d changed, %d removed.\n", added, changed, removed)
+	return nil
+}
+
+func splitLines(s string) []string {
+	if len(s) == 0 {
+		return nil
+	}
+	return difflib.SplitLines(s)
+}
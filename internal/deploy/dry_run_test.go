@@ -0,0 +1,127 @@
+package deploy
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_writeManifestsDiff(t *testing.T) {
+	current := `---
+# Source: app/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: hello-web-1
+spec:
+  type: ClusterIP
+---
+# Source: app/templates/configmap.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: hello-config
+  namespace: default
+data:
+  key: value
+`
+	tests := []struct {
+		name    string
+		current string
+		updated string
+		want    string
+	}{
+		{
+			name:    "no changes",
+			current: current,
+			updated: current,
+			want:    "No changes.\n",
+		},
+		{
+			name:    "new app",
+			updated: "---\n# Source: app/templates/service.yaml\napiVersion: v1\nkind: Service\nmetadata:\n  name: hello-web-1\n",
+			want: `Service hello-web-1 (added)
+--- current
++++ new
+@@ -0,0 +1,4 @@
++apiVersion: v1
++kind: Service
++metadata:
++  name: hello-web-1
+1 added, 0 changed, 0 removed.
+`,
+		},
+		{
+			name:    "changed and removed objects",
+			current: current,
+			updated: "---\n# Source: app/templates/service.yaml\napiVersion: v1\nkind: Service\nmetadata:\n  name: hello-web-1\nspec:\n  type: NodePort\n",
+			want: `ConfigMap default/hello-config (removed)
+--- current
++++ new
+@@ -1,7 +0,0 @@
+-apiVersion: v1
+-kind: ConfigMap
+-metadata:
+-  name: hello-config
+-  namespace: default
+-data:
+-  key: value
+Service hello-web-1 (changed)
+--- current
++++ new
+@@ -3,4 +3,4 @@
+ metadata:
+   name: hello-web-1
+ spec:
+-  type: ClusterIP
++  type: NodePort
+0 added, 1 changed, 1 removed.
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			err := writeManifestsDiff(out, tt.current, tt.updated)
+			require.Nil(t, err)
+			require.Equal(t, tt.want, out.String())
+		})
+	}
+}
+
+func Test_validateDryRun(t *testing.T) {
+	yes := true
+	no := false
+	source := "src"
+	tests := []struct {
+		name    string
+		cs      *ChangeSet
+		wantErr string
+	}{
+		{
+			name: "dry run with diff",
+			cs:   &ChangeSet{dryRun: &yes, diff: &yes},
+		},
+		{
+			name:    "diff without dry run",
+			cs:      &ChangeSet{dryRun: &no, diff: &yes},
+			wantErr: `"diff" used improperly diff requires dry-run`,
+		},
+		{
+			name:    "dry run from source",
+			cs:      &ChangeSet{dryRun: &yes, sourcePath: &source},
+			wantErr: `"dry-run" used improperly dry-run can't build an image from source, deploy an image instead`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateDryRun(tt.cs)
+			if len(tt.wantErr) > 0 {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.Nil(t, err)
+		})
+	}
+}
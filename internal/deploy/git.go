@@ -0,0 +1,125 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport/http"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport/ssh"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/theketchio/ketch/internal/errors"
+)
+
+// gitSource is a remote git repository containing source code of an app,
+// it is passed to "ketch app deploy" as URL#ref, e.g. https://github.com/org/repo.git#main.
+type gitSource struct {
+	url string
+	// ref is a branch, a tag or a commit to check out, the default branch is used if it's empty.
+	ref string
+}
+
+var gitURLPrefixes = []string{"https://", "http://", "ssh://", "git://", "git@"}
+
+// parseGitSource returns a gitSource if the given source is a URL of a git repository.
+func parseGitSource(source string) (*gitSource, bool) {
+	for _, prefix := range gitURLPrefixes {
+		if strings.HasPrefix(source, prefix) {
+			url, ref := source, ""
+			if i := strings.LastIndex(source, "#"); i >= 0 {
+				url, ref = source[:i], source[i+1:]
+			}
+			return &gitSource{url: url, ref: ref}, true
+		}
+	}
+	return nil, false
+}
+
+type CloneSourceRequest struct {
+	source          gitSource
+	secretName      string
+	secretNamespace string
+	client          kubernetes.Interface
+}
+
+type CloneSourceFn func(ctx context.Context, args CloneSourceRequest) (string, error)
+
+// CloneGitSource clones the repository to a temporary directory and checks out the requested ref.
+// The caller is responsible for removing the directory.
+func CloneGitSource(ctx context.Context, args CloneSourceRequest) (string, error) {
+	auth, err := gitAuth(ctx, args)
+	if err != nil {
+		return "", err
+	}
+	dir, err := ioutil.TempDir("", "ketch-source-")
+	if err != nil {
+		return "", errors.Wrap(err, "could not create a directory to clone %q", args.source.url)
+	}
+	repo, err := git.PlainCloneContext(ctx, dir, false, &git.CloneOptions{
+		URL:  args.source.url,
+		Auth: auth,
+	})
+	if err != nil {
+		return dir, errors.Wrap(err, "could not clone %q", args.source.url)
+	}
+	if args.source.ref == "" {
+		return dir, nil
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(args.source.ref))
+	if err != nil {
+		// branches other than the default one exist only as remote references after cloning.
+		hash, err = repo.ResolveRevision(plumbing.Revision(fmt.Sprintf("%s/%s", git.DefaultRemoteName, args.source.ref)))
+	}
+	if err != nil {
+		return dir, errors.Wrap(err, "could not find %q in %q", args.source.ref, args.source.url)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return dir, errors.Wrap(err, "could not get worktree of %q", args.source.url)
+	}
+	if err := worktree.Checkout(&git.CheckoutOptions{Hash: *hash}); err != nil {
+		return dir, errors.Wrap(err, "could not check out %q", args.source.ref)
+	}
+	return dir, nil
+}
+
+// gitAuth returns credentials stored in a Secret of "kubernetes.io/basic-auth" or "kubernetes.io/ssh-auth" type.
+func gitAuth(ctx context.Context, args CloneSourceRequest) (transport.AuthMethod, error) {
+	return GitAuth(ctx, args.client, args.secretName, args.secretNamespace)
+}
+
+// GitAuth returns credentials to access a git repository stored in a Secret
+// of "kubernetes.io/basic-auth" or "kubernetes.io/ssh-auth" type, no credentials are used if the secret name is empty.
+func GitAuth(ctx context.Context, client kubernetes.Interface, secretName, secretNamespace string) (transport.AuthMethod, error) {
+	if secretName == "" {
+		return nil, nil
+	}
+	secret, err := client.CoreV1().Secrets(secretNamespace).Get(ctx, secretName, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get git secret %q", secretName)
+	}
+	if key, ok := secret.Data[v1.SSHAuthPrivateKey]; ok {
+		auth, err := ssh.NewPublicKeys("git", key, "")
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid ssh key in git secret %q", secretName)
+		}
+		return auth, nil
+	}
+	password, ok := secret.Data[v1.BasicAuthPasswordKey]
+	if !ok {
+		return nil, fmt.Errorf("git secret %q must contain either %q or %q", secretName, v1.SSHAuthPrivateKey, v1.BasicAuthPasswordKey)
+	}
+	username := string(secret.Data[v1.BasicAuthUsernameKey])
+	if username == "" {
+		// git hosting services accept tokens with any non-empty username.
+		username = "git"
+	}
+	return &http.BasicAuth{Username: username, Password: string(password)}, nil
+}
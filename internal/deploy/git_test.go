@@ -0,0 +1,150 @@
+package deploy
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport/http"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_parseGitSource(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   *gitSource
+		wantOk bool
+	}{
+		{
+			name:   "https url with a ref",
+			source: "https://github.com/org/repo.git#v1.2.0",
+			want:   &gitSource{url: "https://github.com/org/repo.git", ref: "v1.2.0"},
+			wantOk: true,
+		},
+		{
+			name:   "ssh url without a ref",
+			source: "git@github.com:org/repo.git",
+			want:   &gitSource{url: "git@github.com:org/repo.git"},
+			wantOk: true,
+		},
+		{
+			name:   "local directory",
+			source: "./src",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseGitSource(tt.source)
+			require.Equal(t, tt.wantOk, ok)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_gitAuth(t *testing.T) {
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "git-credentials", Namespace: "ketch-app"},
+		Data:       map[string][]byte{v1.BasicAuthPasswordKey: []byte("token")},
+	}
+	tests := []struct {
+		name    string
+		args    CloneSourceRequest
+		want    interface{}
+		wantErr bool
+	}{
+		{
+			name: "no secret",
+			args: CloneSourceRequest{client: fake.NewSimpleClientset()},
+		},
+		{
+			name: "token without username",
+			args: CloneSourceRequest{secretName: "git-credentials", secretNamespace: "ketch-app", client: fake.NewSimpleClientset(secret)},
+			want: &http.BasicAuth{Username: "git", Password: "token"},
+		},
+		{
+			name:    "secret in another namespace",
+			args:    CloneSourceRequest{secretName: "git-credentials", secretNamespace: "default", client: fake.NewSimpleClientset(secret)},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := gitAuth(context.Background(), tt.args)
+			if tt.wantErr {
+				require.NotNil(t, err)
+				return
+			}
+			require.Nil(t, err)
+			if tt.want == nil {
+				require.Nil(t, got)
+				return
+			}
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCloneGitSource(t *testing.T) {
+	origin := t.TempDir()
+	repo, err := git.PlainInit(origin, false)
+	require.Nil(t, err)
+	worktree, err := repo.Worktree()
+	require.Nil(t, err)
+	commit := func(content string) string {
+		require.Nil(t, ioutil.WriteFile(path.Join(origin, "Procfile"), []byte(content), 0600))
+		_, err := worktree.Add("Procfile")
+		require.Nil(t, err)
+		hash, err := worktree.Commit(content, &git.CommitOptions{
+			Author: &object.Signature{Name: "ketch", Email: "ketch@theketch.io", When: time.Now()},
+		})
+		require.Nil(t, err)
+		return hash.String()
+	}
+	first := commit("web: ./first")
+	commit("web: ./second")
+
+	tests := []struct {
+		name        string
+		ref         string
+		wantProcess string
+		wantErr     bool
+	}{
+		{
+			name:        "default branch",
+			wantProcess: "web: ./second",
+		},
+		{
+			name:        "commit",
+			ref:         first,
+			wantProcess: "web: ./first",
+		},
+		{
+			name:    "unknown ref",
+			ref:     "no-such-branch",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := CloneGitSource(context.Background(), CloneSourceRequest{source: gitSource{url: origin, ref: tt.ref}})
+			defer os.RemoveAll(dir)
+			if tt.wantErr {
+				require.NotNil(t, err)
+				return
+			}
+			require.Nil(t, err)
+			content, err := ioutil.ReadFile(path.Join(dir, "Procfile"))
+			require.Nil(t, err)
+			require.Equal(t, tt.wantProcess, string(content))
+		})
+	}
+}
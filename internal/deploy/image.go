@@ -26,6 +26,40 @@ func GetImageConfig(ctx context.Context, args ImageConfigRequest) (*registryv1.C
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to parse reference for image %q", args.imageName)
 	}
+	options, err := remoteOptions(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	img, err := remote.Image(ref, options...)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get config for image %q", args.imageName)
+	}
+	return img.ConfigFile()
+}
+
+// GetImageDigest returns a reference to the image pinned to its digest, e.g. "shipa/app@sha256:...".
+// Credentials to pull the image are read from the secret in the namespace if a secret name is given.
+func GetImageDigest(ctx context.Context, kubeClient kubernetes.Interface, image, secretName, secretNamespace string) (string, error) {
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to parse reference for image %q", image)
+	}
+	if digest, ok := ref.(name.Digest); ok {
+		return digest.String(), nil
+	}
+	options, err := remoteOptions(ctx, ImageConfigRequest{imageName: image, secretName: secretName, secretNamespace: secretNamespace, client: kubeClient})
+	if err != nil {
+		return "", err
+	}
+	descriptor, err := remote.Head(ref, options...)
+	if err != nil {
+		return "", errors.Wrap(err, "could not get digest of image %q", image)
+	}
+	return ref.Context().Digest(descriptor.Digest.String()).String(), nil
+}
+
+// remoteOptions returns options to access the image's registry with credentials of the request's secret.
+func remoteOptions(ctx context.Context, args ImageConfigRequest) ([]remote.Option, error) {
 	var options []remote.Option
 	if args.secretName != "" {
 		keychainOpts := k8schain.Options{
@@ -38,9 +72,5 @@ func GetImageConfig(ctx context.Context, args ImageConfigRequest) (*registryv1.C
 		}
 		options = append(options, remote.WithAuthFromKeychain(keychain))
 	}
-	img, err := remote.Image(ref, options...)
-	if err != nil {
-		return nil, errors.Wrap(err, "could not get config for image %q", args.imageName)
-	}
-	return img.ConfigFile()
+	return options, nil
 }
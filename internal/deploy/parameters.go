@@ -7,15 +7,19 @@ import (
 	"os"
 	"path"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/spf13/pflag"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/client-go/kubernetes"
 	"sigs.k8s.io/yaml"
 
 	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/chart"
+	"github.com/theketchio/ketch/internal/templates"
 	"github.com/theketchio/ketch/internal/utils"
 )
 
@@ -27,19 +31,41 @@ const (
 	FlagStrict             = "strict"
 	FlagSteps              = "steps"
 	FlagStepInterval       = "step-interval"
+	FlagCanaryHeader       = "canary-header"
+	FlagCanaryCookie       = "canary-cookie"
+	FlagShadow             = "shadow"
 	FlagWait               = "wait"
+	FlagQueue              = "queue"
+	FlagQueueTimeout       = "queue-timeout"
 	FlagTimeout            = "timeout"
+	FlagDryRun             = "dry-run"
+	FlagDiff               = "diff"
 	FlagDescription        = "description"
+	FlagTeam               = "team"
+	FlagOwner              = "owner"
+	FlagShard              = "shard"
 	FlagEnvironment        = "env"
+	FlagEnvFile            = "env-file"
+	FlagEnvSet             = "env-set"
 	FlagNamespace          = "namespace"
+	FlagNamespaceStrategy  = "namespace-strategy"
+	FlagNamespaceQuota     = "namespace-quota"
 	FlagRegistrySecret     = "registry-secret"
+	FlagGitSecret          = "git-secret"
 	FlagBuilder            = "builder"
 	FlagBuildPacks         = "build-packs"
+	FlagCacheImage         = "cache-image"
 	FlagVolume             = "volume"
 	FlagVolumeMountPath    = "volume-mount-path"
 	FlagVolumeMountOptions = "volume-mount-options"
 	FlagFSGroup            = "fs-group"
 	FlagRunAsUser          = "run-as-user"
+	FlagForceHTTPS         = "force-https"
+	FlagNetworkPolicy      = "network-policy"
+	FlagAllowFrom          = "network-policy-allow-from"
+	FlagCmd                = "cmd"
+	FlagProcfile           = "procfile"
+	FlagCompose            = "compose"
 	FlagUnits              = "units"
 	FlagVersion            = "unit-version"
 	FlagProcess            = "unit-process"
@@ -55,6 +81,8 @@ const (
 
 var (
 	DefaultBuilder = "heroku/buildpacks:20"
+	// DefaultRegistrySecret is used to pull images of a new app if no registry secret is provided.
+	DefaultRegistrySecret string
 )
 
 // Services contains interfaces and function pointers to external services needed for deploy. The purpose of this
@@ -68,8 +96,12 @@ type Services struct {
 	Builder SourceBuilderFn
 	// Function that retrieve image config
 	GetImageConfig GetImageConfigFn
+	// Function that clones a git repository with source code of the app
+	CloneSource CloneSourceFn
 	// Wait is a function that will wait until it detects the a deployment is finished
 	Wait WaitFn
+	// Templates reads the app's chart templates to render the chart on a dry run
+	Templates templates.Reader
 	// Writer probably points to stdout or stderr, receives textual output
 	Writer io.Writer
 }
@@ -81,25 +113,47 @@ type Options struct {
 	AppName                 string
 	Image                   string
 	Namespace               string
+	NamespaceStrategy       string
+	NamespaceQuota          map[string]string
 	KetchYamlFileName       string
 	StrictKetchYamlDecoding bool
 	Steps                   int
 	StepTimeInterval        string
+	CanaryHeader            string
+	CanaryCookie            string
+	Shadow                  int
 	Wait                    bool
+	Queue                   bool
+	QueueTimeout            string
 	Timeout                 string
+	DryRun                  bool
+	Diff                    bool
 	AppSourcePath           string
 	SubPaths                []string
 
 	Description          string
+	Team                 string
+	Owner                string
+	Shard                string
 	Envs                 []string
+	EnvFile              string
+	EnvSets              []string
 	DockerRegistrySecret string
+	GitSecret            string
 	Builder              string
 	BuildPacks           []string
+	CacheImage           string
 	Volume               string
 	VolumeMountPath      string
 	VolumeMountOptions   map[string]string
 	FSGroup              int64
 	RunAsUser            int64
+	ForceHTTPS           bool
+	NetworkPolicy        bool
+	AllowFrom            []string
+	Cmds                 []string
+	ProcfileName         string
+	ComposeFile          string
 
 	Units   int
 	Version int
@@ -112,40 +166,83 @@ type ChangeSet struct {
 	sourcePath           *string
 	image                *string
 	namespace            *string
+	namespaceStrategy    *string
+	namespaceQuota       *map[string]string
 	ketchYamlFileName    *string
 	steps                *int
 	stepTimeInterval     *string
+	canaryHeader         *string
+	canaryCookie         *string
+	shadow               *int
 	wait                 *bool
+	queue                *bool
+	queueTimeout         *string
 	timeout              *string
+	dryRun               *bool
+	diff                 *bool
 	subPaths             *[]string
 	description          *string
+	team                 *string
+	owner                *string
+	shard                *string
 	envs                 *[]string
+	envFile              *string
+	envSets              *[]string
 	dockerRegistrySecret *string
+	gitSecret            *string
 	builder              *string
 	buildPacks           *[]string
+	cacheImage           *string
 	volume               *string
 	volumeMountPath      *string
 	volumeMountOptions   *map[string]string
 	fsGroup              *int64
 	runAsUser            *int64
+	forceHTTPS           *bool
+	networkPolicy        *bool
+	allowFrom            *[]string
+	cmds                 *[]string
+	procfileName         *string
+	composeFile          *string
 
 	appVersion    *string
 	appType       *string
 	processes     *[]ketchv1.ProcessSpec
 	ketchYamlData *ketchv1.KetchYamlData
+	procfile      *chart.Procfile
+	processImages map[string]string
+	processEnvs   map[string][]ketchv1.Env
 	cname         *ketchv1.CnameList
 	units         *int
 	version       *int
 	process       *string
+
+	// queueDeadline is when the deploy stops waiting for deployments in progress, it's set once the deploy starts waiting.
+	queueDeadline time.Time
 }
 
 func (o Options) GetChangeSet(flags *pflag.FlagSet) *ChangeSet {
+	return o.changeSet(flags.Changed)
+}
+
+// GetChangeSetOf returns a ChangeSet of the options named after their flags, e.g. FlagImage,
+// so code setting options without a flag set tells which options it sets.
+func (o Options) GetChangeSetOf(names ...string) *ChangeSet {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[name] = true
+	}
+	return o.changeSet(func(name string) bool { return set[name] })
+}
+
+func (o Options) changeSet(changed func(name string) bool) *ChangeSet {
 	var cs ChangeSet
 	cs.appName = o.AppName
 	cs.yamlStrictDecoding = o.StrictKetchYamlDecoding
 
 	// setting values for defaults we want to retain
 	cs.timeout = &o.Timeout
+	cs.queueTimeout = &o.QueueTimeout
 
 	if o.AppSourcePath != "" {
 		cs.sourcePath = &o.AppSourcePath
@@ -163,30 +260,75 @@ func (o Options) GetChangeSet(flags *pflag.FlagSet) *ChangeSet {
 		FlagStepInterval: func(c *ChangeSet) {
 			c.stepTimeInterval = &o.StepTimeInterval
 		},
+		FlagCanaryHeader: func(c *ChangeSet) {
+			c.canaryHeader = &o.CanaryHeader
+		},
+		FlagCanaryCookie: func(c *ChangeSet) {
+			c.canaryCookie = &o.CanaryCookie
+		},
+		FlagShadow: func(c *ChangeSet) {
+			c.shadow = &o.Shadow
+		},
 		FlagWait: func(c *ChangeSet) {
 			c.wait = &o.Wait
 		},
+		FlagQueue: func(c *ChangeSet) {
+			c.queue = &o.Queue
+		},
 		FlagTimeout: func(c *ChangeSet) {
 			c.timeout = &o.Timeout
 		},
+		FlagDryRun: func(c *ChangeSet) {
+			c.dryRun = &o.DryRun
+		},
+		FlagDiff: func(c *ChangeSet) {
+			c.diff = &o.Diff
+		},
 		FlagDescription: func(c *ChangeSet) {
 			c.description = &o.Description
 		},
+		FlagTeam: func(c *ChangeSet) {
+			c.team = &o.Team
+		},
+		FlagOwner: func(c *ChangeSet) {
+			c.owner = &o.Owner
+		},
+		FlagShard: func(c *ChangeSet) {
+			c.shard = &o.Shard
+		},
 		FlagNamespace: func(c *ChangeSet) {
 			c.namespace = &o.Namespace
 		},
+		FlagNamespaceStrategy: func(c *ChangeSet) {
+			c.namespaceStrategy = &o.NamespaceStrategy
+		},
+		FlagNamespaceQuota: func(c *ChangeSet) {
+			c.namespaceQuota = &o.NamespaceQuota
+		},
 		FlagEnvironment: func(c *ChangeSet) {
 			c.envs = &o.Envs
 		},
+		FlagEnvFile: func(c *ChangeSet) {
+			c.envFile = &o.EnvFile
+		},
+		FlagEnvSet: func(c *ChangeSet) {
+			c.envSets = &o.EnvSets
+		},
 		FlagRegistrySecret: func(c *ChangeSet) {
 			c.dockerRegistrySecret = &o.DockerRegistrySecret
 		},
+		FlagGitSecret: func(c *ChangeSet) {
+			c.gitSecret = &o.GitSecret
+		},
 		FlagBuilder: func(c *ChangeSet) {
 			c.builder = &o.Builder
 		},
 		FlagBuildPacks: func(c *ChangeSet) {
 			c.buildPacks = &o.BuildPacks
 		},
+		FlagCacheImage: func(c *ChangeSet) {
+			c.cacheImage = &o.CacheImage
+		},
 		FlagVolume: func(c *ChangeSet) {
 			c.volume = &o.Volume
 		},
@@ -202,6 +344,24 @@ func (o Options) GetChangeSet(flags *pflag.FlagSet) *ChangeSet {
 		FlagRunAsUser: func(c *ChangeSet) {
 			c.runAsUser = &o.RunAsUser
 		},
+		FlagForceHTTPS: func(c *ChangeSet) {
+			c.forceHTTPS = &o.ForceHTTPS
+		},
+		FlagNetworkPolicy: func(c *ChangeSet) {
+			c.networkPolicy = &o.NetworkPolicy
+		},
+		FlagAllowFrom: func(c *ChangeSet) {
+			c.allowFrom = &o.AllowFrom
+		},
+		FlagCmd: func(c *ChangeSet) {
+			c.cmds = &o.Cmds
+		},
+		FlagProcfile: func(c *ChangeSet) {
+			c.procfileName = &o.ProcfileName
+		},
+		FlagCompose: func(c *ChangeSet) {
+			c.composeFile = &o.ComposeFile
+		},
 		FlagUnits: func(c *ChangeSet) {
 			c.units = &o.Units
 		},
@@ -213,13 +373,18 @@ func (o Options) GetChangeSet(flags *pflag.FlagSet) *ChangeSet {
 		},
 	}
 	for k, f := range m {
-		if flags.Changed(k) {
+		if changed(k) {
 			f(&cs)
 		}
 	}
 	return &cs
 }
 
+// AppName returns the name of the app the change set is applied to.
+func (c *ChangeSet) AppName() string {
+	return c.appName
+}
+
 func (c *ChangeSet) getDescription() (string, error) {
 	if c.description == nil {
 		return "", newMissingError(FlagDescription)
@@ -227,6 +392,37 @@ func (c *ChangeSet) getDescription() (string, error) {
 	return *c.description, nil
 }
 
+func (c *ChangeSet) getTeam() (string, error) {
+	if c.team == nil {
+		return "", newMissingError(FlagTeam)
+	}
+	return *c.team, nil
+}
+
+func (c *ChangeSet) getEnvSets() ([]string, error) {
+	if c.envSets == nil {
+		return nil, newMissingError(FlagEnvSet)
+	}
+	return *c.envSets, nil
+}
+
+func (c *ChangeSet) getOwner() (string, error) {
+	if c.owner == nil {
+		return "", newMissingError(FlagOwner)
+	}
+	return *c.owner, nil
+}
+
+func (c *ChangeSet) getShard() (string, error) {
+	if c.shard == nil {
+		return "", newMissingError(FlagShard)
+	}
+	if errs := validation.IsValidLabelValue(*c.shard); len(errs) > 0 {
+		return "", fmt.Errorf("%w %s: %q is not a valid label value", newInvalidValueError(FlagShard), FlagShard, *c.shard)
+	}
+	return *c.shard, nil
+}
+
 func (c *ChangeSet) getYamlPath() (string, error) {
 	if c.ketchYamlFileName == nil {
 		return "", newMissingError(FlagKetchYaml)
@@ -241,6 +437,20 @@ func (c *ChangeSet) getYamlPath() (string, error) {
 	return *c.ketchYamlFileName, nil
 }
 
+func (c *ChangeSet) getGitSource() (*gitSource, bool) {
+	if c.sourcePath == nil {
+		return nil, false
+	}
+	return parseGitSource(*c.sourcePath)
+}
+
+func (c *ChangeSet) getGitSecret() (string, error) {
+	if c.gitSecret == nil {
+		return "", newMissingError(FlagGitSecret)
+	}
+	return *c.gitSecret, nil
+}
+
 func (c *ChangeSet) getSourceDirectory() (string, error) {
 	if c.sourcePath == nil {
 		return "", newMissingError("source directory")
@@ -265,6 +475,34 @@ func (c *ChangeSet) getNamespace() (string, error) {
 	return *c.namespace, nil
 }
 
+func (c *ChangeSet) getNamespaceStrategy() (ketchv1.NamespaceStrategy, error) {
+	if c.namespaceStrategy == nil {
+		return "", newMissingError(FlagNamespaceStrategy)
+	}
+	strategy := ketchv1.NamespaceStrategy(*c.namespaceStrategy)
+	if strategy != ketchv1.SharedNamespace && strategy != ketchv1.PerAppNamespace {
+		return "", fmt.Errorf("%w %s must be either %s or %s",
+			newInvalidValueError(FlagNamespaceStrategy), FlagNamespaceStrategy, ketchv1.SharedNamespace, ketchv1.PerAppNamespace)
+	}
+	return strategy, nil
+}
+
+func (c *ChangeSet) getNamespaceQuota() (v1.ResourceList, error) {
+	if c.namespaceQuota == nil {
+		return nil, newMissingError(FlagNamespaceQuota)
+	}
+	quota := make(v1.ResourceList, len(*c.namespaceQuota))
+	for name, value := range *c.namespaceQuota {
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w %s: invalid quantity %q of %s",
+				newInvalidValueError(FlagNamespaceQuota), FlagNamespaceQuota, value, name)
+		}
+		quota[v1.ResourceName(name)] = quantity
+	}
+	return quota, nil
+}
+
 func (c *ChangeSet) getSteps() (int, error) {
 	if c.steps == nil {
 		return 0, newMissingError(FlagSteps)
@@ -278,6 +516,17 @@ func (c *ChangeSet) getSteps() (int, error) {
 	return *c.steps, nil
 }
 
+// getShadow returns the percentage of requests mirrored to a shadow deployment.
+func (c *ChangeSet) getShadow() (uint8, error) {
+	if c.shadow == nil {
+		return 0, newMissingError(FlagShadow)
+	}
+	if *c.shadow < 1 || *c.shadow > 100 {
+		return 0, fmt.Errorf("%w %s must be between 1 and 100", newInvalidValueError(FlagShadow), FlagShadow)
+	}
+	return uint8(*c.shadow), nil
+}
+
 func (c *ChangeSet) getStepInterval() (time.Duration, error) {
 	if c.stepTimeInterval == nil {
 		return 0, newMissingError(FlagStepInterval)
@@ -289,6 +538,28 @@ func (c *ChangeSet) getStepInterval() (time.Duration, error) {
 	return dur, nil
 }
 
+// getCanaryRouting returns rules of requests routed to a canary deployment regardless of its weight.
+func (c *ChangeSet) getCanaryRouting() (*ketchv1.CanaryRouting, error) {
+	if c.canaryHeader == nil && c.canaryCookie == nil {
+		return nil, newMissingError(FlagCanaryHeader)
+	}
+	routing := &ketchv1.CanaryRouting{}
+	if c.canaryHeader != nil {
+		name, value, err := ketchv1.ParseCanaryHeader(*c.canaryHeader)
+		if err != nil {
+			return nil, fmt.Errorf("%w %s", newInvalidValueError(FlagCanaryHeader), err)
+		}
+		routing.Header, routing.HeaderValue = name, value
+	}
+	if c.canaryCookie != nil {
+		if err := ketchv1.ValidateCanaryCookie(*c.canaryCookie); err != nil {
+			return nil, fmt.Errorf("%w %s", newInvalidValueError(FlagCanaryCookie), err)
+		}
+		routing.Cookie = *c.canaryCookie
+	}
+	return routing, nil
+}
+
 func (c *ChangeSet) getStepWeight() (uint8, error) {
 	steps, err := c.getSteps()
 	if err != nil {
@@ -297,13 +568,26 @@ func (c *ChangeSet) getStepWeight() (uint8, error) {
 	return uint8(100 / steps), nil
 }
 
+// getEnvironments returns variables read from the env file followed by the ones passed with the env flag,
+// so a variable of the env flag overrides the one with the same name of the file.
 func (c *ChangeSet) getEnvironments() ([]ketchv1.Env, error) {
-	if c.envs == nil {
+	if c.envs == nil && c.envFile == nil {
 		return nil, newMissingError(FlagEnvironment)
 	}
-	envs, err := utils.MakeEnvironments(*c.envs)
-	if err != nil {
-		return nil, newInvalidValueError(FlagEnvironment)
+	var envs []ketchv1.Env
+	if c.envFile != nil {
+		fileEnvs, err := utils.ReadEnvFile(*c.envFile)
+		if err != nil {
+			return nil, fmt.Errorf("%w %v", newInvalidValueError(FlagEnvFile), err)
+		}
+		envs = append(envs, fileEnvs...)
+	}
+	if c.envs != nil {
+		flagEnvs, err := utils.MakeEnvironments(*c.envs)
+		if err != nil {
+			return nil, fmt.Errorf("%w %v", newInvalidValueError(FlagEnvironment), err)
+		}
+		envs = append(envs, flagEnvs...)
 	}
 	return envs, nil
 }
@@ -315,6 +599,52 @@ func (c *ChangeSet) getWait() (bool, error) {
 	return *c.wait, nil
 }
 
+func (c *ChangeSet) getQueue() (bool, error) {
+	if c.queue == nil {
+		return false, newMissingError(FlagQueue)
+	}
+	return *c.queue, nil
+}
+
+func (c *ChangeSet) getQueueTimeout() (time.Duration, error) {
+	if c.queueTimeout == nil {
+		return 0, newMissingError(FlagQueueTimeout)
+	}
+	d, err := time.ParseDuration(*c.queueTimeout)
+	if err != nil {
+		return 0, newInvalidValueError(FlagQueueTimeout)
+	}
+	return d, nil
+}
+
+// getQueueDeadline returns when a deploy with --queue gives up, --queue-timeout after the deploy started waiting,
+// so waiting for several deployments in a row doesn't extend it.
+func (c *ChangeSet) getQueueDeadline(now time.Time) (time.Time, error) {
+	if !c.queueDeadline.IsZero() {
+		return c.queueDeadline, nil
+	}
+	timeout, err := c.getQueueTimeout()
+	if err != nil {
+		return time.Time{}, err
+	}
+	c.queueDeadline = now.Add(timeout)
+	return c.queueDeadline, nil
+}
+
+func (c *ChangeSet) getDryRun() (bool, error) {
+	if c.dryRun == nil {
+		return false, newMissingError(FlagDryRun)
+	}
+	return *c.dryRun, nil
+}
+
+func (c *ChangeSet) getDiff() (bool, error) {
+	if c.diff == nil {
+		return false, newMissingError(FlagDiff)
+	}
+	return *c.diff, nil
+}
+
 func (c *ChangeSet) getTimeout() (time.Duration, error) {
 	if c.timeout == nil {
 		return 0, newMissingError(FlagTimeout)
@@ -473,6 +803,88 @@ func (c *ChangeSet) getRunAsUser() (int64, error) {
 	return *c.runAsUser, nil
 }
 
+func (c *ChangeSet) getForceHTTPS() (bool, error) {
+	if c.forceHTTPS == nil {
+		return false, newMissingError(FlagForceHTTPS)
+	}
+	return *c.forceHTTPS, nil
+}
+
+func (c *ChangeSet) getNetworkPolicy() (bool, error) {
+	if c.networkPolicy == nil {
+		return false, newMissingError(FlagNetworkPolicy)
+	}
+	return *c.networkPolicy, nil
+}
+
+func (c *ChangeSet) getAllowFrom() ([]string, error) {
+	if c.allowFrom == nil {
+		return nil, newMissingError(FlagAllowFrom)
+	}
+	for _, namespace := range *c.allowFrom {
+		if errs := validation.IsDNS1123Label(namespace); len(errs) > 0 {
+			return nil, fmt.Errorf("%w %s: %q is not a valid namespace name",
+				newInvalidValueError(FlagAllowFrom), FlagAllowFrom, namespace)
+		}
+	}
+	return *c.allowFrom, nil
+}
+
+// getCmds returns commands that override commands of the image's processes by process name.
+// A command runs in a shell, so it can refer to env variables like $PORT.
+func (c *ChangeSet) getCmds() (map[string][]string, error) {
+	if c.cmds == nil {
+		return nil, newMissingError(FlagCmd)
+	}
+	cmds := make(map[string][]string, len(*c.cmds))
+	for _, value := range *c.cmds {
+		parts := strings.SplitN(value, "=", 2)
+		if len(parts) != 2 || len(parts[0]) == 0 || len(strings.TrimSpace(parts[1])) == 0 {
+			return nil, fmt.Errorf("%w %s must be in the form process=command",
+				newInvalidValueError(FlagCmd), FlagCmd)
+		}
+		if _, ok := cmds[parts[0]]; ok {
+			return nil, fmt.Errorf("%w %s: process %q has more than one command",
+				newInvalidValueError(FlagCmd), FlagCmd, parts[0])
+		}
+		cmds[parts[0]] = []string{"/bin/sh", "-c", parts[1]}
+	}
+	return cmds, nil
+}
+
+// getProcfile returns the processes of the docker-compose file or the parsed Procfile, they replace the processes of the image.
+func (c *ChangeSet) getProcfile() (*chart.Procfile, error) {
+	if c.procfile != nil {
+		return c.procfile, nil
+	}
+	if c.procfileName == nil {
+		return nil, newMissingError(FlagProcfile)
+	}
+	content, err := ioutil.ReadFile(*c.procfileName)
+	if err != nil {
+		return nil, fmt.Errorf("%w %s: %v", newInvalidValueError(FlagProcfile), FlagProcfile, err)
+	}
+	procfile, err := chart.ParseProcfile(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("%w %s: %v", newInvalidValueError(FlagProcfile), FlagProcfile, err)
+	}
+	return procfile, nil
+}
+
+func (c *ChangeSet) getComposeFile() (string, error) {
+	if c.composeFile == nil {
+		return "", newMissingError(FlagCompose)
+	}
+	stat, err := os.Stat(*c.composeFile)
+	if err != nil {
+		return "", fmt.Errorf("%w %s: %v", newInvalidValueError(FlagCompose), FlagCompose, err)
+	}
+	if stat.IsDir() {
+		return "", fmt.Errorf("%w %s is not a regular file", newInvalidValueError(FlagCompose), *c.composeFile)
+	}
+	return *c.composeFile, nil
+}
+
 func (c *ChangeSet) getBuildPacks() ([]string, error) {
 	if c.buildPacks == nil {
 		return nil, newMissingError(FlagBuildPacks)
@@ -480,6 +892,13 @@ func (c *ChangeSet) getBuildPacks() ([]string, error) {
 	return *c.buildPacks, nil
 }
 
+func (c *ChangeSet) getCacheImage() (string, error) {
+	if c.cacheImage == nil {
+		return "", newMissingError(FlagCacheImage)
+	}
+	return *c.cacheImage, nil
+}
+
 func (c *ChangeSet) getKetchYaml() (*ketchv1.KetchYamlData, error) {
 	if c.ketchYamlData != nil {
 		return c.ketchYamlData, nil
@@ -526,7 +945,7 @@ func (cs *ChangeSet) hasSecureCnames() bool {
 		return false
 	}
 	for _, cname := range *cs.cname {
-		if cname.Secure {
+		if cname.NeedsClusterIssuer() {
 			return true
 		}
 	}
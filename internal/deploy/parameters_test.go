@@ -1,12 +1,18 @@
 package deploy
 
 import (
+	"io/ioutil"
+	"path"
 	"reflect"
 	"strconv"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/chart"
 )
 
 func intRef(i int) *int {
@@ -310,3 +316,318 @@ func TestChangeSet_getRunAsUser(t *testing.T) {
 		})
 	}
 }
+
+func TestChangeSet_getCmds(t *testing.T) {
+	tests := []struct {
+		name    string
+		cmds    *[]string
+		want    map[string][]string
+		wantErr string
+	}{
+		{
+			name:    "no cmd set",
+			wantErr: `"cmd" missing`,
+		},
+		{
+			name: "commands by process",
+			cmds: &[]string{"web=bundle exec puma -p $PORT", "worker=sidekiq -C config/sidekiq.yml"},
+			want: map[string][]string{
+				"web":    {"/bin/sh", "-c", "bundle exec puma -p $PORT"},
+				"worker": {"/bin/sh", "-c", "sidekiq -C config/sidekiq.yml"},
+			},
+		},
+		{
+			name:    "missing command",
+			cmds:    &[]string{"web="},
+			wantErr: `"cmd" invalid value cmd must be in the form process=command`,
+		},
+		{
+			name:    "two commands of a process",
+			cmds:    &[]string{"web=puma", "web=rackup"},
+			wantErr: `"cmd" invalid value cmd: process "web" has more than one command`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set := ChangeSet{cmds: tt.cmds}
+			cmds, err := set.getCmds()
+			if len(tt.wantErr) > 0 {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.want, cmds)
+		})
+	}
+}
+
+func TestChangeSet_getProcfile(t *testing.T) {
+	dir := t.TempDir()
+	procfile := path.Join(dir, "Procfile")
+	require.Nil(t, ioutil.WriteFile(procfile, []byte("release: rake db:migrate\nweb: bundle exec puma -p $PORT\n"), 0600))
+	invalidProcfile := path.Join(dir, "Procfile.invalid")
+	require.Nil(t, ioutil.WriteFile(invalidProcfile, []byte("web bundle exec puma\n"), 0600))
+
+	tests := []struct {
+		name    string
+		set     ChangeSet
+		want    *chart.Procfile
+		wantErr string
+	}{
+		{
+			name:    "no procfile set",
+			wantErr: `"procfile" missing`,
+		},
+		{
+			name: "procfile with a release process",
+			set:  ChangeSet{procfileName: stringRef(procfile)},
+			want: &chart.Procfile{
+				Processes:           map[string][]string{"web": {"/bin/sh", "-c", "bundle exec puma -p $PORT"}},
+				RoutableProcessName: "web",
+				ReleaseCmd:          []string{"rake", "db:migrate"},
+			},
+		},
+		{
+			name:    "missing procfile",
+			set:     ChangeSet{procfileName: stringRef(path.Join(dir, "Procfile.missing"))},
+			wantErr: `"procfile" invalid value`,
+		},
+		{
+			name:    "invalid procfile",
+			set:     ChangeSet{procfileName: stringRef(invalidProcfile)},
+			wantErr: `"procfile" invalid value procfile: procfile line 1: expected "<process name>: <command>"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.set.getProcfile()
+			if len(tt.wantErr) > 0 {
+				require.NotNil(t, err)
+				require.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestChangeSet_getNamespaceStrategy(t *testing.T) {
+	tests := []struct {
+		name    string
+		set     ChangeSet
+		want    ketchv1.NamespaceStrategy
+		wantErr string
+	}{
+		{
+			name:    "no namespace-strategy set",
+			set:     ChangeSet{},
+			wantErr: `"namespace-strategy" missing`,
+		},
+		{
+			name: "per app",
+			set:  ChangeSet{namespaceStrategy: stringRef("perApp")},
+			want: ketchv1.PerAppNamespace,
+		},
+		{
+			name:    "unknown strategy",
+			set:     ChangeSet{namespaceStrategy: stringRef("perTeam")},
+			wantErr: `"namespace-strategy" invalid value namespace-strategy must be either shared or perApp`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			strategy, err := tt.set.getNamespaceStrategy()
+			if len(tt.wantErr) > 0 {
+				require.NotNil(t, err)
+				require.Equal(t, tt.wantErr, err.Error())
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.want, strategy)
+		})
+	}
+}
+
+func TestChangeSet_getNamespaceQuota(t *testing.T) {
+	tests := []struct {
+		name    string
+		set     ChangeSet
+		want    v1.ResourceList
+		wantErr string
+	}{
+		{
+			name:    "no namespace-quota set",
+			set:     ChangeSet{},
+			wantErr: `"namespace-quota" missing`,
+		},
+		{
+			name: "quota",
+			set:  ChangeSet{namespaceQuota: &map[string]string{"requests.memory": "4Gi", "pods": "20"}},
+			want: v1.ResourceList{
+				"requests.memory": resource.MustParse("4Gi"),
+				"pods":            resource.MustParse("20"),
+			},
+		},
+		{
+			name:    "invalid quantity",
+			set:     ChangeSet{namespaceQuota: &map[string]string{"pods": "many"}},
+			wantErr: `"namespace-quota" invalid value namespace-quota: invalid quantity "many" of pods`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quota, err := tt.set.getNamespaceQuota()
+			if len(tt.wantErr) > 0 {
+				require.NotNil(t, err)
+				require.Equal(t, tt.wantErr, err.Error())
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.want, quota)
+		})
+	}
+}
+
+func TestChangeSet_getAllowFrom(t *testing.T) {
+	tests := []struct {
+		name    string
+		set     ChangeSet
+		want    []string
+		wantErr string
+	}{
+		{
+			name:    "no network-policy-allow-from set",
+			set:     ChangeSet{},
+			wantErr: `"network-policy-allow-from" missing`,
+		},
+		{
+			name: "namespaces",
+			set:  ChangeSet{allowFrom: &[]string{"monitoring", "ketch-api"}},
+			want: []string{"monitoring", "ketch-api"},
+		},
+		{
+			name:    "invalid namespace",
+			set:     ChangeSet{allowFrom: &[]string{"Monitoring"}},
+			wantErr: `"network-policy-allow-from" invalid value network-policy-allow-from: "Monitoring" is not a valid namespace name`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			namespaces, err := tt.set.getAllowFrom()
+			if len(tt.wantErr) > 0 {
+				require.NotNil(t, err)
+				require.Equal(t, tt.wantErr, err.Error())
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.want, namespaces)
+		})
+	}
+}
+
+func TestChangeSet_getShard(t *testing.T) {
+	tests := []struct {
+		name    string
+		set     ChangeSet
+		want    string
+		wantErr string
+	}{
+		{
+			name:    "no shard set",
+			set:     ChangeSet{},
+			wantErr: `"shard" missing`,
+		},
+		{
+			name: "shard",
+			set:  ChangeSet{shard: stringRef("payments")},
+			want: "payments",
+		},
+		{
+			name: "empty shard",
+			set:  ChangeSet{shard: stringRef("")},
+			want: "",
+		},
+		{
+			name:    "invalid shard",
+			set:     ChangeSet{shard: stringRef("payments/eu")},
+			wantErr: `"shard" invalid value shard: "payments/eu" is not a valid label value`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shard, err := tt.set.getShard()
+			if len(tt.wantErr) > 0 {
+				require.NotNil(t, err)
+				require.Equal(t, tt.wantErr, err.Error())
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.want, shard)
+		})
+	}
+}
+
+func TestChangeSet_getEnvironments(t *testing.T) {
+	envFile := path.Join(t.TempDir(), ".env")
+	require.Nil(t, ioutil.WriteFile(envFile, []byte("# database\nDB_HOST=db.local\nDEBUG=false\n"), 0600))
+
+	tests := []struct {
+		name    string
+		set     ChangeSet
+		want    []ketchv1.Env
+		wantErr string
+	}{
+		{
+			name:    "no env or env-file set",
+			set:     ChangeSet{},
+			wantErr: `"env" missing`,
+		},
+		{
+			name: "env-file only",
+			set:  ChangeSet{envFile: stringRef(envFile)},
+			want: []ketchv1.Env{{Name: "DB_HOST", Value: "db.local"}, {Name: "DEBUG", Value: "false"}},
+		},
+		{
+			name: "env follows env-file",
+			set:  ChangeSet{envFile: stringRef(envFile), envs: &[]string{"DEBUG=true"}},
+			want: []ketchv1.Env{{Name: "DB_HOST", Value: "db.local"}, {Name: "DEBUG", Value: "false"}, {Name: "DEBUG", Value: "true"}},
+		},
+		{
+			name:    "missing env-file",
+			set:     ChangeSet{envFile: stringRef(path.Join(t.TempDir(), ".env"))},
+			wantErr: `"env-file" invalid value`,
+		},
+		{
+			name:    "invalid name",
+			set:     ChangeSet{envs: &[]string{"DB HOST=db.local"}},
+			wantErr: `"env" invalid value`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			envs, err := tt.set.getEnvironments()
+			if len(tt.wantErr) > 0 {
+				require.NotNil(t, err)
+				require.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+
+			require.Nil(t, err)
+			require.Equal(t, tt.want, envs)
+		})
+	}
+}
+
+func TestOptions_GetChangeSetOf(t *testing.T) {
+	options := Options{AppName: "dashboard", Image: "shipa/dashboard:0.2", Description: "not set", Timeout: "1m"}
+	cs := options.GetChangeSetOf(FlagImage)
+	require.Equal(t, "dashboard", cs.appName)
+	image, err := cs.getImage()
+	require.Nil(t, err)
+	require.Equal(t, "shipa/dashboard:0.2", image)
+	_, err = cs.getDescription()
+	require.True(t, isMissing(err))
+	require.Equal(t, "1m", *cs.timeout)
+}
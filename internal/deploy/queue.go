@@ -0,0 +1,84 @@
+package deploy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	kerrs "github.com/theketchio/ketch/internal/errors"
+	"github.com/theketchio/ketch/internal/utils"
+)
+
+// ErrDeployInProgress is returned when another deployment of the app is rolling out or is made while the app is deployed.
+var ErrDeployInProgress = errors.New("another deployment of the app is in progress")
+
+// queuePollInterval is the time between two checks of the deployment a deploy with --queue waits for.
+var queuePollInterval = 2 * time.Second
+
+// checkDeployInProgress fails if the latest deployment of the app is rolling out.
+func checkDeployInProgress(app *ketchv1.App) error {
+	if message, ok := app.DeployInProgress(); ok {
+		return fmt.Errorf("%w: %s, use --%s to wait for it", ErrDeployInProgress, message, FlagQueue)
+	}
+	return nil
+}
+
+// checkDeployedConcurrently fails if the app got a new deployment since the deploy read deploymentsCount of the app,
+// so two deploys racing each other don't overwrite one another.
+func checkDeployedConcurrently(app *ketchv1.App, deploymentsCount int) error {
+	if app.Spec.DeploymentsCount == deploymentsCount {
+		return nil
+	}
+	deployedBy := app.Annotations[utils.KetchDeployedByAnnotation]
+	if deployedBy == "" {
+		deployedBy = "someone"
+	}
+	return fmt.Errorf("%w: %s deployed version %d of the app meanwhile, use --%s to deploy on top of it",
+		ErrDeployInProgress, deployedBy, app.Spec.DeploymentsCount, FlagQueue)
+}
+
+func isDeployInProgress(err error) bool {
+	return errors.Is(err, ErrDeployInProgress)
+}
+
+// waitForDeployInProgress blocks until the latest deployment of the app stops being in progress and returns the app.
+// It gives up at the deadline of the deploy, --queue-timeout after the deploy started waiting.
+func waitForDeployInProgress(ctx context.Context, svc *Services, cs *ChangeSet) (*ketchv1.App, error) {
+	timeout, err := cs.getQueueTimeout()
+	if err != nil {
+		return nil, err
+	}
+	deadline, err := cs.getQueueDeadline(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	appName := cs.appName
+	reported := ""
+	for {
+		var app ketchv1.App
+		if err := svc.Client.Get(ctx, types.NamespacedName{Name: appName}, &app); err != nil {
+			return nil, kerrs.Wrap(err, "could not get app %q", appName)
+		}
+		message, ok := app.DeployInProgress()
+		if !ok {
+			return &app, nil
+		}
+		if message != reported && svc.Writer != nil {
+			fmt.Fprintf(svc.Writer, "Waiting for the deployment in progress: %s\n", message)
+			reported = message
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timer.C:
+			return nil, fmt.Errorf("%w: %s, gave up waiting for it after %s", ErrDeployInProgress, message, timeout)
+		case <-time.After(queuePollInterval):
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package deploy
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/utils"
+)
+
+func deployingApp(message string) *ketchv1.App {
+	return &ketchv1.App{
+		Status: ketchv1.AppStatus{
+			Conditions: []ketchv1.Condition{{Type: ketchv1.Deploying, Status: corev1.ConditionTrue, Message: message}},
+		},
+	}
+}
+
+func Test_checkDeployInProgress(t *testing.T) {
+	require.Nil(t, checkDeployInProgress(&ketchv1.App{}))
+
+	err := checkDeployInProgress(deployingApp("version 2 is rolling out"))
+	require.True(t, isDeployInProgress(err))
+	require.EqualError(t, err, "another deployment of the app is in progress: version 2 is rolling out, use --queue to wait for it")
+}
+
+func Test_checkDeployedConcurrently(t *testing.T) {
+	app := &ketchv1.App{Spec: ketchv1.AppSpec{DeploymentsCount: 3}}
+	require.Nil(t, checkDeployedConcurrently(app, 3))
+
+	err := checkDeployedConcurrently(app, 2)
+	require.True(t, isDeployInProgress(err))
+	require.EqualError(t, err, "another deployment of the app is in progress: someone deployed version 3 of the app meanwhile, use --queue to deploy on top of it")
+
+	app.Annotations = map[string]string{utils.KetchDeployedByAnnotation: "alice"}
+	require.EqualError(t, checkDeployedConcurrently(app, 2), "another deployment of the app is in progress: alice deployed version 3 of the app meanwhile, use --queue to deploy on top of it")
+}
+
+func Test_waitForDeployInProgress(t *testing.T) {
+	defer func(interval time.Duration) { queuePollInterval = interval }(queuePollInterval)
+	queuePollInterval = time.Millisecond
+
+	m := newMockClient()
+	rollingOut := func(m *mockClient, obj runtime.Object) error {
+		*obj.(*ketchv1.App) = *deployingApp("version 2 is rolling out")
+		return nil
+	}
+	m.get[1] = rollingOut
+	m.get[2] = rollingOut
+	out := &bytes.Buffer{}
+	queueTimeout := "1m"
+	cs := &ChangeSet{appName: "test-app", queueTimeout: &queueTimeout}
+	app, err := waitForDeployInProgress(context.Background(), &Services{Client: m, Writer: out}, cs)
+	require.Nil(t, err)
+	require.Equal(t, m.app, app)
+	require.Equal(t, 3, m.getCounter)
+	require.Equal(t, "Waiting for the deployment in progress: version 2 is rolling out\n", out.String())
+
+	m = newMockClient()
+	m.get[1] = rollingOut
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = waitForDeployInProgress(ctx, &Services{Client: m}, cs)
+	require.Equal(t, context.Canceled, err)
+
+	m = newMockClient()
+	for i := 1; i <= 1000; i++ {
+		m.get[i] = rollingOut
+	}
+	queueTimeout = "10ms"
+	cs = &ChangeSet{appName: "test-app", queueTimeout: &queueTimeout}
+	_, err = waitForDeployInProgress(context.Background(), &Services{Client: m}, cs)
+	require.EqualError(t, err, "another deployment of the app is in progress: version 2 is rolling out, gave up waiting for it after 10ms")
+
+	// the deadline is shared by all waits of a deploy.
+	m = newMockClient()
+	m.get[1] = rollingOut
+	_, err = waitForDeployInProgress(context.Background(), &Services{Client: m}, cs)
+	require.EqualError(t, err, "another deployment of the app is in progress: version 2 is rolling out, gave up waiting for it after 10ms")
+	require.Equal(t, 1, m.getCounter)
+}
+
+func TestChangeSet_getQueueDeadline(t *testing.T) {
+	queueTimeout := "15m"
+	cs := &ChangeSet{queueTimeout: &queueTimeout}
+	now := time.Now()
+	deadline, err := cs.getQueueDeadline(now)
+	require.Nil(t, err)
+	require.Equal(t, now.Add(15*time.Minute), deadline)
+	deadline, err = cs.getQueueDeadline(now.Add(time.Hour))
+	require.Nil(t, err)
+	require.Equal(t, now.Add(15*time.Minute), deadline)
+}
@@ -4,11 +4,18 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path"
 
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
 	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/chart"
 	kerrs "github.com/theketchio/ketch/internal/errors"
+	"github.com/theketchio/ketch/internal/utils"
 	"github.com/theketchio/ketch/internal/validation"
 )
 
@@ -76,6 +83,15 @@ func isValid(err error) bool {
 	return true
 }
 
+// primaryDeployments returns the number of the app's deployments that aren't shadow deployments.
+func primaryDeployments(app *ketchv1.App) int {
+	deps := len(app.Spec.Deployments)
+	if app.Spec.ShadowDeployment() != nil {
+		deps--
+	}
+	return deps
+}
+
 func validateDeploy(cs *ChangeSet, app *ketchv1.App) error {
 	if _, err := cs.getImage(); err != nil {
 		return err
@@ -86,7 +102,7 @@ func validateDeploy(cs *ChangeSet, app *ketchv1.App) error {
 		if !isValid(err) {
 			return err
 		}
-		switch deps := len(app.Spec.Deployments); {
+		switch deps := primaryDeployments(app); {
 		case deps == 0:
 			return fmt.Errorf("canary deployment failed. No primary deployment found for the app")
 		case deps >= 2:
@@ -97,6 +113,35 @@ func validateDeploy(cs *ChangeSet, app *ketchv1.App) error {
 		}
 	}
 
+	_, err = cs.getCanaryRouting()
+	if !isMissing(err) {
+		if !isValid(err) {
+			return err
+		}
+		if cs.steps == nil {
+			return fmt.Errorf("%w %s and %s must be used with %s flag",
+				newInvalidUsageError(FlagCanaryHeader), FlagCanaryHeader, FlagCanaryCookie, FlagSteps)
+		}
+	}
+
+	_, err = cs.getShadow()
+	if !isMissing(err) {
+		if !isValid(err) {
+			return err
+		}
+		if cs.steps != nil {
+			return fmt.Errorf("%w %s can't be used with %s", newInvalidUsageError(FlagShadow), FlagShadow, FlagSteps)
+		}
+		if primaryDeployments(app) == 0 || app.Spec.Canary.Active {
+			return fmt.Errorf("shadow deployment failed. The app must have a single deployment serving its traffic")
+		}
+		switch app.Spec.Ingress.Controller.IngressType {
+		case ketchv1.IstioIngressControllerType, ketchv1.TraefikIngressControllerType:
+		default:
+			return fmt.Errorf("shadow deployment failed. Traffic mirroring is supported by istio and traefik ingress controllers only")
+		}
+	}
+
 	_, err = cs.getUnits()
 	if !isMissing(err) {
 		if !isValid(err) {
@@ -118,6 +163,27 @@ func validateDeploy(cs *ChangeSet, app *ketchv1.App) error {
 		}
 	}
 
+	_, err = cs.getCmds()
+	if !isMissing(err) {
+		if !isValid(err) {
+			return err
+		}
+	}
+
+	_, err = cs.getProcfile()
+	if !isMissing(err) {
+		if !isValid(err) {
+			return err
+		}
+		if cs.sourcePath != nil {
+			return fmt.Errorf("%w %s can't be used to deploy from source, the Procfile of the source directory is used", newInvalidUsageError(FlagProcfile), FlagProcfile)
+		}
+	}
+
+	if _, err := cs.getCacheImage(); !isMissing(err) && cs.sourcePath == nil {
+		return fmt.Errorf("%w %s can only be used to deploy from source", newInvalidUsageError(FlagCacheImage), FlagCacheImage)
+	}
+
 	// Volume Validations
 
 	_, err = cs.getVolumeName()
@@ -168,6 +234,17 @@ func validateDeploy(cs *ChangeSet, app *ketchv1.App) error {
 	return nil
 }
 
+func validateDryRun(cs *ChangeSet) error {
+	dryRun, _ := cs.getDryRun()
+	if diff, _ := cs.getDiff(); diff && !dryRun {
+		return fmt.Errorf("%w %s requires %s", newInvalidUsageError(FlagDiff), FlagDiff, FlagDryRun)
+	}
+	if dryRun && cs.sourcePath != nil {
+		return fmt.Errorf("%w %s can't build an image from source, deploy an image instead", newInvalidUsageError(FlagDryRun), FlagDryRun)
+	}
+	return nil
+}
+
 func validateSourceDeploy(cs *ChangeSet) error {
 	sourcePath, err := cs.getSourceDirectory()
 	if err != nil {
@@ -177,6 +254,13 @@ func validateSourceDeploy(cs *ChangeSet) error {
 	if err != nil || stat.IsDir() {
 		return fmt.Errorf("%q not found in root of source directory", defaultProcFile)
 	}
+	content, err := ioutil.ReadFile(path.Join(sourcePath, defaultProcFile))
+	if err != nil {
+		return err
+	}
+	if _, err := chart.ParseProcfile(string(content)); err != nil {
+		return fmt.Errorf("invalid %s: %w", defaultProcFile, err)
+	}
 	return nil
 }
 
@@ -187,9 +271,18 @@ func validateCreateApp(ctx context.Context, client Client, appName string, cs *C
 			appName)
 	}
 
+	// an app with a dedicated namespace gets a namespace named after it by default.
+	if strategy, _ := cs.getNamespaceStrategy(); strategy == ketchv1.PerAppNamespace && cs.namespace == nil {
+		namespace := "ketch-" + appName
+		cs.namespace = &namespace
+	}
 	if _, err := cs.getNamespace(); err != nil {
 		return err
 	}
+	if cs.dockerRegistrySecret == nil && len(DefaultRegistrySecret) > 0 {
+		secret := DefaultRegistrySecret
+		cs.dockerRegistrySecret = &secret
+	}
 	if _, err := cs.getImage(); err != nil {
 		return err
 	}
@@ -197,6 +290,24 @@ func validateCreateApp(ctx context.Context, client Client, appName string, cs *C
 	return nil
 }
 
+// validateAppNamespace fails if the dedicated namespace of an app with the perApp strategy exists
+// and wasn't created for the app, so the app doesn't take over and later remove a namespace of other workloads.
+func validateAppNamespace(ctx context.Context, client Client, appName, namespace string) error {
+	var ns v1.Namespace
+	err := client.Get(ctx, types.NamespacedName{Name: namespace}, &ns)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return kerrs.Wrap(err, "could not get namespace %q", namespace)
+	}
+	if ns.Labels[utils.KetchAppNameLabel] != appName {
+		return fmt.Errorf("%w namespace %q already exists and isn't dedicated to app %q, use another --%s with --%s %s",
+			newInvalidValueError(FlagNamespace), namespace, appName, FlagNamespace, FlagNamespaceStrategy, ketchv1.PerAppNamespace)
+	}
+	return nil
+}
+
 func directoryExists(dir string) error {
 	fi, err := os.Stat(dir)
 	if os.IsNotExist(err) {
@@ -1,10 +1,16 @@
 package deploy
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrlFake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
 	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
 )
 
@@ -183,6 +189,150 @@ func TestDeployValidations(t *testing.T) {
 			},
 			wantErr: `"fs-group" invalid value fs-group must be 1 or greater`,
 		},
+		{
+			name: "cache image without source",
+			cs: &ChangeSet{
+				image:      stringRef("docker.io/shipasoftware/bulletinboard:1.0"),
+				cacheImage: stringRef("docker.io/shipasoftware/bulletinboard-cache"),
+			},
+			app: &ketchv1.App{
+				Spec: ketchv1.AppSpec{
+					Deployments: []ketchv1.AppDeploymentSpec{},
+				},
+			},
+			wantErr: `"cache-image" used improperly cache-image can only be used to deploy from source`,
+		},
+		{
+			name: "canary header without steps",
+			cs: &ChangeSet{
+				image:        stringRef("docker.io/shipasoftware/bulletinboard:2.0"),
+				canaryHeader: stringRef("X-Canary=true"),
+			},
+			app: &ketchv1.App{
+				Spec: ketchv1.AppSpec{
+					Deployments: []ketchv1.AppDeploymentSpec{{Version: 1}},
+				},
+			},
+			wantErr: `"canary-header" used improperly canary-header and canary-cookie must be used with steps flag`,
+		},
+		{
+			name: "invalid canary header",
+			cs: &ChangeSet{
+				image:            stringRef("docker.io/shipasoftware/bulletinboard:2.0"),
+				steps:            intRef(2),
+				stepTimeInterval: stringRef("1h"),
+				canaryHeader:     stringRef("X-Canary"),
+			},
+			app: &ketchv1.App{
+				Spec: ketchv1.AppSpec{
+					Deployments: []ketchv1.AppDeploymentSpec{{Version: 1}},
+				},
+			},
+			wantErr: `"canary-header" invalid value canary header must be in the form name=value`,
+		},
+		{
+			name: "invalid canary cookie",
+			cs: &ChangeSet{
+				image:            stringRef("docker.io/shipasoftware/bulletinboard:2.0"),
+				steps:            intRef(2),
+				stepTimeInterval: stringRef("1h"),
+				canaryCookie:     stringRef("canary;v2"),
+			},
+			app: &ketchv1.App{
+				Spec: ketchv1.AppSpec{
+					Deployments: []ketchv1.AppDeploymentSpec{{Version: 1}},
+				},
+			},
+			wantErr: `"canary-cookie" invalid value invalid canary cookie name "canary;v2"`,
+		},
+		{
+			name: "valid canary routing",
+			cs: &ChangeSet{
+				image:            stringRef("docker.io/shipasoftware/bulletinboard:2.0"),
+				steps:            intRef(2),
+				stepTimeInterval: stringRef("1h"),
+				canaryHeader:     stringRef("X-Canary=true"),
+				canaryCookie:     stringRef("canary"),
+			},
+			app: &ketchv1.App{
+				Spec: ketchv1.AppSpec{
+					Deployments: []ketchv1.AppDeploymentSpec{{Version: 1}},
+				},
+			},
+			want: nil,
+		},
+		{
+			name: "valid shadow",
+			cs: &ChangeSet{
+				image:  stringRef("docker.io/shipasoftware/bulletinboard:2.0"),
+				shadow: intRef(10),
+			},
+			app: &ketchv1.App{
+				Spec: ketchv1.AppSpec{
+					Deployments: []ketchv1.AppDeploymentSpec{{Version: 1}},
+					Ingress:     ketchv1.IngressSpec{Controller: ketchv1.IngressControllerSpec{IngressType: ketchv1.IstioIngressControllerType}},
+				},
+			},
+			want: nil,
+		},
+		{
+			name: "invalid shadow",
+			cs: &ChangeSet{
+				image:  stringRef("docker.io/shipasoftware/bulletinboard:2.0"),
+				shadow: intRef(101),
+			},
+			app: &ketchv1.App{
+				Spec: ketchv1.AppSpec{
+					Deployments: []ketchv1.AppDeploymentSpec{{Version: 1}},
+					Ingress:     ketchv1.IngressSpec{Controller: ketchv1.IngressControllerSpec{IngressType: ketchv1.IstioIngressControllerType}},
+				},
+			},
+			wantErr: `"shadow" invalid value shadow must be between 1 and 100`,
+		},
+		{
+			name: "shadow with steps",
+			cs: &ChangeSet{
+				image:            stringRef("docker.io/shipasoftware/bulletinboard:2.0"),
+				shadow:           intRef(10),
+				steps:            intRef(2),
+				stepTimeInterval: stringRef("1h"),
+			},
+			app: &ketchv1.App{
+				Spec: ketchv1.AppSpec{
+					Deployments: []ketchv1.AppDeploymentSpec{{Version: 1}},
+					Ingress:     ketchv1.IngressSpec{Controller: ketchv1.IngressControllerSpec{IngressType: ketchv1.IstioIngressControllerType}},
+				},
+			},
+			wantErr: `"shadow" used improperly shadow can't be used with steps`,
+		},
+		{
+			name: "shadow without primary deployment",
+			cs: &ChangeSet{
+				image:  stringRef("docker.io/shipasoftware/bulletinboard:2.0"),
+				shadow: intRef(10),
+			},
+			app: &ketchv1.App{
+				Spec: ketchv1.AppSpec{
+					Deployments: []ketchv1.AppDeploymentSpec{},
+					Ingress:     ketchv1.IngressSpec{Controller: ketchv1.IngressControllerSpec{IngressType: ketchv1.TraefikIngressControllerType}},
+				},
+			},
+			wantErr: "shadow deployment failed. The app must have a single deployment serving its traffic",
+		},
+		{
+			name: "shadow with nginx",
+			cs: &ChangeSet{
+				image:  stringRef("docker.io/shipasoftware/bulletinboard:2.0"),
+				shadow: intRef(10),
+			},
+			app: &ketchv1.App{
+				Spec: ketchv1.AppSpec{
+					Deployments: []ketchv1.AppDeploymentSpec{{Version: 1}},
+					Ingress:     ketchv1.IngressSpec{Controller: ketchv1.IngressControllerSpec{IngressType: ketchv1.NginxIngressControllerType}},
+				},
+			},
+			wantErr: "shadow deployment failed. Traffic mirroring is supported by istio and traefik ingress controllers only",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -197,3 +347,19 @@ func TestDeployValidations(t *testing.T) {
 		})
 	}
 }
+
+func Test_validateAppNamespace(t *testing.T) {
+	cli := ctrlFake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ketch-myapp", Labels: map[string]string{"theketch.io/app-name": "myapp"}}},
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ketch-other", Labels: map[string]string{"theketch.io/app-name": "other"}}},
+	).Build()
+	ctx := context.Background()
+	require.Nil(t, validateAppNamespace(ctx, cli, "myapp", "ketch-myapp"))
+	require.Nil(t, validateAppNamespace(ctx, cli, "myapp", "new-namespace"))
+
+	err := validateAppNamespace(ctx, cli, "myapp", "default")
+	require.False(t, isValid(err))
+	require.EqualError(t, err, `"namespace" invalid value namespace "default" already exists and isn't dedicated to app "myapp", use another --namespace with --namespace-strategy perApp`)
+	require.NotNil(t, validateAppNamespace(ctx, cli, "myapp", "ketch-other"))
+}
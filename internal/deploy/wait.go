@@ -3,6 +3,9 @@ package deploy
 import (
 	"context"
 	"fmt"
+	"io"
+	"sort"
+	"strings"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
@@ -18,6 +21,8 @@ import (
 
 type WaitFn func(ctx context.Context, svc *Services, app *ketchv1.App, timeout time.Duration) error
 
+// WaitForDeployment waits until the controller reports the outcome of the app's latest deployment
+// and prints steps of the rollout in the meantime.
 func WaitForDeployment(ctx context.Context, svc *Services, app *ketchv1.App, timeout time.Duration) error {
 	tctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
@@ -34,35 +39,169 @@ func WaitForDeployment(ctx context.Context, svc *Services, app *ketchv1.App, tim
 			if !ok {
 				return errors.New("wait for deployment channel closed")
 			}
-			if evt, ok := msg.Object.(*corev1.Event); ok {
-				reason, err := ketchv1.ParseAppReconcileOutcome(evt.Reason)
-				if err != nil {
-					return err
-				}
-				if reason.DeploymentCount == app.Spec.DeploymentsCount {
-					switch evt.Type {
-					case corev1.EventTypeNormal:
-						fmt.Fprintln(svc.Writer, "successfully deployed!")
-						return nil
-					case corev1.EventTypeWarning:
-						return errors.New(evt.Message)
-					}
+			evt, ok := msg.Object.(*corev1.Event)
+			if !ok {
+				continue
+			}
+			if evt.Reason != ketchv1.AppReconcileOutcomeReason {
+				if msg.Type == watch.Added {
+					writeDeployProgress(svc.Writer, app, evt)
 				}
+				continue
+			}
+			reason, err := ketchv1.ParseAppReconcileOutcome(evt.Message)
+			if err != nil || reason.DeploymentCount != app.Spec.DeploymentsCount {
+				continue
+			}
+			switch evt.Type {
+			case corev1.EventTypeNormal:
+				fmt.Fprintln(svc.Writer, "successfully deployed!")
+				return nil
+			case corev1.EventTypeWarning:
+				reportFailingPods(ctx, svc, app)
+				return errors.New(evt.Message)
 			}
 		case <-tctx.Done():
+			reportFailingPods(ctx, svc, app)
 			return fmt.Errorf("deployment timed out")
 		}
 	}
 }
 
 func watchAppReconcileEvent(ctx context.Context, kubeClient kubernetes.Interface, app *ketchv1.App) (watch.Interface, error) {
-	reason := ketchv1.AppReconcileOutcome{AppName: app.Name, DeploymentCount: app.Spec.DeploymentsCount}
 	selector := fields.Set(map[string]string{
 		"involvedObject.apiVersion": utils.V1betaPrefix,
 		"involvedObject.kind":       "App",
 		"involvedObject.name":       app.Name,
-		"reason":                    reason.String(),
 	}).AsSelector()
 	return kubeClient.CoreV1().
 		Events(app.Namespace).Watch(ctx, metav1.ListOptions{FieldSelector: selector.String()})
 }
+
+// writeDeployProgress prints a step of rolling out the app's latest deployment recorded by the controller.
+// Events of previous deployments and events that aren't rollout steps are skipped.
+func writeDeployProgress(w io.Writer, app *ketchv1.App, evt *corev1.Event) {
+	switch evt.Reason {
+	case ketchv1.AppReconcileStarted, ketchv1.AppReconcileUpdate, ketchv1.AppReconcileError:
+	default:
+		return
+	}
+	if len(app.Spec.Deployments) == 0 {
+		return
+	}
+	event := ketchv1.AppDeploymentEventFromAnnotations(evt.Annotations)
+	if event.DeploymentVersion != int(app.Spec.Deployments[len(app.Spec.Deployments)-1].Version) {
+		return
+	}
+	if event.ProcessName != "" {
+		fmt.Fprintf(w, "[%s] %s\n", event.ProcessName, event.Description)
+		return
+	}
+	fmt.Fprintln(w, event.Description)
+}
+
+// failureLogTailLines is the number of log lines printed for each container of a failing pod.
+const failureLogTailLines = 20
+
+// reportFailingPods prints events and the last log lines of the pods of the latest deployment that are not ready,
+// so the output of a failed deployment contains the root cause.
+func reportFailingPods(ctx context.Context, svc *Services, app *ketchv1.App) {
+	if len(app.Spec.Deployments) == 0 || app.Spec.Namespace == "" {
+		return
+	}
+	version := app.Spec.Deployments[len(app.Spec.Deployments)-1].Version
+	selector := fmt.Sprintf("%s=%s,%s=%s", utils.KetchAppNameLabel, app.Name, utils.KetchDeploymentVersionLabel, version)
+	pods, err := svc.KubeClient.CoreV1().Pods(app.Spec.Namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		fmt.Fprintf(svc.Writer, "failed to list pods: %v\n", err)
+		return
+	}
+	failing := make([]corev1.Pod, 0, len(pods.Items))
+	for _, pod := range pods.Items {
+		if !isPodReady(pod) {
+			failing = append(failing, pod)
+		}
+	}
+	sort.Slice(failing, func(i, j int) bool {
+		pi, pj := failing[i].Labels[utils.KetchProcessNameLabel], failing[j].Labels[utils.KetchProcessNameLabel]
+		if pi != pj {
+			return pi < pj
+		}
+		return failing[i].Name < failing[j].Name
+	})
+	for _, pod := range failing {
+		fmt.Fprintf(svc.Writer, "Process %s, pod %s (%s):\n", pod.Labels[utils.KetchProcessNameLabel], pod.Name, podFailureReason(pod))
+		writePodEvents(ctx, svc, pod)
+		for _, status := range pod.Status.ContainerStatuses {
+			writeContainerLogs(ctx, svc, pod, status)
+		}
+	}
+}
+
+func isPodReady(pod corev1.Pod) bool {
+	if pod.Status.Phase == corev1.PodSucceeded {
+		return true
+	}
+	for _, c := range pod.Status.Conditions {
+		if c.Type == corev1.PodReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// podFailureReason returns the most specific reason why a pod isn't ready.
+func podFailureReason(pod corev1.Pod) string {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.State.Waiting != nil && status.State.Waiting.Reason != "" {
+			return status.State.Waiting.Reason
+		}
+		if status.State.Terminated != nil && status.State.Terminated.Reason != "" {
+			return status.State.Terminated.Reason
+		}
+	}
+	if pod.Status.Reason != "" {
+		return pod.Status.Reason
+	}
+	return string(pod.Status.Phase)
+}
+
+func writePodEvents(ctx context.Context, svc *Services, pod corev1.Pod) {
+	selector := fields.OneTermEqualSelector("involvedObject.name", pod.Name).String()
+	events, err := svc.KubeClient.CoreV1().Events(pod.Namespace).List(ctx, metav1.ListOptions{FieldSelector: selector})
+	if err != nil {
+		fmt.Fprintf(svc.Writer, "  failed to get events: %v\n", err)
+		return
+	}
+	if len(events.Items) == 0 {
+		return
+	}
+	fmt.Fprintln(svc.Writer, "  Events:")
+	for _, evt := range events.Items {
+		fmt.Fprintf(svc.Writer, "    %s %s: %s\n", evt.Type, evt.Reason, evt.Message)
+	}
+}
+
+// writeContainerLogs prints the last log lines of a container.
+// If the container has restarted, the logs of the previous instance are printed because they contain the crash.
+func writeContainerLogs(ctx context.Context, svc *Services, pod corev1.Pod, status corev1.ContainerStatus) {
+	tailLines := int64(failureLogTailLines)
+	opts := &corev1.PodLogOptions{
+		Container: status.Name,
+		TailLines: &tailLines,
+		Previous:  status.RestartCount > 0,
+	}
+	logs, err := svc.KubeClient.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, opts).DoRaw(ctx)
+	if err != nil {
+		fmt.Fprintf(svc.Writer, "  failed to get logs of container %s: %v\n", status.Name, err)
+		return
+	}
+	fmt.Fprintf(svc.Writer, "  Last log lines of container %s:\n", status.Name)
+	writeIndented(svc.Writer, string(logs), "    ")
+}
+
+func writeIndented(w io.Writer, text string, indent string) {
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		fmt.Fprintf(w, "%s%s\n", indent, line)
+	}
+}
@@ -0,0 +1,160 @@
+package deploy
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/utils"
+)
+
+func TestReportFailingPods(t *testing.T) {
+	app := &ketchv1.App{
+		ObjectMeta: metav1.ObjectMeta{Name: "hello"},
+		Spec: ketchv1.AppSpec{
+			Namespace:   "ketch-hello",
+			Deployments: []ketchv1.AppDeploymentSpec{{Version: 1}, {Version: 2}},
+		},
+	}
+	pod := func(name, process, version string, ready corev1.ConditionStatus, waitingReason string, restarts int32) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "ketch-hello",
+				Labels: map[string]string{
+					utils.KetchAppNameLabel:           "hello",
+					utils.KetchProcessNameLabel:       process,
+					utils.KetchDeploymentVersionLabel: version,
+				},
+			},
+			Status: corev1.PodStatus{
+				Phase:      corev1.PodRunning,
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: ready}},
+				ContainerStatuses: []corev1.ContainerStatus{
+					{
+						Name:         "hello-" + process + "-" + version,
+						RestartCount: restarts,
+						State:        corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: waitingReason}},
+					},
+				},
+			},
+		}
+	}
+	event := &corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: "hello-worker-2-abc.1", Namespace: "ketch-hello"},
+		InvolvedObject: corev1.ObjectReference{Name: "hello-worker-2-abc"},
+		Type:           corev1.EventTypeWarning,
+		Reason:         "BackOff",
+		Message:        "Back-off restarting failed container",
+	}
+	kubeClient := fake.NewSimpleClientset(
+		pod("hello-web-2-abc", "web", "2", corev1.ConditionTrue, "", 0),
+		pod("hello-worker-2-abc", "worker", "2", corev1.ConditionFalse, "CrashLoopBackOff", 3),
+		pod("hello-worker-1-abc", "worker", "1", corev1.ConditionFalse, "CrashLoopBackOff", 3),
+		event,
+	)
+	out := &bytes.Buffer{}
+	svc := &Services{KubeClient: kubeClient, Writer: out}
+
+	reportFailingPods(context.Background(), svc, app)
+
+	expected := `Process worker, pod hello-worker-2-abc (CrashLoopBackOff):
+  Events:
+    Warning BackOff: Back-off restarting failed container
+  Last log lines of container hello-worker-2:
+    fake logs
+`
+	require.Equal(t, expected, out.String())
+}
+
+func TestWaitForDeployment(t *testing.T) {
+	app := &ketchv1.App{
+		ObjectMeta: metav1.ObjectMeta{Name: "hello"},
+		Spec: ketchv1.AppSpec{
+			Namespace:        "ketch-hello",
+			Deployments:      []ketchv1.AppDeploymentSpec{{Version: 2}},
+			DeploymentsCount: 2,
+		},
+	}
+	progress := func(version, process, message string) *corev1.Event {
+		return &corev1.Event{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					ketchv1.DeploymentAnnotationDevelopmentVersion: version,
+					ketchv1.DeploymentAnnotationProcessName:        process,
+					ketchv1.DeploymentAnnotationDescription:        message,
+				},
+			},
+			Type:    corev1.EventTypeNormal,
+			Reason:  ketchv1.AppReconcileUpdate,
+			Message: message,
+		}
+	}
+	outcome := func(eventType string, count int, err ...error) *corev1.Event {
+		reason := ketchv1.AppReconcileOutcome{AppName: "hello", DeploymentCount: count}
+		return &corev1.Event{Type: eventType, Reason: ketchv1.AppReconcileOutcomeReason, Message: reason.String(err...)}
+	}
+	tests := []struct {
+		name    string
+		events  []*corev1.Event
+		wantOut string
+		wantErr string
+	}{
+		{
+			name: "success",
+			events: []*corev1.Event{
+				progress("1", "", "helm chart rendered"),
+				outcome(corev1.EventTypeNormal, 1),
+				progress("2", "", "helm chart rendered"),
+				progress("2", "", "helm release upgraded"),
+				progress("2", "web", "1 of 1 new units ready"),
+				outcome(corev1.EventTypeNormal, 2),
+			},
+			wantOut: `helm chart rendered
+helm release upgraded
+[web] 1 of 1 new units ready
+successfully deployed!
+`,
+		},
+		{
+			name: "failure",
+			events: []*corev1.Event{
+				progress("2", "", "helm chart rendered"),
+				outcome(corev1.EventTypeWarning, 2, errors.New("failed to update helm chart")),
+			},
+			wantOut: "helm chart rendered\n",
+			wantErr: "failed to update helm chart",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			watcher := watch.NewFakeWithChanSize(len(tt.events), false)
+			for _, event := range tt.events {
+				watcher.Add(event)
+			}
+			kubeClient := fake.NewSimpleClientset()
+			kubeClient.PrependWatchReactor("events", k8stesting.DefaultWatchReactor(watcher, nil))
+			out := &bytes.Buffer{}
+			svc := &Services{KubeClient: kubeClient, Writer: out}
+
+			err := WaitForDeployment(context.Background(), svc, app, time.Minute)
+			if tt.wantErr != "" {
+				require.NotNil(t, err)
+				require.Contains(t, err.Error(), tt.wantErr)
+			} else {
+				require.Nil(t, err)
+			}
+			require.Equal(t, tt.wantOut, out.String())
+		})
+	}
+}
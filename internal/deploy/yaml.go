@@ -21,7 +21,10 @@ type Application struct {
 	Image          *string   `json:"image,omitempty"`
 	Namespace      *string   `json:"namespace"`
 	Description    *string   `json:"description,omitempty"`
+	Team           *string   `json:"team,omitempty"`
+	Owner          *string   `json:"owner,omitempty"`
 	Environment    []string  `json:"environment,omitempty"`
+	EnvSets        []string  `json:"envSets,omitempty"`
 	RegistrySecret *string   `json:"registrySecret,omitempty"`
 	Builder        *string   `json:"builder,omitempty"`
 	BuildPacks     []string  `json:"buildPacks,omitempty"`
@@ -72,8 +75,16 @@ func (o *Options) GetChangeSetFromYaml(filename string) (*ChangeSet, error) {
 	if err != nil {
 		return nil, err
 	}
+	return o.GetChangeSetFromApplication(application)
+}
 
+// GetChangeSetFromApplication returns a ChangeSet from the values of an application.yaml.
+func (o *Options) GetChangeSetFromApplication(application Application) (*ChangeSet, error) {
+	if application.Name == nil {
+		return nil, errors.New("missing required field name")
+	}
 	var envs []ketchv1.Env
+	var err error
 	if application.Environment != nil {
 		envs, err = utils.MakeEnvironments(application.Environment)
 		if err != nil {
@@ -98,21 +109,32 @@ func (o *Options) GetChangeSetFromYaml(filename string) (*ChangeSet, error) {
 		appType:              application.Type,
 		image:                application.Image,
 		description:          application.Description,
+		team:                 application.Team,
+		owner:                application.Owner,
 		namespace:            application.Namespace,
 		dockerRegistrySecret: application.RegistrySecret,
 		builder:              application.Builder,
 		timeout:              &o.Timeout,
 		wait:                 &o.Wait,
+		dryRun:               &o.DryRun,
+		diff:                 &o.Diff,
 	}
 	if o.AppSourcePath != "" {
 		c.sourcePath = &o.AppSourcePath
 	}
+	if o.Queue {
+		c.queue = &o.Queue
+		c.queueTimeout = &o.QueueTimeout
+	}
 	if application.CName != nil {
 		c.cname = &ketchv1.CnameList{{Name: application.CName.DNSName, Secure: application.CName.Secure}}
 	}
 	if application.Environment != nil {
 		c.envs = &application.Environment
 	}
+	if application.EnvSets != nil {
+		c.envSets = &application.EnvSets
+	}
 	if application.BuildPacks != nil {
 		c.buildPacks = &application.BuildPacks
 	}
@@ -188,6 +210,12 @@ func GetApplicationFromKetchApp(app ketchv1.App) *Application {
 	if app.Spec.Description != "" {
 		application.Description = &app.Spec.Description
 	}
+	if app.Spec.Team != "" {
+		application.Team = &app.Spec.Team
+	}
+	if app.Spec.Owner != "" {
+		application.Owner = &app.Spec.Owner
+	}
 	if app.Spec.DockerRegistry.SecretName != "" {
 		application.RegistrySecret = &app.Spec.DockerRegistry.SecretName
 	}
@@ -197,6 +225,9 @@ func GetApplicationFromKetchApp(app ketchv1.App) *Application {
 	if len(app.Spec.BuildPacks) > 0 {
 		application.BuildPacks = app.Spec.BuildPacks
 	}
+	if len(app.Spec.EnvSets) > 0 {
+		application.EnvSets = app.Spec.EnvSets
+	}
 	var environment []string
 	for _, env := range app.Spec.Env {
 		environment = append(environment, fmt.Sprintf("%s=%s", env.Name, env.Value))
@@ -27,12 +27,16 @@ name: test
 image: gcr.io/kubernetes/sample-app:latest
 namespace: mynamespace
 description: a test
+team: payments
+owner: alice
 builder: heroku/buildpacks:20
 buildPacks:
   - test-buildpack
 environment:
   - PORT=6666
   - FOO=bar
+envSets:
+  - database
 processes:
   - name: web
     units: 1
@@ -51,7 +55,10 @@ cname:
 				sourcePath:           conversions.StrPtr("."),
 				image:                conversions.StrPtr("gcr.io/kubernetes/sample-app:latest"),
 				description:          conversions.StrPtr("a test"),
+				team:                 conversions.StrPtr("payments"),
+				owner:                conversions.StrPtr("alice"),
 				envs:                 &[]string{"PORT=6666", "FOO=bar"},
+				envSets:              &[]string{"database"},
 				namespace:            conversions.StrPtr("mynamespace"),
 				dockerRegistrySecret: nil,
 				builder:              conversions.StrPtr("heroku/buildpacks:20"),
@@ -59,6 +66,8 @@ cname:
 				cname:                &ketchv1.CnameList{{Name: "test.10.10.10.20", Secure: false}},
 				timeout:              conversions.StrPtr("1m"),
 				wait:                 conversions.BoolPtr(true),
+				dryRun:               conversions.BoolPtr(false),
+				diff:                 conversions.BoolPtr(false),
 				processes: &[]ketchv1.ProcessSpec{
 					{
 						Name:  "web",
@@ -108,6 +117,8 @@ image: gcr.io/kubernetes/sample-app:latest`,
 				appType:            conversions.StrPtr("Application"),
 				timeout:            conversions.StrPtr(""),
 				wait:               conversions.BoolPtr(false),
+				dryRun:             conversions.BoolPtr(false),
+				diff:               conversions.BoolPtr(false),
 			},
 		},
 		{
@@ -156,6 +167,8 @@ processes:
 				namespace:          conversions.StrPtr("mynamespace"),
 				timeout:            conversions.StrPtr(""),
 				wait:               conversions.BoolPtr(false),
+				dryRun:             conversions.BoolPtr(false),
+				diff:               conversions.BoolPtr(false),
 				processes: &[]ketchv1.ProcessSpec{
 					{
 						Name:  "web",
@@ -186,6 +199,8 @@ image: gcr.io/kubernetes/sample-app:latest
 				appType:            conversions.StrPtr("Application"),
 				timeout:            conversions.StrPtr(""),
 				wait:               conversions.BoolPtr(false),
+				dryRun:             conversions.BoolPtr(false),
+				diff:               conversions.BoolPtr(false),
 			},
 		},
 		{
@@ -252,7 +267,10 @@ func TestGetApplicationFromKetchApp(t *testing.T) {
 					Namespace:      "mynamespace",
 					Version:        conversions.StrPtr("v1"),
 					Description:    "a test",
+					Team:           "payments",
+					Owner:          "alice",
 					Env:            []ketchv1.Env{{Name: "TEST_KEY", Value: "TEST_VALUE"}},
+					EnvSets:        []string{"database", "observability"},
 					DockerRegistry: ketchv1.DockerRegistrySpec{SecretName: "a_secret"},
 					Builder:        "builder",
 					BuildPacks:     []string{"test/buildpack"},
@@ -285,7 +303,10 @@ func TestGetApplicationFromKetchApp(t *testing.T) {
 				Image:          conversions.StrPtr("gcr.io/shipa-ci/sample-go-app:latest"),
 				Namespace:      conversions.StrPtr("mynamespace"),
 				Description:    conversions.StrPtr("a test"),
+				Team:           conversions.StrPtr("payments"),
+				Owner:          conversions.StrPtr("alice"),
 				Environment:    []string{"TEST_KEY=TEST_VALUE"},
+				EnvSets:        []string{"database", "observability"},
 				RegistrySecret: conversions.StrPtr("a_secret"),
 				Builder:        conversions.StrPtr("builder"),
 				BuildPacks:     []string{"test/buildpack"},
@@ -1,11 +1,15 @@
 package mocks
 
 import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
 	dynamicFake "k8s.io/client-go/dynamic/fake"
 	"k8s.io/client-go/kubernetes"
 	kubeFake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	ktesting "k8s.io/client-go/testing"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	ctrlFake "sigs.k8s.io/controller-runtime/pkg/client/fake"
 
@@ -28,10 +32,19 @@ type Configuration struct {
 	KubeClientObjects    []runtime.Object
 	DynamicClientObjects []runtime.Object
 	StorageInstance      templates.Client
+	// KubeClientReactors are prepended to reactors of the kubernetes client, e.g. to forbid requests.
+	KubeClientReactors []KubeClientReactor
 
 	ctrlClient client.Client
 }
 
+// KubeClientReactor reacts to requests of the kubernetes client with the verb to the resource.
+type KubeClientReactor struct {
+	Verb     string
+	Resource string
+	Reaction ktesting.ReactionFunc
+}
+
 func (cfg *Configuration) Client() client.Client {
 	if cfg.ctrlClient == nil {
 		cfg.ctrlClient = ctrlFake.NewClientBuilder().
@@ -48,10 +61,40 @@ func (cfg *Configuration) Storage() templates.Client {
 
 // KubernetesClient returns kubernetes typed client. It's used to work with standard kubernetes types.
 func (cfg *Configuration) KubernetesClient() kubernetes.Interface {
-	return kubeFake.NewSimpleClientset(cfg.KubeClientObjects...)
+	clientset := kubeFake.NewSimpleClientset(cfg.KubeClientObjects...)
+	for _, reactor := range cfg.KubeClientReactors {
+		clientset.PrependReactor(reactor.Verb, reactor.Resource, reactor.Reaction)
+	}
+	return clientset
 }
 
+// podMetricsResource is the resource of pod metrics served by metrics-server,
+// the fake client can't guess it from the PodMetrics kind.
+var podMetricsResource = schema.GroupVersionResource{Group: "metrics.k8s.io", Version: "v1beta1", Resource: "pods"}
+
 // DynamicClient returns kubernetes dynamic client. It's used to work with CRDs for which we don't have go types like ClusterIssuer.
 func (cfg *Configuration) DynamicClient() dynamic.Interface {
-	return dynamicFake.NewSimpleDynamicClient(runtime.NewScheme(), cfg.DynamicClientObjects...)
+	var objects []runtime.Object
+	var podMetrics []*unstructured.Unstructured
+	for _, obj := range cfg.DynamicClientObjects {
+		if u, ok := obj.(*unstructured.Unstructured); ok && u.GroupVersionKind().Group == podMetricsResource.Group && u.GetKind() == "PodMetrics" {
+			podMetrics = append(podMetrics, u)
+			continue
+		}
+		objects = append(objects, obj)
+	}
+	client := dynamicFake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		podMetricsResource: "PodMetricsList",
+	}, objects...)
+	for _, u := range podMetrics {
+		if err := client.Tracker().Create(podMetricsResource, u, u.GetNamespace()); err != nil {
+			panic(err)
+		}
+	}
+	return client
+}
+
+// RESTConfig returns a config of kubernetes clients. It's used to stream to pods like "ketch app run" does.
+func (cfg *Configuration) RESTConfig() *rest.Config {
+	return &rest.Config{}
 }
@@ -24,25 +24,35 @@ type BuildRequest struct {
 	Builder    string
 	WorkingDir string
 	BuildPacks []string
+	CacheImage string
+	// Out receives logs of the build instead of the output of the client.
+	Out io.Writer
 }
 
 // Client wrapper around the pack client
 type Client struct {
 	builder packService
+	// newBuilder returns a pack client logging to the writer, it's used by builds with their own output.
+	newBuilder func(out io.Writer) (packService, error)
 }
 
 func New(out io.Writer) (*Client, error) {
-	buildLogger := logging.NewSimpleLogger(out)
-	builder, err := client.NewClient(client.WithLogger(buildLogger))
+	builder, err := newPackClient(out)
 	if err != nil {
 		return nil, err
 	}
 
 	return &Client{
-		builder: builder,
+		builder:    builder,
+		newBuilder: newPackClient,
 	}, nil
 }
 
+func newPackClient(out io.Writer) (packService, error) {
+	buildLogger := logging.NewSimpleLogger(out)
+	return client.NewClient(client.WithLogger(buildLogger))
+}
+
 // BuildAndPushImage builds and pushes an image via pack with the specified parameters in BuildRequest
 func (c *Client) BuildAndPushImage(ctx context.Context, req BuildRequest) error {
 	buildOptions := client.BuildOptions{
@@ -54,6 +64,7 @@ func (c *Client) BuildAndPushImage(ctx context.Context, req BuildRequest) error
 		AdditionalMirrors: nil,
 		Env:               nil,
 		Publish:           true,
+		CacheImage:        req.CacheImage,
 		ClearCache:        false,
 		TrustBuilder: func(s string) bool {
 			return true
@@ -64,5 +75,12 @@ func (c *Client) BuildAndPushImage(ctx context.Context, req BuildRequest) error
 		DefaultProcessType: defaultProcessType,
 		PullPolicy:         packConfig.PullIfNotPresent,
 	}
-	return c.builder.Build(ctx, buildOptions)
+	builder := c.builder
+	if req.Out != nil {
+		var err error
+		if builder, err = c.newBuilder(req.Out); err != nil {
+			return err
+		}
+	}
+	return builder.Build(ctx, buildOptions)
 }
@@ -0,0 +1,49 @@
+package provisioner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+)
+
+// Crossplane provisions a dependency with a Crossplane claim, Crossplane writes its connection details to the secret.
+// The cluster administrator grants ketch's role access to kinds of claims configured in the cluster.
+type Crossplane struct {
+	Client client.Client
+}
+
+var _ Provisioner = &Crossplane{}
+
+func (c *Crossplane) Provision(ctx context.Context, app *ketchv1.App, config ketchv1.DependencyProvisioner) (ketchv1.DependencyStatus, error) {
+	if config.APIVersion == "" || config.Kind == "" {
+		return ketchv1.DependencyStatus{}, fmt.Errorf("crossplane provisioner of dependency %q requires apiVersion and kind of a claim", config.Name)
+	}
+	var parameters map[string]interface{}
+	if config.Parameters != nil && len(config.Parameters.Raw) > 0 {
+		if err := json.Unmarshal(config.Parameters.Raw, &parameters); err != nil {
+			return ketchv1.DependencyStatus{}, fmt.Errorf("invalid parameters of dependency %q: %w", config.Name, err)
+		}
+	}
+	claim := &unstructured.Unstructured{}
+	claim.SetAPIVersion(config.APIVersion)
+	claim.SetKind(config.Kind)
+	claim.SetName(ketchv1.DependencySecretName(app.Name, config.Name))
+	claim.SetNamespace(app.Spec.Namespace)
+	err := apply(ctx, c.Client, app, claim, func() error {
+		if parameters != nil {
+			if err := unstructured.SetNestedMap(claim.Object, parameters, "spec", "parameters"); err != nil {
+				return err
+			}
+		}
+		return unstructured.SetNestedField(claim.Object, claim.GetName(), "spec", "writeConnectionSecretToRef", "name")
+	})
+	if err != nil {
+		return ketchv1.DependencyStatus{}, fmt.Errorf("failed to apply %s %s: %w", config.Kind, claim.GetName(), err)
+	}
+	return status(ctx, c.Client, claim, config)
+}
@@ -0,0 +1,60 @@
+package provisioner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+)
+
+const (
+	externalSecretAPIVersion = "external-secrets.io/v1beta1"
+	externalSecretKind       = "ExternalSecret"
+)
+
+// ExternalSecrets provides connection details of an existing dependency with an ExternalSecret,
+// External Secrets Operator syncs them from a ClusterSecretStore to the secret.
+type ExternalSecrets struct {
+	Client client.Client
+}
+
+var _ Provisioner = &ExternalSecrets{}
+
+func (e *ExternalSecrets) Provision(ctx context.Context, app *ketchv1.App, config ketchv1.DependencyProvisioner) (ketchv1.DependencyStatus, error) {
+	if config.SecretStore == "" || config.RemoteKey == "" {
+		return ketchv1.DependencyStatus{}, fmt.Errorf("externalSecrets provisioner of dependency %q requires secretStore and remoteKey", config.Name)
+	}
+	externalSecret := &unstructured.Unstructured{}
+	externalSecret.SetAPIVersion(externalSecretAPIVersion)
+	externalSecret.SetKind(externalSecretKind)
+	externalSecret.SetName(ketchv1.DependencySecretName(app.Name, config.Name))
+	externalSecret.SetNamespace(app.Spec.Namespace)
+	err := apply(ctx, e.Client, app, externalSecret, func() error {
+		spec := map[string]interface{}{
+			"refreshInterval": "1h",
+			"secretStoreRef": map[string]interface{}{
+				"name": config.SecretStore,
+				"kind": "ClusterSecretStore",
+			},
+			"target": map[string]interface{}{
+				"name": externalSecret.GetName(),
+			},
+			"dataFrom": []interface{}{
+				map[string]interface{}{
+					"extract": map[string]interface{}{
+						"key": strings.ReplaceAll(config.RemoteKey, "{{app}}", app.Name),
+					},
+				},
+			},
+		}
+		return unstructured.SetNestedMap(externalSecret.Object, spec, "spec")
+	})
+	if err != nil {
+		return ketchv1.DependencyStatus{}, fmt.Errorf("failed to apply %s %s: %w", externalSecretKind, externalSecret.GetName(), err)
+	}
+	return status(ctx, e.Client, externalSecret, config)
+}
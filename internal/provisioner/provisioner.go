@@ -0,0 +1,101 @@
+// Package provisioner provisions dependencies apps declare in "requires" of ketch.yaml,
+// like databases or caches, and makes their connection details available in secrets of the apps' namespaces.
+package provisioner
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/utils"
+)
+
+// Provisioner creates resources of a dependency of an app, they write connection details of the dependency
+// to a secret named ketchv1.DependencySecretName in the app's namespace.
+type Provisioner interface {
+	Provision(ctx context.Context, app *ketchv1.App, config ketchv1.DependencyProvisioner) (ketchv1.DependencyStatus, error)
+}
+
+// Provisioners are provisioners by type.
+type Provisioners map[ketchv1.ProvisionerType]Provisioner
+
+// Default returns provisioners of all supported types.
+func Default(cli client.Client) Provisioners {
+	return Provisioners{
+		ketchv1.CrossplaneProvisionerType:      &Crossplane{Client: cli},
+		ketchv1.ExternalSecretsProvisionerType: &ExternalSecrets{Client: cli},
+	}
+}
+
+// Provision provisions the dependency with the provisioner of its type.
+func (p Provisioners) Provision(ctx context.Context, app *ketchv1.App, config ketchv1.DependencyProvisioner) (ketchv1.DependencyStatus, error) {
+	provisioner, ok := p[config.Type]
+	if !ok {
+		return ketchv1.DependencyStatus{}, fmt.Errorf("unsupported provisioner type %q of dependency %q", config.Type, config.Name)
+	}
+	return provisioner.Provision(ctx, app, config)
+}
+
+// apply creates or updates the dependency's object, mutate sets its spec.
+// Objects aren't owned by the app, so data of a dependency isn't lost when the app is removed or stops requiring it.
+func apply(ctx context.Context, cli client.Client, app *ketchv1.App, obj *unstructured.Unstructured, mutate func() error) error {
+	_, err := controllerutil.CreateOrUpdate(ctx, cli, obj, func() error {
+		labels := obj.GetLabels()
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		labels[utils.KetchAppNameLabel] = app.Name
+		obj.SetLabels(labels)
+		return mutate()
+	})
+	return err
+}
+
+// status returns the status of the dependency, it's ready when the object's Ready condition is true and the secret exists.
+func status(ctx context.Context, cli client.Client, obj *unstructured.Unstructured, config ketchv1.DependencyProvisioner) (ketchv1.DependencyStatus, error) {
+	status := ketchv1.DependencyStatus{
+		Name:        config.Name,
+		Provisioner: config.Type,
+		SecretName:  obj.GetName(),
+	}
+	if ready, message := readyCondition(obj); !ready {
+		status.Message = message
+		return status, nil
+	}
+	var secret v1.Secret
+	err := cli.Get(ctx, client.ObjectKey{Name: obj.GetName(), Namespace: obj.GetNamespace()}, &secret)
+	if err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			return status, err
+		}
+		status.Message = fmt.Sprintf("waiting for secret %s with connection details", obj.GetName())
+		return status, nil
+	}
+	status.Ready = true
+	return status, nil
+}
+
+// readyCondition returns true if the Ready condition of the object is true, or its message otherwise.
+func readyCondition(obj *unstructured.Unstructured) (bool, string) {
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, item := range conditions {
+		condition, ok := item.(map[string]interface{})
+		if !ok || condition["type"] != "Ready" {
+			continue
+		}
+		if condition["status"] == "True" {
+			return true, ""
+		}
+		message, _ := condition["message"].(string)
+		if message == "" {
+			message, _ = condition["reason"].(string)
+		}
+		return false, fmt.Sprintf("%s %s is not ready: %s", obj.GetKind(), obj.GetName(), message)
+	}
+	return false, fmt.Sprintf("%s %s is not ready", obj.GetKind(), obj.GetName())
+}
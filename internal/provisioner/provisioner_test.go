@@ -0,0 +1,130 @@
+package provisioner
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/utils"
+)
+
+var (
+	claimGVK          = schema.GroupVersionKind{Group: "database.example.org", Version: "v1alpha1", Kind: "PostgreSQLInstance"}
+	externalSecretGVK = schema.GroupVersionKind{Group: "external-secrets.io", Version: "v1beta1", Kind: "ExternalSecret"}
+)
+
+func testScheme() *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	for _, gvk := range []schema.GroupVersionKind{claimGVK, externalSecretGVK} {
+		scheme.AddKnownTypeWithName(gvk, &unstructured.Unstructured{})
+		scheme.AddKnownTypeWithName(gvk.GroupVersion().WithKind(gvk.Kind+"List"), &unstructured.UnstructuredList{})
+	}
+	return scheme
+}
+
+func testApp() *ketchv1.App {
+	return &ketchv1.App{
+		ObjectMeta: metav1.ObjectMeta{Name: "dashboard"},
+		Spec:       ketchv1.AppSpec{Namespace: "ketch-gke"},
+	}
+}
+
+func getObject(t *testing.T, cli client.Client, gvk schema.GroupVersionKind, name string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	require.Nil(t, cli.Get(context.Background(), client.ObjectKey{Name: name, Namespace: "ketch-gke"}, obj))
+	return obj
+}
+
+func setReady(t *testing.T, cli client.Client, obj *unstructured.Unstructured) {
+	conditions := []interface{}{map[string]interface{}{"type": "Ready", "status": "True"}}
+	require.Nil(t, unstructured.SetNestedSlice(obj.Object, conditions, "status", "conditions"))
+	require.Nil(t, cli.Update(context.Background(), obj))
+}
+
+func TestCrossplane_Provision(t *testing.T) {
+	cli := fake.NewClientBuilder().WithScheme(testScheme()).Build()
+	config := ketchv1.DependencyProvisioner{
+		Name:       "postgres",
+		Type:       ketchv1.CrossplaneProvisionerType,
+		APIVersion: "database.example.org/v1alpha1",
+		Kind:       "PostgreSQLInstance",
+		Parameters: &runtime.RawExtension{Raw: []byte(`{"storageGB":20}`)},
+	}
+	provisioners := Default(cli)
+	status, err := provisioners.Provision(context.Background(), testApp(), config)
+	require.Nil(t, err)
+	require.Equal(t, ketchv1.DependencyStatus{
+		Name:        "postgres",
+		Provisioner: ketchv1.CrossplaneProvisionerType,
+		SecretName:  "dashboard-postgres",
+		Message:     "PostgreSQLInstance dashboard-postgres is not ready",
+	}, status)
+
+	claim := getObject(t, cli, claimGVK, "dashboard-postgres")
+	require.Equal(t, "dashboard", claim.GetLabels()[utils.KetchAppNameLabel])
+	storage, _, _ := unstructured.NestedInt64(claim.Object, "spec", "parameters", "storageGB")
+	require.Equal(t, int64(20), storage)
+	secretName, _, _ := unstructured.NestedString(claim.Object, "spec", "writeConnectionSecretToRef", "name")
+	require.Equal(t, "dashboard-postgres", secretName)
+
+	setReady(t, cli, claim)
+	status, err = provisioners.Provision(context.Background(), testApp(), config)
+	require.Nil(t, err)
+	require.False(t, status.Ready)
+	require.Equal(t, "waiting for secret dashboard-postgres with connection details", status.Message)
+
+	secret := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "dashboard-postgres", Namespace: "ketch-gke"}}
+	require.Nil(t, cli.Create(context.Background(), secret))
+	status, err = provisioners.Provision(context.Background(), testApp(), config)
+	require.Nil(t, err)
+	require.True(t, status.Ready)
+	require.Empty(t, status.Message)
+
+	_, err = provisioners.Provision(context.Background(), testApp(), ketchv1.DependencyProvisioner{Name: "postgres", Type: ketchv1.CrossplaneProvisionerType})
+	require.EqualError(t, err, `crossplane provisioner of dependency "postgres" requires apiVersion and kind of a claim`)
+}
+
+func TestExternalSecrets_Provision(t *testing.T) {
+	cli := fake.NewClientBuilder().WithScheme(testScheme()).Build()
+	config := ketchv1.DependencyProvisioner{
+		Name:        "redis",
+		Type:        ketchv1.ExternalSecretsProvisionerType,
+		SecretStore: "vault",
+		RemoteKey:   "apps/{{app}}/redis",
+	}
+	provisioners := Default(cli)
+	status, err := provisioners.Provision(context.Background(), testApp(), config)
+	require.Nil(t, err)
+	require.False(t, status.Ready)
+	require.Equal(t, "dashboard-redis", status.SecretName)
+
+	externalSecret := getObject(t, cli, externalSecretGVK, "dashboard-redis")
+	store, _, _ := unstructured.NestedString(externalSecret.Object, "spec", "secretStoreRef", "name")
+	require.Equal(t, "vault", store)
+	target, _, _ := unstructured.NestedString(externalSecret.Object, "spec", "target", "name")
+	require.Equal(t, "dashboard-redis", target)
+	dataFrom, _, _ := unstructured.NestedSlice(externalSecret.Object, "spec", "dataFrom")
+	require.Equal(t, []interface{}{map[string]interface{}{"extract": map[string]interface{}{"key": "apps/dashboard/redis"}}}, dataFrom)
+
+	setReady(t, cli, externalSecret)
+	secret := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "dashboard-redis", Namespace: "ketch-gke"}}
+	require.Nil(t, cli.Create(context.Background(), secret))
+	status, err = provisioners.Provision(context.Background(), testApp(), config)
+	require.Nil(t, err)
+	require.True(t, status.Ready)
+
+	_, err = provisioners.Provision(context.Background(), testApp(), ketchv1.DependencyProvisioner{Name: "redis", Type: "terraform"})
+	require.EqualError(t, err, `unsupported provisioner type "terraform" of dependency "redis"`)
+}
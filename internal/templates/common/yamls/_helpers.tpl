@@ -31,3 +31,33 @@ theketch.io/metadata-item-apiVersion: {{ $.apiVersion }}
 {{- end }}
 {{- end }}
 {{- end -}}
+
+{{/*
+
+ketch.stickySessionCookie renders a name of the cookie ingress controllers use to route requests of a client
+to the same pod of a process with sticky sessions, it takes the root context.
+
+*/}}
+{{- define "ketch.stickySessionCookie" -}}
+{{ $.Values.app.name }}-affinity
+{{- end -}}
+
+{{/*
+
+ketch.podAnnotations renders an annotations section of pods of a process, it takes a dict with "root" and "process".
+Pods of a process with metrics get prometheus.io scrape annotations unless the Prometheus Operator scrapes them
+with a PodMonitor.
+
+*/}}
+{{- define "ketch.podAnnotations" -}}
+{{- $annotations := merge (dict) (.process.podMetadata.annotations | default dict) }}
+{{- if and .process.metrics (not (.root.Capabilities.APIVersions.Has "monitoring.coreos.com/v1/PodMonitor")) }}
+{{- $annotations = merge $annotations (.process.metrics.scrapeAnnotations | default dict) }}
+{{- end }}
+{{- if $annotations -}}
+annotations:
+  {{- range $k, $v := $annotations }}
+  {{ $k }}: {{ $v | quote }}
+  {{- end }}
+{{- end }}
+{{- end -}}
@@ -7,6 +7,15 @@
       {{- if .root.app.securityContext }}
       securityContext:
 {{ .root.app.securityContext | toYaml | indent 8 }}
+      {{- end }}
+      {{- if hasKey .process "terminationGracePeriodSeconds" }}
+      terminationGracePeriodSeconds: {{ .process.terminationGracePeriodSeconds }}
+      {{- end }}
+      {{- if .process.priorityClassName }}
+      priorityClassName: {{ .process.priorityClassName | quote }}
+      {{- end }}
+      {{- if .process.runtimeClassName }}
+      runtimeClassName: {{ .process.runtimeClassName | quote }}
       {{- end }}
       containers:
         - name: {{ .root.app.name }}-{{ .process.name }}-{{ .deployment.version }}
@@ -20,7 +29,7 @@
 {{ .root.app.env | toYaml | indent 12 }}
           {{- end }}
           {{- end }}
-          image: {{ .deployment.image }}
+          image: {{ .process.image | default .deployment.image }}
           {{- if .process.containerPorts }}
           ports:
 {{ .process.containerPorts | toYaml | indent 10 }}
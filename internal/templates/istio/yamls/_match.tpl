@@ -0,0 +1,75 @@
+{{/*
+
+ketch.istioEndpointMatch renders conditions of an HTTPMatchRequest that match requests of a cname,
+it takes an http or https entrypoint of "ingress" with the following entries:
+{
+    "cname": "<cname>",    // a hostname, a wildcard like "*.example.com" is matched with a regex
+    "path": "<path>",      // an optional path prefix
+}
+
+*/}}
+{{- define "ketch.istioEndpointMatch" -}}
+authority:
+  {{- if hasPrefix "*." $.cname }}
+  regex: {{ printf "^[^.]+%s(:[0-9]+)?$" (regexQuoteMeta (trimPrefix "*" $.cname)) | quote }}
+  {{- else }}
+  regex: {{ printf "^%s(:[0-9]+)?$" (regexQuoteMeta $.cname) | quote }}
+  {{- end }}
+{{- if $.path }}
+uri:
+  prefix: {{ $.path | quote }}
+{{- end }}
+{{- end }}
+
+{{/*
+
+ketch.istioCanaryMatch renders HTTPMatchRequests of requests routed to the canary deployment,
+it takes a dict with the following entries:
+{
+    "canary": <canaryRouting>,    // "canaryRouting" of values
+    "endpoints": [<endpoint>],    // entrypoints of "ingress" whose cnames are matched too, if routes are matched by path
+}
+
+*/}}
+{{- define "ketch.istioCanaryMatch" -}}
+{{- $headers := list }}
+{{- with $.canary }}
+{{- if .header }}
+{{- $headers = append $headers (dict (lower .header) (dict "exact" .headerValue)) }}
+{{- end }}
+{{- if .cookie }}
+{{- $headers = append $headers (dict "cookie" (dict "regex" (printf "^(.*; *)?%s=%s(;.*)?$" (regexQuoteMeta .cookie) (regexQuoteMeta .cookieValue)))) }}
+{{- end }}
+{{- end }}
+{{- range $_, $header := $headers }}
+{{- if $.endpoints }}
+{{- range $_, $endpoint := $.endpoints }}
+- headers:
+{{ toYaml $header | indent 4 }}
+{{ include "ketch.istioEndpointMatch" $endpoint | indent 2 }}
+{{- end }}
+{{- else }}
+- headers:
+{{ toYaml $header | indent 4 }}
+{{- end }}
+{{- end }}
+{{- end }}
+
+{{/*
+
+ketch.istioOperation renders an operation of an AuthorizationPolicy rule that matches requests of a cname,
+it takes an http or https entrypoint of "ingress".
+
+*/}}
+{{- define "ketch.istioOperation" -}}
+hosts:
+- {{ $.cname | quote }}
+{{- if not (hasPrefix "*." $.cname) }}
+- {{ printf "%s:*" $.cname | quote }}
+{{- end }}
+{{- with $.path }}
+paths:
+- {{ . | quote }}
+- {{ printf "%s/*" . | quote }}
+{{- end }}
+{{- end }}
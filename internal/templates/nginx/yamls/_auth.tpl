@@ -0,0 +1,15 @@
+{{/*
+
+ketch.nginxAuth renders annotations of an Ingress object that protect its hosts,
+it takes "ingress.httpAuth" or "ingress.httpsAuth" of values with either basicAuthSecret or forwardAuthURL set.
+
+*/}}
+{{- define "ketch.nginxAuth" -}}
+{{- if .basicAuthSecret }}
+nginx.ingress.kubernetes.io/auth-type: "basic"
+nginx.ingress.kubernetes.io/auth-secret: {{ .basicAuthSecret | quote }}
+nginx.ingress.kubernetes.io/auth-realm: "Authentication Required"
+{{- else if .forwardAuthURL }}
+nginx.ingress.kubernetes.io/auth-url: {{ .forwardAuthURL | quote }}
+{{- end }}
+{{- end }}
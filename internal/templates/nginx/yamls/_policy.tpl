@@ -0,0 +1,21 @@
+{{/*
+
+ketch.nginxPolicy renders annotations of an Ingress object based on "ingress.policy".
+
+*/}}
+{{- define "ketch.nginxPolicy" -}}
+{{- if .rateLimit }}
+nginx.ingress.kubernetes.io/limit-rps: {{ .rateLimit.requestsPerSecond | quote }}
+nginx.ingress.kubernetes.io/limit-burst-multiplier: {{ max 1 (div .rateLimit.burst .rateLimit.requestsPerSecond) | quote }}
+{{- end }}
+{{- if .maxBodySizeBytes }}
+nginx.ingress.kubernetes.io/proxy-body-size: {{ .maxBodySizeBytes | int64 | quote }}
+{{- end }}
+{{- if .timeoutSeconds }}
+nginx.ingress.kubernetes.io/proxy-read-timeout: {{ .timeoutSeconds | quote }}
+nginx.ingress.kubernetes.io/proxy-send-timeout: {{ .timeoutSeconds | quote }}
+{{- end }}
+{{- if .connectTimeoutSeconds }}
+nginx.ingress.kubernetes.io/proxy-connect-timeout: {{ .connectTimeoutSeconds | quote }}
+{{- end }}
+{{- end }}
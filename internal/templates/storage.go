@@ -98,6 +98,42 @@ func CronJobConfigMapName() string {
 	return "cronjob-templates"
 }
 
+// AppTemplates returns templates to render an app's chart with the given ingress controller type.
+// If custom is set, it is a name of a configmap with templates that replace the built-in templates with the same names
+// and add new ones, a custom template with empty content removes the built-in one.
+func AppTemplates(reader Reader, ingressType string, custom string) (*Templates, error) {
+	tpls, err := reader.Get(IngressConfigMapName(ingressType))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read configmap with the app's chart templates: %w", err)
+	}
+	if custom == "" {
+		return tpls, nil
+	}
+	customTpls, err := reader.Get(custom)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read configmap %q with custom chart templates: %w", custom, err)
+	}
+	merged := tpls.Merge(*customTpls)
+	return &merged, nil
+}
+
+// Merge returns templates with the yamls of overrides added to these templates.
+// A yaml of overrides with empty content removes the yaml with the same name.
+func (tpl Templates) Merge(overrides Templates) Templates {
+	merged := Templates{Yamls: make(map[string]string, len(tpl.Yamls)+len(overrides.Yamls))}
+	for name, value := range tpl.Yamls {
+		merged.Yamls[name] = value
+	}
+	for name, value := range overrides.Yamls {
+		if len(value) == 0 {
+			delete(merged.Yamls, name)
+			continue
+		}
+		merged.Yamls[name] = value
+	}
+	return merged
+}
+
 // Get returns templates stored in a configmap with the provided name.
 func (s *Storage) Get(name string) (*Templates, error) {
 	ctx := context.TODO()
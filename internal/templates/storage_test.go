@@ -1,6 +1,7 @@
 package templates
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -39,3 +40,64 @@ func TestJobConfigMapName(t *testing.T) {
 func TestCronJobConfigMapName(t *testing.T) {
 	require.Equal(t, "cronjob-templates", CronJobConfigMapName())
 }
+
+type mapReader map[string]Templates
+
+func (r mapReader) Get(name string) (*Templates, error) {
+	tpls, ok := r[name]
+	if !ok {
+		return nil, fmt.Errorf("configmap %s not found", name)
+	}
+	return &tpls, nil
+}
+
+func TestAppTemplates(t *testing.T) {
+	reader := mapReader{
+		"ingress-nginx-templates": {Yamls: map[string]string{"deployment.yaml": "deployment", "service.yaml": "service", "ingress.yaml": "ingress"}},
+		"company-templates":       {Yamls: map[string]string{"deployment.yaml": "company deployment", "policy.yaml": "policy", "ingress.yaml": ""}},
+	}
+	tests := []struct {
+		name        string
+		ingressType string
+		custom      string
+
+		want    *Templates
+		wantErr string
+	}{
+		{
+			name:        "built-in templates",
+			ingressType: "nginx",
+			want:        &Templates{Yamls: map[string]string{"deployment.yaml": "deployment", "service.yaml": "service", "ingress.yaml": "ingress"}},
+		},
+		{
+			name:        "custom templates",
+			ingressType: "nginx",
+			custom:      "company-templates",
+			want:        &Templates{Yamls: map[string]string{"deployment.yaml": "company deployment", "service.yaml": "service", "policy.yaml": "policy"}},
+		},
+		{
+			name:        "missing built-in templates",
+			ingressType: "traefik",
+			wantErr:     "failed to read configmap with the app's chart templates: configmap ingress-traefik-templates not found",
+		},
+		{
+			name:        "missing custom templates",
+			ingressType: "nginx",
+			custom:      "unknown",
+			wantErr:     `failed to read configmap "unknown" with custom chart templates: configmap unknown not found`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := AppTemplates(reader, tt.ingressType, tt.custom)
+			if len(tt.wantErr) > 0 {
+				require.NotNil(t, err)
+				require.Equal(t, tt.wantErr, err.Error())
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tt.want, got)
+			require.Equal(t, "ingress", reader["ingress-nginx-templates"].Yamls["ingress.yaml"])
+		})
+	}
+}
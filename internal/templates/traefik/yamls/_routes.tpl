@@ -0,0 +1,88 @@
+{{/*
+
+ketch.traefikRule renders a rule to match requests of a cname,
+it takes an http or https entrypoint of "ingress" with the following entries:
+{
+    "cname": "<cname>",    // a hostname, a wildcard like "*.example.com" is matched with HostRegexp
+    "path": "<path>",      // an optional path prefix
+}
+
+*/}}
+{{- define "ketch.traefikRule" -}}
+{{- if hasPrefix "*." $.cname -}}
+HostRegexp("{subdomain:[a-z0-9-]+}{{ trimPrefix "*" $.cname }}")
+{{- else -}}
+Host("{{ $.cname }}")
+{{- end }}
+{{- if $.path }} && PathPrefix("{{ $.path }}"){{ end }}
+{{- end }}
+
+{{/*
+
+ketch.traefikCanaryRule renders a rule to match requests routed to the canary deployment,
+it takes "canaryRouting" of values. A request matches if it has the canary header or cookie.
+
+*/}}
+{{- define "ketch.traefikCanaryRule" -}}
+{{- $rules := list }}
+{{- if $.header }}
+{{- $rules = append $rules (printf "Headers(%q, %q)" $.header $.headerValue) }}
+{{- end }}
+{{- if $.cookie }}
+{{- $rules = append $rules (printf "HeadersRegexp(%q, %q)" "Cookie" (printf "(^|; *)%s=%s(;|$)" (regexQuoteMeta $.cookie) (regexQuoteMeta $.cookieValue))) }}
+{{- end }}
+{{- join " || " $rules }}
+{{- end }}
+
+{{/*
+
+ketch.traefikMiddlewares renders middlewares of a route that enforce "ingress.policy", the allowlist and the auth
+of an entrypoint, it renders nothing if there are no such middlewares. It takes a dict with the following entries:
+{
+    "Values": <values>,          // values of the chart
+    "allowlist": <allowlist>,    // an optional "allowlist" of an http or https entrypoint of "ingress"
+    "auth": <auth>,              // an optional "auth" of an http or https entrypoint of "ingress"
+}
+
+*/}}
+{{- define "ketch.traefikMiddlewares" -}}
+{{- $names := list }}
+{{- with .allowlist }}
+{{- $names = append $names .name }}
+{{- end }}
+{{- with .Values.app.ingress.policy }}
+{{- if .rateLimit }}
+{{- $names = append $names (printf "%s-rate-limit" $.Values.app.name) }}
+{{- end }}
+{{- if .maxBodySizeBytes }}
+{{- $names = append $names (printf "%s-buffering" $.Values.app.name) }}
+{{- end }}
+{{- end }}
+{{- with .auth }}
+{{- $names = append $names .name }}
+{{- end }}
+{{- if $names }}
+middlewares:
+{{- range $_, $name := $names }}
+  - name: {{ $name }}
+{{- end }}
+{{- end }}
+{{- end }}
+
+{{/*
+
+ketch.traefikIssuerAnnotation renders the cert-manager annotation of the issuer of certificates,
+it takes "ingressController" of values with either certificateIssuer or clusterIssuer set, certificateIssuer takes precedence.
+
+*/}}
+{{- define "ketch.traefikIssuerAnnotation" -}}
+{{- if $.certificateIssuer -}}
+{{- if eq $.certificateIssuer.issuerRef.kind "Issuer" -}}
+cert-manager.io/issuer: {{ $.certificateIssuer.issuerRef.name | quote }}
+{{- else -}}
+cert-manager.io/cluster-issuer: {{ $.certificateIssuer.issuerRef.name | quote }}
+{{- end }}
+{{- else -}}
+cert-manager.io/cluster-issuer: {{ $.clusterIssuer | quote }}
+{{- end }}
+{{- end }}
@@ -1,9 +1,16 @@
 package utils
 
 import (
+	"bufio"
 	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
 	"strings"
 
+	"k8s.io/apimachinery/pkg/util/validation"
+
 	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
 )
 
@@ -16,8 +23,78 @@ func MakeEnvironments(envs []string) ([]ketchv1.Env, error) {
 		if len(parts) != 2 {
 			return nil, errors.New("env variables should have NAME=VALUE format")
 		}
+		name := strings.TrimSpace(parts[0])
+		if err := validateEnvName(name); err != nil {
+			return nil, err
+		}
 		splittedEnvs = append(splittedEnvs,
-			ketchv1.Env{Name: strings.TrimSpace(parts[0]), Value: strings.TrimSpace(parts[1])})
+			ketchv1.Env{Name: name, Value: strings.TrimSpace(parts[1])})
 	}
 	return splittedEnvs, nil
 }
+
+// ReadEnvFile reads environment variables from a dotenv file.
+func ReadEnvFile(filename string) ([]ketchv1.Env, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseEnvFile(f)
+}
+
+// ParseEnvFile parses a dotenv file containing a NAME=VALUE pair per line.
+// Empty lines and lines starting with '#' are skipped, a line may start with "export".
+// Values can be enclosed in single or double quotes, escape sequences like \n are interpreted in double quotes only.
+func ParseEnvFile(r io.Reader) ([]ketchv1.Env, error) {
+	var envs []ketchv1.Env
+	scanner := bufio.NewScanner(r)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("line %d: env variables should have NAME=VALUE format", lineNumber)
+		}
+		name := strings.TrimSpace(parts[0])
+		if err := validateEnvName(name); err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		value, err := unquoteEnvValue(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		envs = append(envs, ketchv1.Env{Name: name, Value: value})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return envs, nil
+}
+
+func unquoteEnvValue(value string) (string, error) {
+	if len(value) < 2 {
+		return value, nil
+	}
+	switch {
+	case value[0] == '"' && value[len(value)-1] == '"':
+		unquoted, err := strconv.Unquote(value)
+		if err != nil {
+			return "", fmt.Errorf("invalid quoted value %s", value)
+		}
+		return unquoted, nil
+	case value[0] == '\'' && value[len(value)-1] == '\'':
+		return value[1 : len(value)-1], nil
+	}
+	return value, nil
+}
+
+func validateEnvName(name string) error {
+	if msgs := validation.IsEnvVarName(name); len(msgs) > 0 {
+		return fmt.Errorf("invalid env variable name %q: %s", name, strings.Join(msgs, ", "))
+	}
+	return nil
+}
@@ -5,5 +5,28 @@ const (
 	KetchAppNameLabel           = KetchLabelPrefix + "app-name"
 	KetchProcessNameLabel       = KetchLabelPrefix + "app-process"
 	KetchDeploymentVersionLabel = KetchLabelPrefix + "app-deployment-version"
+	KetchJobNameLabel           = KetchLabelPrefix + "job-name"
 	V1betaPrefix                = KetchLabelPrefix + "v1beta1"
+
+	// KetchTeamLabel and KetchOwnerLabel are added to all resources of an app with its team and owner.
+	KetchTeamLabel  = KetchLabelPrefix + "team"
+	KetchOwnerLabel = KetchLabelPrefix + "owner"
+
+	// KetchShardLabel is set on Apps and Jobs by "ketch app deploy --shard" and "ketch job deploy --shard",
+	// only ketch-controllers started with the same --shard reconcile them.
+	KetchShardLabel = KetchLabelPrefix + "shard"
+
+	// KetchImportedByLabel is set by "ketch app import" on objects an App was imported from, its value is the name of the app.
+	KetchImportedByLabel = KetchLabelPrefix + "imported-by"
+
+	// KetchRepairRequestedAnnotation is set on an App by "ketch app repair" to ask the controller to unlock the app's helm release.
+	KetchRepairRequestedAnnotation = KetchLabelPrefix + "repair-requested-at"
+	// KetchDeployedByAnnotation is set on an App by "ketch app deploy" and "ketch app rollback" to record who deployed the app.
+	KetchDeployedByAnnotation = KetchLabelPrefix + "deployed-by"
+	// KetchServiceBindingsChecksumAnnotation is set on pods of processes with service bindings,
+	// its value changes when secrets of the bindings change, so the pods are restarted with rotated credentials.
+	KetchServiceBindingsChecksumAnnotation = KetchLabelPrefix + "service-bindings-checksum"
+	// KetchRestartedAtAnnotation is set on pods of a process restarted by "ketch dashboard",
+	// a new value rolls the pods of the process.
+	KetchRestartedAtAnnotation = KetchLabelPrefix + "restarted-at"
 )
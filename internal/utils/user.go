@@ -0,0 +1,14 @@
+package utils
+
+import (
+	"os"
+	"os/user"
+)
+
+// CurrentUser returns a name of the user running ketch.
+func CurrentUser() string {
+	if u, err := user.Current(); err == nil && len(u.Username) > 0 {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
@@ -0,0 +1,60 @@
+package validation
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+)
+
+const (
+	ErrCnameNotResolved          Error = "cname could not be resolved"
+	ErrCnameNotPointingToIngress Error = "cname does not point to the ingress controller"
+
+	// wildcardCheckLabel replaces '*' of a wildcard cname to get a name that the wildcard DNS record must resolve.
+	wildcardCheckLabel = "ketch-dns-check"
+)
+
+// Resolver looks up DNS records, *net.Resolver implements it.
+type Resolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+	LookupCNAME(ctx context.Context, host string) (string, error)
+}
+
+// ValidateCnameDNS checks whether the DNS record of the cname points to the endpoint of an ingress controller.
+// The endpoint is either an IP address or a hostname of a load balancer,
+// a cname pointing to a hostname is valid if it's an alias of the hostname or resolves to the same addresses.
+func ValidateCnameDNS(ctx context.Context, resolver Resolver, cname, endpoint string) error {
+	host := cname
+	if strings.HasPrefix(cname, "*.") {
+		host = wildcardCheckLabel + cname[1:]
+	}
+	isIP := net.ParseIP(endpoint) != nil
+	if !isIP {
+		if target, err := resolver.LookupCNAME(ctx, host); err == nil && sameHost(target, endpoint) {
+			return nil
+		}
+	}
+	addrs, err := resolver.LookupHost(ctx, host)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrCnameNotResolved, err)
+	}
+	expected := []string{endpoint}
+	if !isIP {
+		if expected, err = resolver.LookupHost(ctx, endpoint); err != nil {
+			return fmt.Errorf("failed to resolve the ingress controller's endpoint %s: %w", endpoint, err)
+		}
+	}
+	for _, addr := range addrs {
+		for _, want := range expected {
+			if net.ParseIP(addr).Equal(net.ParseIP(want)) {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("%w: %s resolves to %s, expected %s", ErrCnameNotPointingToIngress, cname, strings.Join(addrs, ", "), endpoint)
+}
+
+func sameHost(a, b string) bool {
+	return strings.EqualFold(strings.TrimSuffix(a, "."), strings.TrimSuffix(b, "."))
+}
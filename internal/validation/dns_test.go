@@ -0,0 +1,95 @@
+package validation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeResolver resolves names using static records.
+type fakeResolver struct {
+	hosts  map[string][]string
+	cnames map[string]string
+}
+
+func (r fakeResolver) LookupHost(_ context.Context, host string) ([]string, error) {
+	addrs, ok := r.hosts[host]
+	if !ok {
+		return nil, fmt.Errorf("no such host %s", host)
+	}
+	return addrs, nil
+}
+
+func (r fakeResolver) LookupCNAME(_ context.Context, host string) (string, error) {
+	if cname, ok := r.cnames[host]; ok {
+		return cname, nil
+	}
+	return host + ".", nil
+}
+
+func TestValidateCnameDNS(t *testing.T) {
+	resolver := fakeResolver{
+		hosts: map[string][]string{
+			"theketch.io":                      {"10.10.10.10"},
+			"app.theketch.io":                  {"20.20.20.20"},
+			"ketch-dns-check.apps.theketch.io": {"10.10.10.10"},
+			"lb.example.com":                   {"30.30.30.30"},
+			"www.theketch.io":                  {"30.30.30.30"},
+		},
+		cnames: map[string]string{
+			"alias.theketch.io": "lb.example.com.",
+		},
+	}
+	tests := []struct {
+		name     string
+		cname    string
+		endpoint string
+		wantErr  error
+	}{
+		{
+			name:     "A record points to the ingress IP",
+			cname:    "theketch.io",
+			endpoint: "10.10.10.10",
+		},
+		{
+			name:     "wildcard cname",
+			cname:    "*.apps.theketch.io",
+			endpoint: "10.10.10.10",
+		},
+		{
+			name:     "alias of the load balancer hostname",
+			cname:    "alias.theketch.io",
+			endpoint: "lb.example.com",
+		},
+		{
+			name:     "same addresses as the load balancer hostname",
+			cname:    "www.theketch.io",
+			endpoint: "lb.example.com",
+		},
+		{
+			name:     "points elsewhere",
+			cname:    "app.theketch.io",
+			endpoint: "10.10.10.10",
+			wantErr:  ErrCnameNotPointingToIngress,
+		},
+		{
+			name:     "no record",
+			cname:    "missing.theketch.io",
+			endpoint: "10.10.10.10",
+			wantErr:  ErrCnameNotResolved,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateCnameDNS(context.Background(), resolver, tt.cname, tt.endpoint)
+			if tt.wantErr != nil {
+				require.True(t, errors.Is(err, tt.wantErr), "got %v", err)
+				return
+			}
+			require.Nil(t, err)
+		})
+	}
+}
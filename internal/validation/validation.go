@@ -12,6 +12,7 @@ import (
 var (
 	nameRegexp         = regexp.MustCompile(`^[a-z][a-z0-9-]{0,39}$`)
 	yamlFilenameRegexp = regexp.MustCompile(`.*\.(yaml|yml)$`)
+	cnamePathRegexp    = regexp.MustCompile(`^(/[\w.~%-]+)+$`)
 )
 
 // Error represents the package's Error type that is returned by Validate* functions.
@@ -24,6 +25,7 @@ const (
 	ErrIPAddress           Error = "invalid cname: cname must be a DNS name, not an IP address"
 	ErrInvalidWildcard     Error = "invalid cname: a wildcard cname must start with '*.', followed by a valid DNS subdomain, which must consist of lower case alphanumeric characters, '-' or '.' and end with an alphanumeric character"
 	ErrInvalidDnsSubdomain Error = "invalid cname: cname must consist of lower case alphanumeric characters, '-' or '.', and must start and end with an alphanumeric character"
+	ErrInvalidCnamePath    Error = "invalid cname: path must start with '/' and consist of alphanumeric characters, '-', '_', '.', '~' or '%' separated by '/'"
 )
 
 // ValidateName checks whether the given name is valid.
@@ -57,6 +59,14 @@ func ValidateCname(cname string) error {
 	return nil
 }
 
+// ValidateCnamePath checks whether the given path prefix of a CNAME is valid.
+func ValidateCnamePath(path string) error {
+	if !cnamePathRegexp.MatchString(path) {
+		return ErrInvalidCnamePath
+	}
+	return nil
+}
+
 func ValidateYamlFilename(name string) bool {
 	return yamlFilenameRegexp.MatchString(name)
 }
@@ -0,0 +1,241 @@
+// Package ketchclient creates, deploys and scales apps of a cluster running ketch and sets their cnames
+// and the cluster's ingress settings, so CI systems, internal tooling and infrastructure-as-code
+// providers like Terraform can drive ketch without shelling out to the CLI.
+package ketchclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	k8svalidation "k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/controllers"
+	"github.com/theketchio/ketch/internal/deploy"
+	"github.com/theketchio/ketch/internal/templates"
+	"github.com/theketchio/ketch/internal/validation"
+)
+
+// ErrInvalidRequest is returned for requests with missing or invalid values.
+var ErrInvalidRequest = errors.New("invalid request")
+
+var scheme = runtime.NewScheme()
+
+func init() {
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = ketchv1.AddToScheme()(scheme)
+}
+
+// Client changes apps of a cluster like the ketch CLI does.
+type Client struct {
+	client         client.Client
+	kubeClient     kubernetes.Interface
+	templates      templates.Reader
+	getImageConfig deploy.GetImageConfigFn
+}
+
+// CreateAppRequest describes a new app without a deployment, like "ketch apply" creates it.
+type CreateAppRequest struct {
+	Name string `json:"name"`
+	// Namespace the app runs in, it must exist.
+	Namespace   string `json:"namespace"`
+	Description string `json:"description,omitempty"`
+	Team        string `json:"team,omitempty"`
+	Owner       string `json:"owner,omitempty"`
+	// RegistrySecret is a name of a secret with credentials to pull images of the app.
+	RegistrySecret string `json:"registrySecret,omitempty"`
+}
+
+// DeployRequest describes a deployment of an image, like "ketch app deploy" does it.
+type DeployRequest struct {
+	App   string `json:"app"`
+	Image string `json:"image"`
+	// Namespace of the app, it's required to create the app with the deployment.
+	Namespace string `json:"namespace,omitempty"`
+	// Env are env variables of the app in NAME=VALUE format, they are merged with existing variables.
+	Env []string `json:"env,omitempty"`
+	// Steps of a canary deployment, every step is taken after StepInterval, e.g. "5m".
+	Steps        int    `json:"steps,omitempty"`
+	StepInterval string `json:"stepInterval,omitempty"`
+	// Wait blocks until the deployment is rolled out or Timeout passes, Timeout defaults to "20s".
+	Wait    bool   `json:"wait,omitempty"`
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// SetEnvRequest sets env variables of an app, like "ketch env set" does.
+type SetEnvRequest struct {
+	App string            `json:"app"`
+	Env map[string]string `json:"env"`
+	// Sensitive marks the variables as sensitive, ketch masks their values in its output.
+	Sensitive bool `json:"sensitive,omitempty"`
+}
+
+// ScaleRequest sets the number of units of a process, like "ketch unit set" does.
+type ScaleRequest struct {
+	App     string `json:"app"`
+	Process string `json:"process"`
+	// DeploymentVersion of the process, the latest deployment if it's 0.
+	DeploymentVersion int `json:"deploymentVersion,omitempty"`
+	Units             int `json:"units"`
+}
+
+// New returns a client of the cluster of the config.
+func New(config *rest.Config) (*Client, error) {
+	c, err := client.New(config, client.Options{Scheme: scheme})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+	kubeClient, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+	return NewWithClients(c, kubeClient), nil
+}
+
+// NewWithClients returns a client using the given clients, the controller-runtime client must know ketch types.
+func NewWithClients(c client.Client, kubeClient kubernetes.Interface) *Client {
+	return &Client{
+		client:         c,
+		kubeClient:     kubeClient,
+		templates:      templates.NewStorage(c, controllers.KetchNamespace),
+		getImageConfig: deploy.GetImageConfig,
+	}
+}
+
+// CreateApp creates an app without a deployment, an app with the name must not exist.
+func (c *Client) CreateApp(ctx context.Context, req CreateAppRequest) error {
+	if !validation.ValidateName(req.Name) {
+		return fmt.Errorf("%w: app name %q is not valid. name must start with a letter follow by up to 39 lower case numbers letters and dashes", ErrInvalidRequest, req.Name)
+	}
+	if req.Namespace == "" {
+		return fmt.Errorf("%w: namespace of app %q is required", ErrInvalidRequest, req.Name)
+	}
+	app := ketchv1.App{
+		ObjectMeta: metav1.ObjectMeta{Name: req.Name},
+		Spec: ketchv1.AppSpec{
+			Namespace:      req.Namespace,
+			Description:    req.Description,
+			Team:           req.Team,
+			Owner:          req.Owner,
+			DockerRegistry: ketchv1.DockerRegistrySpec{SecretName: req.RegistrySecret},
+			Deployments:    []ketchv1.AppDeploymentSpec{},
+			Ingress:        ketchv1.IngressSpec{GenerateDefaultCname: true},
+		},
+	}
+	if err := c.client.Create(ctx, &app); err != nil {
+		if apierrors.IsAlreadyExists(err) {
+			return fmt.Errorf("app %q already exists: %w", req.Name, err)
+		}
+		return fmt.Errorf("failed to create app: %w", err)
+	}
+	return nil
+}
+
+// DeployApp deploys the image, the app is created if it doesn't exist. Progress of the deployment is written to out.
+func (c *Client) DeployApp(ctx context.Context, req DeployRequest, out io.Writer) error {
+	if req.Image == "" {
+		return fmt.Errorf("%w: image of app %q is required", ErrInvalidRequest, req.App)
+	}
+	options := deploy.Options{AppName: req.App, Image: req.Image, Timeout: "20s"}
+	set := []string{deploy.FlagImage}
+	if req.Namespace != "" {
+		options.Namespace = req.Namespace
+		set = append(set, deploy.FlagNamespace)
+	}
+	if len(req.Env) > 0 {
+		options.Envs = req.Env
+		set = append(set, deploy.FlagEnvironment)
+	}
+	if req.Steps > 0 {
+		options.Steps = req.Steps
+		options.StepTimeInterval = req.StepInterval
+		set = append(set, deploy.FlagSteps, deploy.FlagStepInterval)
+	}
+	if req.Wait {
+		options.Wait = true
+		set = append(set, deploy.FlagWait)
+	}
+	if req.Timeout != "" {
+		options.Timeout = req.Timeout
+		set = append(set, deploy.FlagTimeout)
+	}
+	if out == nil {
+		out = io.Discard
+	}
+	svc := &deploy.Services{
+		Client:         c.client,
+		KubeClient:     c.kubeClient,
+		GetImageConfig: c.getImageConfig,
+		Wait:           deploy.WaitForDeployment,
+		Templates:      c.templates,
+		Writer:         out,
+	}
+	return deploy.New(options.GetChangeSetOf(set...)).Run(ctx, svc)
+}
+
+// SetEnv sets env variables of the app, a variable keeps being sensitive when its value changes.
+func (c *Client) SetEnv(ctx context.Context, req SetEnvRequest) error {
+	if len(req.Env) == 0 {
+		return fmt.Errorf("%w: env variables of app %q are required", ErrInvalidRequest, req.App)
+	}
+	envs := make([]ketchv1.Env, 0, len(req.Env))
+	for name, value := range req.Env {
+		if msgs := k8svalidation.IsEnvVarName(name); len(msgs) > 0 {
+			return fmt.Errorf("%w: invalid env variable name %q: %s", ErrInvalidRequest, name, strings.Join(msgs, ", "))
+		}
+		envs = append(envs, ketchv1.Env{Name: name, Value: value})
+	}
+	sort.Slice(envs, func(i, j int) bool {
+		return envs[i].Name < envs[j].Name
+	})
+	return c.updateApp(ctx, req.App, func(app *ketchv1.App) error {
+		for i := range envs {
+			envs[i].Sensitive = req.Sensitive || app.IsSensitiveEnv(envs[i].Name)
+		}
+		app.SetEnvs(envs)
+		return nil
+	})
+}
+
+// Scale sets the number of units of the process.
+func (c *Client) Scale(ctx context.Context, req ScaleRequest) error {
+	if req.Units < 0 {
+		return fmt.Errorf("%w: invalid number of units %d", ErrInvalidRequest, req.Units)
+	}
+	return c.updateApp(ctx, req.App, func(app *ketchv1.App) error {
+		if err := app.SetUnits(ketchv1.NewSelector(req.DeploymentVersion, req.Process), req.Units); err != nil {
+			return fmt.Errorf("%w: failed to set units: %v", ErrInvalidRequest, err)
+		}
+		return nil
+	})
+}
+
+// updateApp changes the app with update, retrying on conflicts with other updates of the app.
+func (c *Client) updateApp(ctx context.Context, name string, update func(app *ketchv1.App) error) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		app := ketchv1.App{}
+		if err := c.client.Get(ctx, types.NamespacedName{Name: name}, &app); err != nil {
+			return fmt.Errorf("failed to get app: %w", err)
+		}
+		if err := update(&app); err != nil {
+			return err
+		}
+		if err := c.client.Update(ctx, &app); err != nil {
+			return fmt.Errorf("failed to update app: %w", err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,145 @@
+package ketchclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	registryv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/deploy"
+	"github.com/theketchio/ketch/internal/mocks"
+	"github.com/theketchio/ketch/internal/utils/conversions"
+)
+
+func getImageConfig(ctx context.Context, args deploy.ImageConfigRequest) (*registryv1.ConfigFile, error) {
+	return &registryv1.ConfigFile{
+		Config: registryv1.Config{
+			Cmd: []string{"/bin/dashboard"},
+		},
+	}, nil
+}
+
+func testClient(objects ...runtime.Object) *Client {
+	cfg := &mocks.Configuration{CtrlClientObjects: objects}
+	c := NewWithClients(cfg.Client(), cfg.KubernetesClient())
+	c.getImageConfig = getImageConfig
+	return c
+}
+
+func testApp() *ketchv1.App {
+	return &ketchv1.App{
+		ObjectMeta: metav1.ObjectMeta{Name: "dashboard"},
+		Spec: ketchv1.AppSpec{
+			Namespace: "apps",
+			Env:       []ketchv1.Env{{Name: "TOKEN", Value: "abc", Sensitive: true}},
+			Deployments: []ketchv1.AppDeploymentSpec{
+				{
+					Version: 1,
+					Image:   "shipa/dashboard:0.1",
+					Processes: []ketchv1.ProcessSpec{
+						{Name: "web", Units: conversions.IntPtr(1)},
+						{Name: "worker", Units: conversions.IntPtr(1)},
+					},
+				},
+			},
+		},
+	}
+}
+
+func getApp(t *testing.T, c *Client, name string) ketchv1.App {
+	app := ketchv1.App{}
+	require.Nil(t, c.client.Get(context.Background(), types.NamespacedName{Name: name}, &app))
+	return app
+}
+
+func TestClient_CreateApp(t *testing.T) {
+	c := testClient(testApp())
+	err := c.CreateApp(context.Background(), CreateAppRequest{Name: "api", Namespace: "apps", Team: "payments", RegistrySecret: "registry"})
+	require.Nil(t, err)
+	app := getApp(t, c, "api")
+	require.Equal(t, "apps", app.Spec.Namespace)
+	require.Equal(t, "payments", app.Spec.Team)
+	require.Equal(t, "registry", app.Spec.DockerRegistry.SecretName)
+	require.True(t, app.Spec.Ingress.GenerateDefaultCname)
+	require.Len(t, app.Spec.Deployments, 0)
+
+	err = c.CreateApp(context.Background(), CreateAppRequest{Name: "dashboard", Namespace: "apps"})
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), `app "dashboard" already exists`)
+
+	err = c.CreateApp(context.Background(), CreateAppRequest{Name: "Dashboard", Namespace: "apps"})
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), `app name "Dashboard" is not valid`)
+
+	err = c.CreateApp(context.Background(), CreateAppRequest{Name: "worker"})
+	require.EqualError(t, err, `invalid request: namespace of app "worker" is required`)
+}
+
+func TestClient_DeployApp(t *testing.T) {
+	c := testClient(testApp())
+	out := &bytes.Buffer{}
+	err := c.DeployApp(context.Background(), DeployRequest{App: "dashboard", Image: "shipa/dashboard:0.2", Env: []string{"DEBUG=true"}}, out)
+	require.Nil(t, err)
+	app := getApp(t, c, "dashboard")
+	require.Len(t, app.Spec.Deployments, 1)
+	require.Equal(t, "shipa/dashboard:0.2", app.Spec.Deployments[0].Image)
+	require.Equal(t, "true", app.Spec.Env[1].Value)
+
+	// a deploy creates a missing app.
+	err = c.DeployApp(context.Background(), DeployRequest{App: "api", Image: "shipa/api:0.1", Namespace: "apps"}, nil)
+	require.Nil(t, err)
+	app = getApp(t, c, "api")
+	require.Equal(t, "apps", app.Spec.Namespace)
+	require.Equal(t, "shipa/api:0.1", app.Spec.Deployments[0].Image)
+
+	err = c.DeployApp(context.Background(), DeployRequest{App: "dashboard"}, nil)
+	require.True(t, errors.Is(err, ErrInvalidRequest))
+	require.EqualError(t, err, `invalid request: image of app "dashboard" is required`)
+
+	err = c.DeployApp(context.Background(), DeployRequest{App: "dashboard", Image: "shipa/dashboard:0.3", Steps: 4}, nil)
+	require.NotNil(t, err)
+}
+
+func TestClient_SetEnv(t *testing.T) {
+	c := testClient(testApp())
+	err := c.SetEnv(context.Background(), SetEnvRequest{App: "dashboard", Env: map[string]string{"TOKEN": "def", "URL": "https://example.com/?a=b"}})
+	require.Nil(t, err)
+	app := getApp(t, c, "dashboard")
+	require.Equal(t, []ketchv1.Env{
+		{Name: "TOKEN", Value: "def", Sensitive: true},
+		{Name: "URL", Value: "https://example.com/?a=b"},
+	}, app.Spec.Env)
+
+	err = c.SetEnv(context.Background(), SetEnvRequest{App: "dashboard", Env: map[string]string{"1TOKEN": "def"}})
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), `invalid env variable name "1TOKEN"`)
+
+	err = c.SetEnv(context.Background(), SetEnvRequest{App: "dashboard"})
+	require.EqualError(t, err, `invalid request: env variables of app "dashboard" are required`)
+
+	err = c.SetEnv(context.Background(), SetEnvRequest{App: "unknown", Env: map[string]string{"A": "b"}})
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "failed to get app")
+}
+
+func TestClient_Scale(t *testing.T) {
+	c := testClient(testApp())
+	require.Nil(t, c.Scale(context.Background(), ScaleRequest{App: "dashboard", Process: "worker", Units: 3}))
+	app := getApp(t, c, "dashboard")
+	require.Equal(t, 1, *app.Spec.Deployments[0].Processes[0].Units)
+	require.Equal(t, 3, *app.Spec.Deployments[0].Processes[1].Units)
+
+	err := c.Scale(context.Background(), ScaleRequest{App: "dashboard", Process: "worker", Units: -1})
+	require.EqualError(t, err, "invalid request: invalid number of units -1")
+
+	err = c.Scale(context.Background(), ScaleRequest{App: "dashboard", Process: "db", Units: 1})
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "failed to set units")
+}
@@ -0,0 +1,189 @@
+package ketchclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+	"github.com/theketchio/ketch/internal/validation"
+)
+
+var (
+	ErrClusterIssuerRequired   = errors.New("secure cnames require app.Ingress.Controller.ClusterIssuer to be set")
+	ErrTLSSecretNotFound       = errors.New("tls secret not found in the app namespace")
+	ErrIngressEndpointNotFound = errors.New("ingress controller's service endpoint is unknown, DNS can't be validated")
+)
+
+// dnsValidationTimeout limits the time the DNS validation of a cname spends on DNS lookups.
+const dnsValidationTimeout = 10 * time.Second
+
+// SetCnameRequest adds a cname to an app or changes an existing one, like "ketch cname add" does.
+type SetCnameRequest struct {
+	App string `json:"app"`
+	// Cname is a hostname optionally followed by a path prefix, e.g. "example.com/api".
+	Cname string `json:"cname"`
+	// Secure serves a new cname over https with a certificate of the cluster issuer.
+	Secure bool `json:"secure,omitempty"`
+	// Primary makes the cname the canonical address of the app.
+	Primary bool `json:"primary,omitempty"`
+	// TLSSecret is a name of a kubernetes.io/tls secret in the app's namespace, it implies Secure.
+	TLSSecret string `json:"tlsSecret,omitempty"`
+	// ValidateDNS checks that the DNS record of a new cname points to the ingress controller.
+	ValidateDNS bool `json:"validateDNS,omitempty"`
+	// Resolver looks up DNS records to validate, net.DefaultResolver if it's nil.
+	Resolver validation.Resolver `json:"-"`
+
+	// DNSTarget, DNSTTL and DNSProviderHints override external-dns settings of the cluster for the cname.
+	DNSTarget        string            `json:"dnsTarget,omitempty"`
+	DNSTTL           int64             `json:"dnsTTL,omitempty"`
+	DNSProviderHints map[string]string `json:"dnsProviderHints,omitempty"`
+}
+
+// RemoveCnameRequest removes a cname of an app, like "ketch cname remove" does.
+type RemoveCnameRequest struct {
+	App   string `json:"app"`
+	Cname string `json:"cname"`
+}
+
+// dns returns the cname's external-dns overrides, nil if none is set.
+func (r SetCnameRequest) dns() *ketchv1.CnameDNS {
+	if r.DNSTarget == "" && r.DNSTTL == 0 && len(r.DNSProviderHints) == 0 {
+		return nil
+	}
+	return &ketchv1.CnameDNS{Target: r.DNSTarget, TTL: r.DNSTTL, ProviderHints: r.DNSProviderHints}
+}
+
+// SetCname adds the cname to the app. A certificate, external-dns settings or the primary flag of an existing cname are changed.
+func (c *Client) SetCname(ctx context.Context, req SetCnameRequest) error {
+	cname, err := parseCname(req.Cname)
+	if err != nil {
+		return err
+	}
+	app := ketchv1.App{}
+	if err := c.client.Get(ctx, types.NamespacedName{Name: req.App}, &app); err != nil {
+		return fmt.Errorf("failed to get the app: %w", err)
+	}
+	if req.DNSTTL < 0 {
+		return fmt.Errorf("dns ttl must be greater than or equal to 0")
+	}
+	if len(req.TLSSecret) > 0 {
+		if err := c.checkTLSSecret(ctx, app.Spec.Namespace, req.TLSSecret); err != nil {
+			return err
+		}
+	}
+	existing := app.Spec.Ingress.Cnames.Find(cname.Address())
+	switch {
+	case existing == nil:
+		cname.Secure = req.Secure || len(req.TLSSecret) > 0
+		cname.SecretName = req.TLSSecret
+		cname.DNS = req.dns()
+		served := cname
+		served.Secure = served.Secure || app.Spec.Ingress.HTTPSForced()
+		if served.NeedsClusterIssuer() && app.Spec.Ingress.Controller.IssuerRef() == nil {
+			return ErrClusterIssuerRequired
+		}
+		if req.ValidateDNS {
+			if err := c.validateCnameDNS(ctx, app, cname.Name, req.Resolver); err != nil {
+				return err
+			}
+		}
+		app.Spec.Ingress.Cnames = append(app.Spec.Ingress.Cnames, cname)
+	case len(req.TLSSecret) > 0 || req.dns() != nil:
+		if len(req.TLSSecret) > 0 {
+			existing.Secure = true
+			existing.SecretName = req.TLSSecret
+		}
+		if dns := req.dns(); dns != nil {
+			existing.DNS = dns
+		}
+	case !req.Primary:
+		return nil
+	}
+	if req.Primary {
+		app.Spec.Ingress.Cnames.SetPrimary(cname.Address())
+	}
+	if err := c.client.Update(ctx, &app); err != nil {
+		return fmt.Errorf("failed to update the app: %w", err)
+	}
+	return nil
+}
+
+// RemoveCname removes the cname from the app, removing a cname the app doesn't have changes nothing.
+func (c *Client) RemoveCname(ctx context.Context, req RemoveCnameRequest) error {
+	app := ketchv1.App{}
+	if err := c.client.Get(ctx, types.NamespacedName{Name: req.App}, &app); err != nil {
+		return fmt.Errorf("failed to get the app: %w", err)
+	}
+	cnames := make(ketchv1.CnameList, 0, len(app.Spec.Ingress.Cnames))
+	for _, cname := range app.Spec.Ingress.Cnames {
+		if cname.Address() == strings.TrimRight(req.Cname, "/") {
+			continue
+		}
+		cnames = append(cnames, cname)
+	}
+	app.Spec.Ingress.Cnames = cnames
+	if err := c.client.Update(ctx, &app); err != nil {
+		return fmt.Errorf("failed to update the app: %w", err)
+	}
+	return nil
+}
+
+// checkTLSSecret makes sure the secret exists and contains a certificate.
+func (c *Client) checkTLSSecret(ctx context.Context, namespace, name string) error {
+	var secret corev1.Secret
+	if err := c.client.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, &secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return fmt.Errorf("%w: %s", ErrTLSSecretNotFound, name)
+		}
+		return fmt.Errorf("failed to get the tls secret: %w", err)
+	}
+	if len(secret.Data[corev1.TLSCertKey]) == 0 || len(secret.Data[corev1.TLSPrivateKeyKey]) == 0 {
+		return fmt.Errorf("secret %s must contain %s and %s", name, corev1.TLSCertKey, corev1.TLSPrivateKeyKey)
+	}
+	return nil
+}
+
+func (c *Client) validateCnameDNS(ctx context.Context, app ketchv1.App, hostname string, resolver validation.Resolver) error {
+	endpoint := app.Spec.Ingress.Controller.ServiceEndpoint
+	if endpoint == "" {
+		spec, err := ketchv1.GetIngressControllerSpec(ctx, c.client)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrIngressEndpointNotFound, err)
+		}
+		endpoint = spec.ServiceEndpoint
+	}
+	if endpoint == "" {
+		return ErrIngressEndpointNotFound
+	}
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	ctx, cancel := context.WithTimeout(ctx, dnsValidationTimeout)
+	defer cancel()
+	return validation.ValidateCnameDNS(ctx, resolver, hostname, endpoint)
+}
+
+// parseCname splits an address like "example.com/api" into a cname's hostname and path prefix and validates them.
+func parseCname(address string) (ketchv1.Cname, error) {
+	name, path := address, ""
+	if i := strings.Index(address, "/"); i >= 0 {
+		name, path = address[:i], strings.TrimRight(address[i:], "/")
+	}
+	if err := validation.ValidateCname(name); err != nil {
+		return ketchv1.Cname{}, err
+	}
+	if path != "" {
+		if err := validation.ValidateCnamePath(path); err != nil {
+			return ketchv1.Cname{}, err
+		}
+	}
+	return ketchv1.Cname{Name: name, Path: path}, nil
+}
@@ -0,0 +1,62 @@
+package ketchclient
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+)
+
+func TestClient_SetCname(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "example-tls", Namespace: "apps"},
+		Type:       corev1.SecretTypeTLS,
+		Data:       map[string][]byte{corev1.TLSCertKey: []byte("cert"), corev1.TLSPrivateKeyKey: []byte("key")},
+	}
+	c := testClient(testApp(), secret)
+
+	err := c.SetCname(context.Background(), SetCnameRequest{App: "dashboard", Cname: "example.com/api/", Primary: true, DNSTTL: 60})
+	require.Nil(t, err)
+	app := getApp(t, c, "dashboard")
+	require.Equal(t, ketchv1.CnameList{{Name: "example.com", Path: "/api", Primary: true, DNS: &ketchv1.CnameDNS{TTL: 60}}}, app.Spec.Ingress.Cnames)
+
+	err = c.SetCname(context.Background(), SetCnameRequest{App: "dashboard", Cname: "example.com/api", TLSSecret: "example-tls"})
+	require.Nil(t, err)
+	app = getApp(t, c, "dashboard")
+	require.Equal(t, ketchv1.CnameList{{Name: "example.com", Path: "/api", Primary: true, Secure: true, SecretName: "example-tls", DNS: &ketchv1.CnameDNS{TTL: 60}}}, app.Spec.Ingress.Cnames)
+
+	err = c.SetCname(context.Background(), SetCnameRequest{App: "dashboard", Cname: "secure.example.com", Secure: true})
+	require.True(t, errors.Is(err, ErrClusterIssuerRequired))
+
+	err = c.SetCname(context.Background(), SetCnameRequest{App: "dashboard", Cname: "tls.example.com", TLSSecret: "missing"})
+	require.True(t, errors.Is(err, ErrTLSSecretNotFound))
+
+	err = c.SetCname(context.Background(), SetCnameRequest{App: "dashboard", Cname: "dns.example.com", ValidateDNS: true})
+	require.True(t, errors.Is(err, ErrIngressEndpointNotFound))
+
+	err = c.SetCname(context.Background(), SetCnameRequest{App: "dashboard", Cname: "example.com", DNSTTL: -1})
+	require.EqualError(t, err, "dns ttl must be greater than or equal to 0")
+
+	err = c.SetCname(context.Background(), SetCnameRequest{App: "api", Cname: "example.com"})
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "failed to get the app")
+}
+
+func TestClient_RemoveCname(t *testing.T) {
+	app := testApp()
+	app.Spec.Ingress.Cnames = ketchv1.CnameList{{Name: "example.com", Path: "/api"}, {Name: "example.com"}}
+	c := testClient(app)
+
+	err := c.RemoveCname(context.Background(), RemoveCnameRequest{App: "dashboard", Cname: "example.com/api/"})
+	require.Nil(t, err)
+	require.Equal(t, ketchv1.CnameList{{Name: "example.com"}}, getApp(t, c, "dashboard").Spec.Ingress.Cnames)
+
+	err = c.RemoveCname(context.Background(), RemoveCnameRequest{App: "dashboard", Cname: "other.example.com"})
+	require.Nil(t, err)
+	require.Equal(t, ketchv1.CnameList{{Name: "example.com"}}, getApp(t, c, "dashboard").Spec.Ingress.Cnames)
+}
@@ -0,0 +1,74 @@
+package ketchclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+)
+
+// ErrIngressSettingsRequired is returned for ingress settings without a class name, a service endpoint or an ingress type.
+var ErrIngressSettingsRequired = errors.New("ingress-class-name, ingress-service-endpoint, and ingress-type are required")
+
+// ValidateIngress checks settings of the ketch-ingress configmap, like "ketch ingress set" and "ketch apply" write them.
+func ValidateIngress(data map[string]string) error {
+	for _, key := range []string{"className", "serviceEndpoint", "ingressType"} {
+		if data[key] == "" {
+			return ErrIngressSettingsRequired
+		}
+	}
+	if profile := data["podSecurityProfile"]; profile != "" {
+		if _, err := ketchv1.ParsePodSecurityProfile(profile); err != nil {
+			return err
+		}
+	}
+	if appDefaults := data[ketchv1.AppDefaultsKey]; appDefaults != "" {
+		if _, err := ketchv1.ParseAppDefaults(appDefaults); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetIngress returns settings of the cluster's ingress controller, an error satisfying apierrors.IsNotFound if ingress isn't set.
+func (c *Client) GetIngress(ctx context.Context) (map[string]string, error) {
+	configmap := corev1.ConfigMap{}
+	if err := c.client.Get(ctx, types.NamespacedName{Name: ketchv1.IngressConfigmapName, Namespace: ketchv1.IngressConfigmapNamespace}, &configmap); err != nil {
+		return nil, fmt.Errorf("failed to get ingress: %w", err)
+	}
+	return configmap.Data, nil
+}
+
+// SetIngress validates and replaces settings of the cluster's ingress controller, creating the ketch-ingress configmap if it doesn't exist.
+func (c *Client) SetIngress(ctx context.Context, data map[string]string) error {
+	if err := ValidateIngress(data); err != nil {
+		return err
+	}
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		configmap := corev1.ConfigMap{}
+		err := c.client.Get(ctx, types.NamespacedName{Name: ketchv1.IngressConfigmapName, Namespace: ketchv1.IngressConfigmapNamespace}, &configmap)
+		if apierrors.IsNotFound(err) {
+			configmap.Name = ketchv1.IngressConfigmapName
+			configmap.Namespace = ketchv1.IngressConfigmapNamespace
+			configmap.Data = data
+			if err := c.client.Create(ctx, &configmap); err != nil {
+				return fmt.Errorf("failed to create ingress: %w", err)
+			}
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get ingress: %w", err)
+		}
+		configmap.Data = data
+		if err := c.client.Update(ctx, &configmap); err != nil {
+			return fmt.Errorf("failed to set ingress: %w", err)
+		}
+		return nil
+	})
+}
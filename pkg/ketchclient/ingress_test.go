@@ -0,0 +1,77 @@
+package ketchclient
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ketchv1 "github.com/theketchio/ketch/internal/api/v1beta1"
+)
+
+func TestValidateIngress(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			data: map[string]string{"className": "nginx", "serviceEndpoint": "10.10.10.10", "ingressType": "nginx"},
+		},
+		{
+			name:    "no service endpoint",
+			data:    map[string]string{"className": "nginx", "ingressType": "nginx"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid pod security profile",
+			data:    map[string]string{"className": "nginx", "serviceEndpoint": "10.10.10.10", "ingressType": "nginx", "podSecurityProfile": "privileged"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateIngress(tt.data)
+			require.Equal(t, tt.wantErr, err != nil, err)
+		})
+	}
+}
+
+func TestClient_SetIngress(t *testing.T) {
+	c := testClient()
+	_, err := c.GetIngress(context.Background())
+	require.True(t, apierrors.IsNotFound(err))
+
+	data := map[string]string{"className": "nginx", "serviceEndpoint": "10.10.10.10", "ingressType": "nginx"}
+	require.Nil(t, c.SetIngress(context.Background(), data))
+	got, err := c.GetIngress(context.Background())
+	require.Nil(t, err)
+	require.Equal(t, data, got)
+
+	data = map[string]string{"className": "istio", "serviceEndpoint": "10.10.10.20", "ingressType": "istio"}
+	require.Nil(t, c.SetIngress(context.Background(), data))
+	got, err = c.GetIngress(context.Background())
+	require.Nil(t, err)
+	require.Equal(t, data, got)
+
+	err = c.SetIngress(context.Background(), map[string]string{"className": "nginx"})
+	require.True(t, errors.Is(err, ErrIngressSettingsRequired))
+}
+
+func TestClient_SetIngressUpdatesExistingConfigmap(t *testing.T) {
+	configmap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: ketchv1.IngressConfigmapName, Namespace: ketchv1.IngressConfigmapNamespace},
+		Data:       map[string]string{"className": "nginx", "serviceEndpoint": "10.10.10.10", "ingressType": "nginx", "clusterIssuer": "letsencrypt"},
+	}
+	c := testClient(configmap)
+	data := map[string]string{"className": "nginx", "serviceEndpoint": "10.10.10.10", "ingressType": "nginx"}
+	require.Nil(t, c.SetIngress(context.Background(), data))
+	got, err := c.GetIngress(context.Background())
+	require.Nil(t, err)
+	require.Equal(t, data, got)
+}